@@ -0,0 +1,336 @@
+package camera
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pantheon/artemis/persist"
+)
+
+// MinACLTokenLength is the shortest token an ACL entry may use, so a
+// guessable token can't stand in for the admin token's protection.
+const MinACLTokenLength = 16
+
+var (
+	// ErrACLNotFound means there is no ACL entry with the given name.
+	ErrACLNotFound = errors.New("camera ACL not found")
+
+	// ErrACLTokenTaken means another ACL entry already uses the token.
+	ErrACLTokenTaken = errors.New("token is already used by another camera ACL")
+
+	// ErrACLInvalid wraps an ACL entry's validation error.
+	ErrACLInvalid = errors.New("invalid camera ACL")
+)
+
+// ACLEntry limits one API token to a set of cameras, e.g. a guest tablet
+// that may see the front door but not the bedroom.
+type ACLEntry struct {
+	Name      string    `json:"name"`            // Label, e.g. "kids-ipad"
+	Token     string    `json:"token,omitempty"` // Bearer token; never listed back
+	Cameras   []string  `json:"cameras"`         // Allowed name-URIs
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ACLStore holds the camera ACLs and saves them to a JSON file on every
+// change, so they survive restarts. Names match case-insensitively. Safe for
+// concurrent use.
+type ACLStore struct {
+	path string // "" keeps ACLs in memory only
+
+	mu      sync.RWMutex
+	entries []ACLEntry // Sorted by name
+}
+
+// NewACLStore loads the ACLs saved at path, if any. A missing file is an
+// empty store; an unreadable one is an error, since silently dropping the
+// ACLs would either lock everyone out or, worse, be saved over.
+func NewACLStore(path string) (*ACLStore, error) {
+	s := &ACLStore{path: path}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// List returns every ACL entry, sorted by name, without its token.
+func (s *ACLStore) List() []ACLEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]ACLEntry, len(s.entries))
+	for i, entry := range s.entries {
+		entry.Token = ""
+		entry.Cameras = slices.Clone(entry.Cameras)
+		entries[i] = entry
+	}
+	return entries
+}
+
+// Put creates or replaces the ACL entry called name and reports whether it
+// was created. Replacing an entry without a token keeps its current token;
+// a new entry needs one. Returns ErrACLTokenTaken if another entry uses the
+// token. The returned entry has no token.
+func (s *ACLStore) Put(name string, entry ACLEntry) (ACLEntry, bool, error) {
+	entry.Name = strings.TrimSpace(name)
+	if entry.Name == "" {
+		return ACLEntry{}, false, fmt.Errorf("%w: name is required", ErrACLInvalid)
+	}
+	cameras := make([]string, 0, len(entry.Cameras))
+	for _, nameURI := range entry.Cameras {
+		if nameURI = strings.TrimSpace(nameURI); nameURI != "" && !slices.Contains(cameras, nameURI) {
+			cameras = append(cameras, nameURI)
+		}
+	}
+	entry.Cameras = cameras
+	entry.UpdatedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.entries
+	entries := slices.Clone(previous)
+	i := aclIndex(entries, entry.Name)
+	created := i == -1
+	if entry.Token == "" && !created {
+		entry.Token = entries[i].Token
+	}
+	if len(entry.Token) < MinACLTokenLength {
+		return ACLEntry{}, false, fmt.Errorf("%w: token must be at least %d characters", ErrACLInvalid, MinACLTokenLength)
+	}
+	for j, other := range entries {
+		if j != i && tokensEqual(other.Token, entry.Token) {
+			return ACLEntry{}, false, ErrACLTokenTaken
+		}
+	}
+
+	if created {
+		entries = append(entries, entry)
+		slices.SortFunc(entries, func(a, b ACLEntry) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		})
+	} else {
+		entries[i] = entry
+	}
+
+	s.entries = entries
+	if err := s.save(); err != nil {
+		s.entries = previous
+		return ACLEntry{}, false, err
+	}
+	entry.Token = ""
+	return entry, created, nil
+}
+
+// Delete removes an ACL entry; its token stops working at once. Returns
+// ErrACLNotFound if there is none.
+func (s *ACLStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.entries
+	i := aclIndex(previous, name)
+	if i == -1 {
+		return ErrACLNotFound
+	}
+	s.entries = slices.Delete(slices.Clone(previous), i, i+1)
+	if err := s.save(); err != nil {
+		s.entries = previous
+		return err
+	}
+	return nil
+}
+
+// Snapshot returns every ACL entry, sorted by name, with its token, for a
+// backup.
+func (s *ACLStore) Snapshot() []ACLEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]ACLEntry, len(s.entries))
+	for i, entry := range s.entries {
+		entry.Cameras = slices.Clone(entry.Cameras)
+		entries[i] = entry
+	}
+	return entries
+}
+
+// ValidateACLs checks a backup's ACL entries: each has a name and a token
+// of at least MinACLTokenLength, and no two share a name or a token.
+func ValidateACLs(entries []ACLEntry) error {
+	for i, entry := range entries {
+		if strings.TrimSpace(entry.Name) == "" {
+			return fmt.Errorf("cameraAcls[%d]: name is required", i)
+		}
+		if len(entry.Token) < MinACLTokenLength {
+			return fmt.Errorf("cameraAcls[%d]: token must be at least %d characters", i, MinACLTokenLength)
+		}
+		for _, other := range entries[:i] {
+			if strings.EqualFold(strings.TrimSpace(other.Name), strings.TrimSpace(entry.Name)) {
+				return fmt.Errorf("cameraAcls[%d]: duplicate name %q", i, entry.Name)
+			}
+			if tokensEqual(other.Token, entry.Token) {
+				return fmt.Errorf("cameraAcls[%d]: token is used by %q too", i, other.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Restore writes a backup's ACL entries, tokens included. With replace,
+// they become the only entries; otherwise each one is added, or replaces
+// the entry of the same name. Returns ErrACLTokenTaken if a restored token
+// is used by an entry the restore keeps.
+func (s *ACLStore) Restore(restored []ACLEntry, replace bool) error {
+	if err := ValidateACLs(restored); err != nil {
+		return fmt.Errorf("%w: %v", ErrACLInvalid, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.entries
+	var entries []ACLEntry
+	if !replace {
+		entries = slices.Clone(previous)
+	}
+	for _, entry := range restored {
+		entry.Name = strings.TrimSpace(entry.Name)
+		entry.Cameras = slices.Clone(entry.Cameras)
+		if i := aclIndex(entries, entry.Name); i != -1 {
+			entries = slices.Delete(entries, i, i+1)
+		}
+		for _, other := range entries {
+			if tokensEqual(other.Token, entry.Token) {
+				return ErrACLTokenTaken
+			}
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b ACLEntry) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	s.entries = entries
+	if err := s.save(); err != nil {
+		s.entries = previous
+		return err
+	}
+	return nil
+}
+
+// Lookup returns the ACL entry whose token is token.
+func (s *ACLStore) Lookup(token string) (ACLEntry, bool) {
+	if token == "" {
+		return ACLEntry{}, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, entry := range s.entries {
+		if tokensEqual(entry.Token, token) {
+			entry.Cameras = slices.Clone(entry.Cameras)
+			return entry, true
+		}
+	}
+	return ACLEntry{}, false
+}
+
+// tokensEqual compares tokens in constant time, so response timing doesn't
+// leak them.
+func tokensEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// aclIndex finds an entry by name (case-insensitive), or -1.
+func aclIndex(entries []ACLEntry, name string) int {
+	name = strings.TrimSpace(name)
+	return slices.IndexFunc(entries, func(e ACLEntry) bool {
+		return strings.EqualFold(e.Name, name)
+	})
+}
+
+// save writes every entry to the store's file via a temp file and rename,
+// so a crash mid-write never leaves a truncated file. The file holds tokens,
+// so only the owner may read it. Caller holds s.mu.
+func (s *ACLStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	if err := persist.WriteJSON(s.path, s.entries, 0o600); err != nil {
+		return fmt.Errorf("failed to save camera ACLs: %w", err)
+	}
+	log.Printf("🔐 Saved %d camera ACL(s) to %s", len(s.entries), s.path)
+	return nil
+}
+
+// allowedCamerasKey is the context key for the cameras a request may see.
+type allowedCamerasKey struct{}
+
+// WithAllowedCameras limits the cameras visible through ctx to nameURIs.
+// Contexts without a limit (admin requests, or ACLs turned off) see every
+// camera.
+func WithAllowedCameras(ctx context.Context, nameURIs []string) context.Context {
+	return context.WithValue(ctx, allowedCamerasKey{}, nameURIs)
+}
+
+// Allowed reports whether ctx may see the camera nameURI.
+func Allowed(ctx context.Context, nameURI string) bool {
+	allowed, limited := ctx.Value(allowedCamerasKey{}).([]string)
+	return !limited || slices.Contains(allowed, nameURI)
+}
+
+// FilterAllowed returns the cameras ctx may see, in their original order.
+func FilterAllowed(ctx context.Context, cameras []Camera) []Camera {
+	if _, limited := ctx.Value(allowedCamerasKey{}).([]string); !limited {
+		return cameras
+	}
+	var visible []Camera
+	for _, cam := range cameras {
+		if Allowed(ctx, cam.NameURI) {
+			visible = append(visible, cam)
+		}
+	}
+	return visible
+}
+
+// ClipAllowed reports whether ctx may download the clip file, which is
+// named after its camera ("front-door-20260101-120000.mp4"). The timestamp
+// is matched too, so "front" doesn't grant "front-2"'s clips.
+func ClipAllowed(ctx context.Context, file string) bool {
+	allowed, limited := ctx.Value(allowedCamerasKey{}).([]string)
+	if !limited {
+		return true
+	}
+	for _, nameURI := range allowed {
+		rest, ok := strings.CutPrefix(file, nameURI+"-")
+		if !ok || len(rest) < len(clipTimeLayout) {
+			continue
+		}
+		if _, err := time.Parse(clipTimeLayout, rest[:len(clipTimeLayout)]); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package camera
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+const guestToken = "guest-tablet-token-0001"
+
+func TestACLStore_PersistsAndKeepsTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "camera_acls.json")
+
+	store, err := NewACLStore(path)
+	if err != nil {
+		t.Fatalf("NewACLStore returned error: %v", err)
+	}
+	entry, created, err := store.Put("Guest", ACLEntry{Token: guestToken, Cameras: []string{"front-door", " front-door", ""}})
+	if err != nil || !created {
+		t.Fatalf("expected the entry to be created, got %v (created=%v)", err, created)
+	}
+	if entry.Token != "" || len(entry.Cameras) != 1 {
+		t.Errorf("expected a deduplicated entry without its token, got %+v", entry)
+	}
+
+	// Replacing without a token keeps the current one
+	if _, created, err := store.Put("guest", ACLEntry{Cameras: []string{"front-door", "garage"}}); err != nil || created {
+		t.Fatalf("expected the entry to be replaced, got %v (created=%v)", err, created)
+	}
+
+	reloaded, err := NewACLStore(path)
+	if err != nil {
+		t.Fatalf("NewACLStore returned error on reload: %v", err)
+	}
+	found, ok := reloaded.Lookup(guestToken)
+	if !ok || found.Name != "guest" || len(found.Cameras) != 2 {
+		t.Errorf("expected the guest token to see 2 cameras after a reload, got %+v (ok=%v)", found, ok)
+	}
+	if list := reloaded.List(); len(list) != 1 || list[0].Token != "" {
+		t.Errorf("expected one listed entry without its token, got %+v", list)
+	}
+
+	if err := reloaded.Delete("GUEST"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if _, ok := reloaded.Lookup(guestToken); ok {
+		t.Error("expected a deleted entry's token to stop working")
+	}
+}
+
+func TestACLStore_Validation(t *testing.T) {
+	store, _ := NewACLStore("")
+
+	if _, _, err := store.Put("Guest", ACLEntry{Cameras: []string{"front-door"}}); !errors.Is(err, ErrACLInvalid) {
+		t.Errorf("expected ErrACLInvalid for a new entry without a token, got %v", err)
+	}
+	if _, _, err := store.Put("Guest", ACLEntry{Token: "short"}); !errors.Is(err, ErrACLInvalid) {
+		t.Errorf("expected ErrACLInvalid for a short token, got %v", err)
+	}
+	store.Put("Guest", ACLEntry{Token: guestToken})
+	if _, _, err := store.Put("Kids", ACLEntry{Token: guestToken}); !errors.Is(err, ErrACLTokenTaken) {
+		t.Errorf("expected ErrACLTokenTaken for a reused token, got %v", err)
+	}
+	if err := store.Delete("Missing"); !errors.Is(err, ErrACLNotFound) {
+		t.Errorf("expected ErrACLNotFound, got %v", err)
+	}
+}
+
+func TestAllowedCameras(t *testing.T) {
+	cameras := []Camera{{NameURI: "front-door"}, {NameURI: "bedroom"}, {NameURI: "front-door-2"}}
+
+	unlimited := context.Background()
+	if !Allowed(unlimited, "bedroom") || len(FilterAllowed(unlimited, cameras)) != 3 || !ClipAllowed(unlimited, "bedroom-20260101-120000.mp4") {
+		t.Error("expected a context without an ACL to see every camera")
+	}
+
+	limited := WithAllowedCameras(context.Background(), []string{"front-door"})
+	if visible := FilterAllowed(limited, cameras); len(visible) != 1 || visible[0].NameURI != "front-door" {
+		t.Errorf("expected only front-door, got %+v", visible)
+	}
+	if Allowed(limited, "bedroom") {
+		t.Error("expected bedroom to be hidden")
+	}
+
+	clips := map[string]bool{
+		"front-door-20260101-120000.mp4":   true,
+		"front-door-20260101-120000-2.mp4": true,
+		"front-door-2-20260101-120000.mp4": false, // Another camera's clip
+		"bedroom-20260101-120000.mp4":      false,
+	}
+	for file, want := range clips {
+		if got := ClipAllowed(limited, file); got != want {
+			t.Errorf("ClipAllowed(%q) = %v, want %v", file, got, want)
+		}
+	}
+
+	if FilterAllowed(WithAllowedCameras(context.Background(), nil), cameras) != nil {
+		t.Error("expected an ACL without cameras to see none")
+	}
+}
@@ -0,0 +1,121 @@
+package camera
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+)
+
+// Endpoint on the Wyze Bridge web UI that reports its version and which
+// optional features are turned on. Older bridges don't have it.
+const bridgeStatusEndpoint = "/status"
+
+// BridgeStatus describes the Wyze Bridge as a whole, so the app can hide
+// controls for features the bridge doesn't offer.
+type BridgeStatus struct {
+	Version       string         `json:"version,omitempty"` // Bridge version, if it reports one
+	TotalCameras  int            `json:"totalCameras"`      // Cameras the bridge knows about
+	OnlineCameras int            `json:"onlineCameras"`     // Of those, how many are online
+	Features      BridgeFeatures `json:"features"`          // Optional features that are turned on
+
+	// Whether the bridge answered the status endpoint. False on older
+	// bridges: the camera counts are still accurate, but Features holds
+	// the defaults those versions had.
+	Reported bool `json:"reported"`
+}
+
+// BridgeFeatures lists the optional bridge features the app can offer.
+type BridgeFeatures struct {
+	WebRTC    bool `json:"webrtc"`    // Low-latency WebRTC streams
+	Recording bool `json:"recording"` // Recording streams to disk
+	Events    bool `json:"events"`    // Motion event notifications
+}
+
+// legacyBridgeFeatures is assumed for bridges without a status endpoint.
+// They always served WebRTC, and had neither recording nor motion events.
+var legacyBridgeFeatures = BridgeFeatures{WebRTC: true}
+
+// bridgeStatusBody is the status endpoint's response, optionally wrapped in
+// {"status": "success", "data": {...}} like the /api response.
+type bridgeStatusBody struct {
+	Status  string          `json:"status"`
+	Data    json.RawMessage `json:"data"`
+	Version string          `json:"version"`
+	WebRTC  bool            `json:"webrtc"`
+	Record  bool            `json:"record"`
+	Events  bool            `json:"events"`
+}
+
+// GetBridgeStatus reports the bridge's version, camera counts, and enabled
+// features. The counts come from the camera list, so they match
+// GET /api/cameras. A bridge without the status endpoint (404) isn't an
+// error: Reported is false and Features holds legacyBridgeFeatures.
+func (c *Client) GetBridgeStatus(ctx context.Context) (*BridgeStatus, error) {
+	cameras, err := c.GetCameras(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &BridgeStatus{TotalCameras: len(cameras), Features: legacyBridgeFeatures}
+	for _, cam := range cameras {
+		if cam.Status == "online" {
+			status.OnlineCameras++
+		}
+	}
+
+	body, err := c.fetchBridgeStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		log.Printf("📷 Wyze Bridge has no status endpoint — assuming legacy features")
+		return status, nil
+	}
+
+	status.Reported = true
+	status.Version = body.Version
+	status.Features = BridgeFeatures{WebRTC: body.WebRTC, Recording: body.Record, Events: body.Events}
+	return status, nil
+}
+
+// fetchBridgeStatus reads the status endpoint. Returns nil, nil if the
+// bridge doesn't have one: a 404, or a 200 without a version. A real status
+// report always has one; some bridges answer unknown paths with their web UI.
+func (c *Client) fetchBridgeStatus(ctx context.Context) (*bridgeStatusBody, error) {
+	resp, err := c.get(ctx, c.bridgeURL+bridgeStatusEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Wyze Bridge: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bridge returned status %d for its status", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bridge status: %w", err)
+	}
+
+	var body bridgeStatusBody
+	if json.Unmarshal(data, &body) != nil {
+		return nil, nil
+	}
+	if isJSONObject(body.Data) {
+		var inner bridgeStatusBody
+		if json.Unmarshal(body.Data, &inner) != nil {
+			return nil, nil
+		}
+		body = inner
+	}
+	if body.Version == "" {
+		return nil, nil
+	}
+	return &body, nil
+}
@@ -0,0 +1,75 @@
+package camera
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetBridgeStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int    // Status endpoint response code
+		statusBody string // Status endpoint response body
+		want       BridgeStatus
+		wantErr    bool
+	}{
+		{
+			name:   "legacy bridge without a status endpoint",
+			status: http.StatusNotFound,
+			want:   BridgeStatus{TotalCameras: 2, OnlineCameras: 1, Features: BridgeFeatures{WebRTC: true}},
+		},
+		{
+			name:       "flat status",
+			status:     http.StatusOK,
+			statusBody: `{"version": "2.10.3", "webrtc": false, "record": true, "events": true}`,
+			want:       BridgeStatus{Version: "2.10.3", TotalCameras: 2, OnlineCameras: 1, Features: BridgeFeatures{Recording: true, Events: true}, Reported: true},
+		},
+		{
+			name:       "wrapped status",
+			status:     http.StatusOK,
+			statusBody: `{"status": "success", "data": {"version": "2.11.0", "webrtc": true}}`,
+			want:       BridgeStatus{Version: "2.11.0", TotalCameras: 2, OnlineCameras: 1, Features: BridgeFeatures{WebRTC: true}, Reported: true},
+		},
+		{
+			name:       "bridge serving its web UI for unknown paths",
+			status:     http.StatusOK,
+			statusBody: `<!DOCTYPE html><html><body>Wyze Bridge</body></html>`,
+			want:       BridgeStatus{TotalCameras: 2, OnlineCameras: 1, Features: BridgeFeatures{WebRTC: true}},
+		},
+		{
+			name:    "failing status endpoint",
+			status:  http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == bridgeStatusEndpoint {
+					w.WriteHeader(tt.status)
+					w.Write([]byte(tt.statusBody))
+					return
+				}
+				w.Write([]byte(legacyBridgeBody))
+			}))
+			t.Cleanup(server.Close)
+
+			status, err := NewClient(server.URL, "").GetBridgeStatus(context.Background())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetBridgeStatus returned error: %v", err)
+			}
+			if *status != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, *status)
+			}
+		})
+	}
+}
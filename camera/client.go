@@ -1,13 +1,20 @@
 package camera
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/pantheon/artemis/tracing"
+	"github.com/pantheon/artemis/upstream"
 )
 
 // Default configuration for the Wyze Bridge connection.
@@ -22,26 +29,76 @@ const (
 	// Timeout for HTTP requests to the bridge.
 	requestTimeout = 10 * time.Second
 
+	// Names the bridge in classified errors (see upstream.Error).
+	serviceName = "Wyze Bridge"
+
 	// Default ports for stream URLs.
 	// These match the port mappings in docker-compose.yml.
 	hlsPort    = "8888"
 	rtspPort   = "8554"
 	webrtcPort = "8889"
+
+	// The bridge serves a camera's lower-resolution substream under its
+	// name plus this suffix (e.g., "front-door-sub") when SUBSTREAM is
+	// enabled for the camera.
+	subStreamSuffix = "-sub"
+)
+
+// Stream qualities for GET /api/cameras/stream?quality=...
+const (
+	QualityHD = "hd" // Main stream (default)
+	QualitySD = "sd" // Substream — lower resolution, for cellular
+)
+
+// Ways of sending the bridge API key (WYZE_BRIDGE_AUTH_MODE).
+const (
+	AuthModeQuery  = "query"  // ?api=<key> on every URL — works with every bridge version
+	AuthModeHeader = "header" // Authorization: Bearer <key>
+	AuthModeBasic  = "basic"  // HTTP basic auth with the key as the password
 )
 
 // Client communicates with the Docker Wyze Bridge REST API.
 // It queries the bridge for camera info and constructs stream URLs
 // that the iOS app can use to view live camera feeds.
 type Client struct {
-	bridgeURL  string       // Base URL of the Wyze Bridge web UI (e.g., "http://localhost:5050")
-	apiKey     string       // Optional API key for bridge authentication (WB_API)
-	httpClient *http.Client // HTTP client with timeout configured
+	bridgeURL  string            // Base URL of the Wyze Bridge web UI (e.g., "http://localhost:5050")
+	apiKey     string            // Optional API key for bridge authentication (WB_API)
+	authMode   string            // How apiKey is sent (AuthModeQuery, AuthModeHeader, or AuthModeBasic)
+	username   string            // Basic auth username (AuthModeBasic only)
+	httpClient *http.Client      // HTTP client with timeout configured
+	breaker    *upstream.Breaker // Optional; see SetBreaker
+
+	// Short-lived copy of the camera list used by ResolveDisplayName,
+	// so resolving names doesn't hit the bridge on every request, and the
+	// fetch in flight that will replace it. cacheGen counts invalidations;
+	// a list fetched under an older generation is never cached.
+	cacheMu       sync.Mutex
+	cachedCameras []Camera
+	cachedAt      time.Time
+	cacheGen      uint64
+	cameraFetch   *cameraFetch
+
+	// Last camera list and its validators, for conditional GetCameras
+	// requests.
+	list cameraListCache
 }
 
 // NewClient creates a new Wyze Bridge client.
 // bridgeURL is the base URL of the bridge (e.g., "http://localhost:5050").
 // apiKey is optional — only needed if WB_AUTH is enabled on the bridge.
+// The key is sent as the ?api= query parameter; see NewClientWithAuth.
 func NewClient(bridgeURL, apiKey string) *Client {
+	return NewClientWithAuth(bridgeURL, apiKey, AuthModeQuery, "")
+}
+
+// NewClientWithAuth creates a Wyze Bridge client that sends apiKey the way
+// authMode says. Bridges that accept a header or basic auth keep the key out
+// of URLs, where proxies and access logs would record it. username is only
+// used for AuthModeBasic. An unknown authMode falls back to AuthModeQuery.
+func NewClientWithAuth(bridgeURL, apiKey, authMode, username string) *Client {
+	if authMode != AuthModeHeader && authMode != AuthModeBasic {
+		authMode = AuthModeQuery
+	}
 	if bridgeURL == "" {
 		bridgeURL = defaultBridgeURL
 	}
@@ -52,39 +109,70 @@ func NewClient(bridgeURL, apiKey string) *Client {
 	return &Client{
 		bridgeURL: bridgeURL,
 		apiKey:    apiKey,
+		authMode:  authMode,
+		username:  username,
 		httpClient: &http.Client{
-			Timeout: requestTimeout,
+			Timeout:   requestTimeout,
+			Transport: tracing.NewTransport(), // client span per outbound request
 		},
 	}
 }
 
+// SetBreaker guards every request to the bridge with breaker, so an outage
+// fails fast instead of each request waiting out the timeout.
+// nil (the default) disables it.
+func (c *Client) SetBreaker(breaker *upstream.Breaker) {
+	c.breaker = breaker
+}
+
 // GetCameras queries the Wyze Bridge API for all available cameras.
 // Returns a list of Camera objects with name, model, status, and stream URLs.
 //
-// The bridge API returns a JSON object where each key is a camera name-uri:
+// The bridge API returns camera entries keyed by camera name-uri, either at
+// the top level or nested under "cameras" (see bridgeCameraMap):
 //
 //	{
 //	  "front-door": { "name_uri": "front-door", "nickname": "Front Door", ... },
 //	  "back-yard":  { "name_uri": "back-yard", "nickname": "Back Yard", ... }
 //	}
 //
-// We iterate over the keys and construct stream URLs for each camera.
-func (c *Client) GetCameras() ([]Camera, error) {
+// We iterate over the entries and construct stream URLs for each camera.
+//
+// If the bridge sent an ETag or Last-Modified with the previous list, the
+// request is conditional and a 304 Not Modified reuses that list without
+// downloading or parsing it again. Bridges that send neither get a full
+// fetch every time.
+func (c *Client) GetCameras(ctx context.Context) ([]Camera, error) {
+	return c.getCameras(ctx, true)
+}
+
+func (c *Client) getCameras(ctx context.Context, conditional bool) ([]Camera, error) {
 	log.Printf("📷 Fetching cameras from Wyze Bridge at %s...", c.bridgeURL)
 
-	// Build the request URL. Include API key if configured.
+	// Build the request URL. get adds the API key if configured.
 	reqURL := c.bridgeURL + bridgeAPIEndpoint
-	if c.apiKey != "" {
-		reqURL += "?api=" + c.apiKey
+
+	var header http.Header
+	if conditional {
+		header = c.list.conditionalHeader()
 	}
 
 	// Make the GET request to the bridge API.
-	resp, err := c.httpClient.Get(reqURL)
+	resp, err := c.getWithHeader(ctx, reqURL, header)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reach Wyze Bridge at %s: %w", c.bridgeURL, err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && header != nil {
+		if cameras, ok := c.list.cameras(); ok {
+			log.Printf("📷 Camera list unchanged (304), reusing %d camera(s)", len(cameras))
+			return cameras, nil
+		}
+		// The list was dropped since the validators were read
+		return c.getCameras(ctx, false)
+	}
+
 	// Read the response body.
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -96,33 +184,12 @@ func (c *Client) GetCameras() ([]Camera, error) {
 		return nil, fmt.Errorf("bridge returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	// The bridge API returns a JSON object with a top-level "cameras" key.
-	// Inside "cameras" is an object keyed by camera URI name, each containing
-	// camera metadata. Example:
-	//   { "available": 1, "cameras": { "pet-cam": { ... } } }
-	//
-	// We first try parsing this nested structure. If "cameras" is missing
-	// (older bridge versions), fall back to treating the entire response as
-	// the camera map for backwards compatibility.
-	var envelope struct {
-		Cameras map[string]json.RawMessage `json:"cameras"`
-	}
-	cameraMap := make(map[string]json.RawMessage)
-
-	if err := json.Unmarshal(body, &envelope); err != nil {
+	// The response shape depends on the bridge version; see bridgeCameraMap.
+	cameraMap, err := bridgeCameraMap(body)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse bridge response: %w", err)
 	}
 
-	if envelope.Cameras != nil {
-		// Modern bridge response — cameras nested under "cameras" key.
-		cameraMap = envelope.Cameras
-	} else {
-		// Fallback for older bridge versions — top-level is the camera map.
-		if err := json.Unmarshal(body, &cameraMap); err != nil {
-			return nil, fmt.Errorf("failed to parse bridge response (fallback): %w", err)
-		}
-	}
-
 	// Extract the bridge host from the URL for constructing stream URLs.
 	// Stream URLs use different ports on the same host.
 	bridgeHost := extractHost(c.bridgeURL)
@@ -134,23 +201,105 @@ func (c *Client) GetCameras() ([]Camera, error) {
 		cameras = append(cameras, camera)
 	}
 
+	c.list.store(resp.Header, cameras)
+
 	log.Printf("📷 Found %d camera(s) from Wyze Bridge", len(cameras))
 	return cameras, nil
 }
 
+// bridgeCameraMap picks the camera entries out of a bridge /api response,
+// keyed by name-uri. Bridge versions have returned three shapes:
+//
+//	legacy:  { "front-door": { ... }, "back-yard": { ... } }
+//	wrapped: { "available": 2, "enabled": 2, "cameras": { "front-door": { ... } } }
+//	status:  { "status": "success", "data": { "cameras": { ... } } }
+//
+// A status wrapper is unwrapped first (a failure status is returned as an
+// error), then "cameras" is used if present — as an object keyed by
+// name-uri or as a list of entries. Otherwise the response is the legacy
+// flat map, where only object values are cameras; counters and flags that
+// sit alongside them are skipped.
+func bridgeCameraMap(body []byte) (map[string]json.RawMessage, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(body, &top); err != nil {
+		return nil, err
+	}
+
+	// Status wrapper. A legacy camera named "status" has an object value,
+	// so it doesn't decode as a string and is left alone.
+	var status string
+	if raw, ok := top["status"]; ok && json.Unmarshal(raw, &status) == nil {
+		switch strings.ToLower(status) {
+		case "error", "fail", "failed":
+			var message string
+			_ = json.Unmarshal(top["message"], &message)
+			return nil, fmt.Errorf("bridge reported status %q: %s", status, message)
+		}
+		if data, ok := top["data"]; ok && isJSONObject(data) {
+			return bridgeCameraMap(data)
+		}
+	}
+
+	if raw, ok := top["cameras"]; ok {
+		return parseCamerasField(raw)
+	}
+
+	cameras := make(map[string]json.RawMessage)
+	for nameURI, raw := range top {
+		if isJSONObject(raw) {
+			cameras[nameURI] = raw
+		}
+	}
+	return cameras, nil
+}
+
+// parseCamerasField decodes a "cameras" value: an object keyed by name-uri,
+// or a list of entries keyed here by their own name_uri. null means none.
+func parseCamerasField(raw json.RawMessage) (map[string]json.RawMessage, error) {
+	cameras := make(map[string]json.RawMessage)
+	trimmed := strings.TrimSpace(string(raw))
+	if trimmed == "null" {
+		return cameras, nil
+	}
+
+	if !strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(raw, &cameras); err != nil {
+			return nil, fmt.Errorf("unexpected \"cameras\" value: %w", err)
+		}
+		return cameras, nil
+	}
+
+	var entries []json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("unexpected \"cameras\" value: %w", err)
+	}
+	for _, entry := range entries {
+		var info BridgeCameraInfo
+		if err := json.Unmarshal(entry, &info); err != nil || info.NameURI == "" {
+			continue // Can't be addressed without a name-uri
+		}
+		cameras[info.NameURI] = entry
+	}
+	return cameras, nil
+}
+
+// isJSONObject reports whether raw holds a JSON object.
+func isJSONObject(raw json.RawMessage) bool {
+	return strings.HasPrefix(strings.TrimSpace(string(raw)), "{")
+}
+
 // GetCamera returns info and stream URLs for a specific camera by name.
 // The name parameter is the URL-safe camera name (e.g., "front-door").
-func (c *Client) GetCamera(nameURI string) (*Camera, error) {
+// If the bridge 404s the direct lookup, the full camera list is searched
+// by name, nickname, and slug before giving up (see findCameraInList).
+func (c *Client) GetCamera(ctx context.Context, nameURI string) (*Camera, error) {
 	log.Printf("📷 Fetching camera '%s' from Wyze Bridge...", nameURI)
 
 	// Build the request URL for a specific camera.
 	reqURL := c.bridgeURL + "/api/" + nameURI
-	if c.apiKey != "" {
-		reqURL += "?api=" + c.apiKey
-	}
 
 	// Make the GET request.
-	resp, err := c.httpClient.Get(reqURL)
+	resp, err := c.get(ctx, reqURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reach Wyze Bridge: %w", err)
 	}
@@ -162,9 +311,11 @@ func (c *Client) GetCamera(nameURI string) (*Camera, error) {
 		return nil, fmt.Errorf("failed to read bridge response: %w", err)
 	}
 
-	// 404 or empty response means camera not found.
+	// A 404 doesn't always mean the camera is missing — some bridge versions
+	// key cameras differently from their name_uri, so the direct lookup
+	// misses a camera that is in the list. Fall back to scanning the list.
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("camera '%s' not found", nameURI)
+		return c.findCameraInList(ctx, nameURI)
 	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("bridge returned status %d for camera '%s'", resp.StatusCode, nameURI)
@@ -175,6 +326,122 @@ func (c *Client) GetCamera(nameURI string) (*Camera, error) {
 	return &cam, nil
 }
 
+// findCameraInList looks for a camera in the full GetCameras result, for
+// when the direct /api/<name> lookup 404s. Matches are tried from strictest
+// to loosest, and the first rule that matches anything wins:
+//  1. name_uri equals the requested name
+//  2. display name (nickname) equals it, case-insensitively
+//  3. slug of the display name or name_uri equals the slug of the request
+//
+// Returns an *AmbiguousNameError if the winning rule matches several cameras.
+func (c *Client) findCameraInList(ctx context.Context, name string) (*Camera, error) {
+	cameras, err := c.GetCameras(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	slug := Slugify(name)
+	rules := []func(Camera) bool{
+		func(cam Camera) bool { return cam.NameURI == name },
+		func(cam Camera) bool { return strings.EqualFold(cam.Name, name) },
+		func(cam Camera) bool {
+			return slug != "" && (Slugify(cam.Name) == slug || Slugify(cam.NameURI) == slug)
+		},
+	}
+
+	for _, matches := range rules {
+		var found []Camera
+		for _, cam := range cameras {
+			if matches(cam) {
+				found = append(found, cam)
+			}
+		}
+
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			log.Printf("📷 Camera '%s' not found directly — matched '%s' via camera list fallback", name, found[0].NameURI)
+			return &found[0], nil
+		default:
+			return nil, &AmbiguousNameError{DisplayName: name, Candidates: found}
+		}
+	}
+
+	return nil, fmt.Errorf("camera '%s' not found", name)
+}
+
+// SetCameraEnabled enables or disables streaming for a camera on the bridge.
+// Uses the bridge's per-camera control commands (GET /api/<name>/enable or
+// /api/<name>/disable). A disabled camera stops streaming entirely until it is
+// re-enabled, which is what privacy controls rely on.
+func (c *Client) SetCameraEnabled(ctx context.Context, nameURI string, enabled bool) error {
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+
+	err := c.sendCameraCommand(ctx, nameURI, action)
+	if errors.Is(err, errCommandNotFound) {
+		return fmt.Errorf("camera '%s' not found", nameURI)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The cached camera list no longer reflects this camera's enabled flag.
+	c.cacheMu.Lock()
+	c.cachedCameras = nil
+	c.cacheGen++
+	c.cameraFetch = nil
+	c.cacheMu.Unlock()
+	c.list.reset()
+
+	return nil
+}
+
+// errCommandNotFound is returned by sendCameraCommand when the bridge 404s a
+// command — either the camera or the command itself is unknown to it.
+var errCommandNotFound = errors.New("bridge returned 404 for camera command")
+
+// sendCameraCommand runs one of the bridge's per-camera control commands
+// (GET /api/<name>/<action>).
+func (c *Client) sendCameraCommand(ctx context.Context, nameURI, action string) error {
+	log.Printf("📷 Sending '%s' to camera '%s'...", action, nameURI)
+
+	reqURL := c.bridgeURL + "/api/" + nameURI + "/" + action
+
+	resp, err := c.get(ctx, reqURL)
+	if err != nil {
+		return fmt.Errorf("failed to reach Wyze Bridge: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read bridge response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errCommandNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bridge returned status %d for '%s' on camera '%s'", resp.StatusCode, action, nameURI)
+	}
+
+	// The bridge answers commands with {"status": "success"|"error", ...}.
+	// Older versions return an empty body, which we treat as success.
+	var result struct {
+		Status   string `json:"status"`
+		Response string `json:"response"`
+	}
+	if json.Unmarshal(body, &result) == nil && result.Status == "error" {
+		return fmt.Errorf("bridge rejected '%s' for camera '%s': %s", action, nameURI, result.Response)
+	}
+
+	return nil
+}
+
 // parseCameraEntry transforms a raw bridge API camera entry into our Camera model.
 // Handles the flexible JSON structure by trying known fields and falling back
 // to defaults when fields are missing (bridge response varies by version/model).
@@ -238,11 +505,9 @@ func (c *Client) parseCameraEntry(nameURI string, rawData json.RawMessage, bridg
 	}
 
 	// Construct stream URLs using the bridge host and standard ports.
-	streams := StreamURLs{
-		HLS:    fmt.Sprintf("http://%s:%s/%s/stream.m3u8", bridgeHost, hlsPort, uri),
-		RTSP:   fmt.Sprintf("rtsp://%s:%s/%s", bridgeHost, rtspPort, uri),
-		WebRTC: fmt.Sprintf("http://%s:%s/%s/", bridgeHost, webrtcPort, uri),
-	}
+	streams := streamURLs(bridgeHost, uri)
+	sd := streamURLs(bridgeHost, uri+subStreamSuffix)
+	streams.SD = &sd
 
 	return Camera{
 		Name:      displayName,
@@ -255,15 +520,21 @@ func (c *Client) parseCameraEntry(nameURI string, rawData json.RawMessage, bridg
 	}
 }
 
+// streamURLs builds the HLS, RTSP, and WebRTC URLs for a stream path.
+func streamURLs(bridgeHost, path string) StreamURLs {
+	return StreamURLs{
+		HLS:    fmt.Sprintf("http://%s:%s/%s/stream.m3u8", bridgeHost, hlsPort, path),
+		RTSP:   fmt.Sprintf("rtsp://%s:%s/%s", bridgeHost, rtspPort, path),
+		WebRTC: fmt.Sprintf("http://%s:%s/%s/", bridgeHost, webrtcPort, path),
+	}
+}
+
 // CheckHealth verifies the Wyze Bridge is running and reachable.
 // Returns nil if healthy, or an error describing the problem.
-func (c *Client) CheckHealth() error {
+func (c *Client) CheckHealth(ctx context.Context) error {
 	reqURL := c.bridgeURL + bridgeAPIEndpoint
-	if c.apiKey != "" {
-		reqURL += "?api=" + c.apiKey
-	}
 
-	resp, err := c.httpClient.Get(reqURL)
+	resp, err := c.get(ctx, reqURL)
 	if err != nil {
 		return fmt.Errorf("wyze Bridge unreachable at %s: %w", c.bridgeURL, err)
 	}
@@ -276,6 +547,46 @@ func (c *Client) CheckHealth() error {
 	return nil
 }
 
+// get sends a GET request to the bridge that is cancelled along with ctx,
+// authenticated according to the client's auth mode.
+//
+// reqURL must not contain the API key. Errors report reqURL as given, so
+// the key never ends up in a log line, even in query mode.
+func (c *Client) get(ctx context.Context, reqURL string) (*http.Response, error) {
+	return c.getWithHeader(ctx, reqURL, nil)
+}
+
+// getWithHeader is get with extra request headers.
+func (c *Client) getWithHeader(ctx context.Context, reqURL string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	if c.apiKey != "" {
+		switch c.authMode {
+		case AuthModeHeader:
+			req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		case AuthModeBasic:
+			req.SetBasicAuth(c.username, c.apiKey)
+		default:
+			query := req.URL.Query()
+			query.Set("api", c.apiKey)
+			req.URL.RawQuery = query.Encode()
+		}
+	}
+
+	resp, err := c.breaker.Do(c.httpClient, req, serviceName)
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = reqURL // Drop the ?api= key Do reports in the URL
+	}
+	return resp, err
+}
+
 // extractHost extracts the hostname (without scheme or port) from a URL.
 // e.g., "http://192.168.1.100:5050" → "192.168.1.100"
 //
@@ -0,0 +1,220 @@
+package camera
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_AuthModes(t *testing.T) {
+	tests := []struct {
+		mode      string
+		wantQuery string
+		wantAuth  string
+	}{
+		{AuthModeQuery, "api=s3cret", ""},
+		{AuthModeHeader, "", "Bearer s3cret"},
+		{AuthModeBasic, "", "Basic d2I6czNjcmV0"}, // wb:s3cret
+		{"bogus", "api=s3cret", ""},               // Falls back to query
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			var gotQuery, gotAuth string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotQuery, gotAuth = r.URL.RawQuery, r.Header.Get("Authorization")
+			}))
+			t.Cleanup(server.Close)
+
+			client := NewClientWithAuth(server.URL, "s3cret", tt.mode, "wb")
+			if err := client.CheckHealth(context.Background()); err != nil {
+				t.Fatalf("CheckHealth returned error: %v", err)
+			}
+			if gotQuery != tt.wantQuery || gotAuth != tt.wantAuth {
+				t.Errorf("expected query %q and Authorization %q, got %q and %q", tt.wantQuery, tt.wantAuth, gotQuery, gotAuth)
+			}
+		})
+	}
+}
+
+func TestClient_ErrorsDontLeakQueryKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close() // Nothing listening, so the request fails with the URL in the error
+
+	err := NewClient(server.URL, "s3cret").CheckHealth(context.Background())
+	if err == nil {
+		t.Fatal("expected an error from an unreachable bridge")
+	}
+	if strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("expected the API key to be kept out of the error, got %v", err)
+	}
+}
+
+// Bridge /api responses in the shape each bridge version returns them,
+// trimmed to two cameras each.
+const (
+	legacyBridgeBody = `{
+		"front-door": {"name_uri": "front-door", "nickname": "Front Door", "product_model": "WYZE_CAKP2JFUS", "model_name": "V3", "connected": true, "enabled": true, "img_url": "img/front-door.jpg"},
+		"back-yard": {"name_uri": "back-yard", "nickname": "Back Yard", "product_model": "HL_CAM4", "model_name": "V4", "connected": false, "enabled": true, "img_url": "img/back-yard.jpg"}
+	}`
+	wrappedBridgeBody = `{
+		"available": 2,
+		"enabled": 2,
+		"total": 2,
+		"cameras": {
+			"front-door": {"name_uri": "front-door", "nickname": "Front Door", "product_model": "WYZE_CAKP2JFUS", "model_name": "V3", "connected": true, "enabled": true},
+			"back-yard": {"name_uri": "back-yard", "nickname": "Back Yard", "product_model": "HL_CAM4", "model_name": "V4", "connected": false, "enabled": true}
+		}
+	}`
+	statusBridgeBody = `{
+		"status": "success",
+		"data": {
+			"available": 2,
+			"cameras": [
+				{"name_uri": "front-door", "nickname": "Front Door", "product_model": "WYZE_CAKP2JFUS", "model_name": "V3", "connected": true, "enabled": true},
+				{"name_uri": "back-yard", "nickname": "Back Yard", "product_model": "HL_CAM4", "model_name": "V4", "connected": false, "enabled": true}
+			]
+		}
+	}`
+)
+
+func TestGetCameras_BridgeResponseShapes(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"legacy flat map", legacyBridgeBody},
+		{"wrapped under cameras", wrappedBridgeBody},
+		{"status wrapper", statusBridgeBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newStubBridge(t, tt.body)
+			cameras, err := client.GetCameras(context.Background())
+			if err != nil {
+				t.Fatalf("GetCameras returned error: %v", err)
+			}
+
+			byURI := make(map[string]Camera)
+			for _, cam := range cameras {
+				byURI[cam.NameURI] = cam
+			}
+			if len(cameras) != 2 || byURI["front-door"].Status != "online" || byURI["back-yard"].Status != "offline" {
+				t.Errorf("expected online front-door and offline back-yard, got %+v", cameras)
+			}
+			if byURI["front-door"].Name != "Front Door" || byURI["front-door"].Model != "V3" {
+				t.Errorf("expected front-door's nickname and model, got %+v", byURI["front-door"])
+			}
+		})
+	}
+}
+
+func TestGetCameras_StatusError(t *testing.T) {
+	client, _ := newStubBridge(t, `{"status": "error", "message": "wyze login failed"}`)
+
+	if _, err := client.GetCameras(context.Background()); err == nil || !strings.Contains(err.Error(), "wyze login failed") {
+		t.Errorf("expected the bridge's error message, got %v", err)
+	}
+}
+
+func TestGetCameras_ConditionalRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator string // Response header the bridge sends
+		value     string
+		request   string // Conditional request header it checks
+	}{
+		{"etag", "ETag", `"v1"`, "If-None-Match"},
+		{"last-modified", "Last-Modified", "Wed, 14 Oct 2026 08:00:00 GMT", "If-Modified-Since"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var full, notModified int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(tt.request) == tt.value {
+					notModified++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				full++
+				w.Header().Set(tt.validator, tt.value)
+				w.Write([]byte(legacyBridgeBody))
+			}))
+			t.Cleanup(server.Close)
+			client := NewClient(server.URL, "")
+
+			for i := 0; i < 3; i++ {
+				cameras, err := client.GetCameras(context.Background())
+				if err != nil {
+					t.Fatalf("GetCameras #%d returned error: %v", i+1, err)
+				}
+				if len(cameras) != 2 {
+					t.Fatalf("GetCameras #%d: expected 2 cameras, got %d", i+1, len(cameras))
+				}
+			}
+			if full != 1 || notModified != 2 {
+				t.Errorf("expected 1 full fetch and 2 not-modified, got %d and %d", full, notModified)
+			}
+
+			// Changing a camera drops the cached list
+			client.list.reset()
+			if _, err := client.GetCameras(context.Background()); err != nil {
+				t.Fatalf("GetCameras returned error: %v", err)
+			}
+			if full != 2 {
+				t.Errorf("expected a full fetch after reset, got %d full fetch(es)", full)
+			}
+		})
+	}
+}
+
+func TestGetCameras_NoValidatorsAlwaysFetches(t *testing.T) {
+	var conditional int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			conditional++
+		}
+		w.Write([]byte(legacyBridgeBody))
+	}))
+	t.Cleanup(server.Close)
+	client := NewClient(server.URL, "")
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetCameras(context.Background()); err != nil {
+			t.Fatalf("GetCameras returned error: %v", err)
+		}
+	}
+	if conditional != 0 {
+		t.Errorf("expected no conditional requests without validators, got %d", conditional)
+	}
+}
+
+func TestGetCameras_CachedListIsACopy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(legacyBridgeBody))
+	}))
+	t.Cleanup(server.Close)
+	client := NewClient(server.URL, "")
+
+	first, _ := client.GetCameras(context.Background())
+	first[0].Name = "Changed"
+
+	second, err := client.GetCameras(context.Background())
+	if err != nil {
+		t.Fatalf("GetCameras returned error: %v", err)
+	}
+	for _, cam := range second {
+		if cam.Name == "Changed" {
+			t.Error("expected changes to a returned list not to leak into the cache")
+		}
+	}
+}
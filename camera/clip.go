@@ -0,0 +1,223 @@
+package camera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// clipGrace is how long ffmpeg gets past the clip's duration to connect to
+// the stream and finish writing the file before it is killed.
+const clipGrace = 30 * time.Second
+
+// clipExt is the container every clip is written as.
+const clipExt = ".mp4"
+
+// clipTimeLayout is the UTC capture time in a clip's file name, after the
+// camera's name-uri.
+const clipTimeLayout = "20060102-150405"
+
+// ErrFFmpegUnavailable means ffmpeg wasn't found on the PATH when the
+// recorder was created, so clips can't be captured.
+var ErrFFmpegUnavailable = errors.New("ffmpeg not available")
+
+// ErrCaptureBusy means the maximum number of captures is already running.
+var ErrCaptureBusy = errors.New("too many clip captures in progress")
+
+// Clip is a recording of a camera stream written to disk.
+type Clip struct {
+	Name       string        // Camera display name
+	NameURI    string        // URL-safe camera name
+	File       string        // File name within the clip directory, e.g. "front-door-20260101-120000.mp4"
+	Path       string        // Absolute path on the Artemis host
+	Duration   time.Duration // Requested length of the clip
+	SizeBytes  int64
+	CapturedAt time.Time // When recording started
+}
+
+// ClipRecorder captures clips of camera streams to a local directory by
+// running ffmpeg against the bridge's RTSP stream (or HLS, when the camera
+// has no RTSP URL). The video is copied, not re-encoded, so a capture costs
+// little CPU.
+//
+// ffmpeg is looked up once, when the recorder is created; without it every
+// capture fails with ErrFFmpegUnavailable. At most maxConcurrent captures run
+// at once and each is capped at maxDuration.
+type ClipRecorder struct {
+	ffmpeg      string // Path to the ffmpeg binary, "" when not installed
+	dir         string
+	maxDuration time.Duration
+	slots       chan struct{} // One token per running capture
+}
+
+// NewClipRecorder creates a recorder that writes clips to dir (created on
+// first capture).
+func NewClipRecorder(dir string, maxDuration time.Duration, maxConcurrent int) *ClipRecorder {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		ffmpeg = ""
+	}
+	return newClipRecorder(ffmpeg, dir, maxDuration, maxConcurrent)
+}
+
+// newClipRecorder creates a recorder with an explicit ffmpeg path, so tests
+// can substitute a fake one.
+func newClipRecorder(ffmpeg, dir string, maxDuration time.Duration, maxConcurrent int) *ClipRecorder {
+	return &ClipRecorder{
+		ffmpeg:      ffmpeg,
+		dir:         dir,
+		maxDuration: maxDuration,
+		slots:       make(chan struct{}, max(maxConcurrent, 1)),
+	}
+}
+
+// Available reports whether ffmpeg was found, i.e. whether captures can work.
+func (cr *ClipRecorder) Available() bool {
+	return cr != nil && cr.ffmpeg != ""
+}
+
+// MaxDuration is the longest clip Capture accepts.
+func (cr *ClipRecorder) MaxDuration() time.Duration {
+	return cr.maxDuration
+}
+
+// Capture records duration of cam's stream to a new file in the clip
+// directory and returns it once ffmpeg has finished. Returns
+// ErrFFmpegUnavailable or ErrCaptureBusy without recording anything.
+// Cancelling ctx stops the capture; a partial or empty file is removed.
+func (cr *ClipRecorder) Capture(ctx context.Context, cam Camera, duration time.Duration) (*Clip, error) {
+	if !cr.Available() {
+		return nil, ErrFFmpegUnavailable
+	}
+	if duration <= 0 || duration > cr.maxDuration {
+		return nil, fmt.Errorf("clip duration must be between 1s and %s, got %s", cr.maxDuration, duration)
+	}
+
+	source := cam.Streams.RTSP
+	if source == "" {
+		source = cam.Streams.HLS
+	}
+	if source == "" {
+		return nil, fmt.Errorf("camera '%s' has no stream URL", cam.NameURI)
+	}
+
+	select {
+	case cr.slots <- struct{}{}:
+		defer func() { <-cr.slots }()
+	default:
+		return nil, ErrCaptureBusy
+	}
+
+	path, err := cr.createClipFile(cam.NameURI)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🎬 Capturing %s clip of camera '%s' to %s", duration, cam.NameURI, path)
+	capturedAt := time.Now().UTC()
+
+	ctx, cancel := context.WithTimeout(ctx, duration+clipGrace)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, cr.ffmpeg, clipArgs(source, duration, path)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(path)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("capture of camera '%s' stopped: %w", cam.NameURI, ctx.Err())
+		}
+		return nil, fmt.Errorf("ffmpeg failed for camera '%s': %v: %s", cam.NameURI, err, lastLine(stderr.String()))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Size() == 0 {
+		os.Remove(path)
+		return nil, fmt.Errorf("ffmpeg wrote no video for camera '%s'", cam.NameURI)
+	}
+
+	return &Clip{
+		Name:       cam.Name,
+		NameURI:    cam.NameURI,
+		File:       filepath.Base(path),
+		Path:       path,
+		Duration:   duration,
+		SizeBytes:  info.Size(),
+		CapturedAt: capturedAt,
+	}, nil
+}
+
+// ClipPath returns the path of a clip file in the clip directory. file must
+// be a bare clip file name as returned in Clip.File, so a request can't
+// reach anything else on disk.
+func (cr *ClipRecorder) ClipPath(file string) (string, error) {
+	if file != filepath.Base(file) || strings.HasPrefix(file, ".") || !strings.HasSuffix(file, clipExt) {
+		return "", fmt.Errorf("invalid clip file name %q", file)
+	}
+	return absPath(filepath.Join(cr.dir, file))
+}
+
+// createClipFile reserves a new, uniquely named file for a clip of nameURI
+// and returns its absolute path. Reserving it up front keeps two captures
+// of the same camera in the same second from writing to the same file.
+func (cr *ClipRecorder) createClipFile(nameURI string) (string, error) {
+	if err := os.MkdirAll(cr.dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create clip directory: %w", err)
+	}
+
+	base := nameURI + "-" + time.Now().UTC().Format(clipTimeLayout)
+	for n := 1; ; n++ {
+		name := base + clipExt
+		if n > 1 {
+			name = fmt.Sprintf("%s-%d%s", base, n, clipExt)
+		}
+		f, err := os.OpenFile(filepath.Join(cr.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create clip file: %w", err)
+		}
+		f.Close()
+		return absPath(f.Name())
+	}
+}
+
+// absPath makes path absolute, so responses name the file unambiguously.
+func absPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve clip path: %w", err)
+	}
+	return abs, nil
+}
+
+// clipArgs builds the ffmpeg arguments to copy duration of source into path.
+// RTSP is read over TCP, since UDP drops packets on busy Wi-Fi.
+func clipArgs(source string, duration time.Duration, path string) []string {
+	args := []string{"-hide_banner", "-loglevel", "error", "-y"}
+	if strings.HasPrefix(source, "rtsp://") {
+		args = append(args, "-rtsp_transport", "tcp")
+	}
+	return append(args,
+		"-i", source,
+		"-t", fmt.Sprintf("%.3f", duration.Seconds()),
+		"-c", "copy",
+		"-movflags", "+faststart",
+		path,
+	)
+}
+
+// lastLine returns the last non-empty line of ffmpeg's error output, which
+// is the one that says what went wrong.
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
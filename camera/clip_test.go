@@ -0,0 +1,105 @@
+package camera
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeFFmpeg writes a shell script standing in for ffmpeg: it records its
+// arguments in args.txt next to it and writes "video" to its last argument,
+// or fails with a message on stderr when failing is set.
+func fakeFFmpeg(t *testing.T, failing bool) string {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "args.txt") + "\n"
+	if failing {
+		script += "echo 'Input #0' >&2\necho 'rtsp://bridge:8554/front-door: Connection refused' >&2\nexit 1\n"
+	} else {
+		script += "for last; do :; done\nprintf video > \"$last\"\n"
+	}
+	path := filepath.Join(dir, "ffmpeg")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+var frontDoor = Camera{
+	Name:    "Front Door",
+	NameURI: "front-door",
+	Status:  "online",
+	Streams: StreamURLs{RTSP: "rtsp://bridge:8554/front-door", HLS: "http://bridge:8888/front-door/stream.m3u8"},
+}
+
+func TestClipRecorder_Capture(t *testing.T) {
+	ffmpeg := fakeFFmpeg(t, false)
+	recorder := newClipRecorder(ffmpeg, filepath.Join(t.TempDir(), "clips"), time.Minute, 1)
+
+	clip, err := recorder.Capture(context.Background(), frontDoor, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Capture returned error: %v", err)
+	}
+	if !strings.HasPrefix(clip.File, "front-door-") || !strings.HasSuffix(clip.File, ".mp4") || clip.SizeBytes != 5 {
+		t.Errorf("unexpected clip: %+v", clip)
+	}
+	if data, _ := os.ReadFile(clip.Path); string(data) != "video" {
+		t.Errorf("expected the clip at %s, got %q", clip.Path, data)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(filepath.Dir(ffmpeg), "args.txt"))
+	if !strings.Contains(string(args), "-rtsp_transport tcp -i rtsp://bridge:8554/front-door -t 5.000 -c copy") {
+		t.Errorf("expected a 5s copy of the RTSP stream, got %s", args)
+	}
+
+	// A second clip in the same second gets its own file
+	second, err := recorder.Capture(context.Background(), frontDoor, time.Second)
+	if err != nil || second.File == clip.File {
+		t.Errorf("expected a second, distinct clip, got %+v (%v)", second, err)
+	}
+}
+
+func TestClipRecorder_FailureRemovesFile(t *testing.T) {
+	dir := t.TempDir()
+	recorder := newClipRecorder(fakeFFmpeg(t, true), dir, time.Minute, 1)
+
+	_, err := recorder.Capture(context.Background(), frontDoor, 5*time.Second)
+	if err == nil || !strings.HasSuffix(err.Error(), "Connection refused") {
+		t.Fatalf("expected ffmpeg's last error line, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected the failed clip to be removed, found %d file(s)", len(entries))
+	}
+}
+
+func TestClipRecorder_Limits(t *testing.T) {
+	if _, err := newClipRecorder("", t.TempDir(), time.Minute, 1).Capture(context.Background(), frontDoor, time.Second); !errors.Is(err, ErrFFmpegUnavailable) {
+		t.Errorf("expected ErrFFmpegUnavailable without ffmpeg, got %v", err)
+	}
+
+	recorder := newClipRecorder(fakeFFmpeg(t, false), t.TempDir(), time.Minute, 1)
+	if _, err := recorder.Capture(context.Background(), frontDoor, 2*time.Minute); err == nil {
+		t.Error("expected a clip over the maximum duration to be refused")
+	}
+
+	recorder.slots <- struct{}{} // One capture already running
+	if _, err := recorder.Capture(context.Background(), frontDoor, time.Second); !errors.Is(err, ErrCaptureBusy) {
+		t.Errorf("expected ErrCaptureBusy at the concurrency limit, got %v", err)
+	}
+}
+
+func TestClipRecorder_ClipPath(t *testing.T) {
+	recorder := newClipRecorder("", "clips", time.Minute, 1)
+	if path, err := recorder.ClipPath("front-door-20260101-120000.mp4"); err != nil || !filepath.IsAbs(path) {
+		t.Errorf("expected an absolute path, got %q (%v)", path, err)
+	}
+	for _, file := range []string{"../pantheon.db", "sub/clip.mp4", ".hidden.mp4", "notes.txt", ""} {
+		if _, err := recorder.ClipPath(file); err == nil {
+			t.Errorf("expected %q to be refused", file)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package camera
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// cameraListCache remembers the last camera list GetCameras parsed and the
+// validators (ETag, Last-Modified) the bridge sent with it, so the next
+// request can ask whether anything changed. Safe for concurrent use.
+type cameraListCache struct {
+	mu           sync.Mutex
+	etag         string
+	lastModified string
+	list         []Camera
+}
+
+// conditionalHeader returns If-None-Match / If-Modified-Since headers for
+// the cached list, or nil if there's no list or the bridge sent no
+// validators with it.
+func (l *cameraListCache) conditionalHeader() http.Header {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.list == nil || (l.etag == "" && l.lastModified == "") {
+		return nil
+	}
+	header := make(http.Header)
+	if l.etag != "" {
+		header.Set("If-None-Match", l.etag)
+	}
+	if l.lastModified != "" {
+		header.Set("If-Modified-Since", l.lastModified)
+	}
+	return header
+}
+
+// cameras returns a copy of the cached list, so callers can't modify it.
+func (l *cameraListCache) cameras() ([]Camera, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.list == nil {
+		return nil, false
+	}
+	return slices.Clone(l.list), true
+}
+
+// store caches a freshly parsed list with the validators from its
+// response. A response without validators clears the cache, since the
+// next request can't be conditional anyway.
+func (l *cameraListCache) store(header http.Header, cameras []Camera) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.etag = header.Get("ETag")
+	l.lastModified = header.Get("Last-Modified")
+	if l.etag == "" && l.lastModified == "" {
+		l.list = nil
+		return
+	}
+	l.list = slices.Clone(cameras)
+	if l.list == nil {
+		l.list = []Camera{}
+	}
+}
+
+// reset drops the cached list, so the next request is a full fetch.
+func (l *cameraListCache) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.etag, l.lastModified, l.list = "", "", nil
+}
@@ -1,5 +1,11 @@
 package camera
 
+import (
+	"time"
+
+	"github.com/pantheon/artemis/errcode"
+)
+
 // Data structures for the Wyze Camera Bridge integration.
 //
 // The Go backend queries the Docker Wyze Bridge REST API (http://<host>:5050/api/)
@@ -28,14 +34,19 @@ type StreamURLs struct {
 	HLS    string `json:"hls"`    // http://<host>:8888/<name>/stream.m3u8 — used by iOS AVPlayer
 	RTSP   string `json:"rtsp"`   // rtsp://<host>:8554/<name> — standard video streaming
 	WebRTC string `json:"webrtc"` // http://<host>:8889/<name>/ — low-latency browser streaming
+
+	// Same URLs for the lower-resolution substream (<name>-sub). Only served
+	// if SUBSTREAM is enabled for the camera on the bridge.
+	SD *StreamURLs `json:"sd,omitempty"`
 }
 
 // CamerasResponse is the response from GET /api/cameras.
 // Wraps the camera list with a success flag and message.
 type CamerasResponse struct {
-	Success bool     `json:"success"` // Whether the bridge query succeeded
-	Cameras []Camera `json:"cameras"` // List of available cameras
-	Message string   `json:"message"` // Human-readable status message
+	Success bool         `json:"success"`        // Whether the bridge query succeeded
+	Cameras []Camera     `json:"cameras"`        // List of available cameras
+	Message string       `json:"message"`        // Human-readable status message
+	Code    errcode.Code `json:"code,omitempty"` // Machine-readable error code (errors only)
 }
 
 // StreamResponse is the response from GET /api/cameras/stream.
@@ -45,20 +56,101 @@ type StreamResponse struct {
 	Name      string     `json:"name"`      // Camera name
 	NameURI   string     `json:"nameUri"`   // URL-safe camera name
 	Status    string     `json:"status"`    // "online" or "offline"
-	StreamURL string     `json:"streamUrl"` // Primary HLS stream URL
-	Streams   StreamURLs `json:"streams"`   // All available stream URLs
+	Quality   string     `json:"quality"`   // "hd" (main stream) or "sd" (substream)
+	StreamURL string     `json:"streamUrl"` // HLS stream URL in the requested quality
+	Streams   StreamURLs `json:"streams"`   // All available stream URLs (HD, with SD under "sd")
 	Message   string     `json:"message"`   // Human-readable status message
 }
 
+// DefaultCameraResponse is the response from GET /api/cameras/default.
+// Source says whether the configured default camera was used ("default") or
+// the first online camera in its place ("fallback").
+type DefaultCameraResponse struct {
+	StreamResponse
+	Source string `json:"source"` // "default" or "fallback"
+}
+
+// BridgeStatusResponse is the response from GET /api/cameras/bridge-status.
+type BridgeStatusResponse struct {
+	Success bool `json:"success"` // Whether the bridge could be queried
+	BridgeStatus
+	Message string `json:"message"` // Human-readable status message
+}
+
+// Default camera sources, see DefaultCameraResponse.
+const (
+	DefaultSourceConfigured = "default"
+	DefaultSourceFallback   = "fallback"
+)
+
+// PrivacyRequest is the request body for POST /api/cameras/privacy.
+// Enabled=true turns privacy mode on (every camera stream is disabled);
+// Enabled=false turns it off (every camera stream is re-enabled).
+type PrivacyRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// CameraActionResult reports the outcome of a bridge action on one camera.
+type CameraActionResult struct {
+	Name    string `json:"name"`            // Camera display name
+	NameURI string `json:"nameUri"`         // URL-safe camera name
+	Success bool   `json:"success"`         // Whether the bridge accepted the action
+	Error   string `json:"error,omitempty"` // Failure reason when Success is false
+}
+
+// PrivacyResponse is the response from POST /api/cameras/privacy.
+// Success is true only when every camera was updated; per-camera outcomes
+// are listed in Results so partial failures are visible to the app.
+type PrivacyResponse struct {
+	Success        bool                 `json:"success"`        // Whether all cameras were updated
+	PrivacyEnabled bool                 `json:"privacyEnabled"` // The privacy state that was requested
+	Affected       int                  `json:"affected"`       // Number of cameras successfully updated
+	Failed         int                  `json:"failed"`         // Number of cameras that could not be updated
+	Results        []CameraActionResult `json:"results"`        // Per-camera outcomes
+	Message        string               `json:"message"`        // Human-readable summary
+}
+
 // BridgeCameraInfo represents the raw camera data returned by the Wyze Bridge API.
 // The bridge's GET /api/ endpoint returns a JSON object where each key is a camera
 // URI name, and the value contains camera metadata. The exact fields vary by camera
 // model and bridge version, so we parse selectively.
 type BridgeCameraInfo struct {
-	NameURI    string `json:"name_uri"`     // URL-safe camera identifier (e.g., "front-door")
-	Nickname   string `json:"nickname"`     // Display name from the Wyze app (e.g., "Front Door")
-	ModelName  string `json:"model_name"`   // Camera model name (e.g., "Wyze Cam v3")
+	NameURI      string `json:"name_uri"`      // URL-safe camera identifier (e.g., "front-door")
+	Nickname     string `json:"nickname"`      // Display name from the Wyze app (e.g., "Front Door")
+	ModelName    string `json:"model_name"`    // Camera model name (e.g., "Wyze Cam v3")
 	ProductModel string `json:"product_model"` // Product model ID (e.g., "WYZE_CAKP2JFUS")
-	Connected  bool   `json:"connected"`    // Whether the camera is currently connected
-	Enabled    bool   `json:"enabled"`      // Whether streaming is enabled in the bridge
+	Connected    bool   `json:"connected"`     // Whether the camera is currently connected
+	Enabled      bool   `json:"enabled"`       // Whether streaming is enabled in the bridge
+}
+
+// RestartResponse is the response from POST /api/cameras/restart.
+// Success is true only when the stream was restarted and passed the
+// readiness check afterwards.
+type RestartResponse struct {
+	Success bool `json:"success"`
+	RestartResult
+	Message string `json:"message"` // Human-readable summary
+}
+
+// SnapshotResponse is the response from GET /api/cameras/snapshot?format=json,
+// for clients that would rather handle a base64 string than raw image bytes.
+type SnapshotResponse struct {
+	Name        string    `json:"name"`        // Camera display name
+	ContentType string    `json:"contentType"` // Image MIME type (e.g., "image/jpeg")
+	DataBase64  string    `json:"dataBase64"`  // Standard base64 of the image bytes
+	CapturedAt  time.Time `json:"capturedAt"`  // When the image was fetched from the bridge
+}
+
+// ClipResponse is the response from POST /api/cameras/capture-clip.
+type ClipResponse struct {
+	Success    bool      `json:"success"`    // Whether the clip was written
+	Name       string    `json:"name"`       // Camera display name
+	NameURI    string    `json:"nameUri"`    // URL-safe camera name
+	File       string    `json:"file"`       // File name in the clip directory
+	Path       string    `json:"path"`       // Absolute path on the Artemis host
+	URL        string    `json:"url"`        // Where the clip can be downloaded from Artemis
+	Seconds    int       `json:"seconds"`    // Length of the clip
+	SizeBytes  int64     `json:"sizeBytes"`  // Size of the file
+	CapturedAt time.Time `json:"capturedAt"` // When recording started
+	Message    string    `json:"message"`    // Human-readable status message
 }
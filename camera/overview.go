@@ -0,0 +1,140 @@
+package camera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // Some bridge builds serve PNG snapshots
+	"log"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Overview grid defaults. Tiles are 16:9 like the camera streams.
+const (
+	OverviewTileWidth   = 320
+	OverviewTileHeight  = 180
+	OverviewMaxCameras  = 16
+	overviewJPEGQuality = 80
+)
+
+// ErrNoOnlineCameras means there is nothing to put in an overview.
+var ErrNoOnlineCameras = errors.New("no cameras are online")
+
+// Placeholder tile colors, for cameras whose snapshot couldn't be fetched.
+var (
+	placeholderBackground = color.RGBA{0x22, 0x22, 0x22, 0xFF}
+	placeholderCross      = color.RGBA{0x55, 0x55, 0x55, 0xFF}
+)
+
+// GetOverview fetches a snapshot of every online camera concurrently and
+// composes them into one JPEG grid, cols tiles wide (0 picks a roughly
+// square grid). Cameras are ordered by name-uri and capped at maxCameras.
+// A camera whose snapshot fails gets a placeholder tile instead of failing
+// the whole image. Cameras ctx isn't allowed to see (camera ACLs) are left
+// out. Returns ErrNoOnlineCameras if there's nothing to show.
+func (c *Client) GetOverview(ctx context.Context, cols, maxCameras int) ([]byte, error) {
+	cameras, err := c.GetCameras(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var online []Camera
+	for _, cam := range FilterAllowed(ctx, cameras) {
+		if cam.Status == "online" {
+			online = append(online, cam)
+		}
+	}
+	if len(online) == 0 {
+		return nil, ErrNoOnlineCameras
+	}
+	sort.Slice(online, func(i, j int) bool { return online[i].NameURI < online[j].NameURI })
+	if len(online) > maxCameras {
+		online = online[:maxCameras]
+	}
+
+	// Fetch all snapshots at once; a nil tile becomes a placeholder
+	tiles := make([]image.Image, len(online))
+	var wg sync.WaitGroup
+	for i, cam := range online {
+		wg.Add(1)
+		go func(i int, nameURI string) {
+			defer wg.Done()
+			snapshot, err := c.GetSnapshot(ctx, nameURI)
+			if err != nil {
+				log.Printf("⚠️  Overview: no snapshot for '%s': %v", nameURI, err)
+				return
+			}
+			img, _, err := image.Decode(bytes.NewReader(snapshot.Data))
+			if err != nil {
+				log.Printf("⚠️  Overview: couldn't decode snapshot for '%s': %v", nameURI, err)
+				return
+			}
+			tiles[i] = img
+		}(i, cam.NameURI)
+	}
+	wg.Wait()
+
+	var buf bytes.Buffer
+	grid := ComposeGrid(tiles, cols, OverviewTileWidth, OverviewTileHeight)
+	if err := jpeg.Encode(&buf, grid, &jpeg.Options{Quality: overviewJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode overview: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ComposeGrid lays tiles out left to right, top to bottom, cols per row,
+// scaling each to tileW x tileH. nil tiles are drawn as placeholders.
+// cols <= 0 picks ceil(sqrt(len(tiles))), a roughly square grid.
+func ComposeGrid(tiles []image.Image, cols, tileW, tileH int) *image.RGBA {
+	if cols <= 0 {
+		cols = int(math.Ceil(math.Sqrt(float64(len(tiles)))))
+	}
+	cols = max(1, min(cols, len(tiles)))
+	rows := (len(tiles) + cols - 1) / cols
+
+	grid := image.NewRGBA(image.Rect(0, 0, cols*tileW, max(rows, 1)*tileH))
+	draw.Draw(grid, grid.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	for i, tile := range tiles {
+		x, y := (i%cols)*tileW, (i/cols)*tileH
+		rect := image.Rect(x, y, x+tileW, y+tileH)
+		if tile == nil {
+			drawPlaceholder(grid, rect)
+		} else {
+			drawScaled(grid, rect, tile)
+		}
+	}
+	return grid
+}
+
+// drawScaled draws src into rect with nearest-neighbor scaling. Snapshot
+// tiles are small, so the quality difference to a smoother filter isn't
+// worth pulling in an image library.
+func drawScaled(dst *image.RGBA, rect image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	for y := 0; y < rect.Dy(); y++ {
+		sy := sb.Min.Y + y*sb.Dy()/rect.Dy()
+		for x := 0; x < rect.Dx(); x++ {
+			sx := sb.Min.X + x*sb.Dx()/rect.Dx()
+			dst.Set(rect.Min.X+x, rect.Min.Y+y, src.At(sx, sy))
+		}
+	}
+}
+
+// drawPlaceholder fills rect with a dark tile crossed out corner to corner.
+func drawPlaceholder(dst *image.RGBA, rect image.Rectangle) {
+	draw.Draw(dst, rect, image.NewUniform(placeholderBackground), image.Point{}, draw.Src)
+	w, h := rect.Dx(), rect.Dy()
+	for x := 0; x < w; x++ {
+		y := x * h / w
+		dst.Set(rect.Min.X+x, rect.Min.Y+y, placeholderCross)
+		dst.Set(rect.Min.X+x, rect.Max.Y-1-y, placeholderCross)
+	}
+}
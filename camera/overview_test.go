@@ -0,0 +1,54 @@
+package camera
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestComposeGrid(t *testing.T) {
+	red := image.NewUniform(color.RGBA{0xFF, 0, 0, 0xFF})
+	solid := func() image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, 64, 36))
+		for y := 0; y < 36; y++ {
+			for x := 0; x < 64; x++ {
+				img.Set(x, y, red.C)
+			}
+		}
+		return img
+	}
+
+	tests := []struct {
+		name         string
+		tiles        int
+		cols         int
+		wantW, wantH int
+	}{
+		{"square by default", 5, 0, 3 * 32, 2 * 18},
+		{"explicit columns", 5, 1, 32, 5 * 18},
+		{"columns capped at tile count", 2, 4, 2 * 32, 18},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiles := make([]image.Image, tt.tiles)
+			for i := range tiles {
+				tiles[i] = solid()
+			}
+			grid := ComposeGrid(tiles, tt.cols, 32, 18)
+			if b := grid.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Errorf("expected %dx%d, got %dx%d", tt.wantW, tt.wantH, b.Dx(), b.Dy())
+			}
+		})
+	}
+}
+
+func TestComposeGrid_PlaceholderForMissingTile(t *testing.T) {
+	grid := ComposeGrid([]image.Image{image.NewUniform(color.White), nil}, 2, 32, 18)
+
+	if got := grid.RGBAAt(10, 2); got != (color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}) {
+		t.Errorf("expected the first tile scaled in, got %v", got)
+	}
+	if got := grid.RGBAAt(32+10, 2); got != placeholderBackground {
+		t.Errorf("expected a placeholder for the missing tile, got %v", got)
+	}
+}
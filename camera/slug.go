@@ -0,0 +1,163 @@
+package camera
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// cameraListTTL is how long the camera list used for display-name lookups is
+// reused before the bridge is queried again. Short enough that renamed or
+// newly added cameras show up quickly, long enough to absorb the burst of
+// lookups the app makes when opening the camera screen.
+const cameraListTTL = 30 * time.Second
+
+// Slugify converts a camera display name into the URL-safe name-uri format
+// the Wyze Bridge uses in its API paths and stream URLs.
+//
+// Mirrors the bridge's own cleaning rules so the result is deterministic:
+//   - Leading/trailing whitespace is trimmed and everything is lowercased
+//   - Runs of whitespace and hyphens collapse into a single hyphen
+//   - ASCII letters, digits, and underscores are kept
+//   - All other characters (punctuation, emoji, non-ASCII letters) are dropped
+//
+// Examples: "Front Door" → "front-door", "Kid's Room!" → "kids-room",
+// "Café Cam" → "caf-cam".
+func Slugify(name string) string {
+	var b strings.Builder
+	pendingHyphen := false
+
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '-':
+			// Defer writing the hyphen so repeated separators collapse
+			// and no hyphen is left dangling at the end.
+			pendingHyphen = b.Len() > 0
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_':
+			if pendingHyphen {
+				b.WriteByte('-')
+				pendingHyphen = false
+			}
+			b.WriteRune(r)
+		default:
+			// Punctuation and non-ASCII characters are dropped entirely,
+			// matching the bridge's ASCII-only name-uri format.
+		}
+	}
+
+	return b.String()
+}
+
+// AmbiguousNameError is returned by ResolveDisplayName when more than one
+// camera matches the requested display name. Candidates lists every match
+// so the caller can ask the user to pick one.
+type AmbiguousNameError struct {
+	DisplayName string
+	Candidates  []Camera
+}
+
+// Error implements the error interface.
+func (e *AmbiguousNameError) Error() string {
+	return fmt.Sprintf("display name '%s' matches %d cameras", e.DisplayName, len(e.Candidates))
+}
+
+// ResolveDisplayName finds the name-uri for a camera given its display name
+// (e.g., "Front Door" → "front-door").
+//
+// Matching is case-insensitive and compares slugs, so "front door",
+// "Front-Door", and "FRONT DOOR" all resolve to the same camera. A camera
+// matches when the slug of the requested name equals either the slug of its
+// display name or its name-uri.
+//
+// Returns an *AmbiguousNameError when several cameras match, and a
+// "not found" error when none do.
+func (c *Client) ResolveDisplayName(ctx context.Context, displayName string) (string, error) {
+	slug := Slugify(displayName)
+	if slug == "" {
+		return "", fmt.Errorf("camera '%s' not found", displayName)
+	}
+
+	cameras, err := c.getCamerasCached(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var matches []Camera
+	for _, cam := range cameras {
+		if Slugify(cam.Name) == slug || strings.EqualFold(cam.NameURI, slug) {
+			matches = append(matches, cam)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("camera '%s' not found", displayName)
+	case 1:
+		log.Printf("📷 Resolved display name '%s' to camera '%s'", displayName, matches[0].NameURI)
+		return matches[0].NameURI, nil
+	default:
+		return "", &AmbiguousNameError{DisplayName: displayName, Candidates: matches}
+	}
+}
+
+// cameraFetchTimeout bounds a camera list fetch shared by getCamerasCached
+// callers, since it outlives the caller that started it.
+const cameraFetchTimeout = time.Minute
+
+// cameraFetch is a camera list fetch in flight, shared by every
+// getCamerasCached call that misses the cache while it runs. cameras and
+// err are set before done is closed.
+type cameraFetch struct {
+	done    chan struct{}
+	cameras []Camera
+	err     error
+}
+
+// getCamerasCached returns the camera list, reusing the last successful
+// GetCameras result if it is younger than cameraListTTL. Calls that miss
+// the cache while a fetch is running wait for it instead of starting their
+// own; each stops waiting when its own ctx ends.
+func (c *Client) getCamerasCached(ctx context.Context) ([]Camera, error) {
+	c.cacheMu.Lock()
+	if c.cachedCameras != nil && time.Since(c.cachedAt) < cameraListTTL {
+		cameras := c.cachedCameras
+		c.cacheMu.Unlock()
+		return cameras, nil
+	}
+	fetch := c.cameraFetch
+	if fetch == nil {
+		fetch = &cameraFetch{done: make(chan struct{})}
+		c.cameraFetch = fetch
+		go c.fetchCameras(context.WithoutCancel(ctx), fetch, c.cacheGen)
+	}
+	c.cacheMu.Unlock()
+
+	select {
+	case <-fetch.done:
+		return fetch.cameras, fetch.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetchCameras runs a shared fetch for getCamerasCached, started under
+// cache generation gen. A successful one fills the cache unless it was
+// invalidated in the meantime.
+func (c *Client) fetchCameras(ctx context.Context, fetch *cameraFetch, gen uint64) {
+	ctx, cancel := context.WithTimeout(ctx, cameraFetchTimeout)
+	defer cancel()
+	fetch.cameras, fetch.err = c.GetCameras(ctx)
+
+	c.cacheMu.Lock()
+	if c.cameraFetch == fetch {
+		c.cameraFetch = nil
+	}
+	if fetch.err == nil && gen == c.cacheGen {
+		c.cachedCameras = fetch.cameras
+		c.cachedAt = time.Now()
+	}
+	c.cacheMu.Unlock()
+	close(fetch.done)
+}
@@ -0,0 +1,229 @@
+package camera
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Slugify
+// =============================================================================
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		// Spaces
+		{"single space", "Front Door", "front-door"},
+		{"multiple spaces collapse", "Back   Yard", "back-yard"},
+		{"leading and trailing spaces", "  Garage  ", "garage"},
+		{"tabs and newlines", "Pet\tCam\n", "pet-cam"},
+		{"existing hyphens kept", "front-door", "front-door"},
+		{"hyphen and space collapse", "Front - Door", "front-door"},
+
+		// Punctuation
+		{"apostrophe dropped", "Kid's Room", "kids-room"},
+		{"exclamation dropped", "Driveway!", "driveway"},
+		{"parentheses dropped", "Porch (Left)", "porch-left"},
+		{"underscore kept", "cam_01", "cam_01"},
+		{"digits kept", "Cam 2", "cam-2"},
+		{"only punctuation", "!!!", ""},
+
+		// Unicode
+		{"accented letters dropped", "Café Cam", "caf-cam"},
+		{"non-latin script dropped", "Камера Door", "door"},
+		{"emoji dropped", "🐶 Dog Cam", "dog-cam"},
+		{"uppercase folded", "NURSERY", "nursery"},
+
+		// Edge cases
+		{"empty", "", ""},
+		{"trailing separator", "Office -", "office"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Slugify(tt.input); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugify_Deterministic(t *testing.T) {
+	// Slugifying an already-slugged name must be a no-op so name-uris
+	// round-trip cleanly through lookups.
+	for _, name := range []string{"Front Door", "Kid's Room", "Café Cam"} {
+		once := Slugify(name)
+		if twice := Slugify(once); twice != once {
+			t.Errorf("Slugify not idempotent for %q: %q then %q", name, once, twice)
+		}
+	}
+}
+
+// =============================================================================
+// ResolveDisplayName
+// =============================================================================
+
+// newStubBridge starts a fake Wyze Bridge that serves the given /api body.
+// Returns a client pointed at it and a pointer to the request counter.
+func newStubBridge(t *testing.T, body string) (*Client, *int) {
+	t.Helper()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, ""), &calls
+}
+
+const stubCamerasBody = `{
+	"cameras": {
+		"front-door": {"name_uri": "front-door", "nickname": "Front Door", "connected": true, "enabled": true},
+		"garage":     {"name_uri": "garage", "nickname": "Garage", "connected": true, "enabled": true},
+		"garage-2":   {"name_uri": "garage-2", "nickname": "garage", "connected": false, "enabled": true}
+	}
+}`
+
+func TestResolveDisplayName_CaseInsensitive(t *testing.T) {
+	client, _ := newStubBridge(t, stubCamerasBody)
+
+	for _, input := range []string{"Front Door", "front door", "FRONT-DOOR"} {
+		got, err := client.ResolveDisplayName(context.Background(), input)
+		if err != nil {
+			t.Fatalf("ResolveDisplayName(%q) returned error: %v", input, err)
+		}
+		if got != "front-door" {
+			t.Errorf("ResolveDisplayName(%q) = %q, want 'front-door'", input, got)
+		}
+	}
+}
+
+func TestResolveDisplayName_Ambiguous(t *testing.T) {
+	client, _ := newStubBridge(t, stubCamerasBody)
+
+	_, err := client.ResolveDisplayName(context.Background(), "Garage")
+
+	var ambiguous *AmbiguousNameError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("expected AmbiguousNameError, got %v", err)
+	}
+	if len(ambiguous.Candidates) != 2 {
+		t.Errorf("expected 2 candidates, got %d", len(ambiguous.Candidates))
+	}
+}
+
+func TestResolveDisplayName_NotFound(t *testing.T) {
+	client, _ := newStubBridge(t, stubCamerasBody)
+
+	if _, err := client.ResolveDisplayName(context.Background(), "Back Yard"); err == nil {
+		t.Fatal("expected error for unknown display name")
+	}
+}
+
+func TestResolveDisplayName_UsesCachedList(t *testing.T) {
+	client, calls := newStubBridge(t, stubCamerasBody)
+
+	client.ResolveDisplayName(context.Background(), "Front Door")
+	client.ResolveDisplayName(context.Background(), "front door")
+
+	if *calls != 1 {
+		t.Errorf("expected 1 bridge request for repeated lookups, got %d", *calls)
+	}
+}
+
+func TestResolveDisplayName_SharesSlowFetch(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(stubCamerasBody))
+	}))
+	t.Cleanup(server.Close)
+	client := NewClient(server.URL, "")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := client.ResolveDisplayName(context.Background(), "Front Door"); err != nil || got != "front-door" {
+				t.Errorf("expected front-door, got %q (%v)", got, err)
+			}
+		}()
+	}
+
+	// A caller with a deadline isn't stuck behind the slow bridge
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.ResolveDisplayName(ctx, "Front Door"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the caller's deadline to end its wait, got %v", err)
+	}
+
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected concurrent lookups to share 1 bridge request, got %d", n)
+	}
+}
+
+// =============================================================================
+// GetCamera — camera list fallback
+// =============================================================================
+
+// newMismatchedKeyBridge simulates a bridge version that keys its camera list
+// differently from each camera's name_uri: /api/<name_uri> 404s, but the
+// camera is present in the /api list.
+func newMismatchedKeyBridge(t *testing.T) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"cameras": {
+				"cam0": {"name_uri": "front-door", "nickname": "Front Door", "connected": true, "enabled": true},
+				"cam1": {"name_uri": "back-yard", "nickname": "Back Yard", "connected": true, "enabled": true}
+			}
+		}`))
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, "")
+}
+
+func TestGetCamera_FallsBackToListOnMismatchedKey(t *testing.T) {
+	client := newMismatchedKeyBridge(t)
+
+	// Matched by name_uri, display name, and slug respectively.
+	for _, name := range []string{"front-door", "Front Door", "FRONT-DOOR"} {
+		cam, err := client.GetCamera(context.Background(), name)
+		if err != nil {
+			t.Fatalf("GetCamera(%q) returned error: %v", name, err)
+		}
+		if cam.NameURI != "front-door" || cam.Status != "online" {
+			t.Errorf("GetCamera(%q) = %+v, want online front-door", name, cam)
+		}
+	}
+}
+
+func TestGetCamera_FallbackNotFound(t *testing.T) {
+	client := newMismatchedKeyBridge(t)
+
+	if _, err := client.GetCamera(context.Background(), "garage"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package camera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MaxSnapshotBytes caps how much of a snapshot image is read from the
+// bridge. Wyze snapshots are well under 1 MB at 2K; anything past this is
+// an error page or a misbehaving bridge, not a picture.
+const MaxSnapshotBytes = 5 << 20
+
+// ErrSnapshotUnavailable means the bridge has no image for the camera right
+// now (camera offline, stream not started yet, or snapshots disabled).
+var ErrSnapshotUnavailable = errors.New("snapshot unavailable")
+
+// Snapshot is a still image captured from a camera by the bridge.
+type Snapshot struct {
+	Data        []byte
+	ContentType string // e.g., "image/jpeg"
+	CapturedAt  time.Time
+}
+
+// GetSnapshot asks the bridge for a fresh still image
+// (GET /snapshot/<name>.jpg on the bridge web UI).
+// Returns ErrSnapshotUnavailable (wrapped) when the bridge can't produce one.
+func (c *Client) GetSnapshot(ctx context.Context, nameURI string) (*Snapshot, error) {
+	log.Printf("📷 Fetching snapshot for camera '%s'...", nameURI)
+
+	reqURL := c.bridgeURL + "/snapshot/" + nameURI + ".jpg"
+
+	resp, err := c.get(ctx, reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Wyze Bridge: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusServiceUnavailable {
+		return nil, fmt.Errorf("%w: bridge returned status %d for camera '%s'", ErrSnapshotUnavailable, resp.StatusCode, nameURI)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bridge returned status %d for snapshot of camera '%s'", resp.StatusCode, nameURI)
+	}
+
+	// Without an image content type it's an error page, not a picture
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "image/jpeg"
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("%w: bridge returned %s instead of an image", ErrSnapshotUnavailable, contentType)
+	}
+
+	// Read one byte past the cap to tell "exactly at the limit" from "too big"
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxSnapshotBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if len(data) > MaxSnapshotBytes {
+		return nil, fmt.Errorf("snapshot for camera '%s' exceeds %d bytes", nameURI, MaxSnapshotBytes)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: bridge returned an empty image", ErrSnapshotUnavailable)
+	}
+
+	return &Snapshot{Data: data, ContentType: contentType, CapturedAt: time.Now().UTC()}, nil
+}
@@ -0,0 +1,89 @@
+package camera
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// StatusMonitor periodically lists the bridge's cameras and reports those
+// that go from online to offline, e.g. for a camera.offline notification.
+//
+// It runs on its own, independent of the stream watchdog, so offline
+// cameras are noticed even when the watchdog is disabled or has stopped.
+type StatusMonitor struct {
+	client   *Client
+	interval time.Duration
+
+	mu        sync.Mutex
+	wasOnline map[string]bool // Status seen on the previous check, keyed by NameURI
+
+	// Called when a camera that was online on the previous check no longer
+	// is. Set via OnOffline before Start.
+	onOffline func(Camera)
+}
+
+// NewStatusMonitor creates a monitor that checks camera status every interval.
+func NewStatusMonitor(client *Client, interval time.Duration) *StatusMonitor {
+	return &StatusMonitor{
+		client:    client,
+		interval:  interval,
+		wasOnline: make(map[string]bool),
+	}
+}
+
+// OnOffline registers a callback invoked when a camera goes from online to
+// offline between two checks. Must be called before Start.
+func (m *StatusMonitor) OnOffline(fn func(Camera)) {
+	m.onOffline = fn
+}
+
+// Start runs the check loop in a background goroutine until ctx is cancelled.
+// The first check only records which cameras are online.
+func (m *StatusMonitor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		m.check(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.check(ctx)
+			}
+		}
+	}()
+}
+
+// check lists the cameras once and notes each one's status. A failed list
+// is logged and skipped; it says nothing about individual cameras.
+func (m *StatusMonitor) check(ctx context.Context) {
+	cameras, err := m.client.GetCameras(ctx)
+	if err != nil {
+		log.Printf("⚠️  Camera status monitor: failed to list cameras: %v", err)
+		return
+	}
+	for _, cam := range cameras {
+		m.noteStatus(cam)
+	}
+}
+
+// noteStatus remembers whether the camera is online and calls the offline
+// hook if it was online on the previous check but isn't anymore.
+func (m *StatusMonitor) noteStatus(cam Camera) {
+	online := cam.Status == "online"
+	m.mu.Lock()
+	wentOffline := m.wasOnline[cam.NameURI] && !online
+	m.wasOnline[cam.NameURI] = online
+	m.mu.Unlock()
+
+	if wentOffline {
+		log.Printf("⚠️  Camera '%s' went offline", cam.NameURI)
+		if m.onOffline != nil {
+			m.onOffline(cam)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package camera
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStatusMonitor_ReportsCamerasGoingOffline(t *testing.T) {
+	var connected atomic.Bool
+	connected.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"cameras": {
+			"cam0": {"name_uri": "front-door", "nickname": "Front Door", "connected": %t, "enabled": true},
+			"cam1": {"name_uri": "back-yard", "nickname": "Back Yard", "connected": false, "enabled": true}
+		}}`, connected.Load())
+	}))
+	t.Cleanup(server.Close)
+
+	monitor := NewStatusMonitor(NewClient(server.URL, ""), time.Minute)
+	var offline []string
+	monitor.OnOffline(func(cam Camera) { offline = append(offline, cam.NameURI) })
+
+	monitor.check(context.Background())
+	connected.Store(false)
+	monitor.check(context.Background())
+	monitor.check(context.Background()) // Still offline
+
+	// back-yard was never seen online, so it isn't reported
+	if len(offline) != 1 || offline[0] != "front-door" {
+		t.Errorf("expected one offline report for front-door, got %v", offline)
+	}
+}
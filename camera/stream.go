@@ -0,0 +1,98 @@
+package camera
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// How long RecoverStream waits for a restarted stream to come back, and how
+// often it re-checks in the meantime. Variables so tests can shorten them.
+var (
+	streamVerifyTimeout  = 20 * time.Second
+	streamVerifyInterval = 2 * time.Second
+)
+
+// ErrRestartUnsupported means the bridge has no restart command (older
+// docker-wyze-bridge versions), so stalled streams can't be restarted remotely.
+var ErrRestartUnsupported = errors.New("wyze Bridge does not support restarting streams")
+
+// RestartResult reports the outcome of restarting one camera's stream.
+type RestartResult struct {
+	Name      string `json:"name"`            // Camera display name
+	NameURI   string `json:"nameUri"`         // URL-safe camera name
+	Restarted bool   `json:"restarted"`       // Whether the bridge accepted the restart
+	Ready     bool   `json:"ready"`           // Whether the stream passed the readiness check afterwards
+	Error     string `json:"error,omitempty"` // Why the restart or re-check failed
+}
+
+// CheckStreamReady verifies a camera's HLS stream is actually being served:
+// the playlist must load and look like an HLS playlist. A camera can be
+// "online" in the bridge while its stream has stalled (e.g., after the camera
+// reconnects), which the app sees as a frozen frame.
+func (c *Client) CheckStreamReady(ctx context.Context, cam Camera) error {
+	resp, err := c.get(ctx, cam.Streams.HLS)
+	if err != nil {
+		return fmt.Errorf("HLS stream unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HLS stream returned status %d", resp.StatusCode)
+	}
+
+	// Every playlist starts with #EXTM3U; anything else is an error page.
+	firstLine, _ := bufio.NewReader(resp.Body).ReadString('\n')
+	if !strings.HasPrefix(strings.TrimSpace(firstLine), "#EXTM3U") {
+		return fmt.Errorf("HLS stream did not return a playlist")
+	}
+
+	return nil
+}
+
+// RestartStream asks the bridge to reconnect a camera's stream
+// (GET /api/<name>/restart). Returns ErrRestartUnsupported if the bridge
+// doesn't know the command. Callers should resolve the camera first, since
+// the bridge answers 404 for unknown cameras and unknown commands alike.
+func (c *Client) RestartStream(ctx context.Context, nameURI string) error {
+	err := c.sendCameraCommand(ctx, nameURI, "restart")
+	if errors.Is(err, errCommandNotFound) {
+		return ErrRestartUnsupported
+	}
+	return err
+}
+
+// RecoverStream restarts a camera's stream and re-checks readiness until it
+// passes or streamVerifyTimeout runs out. Used by the stream watchdog and by
+// POST /api/cameras/restart.
+func (c *Client) RecoverStream(ctx context.Context, cam Camera) (RestartResult, error) {
+	result := RestartResult{Name: cam.Name, NameURI: cam.NameURI}
+
+	if err := c.RestartStream(ctx, cam.NameURI); err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	result.Restarted = true
+	log.Printf("📷 Restarted stream for '%s' — waiting for it to come back", cam.NameURI)
+
+	deadline := time.Now().Add(streamVerifyTimeout)
+	for {
+		err := c.CheckStreamReady(ctx, cam)
+		if err == nil {
+			result.Ready = true
+			log.Printf("✅ Stream for '%s' is ready again", cam.NameURI)
+			return result, nil
+		}
+		if time.Now().After(deadline) {
+			result.Error = fmt.Sprintf("stream not ready %s after restart: %v", streamVerifyTimeout, err)
+			log.Printf("⚠️  Stream for '%s' still not ready after restart: %v", cam.NameURI, err)
+			return result, nil
+		}
+		time.Sleep(streamVerifyInterval)
+	}
+}
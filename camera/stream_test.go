@@ -0,0 +1,121 @@
+package camera
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// shortenStreamVerify makes RecoverStream give up quickly in tests.
+func shortenStreamVerify(t *testing.T) {
+	t.Helper()
+	timeout, interval := streamVerifyTimeout, streamVerifyInterval
+	streamVerifyTimeout, streamVerifyInterval = 50*time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() { streamVerifyTimeout, streamVerifyInterval = timeout, interval })
+}
+
+// newStreamStub serves a bridge whose HLS playlist only loads once the
+// camera's stream has been restarted. restartStatus is what the restart
+// command answers (404 = bridge without restart support).
+func newStreamStub(t *testing.T, restartStatus int) (*Client, Camera, *int32) {
+	t.Helper()
+	var restarts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/front-door/restart":
+			if restartStatus == http.StatusOK {
+				atomic.AddInt32(&restarts, 1)
+			}
+			w.WriteHeader(restartStatus)
+		case "/front-door/stream.m3u8":
+			if atomic.LoadInt32(&restarts) == 0 {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte("#EXTM3U\n#EXT-X-VERSION:3\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cam := Camera{Name: "Front Door", NameURI: "front-door", Status: "online", Enabled: true,
+		Streams: StreamURLs{HLS: server.URL + "/front-door/stream.m3u8"}}
+	return NewClient(server.URL, ""), cam, &restarts
+}
+
+func TestCheckStreamReady(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok/stream.m3u8":
+			w.Write([]byte("#EXTM3U\n"))
+		case "/html/stream.m3u8":
+			w.Write([]byte("<html>stream not ready</html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "")
+
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{"/ok/stream.m3u8", false},
+		{"/html/stream.m3u8", true},
+		{"/missing/stream.m3u8", true},
+	}
+	for _, tt := range tests {
+		err := client.CheckStreamReady(context.Background(), Camera{Streams: StreamURLs{HLS: server.URL + tt.path}})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error=%v, got %v", tt.path, tt.wantErr, err)
+		}
+	}
+}
+
+func TestRecoverStream_RestartsAndVerifies(t *testing.T) {
+	shortenStreamVerify(t)
+	client, cam, restarts := newStreamStub(t, http.StatusOK)
+
+	result, err := client.RecoverStream(context.Background(), cam)
+	if err != nil {
+		t.Fatalf("RecoverStream returned error: %v", err)
+	}
+	if !result.Restarted || !result.Ready {
+		t.Errorf("expected restarted and ready, got %+v", result)
+	}
+	if *restarts != 1 {
+		t.Errorf("expected 1 restart, got %d", *restarts)
+	}
+}
+
+func TestRecoverStream_Unsupported(t *testing.T) {
+	shortenStreamVerify(t)
+	client, cam, _ := newStreamStub(t, http.StatusNotFound)
+
+	result, err := client.RecoverStream(context.Background(), cam)
+	if !errors.Is(err, ErrRestartUnsupported) {
+		t.Fatalf("expected ErrRestartUnsupported, got %v", err)
+	}
+	if result.Restarted {
+		t.Error("expected Restarted=false when the bridge can't restart")
+	}
+}
+
+func TestStreamWatchdog_RestartCooldown(t *testing.T) {
+	wd := NewStreamWatchdog(nil, time.Minute)
+	if !wd.claimRestart("front-door") {
+		t.Fatal("expected first restart to be allowed")
+	}
+	if wd.claimRestart("front-door") {
+		t.Error("expected a second restart within the cooldown to be refused")
+	}
+	if !wd.claimRestart("back-yard") {
+		t.Error("expected the cooldown to be per camera")
+	}
+}
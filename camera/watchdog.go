@@ -0,0 +1,120 @@
+package camera
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// restartCooldown keeps the watchdog from restarting the same camera over
+// and over while it is genuinely broken (e.g., a dead camera that the bridge
+// still reports as connected).
+const restartCooldown = 5 * time.Minute
+
+// StreamWatchdog periodically checks the stream of every online camera and
+// restarts any that have stalled.
+//
+// If the bridge turns out not to support restarting streams, the watchdog
+// logs it once and stops — there's nothing else it can do.
+type StreamWatchdog struct {
+	client   *Client
+	interval time.Duration
+
+	mu          sync.Mutex
+	lastRestart map[string]time.Time // Keyed by camera NameURI
+
+	// Optional hook called after each restart attempt (e.g., to publish an
+	// SSE notice). Set via OnRestart before Start.
+	onRestart func(RestartResult)
+}
+
+// NewStreamWatchdog creates a watchdog that checks streams every interval.
+func NewStreamWatchdog(client *Client, interval time.Duration) *StreamWatchdog {
+	return &StreamWatchdog{
+		client:      client,
+		interval:    interval,
+		lastRestart: make(map[string]time.Time),
+	}
+}
+
+// OnRestart registers a callback invoked with the outcome of every restart
+// the watchdog makes. Must be called before Start.
+func (wd *StreamWatchdog) OnRestart(fn func(RestartResult)) {
+	wd.onRestart = fn
+}
+
+// Start runs the check loop in a background goroutine until ctx is cancelled.
+func (wd *StreamWatchdog) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(wd.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !wd.check(ctx) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// check runs one pass over all cameras. Returns false if the watchdog
+// should stop because the bridge can't restart streams.
+func (wd *StreamWatchdog) check(ctx context.Context) bool {
+	cameras, err := wd.client.GetCameras(ctx)
+	if err != nil {
+		log.Printf("⚠️  Stream watchdog: failed to list cameras: %v", err)
+		return true
+	}
+
+	for _, cam := range cameras {
+		// Offline or disabled cameras have no stream to check
+		if cam.Status != "online" || !cam.Enabled {
+			continue
+		}
+
+		err := wd.client.CheckStreamReady(ctx, cam)
+		if err == nil {
+			continue
+		}
+
+		if !wd.claimRestart(cam.NameURI) {
+			log.Printf("⚠️  Stream watchdog: '%s' still stalled (%v) — restarted recently, waiting", cam.NameURI, err)
+			continue
+		}
+
+		log.Printf("⚠️  Stream watchdog: '%s' is online but its stream is stalled (%v) — restarting", cam.NameURI, err)
+		result, err := wd.client.RecoverStream(ctx, cam)
+		if errors.Is(err, ErrRestartUnsupported) {
+			log.Printf("❌ Stream watchdog stopped: %v", err)
+			return false
+		}
+		if err != nil {
+			log.Printf("❌ Stream watchdog: failed to restart '%s': %v", cam.NameURI, err)
+		}
+
+		if wd.onRestart != nil {
+			wd.onRestart(result)
+		}
+	}
+	return true
+}
+
+// claimRestart records a restart for the camera unless it was restarted
+// within restartCooldown.
+func (wd *StreamWatchdog) claimRestart(nameURI string) bool {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+
+	if last, ok := wd.lastRestart[nameURI]; ok && time.Since(last) < restartCooldown {
+		return false
+	}
+	wd.lastRestart[nameURI] = time.Now()
+	return true
+}
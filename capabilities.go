@@ -0,0 +1,56 @@
+package main
+
+import (
+	"github.com/pantheon/artemis/config"
+	"github.com/pantheon/artemis/handlers"
+)
+
+// buildCapabilities describes the running server for /api/capabilities.
+// statePolling is passed in because the poller can be started implicitly
+// (by the MQTT bridge or the retry queue), not only by its own setting, and
+// clipCapture because it depends on ffmpeg being installed.
+func buildCapabilities(cfg *config.Config, statePolling, clipCapture bool) handlers.Capabilities {
+	groupBy := []string{}
+	if cfg.EnableGovee {
+		groupBy = handlers.GroupByValues
+	}
+
+	return handlers.Capabilities{
+		APIVersion:       handlers.APIVersion,
+		APIBasePath:      cfg.APIBasePath,
+		ResponseEnvelope: cfg.ResponseEnvelope,
+		IfMatchRequired:  cfg.RequireIfMatch,
+		Auth: handlers.AuthCapabilities{
+			Required: false,
+			Admin:    cfg.AdminToken != "",
+		},
+		Integrations: handlers.IntegrationCapabilities{
+			Govee:     cfg.EnableGovee,
+			FireTV:    cfg.EnableFireTV,
+			Cameras:   cfg.EnableCameras,
+			Webhooks:  cfg.EnableWebhooks,
+			MQTT:      cfg.EnableGovee && cfg.MQTTBrokerURL != "",
+			Dashboard: cfg.EnableDashboard,
+		},
+		Features: handlers.FeatureCapabilities{
+			Profiles:       true,
+			Rooms:          true,
+			RoomScenes:     true,
+			RoomApply:      cfg.EnableGovee,
+			DevicePresets:  cfg.EnableGovee,
+			DeviceGroupBy:  groupBy,
+			DeviceEvents:   true,
+			StatePolling:   statePolling,
+			CommandRetry:   cfg.EnableGovee && cfg.GoveeCommandRetry,
+			PartyMode:      cfg.EnableGovee,
+			Diagnostics:    cfg.EnableGovee,
+			CommandProbes:  cfg.EnableGovee,
+			DeviceMetrics:  cfg.EnableGovee && cfg.GoveeDeviceMetrics,
+			WakeOnLAN:      cfg.EnableFireTV,
+			RawKeycodes:    cfg.EnableFireTV && cfg.FireTVAllowRawKeycodes,
+			StreamWatchdog: cfg.EnableCameras && cfg.CameraStreamWatchdogInterval > 0,
+			ClipCapture:    cfg.EnableCameras && clipCapture,
+			DailyReport:    cfg.EnableDailyReport,
+		},
+	}
+}
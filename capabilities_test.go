@@ -0,0 +1,61 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/config"
+)
+
+func TestBuildCapabilities_ReflectsConfig(t *testing.T) {
+	cfg := &config.Config{
+		APIBasePath:                  "/api",
+		AdminToken:                   "secret",
+		EnableGovee:                  true,
+		EnableFireTV:                 false,
+		EnableCameras:                true,
+		MQTTBrokerURL:                "tcp://localhost:1883",
+		GoveeCommandRetry:            true,
+		CameraStreamWatchdogInterval: time.Minute,
+	}
+
+	caps := buildCapabilities(cfg, true, true)
+
+	if caps.APIBasePath != "/api" || caps.APIVersion == "" {
+		t.Errorf("unexpected API info: %q %q", caps.APIVersion, caps.APIBasePath)
+	}
+	if !caps.Auth.Admin || caps.Auth.Required {
+		t.Errorf("unexpected auth: %+v", caps.Auth)
+	}
+	if !caps.Integrations.Govee || caps.Integrations.FireTV || !caps.Integrations.Cameras || !caps.Integrations.MQTT {
+		t.Errorf("unexpected integrations: %+v", caps.Integrations)
+	}
+	if !caps.Features.StatePolling || !caps.Features.CommandRetry || !caps.Features.StreamWatchdog || !caps.Features.ClipCapture {
+		t.Errorf("expected polling, retry, watchdog and clips: %+v", caps.Features)
+	}
+	if caps.Features.WakeOnLAN || caps.Features.RawKeycodes {
+		t.Errorf("expected Fire TV features off with Fire TV disabled: %+v", caps.Features)
+	}
+	if !slices.Contains(caps.Features.DeviceGroupBy, "room") {
+		t.Errorf("expected room grouping, got %v", caps.Features.DeviceGroupBy)
+	}
+}
+
+func TestBuildCapabilities_GoveeDisabled(t *testing.T) {
+	cfg := &config.Config{
+		EnableGovee:       false,
+		MQTTBrokerURL:     "tcp://localhost:1883",
+		GoveeCommandRetry: true,
+	}
+
+	caps := buildCapabilities(cfg, false, false)
+
+	// MQTT and the retry queue only run on top of the Govee clients
+	if caps.Integrations.MQTT || caps.Features.CommandRetry || caps.Features.PartyMode || caps.Features.RoomApply {
+		t.Errorf("expected Govee-dependent features off: %+v %+v", caps.Integrations, caps.Features)
+	}
+	if caps.Features.DeviceGroupBy == nil || len(caps.Features.DeviceGroupBy) != 0 {
+		t.Errorf("expected an empty (non-nil) groupBy list, got %#v", caps.Features.DeviceGroupBy)
+	}
+}
@@ -1,55 +1,435 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// ShutdownAction is a Govee command sent during graceful shutdown, e.g. to
+// leave lights off after a nightly restart. Same shape as a control request:
+// {"deviceId": "AA:BB:...", "model": "H6008", "command": "turn", "value": false}
+//
+// With room instead of deviceId, the command goes to every Govee light in
+// the room, as for a room apply: {"room": "Living Room", "command": "turn",
+// "value": false}. Each light's model and account come from the device list.
+type ShutdownAction struct {
+	APIKeyIndex int         `json:"apiKeyIndex"` // 0 = primary, 1 = secondary
+	DeviceID    string      `json:"deviceId"`
+	Model       string      `json:"model"`
+	Room        string      `json:"room,omitempty"`      // Room ID or name, instead of deviceId
+	ProfileID   string      `json:"profileId,omitempty"` // Disambiguates a room name used in several profiles
+	Command     string      `json:"command"` // "turn", "brightness", "color", or "colorTem"
+	Value       interface{} `json:"value"`
+	TimeoutMs   int         `json:"timeoutMs,omitempty"` // Give up on this device sooner; 0 = the whole shutdown timeout
+}
+
+// FireTVService is one Python Fire TV service. Households with several
+// network segments (VLANs) run one per segment; commands for a host in one
+// of a service's subnets are sent to that service.
+type FireTVService struct {
+	URL     string   `json:"url"`
+	Subnets []string `json:"subnets,omitempty"` // CIDRs or single IPs, e.g. "192.168.20.0/24"
+}
+
 // Config holds all configuration for the application
 type Config struct {
-	Port                  string
-	Host                  string
-	Environment           string
-	APIBasePath           string
-	EnableRequestLogging  bool
+	Port                 string
+	Host                 string
+	Environment          string
+	APIBasePath          string
+	EnableRequestLogging bool
+
+	// How much the server logs about itself: "info" prints a one-line route
+	// summary at startup, "debug" lists every route. Default: "info"
+	LogLevel string
+
+	// Log request and response bodies for API routes (debugging aid).
+	// Values of sensitive JSON fields (API keys, PINs, tokens) are redacted
+	// and non-JSON bodies are never printed. Never enable in production.
+	// Default: false
+	LogBodies bool
+
+	// Maximum number of bytes of each body printed when LogBodies is on.
+	// Default: 2048
+	LogBodyMaxBytes int
+
+	// Path of a Unix domain socket to listen on instead of Host:Port
+	// (e.g., "/run/artemis.sock"). Useful when a reverse proxy on the same
+	// host (nginx, Caddy) fronts Artemis. Empty = listen on TCP.
+	ListenSocket string
+
+	// Reverse proxies allowed to report the real client IP via
+	// X-Forwarded-For / X-Real-IP (comma-separated CIDRs or IPs,
+	// e.g., "127.0.0.1,10.0.0.0/8"). When empty, forwarding headers are
+	// ignored and the TCP peer address is logged as the client.
+	TrustedProxies []string
+
+	// Shared key every API request must carry in ServerAPIKeyHeader, except
+	// /health. Empty (the default) leaves the API open, as before.
+	ServerAPIKey       string
+	ServerAPIKeyHeader string // Default: "X-API-Key"
+
+	// Bearer token required by the /admin endpoints (backup export/import).
+	// Clients send "Authorization: Bearer <token>". When empty the admin
+	// endpoints are disabled.
+	AdminToken string
+
+	// Wrap list responses (device, profile and room lists, device search) in
+	// a uniform {"success", "data", "message"} envelope instead of returning
+	// bare arrays. Default: false (current shapes, for existing clients)
+	ResponseEnvelope bool
+
+	// Answer 400 when ?fields= names a field the listed items don't have,
+	// instead of leaving it out. Default: false (unknown fields are ignored)
+	ResponseFieldsStrict bool
+
+	// Reject updates to rooms and device presets that don't send If-Match
+	// with the object's ETag (428), so concurrent edits can't silently
+	// overwrite each other. Default: true (false honors If-Match only when
+	// sent, for clients that predate versions)
+	RequireIfMatch bool
+
+	// OTLP/HTTP collector that request traces are exported to (e.g.,
+	// "http://localhost:4318"). When empty, tracing is off and adds no overhead.
+	OTelExporterEndpoint string
+
+	// Circuit breakers for the Govee, Fire TV, and Wyze Bridge clients: after
+	// this many consecutive failed requests, requests to that upstream fail
+	// fast for UpstreamBreakerCooldown, then one trial request tests whether
+	// it recovered. 0 disables the breakers. Default: 5 failures, 30s
+	UpstreamBreakerThreshold int
+	UpstreamBreakerCooldown  time.Duration
+
+	// With fail-fast (the default), routes that need an upstream answer 503
+	// straight away while its breakers are open, and its clients don't send
+	// requests. Set UPSTREAM_FAIL_FAST=false to always try instead; the
+	// breakers then only report health. Default: true
+	UpstreamFailFast bool
+
+	// Control and command endpoints remember their response to a request
+	// with an Idempotency-Key header for this long, and replay it when the
+	// same key is sent again, so a client retry doesn't apply a command
+	// twice. At most IdempotencyMaxKeys keys are kept; the oldest are
+	// dropped first. A TTL of 0 disables idempotency keys.
+	// Default: 5m, 1000 keys
+	IdempotencyTTL     time.Duration
+	IdempotencyMaxKeys int
+
+	// Longest deadline a client may set with the X-Request-Timeout-Ms
+	// header; longer requested deadlines are clamped to it. 0 ignores the
+	// header. Default: 30s
+	MaxRequestTimeout time.Duration
+
+	// Per-integration switches. A disabled integration has no client, no
+	// startup health check, and its routes answer 404 "feature disabled".
+	// All default to true so existing deployments are unaffected.
+	EnableGovee   bool
+	EnableFireTV  bool
+	EnableCameras bool
 
 	// Govee Smart Light Integration
 	// Primary API key from https://developer.govee.com
 	// Required to control Govee smart lights and devices
-	GoveeAPIKey           string
+	GoveeAPIKey string
 
 	// Secondary Govee API key (optional)
 	// Used to access devices from a second Govee account (e.g., spouse's account)
 	// If set, devices from both accounts will be combined in the UI
-	GoveeAPIKeySecondary  string
+	GoveeAPIKeySecondary string
+
+	// Which Govee API to use: "v1" (developer-api.govee.com) or "v2"
+	// (the capability-based platform API at openapi.api.govee.com).
+	// Applies to both API keys. Default: "v1"
+	GoveeAPIVersion string
+
+	// Proxy for requests to the Govee cloud only (e.g.,
+	// "http://proxy.corp:3128"), overriding HTTP_PROXY/HTTPS_PROXY for
+	// Govee. Every other client keeps using the environment's proxy
+	// settings, and NO_PROXY exempts local services. Default: "" (use the
+	// environment)
+	GoveeProxyURL string
+
+	// Labels for each Govee account, in API key order (primary first), e.g.
+	// "Mine,Shared". When a device name exists in more than one account,
+	// the device list shows it as "Mine: Bedroom Light". Empty disables this.
+	GoveeAccountLabels []string
+
+	// Where the account label goes: "prefix" ("Mine: Bedroom Light") or
+	// "suffix" ("Bedroom Light (Mine)"). Default: "prefix"
+	GoveeAccountLabelPosition string
+
+	// How often the background state poller refreshes the shared device-state
+	// cache for retrievable Govee devices (e.g., "30s", "1m").
+	// Set to 0 to disable polling — state reads then always query Govee directly.
+	// Default: 0 (disabled)
+	GoveeStatePollInterval time.Duration
+
+	// How long a polled device state is served from the cache before it is
+	// considered stale. Default: 0 (twice the poll interval)
+	GoveeStateCacheTTL time.Duration
+
+	// Room scenes and gradients skip devices the state poller last saw
+	// offline instead of spending a rate-limited command on each; requests
+	// can override it with ?skipOffline=. Only applies while the poller runs.
+	// Default: true
+	GoveeBatchSkipOffline bool
+
+	// Serve per-device gauges (power, brightness, reachability) from the
+	// state poller's cache at /api/metrics for Prometheus. Off by default
+	// since it adds a series per device; enabling it also enables the state
+	// poller if it's off. Default: false
+	GoveeDeviceMetrics bool
+
+	// Offline retry queue (optional). When enabled, a control command that
+	// fails because the device is offline is held (latest command per device
+	// only) and retried once the state poller sees the device reachable again.
+	// Enabling this also enables the state poller if it's off.
+	// Default: false
+	GoveeCommandRetry bool
+
+	// How long a queued command waits for its device before being dropped.
+	// Default: 2m
+	GoveeCommandRetryMaxAge time.Duration
+
+	// Retries made once a device looks reachable before giving up.
+	// Default: 3
+	GoveeCommandRetryMaxAttempts int
+
+	// Publish device.offline when the state poller sees a device become
+	// unreachable, and device.online when it comes back. Enabling this also
+	// enables the state poller if it's off. Default: false
+	GoveeOfflineAlerts bool
+
+	// How long a device must stay unreachable before device.offline is
+	// published, so brief blips stay quiet. Default: 2m
+	GoveeOfflineAlertGrace time.Duration
+
+	// Debounce window for brightness and color commands (e.g., "150ms").
+	// Commands for the same device arriving within it are merged and only
+	// the latest is sent, so dragging a slider doesn't burn through Govee's
+	// rate limit. The final value is always sent. Set to 0 to disable.
+	// Default: 0 (disabled)
+	GoveeCoalesceWindow time.Duration
+
+	// Strict-serial mode for the primary and secondary API keys: every Govee
+	// request for that key waits its turn in a single queue, starting no
+	// sooner than GoveeStrictSerialSpacing after the previous one. For
+	// accounts that get rate limited by bursts even under 60/minute.
+	// Default: false (requests are sent concurrently)
+	GoveeStrictSerial          bool
+	GoveeStrictSerialSecondary bool
+
+	// Minimum time between the starts of two requests in strict-serial mode.
+	// Default: 1s
+	GoveeStrictSerialSpacing time.Duration
+
+	// Client-side limit on Govee requests per minute, per API key, shared by
+	// device lists, state reads, and commands. Requests over the budget wait
+	// for it to refill, or fail at once with GoveeRateLimitFailFast. Set to 0
+	// to disable. Default: 60 (Govee's documented limit)
+	GoveeRateLimit         int
+	GoveeRateLimitFailFast bool
+
+	// How many times a Govee device list or control command is retried after
+	// a 5xx, a timeout, or a refused connection, with exponential backoff
+	// from 200ms. 4xx answers are never retried. Set to 0 to disable.
+	// Default: 3
+	GoveeRetries int
+
+	// How long each Govee client reuses its device list before asking Govee
+	// again. Device listing, search, and device ID checks share it;
+	// GET /api/govee/devices?fresh=true refreshes it. Set to 0 to always
+	// fetch. Default: 60s
+	GoveeDeviceCacheTTL time.Duration
+
+	// Whether control requests are checked against the cached device list
+	// (device ID, model, supported commands) before they're sent. Turn off
+	// if the list goes stale, e.g. a device's commands change. Default: true
+	GoveeDeviceCheck bool
+
+	// File the optimistic device states (the state implied by the last
+	// successful commands, served for devices that can't report their own)
+	// are saved to, so they survive restarts. Empty keeps them in memory only.
+	// Default: "" (memory only)
+	GoveeOptimisticStateFile string
+
+	// File per-device presets (named states such as "Reading") are saved
+	// to. Default: "presets.json"
+	GoveePresetsFile string
+
+	// File macros (named step lists across lights, Fire TV, and cameras)
+	// are saved to. Default: "macros.json"
+	MacrosFile string
+
+	// Number of recent events each SSE stream keeps for replay when a client
+	// reconnects with Last-Event-ID. Older events are dropped and the client
+	// is told it may have missed updates.
+	// Default: 100
+	EventBufferSize int
+
+	// How often idle SSE streams send a heartbeat comment so NATs and
+	// proxies don't drop the connection (many cut idle connections at ~60s).
+	// Set to 0 to disable. Default: 25s
+	SSEHeartbeatInterval time.Duration
+
+	// Outbound webhooks: /api/webhooks registers URLs that device and
+	// camera events are POSTed to. Off by default, since anyone who can
+	// reach the API could then have events sent anywhere.
+	// Default: false
+	EnableWebhooks bool
+
+	// File registered webhooks (including their signing secrets) are
+	// saved to. Default: "webhooks.json"
+	WebhooksFile string
+
+	// Browser dashboard at /dashboard/ (and a redirect from /), for users
+	// without the iOS app. It has the same access as the API it calls.
+	// Default: false
+	EnableDashboard bool
+
+	// Daily report (optional). Samples light and camera states through the
+	// day, takes a snapshot once a day, and serves a comparison of the last
+	// two at /api/reports/daily-diff. Enabling this also enables the state
+	// poller if it's off. Default: false
+	EnableDailyReport bool
+
+	// Local time of day ("HH:MM", 24-hour) the daily snapshot is taken.
+	// Default: "00:00"
+	DailyReportTime string
+
+	// File daily snapshots are saved to. Default: "daily_report.json"
+	DailyReportFile string
+
+	// How many daily snapshots to keep (at least 2). Default: 7
+	DailyReportKeep int
+
+	// MQTT Bridge (optional)
+	// Broker URL for the Home Assistant / MQTT integration
+	// (e.g., "tcp://192.168.1.10:1883"). Leave empty to disable the bridge.
+	// When enabled, Artemis subscribes to <prefix>/govee/<deviceId>/set for
+	// commands and publishes device state to <prefix>/govee/<deviceId>/state.
+	MQTTBrokerURL string
+
+	// Credentials for the MQTT broker (optional — leave empty for anonymous)
+	MQTTUsername string
+	MQTTPassword string
+
+	// Client ID Artemis connects with. Must be unique per broker.
+	// Default: artemis
+	MQTTClientID string
+
+	// Root of every topic the bridge uses. Default: artemis
+	MQTTTopicPrefix string
+
+	// How often device state is published to MQTT. If the state poller is
+	// disabled, it is started at this interval while the bridge is enabled.
+	// Default: 30s
+	MQTTStateInterval time.Duration
 
 	// Fire TV Remote Integration
 	// URL of the Python Fire TV microservice that handles device communication.
 	// The Python service runs locally and uses the Android TV Remote protocol v2
 	// to discover, pair with, and control Fire TV devices on the LAN.
 	// Default: http://localhost:9090
-	FireTVServiceURL      string
+	FireTVServiceURL string
+
+	// Every Fire TV service, in order. Set with FIRETV_SERVICES as a JSON
+	// array, e.g. [{"url": "http://10.0.20.5:9090", "subnets": ["10.0.20.0/24"]}].
+	// When unset, the only service is FireTVServiceURL.
+	FireTVServices []FireTVService
+
+	// Allow POST /api/firetv/command to send raw Android keycodes
+	// ({"keycode": 85}), bypassing the named-command allowlist. For advanced
+	// users who need keys without a named command. Default: false
+	FireTVAllowRawKeycodes bool
+
+	// How long POST /api/firetv/search waits after launching an app before
+	// navigating to its search box. Slow apps need longer; requests can
+	// override it with launchDelayMs. Default: 3s
+	FireTVSearchLaunchDelay time.Duration
 
 	// Wyze Camera Bridge Integration
 	// URL of the Docker Wyze Bridge web UI / REST API.
 	// The bridge runs as a Docker container and provides camera info at /api/
 	// and streams via HLS (port 8888), RTSP (port 8554), and WebRTC (port 8889).
 	// Default: http://localhost:5050
-	WyzeBridgeURL         string
+	WyzeBridgeURL string
 
 	// Optional API key for the Wyze Bridge.
 	// Only required if WB_AUTH is enabled on the bridge container.
 	// Must match the WYZE_BRIDGE_API_KEY set in the bridge's environment.
-	WyzeBridgeAPIKey      string
+	WyzeBridgeAPIKey string
+
+	// How the API key is sent to the bridge: "query" (?api=<key> on every
+	// URL), "header" (Authorization: Bearer <key>), or "basic" (HTTP basic
+	// auth with WyzeBridgeUsername and the key as password). Header and basic
+	// keep the key out of URLs and proxy logs, but need a bridge version that
+	// accepts them. Default: "query"
+	WyzeBridgeAuthMode string
+
+	// Basic auth username for WYZE_BRIDGE_AUTH_MODE=basic
+	WyzeBridgeUsername string
+
+	// name-uri of the camera the app's home screen shows for quick-view
+	// (GET /api/cameras/default). When unset or offline, the first online
+	// camera is used instead. Default: "" (always the first online camera)
+	DefaultCamera string
+
+	// How often the stream watchdog checks that every online camera's HLS
+	// stream is actually being served, restarting any that have stalled.
+	// 0 disables the watchdog. Default: 0
+	CameraStreamWatchdogInterval time.Duration
+
+	// How often camera status is checked for cameras that went offline,
+	// published as camera.offline events (SSE and webhooks).
+	// 0 disables the check. Default: 1m
+	CameraStatusInterval time.Duration
+
+	// Directory POST /api/cameras/capture-clip writes clips to (created on
+	// the first capture). Capturing needs ffmpeg on the PATH.
+	// Default: "./clips"
+	CameraClipDir string
+
+	// Longest clip a capture may ask for. Default: 60s
+	CameraClipMaxDuration time.Duration
+
+	// How many clips may be captured at once; more answer 503. Default: 2
+	CameraClipMaxConcurrent int
+
+	// Require a bearer token on the camera endpoints and limit each token to
+	// the cameras its ACL lists (managed under /api/cameras/acls with
+	// ADMIN_TOKEN, which sees every camera). Inactive while ADMIN_TOKEN is
+	// unset. Default: false
+	EnableCameraACL bool
+
+	// JSON file the camera ACLs are saved to. Holds the tokens, so it's
+	// written readable by its owner only. Default: "camera_acls.json"
+	CameraACLFile string
+
+	// How long a graceful shutdown (SIGINT/SIGTERM) waits for in-flight
+	// requests to finish before closing their connections. Default: 10s
+	ShutdownDrainTimeout time.Duration
+
+	// Govee commands to run when the server shuts down cleanly, as a JSON
+	// array of ShutdownAction. Empty (the default) runs nothing.
+	ShutdownActions []ShutdownAction
+
+	// Upper bound on how long shutdown waits for ShutdownActions, so a slow
+	// or unreachable Govee API can't hang termination. Default: 5s
+	ShutdownActionsTimeout time.Duration
 
 	// Database Configuration
 	// Path to the SQLite database file for storing profiles, rooms, and devices.
 	// Use ":memory:" for an ephemeral in-memory database (useful for testing).
 	// Default: ./pantheon.db
-	DBPath                string
+	DBPath string
 }
 
 // Load reads configuration from environment variables
@@ -59,17 +439,109 @@ func Load() (*Config, error) {
 	_ = godotenv.Load()
 
 	cfg := &Config{
-		Port:                  getEnv("PORT", "8080"),
-		Host:                  getEnv("HOST", "0.0.0.0"),
-		Environment:           getEnv("ENVIRONMENT", "development"),
-		APIBasePath:           getEnv("API_BASE_PATH", "/api"),
-		EnableRequestLogging:  getEnvAsBool("ENABLE_REQUEST_LOGGING", true),
-		GoveeAPIKey:           getEnv("GOVEE_API_KEY", ""),
-		GoveeAPIKeySecondary:  getEnv("GOVEE_API_KEY_SECONDARY", ""),
-		FireTVServiceURL:      getEnv("FIRETV_SERVICE_URL", "http://localhost:9090"),
-		WyzeBridgeURL:         getEnv("WYZE_BRIDGE_URL", "http://localhost:5050"),
-		WyzeBridgeAPIKey:      getEnv("WYZE_BRIDGE_API_KEY", ""),
-		DBPath:                getEnv("DB_PATH", "./pantheon.db"),
+		Port:                         getEnv("PORT", "8080"),
+		Host:                         getEnv("HOST", "0.0.0.0"),
+		Environment:                  getEnv("ENVIRONMENT", "development"),
+		APIBasePath:                  getEnv("API_BASE_PATH", "/api"),
+		EnableRequestLogging:         getEnvAsBool("ENABLE_REQUEST_LOGGING", true),
+		LogLevel:                     strings.ToLower(getEnv("LOG_LEVEL", "info")),
+		ListenSocket:                 getEnv("LISTEN_SOCKET", ""),
+		LogBodies:                    getEnvAsBool("LOG_BODIES", false),
+		LogBodyMaxBytes:              getEnvAsInt("LOG_BODY_MAX_BYTES", 2048),
+		TrustedProxies:               getEnvAsList("TRUSTED_PROXIES"),
+		ServerAPIKey:                 getEnv("SERVER_API_KEY", ""),
+		ServerAPIKeyHeader:           getEnv("SERVER_API_KEY_HEADER", "X-API-Key"),
+		AdminToken:                   getEnv("ADMIN_TOKEN", ""),
+		ResponseEnvelope:             getEnvAsBool("RESPONSE_ENVELOPE", false),
+		ResponseFieldsStrict:         getEnvAsBool("RESPONSE_FIELDS_STRICT", false),
+		RequireIfMatch:               getEnvAsBool("REQUIRE_IF_MATCH", true),
+		OTelExporterEndpoint:         getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		UpstreamBreakerThreshold:     getEnvAsInt("UPSTREAM_BREAKER_THRESHOLD", 5),
+		UpstreamBreakerCooldown:      getEnvAsDuration("UPSTREAM_BREAKER_COOLDOWN", 30*time.Second),
+		UpstreamFailFast:             getEnvAsBool("UPSTREAM_FAIL_FAST", true),
+		IdempotencyTTL:               getEnvAsDuration("IDEMPOTENCY_TTL", 5*time.Minute),
+		IdempotencyMaxKeys:           getEnvAsInt("IDEMPOTENCY_MAX_KEYS", 1000),
+		MaxRequestTimeout:            getEnvAsDuration("MAX_REQUEST_TIMEOUT", 30*time.Second),
+		EnableGovee:                  getEnvAsBool("ENABLE_GOVEE", true),
+		EnableFireTV:                 getEnvAsBool("ENABLE_FIRETV", true),
+		EnableCameras:                getEnvAsBool("ENABLE_CAMERAS", true),
+		GoveeAPIKey:                  getEnv("GOVEE_API_KEY", ""),
+		GoveeAPIKeySecondary:         getEnv("GOVEE_API_KEY_SECONDARY", ""),
+		GoveeAPIVersion:              getEnv("GOVEE_API_VERSION", "v1"),
+		GoveeProxyURL:                getEnv("GOVEE_PROXY_URL", ""),
+		GoveeAccountLabels:           getEnvAsList("GOVEE_ACCOUNT_LABELS"),
+		GoveeAccountLabelPosition:    getEnv("GOVEE_ACCOUNT_LABEL_POSITION", "prefix"),
+		GoveeStatePollInterval:       getEnvAsDuration("GOVEE_STATE_POLL_INTERVAL", 0),
+		GoveeStateCacheTTL:           getEnvAsDuration("GOVEE_STATE_CACHE_TTL", 0),
+		GoveeBatchSkipOffline:        getEnvAsBool("GOVEE_BATCH_SKIP_OFFLINE", true),
+		GoveeDeviceMetrics:           getEnvAsBool("GOVEE_DEVICE_METRICS", false),
+		GoveeCommandRetry:            getEnvAsBool("GOVEE_COMMAND_RETRY", false),
+		GoveeCommandRetryMaxAge:      getEnvAsDuration("GOVEE_COMMAND_RETRY_MAX_AGE", 2*time.Minute),
+		GoveeCommandRetryMaxAttempts: getEnvAsInt("GOVEE_COMMAND_RETRY_MAX_ATTEMPTS", 3),
+		GoveeOfflineAlerts:           getEnvAsBool("GOVEE_OFFLINE_ALERTS", false),
+		GoveeOfflineAlertGrace:       getEnvAsDuration("GOVEE_OFFLINE_ALERT_GRACE", 2*time.Minute),
+		GoveeCoalesceWindow:          getEnvAsDuration("GOVEE_COALESCE_WINDOW", 0),
+		GoveeStrictSerial:            getEnvAsBool("GOVEE_STRICT_SERIAL", false),
+		GoveeStrictSerialSecondary:   getEnvAsBool("GOVEE_STRICT_SERIAL_SECONDARY", false),
+		GoveeStrictSerialSpacing:     getEnvAsDuration("GOVEE_STRICT_SERIAL_SPACING", time.Second),
+		GoveeRateLimit:               getEnvAsInt("GOVEE_RATE_LIMIT", 60),
+		GoveeRateLimitFailFast:       getEnvAsBool("GOVEE_RATE_LIMIT_FAIL_FAST", false),
+		GoveeRetries:                 getEnvAsInt("GOVEE_RETRIES", 3),
+		GoveeDeviceCacheTTL:          getEnvAsDuration("GOVEE_DEVICE_CACHE_TTL", time.Minute),
+		GoveeDeviceCheck:             getEnvAsBool("GOVEE_DEVICE_CHECK", true),
+		GoveeOptimisticStateFile:     getEnv("GOVEE_OPTIMISTIC_STATE_FILE", ""),
+		GoveePresetsFile:             getEnv("GOVEE_PRESETS_FILE", "presets.json"),
+		MacrosFile:                   getEnv("MACROS_FILE", "macros.json"),
+		EventBufferSize:              getEnvAsInt("EVENT_BUFFER_SIZE", 100),
+		SSEHeartbeatInterval:         getEnvAsDuration("SSE_HEARTBEAT_INTERVAL", 25*time.Second),
+		EnableWebhooks:               getEnvAsBool("ENABLE_WEBHOOKS", false),
+		WebhooksFile:                 getEnv("WEBHOOKS_FILE", "webhooks.json"),
+		EnableDashboard:              getEnvAsBool("ENABLE_DASHBOARD", false),
+		EnableDailyReport:            getEnvAsBool("ENABLE_DAILY_REPORT", false),
+		DailyReportTime:              getEnv("DAILY_REPORT_TIME", "00:00"),
+		DailyReportFile:              getEnv("DAILY_REPORT_FILE", "daily_report.json"),
+		DailyReportKeep:              getEnvAsInt("DAILY_REPORT_KEEP", 7),
+		MQTTBrokerURL:                getEnv("MQTT_BROKER_URL", ""),
+		MQTTUsername:                 getEnv("MQTT_USERNAME", ""),
+		MQTTPassword:                 getEnv("MQTT_PASSWORD", ""),
+		MQTTClientID:                 getEnv("MQTT_CLIENT_ID", "artemis"),
+		MQTTTopicPrefix:              getEnv("MQTT_TOPIC_PREFIX", "artemis"),
+		MQTTStateInterval:            getEnvAsDuration("MQTT_STATE_INTERVAL", 30*time.Second),
+		FireTVServiceURL:             getEnv("FIRETV_SERVICE_URL", "http://localhost:9090"),
+		FireTVAllowRawKeycodes:       getEnvAsBool("FIRETV_ALLOW_RAW_KEYCODES", false),
+		FireTVSearchLaunchDelay:      getEnvAsDuration("FIRETV_SEARCH_LAUNCH_DELAY", 3*time.Second),
+		WyzeBridgeURL:                getEnv("WYZE_BRIDGE_URL", "http://localhost:5050"),
+		WyzeBridgeAPIKey:             getEnv("WYZE_BRIDGE_API_KEY", ""),
+		WyzeBridgeAuthMode:           getEnv("WYZE_BRIDGE_AUTH_MODE", "query"),
+		WyzeBridgeUsername:           getEnv("WYZE_BRIDGE_USERNAME", ""),
+		DefaultCamera:                getEnv("DEFAULT_CAMERA", ""),
+		CameraStreamWatchdogInterval: getEnvAsDuration("CAMERA_STREAM_WATCHDOG_INTERVAL", 0),
+		CameraStatusInterval:         getEnvAsDuration("CAMERA_STATUS_INTERVAL", time.Minute),
+		CameraClipDir:                getEnv("CAMERA_CLIP_DIR", "./clips"),
+		CameraClipMaxDuration:        getEnvAsDuration("CAMERA_CLIP_MAX_DURATION", 60*time.Second),
+		CameraClipMaxConcurrent:      getEnvAsInt("CAMERA_CLIP_MAX_CONCURRENT", 2),
+		EnableCameraACL:              getEnvAsBool("ENABLE_CAMERA_ACL", false),
+		CameraACLFile:                getEnv("CAMERA_ACL_FILE", "camera_acls.json"),
+		ShutdownDrainTimeout:         getEnvAsDuration("SHUTDOWN_DRAIN_TIMEOUT", 10*time.Second),
+		ShutdownActionsTimeout:       getEnvAsDuration("SHUTDOWN_ACTIONS_TIMEOUT", 5*time.Second),
+		DBPath:                       getEnv("DB_PATH", "./pantheon.db"),
+	}
+
+	// Unlike the other settings, a malformed action list is an error rather
+	// than falling back to a default — silently skipping it would leave
+	// lights on that the user expects to be turned off.
+	if raw := getEnv("SHUTDOWN_ACTIONS", ""); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &cfg.ShutdownActions); err != nil {
+			return nil, fmt.Errorf("SHUTDOWN_ACTIONS must be a JSON array of actions: %w", err)
+		}
+	}
+
+	if raw := getEnv("FIRETV_SERVICES", ""); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &cfg.FireTVServices); err != nil {
+			return nil, fmt.Errorf("FIRETV_SERVICES must be a JSON array of services: %w", err)
+		}
+	} else {
+		cfg.FireTVServices = []FireTVService{{URL: cfg.FireTVServiceURL}}
 	}
 
 	return cfg, nil
@@ -92,14 +564,144 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getEnvAsInt retrieves an environment variable as an integer
+func getEnvAsInt(key string, defaultValue int) int {
+	valStr := getEnv(key, "")
+	if val, err := strconv.Atoi(valStr); err == nil {
+		return val
+	}
+	return defaultValue
+}
+
+// getEnvAsList retrieves a comma-separated environment variable as a slice
+// Whitespace around entries is trimmed and empty entries are dropped
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, part := range strings.Split(getEnv(key, ""), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// getEnvAsDuration retrieves an environment variable as a time.Duration
+// Accepts Go duration strings like "30s", "5m", or "1h30m"
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valStr := getEnv(key, "")
+	if val, err := time.ParseDuration(valStr); err == nil {
+		return val
+	}
+	return defaultValue
+}
+
+// ParseProxyURL parses a proxy setting the way http.ProxyFromEnvironment
+// does: a bare "host:port" means an http:// proxy. The scheme must be http,
+// https, socks5, or socks5h.
+func ParseProxyURL(raw string) (*url.URL, error) {
+	proxyURL, err := url.Parse(raw)
+	if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+		// Not an absolute URL; try it as a host:port
+		if withScheme, err := url.Parse("http://" + raw); err == nil {
+			proxyURL = withScheme
+		}
+	}
+	if proxyURL == nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", raw)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("proxy URL %q must use http, https, socks5, or socks5h", proxyURL.Redacted())
+	}
+	return proxyURL, nil
+}
+
 // GetAddress returns the full address string for the server
 func (c *Config) GetAddress() string {
 	return fmt.Sprintf("%s:%s", c.Host, c.Port)
 }
 
+// GetListenDescription returns a human-readable description of where the
+// server listens, for startup logs: the socket path or the TCP URL.
+func (c *Config) GetListenDescription() string {
+	if c.ListenSocket != "" {
+		return "unix:" + c.ListenSocket
+	}
+	return "http://" + c.GetAddress()
+}
+
 // Validate checks that all required configuration values are present
 // Returns an error if any critical configuration is missing
 func (c *Config) Validate() error {
+	if c.LogLevel != "info" && c.LogLevel != "debug" {
+		return fmt.Errorf("LOG_LEVEL must be \"info\" or \"debug\", got %q", c.LogLevel)
+	}
+
+	// Outbound clients use the environment's proxy (http.ProxyFromEnvironment),
+	// which only rejects a malformed one on the first request
+	for _, name := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy"} {
+		if value := os.Getenv(name); value != "" {
+			if _, err := ParseProxyURL(value); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+
+	if c.UpstreamBreakerThreshold > 0 && c.UpstreamBreakerCooldown <= 0 {
+		return fmt.Errorf("UPSTREAM_BREAKER_COOLDOWN must be positive when UPSTREAM_BREAKER_THRESHOLD is set")
+	}
+
+	if c.IdempotencyTTL > 0 && c.IdempotencyMaxKeys <= 0 {
+		return fmt.Errorf("IDEMPOTENCY_MAX_KEYS must be positive when IDEMPOTENCY_TTL is set")
+	}
+
+	if c.EnableCameras {
+		switch c.WyzeBridgeAuthMode {
+		case "query", "header":
+		case "basic":
+			if c.WyzeBridgeUsername == "" {
+				return fmt.Errorf("WYZE_BRIDGE_USERNAME is required when WYZE_BRIDGE_AUTH_MODE=basic")
+			}
+		default:
+			return fmt.Errorf("WYZE_BRIDGE_AUTH_MODE must be \"query\", \"header\", or \"basic\", got %q", c.WyzeBridgeAuthMode)
+		}
+		if c.CameraClipMaxDuration < time.Second {
+			return fmt.Errorf("CAMERA_CLIP_MAX_DURATION must be at least 1s, got %s", c.CameraClipMaxDuration)
+		}
+		if c.CameraClipMaxConcurrent <= 0 {
+			return fmt.Errorf("CAMERA_CLIP_MAX_CONCURRENT must be positive, got %d", c.CameraClipMaxConcurrent)
+		}
+	}
+
+	if c.EnableDailyReport {
+		if _, err := time.Parse("15:04", c.DailyReportTime); err != nil {
+			return fmt.Errorf("DAILY_REPORT_TIME must be HH:MM (24-hour), got %q", c.DailyReportTime)
+		}
+		if c.DailyReportKeep < 2 {
+			return fmt.Errorf("DAILY_REPORT_KEEP must be at least 2, got %d", c.DailyReportKeep)
+		}
+	}
+
+	if c.EnableFireTV {
+		if len(c.FireTVServices) == 0 {
+			return fmt.Errorf("FIRETV_SERVICES must list at least one service")
+		}
+		for i, service := range c.FireTVServices {
+			if service.URL == "" {
+				return fmt.Errorf("FIRETV_SERVICES[%d]: url is required", i)
+			}
+		}
+		if c.FireTVSearchLaunchDelay < 0 || c.FireTVSearchLaunchDelay > 15*time.Second {
+			return fmt.Errorf("FIRETV_SEARCH_LAUNCH_DELAY must be between 0s and 15s, got %s", c.FireTVSearchLaunchDelay)
+		}
+	}
+
+	// Nothing Govee-specific is needed when the integration is switched off
+	if !c.EnableGovee {
+		return nil
+	}
+
 	// Check for Govee API key
 	// Get your API key from https://developer.govee.com
 	// 1. Sign up or log in with your Govee account
@@ -111,5 +713,50 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("GOVEE_API_KEY is required but not set in .env file")
 	}
 
+	if c.GoveeAPIVersion != "v1" && c.GoveeAPIVersion != "v2" {
+		return fmt.Errorf("GOVEE_API_VERSION must be \"v1\" or \"v2\", got %q", c.GoveeAPIVersion)
+	}
+
+	if c.GoveeRateLimit < 0 {
+		return fmt.Errorf("GOVEE_RATE_LIMIT must not be negative, got %d", c.GoveeRateLimit)
+	}
+	if c.GoveeRetries < 0 || c.GoveeRetries > 10 {
+		return fmt.Errorf("GOVEE_RETRIES must be between 0 and 10, got %d", c.GoveeRetries)
+	}
+	if c.GoveeDeviceCacheTTL < 0 {
+		return fmt.Errorf("GOVEE_DEVICE_CACHE_TTL must not be negative, got %s", c.GoveeDeviceCacheTTL)
+	}
+
+	if c.GoveeProxyURL != "" {
+		if _, err := ParseProxyURL(c.GoveeProxyURL); err != nil {
+			return fmt.Errorf("GOVEE_PROXY_URL: %w", err)
+		}
+	}
+
+	if c.ShutdownDrainTimeout <= 0 {
+		return fmt.Errorf("SHUTDOWN_DRAIN_TIMEOUT must be positive, got %s", c.ShutdownDrainTimeout)
+	}
+	for i, action := range c.ShutdownActions {
+		if action.Room != "" {
+			if action.DeviceID != "" {
+				return fmt.Errorf("SHUTDOWN_ACTIONS[%d]: set deviceId or room, not both", i)
+			}
+			if action.Command == "" {
+				return fmt.Errorf("SHUTDOWN_ACTIONS[%d]: command is required", i)
+			}
+			continue
+		}
+		if action.DeviceID == "" || action.Model == "" || action.Command == "" {
+			return fmt.Errorf("SHUTDOWN_ACTIONS[%d]: deviceId (or room), model, and command are required", i)
+		}
+		if action.APIKeyIndex < 0 || (action.APIKeyIndex > 0 && c.GoveeAPIKeySecondary == "") || action.APIKeyIndex > 1 {
+			return fmt.Errorf("SHUTDOWN_ACTIONS[%d]: apiKeyIndex %d has no configured API key", i, action.APIKeyIndex)
+		}
+	}
+
+	if c.GoveeAccountLabelPosition != "prefix" && c.GoveeAccountLabelPosition != "suffix" {
+		return fmt.Errorf("GOVEE_ACCOUNT_LABEL_POSITION must be \"prefix\" or \"suffix\", got %q", c.GoveeAccountLabelPosition)
+	}
+
 	return nil
 }
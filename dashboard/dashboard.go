@@ -0,0 +1,59 @@
+// Package dashboard serves a minimal status page for browsers, for users
+// without the iOS app. The page is plain HTML/JS embedded in the binary and
+// only calls the existing JSON API — no external scripts, fonts, or CDNs.
+package dashboard
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strconv"
+)
+
+// Path is where the dashboard is served.
+const Path = "/dashboard/"
+
+//go:embed static
+var static embed.FS
+
+// contentSecurityPolicy keeps the page to its own scripts and styles and the
+// API on the same origin. Snapshots are plain same-origin images.
+const contentSecurityPolicy = "default-src 'self'; img-src 'self' data:; object-src 'none'; base-uri 'none'; frame-ancestors 'none'"
+
+// Handler serves the dashboard under Path. apiBasePath (e.g. "/api") and
+// apiKeyHeader (SERVER_API_KEY_HEADER) are written into the page so its
+// scripts call the right endpoints and can send the API key.
+func Handler(apiBasePath, apiKeyHeader string) (http.Handler, error) {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		return nil, err
+	}
+
+	// Render the page once; it only varies by the base path and header
+	page, err := template.ParseFS(files, "index.html")
+	if err != nil {
+		return nil, err
+	}
+	var rendered bytes.Buffer
+	data := struct{ APIBasePath, APIKeyHeader string }{apiBasePath, apiKeyHeader}
+	if err := page.Execute(&rendered, data); err != nil {
+		return nil, err
+	}
+	index := rendered.Bytes()
+
+	assets := http.StripPrefix(Path, http.FileServerFS(files))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", contentSecurityPolicy)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if r.URL.Path != Path {
+			assets.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Length", strconv.Itoa(len(index)))
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(index)
+	}), nil
+}
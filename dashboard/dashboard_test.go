@@ -0,0 +1,60 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	handler, err := Handler("/api/v2", "X-Artemis-Key")
+	if err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	tests := []struct {
+		path        string
+		wantStatus  int
+		wantType    string
+		wantContent string
+	}{
+		{Path, http.StatusOK, "text/html", `<meta name="artemis-api-base" content="/api/v2">`},
+		{Path, http.StatusOK, "text/html", `<meta name="artemis-api-key-header" content="X-Artemis-Key">`},
+		{Path + "app.js", http.StatusOK, "javascript", "apiBase"},
+		{Path + "style.css", http.StatusOK, "text/css", "body"},
+		{Path + "missing.js", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if !strings.Contains(w.Header().Get("Content-Type"), tt.wantType) {
+				t.Errorf("expected a %s Content-Type, got %q", tt.wantType, w.Header().Get("Content-Type"))
+			}
+			if !strings.Contains(w.Body.String(), tt.wantContent) {
+				t.Errorf("expected the body to contain %q", tt.wantContent)
+			}
+			if w.Header().Get("Content-Security-Policy") == "" {
+				t.Error("expected a Content-Security-Policy header")
+			}
+		})
+	}
+}
+
+func TestHandler_NoExternalResources(t *testing.T) {
+	for _, name := range []string{"static/index.html", "static/app.js", "static/style.css"} {
+		data, err := static.ReadFile(name)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if strings.Contains(string(data), "http://") || strings.Contains(string(data), "https://") {
+			t.Errorf("%s references an external URL; the dashboard must be self-contained", name)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// Artemis dashboard: a thin browser front end over the JSON API.
+// Everything is built with textContent, never innerHTML, since device and
+// camera names come from third-party services.
+"use strict";
+
+const apiBase = document.querySelector('meta[name="artemis-api-base"]').content;
+const apiKeyHeader = document.querySelector('meta[name="artemis-api-key-header"]').content;
+const apiKeyStorage = "artemis-api-key";
+
+// apiKey returns the saved API key (SERVER_API_KEY), or "" when none is saved.
+function apiKey() {
+  return localStorage.getItem(apiKeyStorage) || "";
+}
+
+// askForAPIKey prompts for the API key after a 401 and saves it.
+// Returns false when the user cancels.
+function askForAPIKey() {
+  const key = window.prompt("This server needs its API key (SERVER_API_KEY):", "");
+  if (!key) return false;
+  localStorage.setItem(apiKeyStorage, key.trim());
+  return true;
+}
+
+// loadImage fetches an image endpoint with the API key header and shows it
+// in img through a blob URL, since <img src> can't send headers.
+async function loadImage(img, path) {
+  const headers = {};
+  const key = apiKey();
+  if (key) headers[apiKeyHeader] = key;
+  try {
+    const resp = await fetch(apiBase + path, { headers });
+    if (!resp.ok) throw new Error(`HTTP ${resp.status}`);
+    const url = URL.createObjectURL(await resp.blob());
+    img.addEventListener("load", () => URL.revokeObjectURL(url), { once: true });
+    img.src = url;
+  } catch (err) {
+    img.alt = `${img.alt} unavailable (${err.message})`;
+  }
+}
+
+// api calls an endpoint and returns its JSON, unwrapping the list envelope
+// (RESPONSE_ENVELOPE=true). Throws an Error with the API's message on failure.
+// On a 401 it asks for the API key once and retries.
+async function api(path, options = {}, retried = false) {
+  const init = { ...options, headers: { ...(options.headers || {}) } };
+  if (init.body !== undefined) {
+    init.headers["Content-Type"] = "application/json";
+    init.body = JSON.stringify(init.body);
+  }
+  const sentKey = apiKey();
+  if (sentKey) {
+    init.headers[apiKeyHeader] = sentKey;
+  }
+
+  const resp = await fetch(apiBase + path, init);
+  if (resp.status === 401 && !retried) {
+    // Another request may have saved a key since this one was sent
+    if (apiKey() !== sentKey || askForAPIKey()) {
+      return api(path, options, true);
+    }
+  }
+  let data = null;
+  try {
+    data = await resp.json();
+  } catch {
+    // Non-JSON body; fall through to the status check
+  }
+  if (!resp.ok) {
+    const message = (data && (data.error || data.message)) || `HTTP ${resp.status}`;
+    throw new Error(message);
+  }
+  if (data && !Array.isArray(data) && "data" in data && "success" in data) {
+    return data.data;
+  }
+  return data;
+}
+
+function el(tag, text, className) {
+  const node = document.createElement(tag);
+  if (text !== undefined) node.textContent = text;
+  if (className) node.className = className;
+  return node;
+}
+
+function button(label, onClick) {
+  const node = el("button", label);
+  node.type = "button";
+  node.addEventListener("click", async () => {
+    node.disabled = true;
+    try {
+      await onClick();
+    } finally {
+      node.disabled = false;
+    }
+  });
+  return node;
+}
+
+// section returns the status line and item list of a dashboard section.
+function section(id) {
+  const root = document.getElementById(id);
+  return { status: root.querySelector(".status"), items: root.querySelector(".items") };
+}
+
+// ---------------------------------------------------------------------------
+// Health
+// ---------------------------------------------------------------------------
+
+async function loadHealth() {
+  const badge = document.getElementById("health");
+  try {
+    const health = await api("/health");
+    badge.textContent = health.status || "healthy";
+    badge.className = "badge ok";
+  } catch (err) {
+    badge.textContent = "unreachable";
+    badge.className = "badge bad";
+  }
+}
+
+// ---------------------------------------------------------------------------
+// Govee lights
+// ---------------------------------------------------------------------------
+
+async function loadGovee() {
+  const { status, items } = section("govee");
+  status.textContent = "Loading…";
+  items.replaceChildren();
+
+  let devices;
+  try {
+    devices = await api("/govee/devices");
+  } catch (err) {
+    status.textContent = err.message;
+    return;
+  }
+  status.textContent = `${devices.length} device(s)`;
+
+  for (const device of devices) {
+    const row = el("li");
+    const state = el("span", "", "badge");
+    const result = el("span", "", "status");
+    const target = { deviceId: device.id, model: device.model, apiKeyIndex: device.apiKeyIndex };
+
+    const refreshState = async () => {
+      const params = new URLSearchParams({ deviceId: device.id, model: device.model, apiKeyIndex: device.apiKeyIndex });
+      try {
+        const s = await api(`/govee/devices/state?${params}`);
+        state.textContent = s.isOn ? "on" : "off";
+        state.className = s.isOn ? "badge ok" : "badge";
+      } catch (err) {
+        state.textContent = "unknown";
+        state.className = "badge";
+      }
+    };
+    const turn = (on) => async () => {
+      try {
+        const resp = await api("/govee/devices/control", { method: "POST", body: { ...target, command: "turn", value: on } });
+        result.textContent = resp.message || "";
+        await refreshState();
+      } catch (err) {
+        result.textContent = err.message;
+      }
+    };
+
+    row.append(el("span", device.name || device.id, "name"), state, button("On", turn(true)), button("Off", turn(false)), result);
+    items.append(row);
+    refreshState();
+  }
+}
+
+// ---------------------------------------------------------------------------
+// Cameras
+// ---------------------------------------------------------------------------
+
+async function loadCameras() {
+  const { status, items } = section("cameras");
+  status.textContent = "Loading…";
+  items.replaceChildren();
+
+  let cameras;
+  try {
+    const resp = await api("/cameras");
+    cameras = resp.cameras || [];
+  } catch (err) {
+    status.textContent = err.message;
+    return;
+  }
+  const online = cameras.filter((cam) => cam.status === "online").length;
+  status.textContent = `${online} of ${cameras.length} camera(s) online`;
+
+  for (const cam of cameras) {
+    const card = el("li");
+    const header = el("div");
+    const badge = el("span", cam.status, cam.status === "online" ? "badge ok" : "badge bad");
+    const result = el("span", "", "status");
+    header.append(el("strong", cam.name || cam.nameUri), " ", badge);
+    card.append(header);
+
+    if (cam.status === "online") {
+      const img = el("img");
+      img.alt = `Snapshot of ${cam.name || cam.nameUri}`;
+      loadImage(img, `/cameras/snapshot?name=${encodeURIComponent(cam.nameUri)}&t=${Date.now()}`);
+      card.append(img);
+    }
+
+    const restart = button("Restart stream", async () => {
+      result.textContent = "Restarting…";
+      try {
+        const resp = await api(`/cameras/restart?name=${encodeURIComponent(cam.nameUri)}`, { method: "POST" });
+        result.textContent = resp.message || "Restarted";
+      } catch (err) {
+        result.textContent = err.message;
+      }
+    });
+    card.append(restart, result);
+    items.append(card);
+  }
+}
+
+// ---------------------------------------------------------------------------
+// Fire TV
+// ---------------------------------------------------------------------------
+
+async function scanFireTV() {
+  const { status, items } = section("firetv");
+  status.textContent = "Scanning…";
+  items.replaceChildren();
+
+  let devices;
+  try {
+    const resp = await api("/firetv/discover");
+    devices = resp.devices || [];
+  } catch (err) {
+    status.textContent = err.message;
+    return;
+  }
+  status.textContent = `Found ${devices.length} device(s)`;
+
+  for (const device of devices) {
+    const row = el("li");
+    const result = el("span", "", "status");
+    const send = (command) => async () => {
+      try {
+        const resp = await api("/firetv/command", { method: "POST", body: { host: device.host, command, serviceIndex: device.serviceIndex } });
+        result.textContent = resp.message || "";
+      } catch (err) {
+        result.textContent = err.message;
+      }
+    };
+
+    row.append(
+      el("span", `${device.name || "Fire TV"} (${device.host})`, "name"),
+      button("Power", send("power")),
+      button("Home", send("home")),
+      button("Play/Pause", send("play_pause")),
+      result,
+    );
+    items.append(row);
+  }
+}
+
+// ---------------------------------------------------------------------------
+
+function refreshAll() {
+  loadHealth();
+  loadGovee();
+  loadCameras();
+}
+
+document.getElementById("refresh").addEventListener("click", refreshAll);
+document.getElementById("firetv-scan").addEventListener("click", scanFireTV);
+refreshAll();
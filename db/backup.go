@@ -0,0 +1,259 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// BackupFormatVersion is the current version of the export bundle format.
+// Bump it whenever the bundle's shape changes, and teach migrateBackup how
+// to upgrade bundles written by older versions.
+//
+// Version 2 added the stores kept in files (presets, macros, webhooks,
+// camera ACLs, optimistic states), which the admin handler bundles next to
+// this database part.
+const BackupFormatVersion = 2
+
+// Backup is the database part of the export bundle: every profile, room,
+// and device. The admin export/import endpoints add the file-backed stores
+// to it to back up or move a whole install.
+type Backup struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exportedAt"`
+	Profiles   []Profile `json:"profiles"`
+	Rooms      []Room    `json:"rooms"`
+	Devices    []Device  `json:"devices"`
+}
+
+// ImportMode controls how ImportBackup treats data already in the database.
+type ImportMode string
+
+const (
+	// ImportMerge upserts every record in the bundle by ID and keeps
+	// everything else already in the database.
+	ImportMerge ImportMode = "merge"
+
+	// ImportReplace deletes all existing data before importing the bundle.
+	ImportReplace ImportMode = "replace"
+)
+
+// ImportSummary reports how many records an import wrote.
+type ImportSummary struct {
+	Mode     ImportMode `json:"mode"`
+	Profiles int        `json:"profiles"`
+	Rooms    int        `json:"rooms"`
+	Devices  int        `json:"devices"`
+}
+
+// ExportBackup reads every profile, room, and device into a Backup.
+func ExportBackup(db *sql.DB) (*Backup, error) {
+	backup := &Backup{
+		Version:    BackupFormatVersion,
+		ExportedAt: time.Now().UTC(),
+		Profiles:   []Profile{},
+		Rooms:      []Room{},
+		Devices:    []Device{},
+	}
+
+	rows, err := db.Query("SELECT id, name, created_at, updated_at FROM profiles ORDER BY created_at ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to export profiles: %w", err)
+	}
+	for rows.Next() {
+		var p Profile
+		if err := rows.Scan(&p.ID, &p.Name, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan profile row: %w", err)
+		}
+		backup.Profiles = append(backup.Profiles, p)
+	}
+	rows.Close()
+
+	rows, err = db.Query("SELECT id, profile_id, name, icon, beacon_uuid, beacon_major, beacon_minor, version, created_at, updated_at FROM rooms ORDER BY created_at ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to export rooms: %w", err)
+	}
+	for rows.Next() {
+		var r Room
+		if err := rows.Scan(&r.ID, &r.ProfileID, &r.Name, &r.Icon, &r.BeaconUUID, &r.BeaconMajor, &r.BeaconMinor, &r.Version, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan room row: %w", err)
+		}
+		backup.Rooms = append(backup.Rooms, r)
+	}
+	rows.Close()
+
+	rows, err = db.Query("SELECT id, profile_id, room_id, name, device_type, external_id, model, metadata, created_at, updated_at FROM devices ORDER BY created_at ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to export devices: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d Device
+		if err := rows.Scan(&d.ID, &d.ProfileID, &d.RoomID, &d.Name, &d.DeviceType, &d.ExternalID, &d.Model, &d.Metadata, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan device row: %w", err)
+		}
+		backup.Devices = append(backup.Devices, d)
+	}
+	return backup, rows.Err()
+}
+
+// migrateBackup upgrades a bundle written by an older format version to the
+// current one. Future format changes add a case per old version here.
+func migrateBackup(backup *Backup) error {
+	switch {
+	case backup.Version == BackupFormatVersion:
+		return nil
+	case backup.Version == 1:
+		// Version 1 only held the database. Its file-backed sections are
+		// missing, and importing leaves those stores as they are.
+		backup.Version = 2
+		return nil
+	case backup.Version < 1:
+		return fmt.Errorf("backup is missing a valid version (got %d)", backup.Version)
+	default:
+		return fmt.Errorf("backup version %d is newer than this server supports (%d) — upgrade Artemis first",
+			backup.Version, BackupFormatVersion)
+	}
+}
+
+// ValidateBackup migrates a bundle to the current format and checks it is
+// self-consistent: every record has an ID and name, IDs are unique, and every
+// room and device points at a profile (and room) that is in the bundle.
+func ValidateBackup(backup *Backup) error {
+	if err := migrateBackup(backup); err != nil {
+		return err
+	}
+
+	profiles := make(map[string]bool, len(backup.Profiles))
+	for i, p := range backup.Profiles {
+		if p.ID == "" || p.Name == "" {
+			return fmt.Errorf("profiles[%d]: id and name are required", i)
+		}
+		if profiles[p.ID] {
+			return fmt.Errorf("profiles[%d]: duplicate id %s", i, p.ID)
+		}
+		profiles[p.ID] = true
+	}
+
+	rooms := make(map[string]string, len(backup.Rooms)) // room ID → profile ID
+	for i, r := range backup.Rooms {
+		if r.ID == "" || r.Name == "" {
+			return fmt.Errorf("rooms[%d]: id and name are required", i)
+		}
+		if _, dup := rooms[r.ID]; dup {
+			return fmt.Errorf("rooms[%d]: duplicate id %s", i, r.ID)
+		}
+		if !profiles[r.ProfileID] {
+			return fmt.Errorf("rooms[%d]: profile %q is not in the backup", i, r.ProfileID)
+		}
+		rooms[r.ID] = r.ProfileID
+	}
+
+	devices := make(map[string]bool, len(backup.Devices))
+	for i, d := range backup.Devices {
+		if d.ID == "" || d.Name == "" || d.DeviceType == "" {
+			return fmt.Errorf("devices[%d]: id, name, and deviceType are required", i)
+		}
+		if devices[d.ID] {
+			return fmt.Errorf("devices[%d]: duplicate id %s", i, d.ID)
+		}
+		if !profiles[d.ProfileID] {
+			return fmt.Errorf("devices[%d]: profile %q is not in the backup", i, d.ProfileID)
+		}
+		if d.RoomID != nil {
+			roomProfile, ok := rooms[*d.RoomID]
+			if !ok {
+				return fmt.Errorf("devices[%d]: room %q is not in the backup", i, *d.RoomID)
+			}
+			if roomProfile != d.ProfileID {
+				return fmt.Errorf("devices[%d]: room %q belongs to a different profile", i, *d.RoomID)
+			}
+		}
+		devices[d.ID] = true
+	}
+
+	return nil
+}
+
+// ImportBackup validates a bundle and writes it to the database in a single
+// transaction, so a failed import leaves existing data untouched.
+func ImportBackup(db *sql.DB, backup *Backup, mode ImportMode) (*ImportSummary, error) {
+	if mode != ImportMerge && mode != ImportReplace {
+		return nil, fmt.Errorf("unknown import mode %q (expected merge or replace)", mode)
+	}
+	if err := ValidateBackup(backup); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start import: %w", err)
+	}
+	defer tx.Rollback() // No-op after a successful Commit
+
+	if mode == ImportReplace {
+		// Profiles cascade to rooms and devices, but delete explicitly so the
+		// order doesn't depend on foreign key enforcement being on.
+		for _, table := range []string{"devices", "rooms", "profiles"} {
+			if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+				return nil, fmt.Errorf("failed to clear %s: %w", table, err)
+			}
+		}
+	}
+
+	// Upserts update in place rather than INSERT OR REPLACE, which would
+	// delete the old row first and cascade-delete its children.
+	for _, p := range backup.Profiles {
+		_, err := tx.Exec(
+			`INSERT INTO profiles (id, name, created_at, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET name = excluded.name, created_at = excluded.created_at, updated_at = excluded.updated_at`,
+			p.ID, p.Name, p.CreatedAt, p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import profile %s: %w", p.ID, err)
+		}
+	}
+
+	for _, r := range backup.Rooms {
+		// Backups from before room versions start at 1. A room that already
+		// exists is bumped instead, so If-Match with an ETag read before
+		// the import fails rather than overwriting the imported room.
+		version := max(r.Version, 1)
+		_, err := tx.Exec(
+			`INSERT INTO rooms (id, profile_id, name, icon, beacon_uuid, beacon_major, beacon_minor, version, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET profile_id = excluded.profile_id, name = excluded.name, icon = excluded.icon,
+				beacon_uuid = excluded.beacon_uuid, beacon_major = excluded.beacon_major, beacon_minor = excluded.beacon_minor,
+				version = rooms.version + 1, created_at = excluded.created_at, updated_at = excluded.updated_at`,
+			r.ID, r.ProfileID, r.Name, r.Icon, r.BeaconUUID, r.BeaconMajor, r.BeaconMinor, version, r.CreatedAt, r.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import room %s: %w", r.ID, err)
+		}
+	}
+
+	for _, d := range backup.Devices {
+		_, err := tx.Exec(
+			`INSERT INTO devices (id, profile_id, room_id, name, device_type, external_id, model, metadata, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET profile_id = excluded.profile_id, room_id = excluded.room_id, name = excluded.name,
+				device_type = excluded.device_type, external_id = excluded.external_id, model = excluded.model,
+				metadata = excluded.metadata, created_at = excluded.created_at, updated_at = excluded.updated_at`,
+			d.ID, d.ProfileID, d.RoomID, d.Name, d.DeviceType, d.ExternalID, d.Model, d.Metadata, d.CreatedAt, d.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import device %s: %w", d.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	return &ImportSummary{
+		Mode:     mode,
+		Profiles: len(backup.Profiles),
+		Rooms:    len(backup.Rooms),
+		Devices:  len(backup.Devices),
+	}, nil
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportImportBackup_RoundTrip(t *testing.T) {
+	source := setupTestDB(t)
+
+	profile, _ := CreateProfile(source, "Shakur")
+	room, _ := CreateRoom(source, profile.ID, "Office", "desktopcomputer")
+	UpdateRoomBeacon(source, room.ID, "E2C56DB5-DFFB-48D2-B060-D0F5A71096E0", 1, 2, 0)
+	model := "H6008"
+	lamp, _ := CreateDevice(source, profile.ID, "Desk Lamp", "govee_light", nil, &model)
+	AssignDeviceToRoom(source, lamp.ID, room.ID)
+	CreateDevice(source, profile.ID, "TV", "fire_tv", nil, nil)
+
+	backup, err := ExportBackup(source)
+	if err != nil {
+		t.Fatalf("ExportBackup returned error: %v", err)
+	}
+	if backup.Version != BackupFormatVersion {
+		t.Errorf("expected version %d, got %d", BackupFormatVersion, backup.Version)
+	}
+	if len(backup.Profiles) != 1 || len(backup.Rooms) != 1 || len(backup.Devices) != 2 {
+		t.Fatalf("unexpected export counts: %d/%d/%d", len(backup.Profiles), len(backup.Rooms), len(backup.Devices))
+	}
+
+	target := setupTestDB(t)
+	summary, err := ImportBackup(target, backup, ImportReplace)
+	if err != nil {
+		t.Fatalf("ImportBackup returned error: %v", err)
+	}
+	if summary.Profiles != 1 || summary.Rooms != 1 || summary.Devices != 2 {
+		t.Errorf("unexpected import summary: %+v", summary)
+	}
+
+	// IDs, beacon config, and room assignment all survive the move.
+	restoredRoom, err := GetRoom(target, room.ID)
+	if err != nil {
+		t.Fatalf("room missing after import: %v", err)
+	}
+	if restoredRoom.BeaconMajor == nil || *restoredRoom.BeaconMajor != 1 {
+		t.Error("expected beacon config to be restored")
+	}
+	restoredLamp, err := GetDevice(target, lamp.ID)
+	if err != nil {
+		t.Fatalf("device missing after import: %v", err)
+	}
+	if restoredLamp.RoomID == nil || *restoredLamp.RoomID != room.ID {
+		t.Error("expected device to stay assigned to its room")
+	}
+	if restoredLamp.Model == nil || *restoredLamp.Model != "H6008" {
+		t.Error("expected device model to be restored")
+	}
+}
+
+func TestImportBackup_MergeKeepsExistingData(t *testing.T) {
+	database := setupTestDB(t)
+	existing, _ := CreateProfile(database, "Existing")
+	room, _ := CreateRoom(database, existing.ID, "Kitchen", "fork.knife")
+
+	backup, _ := ExportBackup(database)
+	backup.Profiles[0].Name = "Renamed"
+	backup.Profiles = append(backup.Profiles, Profile{ID: "imported-profile", Name: "Imported"})
+
+	if _, err := ImportBackup(database, backup, ImportMerge); err != nil {
+		t.Fatalf("ImportBackup returned error: %v", err)
+	}
+
+	profiles, _ := ListProfiles(database)
+	if len(profiles) != 2 {
+		t.Fatalf("expected 2 profiles after merge, got %d", len(profiles))
+	}
+	updated, _ := GetProfile(database, existing.ID)
+	if updated.Name != "Renamed" {
+		t.Errorf("expected merged profile to be updated, got %q", updated.Name)
+	}
+	// Upserting the profile must not cascade-delete its rooms.
+	if _, err := GetRoom(database, room.ID); err != nil {
+		t.Errorf("expected room to survive merge: %v", err)
+	}
+}
+
+func TestImportBackup_ReplaceRemovesExistingData(t *testing.T) {
+	database := setupTestDB(t)
+	CreateProfile(database, "Old")
+
+	backup := &Backup{Version: BackupFormatVersion, Profiles: []Profile{{ID: "new", Name: "New"}}}
+	if _, err := ImportBackup(database, backup, ImportReplace); err != nil {
+		t.Fatalf("ImportBackup returned error: %v", err)
+	}
+
+	profiles, _ := ListProfiles(database)
+	if len(profiles) != 1 || profiles[0].ID != "new" {
+		t.Errorf("expected only the imported profile, got %+v", profiles)
+	}
+}
+
+func TestValidateBackup_UpgradesVersionOne(t *testing.T) {
+	backup := &Backup{Version: 1, Profiles: []Profile{{ID: "p", Name: "A"}}}
+	if err := ValidateBackup(backup); err != nil {
+		t.Fatalf("expected a version 1 bundle to be accepted, got %v", err)
+	}
+	if backup.Version != BackupFormatVersion {
+		t.Errorf("expected version %d after migrating, got %d", BackupFormatVersion, backup.Version)
+	}
+}
+
+func TestImportBackup_InvalidLeavesDataUntouched(t *testing.T) {
+	database := setupTestDB(t)
+	CreateProfile(database, "Keep Me")
+
+	tests := []struct {
+		name    string
+		backup  Backup
+		wantErr string
+	}{
+		{"missing version", Backup{}, "missing a valid version"},
+		{"future version", Backup{Version: BackupFormatVersion + 1}, "newer than this server supports"},
+		{"duplicate profile", Backup{Version: 1, Profiles: []Profile{{ID: "p", Name: "A"}, {ID: "p", Name: "B"}}}, "duplicate id"},
+		{"orphan room", Backup{Version: 1, Rooms: []Room{{ID: "r", ProfileID: "nope", Name: "Office"}}}, "not in the backup"},
+		{"device missing type", Backup{Version: 1,
+			Profiles: []Profile{{ID: "p", Name: "A"}},
+			Devices:  []Device{{ID: "d", ProfileID: "p", Name: "Lamp"}}}, "deviceType are required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ImportBackup(database, &tt.backup, ImportReplace)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	profiles, _ := ListProfiles(database)
+	if len(profiles) != 1 || profiles[0].Name != "Keep Me" {
+		t.Errorf("expected existing data to be untouched, got %+v", profiles)
+	}
+}
@@ -1,6 +1,9 @@
 package db
 
-import "database/sql"
+import (
+	"database/sql"
+	"fmt"
+)
 
 // migrations is the ordered list of SQL statements to run when initializing the database.
 // Each migration creates a table if it doesn't already exist, making it safe to run
@@ -52,13 +55,60 @@ var migrations = []string{
 	);`,
 }
 
+// addedColumns are columns added to tables after they were first created.
+// CREATE TABLE IF NOT EXISTS leaves existing tables alone, and SQLite has no
+// ADD COLUMN IF NOT EXISTS, so each is added only when the table lacks it.
+var addedColumns = []struct {
+	table, column, definition string
+}{
+	// version is bumped on every update, for optimistic concurrency (If-Match)
+	{"rooms", "version", "INTEGER NOT NULL DEFAULT 1"},
+}
+
 // RunMigrations executes all schema migrations against the given database connection.
-// Safe to call on every startup since all statements use IF NOT EXISTS.
+// Safe to call on every startup since all statements use IF NOT EXISTS and
+// columns are only added when missing.
 func RunMigrations(db *sql.DB) error {
 	for _, migration := range migrations {
 		if _, err := db.Exec(migration); err != nil {
 			return err
 		}
 	}
+	for _, c := range addedColumns {
+		exists, err := hasColumn(db, c.table, c.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)); err != nil {
+			return fmt.Errorf("failed to add %s.%s: %w", c.table, c.column, err)
+		}
+	}
 	return nil
 }
+
+// hasColumn reports whether table has a column with the given name.
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
@@ -24,6 +24,7 @@ type Room struct {
 	BeaconUUID  *string `json:"beaconUuid,omitempty"`    // iBeacon proximity UUID
 	BeaconMajor *int    `json:"beaconMajor,omitempty"`   // iBeacon major value
 	BeaconMinor *int    `json:"beaconMinor,omitempty"`   // iBeacon minor value
+	Version     int     `json:"version"`                 // Bumped on every update; sent as the ETag for If-Match
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
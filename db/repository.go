@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -141,25 +142,42 @@ func DeleteProfile(db *sql.DB, id string) error {
 // Room Operations
 // =============================================================================
 
+// ErrStaleVersion is returned by a conditional update when the object has
+// been changed (its version bumped) since the caller read it.
+var ErrStaleVersion = errors.New("version is stale")
+
+// ErrRoomExists is returned when a profile already has a room with the
+// name. Names match case-insensitively.
+var ErrRoomExists = errors.New("room already exists")
+
 // CreateRoom adds a new room under the given profile.
 // Beacon configuration is not set here — use UpdateRoomBeacon for that.
+// Returns ErrRoomExists if the profile already has a room with the name.
 func CreateRoom(db *sql.DB, profileID, name, icon string) (*Room, error) {
 	id := generateUUID()
 	now := time.Now().UTC()
 
-	_, err := db.Exec(
-		"INSERT INTO rooms (id, profile_id, name, icon, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
-		id, profileID, name, icon, now, now,
+	// One statement, so SQLite's write lock makes the name check and the
+	// insert atomic: of two concurrent creates with one name, one inserts.
+	result, err := db.Exec(
+		`INSERT INTO rooms (id, profile_id, name, icon, created_at, updated_at)
+		 SELECT ?, ?, ?, ?, ?, ?
+		 WHERE NOT EXISTS (SELECT 1 FROM rooms WHERE profile_id = ? AND name = ? COLLATE NOCASE)`,
+		id, profileID, name, icon, now, now, profileID, name,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create room: %w", err)
 	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, ErrRoomExists
+	}
 
 	return &Room{
 		ID:        id,
 		ProfileID: profileID,
 		Name:      name,
 		Icon:      icon,
+		Version:   1,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}, nil
@@ -169,8 +187,8 @@ func CreateRoom(db *sql.DB, profileID, name, icon string) (*Room, error) {
 func GetRoom(db *sql.DB, id string) (*Room, error) {
 	var r Room
 	err := db.QueryRow(
-		"SELECT id, profile_id, name, icon, beacon_uuid, beacon_major, beacon_minor, created_at, updated_at FROM rooms WHERE id = ?", id,
-	).Scan(&r.ID, &r.ProfileID, &r.Name, &r.Icon, &r.BeaconUUID, &r.BeaconMajor, &r.BeaconMinor, &r.CreatedAt, &r.UpdatedAt)
+		"SELECT id, profile_id, name, icon, beacon_uuid, beacon_major, beacon_minor, version, created_at, updated_at FROM rooms WHERE id = ?", id,
+	).Scan(&r.ID, &r.ProfileID, &r.Name, &r.Icon, &r.BeaconUUID, &r.BeaconMajor, &r.BeaconMinor, &r.Version, &r.CreatedAt, &r.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("room not found: %s", id)
 	}
@@ -183,7 +201,7 @@ func GetRoom(db *sql.DB, id string) (*Room, error) {
 // ListRoomsByProfile returns all rooms belonging to a profile, ordered by creation time.
 func ListRoomsByProfile(db *sql.DB, profileID string) ([]Room, error) {
 	rows, err := db.Query(
-		"SELECT id, profile_id, name, icon, beacon_uuid, beacon_major, beacon_minor, created_at, updated_at FROM rooms WHERE profile_id = ? ORDER BY created_at ASC",
+		"SELECT id, profile_id, name, icon, beacon_uuid, beacon_major, beacon_minor, version, created_at, updated_at FROM rooms WHERE profile_id = ? ORDER BY created_at ASC",
 		profileID,
 	)
 	if err != nil {
@@ -194,7 +212,7 @@ func ListRoomsByProfile(db *sql.DB, profileID string) ([]Room, error) {
 	var rooms []Room
 	for rows.Next() {
 		var r Room
-		if err := rows.Scan(&r.ID, &r.ProfileID, &r.Name, &r.Icon, &r.BeaconUUID, &r.BeaconMajor, &r.BeaconMinor, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		if err := rows.Scan(&r.ID, &r.ProfileID, &r.Name, &r.Icon, &r.BeaconUUID, &r.BeaconMajor, &r.BeaconMinor, &r.Version, &r.CreatedAt, &r.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan room row: %w", err)
 		}
 		rooms = append(rooms, r)
@@ -202,43 +220,61 @@ func ListRoomsByProfile(db *sql.DB, profileID string) ([]Room, error) {
 	return rooms, rows.Err()
 }
 
-// UpdateRoom changes a room's name and icon, bumping updated_at.
-func UpdateRoom(db *sql.DB, id, name, icon string) (*Room, error) {
+// UpdateRoom changes a room's name and icon, bumping updated_at and version.
+// A non-zero ifVersion makes the update conditional: it only applies while
+// the room is still at that version, and ErrStaleVersion is returned
+// otherwise. Zero updates whatever version the room is at. Renaming to
+// another room's name in the same profile returns ErrRoomExists.
+func UpdateRoom(db *sql.DB, id, name, icon string, ifVersion int) (*Room, error) {
 	now := time.Now().UTC()
 	result, err := db.Exec(
-		"UPDATE rooms SET name = ?, icon = ?, updated_at = ? WHERE id = ?",
-		name, icon, now, id,
+		`UPDATE rooms SET name = ?, icon = ?, updated_at = ?, version = version + 1
+		 WHERE id = ? AND (? = 0 OR version = ?)
+		 AND NOT EXISTS (SELECT 1 FROM rooms other WHERE other.profile_id = rooms.profile_id AND other.id != rooms.id AND other.name = ? COLLATE NOCASE)`,
+		name, icon, now, id, ifVersion, ifVersion, name,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update room: %w", err)
 	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return nil, fmt.Errorf("room not found: %s", id)
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		room, err := GetRoom(db, id)
+		if err != nil {
+			return nil, err
+		}
+		if ifVersion != 0 && room.Version != ifVersion {
+			return nil, ErrStaleVersion
+		}
+		return nil, ErrRoomExists
 	}
-
 	return GetRoom(db, id)
 }
 
 // UpdateRoomBeacon sets the iBeacon configuration for a room.
 // This links the room to a physical BLE beacon for proximity detection.
 // The uuid/major/minor combo should be unique across all rooms.
-func UpdateRoomBeacon(db *sql.DB, id string, uuid string, major, minor int) (*Room, error) {
+// ifVersion works as for UpdateRoom.
+func UpdateRoomBeacon(db *sql.DB, id string, uuid string, major, minor int, ifVersion int) (*Room, error) {
 	now := time.Now().UTC()
 	result, err := db.Exec(
-		"UPDATE rooms SET beacon_uuid = ?, beacon_major = ?, beacon_minor = ?, updated_at = ? WHERE id = ?",
-		uuid, major, minor, now, id,
+		"UPDATE rooms SET beacon_uuid = ?, beacon_major = ?, beacon_minor = ?, updated_at = ?, version = version + 1 WHERE id = ? AND (? = 0 OR version = ?)",
+		uuid, major, minor, now, id, ifVersion, ifVersion,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update room beacon: %w", err)
 	}
+	return updatedRoom(db, result, id)
+}
 
+// updatedRoom returns the room after a conditional update. When no row
+// changed, the room either doesn't exist or was at a different version.
+func updatedRoom(db *sql.DB, result sql.Result, id string) (*Room, error) {
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return nil, fmt.Errorf("room not found: %s", id)
+		if _, err := GetRoom(db, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrStaleVersion
 	}
-
 	return GetRoom(db, id)
 }
 
@@ -346,6 +382,29 @@ func ListDevicesByRoom(db *sql.DB, roomID string) ([]Device, error) {
 	return devices, rows.Err()
 }
 
+// ListDevicesByType returns every device of the given type across all
+// profiles (e.g., all "govee_light" devices, to match them by external_id).
+func ListDevicesByType(db *sql.DB, deviceType string) ([]Device, error) {
+	rows, err := db.Query(
+		"SELECT id, profile_id, room_id, name, device_type, external_id, model, metadata, created_at, updated_at FROM devices WHERE device_type = ? ORDER BY created_at ASC",
+		deviceType,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices by type: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []Device
+	for rows.Next() {
+		var d Device
+		if err := rows.Scan(&d.ID, &d.ProfileID, &d.RoomID, &d.Name, &d.DeviceType, &d.ExternalID, &d.Model, &d.Metadata, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan device row: %w", err)
+		}
+		devices = append(devices, d)
+	}
+	return devices, rows.Err()
+}
+
 // AssignDeviceToRoom places a device into a room.
 // The device must belong to the same profile as the room (not enforced here,
 // but the API layer should validate this).
@@ -405,6 +464,25 @@ func UpdateDevice(db *sql.DB, id, name string) (*Device, error) {
 	return GetDevice(db, id)
 }
 
+// UpdateDeviceMetadata replaces a device's metadata JSON blob (nil clears it).
+func UpdateDeviceMetadata(db *sql.DB, id string, metadata *string) (*Device, error) {
+	now := time.Now().UTC()
+	result, err := db.Exec(
+		"UPDATE devices SET metadata = ?, updated_at = ? WHERE id = ?",
+		metadata, now, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update device metadata: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("device not found: %s", id)
+	}
+
+	return GetDevice(db, id)
+}
+
 // DeleteDevice permanently removes a device record.
 func DeleteDevice(db *sql.DB, id string) error {
 	result, err := db.Exec("DELETE FROM devices WHERE id = ?", id)
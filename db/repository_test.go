@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"testing"
 )
 
@@ -195,6 +196,9 @@ func TestCreateRoom(t *testing.T) {
 	if room.Icon != "sofa" {
 		t.Errorf("expected icon 'sofa', got '%s'", room.Icon)
 	}
+	if _, err := CreateRoom(database, profile.ID, "LIVING ROOM", "house"); !errors.Is(err, ErrRoomExists) {
+		t.Errorf("expected ErrRoomExists for a case-insensitive duplicate, got %v", err)
+	}
 	// Beacon fields should be nil initially
 	if room.BeaconUUID != nil {
 		t.Error("expected beacon_uuid to be nil initially")
@@ -260,7 +264,7 @@ func TestUpdateRoom(t *testing.T) {
 
 	profile, _ := CreateProfile(database, "Shakur")
 	room, _ := CreateRoom(database, profile.ID, "OldRoom", "house")
-	updated, err := UpdateRoom(database, room.ID, "NewRoom", "star")
+	updated, err := UpdateRoom(database, room.ID, "NewRoom", "star", 0)
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
@@ -276,12 +280,74 @@ func TestUpdateRoom(t *testing.T) {
 func TestUpdateRoomNotFound(t *testing.T) {
 	database := setupTestDB(t)
 
-	_, err := UpdateRoom(database, "nonexistent", "Name", "icon")
+	_, err := UpdateRoom(database, "nonexistent", "Name", "icon", 0)
 	if err == nil {
 		t.Fatal("expected error for nonexistent room, got nil")
 	}
 }
 
+func TestUpdateRoomVersion(t *testing.T) {
+	database := setupTestDB(t)
+
+	profile, _ := CreateProfile(database, "Shakur")
+	room, _ := CreateRoom(database, profile.ID, "Office", "house")
+	if room.Version != 1 {
+		t.Fatalf("expected a new room at version 1, got %d", room.Version)
+	}
+
+	// A fresh version applies and bumps it
+	updated, err := UpdateRoom(database, room.ID, "Study", "book", 1)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if updated.Version != 2 || updated.Name != "Study" {
+		t.Errorf("expected Study at version 2, got %+v", updated)
+	}
+
+	// A stale version is rejected and changes nothing
+	if _, err := UpdateRoomBeacon(database, room.ID, "uuid", 1, 1, 1); !errors.Is(err, ErrStaleVersion) {
+		t.Fatalf("expected ErrStaleVersion, got %v", err)
+	}
+	current, _ := GetRoom(database, room.ID)
+	if current.Version != 2 || current.BeaconUUID != nil {
+		t.Errorf("expected the room unchanged at version 2, got %+v", current)
+	}
+
+	// A missing room is still "not found", whatever the version
+	if _, err := UpdateRoom(database, "nonexistent", "Name", "icon", 3); err == nil || errors.Is(err, ErrStaleVersion) {
+		t.Errorf("expected a not-found error, got %v", err)
+	}
+}
+
+func TestRunMigrations_AddsRoomVersion(t *testing.T) {
+	database, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close()
+	database.SetMaxOpenConns(1) // Every connection to :memory: is its own database
+
+	// A rooms table from before versions existed
+	database.Exec("CREATE TABLE profiles (id TEXT PRIMARY KEY, name TEXT NOT NULL, created_at DATETIME, updated_at DATETIME)")
+	database.Exec("CREATE TABLE rooms (id TEXT PRIMARY KEY, profile_id TEXT NOT NULL, name TEXT NOT NULL, icon TEXT, beacon_uuid TEXT, beacon_major INTEGER, beacon_minor INTEGER, created_at DATETIME, updated_at DATETIME)")
+	database.Exec("INSERT INTO rooms (id, profile_id, name, icon, created_at, updated_at) VALUES ('r1', 'p1', 'Den', 'house', '2024-01-01', '2024-01-01')")
+
+	// Running twice must not try to add the column again
+	for i := 0; i < 2; i++ {
+		if err := RunMigrations(database); err != nil {
+			t.Fatalf("run %d: %v", i+1, err)
+		}
+	}
+
+	room, err := GetRoom(database, "r1")
+	if err != nil {
+		t.Fatalf("expected the old room to be readable, got %v", err)
+	}
+	if room.Version != 1 {
+		t.Errorf("expected existing rooms to start at version 1, got %d", room.Version)
+	}
+}
+
 func TestUpdateRoomBeacon(t *testing.T) {
 	database := setupTestDB(t)
 
@@ -290,7 +356,7 @@ func TestUpdateRoomBeacon(t *testing.T) {
 
 	// Set beacon configuration
 	beaconUUID := "E2C56DB5-DFFB-48D2-B060-D0F5A71096E0"
-	updated, err := UpdateRoomBeacon(database, room.ID, beaconUUID, 1, 100)
+	updated, err := UpdateRoomBeacon(database, room.ID, beaconUUID, 1, 100, 0)
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
@@ -309,7 +375,7 @@ func TestUpdateRoomBeacon(t *testing.T) {
 func TestUpdateRoomBeaconNotFound(t *testing.T) {
 	database := setupTestDB(t)
 
-	_, err := UpdateRoomBeacon(database, "nonexistent", "uuid", 1, 1)
+	_, err := UpdateRoomBeacon(database, "nonexistent", "uuid", 1, 1, 0)
 	if err == nil {
 		t.Fatal("expected error for nonexistent room, got nil")
 	}
@@ -541,6 +607,34 @@ func TestUpdateDeviceNotFound(t *testing.T) {
 	}
 }
 
+func TestUpdateDeviceMetadata(t *testing.T) {
+	database := setupTestDB(t)
+
+	profile, _ := CreateProfile(database, "Shakur")
+	device, _ := CreateDevice(database, profile.ID, "Living Room TV", "fire_tv", nil, nil)
+
+	metadata := `{"macAddress":"AA:BB:CC:DD:EE:FF"}`
+	updated, err := UpdateDeviceMetadata(database, device.ID, &metadata)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if updated.Metadata == nil || *updated.Metadata != metadata {
+		t.Errorf("expected metadata %s, got %v", metadata, updated.Metadata)
+	}
+
+	cleared, err := UpdateDeviceMetadata(database, device.ID, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cleared.Metadata != nil {
+		t.Errorf("expected metadata to be cleared, got %s", *cleared.Metadata)
+	}
+
+	if _, err := UpdateDeviceMetadata(database, "nonexistent", nil); err == nil {
+		t.Fatal("expected error for nonexistent device, got nil")
+	}
+}
+
 func TestDeleteDevice(t *testing.T) {
 	database := setupTestDB(t)
 
@@ -702,9 +796,9 @@ func TestFullOnboardingFlow(t *testing.T) {
 
 	// Step 5: Configure beacons
 	beaconUUID := "E2C56DB5-DFFB-48D2-B060-D0F5A71096E0"
-	UpdateRoomBeacon(database, livingRoom.ID, beaconUUID, 1, 1)
-	UpdateRoomBeacon(database, office.ID, beaconUUID, 1, 2)
-	UpdateRoomBeacon(database, bedroom.ID, beaconUUID, 1, 3)
+	UpdateRoomBeacon(database, livingRoom.ID, beaconUUID, 1, 1, 0)
+	UpdateRoomBeacon(database, office.ID, beaconUUID, 1, 2, 0)
+	UpdateRoomBeacon(database, bedroom.ID, beaconUUID, 1, 3, 0)
 
 	// Verify: list rooms and check beacon config
 	rooms, _ := ListRoomsByProfile(database, profile.ID)
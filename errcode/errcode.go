@@ -0,0 +1,77 @@
+// Package errcode is the catalog of machine-readable error codes sent in the
+// "code" field of every JSON error response, so clients can react to an
+// error (retry, re-fetch, show a setting) without parsing its message.
+//
+// Codes are stable: a code is never renamed or reused for something else,
+// and messages may change freely. Handlers pick a specific code when they
+// know one (e.g. DeviceNotFound); otherwise the response status decides
+// (see ForStatus).
+package errcode
+
+import "net/http"
+
+// Code is a stable, machine-readable error code.
+type Code string
+
+// Request errors: retrying the same request won't help.
+const (
+	InvalidRequest       Code = "INVALID_REQUEST"        // Malformed body, missing or invalid parameter
+	InvalidCommand       Code = "INVALID_COMMAND"        // Unknown control command, or a value it can't take
+	Unauthorized         Code = "UNAUTHORIZED"           // Missing or wrong bearer token
+	Forbidden            Code = "FORBIDDEN"              // The endpoint is switched off (e.g. no ADMIN_TOKEN), or the token can't see this camera
+	NotFound             Code = "NOT_FOUND"              // Profile, room, preset, camera, ... doesn't exist
+	DeviceNotFound       Code = "DEVICE_NOT_FOUND"       // No configured Govee account has the device
+	FeatureDisabled      Code = "FEATURE_DISABLED"       // The integration is turned off on this server
+	MethodNotAllowed     Code = "METHOD_NOT_ALLOWED"     // The route doesn't take this HTTP method
+	Conflict             Code = "CONFLICT"               // Duplicate name, ambiguous name, or a request still in progress
+	StaleVersion         Code = "STALE_VERSION"          // If-Match doesn't match the current version; re-fetch and retry
+	IfMatchRequired      Code = "IF_MATCH_REQUIRED"      // If-Match wasn't sent and REQUIRE_IF_MATCH is on (the default)
+	IdempotencyKeyReused Code = "IDEMPOTENCY_KEY_REUSED" // The Idempotency-Key was used for a different request
+	UnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE" // The body isn't JSON
+	NotSupported         Code = "NOT_SUPPORTED"          // The upstream service doesn't support the operation
+)
+
+// Upstream errors: Govee, the Fire TV service, or the Wyze Bridge failed.
+// Most are worth retrying later.
+const (
+	GoveeRateLimited   Code = "GOVEE_RATE_LIMITED"  // Govee's per-key or per-device limit was hit; back off, then retry
+	DeviceOffline      Code = "DEVICE_OFFLINE"      // Govee can't reach the device
+	UpstreamError      Code = "UPSTREAM_ERROR"      // The service failed or rejected the request
+	UpstreamTimeout    Code = "UPSTREAM_TIMEOUT"    // The service or device didn't answer in time
+	ServiceUnavailable Code = "SERVICE_UNAVAILABLE" // The service isn't running, or its circuit breaker is open
+	RequestTimeout     Code = "REQUEST_TIMEOUT"     // The client's own X-Request-Timeout-Ms deadline passed
+	Internal           Code = "INTERNAL_ERROR"      // Artemis itself failed (database, file, ...)
+)
+
+// statusCodes is the generic code for each error status.
+var statusCodes = map[int]Code{
+	http.StatusBadRequest:            InvalidRequest,
+	http.StatusUnauthorized:          Unauthorized,
+	http.StatusForbidden:             Forbidden,
+	http.StatusNotFound:              NotFound,
+	http.StatusMethodNotAllowed:      MethodNotAllowed,
+	http.StatusConflict:              Conflict,
+	http.StatusPreconditionFailed:    StaleVersion,
+	http.StatusUnsupportedMediaType:  UnsupportedMediaType,
+	http.StatusUnprocessableEntity:   InvalidRequest,
+	http.StatusPreconditionRequired:  IfMatchRequired,
+	http.StatusTooManyRequests:       GoveeRateLimited,
+	http.StatusNotImplemented:        NotSupported,
+	http.StatusBadGateway:            UpstreamError,
+	http.StatusServiceUnavailable:    ServiceUnavailable,
+	http.StatusGatewayTimeout:        UpstreamTimeout,
+	http.StatusInternalServerError:   Internal,
+	http.StatusRequestEntityTooLarge: InvalidRequest,
+}
+
+// ForStatus returns the generic code for an error status: InvalidRequest
+// for an unlisted 4xx and Internal for an unlisted 5xx.
+func ForStatus(status int) Code {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status < 500 {
+		return InvalidRequest
+	}
+	return Internal
+}
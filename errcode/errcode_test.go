@@ -0,0 +1,24 @@
+package errcode
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestForStatus(t *testing.T) {
+	tests := map[int]Code{
+		http.StatusBadRequest:          InvalidRequest,
+		http.StatusNotFound:            NotFound,
+		http.StatusPreconditionFailed:  StaleVersion,
+		http.StatusTooManyRequests:     GoveeRateLimited,
+		http.StatusServiceUnavailable:  ServiceUnavailable,
+		http.StatusGatewayTimeout:      UpstreamTimeout,
+		http.StatusTeapot:              InvalidRequest, // Unlisted 4xx
+		http.StatusInsufficientStorage: Internal,       // Unlisted 5xx
+	}
+	for status, want := range tests {
+		if got := ForStatus(status); got != want {
+			t.Errorf("ForStatus(%d) = %s, want %s", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types shared by all streams.
+const (
+	// TypeMissed is sent to a reconnecting client whose Last-Event-ID is
+	// older than anything still buffered (or from before a server restart).
+	// The client should re-fetch full state rather than rely on replay.
+	TypeMissed = "missed"
+)
+
+// Event is a single message delivered to SSE subscribers.
+// IDs increase monotonically per broker so clients can resume with
+// the standard Last-Event-ID header after a disconnect.
+type Event struct {
+	ID   uint64      `json:"id"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+	Time time.Time   `json:"time"`
+}
+
+// subscriberBuffer is how many undelivered events a slow subscriber may
+// queue. One that falls further behind is disconnected (its channel is
+// closed) so it can resubscribe from the last ID it saw and get the rest
+// from the ring buffer, or a "missed" marker if they're gone.
+const subscriberBuffer = 32
+
+// Broker fans published events out to live subscribers and keeps a bounded
+// ring buffer of recent events for replay on reconnect.
+//
+// Each SSE stream (device events, camera events, ...) gets its own Broker so
+// their IDs and buffers are independent. Safe for concurrent use.
+type Broker struct {
+	mu          sync.Mutex
+	nextID      uint64
+	ring        []Event // Fixed-capacity circular buffer of recent events
+	start       int     // Index of the oldest event in ring
+	count       int     // Number of valid events in ring
+	subscribers map[chan Event]struct{}
+}
+
+// NewBroker creates a broker that remembers the last bufferSize events.
+func NewBroker(bufferSize int) *Broker {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	return &Broker{
+		nextID:      1,
+		ring:        make([]Event, bufferSize),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish assigns the next ID to an event, stores it in the replay buffer,
+// and delivers it to every current subscriber. Never blocks on slow clients:
+// a subscriber whose buffer is full is dropped instead.
+func (b *Broker) Publish(eventType string, data interface{}) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	event := Event{ID: b.nextID, Type: eventType, Data: data, Time: time.Now()}
+	b.nextID++
+
+	// Append to the ring, overwriting the oldest entry once full.
+	if b.count < len(b.ring) {
+		b.ring[(b.start+b.count)%len(b.ring)] = event
+		b.count++
+	} else {
+		b.ring[b.start] = event
+		b.start = (b.start + 1) % len(b.ring)
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Subscriber is backed up — disconnect it rather than stall
+			// publishers or silently skip events it would never see.
+			close(ch)
+			delete(b.subscribers, ch)
+		}
+	}
+
+	return event
+}
+
+// Subscribe registers a new live subscriber.
+//
+// lastEventID is the ID the client last saw (0 for a fresh connection).
+// Returns the buffered events newer than lastEventID to replay first, whether
+// the client may have missed events that are no longer buffered, a channel
+// of live events, and a cancel function that must be called on disconnect.
+//
+// Replay and registration happen under one lock, so no event can fall in
+// the gap between the replayed history and the live channel.
+//
+// The live channel is closed if the subscriber falls more than
+// subscriberBuffer events behind. Events already queued are still received;
+// after that, resubscribe with the last ID seen to resume.
+func (b *Broker) Subscribe(lastEventID uint64) (replay []Event, missed bool, live <-chan Event, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lastEventID > 0 {
+		latest := b.nextID - 1
+		oldest := latest + 1 // Nothing buffered yet
+		if b.count > 0 {
+			oldest = b.ring[b.start].ID
+		}
+
+		switch {
+		case lastEventID > latest:
+			// The ID is from before a restart — our numbering started over.
+			missed = true
+			lastEventID = 0
+		case lastEventID+1 < oldest:
+			// Events between lastEventID and the oldest buffered one were dropped.
+			missed = true
+		}
+
+		for i := 0; i < b.count; i++ {
+			event := b.ring[(b.start+i)%len(b.ring)]
+			if event.ID > lastEventID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	b.subscribers[ch] = struct{}{}
+
+	cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, ch)
+	}
+
+	return replay, missed, ch, cancel
+}
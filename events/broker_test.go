@@ -0,0 +1,122 @@
+package events
+
+import "testing"
+
+func TestBroker_AssignsIncreasingIDs(t *testing.T) {
+	broker := NewBroker(10)
+	first := broker.Publish("a", nil)
+	second := broker.Publish("b", nil)
+	if first.ID != 1 || second.ID != 2 {
+		t.Errorf("expected IDs 1 and 2, got %d and %d", first.ID, second.ID)
+	}
+}
+
+func TestBroker_FreshSubscriberGetsNoReplay(t *testing.T) {
+	broker := NewBroker(10)
+	broker.Publish("a", nil)
+
+	replay, missed, _, cancel := broker.Subscribe(0)
+	defer cancel()
+
+	if len(replay) != 0 || missed {
+		t.Errorf("expected no replay for a fresh connection, got %d events (missed=%v)", len(replay), missed)
+	}
+}
+
+func TestBroker_ReplaysAfterLastEventID(t *testing.T) {
+	broker := NewBroker(10)
+	for i := 0; i < 5; i++ {
+		broker.Publish("tick", i)
+	}
+
+	replay, missed, _, cancel := broker.Subscribe(3)
+	defer cancel()
+
+	if missed {
+		t.Error("expected missed=false when the gap is still buffered")
+	}
+	if len(replay) != 2 || replay[0].ID != 4 || replay[1].ID != 5 {
+		t.Errorf("expected replay of events 4 and 5, got %+v", replay)
+	}
+}
+
+func TestBroker_MissedWhenGapExceedsBuffer(t *testing.T) {
+	broker := NewBroker(3)
+	for i := 0; i < 10; i++ {
+		broker.Publish("tick", i)
+	}
+
+	// Buffer now holds 8, 9, 10 — the client last saw 2.
+	replay, missed, _, cancel := broker.Subscribe(2)
+	defer cancel()
+
+	if !missed {
+		t.Error("expected missed=true when events were dropped from the buffer")
+	}
+	if len(replay) != 3 || replay[0].ID != 8 {
+		t.Errorf("expected replay of the 3 buffered events starting at 8, got %+v", replay)
+	}
+}
+
+func TestBroker_MissedAfterRestart(t *testing.T) {
+	broker := NewBroker(10)
+	broker.Publish("tick", nil)
+
+	// The client saw ID 500 from a previous server run.
+	replay, missed, _, cancel := broker.Subscribe(500)
+	defer cancel()
+
+	if !missed {
+		t.Error("expected missed=true for an ID from before a restart")
+	}
+	if len(replay) != 1 {
+		t.Errorf("expected everything buffered to be replayed, got %d", len(replay))
+	}
+}
+
+func TestBroker_LiveDeliveryAndCancel(t *testing.T) {
+	broker := NewBroker(10)
+	_, _, live, cancel := broker.Subscribe(0)
+
+	broker.Publish("hello", "world")
+	event := <-live
+	if event.Type != "hello" || event.Data != "world" {
+		t.Errorf("unexpected live event %+v", event)
+	}
+
+	cancel()
+	broker.Publish("after-cancel", nil)
+	select {
+	case event := <-live:
+		t.Errorf("expected no delivery after cancel, got %+v", event)
+	default:
+	}
+}
+
+func TestBroker_DisconnectsSubscriberThatFallsBehind(t *testing.T) {
+	broker := NewBroker(100)
+	_, _, live, cancel := broker.Subscribe(0)
+	defer cancel()
+
+	// One more than the subscriber can queue, without reading any
+	for i := 0; i < subscriberBuffer+1; i++ {
+		broker.Publish("tick", i)
+	}
+
+	// Everything queued before the overflow still arrives, then the channel closes
+	var lastID uint64
+	for event := range live {
+		lastID = event.ID
+	}
+	if lastID != subscriberBuffer {
+		t.Fatalf("expected the first %d events before the close, got up to %d", subscriberBuffer, lastID)
+	}
+
+	// Resubscribing from the last ID seen replays the rest
+	replay, missed, _, cancel2 := broker.Subscribe(lastID)
+	defer cancel2()
+	if missed || len(replay) != 1 || replay[0].ID != subscriberBuffer+1 {
+		t.Errorf("expected the overflowed event in the replay, got %+v (missed=%v)", replay, missed)
+	}
+	cancel() // Cancelling a dropped subscriber is harmless
+}
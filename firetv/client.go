@@ -2,12 +2,19 @@ package firetv
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
 	"time"
+
+	"github.com/pantheon/artemis/tracing"
+	"github.com/pantheon/artemis/upstream"
 )
 
 // Base URL for the Python Fire TV Remote microservice.
@@ -25,14 +32,40 @@ const (
 	// Timeout for HTTP requests to the Python service.
 	// Discovery can take up to 5 seconds (mDNS scan), so we allow extra headroom.
 	requestTimeout = 15 * time.Second
+
+	// Extra time allowed on top of the requested scan duration for the
+	// Python service to start the scan and return results.
+	discoverHeadroom = 10 * time.Second
+
+	// Names the Python service in classified errors (see upstream.Error).
+	serviceName = "Fire TV service"
+)
+
+// Limits for caller-controlled discovery scans.
+// Values outside these ranges are clamped rather than rejected so the app can
+// always get some result.
+const (
+	DefaultDiscoverTimeout = 5 * time.Second  // The Python service's default scan length
+	MinDiscoverTimeout     = 1 * time.Second  // Shortest useful mDNS scan
+	MaxDiscoverTimeout     = 30 * time.Second // Longest scan before the app request would time out
+	MaxDiscoverDevices     = 100              // Upper bound for the "max" limit
 )
 
+// DiscoverOptions controls the length and size of a discovery scan.
+// Zero values mean "use the service default".
+type DiscoverOptions struct {
+	Timeout    time.Duration // How long the mDNS scan runs
+	MaxDevices int           // Stop after this many devices (0 = no limit)
+}
+
 // Client communicates with the Python Fire TV Remote microservice.
 // It proxies discovery, pairing, and command requests from the Go backend
 // to the Python service, which handles the actual Android TV Remote protocol.
 type Client struct {
-	baseURL    string       // Base URL of the Python microservice (e.g., "http://localhost:9090")
-	httpClient *http.Client // HTTP client with timeout configured
+	baseURL    string            // Base URL of the Python microservice (e.g., "http://localhost:9090")
+	httpClient *http.Client      // HTTP client with timeout configured
+	subnets    []netip.Prefix    // Networks this service reaches (see SetSubnets)
+	breaker    *upstream.Breaker // Optional; see SetBreaker
 }
 
 // NewClient creates a new Fire TV client that connects to the Python microservice.
@@ -46,20 +79,91 @@ func NewClient(serviceURL string) *Client {
 	return &Client{
 		baseURL: serviceURL,
 		httpClient: &http.Client{
-			Timeout: requestTimeout,
+			Timeout:   requestTimeout,
+			Transport: tracing.NewTransport(), // client span per outbound request
 		},
 	}
 }
 
+// BaseURL returns the URL of the Python service this client talks to.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// SetSubnets records the networks this service can reach, as CIDRs
+// ("192.168.20.0/24") or single IPs. Used to route a request to the right
+// service when several run on different network segments (see Serves).
+func (c *Client) SetSubnets(subnets []string) error {
+	prefixes := make([]netip.Prefix, 0, len(subnets))
+	for _, subnet := range subnets {
+		prefix, err := netip.ParsePrefix(subnet)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(subnet)
+			if addrErr != nil {
+				return fmt.Errorf("invalid subnet %q: must be a CIDR or an IP address", subnet)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	c.subnets = prefixes
+	return nil
+}
+
+// SetBreaker guards every request to the Python service with breaker, so an
+// outage fails fast instead of each request waiting out the timeout.
+// nil (the default) disables it.
+func (c *Client) SetBreaker(breaker *upstream.Breaker) {
+	c.breaker = breaker
+}
+
+// Serves reports whether host is an IP address inside one of the service's
+// subnets. Hostnames never match.
+func (c *Client) Serves(host string) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range c.subnets {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
 // Discover scans the local network for Fire TV devices.
 // Calls the Python service's GET /discover endpoint, which uses mDNS/Zeroconf
 // to find devices advertising the Android TV Remote v2 service type.
-// The scan takes approximately 5 seconds to collect all device responses.
-func (c *Client) Discover() (*DiscoverResponse, error) {
+// The scan takes approximately 5 seconds by default; opts can shorten or
+// lengthen it and cap the number of devices returned. The options are passed
+// to the service as ?timeout=<seconds>&max=<n> query parameters.
+func (c *Client) Discover(ctx context.Context, opts DiscoverOptions) (*DiscoverResponse, error) {
 	log.Printf("📺 Requesting Fire TV device discovery from Python service...")
 
+	query := url.Values{}
+	httpClient := c.httpClient
+	if opts.Timeout > 0 {
+		query.Set("timeout", strconv.FormatFloat(opts.Timeout.Seconds(), 'f', -1, 64))
+
+		// A long scan would outlive the default request timeout, so use a
+		// copy of the client with a deadline sized to this scan.
+		scanClient := *c.httpClient
+		scanClient.Timeout = opts.Timeout + discoverHeadroom
+		httpClient = &scanClient
+	}
+	if opts.MaxDevices > 0 {
+		query.Set("max", strconv.Itoa(opts.MaxDevices))
+	}
+
+	discoverURL := c.baseURL + discoverEndpoint
+	if len(query) > 0 {
+		discoverURL += "?" + query.Encode()
+	}
+
 	// Send GET request to the Python service's discover endpoint.
-	resp, err := c.httpClient.Get(c.baseURL + discoverEndpoint)
+	resp, err := c.get(ctx, httpClient, discoverURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reach Fire TV service: %w", err)
 	}
@@ -93,28 +197,28 @@ func (c *Client) Discover() (*DiscoverResponse, error) {
 // StartPairing initiates the pairing process with a Fire TV device.
 // This is Step 1 of the pairing flow — the TV will display a 6-digit PIN.
 // The user must read the PIN and submit it via FinishPairing().
-func (c *Client) StartPairing(host string) (*PairResponse, error) {
+func (c *Client) StartPairing(ctx context.Context, host string) (*PairResponse, error) {
 	log.Printf("📺 Starting pairing with Fire TV at %s...", host)
 
 	// Build the pairing request with just the host (no PIN = start pairing).
 	reqBody := PairRequest{Host: host}
-	return c.sendPairRequest(reqBody)
+	return c.sendPairRequest(ctx, reqBody)
 }
 
 // FinishPairing completes the pairing process with the PIN shown on the TV.
 // This is Step 2 of the pairing flow — submits the user-entered PIN to verify.
 // If successful, the device is paired and can receive remote commands.
-func (c *Client) FinishPairing(host, pin string) (*PairResponse, error) {
+func (c *Client) FinishPairing(ctx context.Context, host, pin string) (*PairResponse, error) {
 	log.Printf("📺 Finishing pairing with Fire TV at %s (PIN: %s)...", host, pin)
 
 	// Build the pairing request with both host and PIN (PIN present = finish pairing).
 	reqBody := PairRequest{Host: host, PIN: pin}
-	return c.sendPairRequest(reqBody)
+	return c.sendPairRequest(ctx, reqBody)
 }
 
 // sendPairRequest sends a pairing request to the Python service.
 // Used internally by both StartPairing and FinishPairing.
-func (c *Client) sendPairRequest(reqBody PairRequest) (*PairResponse, error) {
+func (c *Client) sendPairRequest(ctx context.Context, reqBody PairRequest) (*PairResponse, error) {
 	// Encode the request body as JSON.
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
@@ -122,11 +226,7 @@ func (c *Client) sendPairRequest(reqBody PairRequest) (*PairResponse, error) {
 	}
 
 	// Send POST request to the Python service's pair endpoint.
-	resp, err := c.httpClient.Post(
-		c.baseURL+pairEndpoint,
-		"application/json",
-		bytes.NewReader(jsonBody),
-	)
+	resp, err := c.postJSON(ctx, c.baseURL+pairEndpoint, jsonBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reach Fire TV service: %w", err)
 	}
@@ -159,17 +259,38 @@ func (c *Client) sendPairRequest(reqBody PairRequest) (*PairResponse, error) {
 // SendCommand sends a remote control command to a paired Fire TV device.
 // Supports navigation, media, power, volume, text input, and app launch commands.
 // The device must have been previously paired via StartPairing/FinishPairing.
-func (c *Client) SendCommand(host, command, text, appPackage string) (*CommandResponse, error) {
+func (c *Client) SendCommand(ctx context.Context, host, command, text, appPackage string) (*CommandResponse, error) {
 	log.Printf("📺 Sending command '%s' to Fire TV at %s", command, host)
 
 	// Build the command request.
-	reqBody := CommandRequest{
+	return c.postCommand(ctx, CommandRequest{
 		Host:       host,
 		Command:    command,
 		Text:       text,
 		AppPackage: appPackage,
+	})
+}
+
+// SendKeycode sends a raw Android keyevent to a paired Fire TV device,
+// bypassing the service's named-command allowlist. For keys that have no
+// named command; prefer SendCommand whenever one exists.
+// keycode must be within MinKeycode..MaxKeycode.
+func (c *Client) SendKeycode(ctx context.Context, host string, keycode int) (*CommandResponse, error) {
+	if keycode < MinKeycode || keycode > MaxKeycode {
+		return nil, fmt.Errorf("keycode must be between %d and %d, got %d", MinKeycode, MaxKeycode, keycode)
 	}
 
+	log.Printf("📺 Sending raw keycode %d to Fire TV at %s", keycode, host)
+	return c.postCommand(ctx, CommandRequest{
+		Host:    host,
+		Command: KeyeventCommand,
+		Keycode: keycode,
+	})
+}
+
+// postCommand sends a command request to the Python service's command
+// endpoint and parses the result.
+func (c *Client) postCommand(ctx context.Context, reqBody CommandRequest) (*CommandResponse, error) {
 	// Encode the request body as JSON.
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
@@ -177,11 +298,7 @@ func (c *Client) SendCommand(host, command, text, appPackage string) (*CommandRe
 	}
 
 	// Send POST request to the Python service's command endpoint.
-	resp, err := c.httpClient.Post(
-		c.baseURL+commandEndpoint,
-		"application/json",
-		bytes.NewReader(jsonBody),
-	)
+	resp, err := c.postJSON(ctx, c.baseURL+commandEndpoint, jsonBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reach Fire TV service: %w", err)
 	}
@@ -214,8 +331,8 @@ func (c *Client) SendCommand(host, command, text, appPackage string) (*CommandRe
 // CheckHealth verifies the Python Fire TV microservice is running.
 // Returns nil if the service is reachable and healthy, or an error otherwise.
 // Used during Go server startup to warn if the Python service isn't running.
-func (c *Client) CheckHealth() error {
-	resp, err := c.httpClient.Get(c.baseURL + healthEndpoint)
+func (c *Client) CheckHealth(ctx context.Context) error {
+	resp, err := c.get(ctx, c.httpClient, c.baseURL+healthEndpoint)
 	if err != nil {
 		return fmt.Errorf("fire TV service unreachable: %w", err)
 	}
@@ -227,3 +344,23 @@ func (c *Client) CheckHealth() error {
 
 	return nil
 }
+
+// get sends a GET request with httpClient that is cancelled along with ctx.
+// Takes the HTTP client explicitly so Discover can use its longer timeout.
+func (c *Client) get(ctx context.Context, httpClient *http.Client, reqURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.breaker.Do(httpClient, req, serviceName)
+}
+
+// postJSON sends a JSON-encoded POST request that is cancelled along with ctx.
+func (c *Client) postJSON(ctx context.Context, reqURL string, jsonBody []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.breaker.Do(c.httpClient, req, serviceName)
+}
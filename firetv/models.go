@@ -12,6 +12,10 @@ type DiscoveredDevice struct {
 	Host  string `json:"host"`            // Device IP address on the LAN (e.g., "192.168.1.50")
 	Port  int    `json:"port"`            // Android TV Remote service port (usually 6466)
 	Model string `json:"model,omitempty"` // Device model from mDNS TXT records (may be empty)
+
+	// Which configured Fire TV service found the device (set by Artemis, not
+	// the Python service). Send it back as serviceIndex to pair or command.
+	ServiceIndex int `json:"serviceIndex"`
 }
 
 // DiscoverResponse is the response from the Python service's /discover endpoint.
@@ -20,6 +24,15 @@ type DiscoverResponse struct {
 	Success bool               `json:"success"` // Whether the scan completed without errors
 	Devices []DiscoveredDevice `json:"devices"` // List of discovered Fire TV devices
 	Message string             `json:"message"` // Human-readable status message (e.g., "Found 2 device(s)")
+
+	// True when more devices were found than the requested max and the
+	// list was cut short. Set by the service or by Artemis when it applies
+	// the limit itself.
+	Truncated bool `json:"truncated"`
+
+	// Services that failed to scan, when several are configured (set by
+	// Artemis). Devices from the others are still returned.
+	ServiceErrors []string `json:"serviceErrors,omitempty"`
 }
 
 // PairRequest is sent to the Python service to start or complete pairing.
@@ -39,16 +52,29 @@ type PairResponse struct {
 	AwaitingPIN bool   `json:"awaiting_pin"`           // True when the TV is displaying a PIN
 }
 
+// Valid range for raw Android keycodes sent with KeyeventCommand.
+// 0 is KEYCODE_UNKNOWN; 316 (KEYCODE_MACRO_4) is the highest keycode
+// defined as of Android 14.
+const (
+	MinKeycode = 1
+	MaxKeycode = 316
+)
+
+// KeyeventCommand is the command name for a raw keyevent (see SendKeycode).
+const KeyeventCommand = "keyevent"
+
 // CommandRequest is sent to the Python service to execute a remote command.
-// Supports three types of commands:
+// Supports four types of commands:
 //   - Standard key commands: Set Command to a key name (e.g., "home", "play_pause")
 //   - Text input: Set Command to "text_input" and provide Text field
 //   - App launch: Set Command to "launch_app" and provide AppPackage field
+//   - Raw keyevent: Set Command to "keyevent" and provide Keycode field
 type CommandRequest struct {
 	Host       string `json:"host"`                    // IP address of the target Fire TV device
 	Command    string `json:"command"`                 // Command name (e.g., "home", "up", "text_input")
 	Text       string `json:"text,omitempty"`          // Text to send (for "text_input" command)
 	AppPackage string `json:"app_package,omitempty"`   // Android package name (for "launch_app" command)
+	Keycode    int    `json:"keycode,omitempty"`       // Android keycode (for "keyevent" command)
 }
 
 // CommandResponse is the response from the Python service's /command endpoint.
@@ -58,6 +84,23 @@ type CommandResponse struct {
 	Command string `json:"command"` // Echo of the command that was executed
 }
 
+// ResetResponse is the response from the Python service's /reset endpoint.
+type ResetResponse struct {
+	Success bool   `json:"success"` // Whether the sessions were cleared
+	Message string `json:"message"` // Status message (e.g., "Cleared 2 pairing session(s)")
+}
+
+// ServiceRestartResult reports the outcome of resetting one Fire TV service
+// (see Client.RestartService).
+type ServiceRestartResult struct {
+	ServiceIndex int    `json:"serviceIndex"`      // Which configured service (set by Artemis)
+	URL          string `json:"url"`               // Base URL of the service
+	Reset        bool   `json:"reset"`             // Whether the service accepted the reset
+	Healthy      bool   `json:"healthy"`           // Whether the health check passed afterwards
+	Message      string `json:"message,omitempty"` // The service's reset message
+	Error        string `json:"error,omitempty"`   // Why the reset or health check failed
+}
+
 // ErrorDetail is returned by the Python service when a request fails.
 // FastAPI wraps errors in a {"detail": "message"} format.
 type ErrorDetail struct {
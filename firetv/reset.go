@@ -0,0 +1,96 @@
+package firetv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Endpoint on the Python microservice that clears stuck pairing sessions and
+// remote connections without restarting the process.
+const resetEndpoint = "/reset"
+
+// How long RestartService waits for the service to report healthy after a
+// reset, and how often it re-checks in the meantime. Variables so tests can
+// shorten them.
+var (
+	healthVerifyTimeout  = 15 * time.Second
+	healthVerifyInterval = 1 * time.Second
+)
+
+// ErrResetUnsupported means the Python service has no /reset endpoint (older
+// versions), so it can only be restarted on the host.
+var ErrResetUnsupported = errors.New("fire TV service does not support resetting")
+
+// Reset asks the Python service to drop its pairing sessions and cached
+// remote connections (POST /reset). Returns ErrResetUnsupported if the
+// service doesn't know the endpoint.
+func (c *Client) Reset(ctx context.Context) (*ResetResponse, error) {
+	log.Printf("📺 Resetting Fire TV service at %s...", c.baseURL)
+
+	resp, err := c.postJSON(ctx, c.baseURL+resetEndpoint, []byte("{}"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Fire TV service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reset response: %w", err)
+	}
+
+	// FastAPI answers 404 for unknown paths and 405 for known paths without POST.
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return nil, ErrResetUnsupported
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errDetail ErrorDetail
+		if json.Unmarshal(body, &errDetail) == nil && errDetail.Detail != "" {
+			return nil, fmt.Errorf("reset failed: %s", errDetail.Detail)
+		}
+		return nil, fmt.Errorf("reset failed with status %d", resp.StatusCode)
+	}
+
+	var result ResetResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse reset response: %w", err)
+	}
+	return &result, nil
+}
+
+// RestartService resets the Python service and re-checks its health until it
+// passes or healthVerifyTimeout runs out. The returned error is only set when
+// the reset itself failed; a service that stays unhealthy is reported in the
+// result.
+func (c *Client) RestartService(ctx context.Context) (ServiceRestartResult, error) {
+	result := ServiceRestartResult{URL: c.baseURL}
+
+	reset, err := c.Reset(ctx)
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	result.Reset = true
+	result.Message = reset.Message
+
+	deadline := time.Now().Add(healthVerifyTimeout)
+	for {
+		err := c.CheckHealth(ctx)
+		if err == nil {
+			result.Healthy = true
+			log.Printf("✅ Fire TV service at %s is healthy after reset", c.baseURL)
+			return result, nil
+		}
+		if time.Now().After(deadline) || ctx.Err() != nil {
+			result.Error = fmt.Sprintf("not healthy %s after reset: %v", healthVerifyTimeout, err)
+			log.Printf("⚠️  Fire TV service at %s still unhealthy after reset: %v", c.baseURL, err)
+			return result, nil
+		}
+		time.Sleep(healthVerifyInterval)
+	}
+}
@@ -0,0 +1,65 @@
+package firetv
+
+import "time"
+
+// Limits and pacing for the "open an app and search" composite
+// (POST /api/firetv/search).
+const (
+	MaxSearchQueryLength = 100              // Characters
+	MaxSearchLaunchDelay = 15 * time.Second // Longest wait for an app to start
+
+	// TextChunkSize is how many characters each text_input command carries.
+	// Long strings sent at once are dropped or garbled by some apps' search
+	// boxes, which filter results on every keystroke.
+	TextChunkSize = 16
+
+	// Pauses that let the app's UI catch up between commands.
+	NavigationPause = 400 * time.Millisecond
+	TextChunkPause  = 150 * time.Millisecond
+)
+
+// SearchKeycode is KEYCODE_SEARCH, which opens search in most apps. It's
+// the generic way in for apps without a SearchProfile.
+const SearchKeycode = 84
+
+// SearchProfile is how to reach an app's search box once it has launched.
+type SearchProfile struct {
+	Name string   `json:"name"`
+	Keys []string `json:"keys"` // Navigation commands, in order; nil sends SearchKeycode instead
+}
+
+// GenericSearchProfile is used for apps without their own profile.
+var GenericSearchProfile = SearchProfile{Name: "generic"}
+
+// searchProfiles holds the key sequences for popular apps, by package.
+// Apps change their layouts, so these are best-effort; an app that moves
+// its search box needs its sequence updated here.
+var searchProfiles = map[string]SearchProfile{
+	"com.netflix.ninja":         {Name: "Netflix", Keys: []string{"left", "up", "up", "select"}},
+	"com.amazon.firetv.youtube": {Name: "YouTube", Keys: []string{"left", "up", "select"}},
+	"com.amazon.avod":           {Name: "Prime Video", Keys: []string{"up", "left", "select"}},
+	"com.disney.disneyplus":     {Name: "Disney+", Keys: []string{"left", "up", "select"}},
+	"com.hulu.plus":             {Name: "Hulu", Keys: []string{"up", "right", "select"}},
+}
+
+// SearchProfileFor returns the search profile for an app package, or
+// GenericSearchProfile.
+func SearchProfileFor(appPackage string) SearchProfile {
+	if profile, ok := searchProfiles[appPackage]; ok {
+		return profile
+	}
+	return GenericSearchProfile
+}
+
+// TextChunks splits text into pieces of at most size characters, never
+// splitting a multi-byte character.
+func TextChunks(text string, size int) []string {
+	runes := []rune(text)
+	var chunks []string
+	for len(runes) > 0 {
+		n := min(size, len(runes))
+		chunks = append(chunks, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return chunks
+}
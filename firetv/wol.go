@@ -0,0 +1,67 @@
+package firetv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net"
+)
+
+// DefaultWakeOnLANAddress is where magic packets go unless a broadcast
+// address is given: the local network's broadcast address, discard port.
+const DefaultWakeOnLANAddress = "255.255.255.255:9"
+
+// wakeOnLANPort is used when a broadcast address has no port.
+const wakeOnLANPort = "9"
+
+// ParseMAC validates a 48-bit MAC address in any of the usual notations
+// ("AA:BB:CC:DD:EE:FF", "aa-bb-cc-dd-ee-ff", "aabb.ccdd.eeff").
+func ParseMAC(s string) (net.HardwareAddr, error) {
+	mac, err := net.ParseMAC(s)
+	if err != nil || len(mac) != 6 {
+		return nil, fmt.Errorf("invalid MAC address %q — expected six hex pairs like AA:BB:CC:DD:EE:FF", s)
+	}
+	return mac, nil
+}
+
+// MagicPacket builds a Wake-on-LAN magic packet for mac: six 0xFF bytes
+// followed by the MAC repeated 16 times.
+func MagicPacket(mac net.HardwareAddr) []byte {
+	packet := bytes.Repeat([]byte{0xFF}, 6)
+	for i := 0; i < 16; i++ {
+		packet = append(packet, mac...)
+	}
+	return packet
+}
+
+// WakeOnLAN sends a magic packet for mac over UDP to broadcast ("host" or
+// "host:port"; "" means DefaultWakeOnLANAddress). This goes out straight
+// from the Go server, so it works without the Fire TV service and when the
+// TV is too deeply asleep for the Android TV remote protocol to wake it.
+// Returns the address the packet was sent to.
+//
+// UDP gives no delivery confirmation: a nil error means the packet left
+// this machine, not that the TV woke up.
+func WakeOnLAN(ctx context.Context, mac net.HardwareAddr, broadcast string) (string, error) {
+	addr := broadcast
+	if addr == "" {
+		addr = DefaultWakeOnLANAddress
+	} else if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, wakeOnLANPort)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp4", addr)
+	if err != nil {
+		return addr, fmt.Errorf("failed to open UDP socket to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(MagicPacket(mac)); err != nil {
+		return addr, fmt.Errorf("failed to send magic packet to %s: %w", addr, err)
+	}
+
+	log.Printf("📺 Sent Wake-on-LAN packet for %s to %s", mac, addr)
+	return addr, nil
+}
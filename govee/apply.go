@@ -0,0 +1,133 @@
+package govee
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// applyPowerOnDelay is the pause after turning a device on before the rest
+// of a state is applied, since commands that arrive while the device is
+// still powering up can be dropped (see resetStepDelay).
+// A variable so tests can run without waiting.
+var applyPowerOnDelay = 1 * time.Second
+
+// TargetState is the state a device should be put in. Fields left nil are
+// not changed.
+type TargetState struct {
+	On         *bool       `json:"on,omitempty"`
+	Brightness *int        `json:"brightness,omitempty"` // 0-100
+	Color      *ColorValue `json:"color,omitempty"`
+	ColorTem   *int        `json:"colorTem,omitempty"` // Kelvin, inside the model's range
+}
+
+// Settings returns the state as DeviceSettings, whose OrderedCommands
+// decides the order ApplyState sends it in.
+func (s TargetState) Settings() DeviceSettings {
+	return DeviceSettings{PowerOn: s.On, Brightness: s.Brightness, Color: s.Color, ColorTem: s.ColorTem}
+}
+
+// Validate reports whether the state sets anything and every value is in
+// range. Messages are user-facing.
+func (s TargetState) Validate() error {
+	if s.On == nil && s.Brightness == nil && s.Color == nil && s.ColorTem == nil {
+		return fmt.Errorf("state must set at least one of on, brightness, color, colorTem")
+	}
+	if s.Brightness != nil && (*s.Brightness < 0 || *s.Brightness > 100) {
+		return fmt.Errorf("brightness must be between 0 and 100, got %d", *s.Brightness)
+	}
+	if s.Color != nil && !validColor(*s.Color) {
+		return fmt.Errorf("RGB values must be between 0 and 255, got R=%d G=%d B=%d", s.Color.R, s.Color.G, s.Color.B)
+	}
+	if s.ColorTem != nil {
+		if _, err := colorTemValue(*s.ColorTem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PlannedCommand is one command of the sequence ApplyState sends.
+type PlannedCommand struct {
+	Command string      `json:"command"` // "turn", "colorTem", "color", or "brightness"
+	Value   interface{} `json:"value"`   // bool, int (Kelvin), ColorValue, or int (0-100)
+	Step    string      `json:"step"`    // Human-readable form, e.g. "color 255,120,0"
+}
+
+// Plan returns the commands ApplyState sends for this state, in the same
+// order as DeviceSettings.OrderedCommands: turn on → colorTem → color →
+// brightness → turn off.
+func (s TargetState) Plan() []PlannedCommand {
+	cmds := s.Settings().OrderedCommands()
+	plan := make([]PlannedCommand, 0, len(cmds))
+	for _, cmd := range cmds {
+		switch value := cmd.Value.(type) {
+		case string: // "turn": "on" or "off"
+			plan = append(plan, PlannedCommand{Command: cmd.Name, Value: value == "on", Step: "turn " + value})
+		case ColorValue:
+			plan = append(plan, PlannedCommand{Command: cmd.Name, Value: value, Step: fmt.Sprintf("color %d,%d,%d", value.R, value.G, value.B)})
+		case int: // "colorTem" or "brightness"
+			plan = append(plan, PlannedCommand{Command: cmd.Name, Value: value, Step: fmt.Sprintf("%s %d", cmd.Name, value)})
+		}
+	}
+	return plan
+}
+
+// ApplyState puts a device in the target state by sending the commands of
+// state.Plan() in order, pausing briefly after turning the device on.
+//
+// A positive transition fades color and brightness (one after the other,
+// each over the full transition) and blocks until both finish. colorTem is
+// never faded.
+//
+// Returns the steps that were sent, in order (e.g., "turn on",
+// "color 255,0,0", "brightness 40"); on failure, the ones sent before it.
+func (c *Client) ApplyState(ctx context.Context, deviceID, model string, state TargetState, transition time.Duration) ([]string, error) {
+	if err := state.Validate(); err != nil {
+		return nil, err
+	}
+
+	plan := state.Plan()
+	var steps []string
+	for i, command := range plan {
+		if i > 0 && plan[i-1].Command == "turn" {
+			time.Sleep(applyPowerOnDelay)
+		}
+		if err := c.sendPlanned(ctx, deviceID, model, command, transition); err != nil {
+			return steps, fmt.Errorf("%s failed: %w", command.Step, err)
+		}
+		steps = append(steps, command.Step)
+	}
+
+	log.Printf("💡 Applied state to %s: %v", deviceID, steps)
+	return steps, nil
+}
+
+// sendPlanned sends one planned command, fading color and brightness when
+// transition is positive.
+func (c *Client) sendPlanned(ctx context.Context, deviceID, model string, command PlannedCommand, transition time.Duration) error {
+	switch command.Command {
+	case "turn":
+		if command.Value.(bool) {
+			return c.TurnOn(ctx, deviceID, model)
+		}
+		return c.TurnOff(ctx, deviceID, model)
+	case "colorTem":
+		return c.SetColorTemperature(ctx, deviceID, model, command.Value.(int))
+	case "color":
+		value := command.Value.(ColorValue)
+		if transition > 0 {
+			return c.FadeColor(ctx, deviceID, model, value, transition)
+		}
+		return c.SetColor(ctx, deviceID, model, value.R, value.G, value.B)
+	case "brightness":
+		value := command.Value.(int)
+		if transition > 0 {
+			return c.FadeBrightness(ctx, deviceID, model, value, transition)
+		}
+		return c.SetBrightness(ctx, deviceID, model, value)
+	default:
+		return fmt.Errorf("unknown planned command %q", command.Command)
+	}
+}
@@ -0,0 +1,117 @@
+package govee
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestApplyState_OrdersPowerColorBrightness(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{})
+	on, level := true, 40
+
+	steps, err := client.ApplyState(context.Background(), "AA:BB", "H6008", TargetState{
+		Brightness: &level,
+		Color:      &ColorValue{R: 255, G: 120, B: 0},
+		On:         &on,
+	}, 0)
+	if err != nil {
+		t.Fatalf("ApplyState returned error: %v", err)
+	}
+
+	if got := stub.names(); got != "turn,color,brightness" {
+		t.Errorf("expected turn,color,brightness, got %s", got)
+	}
+	if got := strings.Join(steps, "; "); got != "turn on; color 255,120,0; brightness 40" {
+		t.Errorf("unexpected steps: %s", got)
+	}
+}
+
+func TestApplyState_TurnsOffLast(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{})
+	off, level := false, 40
+
+	steps, err := client.ApplyState(context.Background(), "AA:BB", "H6008", TargetState{On: &off, Brightness: &level}, 0)
+	if err != nil {
+		t.Fatalf("ApplyState returned error: %v", err)
+	}
+	if got := stub.names(); got != "brightness,turn" || strings.Join(steps, "; ") != "brightness 40; turn off" {
+		t.Errorf("expected brightness then turn off, got commands %s, steps %v", got, steps)
+	}
+}
+
+func TestApplyState_SendsColorTem(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{})
+	on, kelvin, level := true, 4000, 60
+
+	steps, err := client.ApplyState(context.Background(), "AA:BB", "H6008", TargetState{On: &on, ColorTem: &kelvin, Brightness: &level}, 0)
+	if err != nil {
+		t.Fatalf("ApplyState returned error: %v", err)
+	}
+	if got := strings.Join(steps, "; "); got != "turn on; colorTem 4000; brightness 60" {
+		t.Errorf("unexpected steps: %s", got)
+	}
+	if got := stub.names(); got != "turn,colorTem,brightness" {
+		t.Errorf("expected turn,colorTem,brightness, got %s", got)
+	}
+}
+
+func TestApplyState_StopsAtFirstFailure(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{reject: map[string]string{"color": "Device Offline"}})
+	on, level := true, 40
+
+	steps, err := client.ApplyState(context.Background(), "AA:BB", "H6008", TargetState{
+		On:         &on,
+		Color:      &ColorValue{R: 1, G: 2, B: 3},
+		Brightness: &level,
+	}, 0)
+	if err == nil || !IsOfflineError(err) {
+		t.Fatalf("expected an offline error, got %v", err)
+	}
+	if len(steps) != 1 || stub.names() != "turn,color" {
+		t.Errorf("expected nothing after the failed color, got steps %v, commands %s", steps, stub.names())
+	}
+}
+
+func TestTargetState_Validate(t *testing.T) {
+	low, high, warm := 0, 101, 2700
+	tests := []struct {
+		name    string
+		state   TargetState
+		wantErr bool
+	}{
+		{"empty", TargetState{}, true},
+		{"brightness in range", TargetState{Brightness: &low}, false},
+		{"brightness too high", TargetState{Brightness: &high}, true},
+		{"bad color", TargetState{Color: &ColorValue{R: 256}}, true},
+		{"colorTem in range", TargetState{ColorTem: &warm}, false},
+		{"colorTem too low", TargetState{ColorTem: &low}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.state.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTargetState_Plan(t *testing.T) {
+	on, off, level := true, false, 25
+
+	plan := TargetState{Brightness: &level, On: &on}.Plan()
+	if len(plan) != 2 || plan[0].Command != "turn" || plan[0].Value != true || plan[1].Value != 25 {
+		t.Errorf("expected turn on then brightness 25, got %+v", plan)
+	}
+
+	// Same order as DeviceSettings.OrderedCommands, including off last
+	kelvin := 3000
+	state := TargetState{On: &off, Color: &ColorValue{R: 1}, ColorTem: &kelvin, Brightness: &level}
+	var commands []ControlCommand
+	for _, command := range state.Plan() {
+		commands = append(commands, ControlCommand{Name: command.Command})
+	}
+	if got, want := commandNames(commands), commandNames(state.Settings().OrderedCommands()); got != want {
+		t.Errorf("expected Plan to follow OrderedCommands (%s), got %s", want, got)
+	}
+}
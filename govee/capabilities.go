@@ -0,0 +1,285 @@
+package govee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pantheon/artemis/upstream"
+)
+
+// Outcomes of probing one command.
+const (
+	ProbeVerified = "verified" // Govee accepted the command
+	ProbeRejected = "rejected" // Govee refused the command
+	ProbeSkipped  = "skipped"  // There was no safe way to send it right now
+)
+
+// probedCommands are the commands ProbeCommands knows how to send without
+// changing the light, in the order they're tried.
+var probedCommands = []string{"turn", "brightness", "color", "colorTem"}
+
+// CommandProbe is the outcome of probing one command.
+type CommandProbe struct {
+	Command    string `json:"command"`          // "turn", "brightness", "color", "colorTem"
+	Advertised bool   `json:"advertised"`       // Listed in the device's supportCmds
+	Result     string `json:"result"`           // ProbeVerified, ProbeRejected, or ProbeSkipped
+	Detail     string `json:"detail,omitempty"` // Why it was rejected or skipped
+}
+
+// CapabilityCheck compares the commands a model advertises with the ones
+// Govee actually accepts for it.
+type CapabilityCheck struct {
+	Model  string         `json:"model"`
+	Probes []CommandProbe `json:"probes"`
+
+	// Commands whose advertised support didn't match the probe, in
+	// plain language (e.g. "advertises color, but Govee rejected it").
+	Discrepancies []string `json:"discrepancies"`
+
+	// Every command was either verified or rejected — none were skipped.
+	// A complete check isn't probed again (see CapabilityCache).
+	Complete bool `json:"complete"`
+
+	// The state read after probing differed from the one before, so the
+	// earlier state was re-applied.
+	Restored bool `json:"restored,omitempty"`
+
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// ProbeCommands checks which commands a retrievable device really accepts
+// by re-sending values it already has: its current power state, brightness,
+// and color or color temperature. None of these should visibly change the
+// light. Commands that can't be re-sent safely — anything but "turn" while
+// the light is off, color in white mode, colorTem in color mode — are
+// skipped. Advertised commands the probe doesn't know are skipped too.
+//
+// If the state read afterwards differs from the one before, the earlier
+// power, brightness, and color are re-applied.
+//
+// Returns an error, and no partial result, if the state can't be read, the
+// device is offline, or a probe fails for a reason other than Govee
+// refusing the command (network trouble, rate limiting) — those say nothing
+// about what the model supports.
+func (c *Client) ProbeCommands(ctx context.Context, deviceID, model string, advertised []string) (*CapabilityCheck, error) {
+	log.Printf("🔬 Probing commands for device %s (%s)", deviceID, model)
+
+	before, err := c.readState(ctx, deviceID, model)
+	if err != nil {
+		return nil, err
+	}
+	if before.Online != nil && !*before.Online {
+		return nil, fmt.Errorf("device %s is offline", deviceID)
+	}
+
+	check := &CapabilityCheck{Model: model, Probes: []CommandProbe{}}
+	commands := slices.Clone(probedCommands)
+	for _, command := range advertised {
+		if !slices.Contains(commands, command) {
+			commands = append(commands, command)
+		}
+	}
+
+	for _, command := range commands {
+		probe := CommandProbe{Command: command, Advertised: slices.Contains(advertised, command)}
+
+		value, reason := probeValue(command, before)
+		if reason != "" {
+			probe.Result, probe.Detail = ProbeSkipped, reason
+			check.Probes = append(check.Probes, probe)
+			continue
+		}
+
+		err := c.sendControlCommand(ctx, deviceID, model, command, value)
+		switch {
+		case err == nil:
+			probe.Result = ProbeVerified
+		case inconclusiveProbeError(err):
+			return nil, fmt.Errorf("probing %s: %w", command, err)
+		default:
+			probe.Result, probe.Detail = ProbeRejected, err.Error()
+		}
+		check.Probes = append(check.Probes, probe)
+	}
+
+	// The probes re-sent what the device had; put it back if it changed anyway.
+	if after, err := c.readState(ctx, deviceID, model); err == nil && !sameLightState(before, after) {
+		log.Printf("⚠️  Device %s changed while probing, restoring its earlier state", deviceID)
+		if _, err := c.ApplyState(ctx, deviceID, model, restoreState(before), 0); err != nil {
+			log.Printf("❌ Failed to restore device %s after probing: %v", deviceID, err)
+		}
+		check.Restored = true
+	}
+
+	check.CheckedAt = time.Now().UTC()
+	check.summarize()
+	log.Printf("✅ Probed %d command(s) for %s: %d discrepancy(ies)", len(check.Probes), model, len(check.Discrepancies))
+	return check, nil
+}
+
+// summarize fills in Discrepancies and Complete from the probes.
+func (c *CapabilityCheck) summarize() {
+	c.Discrepancies = []string{}
+	c.Complete = true
+	for _, probe := range c.Probes {
+		switch {
+		case probe.Result == ProbeSkipped:
+			c.Complete = false
+		case probe.Result == ProbeRejected && probe.Advertised:
+			c.Discrepancies = append(c.Discrepancies, fmt.Sprintf("advertises %s, but Govee rejected it", probe.Command))
+		case probe.Result == ProbeVerified && !probe.Advertised:
+			c.Discrepancies = append(c.Discrepancies, fmt.Sprintf("doesn't advertise %s, but Govee accepted it", probe.Command))
+		}
+	}
+}
+
+// readState reads and normalizes a device's state.
+func (c *Client) readState(ctx context.Context, deviceID, model string) (DeviceState, error) {
+	resp, err := c.GetDeviceState(ctx, deviceID, model)
+	if err != nil {
+		return DeviceState{}, err
+	}
+	return NormalizeState(resp, 0), nil
+}
+
+// probeValue returns the value that re-sends what the device already has
+// for command, or why the command can't be probed safely.
+func probeValue(command string, state DeviceState) (interface{}, string) {
+	if command == "turn" {
+		if state.PowerOn == nil {
+			return nil, "the device didn't report whether it's on"
+		}
+		if *state.PowerOn {
+			return "on", ""
+		}
+		return "off", ""
+	}
+	if !slices.Contains(probedCommands, command) {
+		return nil, "no safe probe for this command"
+	}
+	if !state.IsOn() {
+		return nil, "the light is off, and this command would turn it on"
+	}
+
+	switch command {
+	case "brightness":
+		if state.Brightness == nil {
+			return nil, "the device didn't report its brightness"
+		}
+		return *state.Brightness, ""
+	case "color":
+		if state.ColorTem != nil {
+			return nil, "the light is in white (color temperature) mode"
+		}
+		if state.Color == nil {
+			return nil, "the device didn't report its color"
+		}
+		return *state.Color, ""
+	default: // colorTem
+		if state.ColorTem == nil {
+			return nil, "the light is in color mode"
+		}
+		return *state.ColorTem, ""
+	}
+}
+
+// inconclusiveProbeError reports whether a failed probe says nothing about
+// the command itself: no response, the device went offline, or Govee was
+// rate limiting.
+func inconclusiveProbeError(err error) bool {
+	if _, ok := upstream.As(err); ok {
+		return true
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	return IsOfflineError(err) || IsRateLimitError(err)
+}
+
+// sameLightState reports whether two reads agree on power, brightness, and
+// color. Values missing from either read are ignored.
+func sameLightState(a, b DeviceState) bool {
+	if a.PowerOn != nil && b.PowerOn != nil && *a.PowerOn != *b.PowerOn {
+		return false
+	}
+	if a.Brightness != nil && b.Brightness != nil && *a.Brightness != *b.Brightness {
+		return false
+	}
+	if a.Color != nil && b.Color != nil && *a.Color != *b.Color {
+		return false
+	}
+	return true
+}
+
+// restoreState is the TargetState that puts a device back the way state
+// was read. Color is only restored for lights that were in color mode.
+func restoreState(state DeviceState) TargetState {
+	target := TargetState{On: state.PowerOn}
+	if state.IsOn() {
+		target.Brightness = state.Brightness
+		if state.ColorTem == nil {
+			target.Color = state.Color
+		}
+	}
+	if target.On == nil && target.Brightness == nil && target.Color == nil {
+		on := state.IsOn()
+		target.On = &on
+	}
+	return target
+}
+
+// CapabilityCache keeps what's been learned about each model's commands.
+// A single probe rarely covers everything — a light in color mode can't
+// test colorTem — so each probe's verified and rejected commands are merged
+// into what earlier probes of the model found. Held in memory; a restart
+// starts over. Safe for concurrent use.
+type CapabilityCache struct {
+	mu     sync.RWMutex
+	checks map[string]CapabilityCheck // Uppercased model → merged check
+}
+
+// NewCapabilityCache creates an empty cache.
+func NewCapabilityCache() *CapabilityCache {
+	return &CapabilityCache{checks: make(map[string]CapabilityCheck)}
+}
+
+// Get returns the merged check for a model, if it has been probed.
+func (c *CapabilityCache) Get(model string) (CapabilityCheck, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	check, ok := c.checks[strings.ToUpper(model)]
+	return check, ok
+}
+
+// Merge folds a new check into the model's cached one and returns the
+// result. A command the new check skipped keeps its earlier verified or
+// rejected result; anything the new check settled replaces it.
+func (c *CapabilityCache) Merge(check CapabilityCheck) CapabilityCheck {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := strings.ToUpper(check.Model)
+	merged := check
+	merged.Probes = slices.Clone(check.Probes)
+	if previous, ok := c.checks[key]; ok {
+		for i, probe := range merged.Probes {
+			if probe.Result != ProbeSkipped {
+				continue
+			}
+			j := slices.IndexFunc(previous.Probes, func(p CommandProbe) bool { return p.Command == probe.Command })
+			if j != -1 && previous.Probes[j].Result != ProbeSkipped {
+				merged.Probes[i].Result = previous.Probes[j].Result
+				merged.Probes[i].Detail = previous.Probes[j].Detail
+			}
+		}
+	}
+	merged.summarize()
+	c.checks[key] = merged
+	return merged
+}
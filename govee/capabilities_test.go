@@ -0,0 +1,126 @@
+package govee
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// probeResults maps each probed command to its result.
+func probeResults(check *CapabilityCheck) map[string]string {
+	results := make(map[string]string)
+	for _, probe := range check.Probes {
+		results[probe.Command] = probe.Result
+	}
+	return results
+}
+
+func TestProbeCommands_ReportsDiscrepancies(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{
+		states: []string{stateBody},
+		reject: map[string]string{"color": "Unsupported Cmd"},
+	})
+
+	check, err := client.ProbeCommands(context.Background(), "AA:BB:CC:DD:EE:FF:00:11", "H6159", []string{"turn", "color"})
+	if err != nil {
+		t.Fatalf("ProbeCommands returned error: %v", err)
+	}
+
+	// The light is in color mode, so colorTem can't be tested safely.
+	want := map[string]string{"turn": ProbeVerified, "brightness": ProbeVerified, "color": ProbeRejected, "colorTem": ProbeSkipped}
+	for command, result := range want {
+		if got := probeResults(check)[command]; got != result {
+			t.Errorf("%s: expected %s, got %s", command, result, got)
+		}
+	}
+	if got := strings.Join(check.Discrepancies, "; "); got != "doesn't advertise brightness, but Govee accepted it; advertises color, but Govee rejected it" {
+		t.Errorf("unexpected discrepancies: %s", got)
+	}
+	if check.Complete || check.Restored {
+		t.Errorf("expected an incomplete check with nothing restored, got %+v", check)
+	}
+
+	// Every probe re-sends the current value.
+	sent := stub.commands()
+	if got := commandNames(sent); got != "turn,brightness,color" {
+		t.Errorf("expected turn,brightness,color, got %s", got)
+	}
+	if sent[0].Value != "on" || sent[1].Value != float64(42) {
+		t.Errorf("expected the current power and brightness to be re-sent, got %+v", sent)
+	}
+}
+
+func TestProbeCommands_OffLightOnlyProbesTurn(t *testing.T) {
+	var sent []ControlCommand
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/state") {
+			w.Write([]byte(`{"code": 200, "data": {"properties": [{"powerState": "off"}, {"brightness": 42}]}}`))
+			return
+		}
+		var req ControlRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sent = append(sent, req.Cmd)
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	})
+
+	check, err := client.ProbeCommands(context.Background(), "AA:BB", "H6008", []string{"turn", "brightness"})
+	if err != nil {
+		t.Fatalf("ProbeCommands returned error: %v", err)
+	}
+	if len(sent) != 1 || sent[0].Name != "turn" || sent[0].Value != "off" {
+		t.Errorf("expected only turn off to be sent, got %+v", sent)
+	}
+	if got := probeResults(check)["brightness"]; got != ProbeSkipped {
+		t.Errorf("expected brightness to be skipped, got %s", got)
+	}
+}
+
+func TestProbeCommands_OfflineIsAnError(t *testing.T) {
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/state") {
+			w.Write([]byte(stateBody))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code": 400, "message": "Device Offline"}`))
+	})
+
+	if _, err := client.ProbeCommands(context.Background(), "AA:BB", "H6159", nil); err == nil || !IsOfflineError(err) {
+		t.Errorf("expected an offline error, got %v", err)
+	}
+}
+
+func TestCapabilityCache_Merge(t *testing.T) {
+	cache := NewCapabilityCache()
+
+	// A light in color mode can't test colorTem...
+	first := cache.Merge(CapabilityCheck{Model: "h6159", Probes: []CommandProbe{
+		{Command: "color", Advertised: true, Result: ProbeVerified},
+		{Command: "colorTem", Advertised: true, Result: ProbeSkipped},
+	}})
+	if first.Complete {
+		t.Fatalf("expected an incomplete check, got %+v", first)
+	}
+
+	// ...and in white mode can't test color; together they cover both.
+	merged := cache.Merge(CapabilityCheck{Model: "H6159", Probes: []CommandProbe{
+		{Command: "color", Advertised: true, Result: ProbeSkipped},
+		{Command: "colorTem", Advertised: true, Result: ProbeRejected, Detail: "Unsupported Cmd"},
+	}})
+	if !merged.Complete {
+		t.Errorf("expected the merged check to be complete, got %+v", merged)
+	}
+	if got := probeResults(&merged); got["color"] != ProbeVerified || got["colorTem"] != ProbeRejected {
+		t.Errorf("unexpected merged results: %v", got)
+	}
+	if len(merged.Discrepancies) != 1 {
+		t.Errorf("expected one discrepancy, got %v", merged.Discrepancies)
+	}
+
+	cached, ok := cache.Get("H6159")
+	if !ok || !cached.Complete {
+		t.Errorf("expected the merged check to be cached, got %+v", cached)
+	}
+}
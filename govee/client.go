@@ -2,18 +2,24 @@ package govee
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
+
+	"github.com/pantheon/artemis/tracing"
+	"github.com/pantheon/artemis/upstream"
 )
 
 const (
 	// Govee Developer API base URL
 	// All API requests go to this base + endpoint path
-	baseURL = "https://developer-api.govee.com"
+	defaultBaseURL = "https://developer-api.govee.com"
 
 	// API endpoints
 	devicesEndpoint = "/v1/devices"         // GET - list all devices
@@ -23,35 +29,165 @@ const (
 	// HTTP timeout for API requests
 	// Govee API typically responds within 1-2 seconds
 	requestTimeout = 10 * time.Second
+
+	// How long GetDevicesCached reuses a device list unless changed with
+	// SetDeviceCacheTTL
+	DefaultDeviceCacheTTL = time.Minute
+
+	// Names the Govee API in classified errors (see upstream.Error)
+	serviceName = "Govee API"
+)
+
+// Supported Govee API versions, selected with GOVEE_API_VERSION.
+const (
+	APIVersionV1 = "v1" // developer-api.govee.com (default)
+	APIVersionV2 = "v2" // openapi.api.govee.com platform API — see v2.go
 )
 
 // Client handles all communication with the Govee Developer API
 // It maintains the API key and HTTP client for making requests
 type Client struct {
-	apiKey     string       // Govee API key from developer.govee.com
-	httpClient *http.Client // Reusable HTTP client with timeout
+	apiKey     string            // Govee API key from developer.govee.com
+	apiVersion string            // APIVersionV1 or APIVersionV2
+	baseURL    string            // API base URL (overridable so tests can use a stub server)
+	httpClient *http.Client      // Reusable HTTP client with timeout
+	breaker    *upstream.Breaker // Optional; see SetBreaker
+	limiter    *rateLimiter      // DefaultRateLimit unless changed with SetRateLimit; nil = no limit
+	retries    int               // Retries after transient failures; see SetRetries
+
+	// colorTem ranges reported by Govee in the device list, keyed by model.
+	// Populated by GetDevices and consulted by SetColorTemperature.
+	rangesMu       sync.RWMutex
+	reportedRanges map[string]ColorTemRange
+
+	// v2 timer capability instances reported in the device list, keyed by
+	// model. Populated by GetDevices and consulted by SetDeviceTimer.
+	timersMu       sync.RWMutex
+	timerInstances map[string]string
+
+	// Last successful device list, served by GetDevicesCached for up to
+	// deviceCacheTTL, and the fetch in flight that will replace it.
+	// devicesGen counts InvalidateDeviceCache calls; a list fetched under
+	// an older generation is never cached.
+	devicesMu      sync.Mutex
+	devices        []Device
+	devicesAt      time.Time
+	devicesGen     uint64
+	devicesFetch   *deviceFetch
+	deviceCacheTTL time.Duration
+
+	// Whether control requests are checked against the cached device list
+	// before they're sent; see SetDeviceCheck.
+	skipDeviceCheck bool
+
+	// Per-device mutexes (*sync.Mutex by normalized device ID) that keep
+	// multi-step sequences like SetColorKeepBrightness from interleaving.
+	deviceLocks sync.Map
+
+	// Background fades by normalized device ID, cancelled by the next
+	// command to the device or by CancelFades; see startFade.
+	fadesMu sync.Mutex
+	fades   map[string]*backgroundFade
+	fadesWG sync.WaitGroup
 }
 
 // NewClient creates a new Govee API client with the provided API key
 // The API key can be obtained from https://developer.govee.com
 // after creating an application in the developer portal
+// Requests are limited to DefaultRateLimit a minute (see SetRateLimit).
 func NewClient(apiKey string) *Client {
+	return NewClientWithVersion(apiKey, APIVersionV1)
+}
+
+// NewClientWithVersion creates a Govee API client that talks to the given
+// API version (APIVersionV1 or APIVersionV2). Both expose the same methods;
+// v2 responses are translated into the v1 models.
+// Unknown versions fall back to v1 (config.Validate rejects them earlier).
+func NewClientWithVersion(apiKey, version string) *Client {
+	baseURL := defaultBaseURL
+	if version == APIVersionV2 {
+		baseURL = defaultBaseURLV2
+	} else {
+		version = APIVersionV1
+	}
+
 	return &Client{
-		apiKey: apiKey,
+		apiKey:     apiKey,
+		apiVersion: version,
+		baseURL:    baseURL,
 		httpClient: &http.Client{
-			Timeout: requestTimeout,
+			Timeout:   requestTimeout,
+			Transport: tracing.NewTransport(), // client span per outbound request
 		},
+		limiter:        newRateLimiter(DefaultRateLimit, false),
+		retries:        DefaultRetries,
+		deviceCacheTTL: DefaultDeviceCacheTTL,
+		reportedRanges: make(map[string]ColorTemRange),
+		timerInstances: make(map[string]string),
+		fades:          make(map[string]*backgroundFade),
 	}
 }
 
+// APIVersion returns the Govee API version this client uses.
+func (c *Client) APIVersion() string {
+	return c.apiVersion
+}
+
+// SetBaseURL points the client at a different API host.
+// Used by tests in other packages to route requests to a stub server.
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = baseURL
+}
+
+// SetBreaker guards every request to the Govee API with breaker, so an
+// outage fails fast instead of each request waiting out the timeout.
+// nil (the default) disables it.
+func (c *Client) SetBreaker(breaker *upstream.Breaker) {
+	c.breaker = breaker
+}
+
+// SetProxy sends this client's requests through proxyURL instead of the
+// proxy from HTTP_PROXY/HTTPS_PROXY/NO_PROXY. Call before SetStrictSerial
+// and before the client is used.
+func (c *Client) SetProxy(proxyURL *url.URL) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	c.httpClient.Transport = &tracing.Transport{Base: transport}
+}
+
 // GetDevices retrieves all Govee devices associated with the API key
 // Returns a list of devices with their capabilities and support commands
 // This should be called once on app startup to discover available devices
-func (c *Client) GetDevices() ([]Device, error) {
+//
+// Transient failures (5xx, timeouts, refused connections) are retried with
+// backoff; see SetRetries.
+func (c *Client) GetDevices(ctx context.Context) ([]Device, error) {
+	c.devicesMu.Lock()
+	gen := c.devicesGen
+	c.devicesMu.Unlock()
+
+	var devices []Device
+	err := c.withRetries(ctx, "device list", func() error {
+		var err error
+		if c.apiVersion == APIVersionV2 {
+			devices, err = c.getDevicesV2(ctx)
+		} else {
+			devices, err = c.getDevicesV1(ctx)
+		}
+		return err
+	})
+	if err == nil {
+		c.rememberDevices(devices, gen)
+	}
+	return devices, err
+}
+
+// getDevicesV1 lists devices with one v1 API request.
+func (c *Client) getDevicesV1(ctx context.Context) ([]Device, error) {
 	log.Println("💡 Fetching Govee devices...")
 
 	// Create GET request to devices endpoint
-	req, err := http.NewRequest("GET", baseURL+devicesEndpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+devicesEndpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -61,7 +197,7 @@ func (c *Client) GetDevices() ([]Device, error) {
 	req.Header.Set("Govee-API-Key", c.apiKey)
 
 	// Execute the request
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch devices: %w", err)
 	}
@@ -77,9 +213,9 @@ func (c *Client) GetDevices() ([]Device, error) {
 	if resp.StatusCode != http.StatusOK {
 		var errResp ErrorResponse
 		if err := json.Unmarshal(body, &errResp); err == nil {
-			return nil, fmt.Errorf("govee API error (code %d): %s", errResp.Code, errResp.Message)
+			return nil, statusError(resp.StatusCode, fmt.Errorf("govee API error (code %d): %s", errResp.Code, errResp.Message))
 		}
-		return nil, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body))
+		return nil, statusError(resp.StatusCode, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body)))
 	}
 
 	// Parse successful response
@@ -88,21 +224,156 @@ func (c *Client) GetDevices() ([]Device, error) {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	// Check API response code — Govee can answer HTTP 200 with an error body
+	if devicesResp.Code != 200 {
+		return nil, fmt.Errorf("govee API error: %s (code %d)", devicesResp.Message, devicesResp.Code)
+	}
+
+	// Remember per-model colorTem ranges for validating SetColorTemperature
+	c.rememberColorTemRanges(devicesResp.Data.Devices)
+
 	log.Printf("💡 Found %d Govee device(s)", len(devicesResp.Data.Devices))
 	return devicesResp.Data.Devices, nil
 }
 
+// deviceFetchTimeout bounds a device list fetch shared by GetDevicesCached
+// callers. The fetch doesn't end with the caller that started it, so it
+// needs a limit of its own; this covers a request and its retries.
+const deviceFetchTimeout = time.Minute
+
+// deviceFetch is a device list fetch in flight, shared by every
+// GetDevicesCached call that misses the cache while it runs. devices and
+// err are set before done is closed.
+type deviceFetch struct {
+	done    chan struct{}
+	devices []Device
+	err     error
+}
+
+// GetDevicesCached returns the device list from the last successful
+// GetDevices if it is younger than the client's cache TTL (see
+// SetDeviceCacheTTL), and otherwise fetches a fresh one. Meant for
+// read-heavy paths like search-as-you-type and device checks, where listing
+// devices from Govee on every request would quickly exhaust the rate limit.
+//
+// Calls that miss the cache while a fetch is running wait for that fetch
+// instead of starting their own, so a burst of requests costs one Govee
+// request. Each caller stops waiting when its own ctx ends. Safe for
+// concurrent use.
+func (c *Client) GetDevicesCached(ctx context.Context) ([]Device, error) {
+	c.devicesMu.Lock()
+	if c.devices != nil && time.Since(c.devicesAt) < c.deviceCacheTTL {
+		devices := c.devices
+		c.devicesMu.Unlock()
+		return devices, nil
+	}
+	fetch := c.devicesFetch
+	if fetch == nil {
+		fetch = &deviceFetch{done: make(chan struct{})}
+		c.devicesFetch = fetch
+		go c.fetchDevices(context.WithoutCancel(ctx), fetch)
+	}
+	c.devicesMu.Unlock()
+
+	select {
+	case <-fetch.done:
+		return fetch.devices, fetch.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetchDevices runs a shared fetch for GetDevicesCached. A successful one
+// fills the cache (see rememberDevices).
+func (c *Client) fetchDevices(ctx context.Context, fetch *deviceFetch) {
+	ctx, cancel := context.WithTimeout(ctx, deviceFetchTimeout)
+	defer cancel()
+	fetch.devices, fetch.err = c.GetDevices(ctx)
+
+	c.devicesMu.Lock()
+	if c.devicesFetch == fetch {
+		c.devicesFetch = nil
+	}
+	c.devicesMu.Unlock()
+	close(fetch.done)
+}
+
+// InvalidateDeviceCache drops the cached device list, so the next
+// GetDevicesCached call fetches a fresh one. A fetch already in flight is
+// left to its waiters but no longer shared or cached: it may have read the
+// list from before whatever change prompted the invalidation.
+func (c *Client) InvalidateDeviceCache() {
+	c.devicesMu.Lock()
+	defer c.devicesMu.Unlock()
+
+	c.devices = nil
+	c.devicesAt = time.Time{}
+	c.devicesGen++
+	c.devicesFetch = nil
+}
+
+// SetDeviceCacheTTL sets how long GetDevicesCached reuses a device list.
+// 0 makes every call fetch a fresh one. Call before the client is used.
+func (c *Client) SetDeviceCacheTTL(ttl time.Duration) {
+	c.deviceCacheTTL = max(ttl, 0)
+}
+
+// SetDeviceCheck turns checking control requests against the cached device
+// list on (the default) or off. The check catches unknown devices, model
+// mismatches and unsupported commands without a round trip to Govee; turn
+// it off if the list goes stale faster than the cache TTL.
+func (c *Client) SetDeviceCheck(enabled bool) {
+	c.skipDeviceCheck = !enabled
+}
+
+// DeviceCheck reports whether control requests should be checked against
+// the cached device list (see SetDeviceCheck).
+func (c *Client) DeviceCheck() bool {
+	return !c.skipDeviceCheck
+}
+
+// rememberDevices records a freshly fetched device list for GetDevicesCached,
+// unless the cache was invalidated since generation gen, when the fetch
+// started.
+func (c *Client) rememberDevices(devices []Device, gen uint64) {
+	c.devicesMu.Lock()
+	defer c.devicesMu.Unlock()
+
+	if gen != c.devicesGen {
+		return
+	}
+	if devices == nil {
+		devices = []Device{}
+	}
+	c.devices = devices
+	c.devicesAt = time.Now()
+}
+
+// CheckHealth verifies the Govee API is reachable and the API key is valid.
+// Govee has no health endpoint, so this lists devices (one API request).
+// Returns nil if healthy, or an error describing the problem.
+func (c *Client) CheckHealth(ctx context.Context) error {
+	if _, err := c.GetDevices(ctx); err != nil {
+		return fmt.Errorf("govee API check failed: %w", err)
+	}
+	return nil
+}
+
 // GetDeviceState queries the current state of a Govee device
 // Returns the device's current power state (on/off), brightness, color, etc.
 // deviceID: Device MAC address from GetDevices()
 // model: Device model number from GetDevices()
-func (c *Client) GetDeviceState(deviceID, model string) (*DeviceStateResponse, error) {
+func (c *Client) GetDeviceState(ctx context.Context, deviceID, model string) (*DeviceStateResponse, error) {
+	if c.apiVersion == APIVersionV2 {
+		return c.getDeviceStateV2(ctx, deviceID, model)
+	}
+
 	// Build URL with query parameters
 	// The Govee state endpoint requires device and model as query params
-	url := fmt.Sprintf("%s%s?device=%s&model=%s", baseURL, stateEndpoint, deviceID, model)
+	url := fmt.Sprintf("%s%s?device=%s&model=%s", c.baseURL, stateEndpoint, deviceID, model)
 
 	// Create GET request to state endpoint
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -111,7 +382,7 @@ func (c *Client) GetDeviceState(deviceID, model string) (*DeviceStateResponse, e
 	req.Header.Set("Govee-API-Key", c.apiKey)
 
 	// Execute the request
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query device state: %w", err)
 	}
@@ -127,9 +398,9 @@ func (c *Client) GetDeviceState(deviceID, model string) (*DeviceStateResponse, e
 	if resp.StatusCode != http.StatusOK {
 		var errResp ErrorResponse
 		if err := json.Unmarshal(body, &errResp); err == nil {
-			return nil, fmt.Errorf("govee API error (code %d): %s", errResp.Code, errResp.Message)
+			return nil, statusError(resp.StatusCode, fmt.Errorf("govee API error (code %d): %s", errResp.Code, errResp.Message))
 		}
-		return nil, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body))
+		return nil, statusError(resp.StatusCode, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body)))
 	}
 
 	// Parse successful response
@@ -138,23 +409,28 @@ func (c *Client) GetDeviceState(deviceID, model string) (*DeviceStateResponse, e
 		return nil, fmt.Errorf("failed to parse state response: %w", err)
 	}
 
+	// Check API response code — Govee can answer HTTP 200 with an error body
+	if stateResp.Code != 200 {
+		return nil, fmt.Errorf("govee API error: %s (code %d)", stateResp.Message, stateResp.Code)
+	}
+
 	return &stateResp, nil
 }
 
 // TurnOn turns on a Govee device
 // deviceID: Device MAC address from GetDevices()
 // model: Device model number from GetDevices()
-func (c *Client) TurnOn(deviceID, model string) error {
+func (c *Client) TurnOn(ctx context.Context, deviceID, model string) error {
 	log.Printf("💡 Turning ON device %s", deviceID)
-	return c.sendControlCommand(deviceID, model, "turn", "on")
+	return c.sendControlCommand(ctx, deviceID, model, "turn", "on")
 }
 
 // TurnOff turns off a Govee device
 // deviceID: Device MAC address from GetDevices()
 // model: Device model number from GetDevices()
-func (c *Client) TurnOff(deviceID, model string) error {
+func (c *Client) TurnOff(ctx context.Context, deviceID, model string) error {
 	log.Printf("💡 Turning OFF device %s", deviceID)
-	return c.sendControlCommand(deviceID, model, "turn", "off")
+	return c.sendControlCommand(ctx, deviceID, model, "turn", "off")
 }
 
 // SetBrightness sets the brightness level of a Govee device
@@ -163,14 +439,14 @@ func (c *Client) TurnOff(deviceID, model string) error {
 // level: Brightness level from 0 (dimmest) to 100 (brightest)
 //
 // Note: Only works if device.SupportCmds contains "brightness"
-func (c *Client) SetBrightness(deviceID, model string, level int) error {
+func (c *Client) SetBrightness(ctx context.Context, deviceID, model string, level int) error {
 	// Validate brightness range
 	if level < 0 || level > 100 {
 		return fmt.Errorf("brightness must be between 0 and 100, got %d", level)
 	}
 
 	log.Printf("💡 Setting brightness to %d for device %s", level, deviceID)
-	return c.sendControlCommand(deviceID, model, "brightness", level)
+	return c.sendControlCommand(ctx, deviceID, model, "brightness", level)
 }
 
 // SetColor sets the RGB color of a Govee device
@@ -179,7 +455,7 @@ func (c *Client) SetBrightness(deviceID, model string, level int) error {
 // r, g, b: RGB color channels, each from 0 to 255
 //
 // Note: Only works if device.SupportCmds contains "color"
-func (c *Client) SetColor(deviceID, model string, r, g, b int) error {
+func (c *Client) SetColor(ctx context.Context, deviceID, model string, r, g, b int) error {
 	// Validate RGB values
 	if r < 0 || r > 255 || g < 0 || g > 255 || b < 0 || b > 255 {
 		return fmt.Errorf("RGB values must be between 0 and 255, got R=%d G=%d B=%d", r, g, b)
@@ -189,7 +465,7 @@ func (c *Client) SetColor(deviceID, model string, r, g, b int) error {
 
 	// Create color value struct
 	color := ColorValue{R: r, G: g, B: b}
-	return c.sendControlCommand(deviceID, model, "color", color)
+	return c.sendControlCommand(ctx, deviceID, model, "color", color)
 }
 
 // sendControlCommand is the internal method that sends control commands to Govee API
@@ -197,7 +473,24 @@ func (c *Client) SetColor(deviceID, model string, r, g, b int) error {
 //
 // cmdName: Command name ("turn", "brightness", "color", "colorTem")
 // value: Command-specific value (string, int, or ColorValue struct)
-func (c *Client) sendControlCommand(deviceID, model, cmdName string, value interface{}) error {
+//
+// Transient failures (5xx, timeouts, refused connections) are retried with
+// backoff; see SetRetries.
+//
+// Any background fade on the device is cancelled first (unless ctx belongs
+// to that fade), so the fade can't overwrite the new value with its next step.
+func (c *Client) sendControlCommand(ctx context.Context, deviceID, model, cmdName string, value interface{}) error {
+	c.cancelFade(ctx, deviceID)
+	return c.withRetries(ctx, cmdName+" command", func() error {
+		if c.apiVersion == APIVersionV2 {
+			return c.sendControlCommandV2(ctx, deviceID, model, cmdName, value)
+		}
+		return c.sendControlCommandV1(ctx, deviceID, model, cmdName, value)
+	})
+}
+
+// sendControlCommandV1 sends a control command with one v1 API request.
+func (c *Client) sendControlCommandV1(ctx context.Context, deviceID, model, cmdName string, value interface{}) error {
 	// Build control request payload
 	// The Govee API requires device, model, and cmd fields
 	controlReq := ControlRequest{
@@ -217,7 +510,7 @@ func (c *Client) sendControlCommand(deviceID, model, cmdName string, value inter
 
 	// Create PUT request to control endpoint
 	// The Govee API uses PUT (not POST) for control commands
-	req, err := http.NewRequest("PUT", baseURL+controlEndpoint, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL+controlEndpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -227,7 +520,7 @@ func (c *Client) sendControlCommand(deviceID, model, cmdName string, value inter
 	req.Header.Set("Content-Type", "application/json")
 
 	// Execute request
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send control command: %w", err)
 	}
@@ -243,9 +536,9 @@ func (c *Client) sendControlCommand(deviceID, model, cmdName string, value inter
 	if resp.StatusCode != http.StatusOK {
 		var errResp ErrorResponse
 		if err := json.Unmarshal(body, &errResp); err == nil {
-			return fmt.Errorf("govee API error (code %d): %s", errResp.Code, errResp.Message)
+			return statusError(resp.StatusCode, fmt.Errorf("govee API error (code %d): %s", errResp.Code, errResp.Message))
 		}
-		return fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body))
+		return statusError(resp.StatusCode, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body)))
 	}
 
 	// Parse successful response
@@ -262,3 +555,12 @@ func (c *Client) sendControlCommand(deviceID, model, cmdName string, value inter
 	log.Printf("💡 Control command successful: %s", controlResp.Message)
 	return nil
 }
+
+// do sends req through the client's rate limiter and breaker, classifying
+// transport failures (see upstream.Classify).
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if err := c.limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return c.breaker.Do(c.httpClient, req, serviceName)
+}
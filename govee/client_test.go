@@ -0,0 +1,179 @@
+package govee
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Govee sometimes answers HTTP 200 with the real error in the body.
+const errorBody200 = `{"code": 400, "message": "devices not belong you", "data": {}}`
+
+func TestGetDevices_ErrorBodyWithHTTP200(t *testing.T) {
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(errorBody200))
+	})
+
+	_, err := client.GetDevices(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "devices not belong you (code 400)") {
+		t.Errorf("expected the Govee error from the body, got %v", err)
+	}
+}
+
+func TestGetDeviceState_ErrorBodyWithHTTP200(t *testing.T) {
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code": 400, "message": "device offline", "data": {}}`))
+	})
+
+	_, err := client.GetDeviceState(context.Background(), "AA:BB", "H6008")
+	if err == nil || !strings.Contains(err.Error(), "device offline (code 400)") {
+		t.Errorf("expected the Govee error from the body, got %v", err)
+	}
+	if !IsOfflineError(err) {
+		t.Errorf("expected the error to be recognized as offline, got %v", err)
+	}
+}
+
+func TestSetProxy_RoutesRequestsThroughProxy(t *testing.T) {
+	// An HTTP proxy receives the absolute URL of the target
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Write([]byte(`{"code": 200, "message": "Success", "data": {"devices": []}}`))
+	}))
+	t.Cleanup(proxy.Close)
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	client := NewClient("test-key")
+	client.SetBaseURL("http://govee.invalid")
+	client.SetProxy(proxyURL)
+
+	if _, err := client.GetDevices(context.Background()); err != nil {
+		t.Fatalf("GetDevices returned error: %v", err)
+	}
+	if proxied != "http://govee.invalid"+devicesEndpoint {
+		t.Errorf("expected the request to go through the proxy, proxy saw %q", proxied)
+	}
+}
+
+func TestGetDevicesCached(t *testing.T) {
+	var calls atomic.Int64
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"code": 200, "message": "Success", "data": {"devices": [{"device": "AA:BB", "model": "H6008"}]}}`))
+	})
+
+	// Concurrent callers share the cache once it's filled
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if devices, err := client.GetDevicesCached(context.Background()); err != nil || len(devices) != 1 {
+				t.Errorf("expected 1 device, got %v %+v", err, devices)
+			}
+		}()
+	}
+	wg.Wait()
+	first := calls.Load()
+	if _, err := client.GetDevicesCached(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != first {
+		t.Errorf("expected a call within the TTL to be served from cache, got %d fetches then %d", first, calls.Load())
+	}
+
+	client.InvalidateDeviceCache()
+	client.GetDevicesCached(context.Background())
+	if calls.Load() != first+1 {
+		t.Errorf("expected invalidation to force a refetch, got %d fetches", calls.Load())
+	}
+
+	client.SetDeviceCacheTTL(10 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	client.GetDevicesCached(context.Background())
+	if calls.Load() != first+2 {
+		t.Errorf("expected an expired cache to be refetched, got %d fetches", calls.Load())
+	}
+}
+
+func TestGetDevicesCached_SharesOneFetch(t *testing.T) {
+	var calls atomic.Int64
+	release := make(chan struct{})
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Write([]byte(`{"code": 200, "message": "Success", "data": {"devices": [{"device": "AA:BB", "model": "H6008"}]}}`))
+	})
+
+	// A caller that gives up doesn't end the fetch for the others
+	ctx, cancel := context.WithCancel(context.Background())
+	impatient := make(chan error, 1)
+	go func() {
+		_, err := client.GetDevicesCached(ctx)
+		impatient <- err
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if devices, err := client.GetDevicesCached(context.Background()); err != nil || len(devices) != 1 {
+				t.Errorf("expected 1 device, got %v %+v", err, devices)
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err := <-impatient; err != context.Canceled {
+		t.Errorf("expected the cancelled caller to get context.Canceled, got %v", err)
+	}
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("expected concurrent misses to share one fetch, got %d", calls.Load())
+	}
+}
+
+func TestInvalidateDeviceCache_DiscardsInFlightFetch(t *testing.T) {
+	var calls atomic.Int64
+	started, release := make(chan struct{}), make(chan struct{})
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+			w.Write([]byte(`{"code": 200, "message": "Success", "data": {"devices": [{"device": "AA:BB", "model": "H6008"}]}}`))
+			return
+		}
+		w.Write([]byte(`{"code": 200, "message": "Success", "data": {"devices": [{"device": "AA:BB", "model": "H6008"}, {"device": "CC:DD", "model": "H6159"}]}}`))
+	})
+
+	stale := make(chan []Device, 1)
+	go func() {
+		devices, _ := client.GetDevicesCached(context.Background())
+		stale <- devices
+	}()
+	<-started
+	client.InvalidateDeviceCache()
+	close(release)
+
+	if devices := <-stale; len(devices) != 1 {
+		t.Errorf("expected the waiting caller to get the list it asked for, got %+v", devices)
+	}
+	devices, err := client.GetDevicesCached(context.Background())
+	if err != nil || len(devices) != 2 {
+		t.Errorf("expected the list fetched before invalidation not to be cached, got %v %+v", err, devices)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected a fresh fetch after invalidation, got %d fetches", calls.Load())
+	}
+}
@@ -0,0 +1,98 @@
+package govee
+
+import (
+	"sync"
+	"time"
+)
+
+// Coalescer merges bursts of the same command to the same device, such as
+// the brightness updates a slider fires while being dragged.
+//
+// The first command of a burst waits for the window to pass; any command for
+// the same device and command name arriving meanwhile replaces it, and the
+// replaced caller returns right away as "coalesced". When the window ends the
+// latest command is sent. Commands arriving while it's in flight start the
+// next window once it finishes, so per device and command only one send is
+// ever in flight and the last value is always the one that lands.
+// Safe for concurrent use.
+type Coalescer struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	bursts map[string]*burst // Keyed by stateKey(apiKeyIndex, deviceID) + "/" + command
+}
+
+// burst is the coalescing state of one device+command.
+type burst struct {
+	latest    *coalescedCommand // Waiting to be sent; nil if nothing is
+	scheduled bool              // A window timer is running
+	sending   bool              // A send is in flight
+}
+
+// coalescedCommand is one caller's command waiting in a burst.
+type coalescedCommand struct {
+	send   func() error
+	result chan coalesceResult // Buffered; receives exactly one result
+}
+
+type coalesceResult struct {
+	coalesced bool
+	err       error
+}
+
+// NewCoalescer creates a coalescer that holds commands for window before
+// sending the latest one.
+func NewCoalescer(window time.Duration) *Coalescer {
+	return &Coalescer{window: window, bursts: make(map[string]*burst)}
+}
+
+// Do queues send as the latest command for the device and waits for it to
+// be sent or replaced. Returns coalesced=true (and no error) if a newer
+// command replaced it before it went out; otherwise the error from send.
+func (c *Coalescer) Do(apiKeyIndex int, deviceID, command string, send func() error) (coalesced bool, err error) {
+	key := stateKey(apiKeyIndex, deviceID) + "/" + command
+	cmd := &coalescedCommand{send: send, result: make(chan coalesceResult, 1)}
+
+	c.mu.Lock()
+	b, ok := c.bursts[key]
+	if !ok {
+		b = &burst{}
+		c.bursts[key] = b
+	}
+	if b.latest != nil {
+		b.latest.result <- coalesceResult{coalesced: true}
+	}
+	b.latest = cmd
+	if !b.scheduled && !b.sending {
+		c.schedule(key, b)
+	}
+	c.mu.Unlock()
+
+	result := <-cmd.result
+	return result.coalesced, result.err
+}
+
+// schedule sends the burst's latest command once the window passes.
+// Caller holds c.mu.
+func (c *Coalescer) schedule(key string, b *burst) {
+	b.scheduled = true
+	time.AfterFunc(c.window, func() {
+		c.mu.Lock()
+		cmd := b.latest
+		b.latest = nil
+		b.scheduled = false
+		b.sending = true
+		c.mu.Unlock()
+
+		cmd.result <- coalesceResult{err: cmd.send()}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		b.sending = false
+		if b.latest != nil {
+			c.schedule(key, b)
+		} else {
+			delete(c.bursts, key)
+		}
+	})
+}
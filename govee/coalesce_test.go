@@ -0,0 +1,78 @@
+package govee
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCoalescer_SendsOnlyLatestOfBurst(t *testing.T) {
+	c := NewCoalescer(50 * time.Millisecond)
+
+	var mu sync.Mutex
+	var sent []int
+	send := func(level int) func() error {
+		return func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, level)
+			return nil
+		}
+	}
+
+	// A slider drag: five values in quick succession
+	var wg sync.WaitGroup
+	coalesced := make([]bool, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			coalesced[i], _ = c.Do(0, "AA:BB", "brightness", send(i*10))
+		}(i)
+		time.Sleep(5 * time.Millisecond) // Keep arrival order deterministic
+	}
+	wg.Wait()
+
+	if len(sent) != 1 || sent[0] != 40 {
+		t.Errorf("expected only the final value to be sent, got %v", sent)
+	}
+	for i, got := range coalesced {
+		if want := i < 4; got != want {
+			t.Errorf("command %d: expected coalesced=%v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestCoalescer_KeepsDevicesAndCommandsApart(t *testing.T) {
+	c := NewCoalescer(10 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	results := make([]bool, 3)
+	for i, target := range []struct{ device, command string }{
+		{"AA:BB", "brightness"}, {"AA:BB", "color"}, {"CC:DD", "brightness"},
+	} {
+		wg.Add(1)
+		go func(i int, device, command string) {
+			defer wg.Done()
+			results[i], _ = c.Do(0, device, command, func() error { return nil })
+		}(i, target.device, target.command)
+	}
+	wg.Wait()
+
+	for i, coalesced := range results {
+		if coalesced {
+			t.Errorf("command %d: expected to be sent, not coalesced", i)
+		}
+	}
+}
+
+func TestCoalescer_ReturnsSendError(t *testing.T) {
+	c := NewCoalescer(time.Millisecond)
+	failure := errors.New("govee API error (code 429): rate limited")
+
+	coalesced, err := c.Do(0, "AA:BB", "color", func() error { return failure })
+	if coalesced || !errors.Is(err, failure) {
+		t.Errorf("expected the send error, got coalesced=%v err=%v", coalesced, err)
+	}
+}
@@ -0,0 +1,99 @@
+package govee
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ColorTemRange is the valid color temperature range for a device, in Kelvin.
+type ColorTemRange struct {
+	Min int `json:"min"` // Warmest supported temperature
+	Max int `json:"max"` // Coolest supported temperature
+}
+
+// Contains reports whether kelvin falls inside the range (inclusive).
+func (r ColorTemRange) Contains(kelvin int) bool {
+	return kelvin >= r.Min && kelvin <= r.Max
+}
+
+// Clamp returns kelvin limited to the range.
+func (r ColorTemRange) Clamp(kelvin int) int {
+	return min(max(kelvin, r.Min), r.Max)
+}
+
+// defaultColorTemRange is used for models we have no information about.
+// It matches the widest range the Govee API documents for colorTem.
+var defaultColorTemRange = ColorTemRange{Min: 2000, Max: 9000}
+
+// modelColorTemRanges lists known colorTem ranges for common models.
+// Bulbs and lamps with dedicated white LEDs tend to use a narrower range
+// than RGBIC strips. The device list usually reports the range itself
+// (see Device.Properties), so this table is only a fallback for accounts
+// whose device list omits it.
+var modelColorTemRanges = map[string]ColorTemRange{
+	"H6003": {Min: 2700, Max: 6500}, // Wi-Fi A19 bulb
+	"H6008": {Min: 2700, Max: 6500}, // Wi-Fi A19 bulb (2nd gen)
+	"H6009": {Min: 2700, Max: 6500}, // Wi-Fi BR30 bulb
+	"H6072": {Min: 2700, Max: 6500}, // Lyra floor lamp
+	"H6076": {Min: 2200, Max: 6500}, // Basic floor lamp
+	"H6046": {Min: 2000, Max: 9000}, // RGBIC TV light bars
+	"H6159": {Min: 2000, Max: 9000}, // RGB LED strip
+	"H6163": {Min: 2000, Max: 9000}, // RGBIC LED strip
+	"H619A": {Min: 2000, Max: 9000}, // RGBIC Pro LED strip
+}
+
+// ColorTemRangeForModel returns the known colorTem range for a model,
+// falling back to 2000–9000K for models not in the table.
+func ColorTemRangeForModel(model string) ColorTemRange {
+	if r, ok := modelColorTemRanges[strings.ToUpper(model)]; ok {
+		return r
+	}
+	return defaultColorTemRange
+}
+
+// ColorTemRange returns the valid colorTem range for a model as seen by this
+// client. A range reported by Govee in the device list takes precedence over
+// the static table, since it reflects the exact hardware on the account.
+func (c *Client) ColorTemRange(model string) ColorTemRange {
+	c.rangesMu.RLock()
+	reported, ok := c.reportedRanges[strings.ToUpper(model)]
+	c.rangesMu.RUnlock()
+	if ok {
+		return reported
+	}
+	return ColorTemRangeForModel(model)
+}
+
+// rememberColorTemRanges records any colorTem ranges reported in a device
+// list so later SetColorTemperature calls can validate against them.
+func (c *Client) rememberColorTemRanges(devices []Device) {
+	c.rangesMu.Lock()
+	defer c.rangesMu.Unlock()
+
+	for _, device := range devices {
+		if r := device.Properties.ColorTem.Range; r.Min > 0 && r.Max >= r.Min {
+			c.reportedRanges[strings.ToUpper(device.Model)] = ColorTemRange{Min: r.Min, Max: r.Max}
+		}
+	}
+}
+
+// SetColorTemperature sets the white color temperature of a Govee device
+// deviceID: Device MAC address from GetDevices()
+// model: Device model number from GetDevices()
+// kelvin: Color temperature in Kelvin — must be inside the model's range
+//
+// Note: Only works if device.SupportCmds contains "colorTem"
+func (c *Client) SetColorTemperature(ctx context.Context, deviceID, model string, kelvin int) error {
+	// Validate against the model's own range rather than a fixed one —
+	// many bulbs only accept 2700-6500K and reject anything outside it.
+	valid := c.ColorTemRange(model)
+	if !valid.Contains(kelvin) {
+		return fmt.Errorf("color temperature for model %s must be between %d and %d Kelvin, got %d",
+			model, valid.Min, valid.Max, kelvin)
+	}
+
+	log.Printf("💡 Setting color temperature to %dK for device %s", kelvin, deviceID)
+	return c.sendControlCommand(ctx, deviceID, model, "colorTem", kelvin)
+}
@@ -0,0 +1,101 @@
+package govee
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestColorTemRangeForModel(t *testing.T) {
+	if got := ColorTemRangeForModel("H6003"); got != (ColorTemRange{Min: 2700, Max: 6500}) {
+		t.Errorf("expected 2700-6500 for H6003, got %+v", got)
+	}
+	if got := ColorTemRangeForModel("h6003"); got != (ColorTemRange{Min: 2700, Max: 6500}) {
+		t.Errorf("expected model lookup to be case-insensitive, got %+v", got)
+	}
+	if got := ColorTemRangeForModel("H9999"); got != defaultColorTemRange {
+		t.Errorf("expected default range for unknown model, got %+v", got)
+	}
+}
+
+func TestClientColorTemRange_PrefersReportedRange(t *testing.T) {
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"code": 200,
+			"message": "Success",
+			"data": {"devices": [{
+				"device": "AA:BB",
+				"model": "H6003",
+				"deviceName": "Lamp",
+				"supportCmds": ["turn", "colorTem"],
+				"properties": {"colorTem": {"range": {"min": 3000, "max": 6000}}}
+			}]}
+		}`))
+	})
+
+	if _, err := client.GetDevices(context.Background()); err != nil {
+		t.Fatalf("GetDevices returned error: %v", err)
+	}
+
+	if got := client.ColorTemRange("H6003"); got != (ColorTemRange{Min: 3000, Max: 6000}) {
+		t.Errorf("expected reported range 3000-6000 to override table, got %+v", got)
+	}
+}
+
+func TestSetColorTemperature_ValidatesModelRange(t *testing.T) {
+	calls := 0
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	})
+
+	// 2200K is valid for the default range but not for an H6003 bulb.
+	if err := client.SetColorTemperature(context.Background(), "AA:BB", "H6003", 2200); err == nil {
+		t.Error("expected error for 2200K on H6003")
+	}
+	if calls != 0 {
+		t.Errorf("expected out-of-range value to be rejected before calling Govee, got %d call(s)", calls)
+	}
+
+	if err := client.SetColorTemperature(context.Background(), "AA:BB", "H6003", 4000); err != nil {
+		t.Errorf("expected 4000K to be accepted for H6003, got %v", err)
+	}
+	if err := client.SetColorTemperature(context.Background(), "AA:BB", "H9999", 2200); err != nil {
+		t.Errorf("expected 2200K to be accepted for unknown model, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", calls)
+	}
+}
+
+func TestExecuteCommand_ColorTem(t *testing.T) {
+	var bodies []string
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	})
+
+	// JSON numbers arrive as float64
+	if err := ExecuteCommand(context.Background(), client, "AA:BB", "H6159", "colorTem", 4500.0, 0); err != nil {
+		t.Fatalf("ExecuteCommand returned error: %v", err)
+	}
+	if len(bodies) != 1 || !strings.Contains(bodies[0], `"name":"colorTem","value":4500`) {
+		t.Errorf("expected a colorTem command with value 4500, got %v", bodies)
+	}
+
+	for _, value := range []interface{}{"warm", 12000.0, 2200.0} {
+		err := ExecuteCommand(context.Background(), client, "AA:BB", "H6003", "colorTem", value, 0)
+		if !IsInvalidCommandError(err) {
+			t.Errorf("%v: expected an InvalidCommandError, got %v", value, err)
+		}
+	}
+	if len(bodies) != 1 {
+		t.Errorf("expected invalid values to be rejected before calling Govee, got %d call(s)", len(bodies))
+	}
+	if err := ValidateCommand("colorTem", 1500.0); !IsInvalidCommandError(err) {
+		t.Errorf("expected ValidateCommand to reject 1500K, got %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package govee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ExecuteCommand runs a loosely-typed control command against a device.
+//
+// This is the single control path shared by every transport (the HTTP
+// control endpoint, the MQTT bridge, ...) so validation and behavior stay
+// identical no matter how a command arrives. The value is expected in its
+// decoded-JSON form:
+// - "turn": bool (true = on, false = off)
+// - "brightness": number 0-100
+// - "color": object with numeric r, g, b fields (each 0-255)
+// - "colorTem": number of Kelvin 2000-9000, inside the model's ColorTemRange
+//
+// Numbers may also arrive as numeric strings ("50"); see IntValue.
+//
+// A positive transition fades "brightness" and "color" to the new value
+// instead of snapping (see FadeBrightness / FadeColor). The value is
+// validated up front, then the fade runs in the background so callers aren't
+// held for its duration; failures part-way through are logged. The fade
+// outlives the request that started it, but the next command sent to the
+// device cancels it (see fadeInBackground). transition is ignored for other
+// commands.
+//
+// Returns an error describing either an invalid command/value (an
+// *InvalidCommandError) or a failure reported by the Govee API. Validation
+// messages are user-facing (the HTTP handler returns them verbatim), hence
+// the capitalization.
+func ExecuteCommand(ctx context.Context, client *Client, deviceID, model, command string, value interface{}, transition time.Duration) error {
+	if transition < 0 {
+		return &InvalidCommandError{Err: fmt.Errorf("transitionMs must not be negative")}
+	}
+
+	switch command {
+	case "turn":
+		// Value should be boolean
+		isOn, ok := value.(bool)
+		if !ok {
+			return &InvalidCommandError{Err: errTurnValue}
+		}
+
+		if isOn {
+			return client.TurnOn(ctx, deviceID, model)
+		}
+		return client.TurnOff(ctx, deviceID, model)
+
+	case "brightness":
+		level, err := IntValue(value, "brightness", 0, 100)
+		if err != nil {
+			return &InvalidCommandError{Err: err}
+		}
+
+		if transition > 0 {
+			client.fadeInBackground(ctx, deviceID, func(ctx context.Context) error {
+				return client.FadeBrightness(ctx, deviceID, model, level, transition)
+			})
+			return nil
+		}
+		return client.SetBrightness(ctx, deviceID, model, level)
+
+	case "color":
+		// Value should be object with r, g, b fields
+		// JSON unmarshals objects as map[string]interface{}
+		color, err := colorValue(value)
+		if err != nil {
+			return &InvalidCommandError{Err: err}
+		}
+
+		if transition > 0 {
+			client.fadeInBackground(ctx, deviceID, func(ctx context.Context) error {
+				return client.FadeColor(ctx, deviceID, model, color, transition)
+			})
+			return nil
+		}
+		return client.SetColor(ctx, deviceID, model, color.R, color.G, color.B)
+
+	case "colorTem":
+		kelvin, err := colorTemValue(value)
+		if err != nil {
+			return &InvalidCommandError{Err: err}
+		}
+
+		// Many bulbs only take part of the full range
+		if valid := client.ColorTemRange(model); !valid.Contains(kelvin) {
+			return &InvalidCommandError{Err: fmt.Errorf("colorTem for model %s must be between %d and %d Kelvin, got %d", model, valid.Min, valid.Max, kelvin)}
+		}
+		return client.SetColorTemperature(ctx, deviceID, model, kelvin)
+
+	default:
+		return &InvalidCommandError{Err: fmt.Errorf("Unknown command: %s", command)}
+	}
+}
+
+// colorTemValue parses a "colorTem" value: whole Kelvin within the widest
+// range Govee documents. The model's own range is checked when sending.
+func colorTemValue(value interface{}) (int, error) {
+	return IntValue(value, "colorTem", defaultColorTemRange.Min, defaultColorTemRange.Max)
+}
+
+// errTurnValue is the error for a "turn" value that isn't a boolean.
+var errTurnValue = errors.New("Invalid value for 'turn' command - expected boolean")
+
+// ValidateCommand checks a command and its value like ExecuteCommand, without
+// sending anything, so a batch can reject a bad value once instead of once
+// per device. Returns an *InvalidCommandError.
+func ValidateCommand(command string, value interface{}) error {
+	var err error
+	switch command {
+	case "turn":
+		if _, ok := value.(bool); !ok {
+			err = errTurnValue
+		}
+	case "brightness":
+		_, err = IntValue(value, "brightness", 0, 100)
+	case "color":
+		_, err = colorValue(value)
+	case "colorTem":
+		_, err = colorTemValue(value)
+	default:
+		err = fmt.Errorf("Unknown command: %s", command)
+	}
+	if err != nil {
+		return &InvalidCommandError{Err: err}
+	}
+	return nil
+}
+
+// InvalidCommandError is an ExecuteCommand error for a command or value that
+// was rejected before anything was sent to Govee.
+type InvalidCommandError struct {
+	Err error
+}
+
+func (e *InvalidCommandError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InvalidCommandError) Unwrap() error {
+	return e.Err
+}
+
+// IsInvalidCommandError reports whether err (or an error it wraps) is an
+// *InvalidCommandError.
+func IsInvalidCommandError(err error) bool {
+	var invalid *InvalidCommandError
+	return errors.As(err, &invalid)
+}
+
+// CheckDeviceCommand checks a command against the listed device: the model,
+// when given, must be the device's, and the command must be one the device
+// supports (compared case-insensitively). Devices that list no commands
+// aren't checked. Returns an *InvalidCommandError, or nil.
+func CheckDeviceCommand(device Device, model, command string) error {
+	if model != "" && !strings.EqualFold(model, device.Model) {
+		return &InvalidCommandError{Err: fmt.Errorf("device %s is model %s, not %s", device.Device, device.Model, model)}
+	}
+	supported := func(cmd string) bool { return strings.EqualFold(cmd, command) }
+	if len(device.SupportCmds) > 0 && !slices.ContainsFunc(device.SupportCmds, supported) {
+		return &InvalidCommandError{Err: fmt.Errorf("device does not support command '%s'", command)}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package govee
+
+import (
+	"strings"
+)
+
+// NormalizeDeviceID returns a device ID in a form suitable for comparison:
+// upper case with separators (":", "-", ".", spaces) removed, so
+// "aa-bb-cc-dd-ee-ff-00-11" and "AA:BB:CC:DD:EE:FF:00:11" are the same.
+// Govee itself only accepts the colon-separated upper-case form, so use the
+// ID from the device list when sending commands.
+func NormalizeDeviceID(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '-', '.', ' ':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(id)))
+}
+
+// SameDeviceID reports whether two device IDs name the same device,
+// ignoring case and separators.
+func SameDeviceID(a, b string) bool {
+	return NormalizeDeviceID(a) == NormalizeDeviceID(b)
+}
@@ -0,0 +1,23 @@
+package govee
+
+import "testing"
+
+func TestSameDeviceID(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"AA:BB:CC:DD:EE:FF:00:11", "AA:BB:CC:DD:EE:FF:00:11", true},
+		{"AA:BB:CC:DD:EE:FF:00:11", "aa:bb:cc:dd:ee:ff:00:11", true},
+		{"AA:BB:CC:DD:EE:FF:00:11", "aa-bb-cc-dd-ee-ff-00-11", true},
+		{"AA:BB:CC:DD:EE:FF:00:11", "AABBCCDDEEFF0011", true},
+		{"AA:BB:CC:DD:EE:FF:00:11", " AA:BB:CC:DD:EE:FF:00:11 ", true},
+		{"AA:BB:CC:DD:EE:FF:00:11", "AA:BB:CC:DD:EE:FF:00:12", false},
+		{"AA:BB", "AA:BB:CC", false},
+	}
+	for _, tt := range tests {
+		if got := SameDeviceID(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameDeviceID(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package govee
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DiagnoseStep is the outcome of one call in a diagnostic round trip.
+type DiagnoseStep struct {
+	Name       string `json:"name"`            // e.g. "read state", "set brightness 40"
+	Success    bool   `json:"success"`         // Whether Govee accepted the call
+	DurationMs int64  `json:"durationMs"`      // How long the call took
+	Error      string `json:"error,omitempty"` // Why it failed
+}
+
+// DiagnoseResult describes a completed diagnostic round trip.
+type DiagnoseResult struct {
+	// Whether the device can report its state. If not, the write was
+	// assumed to have worked and RoundTrip only means Govee accepted it.
+	Retrievable bool `json:"retrievable"`
+
+	// Calls made, in order; the sequence stops at the first failure.
+	Steps []DiagnoseStep `json:"steps"`
+
+	// Every step succeeded and, for retrievable devices, the state read
+	// back still had the brightness that was re-applied.
+	RoundTrip bool `json:"roundTrip"`
+
+	// State read before and after the write, nil if it couldn't be read.
+	Before *DeviceState `json:"before,omitempty"`
+	After  *DeviceState `json:"after,omitempty"`
+
+	// Plain-language reading of the result for support engineers.
+	Summary string `json:"summary"`
+}
+
+// DiagnoseDevice checks a device end to end without visibly changing it:
+//  1. Read the state (retrievable devices only)
+//  2. Re-apply the current brightness — a no-op for the light
+//  3. Read the state again and check the brightness survived
+//
+// A failed read points at the Govee API or the account; a read that works
+// followed by a failed write points at the device (offline, unpaired).
+//
+// Devices that can't report state skip both reads: brightness (e.g. from
+// the last command sent) is re-applied and assumed to have worked, which is
+// noted in the summary. For retrievable devices brightness is ignored.
+func (c *Client) DiagnoseDevice(ctx context.Context, deviceID, model string, retrievable bool, brightness int) *DiagnoseResult {
+	log.Printf("🩺 Diagnosing device %s (retrievable: %v)", deviceID, retrievable)
+	result := &DiagnoseResult{Retrievable: retrievable, Steps: []DiagnoseStep{}}
+
+	// step times one call and records its outcome.
+	step := func(name string, call func() error) bool {
+		start := time.Now()
+		err := call()
+		s := DiagnoseStep{Name: name, Success: err == nil, DurationMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			s.Error = err.Error()
+		}
+		result.Steps = append(result.Steps, s)
+		return err == nil
+	}
+	read := func(name string, into **DeviceState) bool {
+		return step(name, func() error {
+			resp, err := c.GetDeviceState(ctx, deviceID, model)
+			if err != nil {
+				return err
+			}
+			state := NormalizeState(resp, 0)
+			*into = &state
+			return nil
+		})
+	}
+
+	if !retrievable {
+		if !step(fmt.Sprintf("set brightness %d", brightness), func() error {
+			return c.SetBrightness(ctx, deviceID, model, brightness)
+		}) {
+			result.Summary = "Govee rejected the command — the device is likely offline or unreachable"
+			return result
+		}
+		result.RoundTrip = true
+		result.Summary = "Govee accepted the command; this device can't report state, so it's assumed to have applied"
+		return result
+	}
+
+	// 1. A read that fails means Govee (or the account) is the problem.
+	if !read("read state", &result.Before) {
+		result.Summary = "Couldn't read the device state — likely a Govee API or account problem"
+		return result
+	}
+	if result.Before.Brightness == nil {
+		result.Summary = "The device didn't report a brightness, so there's nothing to safely re-apply"
+		return result
+	}
+
+	// 2. Re-apply what the device already has.
+	level := *result.Before.Brightness
+	if !step(fmt.Sprintf("set brightness %d", level), func() error {
+		return c.SetBrightness(ctx, deviceID, model, level)
+	}) {
+		result.Summary = "Reads work but the command failed — likely a device problem (offline or unpaired)"
+		return result
+	}
+
+	// 3. The device should report the same brightness afterwards.
+	if !read("read state again", &result.After) {
+		result.Summary = "The command was accepted but the follow-up read failed — the Govee API may be flaky"
+		return result
+	}
+	if result.After.Brightness == nil || *result.After.Brightness != level {
+		result.Summary = "The command was accepted but the device reports a different brightness — it may not have applied it"
+		return result
+	}
+
+	result.RoundTrip = true
+	result.Summary = "Read, write and read back all worked"
+	log.Printf("✅ Diagnosis for device %s: round trip OK", deviceID)
+	return result
+}
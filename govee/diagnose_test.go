@@ -0,0 +1,87 @@
+package govee
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDiagnoseDevice_RoundTrip(t *testing.T) {
+	var commands []string
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/state") {
+			w.Write([]byte(stateBody))
+			return
+		}
+		commands = append(commands, r.Method)
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	})
+
+	result := client.DiagnoseDevice(context.Background(), "AA:BB:CC:DD:EE:FF:00:11", "H6159", true, 0)
+
+	if !result.RoundTrip {
+		t.Fatalf("expected the round trip to work, got %+v", result)
+	}
+	if len(result.Steps) != 3 || result.Steps[1].Name != "set brightness 42" {
+		t.Errorf("expected read, set brightness 42, read; got %+v", result.Steps)
+	}
+	if len(commands) != 1 {
+		t.Errorf("expected one command, got %d", len(commands))
+	}
+	if result.Before == nil || result.After == nil {
+		t.Errorf("expected both states, got before=%v after=%v", result.Before, result.After)
+	}
+}
+
+func TestDiagnoseDevice_ReadFails(t *testing.T) {
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"code": 401, "message": "Invalid API Key"}`))
+	})
+
+	result := client.DiagnoseDevice(context.Background(), "AA:BB", "H6159", true, 0)
+
+	if result.RoundTrip || len(result.Steps) != 1 || result.Steps[0].Success || result.Steps[0].Error == "" {
+		t.Errorf("expected a single failed read, got %+v", result)
+	}
+}
+
+func TestDiagnoseDevice_CommandFails(t *testing.T) {
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/state") {
+			w.Write([]byte(stateBody))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code": 400, "message": "Device Offline"}`))
+	})
+
+	result := client.DiagnoseDevice(context.Background(), "AA:BB", "H6159", true, 0)
+
+	if result.RoundTrip || len(result.Steps) != 2 || !result.Steps[0].Success || result.Steps[1].Success {
+		t.Errorf("expected a working read then a failed command, got %+v", result.Steps)
+	}
+}
+
+func TestDiagnoseDevice_NotRetrievable(t *testing.T) {
+	var reads int
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/state") {
+			reads++
+		}
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	})
+
+	result := client.DiagnoseDevice(context.Background(), "AA:BB", "H6008", false, 60)
+
+	if !result.RoundTrip || len(result.Steps) != 1 || result.Steps[0].Name != "set brightness 60" {
+		t.Errorf("expected a single assumed write, got %+v", result)
+	}
+	if reads != 0 {
+		t.Errorf("expected no state reads, got %d", reads)
+	}
+	if !strings.Contains(result.Summary, "assumed") {
+		t.Errorf("expected the summary to note the assumption, got %q", result.Summary)
+	}
+}
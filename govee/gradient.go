@@ -0,0 +1,104 @@
+package govee
+
+import (
+	"fmt"
+	"math"
+)
+
+// Gradient modes, inferred from the stops.
+const (
+	GradientRGB      = "rgb"      // Stops are RGB colors
+	GradientColorTem = "colorTem" // Stops are white temperatures in Kelvin
+)
+
+// GradientStop is one color of a gradient: an RGB color or a color
+// temperature in Kelvin, never both.
+type GradientStop struct {
+	Color  *ColorValue `json:"color,omitempty"`
+	Kelvin int         `json:"kelvin,omitempty"`
+}
+
+// Gradient is a sequence of evenly spaced stops of the same kind, from the
+// first endpoint to the last.
+type Gradient struct {
+	Mode  string
+	Stops []GradientStop
+}
+
+// NewGradient validates stops (at least two, all colors or all
+// temperatures) and returns their gradient. Messages are user-facing.
+//
+// Temperatures are only checked against the widest range the Govee API
+// documents; each device clamps them to its own range when applied.
+func NewGradient(stops []GradientStop) (Gradient, error) {
+	if len(stops) < 2 {
+		return Gradient{}, fmt.Errorf("a gradient needs at least two colors")
+	}
+
+	var colors, temperatures int
+	for i, stop := range stops {
+		switch {
+		case stop.Color != nil && stop.Kelvin != 0:
+			return Gradient{}, fmt.Errorf("color %d sets both an RGB color and kelvin — pick one", i+1)
+		case stop.Color != nil:
+			if !validColor(*stop.Color) {
+				return Gradient{}, fmt.Errorf("RGB values must be between 0 and 255, got R=%d G=%d B=%d", stop.Color.R, stop.Color.G, stop.Color.B)
+			}
+			colors++
+		case stop.Kelvin != 0:
+			if !defaultColorTemRange.Contains(stop.Kelvin) {
+				return Gradient{}, fmt.Errorf("color temperature must be between %d and %d Kelvin, got %d", defaultColorTemRange.Min, defaultColorTemRange.Max, stop.Kelvin)
+			}
+			temperatures++
+		default:
+			return Gradient{}, fmt.Errorf("color %d must set an RGB color or kelvin", i+1)
+		}
+	}
+
+	switch {
+	case temperatures == 0:
+		return Gradient{Mode: GradientRGB, Stops: stops}, nil
+	case colors == 0:
+		return Gradient{Mode: GradientColorTem, Stops: stops}, nil
+	}
+	return Gradient{}, fmt.Errorf("a gradient can't mix RGB colors and color temperatures")
+}
+
+// At returns the gradient's color at position t, from 0 (the first stop) to
+// 1 (the last), interpolating linearly between the two stops around it.
+// RGB gradients interpolate each channel.
+func (g Gradient) At(t float64) GradientStop {
+	t = min(max(t, 0), 1)
+	segment := t * float64(len(g.Stops)-1)
+	i := min(int(segment), len(g.Stops)-2)
+	from, to, frac := g.Stops[i], g.Stops[i+1], segment-float64(i)
+
+	if g.Mode == GradientColorTem {
+		return GradientStop{Kelvin: lerp(from.Kelvin, to.Kelvin, frac)}
+	}
+	return GradientStop{Color: &ColorValue{
+		R: lerp(from.Color.R, to.Color.R, frac),
+		G: lerp(from.Color.G, to.Color.G, frac),
+		B: lerp(from.Color.B, to.Color.B, frac),
+	}}
+}
+
+// Spread returns the colors of n lights laid out evenly along the gradient:
+// the first light gets the first stop and the last light the last. A single
+// light gets the middle of the gradient.
+func (g Gradient) Spread(n int) []GradientStop {
+	if n == 1 {
+		return []GradientStop{g.At(0.5)}
+	}
+	colors := make([]GradientStop, n)
+	for i := range colors {
+		colors[i] = g.At(float64(i) / float64(n-1))
+	}
+	return colors
+}
+
+// lerp returns the value a fraction of the way from `from` to `to`, rounded
+// to the nearest integer.
+func lerp(from, to int, frac float64) int {
+	return from + int(math.Round(float64(to-from)*frac))
+}
@@ -0,0 +1,71 @@
+package govee
+
+import (
+	"testing"
+)
+
+func TestNewGradient_Validation(t *testing.T) {
+	red := &ColorValue{R: 255}
+	tests := []struct {
+		name  string
+		stops []GradientStop
+		mode  string // Empty when invalid
+	}{
+		{"rgb", []GradientStop{{Color: red}, {Color: &ColorValue{B: 255}}}, GradientRGB},
+		{"colorTem", []GradientStop{{Kelvin: 2700}, {Kelvin: 6500}}, GradientColorTem},
+		{"one stop", []GradientStop{{Color: red}}, ""},
+		{"mixed", []GradientStop{{Color: red}, {Kelvin: 6500}}, ""},
+		{"both in one stop", []GradientStop{{Color: red, Kelvin: 2700}, {Kelvin: 6500}}, ""},
+		{"empty stop", []GradientStop{{Color: red}, {}}, ""},
+		{"bad color", []GradientStop{{Color: red}, {Color: &ColorValue{R: 300}}}, ""},
+		{"bad kelvin", []GradientStop{{Kelvin: 1500}, {Kelvin: 6500}}, ""},
+	}
+
+	for _, tt := range tests {
+		gradient, err := NewGradient(tt.stops)
+		if tt.mode == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.name)
+			}
+			continue
+		}
+		if err != nil || gradient.Mode != tt.mode {
+			t.Errorf("%s: expected mode %s, got %q (%v)", tt.name, tt.mode, gradient.Mode, err)
+		}
+	}
+}
+
+func TestGradient_SpreadRGB(t *testing.T) {
+	// Red → green → blue across five lights puts green in the middle
+	gradient, err := NewGradient([]GradientStop{
+		{Color: &ColorValue{R: 255}},
+		{Color: &ColorValue{G: 255}},
+		{Color: &ColorValue{B: 255}},
+	})
+	if err != nil {
+		t.Fatalf("NewGradient returned error: %v", err)
+	}
+
+	want := []ColorValue{{R: 255}, {R: 127, G: 128}, {G: 255}, {G: 127, B: 128}, {B: 255}}
+	for i, stop := range gradient.Spread(5) {
+		if stop.Color == nil || *stop.Color != want[i] {
+			t.Errorf("light %d: expected %+v, got %+v", i, want[i], stop.Color)
+		}
+	}
+}
+
+func TestGradient_SpreadColorTem(t *testing.T) {
+	gradient, _ := NewGradient([]GradientStop{{Kelvin: 2700}, {Kelvin: 6500}})
+
+	want := []int{2700, 3967, 5233, 6500}
+	for i, stop := range gradient.Spread(4) {
+		if stop.Kelvin != want[i] || stop.Color != nil {
+			t.Errorf("light %d: expected %dK, got %+v", i, want[i], stop)
+		}
+	}
+
+	// A single light gets the middle of the gradient
+	if got := gradient.Spread(1); len(got) != 1 || got[0].Kelvin != 4600 {
+		t.Errorf("expected 4600K for a single light, got %+v", got)
+	}
+}
@@ -23,6 +23,20 @@ type Device struct {
 	// List of supported commands: "turn", "brightness", "color", "colorTem"
 	// Not all devices support all commands - check this before sending commands
 	SupportCmds []string `json:"supportCmds"`
+
+	// Extra per-device properties. For devices supporting "colorTem" this
+	// carries the valid Kelvin range, e.g. {"colorTem":{"range":{"min":2000,"max":9000}}}
+	Properties DeviceProperties `json:"properties"`
+}
+
+// DeviceProperties holds the optional properties block from GET /v1/devices
+type DeviceProperties struct {
+	ColorTem struct {
+		Range struct {
+			Min int `json:"min"` // Warmest supported Kelvin value
+			Max int `json:"max"` // Coolest supported Kelvin value
+		} `json:"range"`
+	} `json:"colorTem"`
 }
 
 // DevicesResponse is the wrapper returned by GET /v1/devices endpoint
@@ -40,7 +54,7 @@ type DevicesResponse struct {
 // - "turn": value = "on" or "off"
 // - "brightness": value = integer 0-100
 // - "color": value = {"r": 0-255, "g": 0-255, "b": 0-255}
-// - "colorTem": value = integer 2000-9000 (Kelvin temperature)
+// - "colorTem": value = integer Kelvin temperature within the model's range (see ColorTemRange)
 type ControlRequest struct {
 	// Device MAC address to control
 	Device string `json:"device"`
@@ -0,0 +1,137 @@
+package govee
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// OfflineEvent reports a device that went unreachable (Online false) or
+// came back after being reported unreachable (Online true).
+type OfflineEvent struct {
+	APIKeyIndex       int       `json:"apiKeyIndex"`
+	DeviceID          string    `json:"deviceId"`
+	Model             string    `json:"model"`
+	DeviceName        string    `json:"deviceName,omitempty"` // As of the poller's last device list
+	Online            bool      `json:"online"`
+	OfflineSince      time.Time `json:"offlineSince"`      // When the poller first saw it offline
+	OfflineForSeconds int64     `json:"offlineForSeconds"` // So far (offline), or in total (back online)
+	Timestamp         time.Time `json:"timestamp"`
+}
+
+// offlineDevice is a device the watcher has seen offline.
+type offlineDevice struct {
+	state    DeviceState // The first offline state seen
+	since    time.Time
+	timer    *time.Timer // Fires the offline event after the grace period
+	notified bool        // The offline event was sent
+}
+
+// OfflineWatcher turns the state poller's reachability changes into offline
+// and back-online notifications. A device must stay offline for the grace
+// period before it's reported, so a blip that clears by the next poll stays
+// quiet; a device only gets a back-online notification if its offline one
+// was sent. Safe for concurrent use.
+type OfflineWatcher struct {
+	grace time.Duration
+	names func(apiKeyIndex int, deviceID string) string // Optional device name lookup
+
+	mu      sync.Mutex
+	offline map[string]*offlineDevice // Keyed by stateKey(apiKeyIndex, deviceID)
+
+	// Optional hook called with each notification (e.g., to publish a
+	// device event). Set via OnChange before the first Observe.
+	onChange func(OfflineEvent)
+}
+
+// NewOfflineWatcher creates a watcher that reports devices offline for at
+// least grace. names, if non-nil, looks up the device names notifications
+// carry (see StatePoller.DeviceName).
+func NewOfflineWatcher(grace time.Duration, names func(apiKeyIndex int, deviceID string) string) *OfflineWatcher {
+	return &OfflineWatcher{
+		grace:   grace,
+		names:   names,
+		offline: make(map[string]*offlineDevice),
+	}
+}
+
+// OnChange registers a callback invoked with every offline and back-online
+// notification. Must be called before the first Observe. The callback runs
+// on a timer goroutine or the poller's, so it should not block.
+func (w *OfflineWatcher) OnChange(fn func(OfflineEvent)) {
+	w.onChange = fn
+}
+
+// Observe feeds the watcher a device's latest polled state (see
+// StatePoller.OnStateChange). States that don't report reachability are
+// ignored.
+func (w *OfflineWatcher) Observe(state DeviceState) {
+	if state.Online == nil {
+		return
+	}
+	key := stateKey(state.APIKeyIndex, NormalizeDeviceID(state.DeviceID))
+
+	w.mu.Lock()
+	device, tracked := w.offline[key]
+	switch {
+	case !*state.Online && !tracked:
+		device = &offlineDevice{state: state, since: time.Now()}
+		device.timer = time.AfterFunc(w.grace, func() { w.notifyOffline(key, device) })
+		w.offline[key] = device
+		w.mu.Unlock()
+		log.Printf("💡 %s went offline — notifying if it's still offline in %s", state.DeviceID, w.grace)
+
+	case *state.Online && tracked:
+		device.timer.Stop()
+		delete(w.offline, key)
+		w.mu.Unlock()
+		if !device.notified {
+			log.Printf("💡 %s is back online within the grace period — not notifying", state.DeviceID)
+			return
+		}
+		log.Printf("💡 %s is back online after %s", state.DeviceID, time.Since(device.since).Round(time.Second))
+		w.notify(w.event(state, device, true))
+
+	default:
+		w.mu.Unlock()
+	}
+}
+
+// notifyOffline sends the offline notification for a device that is still
+// offline once its grace period is over.
+func (w *OfflineWatcher) notifyOffline(key string, device *offlineDevice) {
+	w.mu.Lock()
+	if w.offline[key] != device {
+		w.mu.Unlock()
+		return // Came back online in the meantime
+	}
+	device.notified = true
+	w.mu.Unlock()
+
+	log.Printf("⚠️  %s has been offline for %s", device.state.DeviceID, time.Since(device.since).Round(time.Second))
+	w.notify(w.event(device.state, device, false))
+}
+
+// event builds a notification for device, using state for its identity.
+func (w *OfflineWatcher) event(state DeviceState, device *offlineDevice, online bool) OfflineEvent {
+	now := time.Now()
+	event := OfflineEvent{
+		APIKeyIndex:       state.APIKeyIndex,
+		DeviceID:          state.DeviceID,
+		Model:             state.Model,
+		Online:            online,
+		OfflineSince:      device.since.UTC(),
+		OfflineForSeconds: int64(now.Sub(device.since).Seconds()),
+		Timestamp:         now.UTC(),
+	}
+	if w.names != nil {
+		event.DeviceName = w.names(state.APIKeyIndex, state.DeviceID)
+	}
+	return event
+}
+
+func (w *OfflineWatcher) notify(event OfflineEvent) {
+	if w.onChange != nil {
+		w.onChange(event)
+	}
+}
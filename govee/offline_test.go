@@ -0,0 +1,63 @@
+package govee
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// reachability returns a state for device AA:01 with the given reachability.
+func reachability(online bool) DeviceState {
+	return DeviceState{DeviceID: "AA:01", Model: "H6008", Online: &online}
+}
+
+// newRecordingWatcher returns a watcher with the given grace period and a
+// function returning the notifications it has sent so far.
+func newRecordingWatcher(grace time.Duration) (*OfflineWatcher, func() []OfflineEvent) {
+	var mu sync.Mutex
+	var events []OfflineEvent
+	watcher := NewOfflineWatcher(grace, func(apiKeyIndex int, deviceID string) string { return "Desk Lamp" })
+	watcher.OnChange(func(event OfflineEvent) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	})
+	return watcher, func() []OfflineEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]OfflineEvent(nil), events...)
+	}
+}
+
+func TestOfflineWatcher_NotifiesAfterGrace(t *testing.T) {
+	watcher, events := newRecordingWatcher(20 * time.Millisecond)
+
+	watcher.Observe(reachability(false))
+	watcher.Observe(reachability(false)) // Still offline: no second timer
+	time.Sleep(60 * time.Millisecond)
+
+	got := events()
+	if len(got) != 1 || got[0].Online || got[0].DeviceName != "Desk Lamp" || got[0].OfflineSince.IsZero() {
+		t.Fatalf("expected one offline notification with the device name, got %+v", got)
+	}
+
+	watcher.Observe(reachability(true))
+	got = events()
+	if len(got) != 2 || !got[1].Online || !got[1].OfflineSince.Equal(got[0].OfflineSince) {
+		t.Errorf("expected a back-online notification for the same outage, got %+v", got)
+	}
+}
+
+func TestOfflineWatcher_IgnoresBlips(t *testing.T) {
+	watcher, events := newRecordingWatcher(30 * time.Millisecond)
+
+	watcher.Observe(reachability(true))
+	watcher.Observe(reachability(false))
+	watcher.Observe(reachability(true))
+	watcher.Observe(DeviceState{DeviceID: "AA:01"}) // No reachability reported
+	time.Sleep(60 * time.Millisecond)
+
+	if got := events(); len(got) != 0 {
+		t.Errorf("expected no notifications for a blip shorter than the grace period, got %+v", got)
+	}
+}
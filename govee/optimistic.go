@@ -0,0 +1,186 @@
+package govee
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pantheon/artemis/persist"
+)
+
+// OptimisticStates remembers, per device, the state implied by the last
+// successful control commands: "turn on" means on, "brightness 40" means
+// brightness 40, and so on.
+//
+// Many Govee devices can't report their state ("device not support
+// retrieve"), so this is the best guess available for them. A real state
+// read replaces the guess (see Clear). With a file path, states are saved
+// after every change and loaded again on startup.
+// Safe for concurrent use.
+type OptimisticStates struct {
+	path string // JSON file states are persisted to; empty = memory only
+
+	mu     sync.Mutex
+	states map[string]DeviceState // Keyed by stateKey(apiKeyIndex, deviceID)
+}
+
+// NewOptimisticStates creates a tracker, loading any states previously saved
+// to path. An unreadable or corrupt file is logged and ignored, so a bad
+// file costs the guesses, not the server.
+func NewOptimisticStates(path string) *OptimisticStates {
+	o := &OptimisticStates{path: path, states: make(map[string]DeviceState)}
+	if path == "" {
+		return o
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return o
+	}
+	if err == nil {
+		var saved []DeviceState
+		if err = json.Unmarshal(data, &saved); err == nil {
+			for _, state := range saved {
+				o.states[stateKey(state.APIKeyIndex, state.DeviceID)] = state
+			}
+		}
+	}
+	if err != nil {
+		log.Printf("⚠️  Optimistic state: ignoring %s: %v", path, err)
+	}
+	return o
+}
+
+// Record folds a successful control command into the device's optimistic
+// state. value is in the decoded-JSON form ExecuteCommand takes; commands or
+// values it doesn't understand are ignored.
+func (o *OptimisticStates) Record(apiKeyIndex int, deviceID, model, command string, value interface{}) {
+	key := stateKey(apiKeyIndex, deviceID)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	state, ok := o.states[key]
+	if !ok {
+		state = DeviceState{DeviceID: deviceID, Model: model, APIKeyIndex: apiKeyIndex}
+	}
+
+	switch command {
+	case "turn":
+		isOn, ok := value.(bool)
+		if !ok {
+			return
+		}
+		state.PowerOn = &isOn
+	case "brightness":
+		brightness, err := numberValue(value, command)
+		if err != nil {
+			return
+		}
+		state.Brightness = &brightness
+	case "color":
+		color, err := colorValue(value)
+		if err != nil {
+			return
+		}
+		// Setting a color leaves white (color temperature) mode, and vice versa
+		state.Color = &color
+		state.ColorTem = nil
+	case "colorTem":
+		colorTem, err := numberValue(value, command)
+		if err != nil {
+			return
+		}
+		state.ColorTem = &colorTem
+		state.Color = nil
+	default:
+		return
+	}
+
+	state.FetchedAt = time.Now()
+	o.states[key] = state
+	o.save()
+}
+
+// Get returns the optimistic state for a device, if any command was recorded.
+func (o *OptimisticStates) Get(apiKeyIndex int, deviceID string) (DeviceState, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	state, ok := o.states[stateKey(apiKeyIndex, deviceID)]
+	return state, ok
+}
+
+// Clear drops a device's optimistic state. Called after a real state read
+// succeeds, since the device evidently reports its own state.
+func (o *OptimisticStates) Clear(apiKeyIndex int, deviceID string) {
+	key := stateKey(apiKeyIndex, deviceID)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.states[key]; ok {
+		delete(o.states, key)
+		o.save()
+	}
+}
+
+// Snapshot returns every recorded state, for a backup.
+func (o *OptimisticStates) Snapshot() []DeviceState {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	states := make([]DeviceState, 0, len(o.states))
+	for _, state := range o.states {
+		states = append(states, state)
+	}
+	slices.SortFunc(states, func(a, b DeviceState) int {
+		return cmp.Or(cmp.Compare(a.APIKeyIndex, b.APIKeyIndex), strings.Compare(a.DeviceID, b.DeviceID))
+	})
+	return states
+}
+
+// Restore writes a backup's states. With replace, they become the only
+// states; otherwise each one replaces the device's current state. States
+// without a device ID are skipped.
+func (o *OptimisticStates) Restore(states []DeviceState, replace bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if replace {
+		o.states = make(map[string]DeviceState, len(states))
+	}
+	for _, state := range states {
+		if state.DeviceID != "" {
+			o.states[stateKey(state.APIKeyIndex, state.DeviceID)] = state
+		}
+	}
+	o.save()
+}
+
+// save writes every state to the file, if one is configured. The file is
+// replaced atomically so a crash mid-write can't corrupt it. Failures are
+// logged; the in-memory states are still correct. Caller holds o.mu.
+func (o *OptimisticStates) save() {
+	if o.path == "" {
+		return
+	}
+
+	states := make([]DeviceState, 0, len(o.states))
+	for _, state := range o.states {
+		states = append(states, state)
+	}
+	data, err := json.Marshal(states)
+	if err == nil {
+		err = persist.WriteFile(o.path, data, 0o600)
+	}
+	if err != nil {
+		log.Printf("⚠️  Optimistic state: failed to save %s: %v", o.path, err)
+	}
+}
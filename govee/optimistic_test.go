@@ -0,0 +1,46 @@
+package govee
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOptimisticStates_RecordsLastCommands(t *testing.T) {
+	o := NewOptimisticStates("")
+
+	o.Record(0, "AA:BB", "H6008", "turn", true)
+	o.Record(0, "AA:BB", "H6008", "brightness", float64(40))
+	o.Record(0, "AA:BB", "H6008", "colorTem", float64(4000))
+	o.Record(0, "AA:BB", "H6008", "color", map[string]interface{}{"r": float64(255), "g": float64(0), "b": float64(0)})
+	o.Record(0, "AA:BB", "H6008", "brightness", "loud") // Ignored
+
+	state, ok := o.Get(0, "AA:BB")
+	if !ok {
+		t.Fatal("expected an optimistic state")
+	}
+	if !state.IsOn() || *state.Brightness != 40 || state.Color == nil || state.Color.R != 255 {
+		t.Errorf("expected on, brightness 40, red, got %+v", state)
+	}
+	if state.ColorTem != nil {
+		t.Errorf("expected the color to replace the color temperature, got %d", *state.ColorTem)
+	}
+	if _, ok := o.Get(1, "AA:BB"); ok {
+		t.Error("expected states to be tracked per account")
+	}
+
+	o.Clear(0, "AA:BB")
+	if _, ok := o.Get(0, "AA:BB"); ok {
+		t.Error("expected Clear to drop the state")
+	}
+}
+
+func TestOptimisticStates_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "optimistic.json")
+
+	NewOptimisticStates(path).Record(1, "AA:BB", "H6008", "turn", false)
+
+	state, ok := NewOptimisticStates(path).Get(1, "AA:BB")
+	if !ok || state.PowerOn == nil || *state.PowerOn {
+		t.Errorf("expected the saved off state to be loaded, got %+v (found=%v)", state, ok)
+	}
+}
@@ -0,0 +1,355 @@
+package govee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Party mode limits.
+//
+// Govee allows roughly 10 control commands per device per minute and 60 per
+// account per minute. A party must leave room for the user's own commands,
+// so it uses at most half of each: one color change per device every
+// MinPartyInterval, and party commands on an account spaced at least
+// partyCallSpacing apart no matter how many parties are running.
+const (
+	MinPartyInterval     = 12 * time.Second
+	MaxPartyInterval     = 10 * time.Minute
+	DefaultPartyInterval = MinPartyInterval
+
+	// MaxPartyPaletteSize caps the number of colors a party cycles through.
+	MaxPartyPaletteSize = 32
+)
+
+// partyCallSpacing is the minimum gap between party commands on one account
+// (30 a minute, half of Govee's per-account budget).
+// A variable so tests can run parties without waiting.
+var partyCallSpacing = 2 * time.Second
+
+// DefaultPartyPalette is used when a party is started without a palette.
+var DefaultPartyPalette = []ColorValue{
+	{R: 255, G: 0, B: 0},
+	{R: 255, G: 127, B: 0},
+	{R: 255, G: 255, B: 0},
+	{R: 0, G: 255, B: 0},
+	{R: 0, G: 0, B: 255},
+	{R: 139, G: 0, B: 255},
+}
+
+// ErrPartyNotFound means no running party has the requested ID.
+var ErrPartyNotFound = errors.New("party not found")
+
+// ErrDeviceInParty means a device is already cycling colors in another
+// party. Stop that party first.
+var ErrDeviceInParty = errors.New("device is already in a party")
+
+// PartyDevice identifies one device taking part in a party.
+type PartyDevice struct {
+	APIKeyIndex int    `json:"apiKeyIndex"`
+	DeviceID    string `json:"deviceId"`
+	Model       string `json:"model"`
+}
+
+// PartyOptions configures a new party.
+type PartyOptions struct {
+	Devices  []PartyDevice
+	Interval time.Duration // Time between color changes; zero = DefaultPartyInterval
+	Palette  []ColorValue  // Colors to cycle through; empty = DefaultPartyPalette
+}
+
+// PartyStatus describes a running (or just stopped) party.
+type PartyStatus struct {
+	ID         string        `json:"partyId"`
+	Devices    []PartyDevice `json:"devices"`
+	IntervalMs int64         `json:"intervalMs"`
+	Palette    []ColorValue  `json:"palette"`
+	StartedAt  time.Time     `json:"startedAt"`
+
+	// Devices whose pre-party state couldn't be restored (stop with restore only).
+	RestoreFailed []string `json:"restoreFailed,omitempty"`
+}
+
+// party is one running color loop.
+type party struct {
+	status PartyStatus
+	before map[string]DeviceState // Pre-party state by stateKey, for devices that reported one
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// PartyManager runs "party mode" color loops: each party cycles its devices
+// through a palette until stopped, with neighbouring devices offset by one
+// color so the room doesn't change in lockstep.
+//
+// A device can only be in one party at a time. Loops don't depend on the
+// request that started them; they run until Stop or Shutdown.
+// Safe for concurrent use.
+type PartyManager struct {
+	clients    []*Client
+	pacers     []*pacer    // One per account, shared by every party
+	retryQueue *RetryQueue // Optional; see SetRetryQueue
+
+	mu       sync.Mutex
+	parties  map[string]*party
+	byDevice map[string]string // stateKey -> party ID
+}
+
+// NewPartyManager creates a manager that sends colors through the given
+// clients (indexed by apiKeyIndex).
+func NewPartyManager(clients []*Client) *PartyManager {
+	pacers := make([]*pacer, len(clients))
+	for i := range pacers {
+		pacers[i] = &pacer{}
+	}
+	return &PartyManager{
+		clients:  clients,
+		pacers:   pacers,
+		parties:  make(map[string]*party),
+		byDevice: make(map[string]string),
+	}
+}
+
+// SetRetryQueue makes Start drop any retry still queued for a party's
+// devices, so a stale command isn't replayed over the party's colors.
+// Call before the first Start.
+func (m *PartyManager) SetRetryQueue(q *RetryQueue) {
+	m.retryQueue = q
+}
+
+// Start validates opts, captures each device's current state (best effort,
+// so Stop can restore it), and starts the color loop in the background.
+// ctx only bounds the state capture; the loop outlives it.
+func (m *PartyManager) Start(ctx context.Context, opts PartyOptions) (PartyStatus, error) {
+	if len(opts.Devices) == 0 {
+		return PartyStatus{}, fmt.Errorf("at least one device is required")
+	}
+	interval := opts.Interval
+	if interval == 0 {
+		interval = DefaultPartyInterval
+	}
+	if interval < MinPartyInterval || interval > MaxPartyInterval {
+		return PartyStatus{}, fmt.Errorf("interval must be between %s and %s", MinPartyInterval, MaxPartyInterval)
+	}
+	palette := opts.Palette
+	if len(palette) == 0 {
+		palette = DefaultPartyPalette
+	}
+	if len(palette) > MaxPartyPaletteSize {
+		return PartyStatus{}, fmt.Errorf("palette can have at most %d colors", MaxPartyPaletteSize)
+	}
+	for _, color := range palette {
+		if !validColor(color) {
+			return PartyStatus{}, fmt.Errorf("RGB values must be between 0 and 255, got R=%d G=%d B=%d", color.R, color.G, color.B)
+		}
+	}
+
+	// Drop repeats so a device listed twice (e.g., via two rooms) gets one loop
+	var devices []PartyDevice
+	seen := make(map[string]bool)
+	for _, device := range opts.Devices {
+		if device.APIKeyIndex < 0 || device.APIKeyIndex >= len(m.clients) {
+			return PartyStatus{}, fmt.Errorf("invalid API key index: %d", device.APIKeyIndex)
+		}
+		if device.DeviceID == "" || device.Model == "" {
+			return PartyStatus{}, fmt.Errorf("deviceId and model are required for every device")
+		}
+		key := stateKey(device.APIKeyIndex, device.DeviceID)
+		if !seen[key] {
+			seen[key] = true
+			devices = append(devices, device)
+		}
+	}
+
+	// The loop doesn't belong to the request, so its context doesn't either.
+	// It exists before the devices are claimed so a Stop that lands during
+	// the state capture below still finds something to cancel.
+	loopCtx, cancel := context.WithCancel(context.Background())
+	p := &party{
+		status: PartyStatus{
+			ID:         newRequestID(),
+			Devices:    devices,
+			IntervalMs: interval.Milliseconds(),
+			Palette:    palette,
+			StartedAt:  time.Now().UTC(),
+		},
+		before: make(map[string]DeviceState),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	// Claim the devices before the (slow) state capture so two concurrent
+	// starts can't both get the same device
+	m.mu.Lock()
+	for _, device := range devices {
+		if id, busy := m.byDevice[stateKey(device.APIKeyIndex, device.DeviceID)]; busy {
+			m.mu.Unlock()
+			cancel()
+			return PartyStatus{}, fmt.Errorf("%w: %s (party %s)", ErrDeviceInParty, device.DeviceID, id)
+		}
+	}
+	for _, device := range devices {
+		m.byDevice[stateKey(device.APIKeyIndex, device.DeviceID)] = p.status.ID
+	}
+	m.parties[p.status.ID] = p
+	m.mu.Unlock()
+
+	for _, device := range devices {
+		m.retryQueue.Forget(device.APIKeyIndex, device.DeviceID)
+	}
+
+	for _, device := range devices {
+		resp, err := m.clients[device.APIKeyIndex].GetDeviceState(ctx, device.DeviceID, device.Model)
+		if err != nil {
+			log.Printf("⚠️  Party %s: couldn't capture state of %s, it won't be restored: %v", p.status.ID, device.DeviceID, err)
+			continue
+		}
+		p.before[stateKey(device.APIKeyIndex, device.DeviceID)] = NormalizeState(resp, device.APIKeyIndex)
+	}
+
+	go m.run(loopCtx, p, interval)
+
+	log.Printf("🎉 Party %s started: %d device(s), %d color(s), every %s", p.status.ID, len(devices), len(palette), interval)
+	return p.status, nil
+}
+
+// Stop ends the party with the given ID, or every party when id is empty,
+// and waits for the loops to exit. With restore, each device is then put
+// back in the state captured when its party started.
+// Returns ErrPartyNotFound for an unknown ID.
+func (m *PartyManager) Stop(ctx context.Context, id string, restore bool) ([]PartyStatus, error) {
+	m.mu.Lock()
+	var stopping []*party
+	if id == "" {
+		for _, p := range m.parties {
+			stopping = append(stopping, p)
+		}
+	} else if p, ok := m.parties[id]; ok {
+		stopping = append(stopping, p)
+	} else {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrPartyNotFound, id)
+	}
+	for _, p := range stopping {
+		delete(m.parties, p.status.ID)
+		for _, device := range p.status.Devices {
+			delete(m.byDevice, stateKey(device.APIKeyIndex, device.DeviceID))
+		}
+	}
+	m.mu.Unlock()
+
+	stopped := make([]PartyStatus, 0, len(stopping))
+	for _, p := range stopping {
+		p.cancel()
+		<-p.done
+		if restore {
+			m.restore(ctx, p)
+		}
+		log.Printf("🎉 Party %s stopped", p.status.ID)
+		stopped = append(stopped, p.status)
+	}
+	return stopped, nil
+}
+
+// Parties returns the status of every running party.
+func (m *PartyManager) Parties() []PartyStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parties := make([]PartyStatus, 0, len(m.parties))
+	for _, p := range m.parties {
+		parties = append(parties, p.status)
+	}
+	return parties
+}
+
+// Shutdown stops every party without restoring and waits for the loops to
+// exit, so nothing changes a light after the server has shut down.
+func (m *PartyManager) Shutdown() {
+	m.Stop(context.Background(), "", false)
+}
+
+// run cycles the party's devices through the palette until ctx is cancelled.
+// Failed color changes are logged and the loop carries on — a device that
+// drops offline mid-party shouldn't end it for everyone else.
+func (m *PartyManager) run(ctx context.Context, p *party, interval time.Duration) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	palette := p.status.Palette
+	for step := 0; ; step++ {
+		for i, device := range p.status.Devices {
+			if !m.pacers[device.APIKeyIndex].wait(ctx) {
+				return
+			}
+			color := palette[(step+i)%len(palette)]
+			err := m.clients[device.APIKeyIndex].SetColor(ctx, device.DeviceID, device.Model, color.R, color.G, color.B)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("⚠️  Party %s: color change failed for %s: %v", p.status.ID, device.DeviceID, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// restore puts each device back in its captured pre-party state.
+// Devices without a captured state are left as they are.
+func (m *PartyManager) restore(ctx context.Context, p *party) {
+	for _, device := range p.status.Devices {
+		before, ok := p.before[stateKey(device.APIKeyIndex, device.DeviceID)]
+		if !ok {
+			continue
+		}
+
+		settings := DeviceSettings{PowerOn: before.PowerOn, Brightness: before.Brightness}
+		// A device reports a color temperature only while in white mode
+		if before.ColorTem != nil {
+			settings.ColorTem = before.ColorTem
+		} else {
+			settings.Color = before.Color
+		}
+
+		if err := m.clients[device.APIKeyIndex].ApplySettings(ctx, device.DeviceID, device.Model, settings); err != nil {
+			log.Printf("❌ Party %s: failed to restore %s: %v", p.status.ID, device.DeviceID, err)
+			p.status.RestoreFailed = append(p.status.RestoreFailed, device.DeviceID)
+		}
+	}
+}
+
+// pacer spaces calls out by partyCallSpacing.
+type pacer struct {
+	mu   sync.Mutex
+	next time.Time // Earliest time the next call may go out
+}
+
+// wait blocks until the caller's slot comes up. Returns false if ctx is
+// cancelled first.
+func (p *pacer) wait(ctx context.Context) bool {
+	p.mu.Lock()
+	now := time.Now()
+	slot := p.next
+	if slot.Before(now) {
+		slot = now
+	}
+	p.next = slot.Add(partyCallSpacing)
+	p.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
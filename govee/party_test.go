@@ -0,0 +1,151 @@
+package govee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// partyStub is a fake Govee API that reports every device on and orange at
+// brightness 42 (stateBody) and records the control commands it receives.
+type partyStub struct {
+	mu   sync.Mutex
+	sent []string // "<device> <command>=<value>"
+}
+
+func (s *partyStub) handler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/state") {
+		w.Write([]byte(stateBody))
+		return
+	}
+
+	var req ControlRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	s.mu.Lock()
+	s.sent = append(s.sent, req.Device+" "+req.Cmd.Name+"="+stringValue(req.Cmd.Value))
+	s.mu.Unlock()
+	w.Write([]byte(`{"code": 200, "message": "Success"}`))
+}
+
+// waitForCommands waits until at least n commands were sent and returns them.
+func (s *partyStub) waitForCommands(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.Lock()
+		sent := append([]string(nil), s.sent...)
+		s.mu.Unlock()
+		if len(sent) >= n || time.Now().After(deadline) {
+			return sent
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// newPartyTestManager returns a manager on a stub API with pacing disabled.
+func newPartyTestManager(t *testing.T) (*PartyManager, *partyStub) {
+	t.Helper()
+	previousSpacing := partyCallSpacing
+	partyCallSpacing = 0
+	t.Cleanup(func() { partyCallSpacing = previousSpacing })
+
+	stub := &partyStub{}
+	manager := NewPartyManager([]*Client{newStubClient(t, stub.handler)})
+	t.Cleanup(manager.Shutdown)
+	return manager, stub
+}
+
+var partyDevices = []PartyDevice{
+	{DeviceID: "AA", Model: "H6008"},
+	{DeviceID: "BB", Model: "H6008"},
+}
+
+func TestParty_CyclesColorsAndRestoresOnStop(t *testing.T) {
+	manager, stub := newPartyTestManager(t)
+	palette := []ColorValue{{R: 1, G: 2, B: 3}, {R: 4, G: 5, B: 6}}
+
+	status, err := manager.Start(context.Background(), PartyOptions{Devices: partyDevices, Palette: palette})
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	if status.IntervalMs != DefaultPartyInterval.Milliseconds() {
+		t.Errorf("expected default interval, got %dms", status.IntervalMs)
+	}
+
+	// The first round goes out right away, each device offset by one color
+	got := stub.waitForCommands(t, 2)
+	want := []string{`AA color={"b":3,"g":2,"r":1}`, `BB color={"b":6,"g":5,"r":4}`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected first round %v, got %v", want, got)
+	}
+
+	stopped, err := manager.Stop(context.Background(), status.ID, true)
+	if err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	if len(stopped) != 1 || len(stopped[0].RestoreFailed) != 0 {
+		t.Errorf("expected one cleanly restored party, got %+v", stopped)
+	}
+
+	restored := stub.waitForCommands(t, 8)[2:]
+	wantRestore := []string{
+		"AA turn=on", `AA color={"b":0,"g":128,"r":255}`, "AA brightness=42",
+		"BB turn=on", `BB color={"b":0,"g":128,"r":255}`, "BB brightness=42",
+	}
+	if strings.Join(restored, "\n") != strings.Join(wantRestore, "\n") {
+		t.Errorf("expected restore commands %v, got %v", wantRestore, restored)
+	}
+	if parties := manager.Parties(); len(parties) != 0 {
+		t.Errorf("expected no running parties, got %d", len(parties))
+	}
+}
+
+func TestParty_OneLoopPerDevice(t *testing.T) {
+	manager, _ := newPartyTestManager(t)
+
+	first, err := manager.Start(context.Background(), PartyOptions{Devices: partyDevices[:1]})
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	_, err = manager.Start(context.Background(), PartyOptions{Devices: partyDevices})
+	if !errors.Is(err, ErrDeviceInParty) {
+		t.Fatalf("expected ErrDeviceInParty, got %v", err)
+	}
+
+	if _, err := manager.Stop(context.Background(), first.ID, false); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	if _, err := manager.Start(context.Background(), PartyOptions{Devices: partyDevices}); err != nil {
+		t.Errorf("expected devices to be free after Stop, got %v", err)
+	}
+}
+
+func TestParty_Validation(t *testing.T) {
+	manager, _ := newPartyTestManager(t)
+
+	tests := []struct {
+		name string
+		opts PartyOptions
+	}{
+		{"no devices", PartyOptions{}},
+		{"interval too short", PartyOptions{Devices: partyDevices, Interval: time.Second}},
+		{"bad color", PartyOptions{Devices: partyDevices, Palette: []ColorValue{{R: 300}}}},
+		{"bad account", PartyOptions{Devices: []PartyDevice{{APIKeyIndex: 1, DeviceID: "AA", Model: "H6008"}}}},
+		{"missing model", PartyOptions{Devices: []PartyDevice{{DeviceID: "AA"}}}},
+	}
+	for _, tt := range tests {
+		if _, err := manager.Start(context.Background(), tt.opts); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	if _, err := manager.Stop(context.Background(), "nope", false); !errors.Is(err, ErrPartyNotFound) {
+		t.Errorf("expected ErrPartyNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package govee
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// pollCallSpacing is the minimum gap between state requests made by the
+// poller on a single API key. Govee allows 60 requests/minute per key, so
+// one call per second keeps a full poll cycle inside the budget and leaves
+// room for interactive commands.
+const pollCallSpacing = time.Second
+
+// StatePoller keeps a shared, TTL'd cache of normalized device states.
+//
+// Instead of every feature querying Govee independently, the poller walks
+// all retrievable devices on each configured account at a fixed interval and
+// stores the results. Read paths call Get to serve from the cache and fall
+// back to Refresh on a miss (or when the client explicitly asks for a fresh
+// read). Safe for concurrent use.
+type StatePoller struct {
+	clients  []*Client     // One client per API key, indexed by apiKeyIndex
+	interval time.Duration // How often a full poll cycle runs
+	ttl      time.Duration // How long a cached state is considered fresh
+
+	mu     sync.RWMutex
+	states map[string]DeviceState // Keyed by stateKey(apiKeyIndex, deviceID)
+	names  map[string]string      // Device names from the last device list, same keys
+
+	// Optional hook called whenever a refreshed state differs from the
+	// previously cached one (e.g., to publish a device event). Set via
+	// OnStateChange before Start.
+	onChange func(DeviceState)
+}
+
+// NewStatePoller creates a poller for the given clients.
+// interval is how often to poll; ttl is how long a cached state stays fresh.
+// A ttl of zero defaults to twice the interval so a single slow cycle doesn't
+// empty the cache.
+func NewStatePoller(clients []*Client, interval, ttl time.Duration) *StatePoller {
+	if ttl <= 0 {
+		ttl = 2 * interval
+	}
+	return &StatePoller{
+		clients:  clients,
+		interval: interval,
+		ttl:      ttl,
+		states:   make(map[string]DeviceState),
+		names:    make(map[string]string),
+	}
+}
+
+// Start runs the poll loop in a background goroutine until ctx is cancelled.
+// The first cycle runs immediately so the cache is warm shortly after startup.
+func (p *StatePoller) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		p.pollAll(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				log.Printf("💡 Govee state poller stopped")
+				return
+			case <-ticker.C:
+				p.pollAll(ctx)
+			}
+		}
+	}()
+}
+
+// OnStateChange registers a callback invoked with the new state whenever a
+// device's power, brightness, color, or reachability changes. Must be called
+// before Start. The callback runs on the poller's goroutine, so it should not
+// block.
+func (p *StatePoller) OnStateChange(fn func(DeviceState)) {
+	p.onChange = fn
+}
+
+// Get returns the cached state for a device if one exists and is still
+// within the TTL. The second return value is false on a miss or stale entry.
+func (p *StatePoller) Get(apiKeyIndex int, deviceID string) (DeviceState, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	state, ok := p.states[stateKey(apiKeyIndex, deviceID)]
+	if !ok || time.Since(state.FetchedAt) > p.ttl {
+		return DeviceState{}, false
+	}
+	return state, true
+}
+
+// States returns a snapshot of every fresh cached state.
+func (p *StatePoller) States() []DeviceState {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	states := make([]DeviceState, 0, len(p.states))
+	for _, state := range p.states {
+		if time.Since(state.FetchedAt) <= p.ttl {
+			states = append(states, state)
+		}
+	}
+	return states
+}
+
+// DeviceName returns a device's name as of the last poll cycle, or "" if
+// the poller hasn't listed it yet.
+func (p *StatePoller) DeviceName(apiKeyIndex int, deviceID string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.names[stateKey(apiKeyIndex, deviceID)]
+}
+
+// Refresh reads a device's state directly from Govee, stores it in the
+// cache, and returns it. Used on cache misses and forced fresh reads.
+func (p *StatePoller) Refresh(ctx context.Context, apiKeyIndex int, deviceID, model string) (DeviceState, error) {
+	if apiKeyIndex < 0 || apiKeyIndex >= len(p.clients) {
+		return DeviceState{}, fmt.Errorf("invalid API key index: %d", apiKeyIndex)
+	}
+
+	resp, err := p.clients[apiKeyIndex].GetDeviceState(ctx, deviceID, model)
+	if err != nil {
+		return DeviceState{}, err
+	}
+
+	state := NormalizeState(resp, apiKeyIndex)
+	// Some responses omit the echo fields — fall back to what was requested.
+	if state.DeviceID == "" {
+		state.DeviceID = deviceID
+	}
+	if state.Model == "" {
+		state.Model = model
+	}
+
+	p.store(state)
+	return state, nil
+}
+
+// store saves a state in the cache under its device key and fires the
+// change hook if the state differs from what was cached before.
+func (p *StatePoller) store(state DeviceState) {
+	key := stateKey(state.APIKeyIndex, state.DeviceID)
+
+	p.mu.Lock()
+	previous, existed := p.states[key]
+	p.states[key] = state
+	p.mu.Unlock()
+
+	if p.onChange != nil && (!existed || !sameState(previous, state)) {
+		p.onChange(state)
+	}
+}
+
+// sameState reports whether two states describe the same observable device
+// condition, ignoring when they were fetched.
+func sameState(a, b DeviceState) bool {
+	return equalBoolPtr(a.Online, b.Online) &&
+		equalBoolPtr(a.PowerOn, b.PowerOn) &&
+		equalIntPtr(a.Brightness, b.Brightness) &&
+		equalIntPtr(a.ColorTem, b.ColorTem) &&
+		((a.Color == nil && b.Color == nil) || (a.Color != nil && b.Color != nil && *a.Color == *b.Color))
+}
+
+func equalBoolPtr(a, b *bool) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func equalIntPtr(a, b *int) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// pollAll runs one poll cycle across every account.
+// Accounts are polled concurrently since each has its own rate-limit budget.
+func (p *StatePoller) pollAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for apiKeyIndex := range p.clients {
+		wg.Add(1)
+		go func(apiKeyIndex int) {
+			defer wg.Done()
+			p.pollAccount(ctx, apiKeyIndex)
+		}(apiKeyIndex)
+	}
+	wg.Wait()
+}
+
+// pollAccount refreshes the state of every retrievable device on one account,
+// spacing requests by pollCallSpacing to stay under Govee's rate limit.
+func (p *StatePoller) pollAccount(ctx context.Context, apiKeyIndex int) {
+	devices, err := p.clients[apiKeyIndex].GetDevices(ctx)
+	if err != nil {
+		log.Printf("⚠️  State poller: failed to list devices for API key #%d: %v", apiKeyIndex, err)
+		return
+	}
+
+	p.mu.Lock()
+	for _, device := range devices {
+		p.names[stateKey(apiKeyIndex, device.Device)] = device.DeviceName
+	}
+	p.mu.Unlock()
+
+	polled := 0
+	for _, device := range devices {
+		// Non-retrievable devices can't report state — skip them entirely.
+		if !device.Retrievable {
+			continue
+		}
+
+		// Wait between calls (and bail out promptly on shutdown).
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollCallSpacing):
+		}
+
+		if _, err := p.Refresh(ctx, apiKeyIndex, device.Device, device.Model); err != nil {
+			log.Printf("⚠️  State poller: failed to read %s (API key #%d): %v", device.Device, apiKeyIndex, err)
+			continue
+		}
+		polled++
+	}
+
+	log.Printf("💡 State poller refreshed %d device(s) for API key #%d", polled, apiKeyIndex)
+}
+
+// stateKey builds the cache key for a device on a given account.
+// Device IDs are only unique per account, so the index is part of the key.
+func stateKey(apiKeyIndex int, deviceID string) string {
+	return fmt.Sprintf("%d/%s", apiKeyIndex, deviceID)
+}
@@ -0,0 +1,157 @@
+package govee
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newStubClient starts a fake Govee API server using the given handler and
+// returns a client pointed at it.
+func newStubClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := NewClient("test-key")
+	client.baseURL = server.URL
+	return client
+}
+
+// stateBody is a typical v1 state response for a light that is on.
+const stateBody = `{
+	"code": 200,
+	"message": "Success",
+	"data": {
+		"device": "AA:BB:CC:DD:EE:FF:00:11",
+		"model": "H6159",
+		"properties": [
+			{"online": "true"},
+			{"powerState": "on"},
+			{"brightness": 42},
+			{"color": {"r": 255, "g": 128, "b": 0}}
+		]
+	}
+}`
+
+// =============================================================================
+// NormalizeState
+// =============================================================================
+
+func TestNormalizeState(t *testing.T) {
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(stateBody))
+	})
+
+	resp, err := client.GetDeviceState(context.Background(), "AA:BB:CC:DD:EE:FF:00:11", "H6159")
+	if err != nil {
+		t.Fatalf("GetDeviceState returned error: %v", err)
+	}
+
+	state := NormalizeState(resp, 1)
+
+	if state.Online == nil || !*state.Online {
+		t.Error("expected online=true (parsed from string)")
+	}
+	if state.PowerOn == nil || !*state.PowerOn {
+		t.Error("expected powerOn=true")
+	}
+	if state.Brightness == nil || *state.Brightness != 42 {
+		t.Errorf("expected brightness 42, got %v", state.Brightness)
+	}
+	if state.Color == nil || *state.Color != (ColorValue{R: 255, G: 128, B: 0}) {
+		t.Errorf("expected color {255 128 0}, got %v", state.Color)
+	}
+	if state.ColorTem != nil {
+		t.Errorf("expected no colorTem, got %d", *state.ColorTem)
+	}
+	if state.APIKeyIndex != 1 {
+		t.Errorf("expected apiKeyIndex 1, got %d", state.APIKeyIndex)
+	}
+}
+
+func TestDeviceState_IsOnFallsBackToOnline(t *testing.T) {
+	online := true
+	state := DeviceState{Online: &online}
+	if !state.IsOn() {
+		t.Error("expected IsOn to fall back to online when powerState is missing")
+	}
+
+	off := false
+	state.PowerOn = &off
+	if state.IsOn() {
+		t.Error("expected powerState to take precedence over online")
+	}
+}
+
+// =============================================================================
+// StatePoller
+// =============================================================================
+
+func TestStatePoller_RefreshThenGet(t *testing.T) {
+	var calls int32
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(stateBody))
+	})
+	poller := NewStatePoller([]*Client{client}, time.Minute, 0)
+
+	if _, ok := poller.Get(0, "AA:BB:CC:DD:EE:FF:00:11"); ok {
+		t.Fatal("expected cache miss before any refresh")
+	}
+
+	if _, err := poller.Refresh(context.Background(), 0, "AA:BB:CC:DD:EE:FF:00:11", "H6159"); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+
+	state, ok := poller.Get(0, "AA:BB:CC:DD:EE:FF:00:11")
+	if !ok {
+		t.Fatal("expected cache hit after refresh")
+	}
+	if !state.IsOn() {
+		t.Error("expected cached state to be on")
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("expected 1 upstream call, got %d", calls)
+	}
+}
+
+func TestStatePoller_StaleEntriesExpire(t *testing.T) {
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(stateBody))
+	})
+	poller := NewStatePoller([]*Client{client}, time.Minute, 10*time.Millisecond)
+
+	poller.Refresh(context.Background(), 0, "AA:BB:CC:DD:EE:FF:00:11", "H6159")
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := poller.Get(0, "AA:BB:CC:DD:EE:FF:00:11"); ok {
+		t.Error("expected stale entry to be treated as a miss")
+	}
+	if len(poller.States()) != 0 {
+		t.Error("expected stale entries to be excluded from States()")
+	}
+}
+
+func TestStatePoller_KeysByAccount(t *testing.T) {
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(stateBody))
+	})
+	poller := NewStatePoller([]*Client{client, client}, time.Minute, 0)
+
+	poller.Refresh(context.Background(), 0, "AA:BB:CC:DD:EE:FF:00:11", "H6159")
+
+	if _, ok := poller.Get(1, "AA:BB:CC:DD:EE:FF:00:11"); ok {
+		t.Error("expected state cached for account 0 not to be served for account 1")
+	}
+}
+
+func TestStatePoller_InvalidIndex(t *testing.T) {
+	poller := NewStatePoller(nil, time.Minute, 0)
+	if _, err := poller.Refresh(context.Background(), 3, "x", "y"); err == nil {
+		t.Error("expected error for out-of-range API key index")
+	}
+}
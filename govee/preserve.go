@@ -0,0 +1,105 @@
+package govee
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// brightnessSettle is how long SetColorKeepBrightness waits after the color
+// before reading the brightness back: Govee forwards commands to the device
+// asynchronously, so an immediate read can still show the old brightness.
+// A variable so tests can run the sequence without waiting.
+var brightnessSettle = 500 * time.Millisecond
+
+// ColorUpdate describes a completed SetColorKeepBrightness sequence.
+type ColorUpdate struct {
+	Brightness *int     // The brightness kept; nil when it wasn't known
+	Restored   bool     // Whether the color changed it and it was re-applied
+	Steps      []string // Commands sent, in order (e.g., "color 255,0,0", "brightness 40")
+}
+
+// SetColorKeepBrightness sets a device's color without letting its
+// brightness change, for bulbs that reset brightness along with color (e.g.
+// while a hue wheel is dragged). The sequence is:
+//  1. Read the current brightness; for devices that can't report state
+//     (retrievable false) or when the read fails, use lastKnown instead
+//  2. Set the color
+//  3. Read the brightness again, and re-apply the saved one if it changed.
+//     Without a read, the saved brightness is always re-applied.
+//
+// When no brightness is known at all, only the color is set. Sequences for
+// the same device run one at a time, so a burst of updates can't interleave
+// one's read with another's restore.
+func (c *Client) SetColorKeepBrightness(ctx context.Context, deviceID, model string, color ColorValue, retrievable bool, lastKnown *int) (ColorUpdate, error) {
+	if !validColor(color) {
+		return ColorUpdate{}, fmt.Errorf("RGB values must be between 0 and 255, got R=%d G=%d B=%d", color.R, color.G, color.B)
+	}
+
+	unlock := c.lockDevice(deviceID)
+	defer unlock()
+
+	// 1. The brightness to keep
+	var update ColorUpdate
+	update.Brightness = lastKnown
+	if retrievable {
+		if state, err := c.currentState(ctx, deviceID, model); err == nil && state.Brightness != nil {
+			update.Brightness = state.Brightness
+		} else {
+			log.Printf("⚠️  Color %s: couldn't read brightness, using the last known one", deviceID)
+		}
+	}
+
+	// 2. The color itself
+	if err := c.SetColor(ctx, deviceID, model, color.R, color.G, color.B); err != nil {
+		return update, err
+	}
+	update.Steps = append(update.Steps, fmt.Sprintf("color %d,%d,%d", color.R, color.G, color.B))
+
+	if update.Brightness == nil {
+		log.Printf("⚠️  Color %s: brightness unknown, not preserved", deviceID)
+		return update, nil
+	}
+	level := *update.Brightness
+
+	// 3. Put the brightness back if the color moved it
+	if retrievable {
+		select {
+		case <-ctx.Done():
+			return update, ctx.Err()
+		case <-time.After(brightnessSettle):
+		}
+		if state, err := c.currentState(ctx, deviceID, model); err == nil && state.Brightness != nil && *state.Brightness == level {
+			return update, nil
+		}
+	}
+	if err := c.SetBrightness(ctx, deviceID, model, level); err != nil {
+		return update, fmt.Errorf("color set, but restoring brightness %d failed: %w", level, err)
+	}
+	update.Steps = append(update.Steps, fmt.Sprintf("brightness %d", level))
+	update.Restored = true
+	return update, nil
+}
+
+// ExecuteColorKeepBrightness is ExecuteCommand for a "color" command that
+// must keep the device's brightness (see SetColorKeepBrightness). value is
+// in the decoded-JSON form ExecuteCommand takes; an invalid one is an
+// *InvalidCommandError.
+func ExecuteColorKeepBrightness(ctx context.Context, client *Client, deviceID, model string, value interface{}, retrievable bool, lastKnown *int) (ColorUpdate, error) {
+	color, err := colorValue(value)
+	if err != nil {
+		return ColorUpdate{}, &InvalidCommandError{Err: err}
+	}
+	return client.SetColorKeepBrightness(ctx, deviceID, model, color, retrievable, lastKnown)
+}
+
+// lockDevice serializes multi-step sequences on one device and returns the
+// unlock function.
+func (c *Client) lockDevice(deviceID string) func() {
+	lock, _ := c.deviceLocks.LoadOrStore(NormalizeDeviceID(deviceID), &sync.Mutex{})
+	mu := lock.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
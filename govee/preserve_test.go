@@ -0,0 +1,83 @@
+package govee
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestSetColorKeepBrightness_RestoresChangedBrightness(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{states: []string{brightnessState(40), brightnessState(100)}})
+
+	update, err := client.SetColorKeepBrightness(context.Background(), "AA:BB", "H6008", ColorValue{R: 255}, true, nil)
+	if err != nil {
+		t.Fatalf("SetColorKeepBrightness returned error: %v", err)
+	}
+	if got := stub.log(); got != "read; color 255,0,0; read; brightness 40" {
+		t.Errorf("expected read, color, read, restore; got %s", got)
+	}
+	if !update.Restored || update.Brightness == nil || *update.Brightness != 40 {
+		t.Errorf("expected brightness 40 to be restored, got %+v", update)
+	}
+}
+
+func TestSetColorKeepBrightness_SkipsRestoreWhenUnchanged(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{states: []string{brightnessState(40)}})
+
+	update, err := client.SetColorKeepBrightness(context.Background(), "AA:BB", "H6008", ColorValue{G: 255}, true, nil)
+	if err != nil {
+		t.Fatalf("SetColorKeepBrightness returned error: %v", err)
+	}
+	if got := stub.log(); got != "read; color 0,255,0; read" || update.Restored {
+		t.Errorf("expected no restore when the brightness held, got %s (%+v)", got, update)
+	}
+}
+
+func TestSetColorKeepBrightness_NonRetrievableUsesLastKnown(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{states: []string{brightnessState(100)}})
+	lastKnown := 30
+
+	update, err := client.SetColorKeepBrightness(context.Background(), "AA:BB", "H6008", ColorValue{B: 255}, false, &lastKnown)
+	if err != nil {
+		t.Fatalf("SetColorKeepBrightness returned error: %v", err)
+	}
+	if got := stub.log(); got != "color 0,0,255; brightness 30" || !update.Restored {
+		t.Errorf("expected the color then the last known brightness, without reads; got %s", got)
+	}
+
+	// Nothing known: only the color is sent
+	client, stub = newCommandStub(t, stubOptions{states: []string{brightnessState(100)}})
+	if _, err := client.SetColorKeepBrightness(context.Background(), "AA:BB", "H6008", ColorValue{B: 255}, false, nil); err != nil || stub.log() != "color 0,0,255" {
+		t.Errorf("expected only the color without a known brightness, got %s (%v)", stub.log(), err)
+	}
+}
+
+func TestSetColorKeepBrightness_SerializedPerDevice(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{states: []string{brightnessState(40), brightnessState(100)}})
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.SetColorKeepBrightness(context.Background(), "AA:BB", "H6008", ColorValue{R: 255}, true, nil)
+		}()
+	}
+	wg.Wait()
+
+	// The second sequence starts after the first one's restore, and keeps
+	// the 100 the stub reports from then on
+	want := "read; color 255,0,0; read; brightness 40; read; color 255,0,0; read"
+	if got := stub.log(); got != want {
+		t.Errorf("expected the sequences not to interleave:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestExecuteColorKeepBrightness_RejectsInvalidColor(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{states: []string{brightnessState(40)}})
+
+	_, err := ExecuteColorKeepBrightness(context.Background(), client, "AA:BB", "H6008", map[string]interface{}{"r": 300.0, "g": 0.0, "b": 0.0}, true, nil)
+	if !IsInvalidCommandError(err) || stub.log() != "" {
+		t.Errorf("expected an InvalidCommandError before any request, got %v (%s)", err, stub.log())
+	}
+}
@@ -0,0 +1,314 @@
+package govee
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pantheon/artemis/names"
+	"github.com/pantheon/artemis/persist"
+)
+
+var (
+	// ErrPresetNotFound means the device has no preset with the given name.
+	ErrPresetNotFound = errors.New("preset not found")
+
+	// ErrPresetExists means the device already has a preset with the name.
+	ErrPresetExists = errors.New("preset already exists")
+
+	// ErrInvalidPreset wraps a preset's validation error.
+	ErrInvalidPreset = errors.New("invalid preset")
+
+	// ErrStalePreset means a conditional update named a version the preset
+	// has already moved past.
+	ErrStalePreset = errors.New("preset version is stale")
+)
+
+// Preset is a named state saved for one device ("Reading", "Relax"), applied
+// with ApplyState.
+type Preset struct {
+	Name         string      `json:"name"`
+	State        TargetState `json:"state"`
+	TransitionMs int         `json:"transitionMs,omitempty"` // Fade when applied (max 10000)
+	Version      int         `json:"version"`                // 1 when created, bumped on every update
+	UpdatedAt    time.Time   `json:"updatedAt"`
+}
+
+// Validate checks a preset's name, state, and transition. Messages are
+// user-facing.
+func (p Preset) Validate() error {
+	if err := names.Validate(p.Name); err != nil {
+		return err
+	}
+	if p.TransitionMs < 0 {
+		return fmt.Errorf("transitionMs must not be negative")
+	}
+	return p.State.Validate()
+}
+
+// PresetStore holds each device's presets and saves them to a JSON file on
+// every change, so they survive restarts. Devices are keyed by normalized ID
+// (see NormalizeDeviceID) and names match case-insensitively. Safe for
+// concurrent use.
+type PresetStore struct {
+	path string // "" keeps presets in memory only
+
+	mu      sync.RWMutex
+	presets map[string][]Preset // Normalized device ID → presets, sorted by name
+}
+
+// NewPresetStore loads the presets saved at path, if any. A missing file is
+// an empty store; an unreadable one is an error, so a typo'd path doesn't
+// silently drop every preset on the next save.
+func NewPresetStore(path string) (*PresetStore, error) {
+	s := &PresetStore{path: path, presets: make(map[string][]Preset)}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var saved map[string][]Preset
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for deviceID, presets := range saved {
+		for i := range presets {
+			presets[i].Version = max(presets[i].Version, 1) // Saved before versions existed
+		}
+		s.presets[NormalizeDeviceID(deviceID)] = presets
+	}
+	return s, nil
+}
+
+// Count returns the total number of presets across all devices.
+func (s *PresetStore) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, presets := range s.presets {
+		count += len(presets)
+	}
+	return count
+}
+
+// List returns a device's presets, sorted by name.
+func (s *PresetStore) List(deviceID string) []Preset {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.presets[NormalizeDeviceID(deviceID)])
+}
+
+// Get returns a device's preset by name. Returns ErrPresetNotFound if there
+// is none.
+func (s *PresetStore) Get(deviceID, name string) (Preset, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	presets := s.presets[NormalizeDeviceID(deviceID)]
+	if i := presetIndex(presets, name); i != -1 {
+		return presets[i], nil
+	}
+	return Preset{}, ErrPresetNotFound
+}
+
+// Create validates and saves a new preset for a device, at version 1.
+// Returns ErrPresetExists if the device already has one with that name.
+func (s *PresetStore) Create(deviceID string, preset Preset) (Preset, error) {
+	return s.put(deviceID, preset.Name, preset, true, 0)
+}
+
+// Update validates and replaces a device's preset called name, bumping its
+// version; the preset may be renamed. Returns ErrPresetNotFound if there is
+// no such preset, or ErrPresetExists if the new name is taken by another
+// one. A non-zero ifVersion makes the update conditional: ErrStalePreset
+// is returned unless the preset is still at that version.
+func (s *PresetStore) Update(deviceID, name string, preset Preset, ifVersion int) (Preset, error) {
+	return s.put(deviceID, name, preset, false, ifVersion)
+}
+
+// Delete removes a device's preset. Returns ErrPresetNotFound if there is
+// no such preset.
+func (s *PresetStore) Delete(deviceID, name string) error {
+	key := NormalizeDeviceID(deviceID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.presets[key]
+	i := presetIndex(previous, name)
+	if i == -1 {
+		return ErrPresetNotFound
+	}
+	s.set(key, slices.Delete(slices.Clone(previous), i, i+1))
+	if err := s.save(); err != nil {
+		s.set(key, previous)
+		return err
+	}
+	return nil
+}
+
+// put creates (create) or replaces the preset called name.
+func (s *PresetStore) put(deviceID, name string, preset Preset, create bool, ifVersion int) (Preset, error) {
+	if err := preset.Validate(); err != nil {
+		return Preset{}, fmt.Errorf("%w: %v", ErrInvalidPreset, err)
+	}
+	preset.Name = strings.TrimSpace(preset.Name)
+	preset.UpdatedAt = time.Now().UTC()
+	key := NormalizeDeviceID(deviceID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.presets[key]
+	presets := slices.Clone(previous)
+	preset.Version = 1
+	if !create {
+		i := presetIndex(presets, name)
+		if i == -1 {
+			return Preset{}, ErrPresetNotFound
+		}
+		if ifVersion != 0 && presets[i].Version != ifVersion {
+			return Preset{}, ErrStalePreset
+		}
+		preset.Version = presets[i].Version + 1
+		presets = slices.Delete(presets, i, i+1)
+	}
+	if presetIndex(presets, preset.Name) != -1 {
+		return Preset{}, ErrPresetExists
+	}
+	presets = append(presets, preset)
+	slices.SortFunc(presets, func(a, b Preset) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	s.set(key, presets)
+	if err := s.save(); err != nil {
+		s.set(key, previous)
+		return Preset{}, err
+	}
+	return preset, nil
+}
+
+// Snapshot returns every device's presets, keyed by normalized device ID,
+// for a backup.
+func (s *PresetStore) Snapshot() map[string][]Preset {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := make(map[string][]Preset, len(s.presets))
+	for key, presets := range s.presets {
+		snapshot[key] = slices.Clone(presets)
+	}
+	return snapshot
+}
+
+// ValidatePresets checks a backup's presets: each is valid and no device
+// has two with the same name. Messages are user-facing.
+func ValidatePresets(presets map[string][]Preset) error {
+	for deviceID, list := range presets {
+		if NormalizeDeviceID(deviceID) == "" {
+			return fmt.Errorf("presets: device ID is required")
+		}
+		for i, preset := range list {
+			if err := preset.Validate(); err != nil {
+				return fmt.Errorf("presets[%s][%d]: %v", deviceID, i, err)
+			}
+			if presetIndex(list[:i], preset.Name) != -1 {
+				return fmt.Errorf("presets[%s][%d]: duplicate name %q", deviceID, i, preset.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Restore writes a backup's presets. With replace, they become the store's
+// only presets; otherwise each one is added, or replaces the device's preset
+// of the same name. A replaced preset's version is bumped past the current
+// one, so an update conditional on a version read before the restore fails.
+func (s *PresetStore) Restore(presets map[string][]Preset, replace bool) error {
+	if err := ValidatePresets(presets); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPreset, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.presets
+	s.presets = make(map[string][]Preset, len(previous))
+	if !replace {
+		maps.Copy(s.presets, previous)
+	}
+	for deviceID, restored := range presets {
+		key := NormalizeDeviceID(deviceID)
+		current := previous[key]
+		merged := slices.Clone(s.presets[key])
+		for _, preset := range restored {
+			preset.Name = strings.TrimSpace(preset.Name)
+			preset.Version = max(preset.Version, 1)
+			if i := presetIndex(current, preset.Name); i != -1 {
+				preset.Version = max(preset.Version, current[i].Version+1)
+			}
+			if i := presetIndex(merged, preset.Name); i != -1 {
+				merged = slices.Delete(merged, i, i+1)
+			}
+			merged = append(merged, preset)
+		}
+		slices.SortFunc(merged, func(a, b Preset) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		})
+		s.set(key, merged)
+	}
+
+	if err := s.save(); err != nil {
+		s.presets = previous
+		return err
+	}
+	return nil
+}
+
+// set replaces a device's presets, dropping the device once it has none.
+// Caller holds s.mu.
+func (s *PresetStore) set(key string, presets []Preset) {
+	if len(presets) == 0 {
+		delete(s.presets, key)
+		return
+	}
+	s.presets[key] = presets
+}
+
+// presetIndex finds a preset by name (case-insensitive), or -1.
+func presetIndex(presets []Preset, name string) int {
+	name = strings.TrimSpace(name)
+	return slices.IndexFunc(presets, func(p Preset) bool {
+		return strings.EqualFold(p.Name, name)
+	})
+}
+
+// save writes every preset to the store's file via a temp file and rename,
+// so a crash mid-write never leaves a truncated file. Caller holds s.mu.
+func (s *PresetStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	if err := persist.WriteJSON(s.path, s.presets, 0o600); err != nil {
+		return fmt.Errorf("failed to save presets: %w", err)
+	}
+	log.Printf("💡 Saved presets for %d device(s) to %s", len(s.presets), s.path)
+	return nil
+}
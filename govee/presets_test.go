@@ -0,0 +1,137 @@
+package govee
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPresetStore_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "presets.json")
+	on, brightness := true, 80
+
+	store, err := NewPresetStore(path)
+	if err != nil {
+		t.Fatalf("NewPresetStore returned error: %v", err)
+	}
+	if _, err := store.Create("aa:bb:cc:dd", Preset{Name: "Reading", State: TargetState{On: &on, Brightness: &brightness}}); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	store.Create("AA:BB:CC:DD", Preset{Name: "Relax", State: TargetState{Brightness: &brightness}})
+	if err := store.Delete("AA-BB-CC-DD", "relax"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+
+	reloaded, err := NewPresetStore(path)
+	if err != nil {
+		t.Fatalf("NewPresetStore returned error on reload: %v", err)
+	}
+	presets := reloaded.List("AA:BB:CC:DD")
+	if len(presets) != 1 || presets[0].Name != "Reading" || *presets[0].State.Brightness != 80 {
+		t.Errorf("expected only the Reading preset, got %+v", presets)
+	}
+	if _, err := reloaded.Get("AA:BB:CC:DD", "Relax"); !errors.Is(err, ErrPresetNotFound) {
+		t.Errorf("expected ErrPresetNotFound for a deleted preset, got %v", err)
+	}
+}
+
+func TestPresetStore_NamesAndValidation(t *testing.T) {
+	store, _ := NewPresetStore("")
+	brightness := 40
+
+	created, err := store.Create("AA:01", Preset{Name: "  Relax ", State: TargetState{Brightness: &brightness}})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if created.Name != "Relax" || created.UpdatedAt.IsZero() {
+		t.Errorf("expected a trimmed name and an update time, got %+v", created)
+	}
+	store.Create("AA:01", Preset{Name: "Bright", State: TargetState{Brightness: &brightness}})
+
+	if _, err := store.Create("AA:01", Preset{Name: "relax", State: TargetState{Brightness: &brightness}}); !errors.Is(err, ErrPresetExists) {
+		t.Errorf("expected ErrPresetExists for a case-insensitive duplicate, got %v", err)
+	}
+	if _, err := store.Update("AA:01", "Relax", Preset{Name: "BRIGHT", State: TargetState{Brightness: &brightness}}, 0); !errors.Is(err, ErrPresetExists) {
+		t.Errorf("expected ErrPresetExists when renaming onto another preset, got %v", err)
+	}
+	if _, err := store.Update("AA:01", "Missing", Preset{Name: "Missing", State: TargetState{Brightness: &brightness}}, 0); !errors.Is(err, ErrPresetNotFound) {
+		t.Errorf("expected ErrPresetNotFound, got %v", err)
+	}
+	if _, err := store.Create("AA:01", Preset{Name: "Empty"}); !errors.Is(err, ErrInvalidPreset) {
+		t.Errorf("expected ErrInvalidPreset for a preset without a state, got %v", err)
+	}
+
+	// Renaming keeps the list sorted by name
+	if _, err := store.Update("AA:01", "relax", Preset{Name: "Ambient", State: TargetState{Brightness: &brightness}}, 0); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	presets := store.List("AA:01")
+	if len(presets) != 2 || presets[0].Name != "Ambient" || presets[1].Name != "Bright" {
+		t.Errorf("expected [Ambient Bright], got %+v", presets)
+	}
+	if len(store.List("AA:02")) != 0 {
+		t.Error("expected no presets for another device")
+	}
+}
+
+func TestPresetStore_Versions(t *testing.T) {
+	store, _ := NewPresetStore("")
+	brightness := 40
+
+	created, _ := store.Create("AA:01", Preset{Name: "Relax", State: TargetState{Brightness: &brightness}})
+	if created.Version != 1 {
+		t.Fatalf("expected a new preset at version 1, got %d", created.Version)
+	}
+
+	updated, err := store.Update("AA:01", "Relax", Preset{Name: "Relax", State: TargetState{Brightness: &brightness}}, 1)
+	if err != nil || updated.Version != 2 {
+		t.Fatalf("expected a fresh update to reach version 2, got %+v, %v", updated, err)
+	}
+
+	// Another client still holding version 1 is turned away
+	if _, err := store.Update("AA:01", "Relax", Preset{Name: "Calm", State: TargetState{Brightness: &brightness}}, 1); !errors.Is(err, ErrStalePreset) {
+		t.Errorf("expected ErrStalePreset, got %v", err)
+	}
+	if current, _ := store.Get("AA:01", "Relax"); current.Version != 2 {
+		t.Errorf("expected the preset unchanged at version 2, got %+v", current)
+	}
+}
+
+func TestPresetStore_Restore(t *testing.T) {
+	store, _ := NewPresetStore("")
+	brightness := 40
+	store.Create("AA:01", Preset{Name: "Relax", State: TargetState{Brightness: &brightness}})
+	store.Create("AA:01", Preset{Name: "Focus", State: TargetState{Brightness: &brightness}})
+
+	// Merging replaces same-named presets past their current version and
+	// keeps the rest
+	err := store.Restore(map[string][]Preset{"aa-01": {{Name: "relax", State: TargetState{Brightness: &brightness}, Version: 1}}}, false)
+	if err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if presets := store.List("AA:01"); len(presets) != 2 || presets[1].Name != "relax" || presets[1].Version != 2 {
+		t.Errorf("expected Focus kept and relax bumped to version 2, got %+v", presets)
+	}
+
+	if err := store.Restore(map[string][]Preset{"AA:02": {{Name: "Night", State: TargetState{Brightness: &brightness}}}}, true); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if store.Count() != 1 || len(store.List("AA:01")) != 0 {
+		t.Errorf("expected replace to leave only AA:02's preset, got %d", store.Count())
+	}
+
+	duplicate := map[string][]Preset{"AA:03": {{Name: "A", State: TargetState{Brightness: &brightness}}, {Name: "a", State: TargetState{Brightness: &brightness}}}}
+	if err := store.Restore(duplicate, true); !errors.Is(err, ErrInvalidPreset) || store.Count() != 1 {
+		t.Errorf("expected ErrInvalidPreset with nothing changed, got %v (%d preset(s))", err, store.Count())
+	}
+}
+
+func TestPresetStore_UnreadableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "presets.json")
+	os.WriteFile(path, []byte("not json"), 0o600)
+
+	if _, err := NewPresetStore(path); err == nil {
+		t.Error("expected an error for a corrupt presets file")
+	}
+}
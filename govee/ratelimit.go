@@ -0,0 +1,104 @@
+package govee
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimit is Govee's documented limit per API key, in requests per
+// minute. NewClient limits itself to it.
+const DefaultRateLimit = 60
+
+// ErrRateLimited is returned instead of sending a request when the client's
+// own rate limit is exhausted and it was set to fail fast (see SetRateLimit).
+// IsRateLimitError reports it like a 429 from Govee.
+var ErrRateLimited = errors.New("Govee rate limit reached (client-side limit) — try again shortly")
+
+// rateLimiter is a token bucket holding up to perMinute tokens, refilled
+// continuously at perMinute a minute. Each request takes one, so bursts of
+// up to perMinute go straight through and sustained traffic is held to the
+// rate. A nil *rateLimiter lets everything through.
+type rateLimiter struct {
+	capacity float64
+	perSec   float64
+	failFast bool // Fail with ErrRateLimited instead of waiting for a token
+
+	mu     sync.Mutex
+	tokens float64 // Negative while requests are waiting for refills
+	last   time.Time
+}
+
+// newRateLimiter returns a limiter for perMinute requests a minute, or nil
+// (no limit) when perMinute isn't positive.
+func newRateLimiter(perMinute int, failFast bool) *rateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		capacity: float64(perMinute),
+		perSec:   float64(perMinute) / 60,
+		failFast: failFast,
+		tokens:   float64(perMinute),
+		last:     time.Now(),
+	}
+}
+
+// wait takes a token for one request. Without a token to spare it returns
+// ErrRateLimited in fail-fast mode, and otherwise reserves the next one and
+// waits for it to refill, in the order requests arrived. A request whose
+// ctx ends while waiting gives its reservation back and returns ctx's error.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.capacity, l.tokens+now.Sub(l.last).Seconds()*l.perSec)
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		l.mu.Unlock()
+		return nil
+	}
+	if l.failFast {
+		l.mu.Unlock()
+		return ErrRateLimited
+	}
+	l.tokens--
+	delay := time.Duration((-l.tokens / l.perSec) * float64(time.Second))
+	l.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// NewClientWithRateLimit creates a v1 Govee API client that sends at most
+// perMinute requests a minute, waiting for its turn when the budget is
+// spent. perMinute <= 0 removes the limit.
+func NewClientWithRateLimit(apiKey string, perMinute int) *Client {
+	client := NewClient(apiKey)
+	client.SetRateLimit(perMinute, false)
+	return client
+}
+
+// SetRateLimit limits the client to perMinute requests a minute across all
+// of its methods — device lists, state reads, and commands, from every
+// caller. Once the budget is spent, requests wait for it to refill (up to
+// their context's deadline), or fail at once with ErrRateLimited when
+// failFast is set. perMinute <= 0 removes the limit. Call before the client
+// is used.
+func (c *Client) SetRateLimit(perMinute int, failFast bool) {
+	c.limiter = newRateLimiter(perMinute, failFast)
+}
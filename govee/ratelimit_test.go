@@ -0,0 +1,88 @@
+package govee
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimit_FailFastAfterBudget(t *testing.T) {
+	var calls atomic.Int64
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	})
+	client.SetRateLimit(DefaultRateLimit, true)
+
+	var throttled []int
+	for i := 1; i <= 70; i++ {
+		// Mix the methods: they share one budget
+		var err error
+		switch i % 3 {
+		case 0:
+			_, err = client.GetDevices(context.Background())
+		case 1:
+			_, err = client.GetDeviceState(context.Background(), "AA:BB", "H6008")
+		default:
+			err = client.TurnOn(context.Background(), "AA:BB", "H6008")
+		}
+		if errors.Is(err, ErrRateLimited) {
+			throttled = append(throttled, i)
+		}
+	}
+
+	if len(throttled) != 10 || throttled[0] != 61 {
+		t.Errorf("expected requests 61-70 to be throttled, got %v", throttled)
+	}
+	if calls.Load() != 60 {
+		t.Errorf("expected 60 requests to reach Govee, got %d", calls.Load())
+	}
+	if !IsRateLimitError(ErrRateLimited) {
+		t.Error("expected ErrRateLimited to count as a rate limit error")
+	}
+}
+
+func TestRateLimit_WaitsForRefill(t *testing.T) {
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	})
+	client.SetRateLimit(120, false) // A token every 500ms
+
+	for i := 0; i < 120; i++ {
+		if err := client.TurnOn(context.Background(), "AA:BB", "H6008"); err != nil {
+			t.Fatalf("request %d within the budget failed: %v", i+1, err)
+		}
+	}
+
+	// The next token is ~500ms away, so a shorter deadline gives up...
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.TurnOn(ctx, "AA:BB", "H6008"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to end the wait, got %v", err)
+	}
+
+	// ...and a request without one waits its turn
+	start := time.Now()
+	if err := client.TurnOn(context.Background(), "AA:BB", "H6008"); err != nil {
+		t.Fatalf("expected the request to go through after waiting, got %v", err)
+	}
+	if waited := time.Since(start); waited < 250*time.Millisecond {
+		t.Errorf("expected the request to wait for a refill, waited %s", waited)
+	}
+}
+
+func TestRateLimit_Disabled(t *testing.T) {
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	})
+	client.SetRateLimit(0, true)
+
+	for i := 0; i < 100; i++ {
+		if err := client.TurnOn(context.Background(), "AA:BB", "H6008"); err != nil {
+			t.Fatalf("request %d failed without a limit: %v", i+1, err)
+		}
+	}
+}
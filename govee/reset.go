@@ -0,0 +1,117 @@
+package govee
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// resetStepDelay is the pause between the commands of a reset sequence.
+// Govee forwards cloud commands to the device asynchronously, and a command
+// that arrives while the device is still powering up can be dropped.
+// A variable so tests can run the sequence without waiting.
+var resetStepDelay = 1 * time.Second
+
+// defaultResetColor is applied when the device's previous static color
+// can't be read (non-retrievable device, or it was in an effect mode that
+// reports no color).
+var defaultResetColor = ColorValue{R: 255, G: 255, B: 255}
+
+// ResetResult describes a completed reset sequence.
+type ResetResult struct {
+	// State captured before the reset, nil if it couldn't be read.
+	Before *DeviceState `json:"before,omitempty"`
+
+	// State read after the reset, nil if it couldn't be read.
+	After *DeviceState `json:"after,omitempty"`
+
+	// Commands that were sent, in order (e.g., "turn off", "color 255,255,255").
+	Steps []string `json:"steps"`
+}
+
+// ResetDevice returns a device stuck in a scene, effect, or music mode to
+// plain static control.
+//
+// A color command alone doesn't reliably exit those modes, so the sequence is:
+//  1. Capture the current state (best effort)
+//  2. Turn off, then back on — this drops the active effect
+//  3. Re-apply a static color: the captured color temperature or RGB color,
+//     or white if neither was reported
+//  4. Restore the captured brightness
+//  5. Read the final state (best effort)
+//
+// State reads are best effort: devices that aren't retrievable simply reset
+// to white at their current brightness.
+func (c *Client) ResetDevice(ctx context.Context, deviceID, model string) (*ResetResult, error) {
+	log.Printf("💡 Resetting device %s to static control", deviceID)
+	result := &ResetResult{}
+
+	// 1. Capture what we can so the reset feels like "the same light, unstuck".
+	if resp, err := c.GetDeviceState(ctx, deviceID, model); err == nil {
+		before := NormalizeState(resp, 0)
+		result.Before = &before
+	} else {
+		log.Printf("⚠️  Reset %s: couldn't capture current state: %v", deviceID, err)
+	}
+
+	// run sends one step of the sequence and waits for the device to settle.
+	run := func(step string, send func() error) error {
+		if err := send(); err != nil {
+			return fmt.Errorf("reset failed at %q: %w", step, err)
+		}
+		result.Steps = append(result.Steps, step)
+		time.Sleep(resetStepDelay)
+		return nil
+	}
+
+	// 2. Power-cycle to drop the active effect.
+	if err := run("turn off", func() error { return c.TurnOff(ctx, deviceID, model) }); err != nil {
+		return result, err
+	}
+	if err := run("turn on", func() error { return c.TurnOn(ctx, deviceID, model) }); err != nil {
+		return result, err
+	}
+
+	// 3. Apply a static color so the device is in plain color mode.
+	switch {
+	case result.Before != nil && result.Before.ColorTem != nil:
+		kelvin := *result.Before.ColorTem
+		if err := run(fmt.Sprintf("colorTem %d", kelvin), func() error {
+			return c.SetColorTemperature(ctx, deviceID, model, kelvin)
+		}); err != nil {
+			return result, err
+		}
+	default:
+		color := defaultResetColor
+		if result.Before != nil && result.Before.Color != nil {
+			color = *result.Before.Color
+		}
+		if err := run(fmt.Sprintf("color %d,%d,%d", color.R, color.G, color.B), func() error {
+			return c.SetColor(ctx, deviceID, model, color.R, color.G, color.B)
+		}); err != nil {
+			return result, err
+		}
+	}
+
+	// 4. Restore brightness last so the color is already applied.
+	if result.Before != nil && result.Before.Brightness != nil {
+		level := *result.Before.Brightness
+		if err := run(fmt.Sprintf("brightness %d", level), func() error {
+			return c.SetBrightness(ctx, deviceID, model, level)
+		}); err != nil {
+			return result, err
+		}
+	}
+
+	// 5. Report where the device ended up.
+	if resp, err := c.GetDeviceState(ctx, deviceID, model); err == nil {
+		after := NormalizeState(resp, 0)
+		result.After = &after
+	} else {
+		log.Printf("⚠️  Reset %s: couldn't read final state: %v", deviceID, err)
+	}
+
+	log.Printf("✅ Reset complete for device %s (%d step(s))", deviceID, len(result.Steps))
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package govee
+
+import (
+	"context"
+	"testing"
+)
+
+func TestResetDevice_RestoresCapturedColorAndBrightness(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{states: []string{`{"code": 200, "data": {
+		"device": "AA:BB", "model": "H6008",
+		"properties": [{"powerState": "on"}, {"brightness": 35}, {"color": {"r": 10, "g": 20, "b": 30}}]
+	}}`}})
+
+	result, err := client.ResetDevice(context.Background(), "AA:BB", "H6008")
+	if err != nil {
+		t.Fatalf("ResetDevice returned error: %v", err)
+	}
+
+	sent := stub.commands()
+	if got := commandNames(sent); got != "turn,turn,color,brightness" {
+		t.Errorf("expected turn,turn,color,brightness, got %s", got)
+	}
+	if color, ok := sent[2].Value.(map[string]interface{}); !ok || color["r"] != float64(10) {
+		t.Errorf("expected captured color to be re-applied, got %v", sent[2].Value)
+	}
+	if sent[3].Value != float64(35) {
+		t.Errorf("expected captured brightness 35, got %v", sent[3].Value)
+	}
+	if result.Before == nil || result.After == nil {
+		t.Error("expected before and after states to be captured")
+	}
+	if len(result.Steps) != 4 {
+		t.Errorf("expected 4 recorded steps, got %v", result.Steps)
+	}
+}
+
+func TestResetDevice_PrefersColorTemperature(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{states: []string{`{"code": 200, "data": {
+		"device": "AA:BB", "model": "H6008",
+		"properties": [{"powerState": "on"}, {"colorTem": 4000}]
+	}}`}})
+
+	if _, err := client.ResetDevice(context.Background(), "AA:BB", "H6008"); err != nil {
+		t.Fatalf("ResetDevice returned error: %v", err)
+	}
+
+	sent := stub.commands()
+	if got := commandNames(sent); got != "turn,turn,colorTem" {
+		t.Errorf("expected turn,turn,colorTem, got %s", got)
+	}
+}
+
+func TestResetDevice_DefaultsToWhiteWhenStateUnreadable(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{})
+
+	result, err := client.ResetDevice(context.Background(), "AA:BB", "H6008")
+	if err != nil {
+		t.Fatalf("ResetDevice returned error: %v", err)
+	}
+
+	sent := stub.commands()
+	if got := commandNames(sent); got != "turn,turn,color" {
+		t.Errorf("expected turn,turn,color, got %s", got)
+	}
+	if color, ok := sent[2].Value.(map[string]interface{}); !ok || color["r"] != float64(255) || color["b"] != float64(255) {
+		t.Errorf("expected white, got %v", sent[2].Value)
+	}
+	if result.Before != nil || result.After != nil {
+		t.Error("expected no states for a non-retrievable device")
+	}
+}
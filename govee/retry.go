@@ -0,0 +1,281 @@
+package govee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// retrySweepInterval is how often the retry queue checks pending commands
+// for expiry and for a fresh "reachable" state from the poller.
+const retrySweepInterval = 5 * time.Second
+
+// IsOfflineError reports whether a control error means the device was
+// unreachable (Govee answers "Device Offline" / "devices offline") rather
+// than the command being invalid. Only these failures are worth retrying.
+func IsOfflineError(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "offline")
+}
+
+// IsRateLimitError reports whether err is Govee's answer to too many
+// requests, either as an API error code or an HTTP status of 429, or the
+// client's own ErrRateLimited.
+func IsRateLimitError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	message := err.Error()
+	return strings.Contains(message, "code 429") || strings.Contains(message, "HTTP error 429")
+}
+
+// QueuedCommand is a control command waiting for its device to come back.
+type QueuedCommand struct {
+	APIKeyIndex int         `json:"apiKeyIndex"`
+	DeviceID    string      `json:"deviceId"`
+	Model       string      `json:"model"`
+	Command     string      `json:"command"`
+	Value       interface{} `json:"value"`
+	QueuedAt    time.Time   `json:"queuedAt"`
+	Attempts    int         `json:"attempts"` // Retries made so far (the original send isn't counted)
+
+	lastAttempt time.Time
+	forgotten   bool // Set by Forget while the command is being retried
+}
+
+// RetryOutcome reports how a queued command finished.
+type RetryOutcome struct {
+	QueuedCommand
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"` // Why it was given up on (when !Success)
+}
+
+// RetryQueue holds the latest control command for devices that were offline
+// when it was sent, and replays it once the state poller sees the device
+// reachable again.
+//
+// Only the most recent command per device is kept: if the user taps "on"
+// then "off" while the bulb is unreachable, replaying both would just flash
+// it, and replaying a stale one would undo their latest intent. For the same
+// reason a command that reaches the device some other way drops the queued
+// one (see Forget). Commands are dropped after maxAge or maxAttempts
+// retries. Safe for concurrent use.
+type RetryQueue struct {
+	clients     []*Client
+	poller      *StatePoller
+	maxAge      time.Duration
+	maxAttempts int
+
+	mu       sync.Mutex
+	pending  map[string]*QueuedCommand // Keyed by stateKey(apiKeyIndex, deviceID)
+	retrying map[string]*QueuedCommand // Taken out of pending by sweep, same keys
+
+	// Optional hook called when a queued command finally succeeds or is
+	// given up on (e.g., to publish an SSE notice). Set via OnResult before Start.
+	onResult func(RetryOutcome)
+}
+
+// NewRetryQueue creates a queue that retries through the given clients and
+// watches reachability via the poller (which must be running).
+func NewRetryQueue(clients []*Client, poller *StatePoller, maxAge time.Duration, maxAttempts int) *RetryQueue {
+	return &RetryQueue{
+		clients:     clients,
+		poller:      poller,
+		maxAge:      maxAge,
+		maxAttempts: max(maxAttempts, 1),
+		pending:     make(map[string]*QueuedCommand),
+		retrying:    make(map[string]*QueuedCommand),
+	}
+}
+
+// OnResult registers a callback invoked once per queued command with its
+// final outcome. Must be called before Start.
+func (q *RetryQueue) OnResult(fn func(RetryOutcome)) {
+	q.onResult = fn
+}
+
+// Start runs the sweep loop in a background goroutine until ctx is cancelled.
+func (q *RetryQueue) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(retrySweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				q.sweep(ctx)
+			}
+		}
+	}()
+}
+
+// Enqueue queues a command for retry, replacing any command already
+// waiting for the same device.
+func (q *RetryQueue) Enqueue(apiKeyIndex int, deviceID, model, command string, value interface{}) {
+	now := time.Now()
+	cmd := &QueuedCommand{
+		APIKeyIndex: apiKeyIndex,
+		DeviceID:    deviceID,
+		Model:       model,
+		Command:     command,
+		Value:       value,
+		QueuedAt:    now,
+		lastAttempt: now,
+	}
+
+	q.mu.Lock()
+	if replaced, ok := q.pending[stateKey(apiKeyIndex, deviceID)]; ok {
+		log.Printf("💡 Retry queue: replacing queued %q for %s with %q", replaced.Command, deviceID, command)
+	}
+	q.pending[stateKey(apiKeyIndex, deviceID)] = cmd
+	q.mu.Unlock()
+
+	log.Printf("💡 Retry queue: %s is offline — queued %q for up to %s", deviceID, command, q.maxAge)
+}
+
+// Forget drops the command queued for a device, because a newer command
+// reached the device since: replaying the queued one would undo it. A retry
+// already under way is abandoned if it hasn't been sent yet, and isn't
+// queued again if it fails. Call it whenever a command sent outside the
+// queue succeeds. The dropped command is reported like one given up on.
+// A nil queue does nothing, so callers needn't check.
+func (q *RetryQueue) Forget(apiKeyIndex int, deviceID string) {
+	if q == nil {
+		return
+	}
+	key := stateKey(apiKeyIndex, deviceID)
+
+	q.mu.Lock()
+	dropped, ok := q.pending[key]
+	delete(q.pending, key)
+	if cmd, retrying := q.retrying[key]; retrying {
+		cmd.forgotten = true
+	}
+	q.mu.Unlock()
+
+	if ok {
+		q.finish(RetryOutcome{QueuedCommand: *dropped, Error: errSuperseded})
+	}
+}
+
+// errSuperseded is the outcome of a queued command dropped by Forget.
+const errSuperseded = "superseded by a newer command"
+
+// Pending returns a snapshot of the commands currently waiting.
+func (q *RetryQueue) Pending() []QueuedCommand {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	commands := make([]QueuedCommand, 0, len(q.pending))
+	for _, cmd := range q.pending {
+		commands = append(commands, *cmd)
+	}
+	return commands
+}
+
+// sweep expires old commands and retries any whose device the poller has
+// seen reachable since the last attempt. Requiring a state newer than the
+// last attempt keeps a stale cached "online" from burning retries.
+func (q *RetryQueue) sweep(ctx context.Context) {
+	var due []*QueuedCommand
+	var expired []RetryOutcome
+
+	q.mu.Lock()
+	for key, cmd := range q.pending {
+		if time.Since(cmd.QueuedAt) > q.maxAge {
+			delete(q.pending, key)
+			expired = append(expired, RetryOutcome{
+				QueuedCommand: *cmd,
+				Error:         fmt.Sprintf("device stayed offline for %s", q.maxAge),
+			})
+			continue
+		}
+
+		state, ok := q.poller.Get(cmd.APIKeyIndex, cmd.DeviceID)
+		if ok && state.FetchedAt.After(cmd.lastAttempt) && (state.Online == nil || *state.Online) {
+			// Remove while retrying so a concurrent Enqueue for the same
+			// device isn't overwritten when this attempt finishes.
+			delete(q.pending, key)
+			q.retrying[key] = cmd
+			due = append(due, cmd)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, outcome := range expired {
+		q.finish(outcome)
+	}
+	for _, cmd := range due {
+		q.retry(ctx, cmd)
+	}
+}
+
+// retry sends a queued command once and either reports the outcome or puts
+// it back in the queue for another attempt.
+func (q *RetryQueue) retry(ctx context.Context, cmd *QueuedCommand) {
+	key := stateKey(cmd.APIKeyIndex, cmd.DeviceID)
+	defer func() {
+		q.mu.Lock()
+		if q.retrying[key] == cmd {
+			delete(q.retrying, key)
+		}
+		q.mu.Unlock()
+	}()
+
+	q.mu.Lock()
+	forgotten := cmd.forgotten
+	q.mu.Unlock()
+	if forgotten {
+		q.finish(RetryOutcome{QueuedCommand: *cmd, Error: errSuperseded})
+		return
+	}
+
+	cmd.Attempts++
+	cmd.lastAttempt = time.Now()
+	log.Printf("💡 Retry queue: %s looks reachable — retrying %q (attempt %d/%d)",
+		cmd.DeviceID, cmd.Command, cmd.Attempts, q.maxAttempts)
+
+	err := ExecuteCommand(ctx, q.clients[cmd.APIKeyIndex], cmd.DeviceID, cmd.Model, cmd.Command, cmd.Value, 0)
+	if err == nil {
+		q.finish(RetryOutcome{QueuedCommand: *cmd, Success: true})
+		return
+	}
+
+	if IsOfflineError(err) && cmd.Attempts < q.maxAttempts {
+		q.mu.Lock()
+		// A newer command queued or sent meanwhile wins over this one.
+		_, newer := q.pending[key]
+		forgotten := cmd.forgotten
+		if !newer && !forgotten {
+			q.pending[key] = cmd
+		}
+		q.mu.Unlock()
+		if forgotten {
+			q.finish(RetryOutcome{QueuedCommand: *cmd, Error: errSuperseded})
+		}
+		return
+	}
+
+	q.finish(RetryOutcome{QueuedCommand: *cmd, Error: err.Error()})
+}
+
+// finish logs a final outcome and hands it to the result hook.
+func (q *RetryQueue) finish(outcome RetryOutcome) {
+	if outcome.Success {
+		log.Printf("✅ Retry queue: %q delivered to %s after %d attempt(s)", outcome.Command, outcome.DeviceID, outcome.Attempts)
+	} else {
+		log.Printf("❌ Retry queue: gave up on %q for %s: %s", outcome.Command, outcome.DeviceID, outcome.Error)
+	}
+
+	if q.onResult != nil {
+		q.onResult(outcome)
+	}
+}
@@ -0,0 +1,201 @@
+package govee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// retryStub is a fake Govee API whose devices report online and whose
+// control endpoint answers "Device Offline" until online is set.
+type retryStub struct {
+	mu     sync.Mutex
+	online bool
+	sent   []string // Command names that reached the device
+}
+
+func (s *retryStub) handler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/state") {
+		w.Write([]byte(`{"code": 200, "data": {"properties": [{"online": true}]}}`))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.online {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code": 400, "message": "Device Offline"}`))
+		return
+	}
+	var req ControlRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	s.sent = append(s.sent, req.Cmd.Name+"="+stringValue(req.Cmd.Value))
+	w.Write([]byte(`{"code": 200, "message": "Success"}`))
+}
+
+func stringValue(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return strings.Trim(string(b), `"`)
+}
+
+// newRetryTestQueue wires a queue to a stub server and collects outcomes.
+func newRetryTestQueue(t *testing.T, stub *retryStub, maxAge time.Duration) (*RetryQueue, *StatePoller, *[]RetryOutcome) {
+	t.Helper()
+	client := newStubClient(t, stub.handler)
+	poller := NewStatePoller([]*Client{client}, time.Minute, 0)
+	queue := NewRetryQueue([]*Client{client}, poller, maxAge, 2)
+
+	var outcomes []RetryOutcome
+	queue.OnResult(func(o RetryOutcome) { outcomes = append(outcomes, o) })
+	return queue, poller, &outcomes
+}
+
+func TestIsOfflineError(t *testing.T) {
+	if !IsOfflineError(errors.New("govee API error (code 400): Device Offline")) {
+		t.Error("expected Govee's offline error to be retryable")
+	}
+	if IsOfflineError(errors.New("brightness must be between 0 and 100")) || IsOfflineError(nil) {
+		t.Error("expected validation errors and nil not to be retryable")
+	}
+}
+
+func TestIsRateLimitError(t *testing.T) {
+	for _, err := range []error{
+		errors.New("govee API error (code 429): Too Many Requests"),
+		errors.New("HTTP error 429: rate limited"),
+	} {
+		if !IsRateLimitError(err) {
+			t.Errorf("expected %q to be a rate limit", err)
+		}
+	}
+	if IsRateLimitError(errors.New("govee API error (code 400): Device Offline")) || IsRateLimitError(nil) {
+		t.Error("expected other errors and nil not to be rate limits")
+	}
+}
+
+func TestExecuteCommand_InvalidCommandError(t *testing.T) {
+	client := NewClient("test-key")
+	for _, tt := range []struct {
+		command string
+		value   interface{}
+	}{
+		{"turn", "yes"},
+		{"brightness", 150},
+		{"color", map[string]interface{}{"r": 255}},
+		{"blink", true},
+	} {
+		err := ExecuteCommand(context.Background(), client, "AA:01", "H6008", tt.command, tt.value, 0)
+		if !IsInvalidCommandError(err) {
+			t.Errorf("%s %v: expected an invalid command error, got %v", tt.command, tt.value, err)
+		}
+		if err := ValidateCommand(tt.command, tt.value); !IsInvalidCommandError(err) {
+			t.Errorf("%s %v: expected ValidateCommand to agree, got %v", tt.command, tt.value, err)
+		}
+	}
+	if err := ValidateCommand("color", map[string]interface{}{"r": 255, "g": 0, "b": 64}); err != nil {
+		t.Errorf("expected a valid color to pass, got %v", err)
+	}
+	if IsInvalidCommandError(errors.New("govee API error (code 400): Device Offline")) {
+		t.Error("expected a Govee failure not to be an invalid command")
+	}
+}
+
+func TestRetryQueue_RetriesLatestCommandWhenReachable(t *testing.T) {
+	stub := &retryStub{}
+	queue, poller, outcomes := newRetryTestQueue(t, stub, time.Minute)
+
+	queue.Enqueue(0, "AA:BB", "H6008", "turn", true)
+	queue.Enqueue(0, "AA:BB", "H6008", "turn", false) // Supersedes "on"
+
+	// No fresh state since the command was queued — nothing is retried yet.
+	queue.sweep(context.Background())
+	if len(stub.sent) != 0 || len(queue.Pending()) != 1 {
+		t.Fatalf("expected the command to stay queued, sent=%v pending=%d", stub.sent, len(queue.Pending()))
+	}
+
+	stub.online = true
+	poller.Refresh(context.Background(), 0, "AA:BB", "H6008")
+	queue.sweep(context.Background())
+
+	if len(stub.sent) != 1 || stub.sent[0] != "turn=off" {
+		t.Errorf("expected only the latest command to be sent, got %v", stub.sent)
+	}
+	if len(*outcomes) != 1 || !(*outcomes)[0].Success || (*outcomes)[0].Attempts != 1 {
+		t.Errorf("expected one successful outcome, got %+v", *outcomes)
+	}
+	if len(queue.Pending()) != 0 {
+		t.Error("expected the queue to be empty after delivery")
+	}
+}
+
+func TestRetryQueue_GivesUpAfterMaxAttempts(t *testing.T) {
+	stub := &retryStub{} // Never comes back
+	queue, poller, outcomes := newRetryTestQueue(t, stub, time.Minute)
+
+	queue.Enqueue(0, "AA:BB", "H6008", "turn", true)
+	for i := 0; i < 2; i++ {
+		time.Sleep(time.Millisecond) // Make the next state strictly newer than the last attempt
+		poller.Refresh(context.Background(), 0, "AA:BB", "H6008")
+		queue.sweep(context.Background())
+	}
+
+	if len(*outcomes) != 1 || (*outcomes)[0].Success || (*outcomes)[0].Attempts != 2 {
+		t.Fatalf("expected a failure after 2 attempts, got %+v", *outcomes)
+	}
+	if !strings.Contains((*outcomes)[0].Error, "Device Offline") {
+		t.Errorf("expected the last error to be reported, got %q", (*outcomes)[0].Error)
+	}
+}
+
+func TestRetryQueue_ExpiresOldCommands(t *testing.T) {
+	stub := &retryStub{}
+	queue, _, outcomes := newRetryTestQueue(t, stub, 10*time.Millisecond)
+
+	queue.Enqueue(0, "AA:BB", "H6008", "turn", true)
+	time.Sleep(20 * time.Millisecond)
+	queue.sweep(context.Background())
+
+	if len(*outcomes) != 1 || (*outcomes)[0].Success || !strings.Contains((*outcomes)[0].Error, "stayed offline") {
+		t.Errorf("expected an expiry outcome, got %+v", *outcomes)
+	}
+	if len(queue.Pending()) != 0 {
+		t.Error("expected the expired command to be removed")
+	}
+}
+
+func TestRetryQueue_ForgetDropsSupersededCommand(t *testing.T) {
+	stub := &retryStub{}
+	queue, poller, outcomes := newRetryTestQueue(t, stub, time.Minute)
+
+	// "on" fails with the device offline and is queued...
+	queue.Enqueue(0, "AA:BB", "H6008", "turn", true)
+
+	// ...then "off" gets through directly, so "on" must not be replayed.
+	stub.online = true
+	queue.Forget(0, "AA:BB")
+	poller.Refresh(context.Background(), 0, "AA:BB", "H6008")
+	queue.sweep(context.Background())
+
+	if len(stub.sent) != 0 {
+		t.Errorf("expected the superseded command not to be sent, got %v", stub.sent)
+	}
+	if len(queue.Pending()) != 0 {
+		t.Error("expected the queue to be empty")
+	}
+	if len(*outcomes) != 1 || (*outcomes)[0].Success || (*outcomes)[0].Error != errSuperseded {
+		t.Errorf("expected one superseded outcome, got %+v", *outcomes)
+	}
+
+	// Forgetting a device with nothing queued, or on a nil queue, is a no-op
+	queue.Forget(0, "AA:BB")
+	var none *RetryQueue
+	none.Forget(0, "AA:BB")
+	if len(*outcomes) != 1 {
+		t.Errorf("expected no further outcomes, got %+v", *outcomes)
+	}
+}
@@ -0,0 +1,112 @@
+package govee
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/pantheon/artemis/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// serialTransport sends requests one at a time, starting each no sooner than
+// spacing after the previous one started. Some Govee accounts get 429s from
+// short bursts even well under 60 requests/minute; queueing trades latency
+// for not being rate limited.
+//
+// Each request records a "govee queue" span with the queue depth it found
+// and how long it waited, so slow commands can be told apart from a slow
+// Govee API.
+type serialTransport struct {
+	base    http.RoundTripper
+	spacing time.Duration
+	timeout time.Duration // Per request, once its turn comes; 0 = none
+
+	turn      chan struct{} // Holds one token; whoever has it may send
+	lastStart time.Time     // Guarded by owning the token
+	depth     atomic.Int64  // Requests waiting for or holding the token
+}
+
+func newSerialTransport(base http.RoundTripper, spacing, timeout time.Duration) *serialTransport {
+	t := &serialTransport{base: base, spacing: spacing, timeout: timeout, turn: make(chan struct{}, 1)}
+	t.turn <- struct{}{}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *serialTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := tracing.Tracer().Start(req.Context(), "govee queue")
+	depth := t.depth.Add(1) - 1 // Requests ahead of this one
+	defer t.depth.Add(-1)
+
+	queuedAt := time.Now()
+	wait := func() error {
+		select {
+		case <-t.turn:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if pause := t.spacing - time.Since(t.lastStart); pause > 0 {
+			timer := time.NewTimer(pause)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				t.turn <- struct{}{}
+				return ctx.Err()
+			}
+		}
+		return nil
+	}
+	err := wait()
+
+	span.SetAttributes(
+		attribute.Int64("govee.queue.depth", depth),
+		attribute.Int64("govee.queue.wait_ms", time.Since(queuedAt).Milliseconds()),
+	)
+	span.End()
+	if err != nil {
+		return nil, err
+	}
+
+	// The turn is held until the response headers arrive, so requests never
+	// overlap on Govee's side
+	t.lastStart = time.Now()
+	defer func() { t.turn <- struct{}{} }()
+
+	if t.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	reqCtx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(reqCtx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{resp.Body, cancel}
+	return resp, nil
+}
+
+// SetStrictSerial routes every request this client makes — device lists,
+// state reads, and commands, including those sent by parties, the poller,
+// and the retry queue — through a single queue, starting one no sooner than
+// spacing after the previous. Time spent queued doesn't count towards the
+// request timeout; a request still waiting when its context ends fails with
+// the context's error. Call before the client is used.
+func (c *Client) SetStrictSerial(spacing time.Duration) {
+	c.httpClient.Transport = newSerialTransport(c.httpClient.Transport, spacing, c.httpClient.Timeout)
+	c.httpClient.Timeout = 0 // Applied per request by the transport, after the wait
+}
+
+// cancelOnClose releases a request's timeout once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
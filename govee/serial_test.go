@@ -0,0 +1,68 @@
+package govee
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStrictSerial_SpacesAndSerializesRequests(t *testing.T) {
+	var mu sync.Mutex
+	var starts []time.Time
+	inFlight, maxInFlight := 0, 0
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+		w.Write([]byte(stateBody))
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	})
+	client.SetStrictSerial(30 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetDeviceState(context.Background(), "AA:BB:CC:DD:EE:FF:00:11", "H6159"); err != nil {
+				t.Errorf("GetDeviceState returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight != 1 {
+		t.Errorf("expected one request at a time, got %d in flight", maxInFlight)
+	}
+	for i := 1; i < len(starts); i++ {
+		if gap := starts[i].Sub(starts[i-1]); gap < 25*time.Millisecond {
+			t.Errorf("request %d started %s after the previous one, want at least 30ms", i, gap)
+		}
+	}
+}
+
+func TestStrictSerial_WaitEndsWithContext(t *testing.T) {
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(stateBody))
+	})
+	client.SetStrictSerial(time.Hour)
+
+	// The first request goes straight out; the second would wait an hour
+	if _, err := client.GetDeviceState(context.Background(), "AA:BB", "H6159"); err != nil {
+		t.Fatalf("GetDeviceState returned error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetDeviceState(ctx, "AA:BB", "H6159"); err == nil {
+		t.Error("expected the queued request to fail when its context ended")
+	}
+}
@@ -0,0 +1,84 @@
+package govee
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// DeviceSettings is a desired device state made up of several commands, as
+// applied by a scene, group, or room. Nil fields are left unchanged.
+type DeviceSettings struct {
+	PowerOn    *bool       `json:"powerOn,omitempty"`    // true = on, false = off
+	Brightness *int        `json:"brightness,omitempty"` // 0-100
+	Color      *ColorValue `json:"color,omitempty"`      // RGB color
+	ColorTem   *int        `json:"colorTem,omitempty"`   // Color temperature in Kelvin
+}
+
+// OrderedCommands returns the control commands needed to reach these
+// settings, in the canonical firmware-safe order:
+//
+//	turn on → colorTem → color → brightness
+//
+// Several models silently drop color and brightness commands that arrive
+// while the light is off, so power-on always goes first. When the settings
+// turn the device off, "turn off" goes last instead, after any other
+// changes, so the device comes back with those settings next time.
+//
+// colorTem is sent before color so that when a scene sets both, the RGB
+// color is the one the device ends up showing. Settings that are nil are
+// skipped entirely.
+func (s DeviceSettings) OrderedCommands() []ControlCommand {
+	var cmds []ControlCommand
+
+	if s.PowerOn != nil && *s.PowerOn {
+		cmds = append(cmds, ControlCommand{Name: "turn", Value: "on"})
+	}
+	if s.ColorTem != nil {
+		cmds = append(cmds, ControlCommand{Name: "colorTem", Value: *s.ColorTem})
+	}
+	if s.Color != nil {
+		cmds = append(cmds, ControlCommand{Name: "color", Value: *s.Color})
+	}
+	if s.Brightness != nil {
+		cmds = append(cmds, ControlCommand{Name: "brightness", Value: *s.Brightness})
+	}
+	if s.PowerOn != nil && !*s.PowerOn {
+		cmds = append(cmds, ControlCommand{Name: "turn", Value: "off"})
+	}
+
+	return cmds
+}
+
+// ApplySettings sends the commands for settings to a device in the canonical
+// order (see OrderedCommands). Each value is validated by the same client
+// method used for single commands. Stops at the first failure and reports
+// which command failed; commands before it have already been applied.
+func (c *Client) ApplySettings(ctx context.Context, deviceID, model string, settings DeviceSettings) error {
+	cmds := settings.OrderedCommands()
+	log.Printf("💡 Applying %d command(s) to device %s", len(cmds), deviceID)
+
+	for _, cmd := range cmds {
+		var err error
+		switch cmd.Name {
+		case "turn":
+			if cmd.Value == "on" {
+				err = c.TurnOn(ctx, deviceID, model)
+			} else {
+				err = c.TurnOff(ctx, deviceID, model)
+			}
+		case "colorTem":
+			err = c.SetColorTemperature(ctx, deviceID, model, cmd.Value.(int))
+		case "color":
+			color := cmd.Value.(ColorValue)
+			err = c.SetColor(ctx, deviceID, model, color.R, color.G, color.B)
+		case "brightness":
+			err = c.SetBrightness(ctx, deviceID, model, cmd.Value.(int))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply %s: %w", cmd.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package govee
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func boolPtr(b bool) *bool { return &b }
+func intPtr(n int) *int    { return &n }
+
+// describeCommands renders commands as "name=value" for easy comparison.
+func describeCommands(cmds []ControlCommand) string {
+	parts := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		value, _ := json.Marshal(cmd.Value)
+		parts[i] = cmd.Name + "=" + string(value)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestOrderedCommands(t *testing.T) {
+	red := &ColorValue{R: 255}
+
+	tests := []struct {
+		name     string
+		settings DeviceSettings
+		expected string
+	}{
+		{
+			name:     "full scene turns on first",
+			settings: DeviceSettings{Brightness: intPtr(60), Color: red, PowerOn: boolPtr(true)},
+			expected: `turn="on" color={"r":255,"g":0,"b":0} brightness=60`,
+		},
+		{
+			name:     "color temperature before brightness",
+			settings: DeviceSettings{PowerOn: boolPtr(true), Brightness: intPtr(80), ColorTem: intPtr(2700)},
+			expected: `turn="on" colorTem=2700 brightness=80`,
+		},
+		{
+			name:     "colorTem then color when both are set",
+			settings: DeviceSettings{Color: red, ColorTem: intPtr(4000)},
+			expected: `colorTem=4000 color={"r":255,"g":0,"b":0}`,
+		},
+		{
+			name:     "turn off goes last",
+			settings: DeviceSettings{PowerOn: boolPtr(false), Brightness: intPtr(10)},
+			expected: `brightness=10 turn="off"`,
+		},
+		{
+			name:     "power only",
+			settings: DeviceSettings{PowerOn: boolPtr(false)},
+			expected: `turn="off"`,
+		},
+		{
+			name:     "brightness only skips everything else",
+			settings: DeviceSettings{Brightness: intPtr(40)},
+			expected: `brightness=40`,
+		},
+		{
+			name:     "empty settings send nothing",
+			settings: DeviceSettings{},
+			expected: ``,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeCommands(tt.settings.OrderedCommands()); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestApplySettings_SendsInOrderAndStopsOnError(t *testing.T) {
+	var sent []string
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req ControlRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sent = append(sent, req.Cmd.Name)
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	})
+
+	settings := DeviceSettings{PowerOn: boolPtr(true), Brightness: intPtr(50), Color: &ColorValue{G: 255}}
+	if err := client.ApplySettings(context.Background(), "AA:BB", "H6008", settings); err != nil {
+		t.Fatalf("ApplySettings returned error: %v", err)
+	}
+	if got := strings.Join(sent, ","); got != "turn,color,brightness" {
+		t.Errorf("expected turn,color,brightness, got %s", got)
+	}
+
+	// An invalid brightness is rejected before it's sent; earlier commands still go out.
+	sent = nil
+	settings = DeviceSettings{PowerOn: boolPtr(true), Brightness: intPtr(150)}
+	err := client.ApplySettings(context.Background(), "AA:BB", "H6008", settings)
+	if err == nil || !strings.Contains(err.Error(), "brightness") {
+		t.Errorf("expected brightness error, got %v", err)
+	}
+	if got := strings.Join(sent, ","); got != "turn" {
+		t.Errorf("expected only turn to be sent, got %s", got)
+	}
+}
@@ -0,0 +1,113 @@
+package govee
+
+import (
+	"strconv"
+	"time"
+)
+
+// DeviceState is a normalized view of a device's current state.
+//
+// The Govee state endpoint returns properties as an array of single-key
+// objects whose value types vary by model and firmware (e.g., "online" may be
+// a bool or the string "true"). NormalizeState flattens that into typed
+// fields so callers don't each have to re-implement the parsing.
+//
+// Pointer fields are nil when the device didn't report that property.
+type DeviceState struct {
+	DeviceID    string      `json:"deviceId"`
+	Model       string      `json:"model"`
+	APIKeyIndex int         `json:"apiKeyIndex"`
+	Online      *bool       `json:"online,omitempty"`     // Whether Govee's cloud can reach the device
+	PowerOn     *bool       `json:"powerOn,omitempty"`    // Whether the light is switched on
+	Brightness  *int        `json:"brightness,omitempty"` // 0-100
+	Color       *ColorValue `json:"color,omitempty"`      // Current RGB color
+	ColorTem    *int        `json:"colorTem,omitempty"`   // Current color temperature in Kelvin
+	FetchedAt   time.Time   `json:"fetchedAt"`            // When this state was read from Govee
+}
+
+// IsOn reports whether the device should be shown as on.
+// Prefers the explicit powerState; devices that only report "online"
+// fall back to that, matching what the state endpoint has always returned.
+func (s DeviceState) IsOn() bool {
+	if s.PowerOn != nil {
+		return *s.PowerOn
+	}
+	if s.Online != nil {
+		return *s.Online
+	}
+	return false
+}
+
+// NormalizeState converts a raw state response into a DeviceState.
+// Common property keys: "online", "powerState" ("on"/"off"), "brightness",
+// "color" ({"r","g","b"}), and "colorTem" / "colorTemInKelvin".
+func NormalizeState(resp *DeviceStateResponse, apiKeyIndex int) DeviceState {
+	state := DeviceState{
+		DeviceID:    resp.Data.Device,
+		Model:       resp.Data.Model,
+		APIKeyIndex: apiKeyIndex,
+		FetchedAt:   time.Now(),
+	}
+
+	for _, prop := range resp.Data.Properties {
+		for key, value := range prop {
+			switch key {
+			case "online":
+				if b, ok := toBool(value); ok {
+					state.Online = &b
+				}
+			case "powerState":
+				if str, ok := value.(string); ok {
+					on := str == "on"
+					state.PowerOn = &on
+				}
+			case "brightness":
+				if n, ok := toInt(value); ok {
+					state.Brightness = &n
+				}
+			case "color":
+				if m, ok := value.(map[string]interface{}); ok {
+					r, okR := toInt(m["r"])
+					g, okG := toInt(m["g"])
+					b, okB := toInt(m["b"])
+					if okR && okG && okB {
+						state.Color = &ColorValue{R: r, G: g, B: b}
+					}
+				}
+			case "colorTem", "colorTemInKelvin":
+				// Some models report 0 while in RGB mode — treat that as absent.
+				if n, ok := toInt(value); ok && n > 0 {
+					state.ColorTem = &n
+				}
+			}
+		}
+	}
+
+	return state
+}
+
+// toBool accepts a JSON bool or a "true"/"false" string.
+func toBool(v interface{}) (bool, bool) {
+	switch val := v.(type) {
+	case bool:
+		return val, true
+	case string:
+		b, err := strconv.ParseBool(val)
+		return b, err == nil
+	}
+	return false, false
+}
+
+// toInt accepts a JSON number (decoded as float64) or a numeric string.
+func toInt(v interface{}) (int, bool) {
+	switch val := v.(type) {
+	case float64:
+		return int(val), true
+	case int:
+		return val, true
+	case string:
+		n, err := strconv.Atoi(val)
+		return n, err == nil
+	}
+	return 0, false
+}
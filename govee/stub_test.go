@@ -0,0 +1,106 @@
+package govee
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stubOptions configures the fake device behind newCommandStub.
+type stubOptions struct {
+	// states are the /state bodies returned in order, repeating the last
+	// one. With none, state reads fail with "device not support retrieve".
+	states []string
+	// reject maps command names to the error message they are refused with.
+	reject map[string]string
+}
+
+// brightnessState is a /state body reporting only the given brightness.
+func brightnessState(level int) string {
+	return fmt.Sprintf(`{"code": 200, "data": {"properties": [{"brightness": %d}]}}`, level)
+}
+
+// commandStub records every request a newCommandStub client makes.
+type commandStub struct {
+	mu    sync.Mutex
+	sent  []ControlCommand
+	calls []string
+}
+
+// newCommandStub returns a client backed by a fake device configured by
+// opts, and the recorder of what it received. Apply, reset and brightness
+// settle delays are zeroed for the duration of the test.
+func newCommandStub(t *testing.T, opts stubOptions) (*Client, *commandStub) {
+	t.Helper()
+
+	previousApply, previousReset, previousSettle := applyPowerOnDelay, resetStepDelay, brightnessSettle
+	applyPowerOnDelay, resetStepDelay, brightnessSettle = 0, 0, 0
+	t.Cleanup(func() {
+		applyPowerOnDelay, resetStepDelay, brightnessSettle = previousApply, previousReset, previousSettle
+	})
+
+	stub := &commandStub{}
+	reads := 0
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+
+		if strings.HasSuffix(r.URL.Path, "/state") {
+			stub.calls = append(stub.calls, "read")
+			if len(opts.states) == 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code": 400, "message": "device not support retrieve"}`))
+				return
+			}
+			w.Write([]byte(opts.states[min(reads, len(opts.states)-1)]))
+			reads++
+			return
+		}
+
+		var req ControlRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		stub.sent = append(stub.sent, req.Cmd)
+		if color, ok := req.Cmd.Value.(map[string]interface{}); ok && req.Cmd.Name == "color" {
+			stub.calls = append(stub.calls, fmt.Sprintf("color %v,%v,%v", color["r"], color["g"], color["b"]))
+		} else {
+			stub.calls = append(stub.calls, fmt.Sprintf("%s %v", req.Cmd.Name, req.Cmd.Value))
+		}
+		if message, ok := opts.reject[req.Cmd.Name]; ok {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"code": 400, "message": %q}`, message)
+			return
+		}
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	})
+	return client, stub
+}
+
+// commands returns the control commands received so far, in order.
+func (s *commandStub) commands() []ControlCommand {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ControlCommand(nil), s.sent...)
+}
+
+// names returns the received command names joined with commas.
+func (s *commandStub) names() string {
+	return commandNames(s.commands())
+}
+
+// log returns every request in order: "read", "color r,g,b", or "name value".
+func (s *commandStub) log() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.Join(s.calls, "; ")
+}
+
+func commandNames(cmds []ControlCommand) string {
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.Name
+	}
+	return strings.Join(names, ",")
+}
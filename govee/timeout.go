@@ -0,0 +1,37 @@
+package govee
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// MaxCommandTimeout is the longest per-command timeout a caller may ask for:
+// the client's own request timeout, which applies regardless.
+const MaxCommandTimeout = requestTimeout
+
+// CommandContext bounds a command to timeout, clamped to MaxCommandTimeout,
+// so a batch of commands can give up on an unresponsive device early while
+// interactive commands keep the full client timeout. timeout <= 0 leaves
+// ctx's own deadline (if any) in place.
+func CommandContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, min(timeout, MaxCommandTimeout))
+}
+
+// IsTimeoutError reports whether a command failed because it ran out of
+// time — a per-command deadline or the client's request timeout — rather
+// than Govee rejecting it.
+func IsTimeoutError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,62 @@
+package govee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCommandContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		want    time.Duration // 0 = no deadline
+	}{
+		{"none", 0, 0},
+		{"negative", -time.Second, 0},
+		{"short", 2 * time.Second, 2 * time.Second},
+		{"clamped", time.Minute, MaxCommandTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := CommandContext(context.Background(), tt.timeout)
+			defer cancel()
+
+			deadline, ok := ctx.Deadline()
+			if tt.want == 0 {
+				if ok {
+					t.Errorf("expected no deadline, got one in %s", time.Until(deadline))
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("expected a deadline")
+			}
+			if remaining := time.Until(deadline); remaining > tt.want || remaining < tt.want-time.Second {
+				t.Errorf("expected a deadline about %s away, got %s", tt.want, remaining)
+			}
+		})
+	}
+}
+
+func TestIsTimeoutError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{context.DeadlineExceeded, true},
+		{fmt.Errorf("failed to send request: %w", context.DeadlineExceeded), true},
+		{context.Canceled, false},
+		{errors.New("API error (status 400): Device Offline"), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTimeoutError(tt.err); got != tt.want {
+			t.Errorf("IsTimeoutError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package govee
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Where a timer runs.
+const (
+	TimerOnDevice = "device"  // Pushed to the device with Govee's timer capability
+	TimerArtemis  = "artemis" // Kept by Artemis, which sends the command when it's due
+)
+
+// Bounds of a timer's delay. Device timers count in whole minutes.
+const (
+	MinTimerDelay = time.Minute
+	MaxTimerDelay = 24 * time.Hour
+)
+
+// timerFireTimeout caps the command an Artemis-managed timer sends when it
+// fires, since no request context is left by then.
+const timerFireTimeout = 15 * time.Second
+
+// Timer is an on/off timer set on a device.
+type Timer struct {
+	APIKeyIndex int       `json:"apiKeyIndex"`
+	DeviceID    string    `json:"deviceId"`
+	Model       string    `json:"model"`
+	Action      string    `json:"action"` // "on" or "off"
+	FiresAt     time.Time `json:"firesAt"`
+	Mechanism   string    `json:"mechanism"`          // TimerOnDevice or TimerArtemis
+	Fallback    string    `json:"fallback,omitempty"` // Why a device timer wasn't used, when it was tried
+}
+
+// TimerResult reports how an Artemis-managed timer fired.
+type TimerResult struct {
+	Timer
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// timerPowerValue is the value of the v2 timer capability: the power state
+// to switch to, and after how many minutes.
+type timerPowerValue struct {
+	PowerSwitch int `json:"powerSwitch"` // 1 on, 0 off
+	Minutes     int `json:"minutes"`
+}
+
+// rememberTimers records which models advertise a timer capability, and its
+// instance, from a v2 device list.
+func (c *Client) rememberTimers(devices []v2Device) {
+	c.timersMu.Lock()
+	defer c.timersMu.Unlock()
+
+	for _, device := range devices {
+		for _, capability := range device.Capabilities {
+			if capability.Type == capTimer {
+				c.timerInstances[strings.ToUpper(device.SKU)] = capability.Instance
+			}
+		}
+	}
+}
+
+// timerInstance returns the timer capability instance model advertised, or
+// "" if it has none (always, with API v1, which has no timers).
+func (c *Client) timerInstance(model string) string {
+	c.timersMu.RLock()
+	defer c.timersMu.RUnlock()
+	return c.timerInstances[strings.ToUpper(model)]
+}
+
+// SupportsDeviceTimer reports whether the device list showed model with a
+// timer capability, so SetDeviceTimer can store a timer on it.
+func (c *Client) SupportsDeviceTimer(model string) bool {
+	return c.timerInstance(model) != ""
+}
+
+// SetDeviceTimer stores a timer on the device itself that turns it on or off
+// after delay (rounded up to whole minutes), so it fires even while Artemis
+// isn't running. Only models SupportsDeviceTimer reports can take one.
+func (c *Client) SetDeviceTimer(ctx context.Context, deviceID, model, action string, delay time.Duration) error {
+	instance := c.timerInstance(model)
+	if instance == "" {
+		return fmt.Errorf("model %s has no device timer", model)
+	}
+	if action != "on" && action != "off" {
+		return fmt.Errorf("timer action must be \"on\" or \"off\", got %q", action)
+	}
+
+	value := timerPowerValue{Minutes: int((delay + time.Minute - 1) / time.Minute)}
+	if action == "on" {
+		value.PowerSwitch = 1
+	}
+	log.Printf("⏲️  Setting device timer on %s: %s in %d min", deviceID, action, value.Minutes)
+	return c.sendCapabilityV2(ctx, deviceID, model, capTimer, instance, value)
+}
+
+// TimerScheduler sets on/off timers, on the device where its model supports
+// it and otherwise in Artemis. Artemis-managed timers live in memory: they
+// are lost on restart, which is why a device timer is preferred.
+//
+// A device has at most one timer: setting another replaces it (an Artemis
+// one is cancelled). Safe for concurrent use.
+type TimerScheduler struct {
+	clients []*Client
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer // Artemis-managed timers, keyed by stateKey(apiKeyIndex, deviceID)
+
+	// Optional hook called when an Artemis-managed timer fires. Set via
+	// OnFire before any timer is set.
+	onFire func(TimerResult)
+}
+
+// NewTimerScheduler creates a scheduler that sends commands through the
+// given clients.
+func NewTimerScheduler(clients []*Client) *TimerScheduler {
+	return &TimerScheduler{
+		clients: clients,
+		timers:  make(map[string]*time.Timer),
+	}
+}
+
+// OnFire registers a callback invoked with the outcome of each
+// Artemis-managed timer.
+func (s *TimerScheduler) OnFire(fn func(TimerResult)) {
+	s.onFire = fn
+}
+
+// Set sets a timer that turns the device on or off after delay. It tries a
+// device timer first when the model supports one, and falls back to an
+// Artemis-managed timer when it doesn't or Govee rejects it. The returned
+// Timer says which was used.
+func (s *TimerScheduler) Set(ctx context.Context, apiKeyIndex int, deviceID, model, action string, delay time.Duration) (Timer, error) {
+	if apiKeyIndex < 0 || apiKeyIndex >= len(s.clients) {
+		return Timer{}, fmt.Errorf("invalid API key index %d", apiKeyIndex)
+	}
+	if action != "on" && action != "off" {
+		return Timer{}, fmt.Errorf("timer action must be \"on\" or \"off\", got %q", action)
+	}
+	if delay < MinTimerDelay || delay > MaxTimerDelay {
+		return Timer{}, fmt.Errorf("timer delay must be between %s and %s, got %s", MinTimerDelay, MaxTimerDelay, delay)
+	}
+
+	timer := Timer{
+		APIKeyIndex: apiKeyIndex,
+		DeviceID:    deviceID,
+		Model:       model,
+		Action:      action,
+		FiresAt:     time.Now().Add(delay),
+		Mechanism:   TimerOnDevice,
+	}
+	client := s.clients[apiKeyIndex]
+	if client.SupportsDeviceTimer(model) {
+		err := client.SetDeviceTimer(ctx, deviceID, model, action, delay)
+		if err == nil {
+			s.cancel(apiKeyIndex, deviceID)
+			return timer, nil
+		}
+		log.Printf("⚠️  Device timer on %s failed, keeping the timer in Artemis: %v", deviceID, err)
+		timer.Fallback = "device timer failed: " + err.Error()
+	}
+
+	timer.Mechanism = TimerArtemis
+	s.schedule(timer, delay)
+	return timer, nil
+}
+
+// schedule starts an Artemis-managed timer, replacing the device's previous one.
+func (s *TimerScheduler) schedule(timer Timer, delay time.Duration) {
+	key := stateKey(timer.APIKeyIndex, NormalizeDeviceID(timer.DeviceID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if previous, ok := s.timers[key]; ok {
+		previous.Stop()
+	}
+	var t *time.Timer
+	t = time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		if s.timers[key] != t {
+			s.mu.Unlock()
+			return // Replaced or cancelled while firing
+		}
+		delete(s.timers, key)
+		s.mu.Unlock()
+		s.fire(timer)
+	})
+	s.timers[key] = t
+	log.Printf("⏲️  Timer set in Artemis for %s: %s at %s", timer.DeviceID, timer.Action, timer.FiresAt.Format(time.Kitchen))
+}
+
+// cancel stops the device's Artemis-managed timer, if it has one.
+func (s *TimerScheduler) cancel(apiKeyIndex int, deviceID string) {
+	key := stateKey(apiKeyIndex, NormalizeDeviceID(deviceID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.timers[key]; ok {
+		t.Stop()
+		delete(s.timers, key)
+	}
+}
+
+// fire sends a due timer's command.
+func (s *TimerScheduler) fire(timer Timer) {
+	ctx, cancel := context.WithTimeout(context.Background(), timerFireTimeout)
+	defer cancel()
+
+	client := s.clients[timer.APIKeyIndex]
+	var err error
+	if timer.Action == "on" {
+		err = client.TurnOn(ctx, timer.DeviceID, timer.Model)
+	} else {
+		err = client.TurnOff(ctx, timer.DeviceID, timer.Model)
+	}
+
+	result := TimerResult{Timer: timer, Success: err == nil}
+	if err != nil {
+		result.Error = err.Error()
+		log.Printf("❌ Timer for %s (%s) failed: %v", timer.DeviceID, timer.Action, err)
+	} else {
+		log.Printf("⏲️  Timer fired: %s turned %s", timer.DeviceID, timer.Action)
+	}
+	if s.onFire != nil {
+		s.onFire(result)
+	}
+}
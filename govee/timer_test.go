@@ -0,0 +1,156 @@
+package govee
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// timerDeviceList is a v2 device list with one lamp that has a timer and one
+// that doesn't.
+const timerDeviceList = `{"code": 200, "message": "success", "data": [
+	{"sku": "H7130", "device": "AA:01", "deviceName": "Heater Lamp", "capabilities": [
+		{"type": "devices.capabilities.on_off", "instance": "powerSwitch"},
+		{"type": "devices.capabilities.timer", "instance": "autoOffTimer"}
+	]},
+	{"sku": "H6008", "device": "AA:02", "deviceName": "Desk Lamp", "capabilities": [
+		{"type": "devices.capabilities.on_off", "instance": "powerSwitch"}
+	]}]}`
+
+// newTimerStub returns a v2 client that has loaded timerDeviceList, and the
+// control payloads it received. Control requests answer with controlCode.
+func newTimerStub(t *testing.T, controlCode int) (*Client, func() []v2ControlPayload) {
+	t.Helper()
+	var mu sync.Mutex
+	var controls []v2ControlPayload
+	client := newStubClientV2(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == devicesEndpointV2 {
+			w.Write([]byte(timerDeviceList))
+			return
+		}
+		var req struct {
+			Payload v2ControlPayload `json:"payload"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		controls = append(controls, req.Payload)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": controlCode, "msg": "done"})
+	})
+	devices, err := client.GetDevices(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices[0].SupportCmds) != 2 || devices[0].SupportCmds[1] != "timer" {
+		t.Fatalf("expected the timer capability in supportCmds, got %v", devices[0].SupportCmds)
+	}
+	return client, func() []v2ControlPayload {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]v2ControlPayload(nil), controls...)
+	}
+}
+
+func TestTimerScheduler_UsesDeviceTimer(t *testing.T) {
+	client, controls := newTimerStub(t, 200)
+	scheduler := NewTimerScheduler([]*Client{client})
+
+	timer, err := scheduler.Set(context.Background(), 0, "AA:01", "H7130", "off", 90*time.Second)
+	if err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if timer.Mechanism != TimerOnDevice {
+		t.Errorf("expected a device timer, got %+v", timer)
+	}
+	sent := controls()
+	if len(sent) != 1 || sent[0].Capability.Type != capTimer || sent[0].Capability.Instance != "autoOffTimer" {
+		t.Fatalf("expected one timer capability command, got %+v", sent)
+	}
+	if value := sent[0].Capability.Value.(map[string]interface{}); value["minutes"] != 2.0 || value["powerSwitch"] != 0.0 {
+		t.Errorf("expected off in 2 minutes (rounded up), got %v", value)
+	}
+	if len(scheduler.timers) != 0 {
+		t.Error("expected no Artemis-managed timer")
+	}
+}
+
+func TestTimerScheduler_FallsBackForUnsupportedModel(t *testing.T) {
+	client, controls := newTimerStub(t, 200)
+	scheduler := NewTimerScheduler([]*Client{client})
+
+	timer, err := scheduler.Set(context.Background(), 0, "AA:02", "H6008", "on", time.Hour)
+	if err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	defer scheduler.cancel(0, "AA:02")
+	if timer.Mechanism != TimerArtemis || timer.Fallback != "" || len(controls()) != 0 {
+		t.Errorf("expected an Artemis-managed timer without trying the device, got %+v (%d commands)", timer, len(controls()))
+	}
+	if len(scheduler.timers) != 1 {
+		t.Errorf("expected one Artemis-managed timer, got %d", len(scheduler.timers))
+	}
+}
+
+func TestTimerScheduler_FallsBackWhenDeviceRejects(t *testing.T) {
+	client, _ := newTimerStub(t, 400)
+	scheduler := NewTimerScheduler([]*Client{client})
+
+	timer, err := scheduler.Set(context.Background(), 0, "AA:01", "H7130", "off", time.Hour)
+	if err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	defer scheduler.cancel(0, "AA:01")
+	if timer.Mechanism != TimerArtemis || timer.Fallback == "" {
+		t.Errorf("expected a fallback to Artemis with a reason, got %+v", timer)
+	}
+}
+
+func TestTimerScheduler_ArtemisTimerFires(t *testing.T) {
+	client, controls := newTimerStub(t, 200)
+	scheduler := NewTimerScheduler([]*Client{client})
+	results := make(chan TimerResult, 1)
+	scheduler.OnFire(func(result TimerResult) { results <- result })
+
+	// Replacing a timer cancels the first one
+	scheduler.schedule(Timer{DeviceID: "AA:02", Model: "H6008", Action: "on"}, time.Hour)
+	scheduler.schedule(Timer{DeviceID: "aa02", Model: "H6008", Action: "off"}, 10*time.Millisecond)
+
+	select {
+	case result := <-results:
+		if !result.Success || result.Action != "off" {
+			t.Errorf("expected the replacing off timer to fire, got %+v", result)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timer didn't fire")
+	}
+	if sent := controls(); len(sent) != 1 || sent[0].Capability.Instance != instancePower || sent[0].Capability.Value != 0.0 {
+		t.Errorf("expected one power-off command, got %+v", sent)
+	}
+	if len(scheduler.timers) != 0 {
+		t.Errorf("expected no timers left, got %d", len(scheduler.timers))
+	}
+}
+
+func TestTimerScheduler_Validates(t *testing.T) {
+	client, _ := newTimerStub(t, 200)
+	scheduler := NewTimerScheduler([]*Client{client})
+
+	cases := []struct {
+		action string
+		delay  time.Duration
+		index  int
+	}{
+		{"toggle", time.Hour, 0},
+		{"on", 30 * time.Second, 0},
+		{"on", 25 * time.Hour, 0},
+		{"on", time.Hour, 1},
+	}
+	for _, c := range cases {
+		if _, err := scheduler.Set(context.Background(), c.index, "AA:02", "H6008", c.action, c.delay); err == nil {
+			t.Errorf("expected %+v to be rejected", c)
+		}
+	}
+}
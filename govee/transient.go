@@ -0,0 +1,90 @@
+package govee
+
+import (
+	"context"
+	"errors"
+	"log"
+	"syscall"
+	"time"
+
+	"github.com/pantheon/artemis/upstream"
+)
+
+// DefaultRetries is how many times a client retries a device list or a
+// control command that failed transiently, unless changed with SetRetries.
+const DefaultRetries = 3
+
+// retryBaseDelay is the wait before the first retry. Each later retry waits
+// twice as long as the one before: 200ms, 400ms, 800ms, ...
+const retryBaseDelay = 200 * time.Millisecond
+
+// serverError is a 5xx answer from Govee, which a retry may well get past.
+// Error() is the underlying error's message.
+type serverError struct {
+	err error
+}
+
+func (e *serverError) Error() string {
+	return e.err.Error()
+}
+
+func (e *serverError) Unwrap() error {
+	return e.err
+}
+
+// statusError marks err as a serverError when status is a 5xx, and returns
+// it unchanged otherwise.
+func statusError(status int, err error) error {
+	if status >= 500 {
+		return &serverError{err: err}
+	}
+	return err
+}
+
+// isTransientError reports whether a failed request is worth retrying: a
+// 5xx from Govee, or a request that timed out, was refused, or had its
+// connection reset. 4xx answers (bad request, bad API key, offline device,
+// rate limited) and an open breaker are not: repeating them right away
+// can't help.
+func isTransientError(err error) bool {
+	var server *serverError
+	if errors.As(err, &server) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	if classified, ok := upstream.As(err); ok {
+		return classified.Kind == upstream.KindTimeout || classified.Kind == upstream.KindConnectionRefused
+	}
+	return false
+}
+
+// withRetries runs attempt, and runs it again up to the client's retry count
+// while it fails transiently, waiting retryBaseDelay, then twice that, and so
+// on, in between. Stops early, with the last error, once ctx ends.
+//
+// Control commands are retried after a timeout too: every command sets an
+// absolute value (on, brightness 40, a color), so sending one that already
+// reached the device again leaves it in the same state.
+func (c *Client) withRetries(ctx context.Context, what string, attempt func() error) error {
+	err := attempt()
+	for retry := 1; retry <= c.retries && err != nil && isTransientError(err); retry++ {
+		delay := retryBaseDelay << (retry - 1)
+		log.Printf("⚠️  Govee %s failed (%v), retrying in %s (%d/%d)", what, err, delay, retry, c.retries)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		err = attempt()
+	}
+	return err
+}
+
+// SetRetries sets how many times device lists and control commands are
+// retried after a transient failure (see isTransientError). 0 disables
+// retries. Call before the client is used.
+func (c *Client) SetRetries(retries int) {
+	c.retries = max(retries, 0)
+}
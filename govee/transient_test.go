@@ -0,0 +1,105 @@
+package govee
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSendControlCommand_RetriesServerErrors(t *testing.T) {
+	var calls atomic.Int64
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"code": 500, "message": "Internal Server Error"}`))
+			return
+		}
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	})
+
+	start := time.Now()
+	if err := client.TurnOn(context.Background(), "AA:BB", "H6008"); err != nil {
+		t.Fatalf("expected the third attempt to succeed, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+	// Backoff of 200ms then 400ms
+	if waited := time.Since(start); waited < 600*time.Millisecond {
+		t.Errorf("expected at least 600ms of backoff, took %s", waited)
+	}
+}
+
+func TestGetDevices_RetriesServerErrors(t *testing.T) {
+	var calls atomic.Int64
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"code": 200, "message": "Success", "data": {"devices": [{"device": "AA:BB", "model": "H6008"}]}}`))
+	})
+
+	devices, err := client.GetDevices(context.Background())
+	if err != nil || len(devices) != 1 || calls.Load() != 2 {
+		t.Errorf("expected the list on the second attempt, got %v %+v after %d attempt(s)", err, devices, calls.Load())
+	}
+}
+
+func TestSendControlCommand_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int64
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"code": 401, "message": "Unauthorized"}`))
+	})
+
+	if err := client.TurnOn(context.Background(), "AA:BB", "H6008"); err == nil {
+		t.Fatal("expected an error for a 401")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a 4xx not to be retried, got %d attempts", calls.Load())
+	}
+}
+
+func TestSendControlCommand_RetriesLimited(t *testing.T) {
+	var calls atomic.Int64
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	client.SetRetries(0)
+	if err := client.TurnOn(context.Background(), "AA:BB", "H6008"); err == nil || calls.Load() != 1 {
+		t.Errorf("expected one attempt with retries off, got %d (%v)", calls.Load(), err)
+	}
+
+	// A deadline shorter than the backoff ends the retries early
+	client.SetRetries(3)
+	calls.Store(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := client.TurnOn(ctx, "AA:BB", "H6008"); err == nil || calls.Load() != 1 {
+		t.Errorf("expected the deadline to stop retries after one attempt, got %d (%v)", calls.Load(), err)
+	}
+}
+
+func TestSendControlCommand_RetriesTimeouts(t *testing.T) {
+	var calls atomic.Int64
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	})
+	client.httpClient.Timeout = 50 * time.Millisecond
+
+	if err := client.TurnOn(context.Background(), "AA:BB", "H6008"); err != nil {
+		t.Fatalf("expected the retry after the timeout to succeed, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls.Load())
+	}
+}
@@ -0,0 +1,232 @@
+package govee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Fade emulation limits.
+//
+// Neither Govee API version has a transition parameter for brightness or
+// color, so every fade is emulated server-side by stepping the value over
+// time. Govee limits control commands per device (roughly 10/minute), so a
+// fade is capped at a handful of steps spaced at least minFadeStepInterval
+// apart — smooth enough to avoid a jarring snap without burning the device's
+// whole budget.
+const (
+	maxFadeSteps        = 5
+	minFadeStepInterval = 400 * time.Millisecond
+
+	// MaxTransition is the longest fade accepted; longer requests are clamped.
+	MaxTransition = 10 * time.Second
+)
+
+// planFade decides how many steps a fade of the given length uses and how
+// long to wait between them. Short transitions get fewer steps so each one
+// is at least minFadeStepInterval apart.
+func planFade(transition time.Duration) (steps int, interval time.Duration) {
+	transition = min(transition, MaxTransition)
+	steps = int(transition / minFadeStepInterval)
+	steps = min(max(steps, 1), maxFadeSteps)
+	return steps, transition / time.Duration(steps)
+}
+
+// interpolate returns the intermediate values of a linear fade from `from`
+// to `to` in the given number of steps. The starting value is excluded and
+// the last value is always exactly `to`.
+func interpolate(from, to, steps int) []int {
+	values := make([]int, steps)
+	for i := 1; i <= steps; i++ {
+		// Round to nearest rather than truncate so fades are symmetric.
+		delta := float64(to-from) * float64(i) / float64(steps)
+		if delta >= 0 {
+			values[i-1] = from + int(delta+0.5)
+		} else {
+			values[i-1] = from + int(delta-0.5)
+		}
+	}
+	return values
+}
+
+// interpolateColor is interpolate for each RGB channel.
+func interpolateColor(from, to ColorValue, steps int) []ColorValue {
+	r := interpolate(from.R, to.R, steps)
+	g := interpolate(from.G, to.G, steps)
+	b := interpolate(from.B, to.B, steps)
+
+	colors := make([]ColorValue, steps)
+	for i := range colors {
+		colors[i] = ColorValue{R: r[i], G: g[i], B: b[i]}
+	}
+	return colors
+}
+
+// FadeBrightness moves a device's brightness to level over the transition.
+// Blocks until the fade finishes or ctx is cancelled. Falls back to a direct SetBrightness when
+// the current brightness can't be read.
+func (c *Client) FadeBrightness(ctx context.Context, deviceID, model string, level int, transition time.Duration) error {
+	if level < 0 || level > 100 {
+		return fmt.Errorf("brightness must be between 0 and 100, got %d", level)
+	}
+
+	current, err := c.currentState(ctx, deviceID, model)
+	if err != nil || current.Brightness == nil {
+		log.Printf("⚠️  Fade %s: current brightness unknown, setting directly", deviceID)
+		return c.SetBrightness(ctx, deviceID, model, level)
+	}
+
+	steps, interval := planFade(transition)
+	log.Printf("💡 Fading brightness of %s from %d to %d (%d steps, %s apart)",
+		deviceID, *current.Brightness, level, steps, interval)
+
+	for i, value := range interpolate(*current.Brightness, level, steps) {
+		if i > 0 {
+			if err := sleepCtx(ctx, interval); err != nil {
+				return err
+			}
+		}
+		if err := c.SetBrightness(ctx, deviceID, model, value); err != nil {
+			return fmt.Errorf("fade step %d/%d failed: %w", i+1, steps, err)
+		}
+	}
+	return nil
+}
+
+// FadeColor moves a device's color to the target over the transition.
+// Blocks until the fade finishes or ctx is cancelled. Falls back to a direct SetColor when the
+// current color can't be read (e.g., the device is in color temperature mode).
+func (c *Client) FadeColor(ctx context.Context, deviceID, model string, target ColorValue, transition time.Duration) error {
+	if !validColor(target) {
+		return fmt.Errorf("RGB values must be between 0 and 255, got R=%d G=%d B=%d", target.R, target.G, target.B)
+	}
+
+	current, err := c.currentState(ctx, deviceID, model)
+	if err != nil || current.Color == nil {
+		log.Printf("⚠️  Fade %s: current color unknown, setting directly", deviceID)
+		return c.SetColor(ctx, deviceID, model, target.R, target.G, target.B)
+	}
+
+	steps, interval := planFade(transition)
+	log.Printf("💡 Fading color of %s to RGB(%d, %d, %d) (%d steps, %s apart)",
+		deviceID, target.R, target.G, target.B, steps, interval)
+
+	for i, color := range interpolateColor(*current.Color, target, steps) {
+		if i > 0 {
+			if err := sleepCtx(ctx, interval); err != nil {
+				return err
+			}
+		}
+		if err := c.SetColor(ctx, deviceID, model, color.R, color.G, color.B); err != nil {
+			return fmt.Errorf("fade step %d/%d failed: %w", i+1, steps, err)
+		}
+	}
+	return nil
+}
+
+// backgroundFade is a fade running on its own after the request that
+// started it returned.
+type backgroundFade struct {
+	cancel context.CancelFunc
+}
+
+// fadeKey marks a context as belonging to a background fade, so the fade's
+// own steps don't cancel it.
+type fadeKey struct{}
+
+// fadeInBackground runs fade without blocking the caller, logging failures.
+// The fade keeps ctx's values (e.g. the trace span) but not its cancellation,
+// since it outlives the request that started it. Instead it stops when the
+// next command reaches the device, when another fade on it starts, or on
+// CancelFades.
+func (c *Client) fadeInBackground(ctx context.Context, deviceID string, fade func(ctx context.Context) error) {
+	key := NormalizeDeviceID(deviceID)
+	fadeCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	f := &backgroundFade{cancel: cancel}
+	fadeCtx = context.WithValue(fadeCtx, fadeKey{}, f)
+
+	c.fadesMu.Lock()
+	if running := c.fades[key]; running != nil {
+		running.cancel()
+	}
+	c.fades[key] = f
+	c.fadesWG.Add(1)
+	c.fadesMu.Unlock()
+
+	go func() {
+		defer c.fadesWG.Done()
+		defer func() {
+			cancel()
+			c.fadesMu.Lock()
+			if c.fades[key] == f {
+				delete(c.fades, key)
+			}
+			c.fadesMu.Unlock()
+		}()
+
+		err := fade(fadeCtx)
+		switch {
+		case err == nil:
+		case errors.Is(err, context.Canceled):
+			log.Printf("💡 Fade on device %s cancelled", deviceID)
+		default:
+			log.Printf("❌ Fade failed for device %s: %v", deviceID, err)
+		}
+	}()
+}
+
+// cancelFade stops the background fade on deviceID, unless ctx is that
+// fade's own context (one of its steps).
+func (c *Client) cancelFade(ctx context.Context, deviceID string) {
+	key := NormalizeDeviceID(deviceID)
+	c.fadesMu.Lock()
+	defer c.fadesMu.Unlock()
+
+	running := c.fades[key]
+	if running == nil || ctx.Value(fadeKey{}) == running {
+		return
+	}
+	running.cancel()
+	delete(c.fades, key)
+}
+
+// CancelFades stops every background fade and waits for them to exit, so
+// none can send another step afterwards. Used during shutdown, before the
+// shutdown actions run.
+func (c *Client) CancelFades() {
+	c.fadesMu.Lock()
+	for key, f := range c.fades {
+		f.cancel()
+		delete(c.fades, key)
+	}
+	c.fadesMu.Unlock()
+	c.fadesWG.Wait()
+}
+
+// sleepCtx waits for d, returning early with ctx's error if it's cancelled.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// currentState reads and normalizes a device's state.
+func (c *Client) currentState(ctx context.Context, deviceID, model string) (DeviceState, error) {
+	resp, err := c.GetDeviceState(ctx, deviceID, model)
+	if err != nil {
+		return DeviceState{}, err
+	}
+	return NormalizeState(resp, 0), nil
+}
+
+// validColor reports whether every channel is within 0-255.
+func validColor(c ColorValue) bool {
+	return c.R >= 0 && c.R <= 255 && c.G >= 0 && c.G <= 255 && c.B >= 0 && c.B <= 255
+}
@@ -0,0 +1,164 @@
+package govee
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlanFade(t *testing.T) {
+	tests := []struct {
+		transition   time.Duration
+		wantSteps    int
+		wantInterval time.Duration
+	}{
+		{100 * time.Millisecond, 1, 100 * time.Millisecond}, // Too short to step — one command
+		{800 * time.Millisecond, 2, 400 * time.Millisecond}, // Steps at least 400ms apart
+		{1 * time.Second, 2, 500 * time.Millisecond},        // Rounds steps down
+		{2 * time.Second, 5, 400 * time.Millisecond},        // Hits the step cap exactly
+		{5 * time.Second, 5, 1 * time.Second},               // Capped at 5 steps
+		{time.Minute, 5, 2 * time.Second},                   // Clamped to MaxTransition
+	}
+
+	for _, tt := range tests {
+		steps, interval := planFade(tt.transition)
+		if steps != tt.wantSteps || interval != tt.wantInterval {
+			t.Errorf("planFade(%s) = (%d, %s), want (%d, %s)",
+				tt.transition, steps, interval, tt.wantSteps, tt.wantInterval)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	tests := []struct {
+		from, to, steps int
+		want            []int
+	}{
+		{0, 100, 4, []int{25, 50, 75, 100}},
+		{100, 0, 4, []int{75, 50, 25, 0}},
+		{10, 20, 3, []int{13, 17, 20}}, // Rounded, last is exact
+		{20, 10, 3, []int{17, 13, 10}}, // Symmetric going down
+		{50, 50, 3, []int{50, 50, 50}}, // No change
+		{0, 255, 1, []int{255}},        // Single step jumps to target
+	}
+
+	for _, tt := range tests {
+		if got := interpolate(tt.from, tt.to, tt.steps); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("interpolate(%d, %d, %d) = %v, want %v", tt.from, tt.to, tt.steps, got, tt.want)
+		}
+	}
+}
+
+func TestInterpolateColor(t *testing.T) {
+	got := interpolateColor(ColorValue{R: 255}, ColorValue{B: 255}, 5)
+	want := []ColorValue{
+		{R: 204, G: 0, B: 51},
+		{R: 153, G: 0, B: 102},
+		{R: 102, G: 0, B: 153},
+		{R: 51, G: 0, B: 204},
+		{R: 0, G: 0, B: 255},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("interpolateColor red->blue = %v, want %v", got, want)
+	}
+}
+
+func TestFadeBrightness_StepsFromCurrentValue(t *testing.T) {
+	var sent []int
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/state") {
+			w.Write([]byte(`{"code": 200, "data": {"properties": [{"brightness": 20}]}}`))
+			return
+		}
+		var req ControlRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sent = append(sent, int(req.Cmd.Value.(float64)))
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	})
+
+	// 800ms → 2 steps; keep the test fast but exercise the stepping.
+	if err := client.FadeBrightness(context.Background(), "AA:BB", "H6008", 80, 800*time.Millisecond); err != nil {
+		t.Fatalf("FadeBrightness returned error: %v", err)
+	}
+	if !reflect.DeepEqual(sent, []int{50, 80}) {
+		t.Errorf("expected brightness steps [50 80], got %v", sent)
+	}
+}
+
+func TestFadeColor_FallsBackWhenColorUnknown(t *testing.T) {
+	controls := 0
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/state") {
+			// In color temperature mode — no RGB color to fade from.
+			w.Write([]byte(`{"code": 200, "data": {"properties": [{"colorTem": 3000}]}}`))
+			return
+		}
+		controls++
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	})
+
+	if err := client.FadeColor(context.Background(), "AA:BB", "H6008", ColorValue{R: 255}, 5*time.Second); err != nil {
+		t.Fatalf("FadeColor returned error: %v", err)
+	}
+	if controls != 1 {
+		t.Errorf("expected a single direct color command, got %d", controls)
+	}
+}
+
+func TestExecuteCommand_RejectsInvalidFadeUpFront(t *testing.T) {
+	client := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("no request expected, got %s %s", r.Method, r.URL.Path)
+	})
+
+	if err := ExecuteCommand(context.Background(), client, "AA:BB", "H6008", "brightness", float64(150), time.Second); err == nil {
+		t.Error("expected out-of-range brightness to be rejected before fading")
+	}
+	if err := ExecuteCommand(context.Background(), client, "AA:BB", "H6008", "turn", true, -time.Second); err == nil {
+		t.Error("expected negative transition to be rejected")
+	}
+}
+
+func TestExecuteCommand_NewCommandCancelsFade(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{states: []string{brightnessState(20)}})
+
+	// 2s → 5 steps 400ms apart
+	if err := ExecuteCommand(context.Background(), client, "AA:BB", "H6008", "brightness", 80, 2*time.Second); err != nil {
+		t.Fatalf("ExecuteCommand returned error: %v", err)
+	}
+	if err := client.TurnOff(context.Background(), "AA:BB", "H6008"); err != nil {
+		t.Fatalf("TurnOff returned error: %v", err)
+	}
+	client.fadesWG.Wait()
+
+	got := stub.commands()
+	if len(got) == 0 || got[len(got)-1].Name != "turn" {
+		t.Errorf("expected no fade step after the turn command, got %v", got)
+	}
+	if len(got) > 2 {
+		t.Errorf("expected the fade to stop after at most one step, got %v", got)
+	}
+}
+
+func TestCancelFades(t *testing.T) {
+	client, stub := newCommandStub(t, stubOptions{states: []string{brightnessState(20)}})
+
+	if err := ExecuteCommand(context.Background(), client, "AA:BB", "H6008", "brightness", 80, 2*time.Second); err != nil {
+		t.Fatalf("ExecuteCommand returned error: %v", err)
+	}
+	start := time.Now()
+	client.CancelFades()
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected CancelFades to stop the fade promptly, took %s", elapsed)
+	}
+	if got := stub.commands(); len(got) > 1 {
+		t.Errorf("expected at most one fade step, got %v", got)
+	}
+	if len(client.fades) != 0 {
+		t.Error("expected no fades to be left running")
+	}
+}
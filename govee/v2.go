@@ -0,0 +1,342 @@
+package govee
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+)
+
+// Govee platform API v2 (openapi.api.govee.com)
+//
+// v2 replaces v1's fixed command names with capabilities: each device lists
+// what it can do as {type, instance} pairs, e.g.
+// {"type": "devices.capabilities.on_off", "instance": "powerSwitch"}, and
+// both state and control are expressed in those terms. The client translates
+// v2 responses into the same Device / DeviceStateResponse shapes v1 returns,
+// so handlers, the poller, and the MQTT bridge don't care which API is in use.
+const (
+	defaultBaseURLV2 = "https://openapi.api.govee.com"
+
+	devicesEndpointV2 = "/router/api/v1/user/devices"   // GET - list all devices with capabilities
+	stateEndpointV2   = "/router/api/v1/device/state"   // POST - query device state
+	controlEndpointV2 = "/router/api/v1/device/control" // POST - send one capability change
+)
+
+// v2 capability types and instances that map onto v1 commands.
+const (
+	capOnline       = "devices.capabilities.online"
+	capOnOff        = "devices.capabilities.on_off"
+	capRange        = "devices.capabilities.range"
+	capColorSetting = "devices.capabilities.color_setting"
+	capTimer        = "devices.capabilities.timer" // Device-local delay timer; the instance varies by model
+
+	instanceOnline     = "online"
+	instancePower      = "powerSwitch"
+	instanceBrightness = "brightness"
+	instanceColorRGB   = "colorRgb"
+	instanceColorTemK  = "colorTemperatureK"
+)
+
+// v2Capability is one entry of a device's capability list. Parameters is
+// only present in the device list; State only in state responses.
+type v2Capability struct {
+	Type       string `json:"type"`
+	Instance   string `json:"instance"`
+	Parameters struct {
+		Range struct {
+			Min int `json:"min"`
+			Max int `json:"max"`
+		} `json:"range"`
+	} `json:"parameters"`
+	State struct {
+		Value interface{} `json:"value"`
+	} `json:"state"`
+}
+
+// v2Device is a device from GET /router/api/v1/user/devices.
+type v2Device struct {
+	SKU          string         `json:"sku"` // v2's name for the model number
+	Device       string         `json:"device"`
+	DeviceName   string         `json:"deviceName"`
+	Capabilities []v2Capability `json:"capabilities"`
+}
+
+// v2DevicesResponse wraps the v2 device list.
+type v2DevicesResponse struct {
+	Code    int        `json:"code"`
+	Message string     `json:"message"`
+	Data    []v2Device `json:"data"`
+}
+
+// v2Request is the envelope for v2 state and control requests.
+type v2Request struct {
+	RequestID string      `json:"requestId"`
+	Payload   interface{} `json:"payload"`
+}
+
+// v2StatePayload identifies the device whose state is requested.
+type v2StatePayload struct {
+	SKU    string `json:"sku"`
+	Device string `json:"device"`
+}
+
+// v2ControlPayload carries a single capability change.
+type v2ControlPayload struct {
+	SKU        string `json:"sku"`
+	Device     string `json:"device"`
+	Capability struct {
+		Type     string      `json:"type"`
+		Instance string      `json:"instance"`
+		Value    interface{} `json:"value"`
+	} `json:"capability"`
+}
+
+// v2Response is returned by the v2 state and control endpoints.
+// Govee uses "msg" here but "message" on errors, so both are accepted.
+type v2Response struct {
+	RequestID string `json:"requestId"`
+	Code      int    `json:"code"`
+	Msg       string `json:"msg"`
+	Message   string `json:"message"`
+	Payload   struct {
+		SKU          string         `json:"sku"`
+		Device       string         `json:"device"`
+		Capabilities []v2Capability `json:"capabilities"`
+	} `json:"payload"`
+}
+
+// message returns whichever message field Govee filled in.
+func (r v2Response) message() string {
+	if r.Msg != "" {
+		return r.Msg
+	}
+	return r.Message
+}
+
+// getDevicesV2 lists devices via v2 and converts them to v1 Devices.
+func (c *Client) getDevicesV2(ctx context.Context) ([]Device, error) {
+	log.Println("💡 Fetching Govee devices (API v2)...")
+
+	var devicesResp v2DevicesResponse
+	if err := c.doV2(ctx, "GET", devicesEndpointV2, nil, &devicesResp); err != nil {
+		return nil, fmt.Errorf("failed to fetch devices: %w", err)
+	}
+	if devicesResp.Code != 200 {
+		return nil, fmt.Errorf("govee API error: %s (code %d)", devicesResp.Message, devicesResp.Code)
+	}
+
+	devices := make([]Device, 0, len(devicesResp.Data))
+	for _, d := range devicesResp.Data {
+		devices = append(devices, deviceFromV2(d))
+	}
+
+	// Remember per-model colorTem ranges for validating SetColorTemperature
+	c.rememberColorTemRanges(devices)
+	c.rememberTimers(devicesResp.Data)
+
+	log.Printf("💡 Found %d Govee device(s)", len(devices))
+	return devices, nil
+}
+
+// deviceFromV2 maps a v2 device onto the v1 Device shape. Capabilities with
+// a v1 equivalent become SupportCmds; the rest are ignored.
+func deviceFromV2(d v2Device) Device {
+	device := Device{
+		Device:     d.Device,
+		Model:      d.SKU,
+		DeviceName: d.DeviceName,
+		// v2 can query state for every device it lists
+		Retrievable: true,
+		SupportCmds: []string{},
+	}
+
+	for _, capability := range d.Capabilities {
+		if capability.Type == capTimer && !slices.Contains(device.SupportCmds, "timer") {
+			device.SupportCmds = append(device.SupportCmds, "timer")
+		}
+		switch capability.Instance {
+		case instancePower:
+			device.SupportCmds = append(device.SupportCmds, "turn")
+		case instanceBrightness:
+			device.SupportCmds = append(device.SupportCmds, "brightness")
+		case instanceColorRGB:
+			device.SupportCmds = append(device.SupportCmds, "color")
+		case instanceColorTemK:
+			device.SupportCmds = append(device.SupportCmds, "colorTem")
+			device.Properties.ColorTem.Range.Min = capability.Parameters.Range.Min
+			device.Properties.ColorTem.Range.Max = capability.Parameters.Range.Max
+		}
+	}
+	device.Controllable = len(device.SupportCmds) > 0
+
+	return device
+}
+
+// getDeviceStateV2 queries state via v2 and converts it to the v1 response
+// shape so NormalizeState handles both APIs.
+func (c *Client) getDeviceStateV2(ctx context.Context, deviceID, model string) (*DeviceStateResponse, error) {
+	var stateResp v2Response
+	payload := v2StatePayload{SKU: model, Device: deviceID}
+	if err := c.doV2(ctx, "POST", stateEndpointV2, payload, &stateResp); err != nil {
+		return nil, fmt.Errorf("failed to query device state: %w", err)
+	}
+	if stateResp.Code != 200 {
+		return nil, fmt.Errorf("govee API error: %s (code %d)", stateResp.message(), stateResp.Code)
+	}
+
+	resp := &DeviceStateResponse{Code: stateResp.Code, Message: stateResp.message()}
+	resp.Data.Device = deviceID
+	resp.Data.Model = model
+	resp.Data.Properties = propertiesFromV2(stateResp.Payload.Capabilities)
+	return resp, nil
+}
+
+// propertiesFromV2 converts v2 capability states into v1-style properties.
+func propertiesFromV2(capabilities []v2Capability) []map[string]interface{} {
+	properties := []map[string]interface{}{}
+
+	for _, capability := range capabilities {
+		value := capability.State.Value
+		switch capability.Instance {
+		case instanceOnline:
+			properties = append(properties, map[string]interface{}{"online": value})
+		case instancePower:
+			if n, ok := toInt(value); ok {
+				power := "off"
+				if n == 1 {
+					power = "on"
+				}
+				properties = append(properties, map[string]interface{}{"powerState": power})
+			}
+		case instanceBrightness:
+			properties = append(properties, map[string]interface{}{"brightness": value})
+		case instanceColorRGB:
+			// v2 packs RGB into one integer (0xRRGGBB). It reports 0 while the
+			// device is in color temperature mode, so treat that as absent.
+			if n, ok := toInt(value); ok && n > 0 {
+				properties = append(properties, map[string]interface{}{
+					"color": map[string]interface{}{"r": (n >> 16) & 0xFF, "g": (n >> 8) & 0xFF, "b": n & 0xFF},
+				})
+			}
+		case instanceColorTemK:
+			properties = append(properties, map[string]interface{}{"colorTem": value})
+		}
+	}
+
+	return properties
+}
+
+// capabilityForCommand maps a v1 command name and value onto the v2
+// capability type, instance, and value that does the same thing.
+func capabilityForCommand(cmdName string, value interface{}) (capType, instance string, capValue interface{}, err error) {
+	switch cmdName {
+	case "turn":
+		power := 0
+		if value == "on" {
+			power = 1
+		}
+		return capOnOff, instancePower, power, nil
+	case "brightness":
+		return capRange, instanceBrightness, value, nil
+	case "color":
+		color, ok := value.(ColorValue)
+		if !ok {
+			return "", "", nil, fmt.Errorf("color command expects a ColorValue, got %T", value)
+		}
+		return capColorSetting, instanceColorRGB, color.R<<16 | color.G<<8 | color.B, nil
+	case "colorTem":
+		return capColorSetting, instanceColorTemK, value, nil
+	}
+	return "", "", nil, fmt.Errorf("command %q is not supported by the Govee v2 API", cmdName)
+}
+
+// sendControlCommandV2 translates a v1 command into a v2 capability change.
+func (c *Client) sendControlCommandV2(ctx context.Context, deviceID, model, cmdName string, value interface{}) error {
+	capType, instance, capValue, err := capabilityForCommand(cmdName, value)
+	if err != nil {
+		return err
+	}
+
+	return c.sendCapabilityV2(ctx, deviceID, model, capType, instance, capValue)
+}
+
+// sendCapabilityV2 sends one v2 capability change as is.
+func (c *Client) sendCapabilityV2(ctx context.Context, deviceID, model, capType, instance string, capValue interface{}) error {
+	payload := v2ControlPayload{SKU: model, Device: deviceID}
+	payload.Capability.Type = capType
+	payload.Capability.Instance = instance
+	payload.Capability.Value = capValue
+
+	var controlResp v2Response
+	if err := c.doV2(ctx, "POST", controlEndpointV2, payload, &controlResp); err != nil {
+		return fmt.Errorf("failed to send control command: %w", err)
+	}
+	if controlResp.Code != 200 {
+		return fmt.Errorf("govee API error: %s (code %d)", controlResp.message(), controlResp.Code)
+	}
+
+	log.Printf("💡 Control command successful: %s", controlResp.message())
+	return nil
+}
+
+// doV2 sends a v2 request and decodes the JSON response into out.
+// A non-nil payload is wrapped in the v2 {requestId, payload} envelope.
+func (c *Client) doV2(ctx context.Context, method, endpoint string, payload interface{}, out interface{}) error {
+	var reqBody io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(v2Request{RequestID: newRequestID(), Payload: payload})
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Govee-API-Key", c.apiKey)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Check for HTTP errors
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Message != "" {
+			return statusError(resp.StatusCode, fmt.Errorf("govee API error (code %d): %s", errResp.Code, errResp.Message))
+		}
+		return statusError(resp.StatusCode, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body)))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// newRequestID returns a random ID for the v2 requestId field, which Govee
+// echoes back to correlate responses.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,181 @@
+package govee
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newStubClientV2 is newStubClient for a client using the v2 API.
+func newStubClientV2(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := NewClientWithVersion("test-key", APIVersionV2)
+	client.baseURL = server.URL
+	return client
+}
+
+func TestNewClientWithVersion(t *testing.T) {
+	if v := NewClient("k").APIVersion(); v != APIVersionV1 {
+		t.Errorf("NewClient should default to v1, got %q", v)
+	}
+	v2 := NewClientWithVersion("k", APIVersionV2)
+	if v2.APIVersion() != APIVersionV2 || v2.baseURL != defaultBaseURLV2 {
+		t.Errorf("expected v2 client on %s, got %q on %s", defaultBaseURLV2, v2.APIVersion(), v2.baseURL)
+	}
+}
+
+func TestGetDevicesV2_MapsCapabilitiesToCommands(t *testing.T) {
+	client := newStubClientV2(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != devicesEndpointV2 {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Govee-API-Key") != "test-key" {
+			t.Error("missing Govee-API-Key header")
+		}
+		w.Write([]byte(`{"code": 200, "message": "success", "data": [{
+			"sku": "H6008", "device": "AA:BB", "deviceName": "Desk Lamp",
+			"capabilities": [
+				{"type": "devices.capabilities.on_off", "instance": "powerSwitch"},
+				{"type": "devices.capabilities.range", "instance": "brightness", "parameters": {"range": {"min": 1, "max": 100}}},
+				{"type": "devices.capabilities.color_setting", "instance": "colorRgb"},
+				{"type": "devices.capabilities.color_setting", "instance": "colorTemperatureK", "parameters": {"range": {"min": 2700, "max": 6500}}},
+				{"type": "devices.capabilities.dynamic_scene", "instance": "lightScene"}
+			]}]}`))
+	})
+
+	devices, err := client.GetDevices(context.Background())
+	if err != nil {
+		t.Fatalf("GetDevices returned error: %v", err)
+	}
+	if len(devices) != 1 {
+		t.Fatalf("expected 1 device, got %d", len(devices))
+	}
+
+	d := devices[0]
+	if d.Device != "AA:BB" || d.Model != "H6008" || d.DeviceName != "Desk Lamp" {
+		t.Errorf("unexpected device fields: %+v", d)
+	}
+	if !d.Controllable || !d.Retrievable {
+		t.Error("expected device to be controllable and retrievable")
+	}
+	if want := []string{"turn", "brightness", "color", "colorTem"}; !reflect.DeepEqual(d.SupportCmds, want) {
+		t.Errorf("expected supportCmds %v, got %v", want, d.SupportCmds)
+	}
+	// Reported range is remembered for SetColorTemperature validation.
+	if r := client.ColorTemRange("H6008"); r.Min != 2700 || r.Max != 6500 {
+		t.Errorf("expected reported colorTem range 2700-6500, got %d-%d", r.Min, r.Max)
+	}
+}
+
+func TestGetDeviceStateV2_NormalizesLikeV1(t *testing.T) {
+	client := newStubClientV2(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != stateEndpointV2 {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var req struct {
+			RequestID string         `json:"requestId"`
+			Payload   v2StatePayload `json:"payload"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.RequestID == "" || req.Payload.SKU != "H6008" || req.Payload.Device != "AA:BB" {
+			t.Errorf("unexpected state request: %+v", req)
+		}
+		w.Write([]byte(`{"requestId": "x", "code": 200, "msg": "success", "payload": {"capabilities": [
+			{"type": "devices.capabilities.online", "instance": "online", "state": {"value": true}},
+			{"type": "devices.capabilities.on_off", "instance": "powerSwitch", "state": {"value": 1}},
+			{"type": "devices.capabilities.range", "instance": "brightness", "state": {"value": 42}},
+			{"type": "devices.capabilities.color_setting", "instance": "colorRgb", "state": {"value": 16744448}},
+			{"type": "devices.capabilities.color_setting", "instance": "colorTemperatureK", "state": {"value": 0}}
+		]}}`))
+	})
+
+	resp, err := client.GetDeviceState(context.Background(), "AA:BB", "H6008")
+	if err != nil {
+		t.Fatalf("GetDeviceState returned error: %v", err)
+	}
+
+	state := NormalizeState(resp, 0)
+	if state.DeviceID != "AA:BB" || state.Model != "H6008" {
+		t.Errorf("unexpected identity: %s / %s", state.DeviceID, state.Model)
+	}
+	if state.Online == nil || !*state.Online || !state.IsOn() {
+		t.Error("expected device online and on")
+	}
+	if state.Brightness == nil || *state.Brightness != 42 {
+		t.Errorf("expected brightness 42, got %v", state.Brightness)
+	}
+	if state.Color == nil || *state.Color != (ColorValue{R: 255, G: 128, B: 0}) {
+		t.Errorf("expected color RGB(255, 128, 0), got %v", state.Color)
+	}
+	if state.ColorTem != nil {
+		t.Errorf("expected colorTem 0 to be treated as absent, got %d", *state.ColorTem)
+	}
+}
+
+func TestSendControlCommandV2_TranslatesCommands(t *testing.T) {
+	tests := []struct {
+		name         string
+		send         func(c *Client) error
+		wantType     string
+		wantInstance string
+		wantValue    float64
+	}{
+		{"turn on", func(c *Client) error { return c.TurnOn(context.Background(), "AA:BB", "H6008") }, capOnOff, instancePower, 1},
+		{"turn off", func(c *Client) error { return c.TurnOff(context.Background(), "AA:BB", "H6008") }, capOnOff, instancePower, 0},
+		{"brightness", func(c *Client) error { return c.SetBrightness(context.Background(), "AA:BB", "H6008", 75) }, capRange, instanceBrightness, 75},
+		{"color", func(c *Client) error { return c.SetColor(context.Background(), "AA:BB", "H6008", 255, 128, 0) }, capColorSetting, instanceColorRGB, 16744448},
+		{"colorTem", func(c *Client) error { return c.SetColorTemperature(context.Background(), "AA:BB", "H6008", 4000) }, capColorSetting, instanceColorTemK, 4000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct {
+				Payload struct {
+					SKU        string `json:"sku"`
+					Device     string `json:"device"`
+					Capability struct {
+						Type     string  `json:"type"`
+						Instance string  `json:"instance"`
+						Value    float64 `json:"value"`
+					} `json:"capability"`
+				} `json:"payload"`
+			}
+			client := newStubClientV2(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "POST" || r.URL.Path != controlEndpointV2 {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				json.NewDecoder(r.Body).Decode(&got)
+				w.Write([]byte(`{"requestId": "x", "code": 200, "msg": "success"}`))
+			})
+
+			if err := tt.send(client); err != nil {
+				t.Fatalf("command returned error: %v", err)
+			}
+			c := got.Payload.Capability
+			if got.Payload.SKU != "H6008" || got.Payload.Device != "AA:BB" {
+				t.Errorf("unexpected target %s / %s", got.Payload.SKU, got.Payload.Device)
+			}
+			if c.Type != tt.wantType || c.Instance != tt.wantInstance || c.Value != tt.wantValue {
+				t.Errorf("expected %s/%s=%v, got %s/%s=%v", tt.wantType, tt.wantInstance, tt.wantValue, c.Type, c.Instance, c.Value)
+			}
+		})
+	}
+}
+
+func TestSendControlCommandV2_ReportsAPIError(t *testing.T) {
+	client := newStubClientV2(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code": 400, "message": "Parameter value out of range"}`))
+	})
+
+	err := client.TurnOn(context.Background(), "AA:BB", "H6008")
+	if err == nil || err.Error() != "failed to send control command: govee API error (code 400): Parameter value out of range" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package govee
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// IntValue reads an integer command value in [min, max] from its
+// decoded-JSON form (see numberValue), so clients that stringify numbers
+// ("50") work the same as ones that don't. Errors are user-facing and name
+// the field, e.g. "brightness must be between 0 and 100, got 150".
+func IntValue(value interface{}, name string, min, max int) (int, error) {
+	n, err := numberValue(value, name)
+	if err != nil {
+		return 0, err
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("%s must be between %d and %d, got %d", name, min, max, n)
+	}
+	return n, nil
+}
+
+// numberValue coerces a decoded-JSON number to an int. Accepts float64 (what
+// encoding/json produces), json.Number (decoders with UseNumber), Go ints,
+// and strings holding a number. Fractions are truncated, as they always
+// have been for brightness and color values.
+func numberValue(value interface{}, name string) (int, error) {
+	var f float64
+	switch v := value.(type) {
+	case float64:
+		f = v
+	case float32:
+		f = float64(v)
+	case int:
+		return v, nil
+	case int64:
+		f = float64(v)
+	case json.Number:
+		return numberValue(string(v), name)
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a number, got %q", name, v)
+		}
+		f = parsed
+	default:
+		return 0, fmt.Errorf("%s must be a number, got %s", name, jsonTypeName(value))
+	}
+
+	if math.IsNaN(f) || math.IsInf(f, 0) || f < math.MinInt32 || f > math.MaxInt32 {
+		return 0, fmt.Errorf("%s is out of range, got %v", name, f)
+	}
+	return int(f), nil
+}
+
+// colorValue reads a "color" command value: an object with numeric (or
+// numeric string) r, g, b fields, each 0-255.
+func colorValue(value interface{}) (ColorValue, error) {
+	colorMap, ok := value.(map[string]interface{})
+	if !ok {
+		return ColorValue{}, fmt.Errorf("Invalid value for 'color' command - expected object with r, g, b")
+	}
+
+	var channels [3]int
+	for i, key := range []string{"r", "g", "b"} {
+		raw, ok := colorMap[key]
+		if !ok {
+			return ColorValue{}, fmt.Errorf("Color object must have r, g, b numeric fields")
+		}
+		n, err := numberValue(raw, "color "+key)
+		if err != nil {
+			return ColorValue{}, err
+		}
+		channels[i] = n
+	}
+
+	color := ColorValue{R: channels[0], G: channels[1], B: channels[2]}
+	if !validColor(color) {
+		return ColorValue{}, fmt.Errorf("RGB values must be between 0 and 255, got R=%d G=%d B=%d", color.R, color.G, color.B)
+	}
+	return color, nil
+}
+
+// jsonTypeName names the JSON type of a decoded value, for error messages.
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
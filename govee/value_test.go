@@ -0,0 +1,95 @@
+package govee
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestIntValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    int
+		wantErr string // Substring of the error; empty for success
+	}{
+		{"float", 50.0, 50, ""},
+		{"fraction truncated", 50.7, 50, ""},
+		{"int", 75, 75, ""},
+		{"json.Number", json.Number("20"), 20, ""},
+		{"int as string", "50", 50, ""},
+		{"float as string", " 42.0 ", 42, ""},
+		{"garbage string", "bright", 0, `brightness must be a number, got "bright"`},
+		{"empty string", "", 0, `brightness must be a number, got ""`},
+		{"boolean", true, 0, "brightness must be a number, got a boolean"},
+		{"null", nil, 0, "brightness must be a number, got null"},
+		{"object", map[string]interface{}{}, 0, "brightness must be a number, got an object"},
+		{"NaN string", "NaN", 0, "brightness is out of range"},
+		{"huge", 1e300, 0, "brightness is out of range"},
+		{"above max", "150", 0, "brightness must be between 0 and 100, got 150"},
+		{"below min", -1.0, 0, "brightness must be between 0 and 100, got -1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IntValue(tt.value, "brightness", 0, 100)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestColorValue(t *testing.T) {
+	color, err := colorValue(map[string]interface{}{"r": "255", "g": 128.0, "b": json.Number("0")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if color != (ColorValue{R: 255, G: 128, B: 0}) {
+		t.Errorf("unexpected color %+v", color)
+	}
+
+	errorTests := []struct {
+		name    string
+		value   interface{}
+		wantErr string
+	}{
+		{"not an object", "red", "expected object with r, g, b"},
+		{"missing channel", map[string]interface{}{"r": 1.0, "g": 2.0}, "must have r, g, b"},
+		{"garbage channel", map[string]interface{}{"r": 1.0, "g": "green", "b": 3.0}, `color g must be a number, got "green"`},
+		{"out of range", map[string]interface{}{"r": "300", "g": 0.0, "b": 0.0}, "RGB values must be between 0 and 255"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := colorValue(tt.value); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestOptimisticStates_RecordsStringNumbers(t *testing.T) {
+	states := NewOptimisticStates("")
+	states.Record(0, "AA:BB", "H6008", "brightness", "40")
+	states.Record(0, "AA:BB", "H6008", "color", map[string]interface{}{"r": "10", "g": "20", "b": "30"})
+
+	state, ok := states.Get(0, "AA:BB")
+	if !ok {
+		t.Fatal("expected an optimistic state")
+	}
+	if state.Brightness == nil || *state.Brightness != 40 {
+		t.Errorf("expected brightness 40, got %v", state.Brightness)
+	}
+	if state.Color == nil || *state.Color != (ColorValue{R: 10, G: 20, B: 30}) {
+		t.Errorf("expected color 10,20,30, got %v", state.Color)
+	}
+}
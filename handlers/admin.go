@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/pantheon/artemis/camera"
+	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/macros"
+	"github.com/pantheon/artemis/webhooks"
+)
+
+// BackupStores are the stores Artemis keeps in files, which a backup covers
+// alongside the database. A nil store (its feature is off) is left out of
+// exports and skipped on import.
+type BackupStores struct {
+	Presets    *govee.PresetStore
+	Macros     *macros.Store
+	Webhooks   *webhooks.Store
+	CameraACLs *camera.ACLStore
+	Optimistic *govee.OptimisticStates
+}
+
+// Backup is the export bundle: the database (db.Backup) plus every
+// file-backed store. A null section, as in bundles from format version 1,
+// leaves that store as it is on import.
+type Backup struct {
+	db.Backup
+	Presets          map[string][]govee.Preset `json:"presets"` // Normalized device ID → presets
+	Macros           []macros.Macro            `json:"macros"`
+	Webhooks         []webhooks.Webhook        `json:"webhooks"`   // Secrets included
+	CameraACLs       []camera.ACLEntry         `json:"cameraAcls"` // Tokens included
+	OptimisticStates []govee.DeviceState       `json:"optimisticStates"`
+}
+
+// ImportSummary reports how many records an import wrote, per section.
+type ImportSummary struct {
+	db.ImportSummary
+	Presets          int `json:"presets"`
+	Macros           int `json:"macros"`
+	Webhooks         int `json:"webhooks"`
+	CameraACLs       int `json:"cameraAcls"`
+	OptimisticStates int `json:"optimisticStates"`
+}
+
+// AdminHandler provides backup endpoints for everything Artemis persists.
+// Both endpoints must be wrapped in middleware.RequireToken when registered.
+type AdminHandler struct {
+	DB     *sql.DB
+	Stores BackupStores
+}
+
+// NewAdminHandler creates a new AdminHandler with the given database
+// connection and file-backed stores.
+func NewAdminHandler(database *sql.DB, stores BackupStores) *AdminHandler {
+	return &AdminHandler{DB: database, Stores: stores}
+}
+
+// HandleExport returns the database and every file-backed store as one
+// versioned bundle.
+// GET /api/admin/export
+// Response (200): Backup — save it and POST it to /api/admin/import to restore
+func (h *AdminHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	dbBackup, err := db.ExportBackup(h.DB)
+	if err != nil {
+		log.Printf("❌ Admin export failed: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "Failed to export data")
+		return
+	}
+
+	backup := Backup{Backup: *dbBackup}
+	if h.Stores.Presets != nil {
+		backup.Presets = h.Stores.Presets.Snapshot()
+	}
+	if h.Stores.Macros != nil {
+		backup.Macros = h.Stores.Macros.List()
+	}
+	if h.Stores.Webhooks != nil {
+		backup.Webhooks = h.Stores.Webhooks.List()
+	}
+	if h.Stores.CameraACLs != nil {
+		backup.CameraACLs = h.Stores.CameraACLs.Snapshot()
+	}
+	if h.Stores.Optimistic != nil {
+		backup.OptimisticStates = h.Stores.Optimistic.Snapshot()
+	}
+
+	log.Printf("🗄️  Exported backup: %d profile(s), %d room(s), %d device(s), presets for %d device(s), %d macro(s), %d webhook(s), %d camera ACL(s), %d optimistic state(s)",
+		len(backup.Profiles), len(backup.Rooms), len(backup.Devices), len(backup.Presets),
+		len(backup.Macros), len(backup.Webhooks), len(backup.CameraACLs), len(backup.OptimisticStates))
+
+	// Suggest a filename so browsers save the bundle rather than display it
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="artemis-backup-%s.json"`, backup.ExportedAt.Format("20060102-150405")))
+	writeJSON(w, r, http.StatusOK, backup)
+}
+
+// HandleImport restores a bundle produced by HandleExport.
+// POST /api/admin/import?mode=merge|replace
+// Request body: Backup
+// Response (200): ImportSummary
+//
+// mode=merge (the default) upserts records by ID (or name, for presets,
+// macros, and camera ACLs) and keeps everything else; mode=replace deletes
+// all existing data first. The whole bundle is validated up front, so a
+// rejected import changes nothing. The database is written in one
+// transaction, then each store in turn.
+func (h *AdminHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	mode := db.ImportMode(r.URL.Query().Get("mode"))
+	if mode == "" {
+		mode = db.ImportMerge
+	}
+	if mode != db.ImportMerge && mode != db.ImportReplace {
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid mode %q — must be 'merge' or 'replace'", mode))
+		return
+	}
+
+	var backup Backup
+	if err := decodeJSONBody(r, &backup); err != nil {
+		log.Printf("❌ Admin import: invalid request body: %v", err)
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := validateBackup(&backup); err != nil {
+		log.Printf("❌ Admin import: invalid backup: %v", err)
+		writeError(w, r, http.StatusBadRequest, "Invalid backup: "+err.Error())
+		return
+	}
+
+	dbSummary, err := db.ImportBackup(h.DB, &backup.Backup, mode)
+	if err != nil {
+		log.Printf("❌ Admin import failed: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "Failed to import backup")
+		return
+	}
+	summary := ImportSummary{ImportSummary: *dbSummary}
+
+	if err := h.restoreStores(&backup, mode == db.ImportReplace, &summary); err != nil {
+		log.Printf("❌ Admin import failed after restoring the database: %v", err)
+		writeError(w, r, http.StatusInternalServerError, "Failed to import backup: "+err.Error())
+		return
+	}
+
+	log.Printf("🗄️  Imported backup (%s): %d profile(s), %d room(s), %d device(s), %d preset(s), %d macro(s), %d webhook(s), %d camera ACL(s), %d optimistic state(s)",
+		summary.Mode, summary.Profiles, summary.Rooms, summary.Devices, summary.Presets,
+		summary.Macros, summary.Webhooks, summary.CameraACLs, summary.OptimisticStates)
+	writeJSON(w, r, http.StatusOK, summary)
+}
+
+// validateBackup migrates a bundle to the current format and checks the
+// database part and every store section it carries.
+func validateBackup(backup *Backup) error {
+	if err := db.ValidateBackup(&backup.Backup); err != nil {
+		return err
+	}
+	if err := govee.ValidatePresets(backup.Presets); err != nil {
+		return err
+	}
+	if err := macros.ValidateAll(backup.Macros); err != nil {
+		return err
+	}
+	if err := webhooks.ValidateAll(backup.Webhooks); err != nil {
+		return err
+	}
+	return camera.ValidateACLs(backup.CameraACLs)
+}
+
+// restoreStores writes the bundle's store sections, counting what it wrote
+// into summary. Null sections and stores that are off are skipped.
+func (h *AdminHandler) restoreStores(backup *Backup, replace bool, summary *ImportSummary) error {
+	if backup.Presets != nil && h.Stores.Presets != nil {
+		if err := h.Stores.Presets.Restore(backup.Presets, replace); err != nil {
+			return fmt.Errorf("presets: %w", err)
+		}
+		for _, presets := range backup.Presets {
+			summary.Presets += len(presets)
+		}
+	}
+	if backup.Macros != nil && h.Stores.Macros != nil {
+		if err := h.Stores.Macros.Restore(backup.Macros, replace); err != nil {
+			return fmt.Errorf("macros: %w", err)
+		}
+		summary.Macros = len(backup.Macros)
+	}
+	if backup.Webhooks != nil && h.Stores.Webhooks != nil {
+		if err := h.Stores.Webhooks.Restore(backup.Webhooks, replace); err != nil {
+			return fmt.Errorf("webhooks: %w", err)
+		}
+		summary.Webhooks = len(backup.Webhooks)
+	}
+	if backup.CameraACLs != nil && h.Stores.CameraACLs != nil {
+		if err := h.Stores.CameraACLs.Restore(backup.CameraACLs, replace); err != nil {
+			return fmt.Errorf("camera ACLs: %w", err)
+		}
+		summary.CameraACLs = len(backup.CameraACLs)
+	}
+	if backup.OptimisticStates != nil && h.Stores.Optimistic != nil {
+		h.Stores.Optimistic.Restore(backup.OptimisticStates, replace)
+		summary.OptimisticStates = len(backup.OptimisticStates)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pantheon/artemis/camera"
+	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/macros"
+	"github.com/pantheon/artemis/webhooks"
+)
+
+// setupTestAdminHandler creates an AdminHandler backed by an in-memory SQLite
+// DB and in-memory stores.
+func setupTestAdminHandler(t *testing.T) *AdminHandler {
+	t.Helper()
+	database, err := db.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init test DB: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	presets, _ := govee.NewPresetStore("")
+	macroStore, _ := macros.NewStore("")
+	webhookStore, _ := webhooks.NewStore("")
+	acls, _ := camera.NewACLStore("")
+	return NewAdminHandler(database, BackupStores{
+		Presets:    presets,
+		Macros:     macroStore,
+		Webhooks:   webhookStore,
+		CameraACLs: acls,
+		Optimistic: govee.NewOptimisticStates(""),
+	})
+}
+
+// importBackup posts body to /api/admin/import with the given mode.
+func importBackup(h *AdminHandler, mode string, body []byte) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.HandleImport(w, httptest.NewRequest(http.MethodPost, "/api/admin/import?mode="+mode, bytes.NewReader(body)))
+	return w
+}
+
+func TestAdminExportImport_RoundTrip(t *testing.T) {
+	source := setupTestAdminHandler(t)
+	profile, _ := db.CreateProfile(source.DB, "Shakur")
+	db.CreateRoom(source.DB, profile.ID, "Office", "desktopcomputer")
+
+	w := httptest.NewRecorder()
+	source.HandleExport(w, httptest.NewRequest(http.MethodGet, "/api/admin/export", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), "artemis-backup-") {
+		t.Errorf("expected attachment filename, got %q", w.Header().Get("Content-Disposition"))
+	}
+	exported := w.Body.Bytes()
+
+	target := setupTestAdminHandler(t)
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/import?mode=replace", bytes.NewReader(exported))
+	w = httptest.NewRecorder()
+	target.HandleImport(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var summary db.ImportSummary
+	json.NewDecoder(w.Body).Decode(&summary)
+	if summary.Mode != db.ImportReplace || summary.Profiles != 1 || summary.Rooms != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if _, err := db.GetProfile(target.DB, profile.ID); err != nil {
+		t.Errorf("expected profile to be restored: %v", err)
+	}
+}
+
+func TestAdminExportImport_RoundTripsStores(t *testing.T) {
+	source := setupTestAdminHandler(t)
+	brightness := 40
+	source.Stores.Presets.Create("AA:BB", govee.Preset{Name: "Reading", State: govee.TargetState{Brightness: &brightness}})
+	source.Stores.Macros.Create(macros.Macro{Name: "Movie Night", Steps: []macros.Step{
+		{Target: macros.Target{Type: "govee", ID: "AA:BB"}, Action: "turn", Value: false},
+	}})
+	hook, _ := source.Stores.Webhooks.Add(webhooks.Webhook{URL: "https://example.com/hook", Secret: "s3cret"})
+	source.Stores.CameraACLs.Put("kids-ipad", camera.ACLEntry{Token: "0123456789abcdef", Cameras: []string{"front-door"}})
+	source.Stores.Optimistic.Record(0, "AA:BB", "H6008", "brightness", 40)
+
+	w := httptest.NewRecorder()
+	source.HandleExport(w, httptest.NewRequest(http.MethodGet, "/api/admin/export", nil))
+	exported := w.Body.Bytes()
+
+	target := setupTestAdminHandler(t)
+	target.Stores.Macros.Create(macros.Macro{Name: "Old", Steps: []macros.Step{
+		{Target: macros.Target{Type: "firetv", ID: "10.0.0.5"}, Action: "home"},
+	}})
+	w = importBackup(target, "replace", exported)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var summary ImportSummary
+	json.NewDecoder(w.Body).Decode(&summary)
+	if summary.Presets != 1 || summary.Macros != 1 || summary.Webhooks != 1 || summary.CameraACLs != 1 || summary.OptimisticStates != 1 {
+		t.Errorf("expected one record per store, got %+v", summary)
+	}
+	if _, err := target.Stores.Presets.Get("aa-bb", "reading"); err != nil {
+		t.Errorf("expected the preset to be restored: %v", err)
+	}
+	if list := target.Stores.Macros.List(); len(list) != 1 || list[0].Name != "Movie Night" {
+		t.Errorf("expected replace to leave only the restored macro, got %+v", list)
+	}
+	if hooks := target.Stores.Webhooks.List(); len(hooks) != 1 || hooks[0].ID != hook.ID || hooks[0].Secret != "s3cret" {
+		t.Errorf("expected the webhook with its ID and secret, got %+v", hooks)
+	}
+	if entry, ok := target.Stores.CameraACLs.Lookup("0123456789abcdef"); !ok || entry.Name != "kids-ipad" {
+		t.Errorf("expected the camera ACL token to work, got %+v", entry)
+	}
+	if state, ok := target.Stores.Optimistic.Get(0, "AA:BB"); !ok || state.Brightness == nil || *state.Brightness != 40 {
+		t.Errorf("expected the optimistic state to be restored, got %+v", state)
+	}
+}
+
+func TestAdminImport_VersionOneLeavesStoresUntouched(t *testing.T) {
+	h := setupTestAdminHandler(t)
+	h.Stores.Macros.Create(macros.Macro{Name: "Keep", Steps: []macros.Step{
+		{Target: macros.Target{Type: "firetv", ID: "10.0.0.5"}, Action: "home"},
+	}})
+
+	body := `{"version": 1, "profiles": [{"id": "p1", "name": "Shakur"}]}`
+	if w := importBackup(h, "replace", []byte(body)); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if list := h.Stores.Macros.List(); len(list) != 1 {
+		t.Errorf("expected a version 1 bundle not to touch macros, got %+v", list)
+	}
+}
+
+func TestAdminImport_InvalidStoreChangesNothing(t *testing.T) {
+	h := setupTestAdminHandler(t)
+
+	body := `{"version": 2, "profiles": [{"id": "p1", "name": "Shakur"}],
+		"cameraAcls": [{"name": "guest", "token": "short"}]}`
+	if w := importBackup(h, "replace", []byte(body)); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := db.GetProfile(h.DB, "p1"); err == nil {
+		t.Error("expected the database to be untouched by a rejected import")
+	}
+}
+
+func TestAdminImport_InvalidMode(t *testing.T) {
+	h := setupTestAdminHandler(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/import?mode=overwrite", strings.NewReader(`{"version": 1}`))
+	w := httptest.NewRecorder()
+	h.HandleImport(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestAdminImport_RejectsInvalidBackup(t *testing.T) {
+	h := setupTestAdminHandler(t)
+
+	body := `{"version": 1, "rooms": [{"id": "r1", "profileId": "missing", "name": "Office"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/import", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.HandleImport(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+	var resp map[string]string
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !strings.Contains(resp["error"], "not in the backup") {
+		t.Errorf("expected validation message, got %q", resp["error"])
+	}
+}
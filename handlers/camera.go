@@ -1,12 +1,17 @@
 package handlers
 
 import (
-	"encoding/json"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"sync"
 
 	"github.com/pantheon/artemis/camera"
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/middleware"
 )
 
 // HandleGetCameras returns all cameras from the Wyze Bridge.
@@ -14,7 +19,11 @@ import (
 // Queries the Docker Wyze Bridge REST API for available cameras and
 // returns them with name, model, online/offline status, and stream URLs.
 // The iOS app uses this to populate the camera list view.
-func HandleGetCameras(cameraClient *camera.Client) http.HandlerFunc {
+//
+// With Accept: application/x-ndjson the list is streamed instead, one
+// CameraListLine per line (see streamCameraList). The list is always in
+// its CamerasResponse, so only listOpts.StrictFields applies.
+func HandleGetCameras(cameraClient *camera.Client, listOpts ListOptions) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Only accept GET requests.
 		if r.Method != http.MethodGet {
@@ -22,15 +31,24 @@ func HandleGetCameras(cameraClient *camera.Client) http.HandlerFunc {
 			return
 		}
 
-		log.Printf("📷 Camera list request from client: %s", r.RemoteAddr)
+		log.Printf("📷 Camera list request from client: %s", middleware.ClientIP(r))
+
+		// Clients asking for NDJSON get one camera per line as it's ready.
+		if wantsNDJSON(r) {
+			streamCameraList(w, r, cameraClient)
+			return
+		}
 
 		// Query the Wyze Bridge for all cameras.
-		cameras, err := cameraClient.GetCameras()
+		cameras, err := cameraClient.GetCameras(r.Context())
 		if err != nil {
 			log.Printf("❌ Failed to fetch cameras from Wyze Bridge: %v", err)
-			sendCameraError(w, http.StatusInternalServerError, "Failed to fetch cameras: "+err.Error())
+			status, message := upstreamStatus(err, http.StatusInternalServerError)
+			sendCameraError(w, r, status, "Failed to fetch cameras: "+message)
 			return
 		}
+		// A token limited by a camera ACL only sees its own cameras.
+		cameras = camera.FilterAllowed(r.Context(), cameras)
 
 		// Handle nil cameras slice (no cameras found but no error).
 		if cameras == nil {
@@ -46,19 +64,33 @@ func HandleGetCameras(cameraClient *camera.Client) http.HandlerFunc {
 			Message: formatCameraCountMessage(len(cameras)),
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.Printf("❌ Error encoding cameras response: %v", err)
+		// ?fields= trims each camera; the outer cameras field shadows the
+		// embedded one
+		selected, err := selectFields(r, cameras, listOpts.StrictFields)
+		if err != nil {
+			sendCameraError(w, r, http.StatusBadRequest, err.Error())
+			return
 		}
+		writeJSON(w, r, http.StatusOK, struct {
+			camera.CamerasResponse
+			Cameras interface{} `json:"cameras"`
+		}{response, selected})
 	}
 }
 
 // HandleGetCameraStream returns stream URLs for a specific camera.
 // GET /api/cameras/stream?name=<camera-name-uri>
+// GET /api/cameras/stream?displayName=<camera display name>
 // The name parameter is the URL-safe camera name (e.g., "front-door").
+// Alternatively, displayName (e.g., "Front Door") is resolved to its name-uri
+// via the camera list. If several cameras share that display name, responds
+// 409 Conflict with the candidate cameras so the app can ask the user.
 // Returns HLS, RTSP, and WebRTC stream URLs along with camera status.
 //
+// Optional quality=hd|sd (default hd) picks which stream streamUrl points
+// at; sd is the bridge's lower-resolution substream, for clients on
+// cellular. streams always carries both (SD under streams.sd).
+//
 // The iOS app calls this when the user taps a camera in the list to view
 // the live stream. HLS is the primary protocol used by iOS (AVPlayer).
 func HandleGetCameraStream(cameraClient *camera.Client) http.HandlerFunc {
@@ -72,18 +104,55 @@ func HandleGetCameraStream(cameraClient *camera.Client) http.HandlerFunc {
 		// Parse the camera name from query parameters.
 		// Matches the existing pattern used by HandleGetDeviceState (govee.go).
 		nameURI := r.URL.Query().Get("name")
-		if nameURI == "" {
-			sendCameraError(w, http.StatusBadRequest, "Missing required 'name' query parameter")
+		displayName := r.URL.Query().Get("displayName")
+		if nameURI == "" && displayName == "" {
+			sendCameraError(w, r, http.StatusBadRequest, "Missing required 'name' or 'displayName' query parameter")
+			return
+		}
+
+		quality := r.URL.Query().Get("quality")
+		if quality == "" {
+			quality = camera.QualityHD
+		}
+		if quality != camera.QualityHD && quality != camera.QualitySD {
+			sendCameraError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid quality '%s' — must be 'hd' or 'sd'", quality))
 			return
 		}
 
-		log.Printf("📷 Stream request for camera '%s' from client: %s", nameURI, r.RemoteAddr)
+		// Resolve a display name to its name-uri when no slug was given.
+		if nameURI == "" {
+			resolved, err := cameraClient.ResolveDisplayName(r.Context(), displayName)
+			if err != nil {
+				var ambiguous *camera.AmbiguousNameError
+				if errors.As(err, &ambiguous) {
+					log.Printf("⚠️  Display name '%s' is ambiguous (%d matches)", displayName, len(ambiguous.Candidates))
+					sendCameraCandidates(w, r, ambiguous)
+					return
+				}
+				log.Printf("❌ Failed to resolve camera display name '%s': %v", displayName, err)
+				sendCameraError(w, r, http.StatusNotFound, "Camera not found: "+err.Error())
+				return
+			}
+			nameURI = resolved
+		}
+
+		log.Printf("📷 Stream request for camera '%s' from client: %s", nameURI, middleware.ClientIP(r))
 
 		// Query the bridge for this specific camera.
-		cam, err := cameraClient.GetCamera(nameURI)
+		cam, err := cameraClient.GetCamera(r.Context(), nameURI)
 		if err != nil {
+			// The list fallback can match several cameras by name.
+			var ambiguous *camera.AmbiguousNameError
+			if errors.As(err, &ambiguous) {
+				sendCameraCandidates(w, r, ambiguous)
+				return
+			}
 			log.Printf("❌ Failed to get camera '%s': %v", nameURI, err)
-			sendCameraError(w, http.StatusNotFound, "Camera not found: "+err.Error())
+			sendCameraError(w, r, http.StatusNotFound, "Camera not found: "+err.Error())
+			return
+		}
+		if !camera.Allowed(r.Context(), cam.NameURI) {
+			sendCameraForbidden(w, r, cam)
 			return
 		}
 
@@ -94,7 +163,12 @@ func HandleGetCameraStream(cameraClient *camera.Client) http.HandlerFunc {
 			log.Printf("⚠️  Camera '%s' is offline", nameURI)
 		}
 
-		log.Printf("📷 Returning stream URLs for camera '%s' (status: %s)", nameURI, cam.Status)
+		log.Printf("📷 Returning %s stream URLs for camera '%s' (status: %s)", quality, nameURI, cam.Status)
+
+		streamURL := cam.StreamURL
+		if quality == camera.QualitySD && cam.Streams.SD != nil {
+			streamURL = cam.Streams.SD.HLS
+		}
 
 		// Build the response with all stream URLs.
 		response := camera.StreamResponse{
@@ -102,30 +176,460 @@ func HandleGetCameraStream(cameraClient *camera.Client) http.HandlerFunc {
 			Name:      cam.Name,
 			NameURI:   cam.NameURI,
 			Status:    cam.Status,
-			StreamURL: cam.StreamURL,
+			Quality:   quality,
+			StreamURL: streamURL,
 			Streams:   cam.Streams,
 			Message:   statusMsg,
 		}
 
-		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, r, http.StatusOK, response)
+	}
+}
+
+// HandleGetDefaultCamera returns stream URLs for the home screen's
+// quick-view camera.
+// GET /api/cameras/default[?quality=hd|sd]
+// Uses defaultCamera (DEFAULT_CAMERA, a name-uri) when it's set and online;
+// otherwise falls back to the first online camera, by name-uri. source in
+// the response says which happened. 404s if no camera is online.
+func HandleGetDefaultCamera(cameraClient *camera.Client, defaultCamera string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept GET requests.
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		quality := r.URL.Query().Get("quality")
+		if quality == "" {
+			quality = camera.QualityHD
+		}
+		if quality != camera.QualityHD && quality != camera.QualitySD {
+			sendCameraError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid quality '%s' — must be 'hd' or 'sd'", quality))
+			return
+		}
+
+		cameras, err := cameraClient.GetCameras(r.Context())
+		if err != nil {
+			log.Printf("❌ Failed to get cameras: %v", err)
+			status, message := upstreamStatus(err, http.StatusInternalServerError)
+			sendCameraError(w, r, status, "Failed to fetch cameras: "+message)
+			return
+		}
+
+		cam, source := pickDefaultCamera(camera.FilterAllowed(r.Context(), cameras), defaultCamera)
+		if cam == nil {
+			sendCameraError(w, r, http.StatusNotFound, "No cameras are online")
+			return
+		}
+
+		message := "Showing the default camera"
+		if source == camera.DefaultSourceFallback {
+			message = "Default camera unavailable — showing " + cam.Name
+			if defaultCamera == "" {
+				message = "No default camera set — showing " + cam.Name
+			}
+			log.Printf("📷 Default camera '%s' unavailable, falling back to '%s'", defaultCamera, cam.NameURI)
+		}
+
+		streamURL := cam.StreamURL
+		if quality == camera.QualitySD && cam.Streams.SD != nil {
+			streamURL = cam.Streams.SD.HLS
+		}
+
+		writeJSON(w, r, http.StatusOK, camera.DefaultCameraResponse{
+			StreamResponse: camera.StreamResponse{
+				Success:   true,
+				Name:      cam.Name,
+				NameURI:   cam.NameURI,
+				Status:    cam.Status,
+				Quality:   quality,
+				StreamURL: streamURL,
+				Streams:   cam.Streams,
+				Message:   message,
+			},
+			Source: source,
+		})
+	}
+}
+
+// pickDefaultCamera returns the camera named defaultCamera if it's online,
+// else the online camera with the lowest name-uri (the bridge list has no
+// stable order), along with which of the two it is. Returns nil if no
+// camera is online.
+func pickDefaultCamera(cameras []camera.Camera, defaultCamera string) (*camera.Camera, string) {
+	var fallback *camera.Camera
+	for i := range cameras {
+		cam := &cameras[i]
+		if cam.Status != "online" {
+			continue
+		}
+		if defaultCamera != "" && cam.NameURI == defaultCamera {
+			return cam, camera.DefaultSourceConfigured
+		}
+		if fallback == nil || cam.NameURI < fallback.NameURI {
+			fallback = cam
+		}
+	}
+	if fallback == nil {
+		return nil, ""
+	}
+	return fallback, camera.DefaultSourceFallback
+}
+
+// HandleCameraPrivacy turns camera privacy mode on or off.
+// POST /api/cameras/privacy
+// Request body: {"enabled": true}  → disable streaming on every camera
+//               {"enabled": false} → re-enable streaming on every camera
+//
+// Like an "all lights off" button for cameras. The bridge is told to disable
+// (or enable) each camera concurrently; one camera failing doesn't stop the
+// others. The response lists the outcome per camera so the app can show
+// which ones still need attention.
+func HandleCameraPrivacy(cameraClient *camera.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests.
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req camera.PrivacyRequest
+		if err := decodeJSONBody(r, &req); err != nil {
+			log.Printf("❌ Error decoding camera privacy request: %v", err)
+			sendCameraError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		log.Printf("📷 Privacy mode request (enabled=%v) from client: %s", req.Enabled, middleware.ClientIP(r))
+
+		cameras, err := cameraClient.GetCameras(r.Context())
+		if err != nil {
+			log.Printf("❌ Failed to fetch cameras for privacy mode: %v", err)
+			status, message := upstreamStatus(err, http.StatusInternalServerError)
+			sendCameraError(w, r, status, "Failed to fetch cameras: "+message)
+			return
+		}
+		cameras = camera.FilterAllowed(r.Context(), cameras)
+
+		// Privacy on means streams off, and vice versa.
+		streamEnabled := !req.Enabled
+
+		// Apply to every camera concurrently; each goroutine writes its own slot.
+		results := make([]camera.CameraActionResult, len(cameras))
+		var wg sync.WaitGroup
+		for i, cam := range cameras {
+			wg.Add(1)
+			go func(i int, cam camera.Camera) {
+				defer wg.Done()
+				result := camera.CameraActionResult{Name: cam.Name, NameURI: cam.NameURI, Success: true}
+				if err := cameraClient.SetCameraEnabled(r.Context(), cam.NameURI, streamEnabled); err != nil {
+					log.Printf("❌ Privacy mode: failed to update camera '%s': %v", cam.NameURI, err)
+					result.Success = false
+					result.Error = err.Error()
+				}
+				results[i] = result
+			}(i, cam)
+		}
+		wg.Wait()
+
+		response := camera.PrivacyResponse{
+			PrivacyEnabled: req.Enabled,
+			Results:        results,
+		}
+		for _, result := range results {
+			if result.Success {
+				response.Affected++
+			} else {
+				response.Failed++
+			}
+		}
+		response.Success = response.Failed == 0
+
+		state := "disabled"
+		if req.Enabled {
+			state = "enabled"
+		}
+		response.Message = fmt.Sprintf("Privacy mode %s: %d camera(s) updated, %d failed", state, response.Affected, response.Failed)
+		log.Printf("📷 %s", response.Message)
+
+		writeJSON(w, r, http.StatusOK, response)
+	}
+}
+
+// HandleCameraRestart restarts a camera's stream on the bridge and checks
+// that it comes back.
+// POST /api/cameras/restart?name=front-door
+//
+// For when the app shows a frozen frame: the camera reconnected but the
+// bridge's stream didn't recover. Waits (up to ~20s) for the stream to pass
+// the readiness check again. Answers 501 if the bridge has no restart command.
+func HandleCameraRestart(cameraClient *camera.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests.
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			sendCameraError(w, r, http.StatusBadRequest, "Missing required 'name' query parameter")
+			return
+		}
+
+		// Resolve the camera first — the bridge 404s unknown cameras and
+		// unknown commands alike, so this tells the two apart.
+		cam, err := cameraClient.GetCamera(r.Context(), name)
+		if err != nil {
+			var ambiguous *camera.AmbiguousNameError
+			if errors.As(err, &ambiguous) {
+				sendCameraCandidates(w, r, ambiguous)
+				return
+			}
+			log.Printf("❌ Failed to get camera '%s' for restart: %v", name, err)
+			sendCameraError(w, r, http.StatusNotFound, "Camera not found: "+err.Error())
+			return
+		}
+		if !camera.Allowed(r.Context(), cam.NameURI) {
+			sendCameraForbidden(w, r, cam)
+			return
+		}
+
+		result, err := cameraClient.RecoverStream(r.Context(), *cam)
+		if errors.Is(err, camera.ErrRestartUnsupported) {
+			sendCameraError(w, r, http.StatusNotImplemented, "This Wyze Bridge version can't restart streams — restart the camera from the bridge web UI instead")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to restart stream for '%s': %v", cam.NameURI, err)
+			status, message := upstreamStatus(err, http.StatusBadGateway)
+			sendCameraError(w, r, status, "Failed to restart stream: "+message)
+			return
+		}
+
+		response := camera.RestartResponse{
+			Success:       result.Ready,
+			RestartResult: result,
+			Message:       fmt.Sprintf("Stream for '%s' restarted and ready", cam.Name),
+		}
+		if !result.Ready {
+			response.Message = fmt.Sprintf("Stream for '%s' restarted but isn't ready yet — try again shortly", cam.Name)
+		}
+
+		writeJSON(w, r, http.StatusOK, response)
+	}
+}
+
+// HandleCameraSnapshot returns a still image from a camera.
+// GET /api/cameras/snapshot?name=front-door[&format=json]
+//
+// By default the image bytes are returned as-is (Content-Type image/jpeg).
+// format=json wraps them in a camera.SnapshotResponse with the image in
+// base64, for clients (e.g., React Native bridges) that can't easily
+// handle binary responses. Images over camera.MaxSnapshotBytes are refused.
+func HandleCameraSnapshot(cameraClient *camera.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept GET requests.
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			sendCameraError(w, r, http.StatusBadRequest, "Missing required 'name' query parameter")
+			return
+		}
+		format := r.URL.Query().Get("format")
+		if format != "" && format != "json" && format != "raw" {
+			sendCameraError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid format %q — must be 'raw' or 'json'", format))
+			return
+		}
+
+		cam, err := cameraClient.GetCamera(r.Context(), name)
+		if err != nil {
+			var ambiguous *camera.AmbiguousNameError
+			if errors.As(err, &ambiguous) {
+				sendCameraCandidates(w, r, ambiguous)
+				return
+			}
+			log.Printf("❌ Failed to get camera '%s' for snapshot: %v", name, err)
+			sendCameraError(w, r, http.StatusNotFound, "Camera not found: "+err.Error())
+			return
+		}
+		if !camera.Allowed(r.Context(), cam.NameURI) {
+			sendCameraForbidden(w, r, cam)
+			return
+		}
+
+		snapshot, err := cameraClient.GetSnapshot(r.Context(), cam.NameURI)
+		if errors.Is(err, camera.ErrSnapshotUnavailable) {
+			log.Printf("⚠️  Snapshot unavailable for '%s': %v", cam.NameURI, err)
+			sendCameraError(w, r, http.StatusServiceUnavailable,
+				fmt.Sprintf("No snapshot available for '%s' right now (camera is %s) — try again once its stream is running", cam.Name, cam.Status))
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to get snapshot for '%s': %v", cam.NameURI, err)
+			status, message := upstreamStatus(err, http.StatusBadGateway)
+			sendCameraError(w, r, status, "Failed to get snapshot: "+message)
+			return
+		}
+
+		if format == "json" {
+			writeJSON(w, r, http.StatusOK, camera.SnapshotResponse{
+				Name:        cam.Name,
+				ContentType: snapshot.ContentType,
+				DataBase64:  base64.StdEncoding.EncodeToString(snapshot.Data),
+				CapturedAt:  snapshot.CapturedAt,
+			})
+			return
+		}
+
+		// Snapshots are live images — never let a proxy or the app cache one
+		w.Header().Set("Content-Type", snapshot.ContentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(snapshot.Data)))
+		w.Header().Set("Cache-Control", "no-store")
 		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.Printf("❌ Error encoding stream response: %v", err)
+		w.Write(snapshot.Data)
+	}
+}
+
+// HandleCameraOverview returns one JPEG with a snapshot of every online
+// camera, laid out in a grid, for a multi-camera overview that refreshes
+// without running an HLS player per camera.
+// GET /api/cameras/overview.jpg[?cols=N][&max=N]
+// cols is the number of tiles per row (default: a roughly square grid);
+// max caps the number of cameras (default and limit 16). Cameras whose
+// snapshot fails are drawn as a crossed-out placeholder tile.
+func HandleCameraOverview(cameraClient *camera.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept GET requests.
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		cols, err := overviewParam(r, "cols", 0)
+		if err != nil {
+			sendCameraError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		maxCameras, err := overviewParam(r, "max", camera.OverviewMaxCameras)
+		if err != nil {
+			sendCameraError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		maxCameras = min(maxCameras, camera.OverviewMaxCameras)
+
+		log.Printf("📷 Overview request from client: %s", middleware.ClientIP(r))
+
+		overview, err := cameraClient.GetOverview(r.Context(), cols, maxCameras)
+		if errors.Is(err, camera.ErrNoOnlineCameras) {
+			sendCameraError(w, r, http.StatusNotFound, "No cameras are online")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to build camera overview: %v", err)
+			status, message := upstreamStatus(err, http.StatusBadGateway)
+			sendCameraError(w, r, status, "Failed to build overview: "+message)
+			return
+		}
+
+		// Short-lived so a polling overview doesn't refetch every snapshot
+		// on each render, but stays close to live
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Content-Length", strconv.Itoa(len(overview)))
+		w.Header().Set("Cache-Control", "private, max-age=5")
+		w.WriteHeader(http.StatusOK)
+		w.Write(overview)
+	}
+}
+
+// overviewParam parses an optional positive integer query parameter.
+func overviewParam(r *http.Request, name string, fallback int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("Invalid %s %q — must be a positive integer", name, raw)
+	}
+	return n, nil
+}
+
+// HandleGetBridgeStatus reports the Wyze Bridge's version, camera counts,
+// and which optional features (WebRTC, recording, motion events) are on,
+// so the app can hide controls the bridge can't back.
+// GET /api/cameras/bridge-status
+// Older bridges without a status endpoint still answer 200, with
+// reported=false and the features those versions had.
+func HandleGetBridgeStatus(cameraClient *camera.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept GET requests.
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		status, err := cameraClient.GetBridgeStatus(r.Context())
+		if err != nil {
+			log.Printf("❌ Failed to fetch Wyze Bridge status: %v", err)
+			status, message := upstreamStatus(err, http.StatusBadGateway)
+			sendCameraError(w, r, status, "Failed to fetch bridge status: "+message)
+			return
 		}
+
+		message := fmt.Sprintf("%d of %d camera(s) online", status.OnlineCameras, status.TotalCameras)
+		if !status.Reported {
+			message += " (this bridge doesn't report its features; defaults assumed)"
+		}
+		writeJSON(w, r, http.StatusOK, camera.BridgeStatusResponse{
+			Success:      true,
+			BridgeStatus: *status,
+			Message:      message,
+		})
 	}
 }
 
 // sendCameraError sends a JSON error response for camera endpoints.
-func sendCameraError(w http.ResponseWriter, statusCode int, message string) {
+func sendCameraError(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
 	response := camera.CamerasResponse{
 		Success: false,
 		Cameras: []camera.Camera{},
 		Message: message,
+		Code:    errcode.ForStatus(statusCode),
+	}
+
+	writeJSON(w, r, statusCode, response)
+}
+
+// sendCameraForbidden sends a 403 for a camera the request's token isn't
+// allowed to see (camera ACLs).
+func sendCameraForbidden(w http.ResponseWriter, r *http.Request, cam *camera.Camera) {
+	log.Printf("🛑 Camera '%s' isn't in the caller's camera ACL - Client: %s", cam.NameURI, middleware.ClientIP(r))
+	sendCameraError(w, r, http.StatusForbidden, fmt.Sprintf("This token doesn't have access to camera '%s'", cam.NameURI))
+}
+
+// sendCameraCandidates sends a 409 Conflict listing every camera that matched
+// an ambiguous display name, using the same shape as the camera list response.
+// Cameras the request's token can't see aren't listed.
+func sendCameraCandidates(w http.ResponseWriter, r *http.Request, ambiguous *camera.AmbiguousNameError) {
+	candidates := camera.FilterAllowed(r.Context(), ambiguous.Candidates)
+	if candidates == nil {
+		candidates = []camera.Camera{}
+	}
+	response := camera.CamerasResponse{
+		Success: false,
+		Cameras: candidates,
+		Message: fmt.Sprintf("Display name '%s' matches %d cameras — retry with one of their 'nameUri' values as 'name'",
+			ambiguous.DisplayName, len(ambiguous.Candidates)),
+		Code: errcode.Conflict,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, r, http.StatusConflict, response)
 }
 
 // formatCameraCountMessage returns a human-readable message for camera count.
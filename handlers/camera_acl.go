@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/pantheon/artemis/camera"
+)
+
+// CameraACLRequest is the body of PUT /api/cameras/acls/{name}.
+type CameraACLRequest struct {
+	Token   string   `json:"token"`   // Bearer token; optional when replacing an entry (keeps the current one)
+	Cameras []string `json:"cameras"` // name-URIs the token may see; empty means none
+}
+
+// HandleCameraACLs lists the camera ACLs.
+// GET /api/cameras/acls — tokens are never returned
+func HandleCameraACLs(store *camera.ACLStore, listOpts ListOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept GET requests
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		entries := store.List()
+		writeList(w, r, listOpts, entries, fmt.Sprintf("Found %d camera ACL(s)", len(entries)))
+	}
+}
+
+// HandleCameraACL creates, replaces, or deletes one camera ACL.
+// PUT    /api/cameras/acls/{name} — CameraACLRequest body; 201 if created, else 200
+// DELETE /api/cameras/acls/{name} — returns 204; the token stops working at once
+//
+// A token with an ACL sees only the listed cameras: other cameras are left
+// out of lists and answer 403 on the stream, snapshot, restart and clip
+// endpoints. Camera names aren't checked against the bridge, so an ACL can
+// be set up before a camera is added.
+func HandleCameraACL(store *camera.ACLStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+
+		switch r.Method {
+		case http.MethodPut:
+			var req CameraACLRequest
+			if err := decodeJSONBody(r, &req); err != nil {
+				writeError(w, r, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			entry, created, err := store.Put(name, camera.ACLEntry{Token: req.Token, Cameras: req.Cameras})
+			if err != nil {
+				sendCameraACLError(w, r, name, err)
+				return
+			}
+
+			status := http.StatusOK
+			if created {
+				status = http.StatusCreated
+			}
+			log.Printf("🔐 Camera ACL '%s' set: %d camera(s) %v", entry.Name, len(entry.Cameras), entry.Cameras)
+			writeJSON(w, r, status, entry)
+
+		case http.MethodDelete:
+			if err := store.Delete(name); err != nil {
+				sendCameraACLError(w, r, name, err)
+				return
+			}
+
+			log.Printf("🔐 Deleted camera ACL '%s'", name)
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// sendCameraACLError answers a failed camera ACL store operation: 404 for a
+// missing entry, 409 for a token another entry uses, 400 for an entry that
+// doesn't validate, and 500 if it couldn't be saved.
+func sendCameraACLError(w http.ResponseWriter, r *http.Request, name string, err error) {
+	switch {
+	case errors.Is(err, camera.ErrACLNotFound):
+		writeError(w, r, http.StatusNotFound, fmt.Sprintf("Camera ACL not found: %s", name))
+	case errors.Is(err, camera.ErrACLTokenTaken):
+		writeError(w, r, http.StatusConflict, "Another camera ACL already uses that token")
+	case errors.Is(err, camera.ErrACLInvalid):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("❌ Failed to save camera ACL '%s': %v", name, err)
+		writeError(w, r, http.StatusInternalServerError, "Failed to save camera ACL")
+	}
+}
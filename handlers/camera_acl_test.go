@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pantheon/artemis/camera"
+)
+
+func TestCameraACL_Management(t *testing.T) {
+	store, _ := camera.NewACLStore("")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/cameras/acls", HandleCameraACLs(store, ListOptions{}))
+	mux.HandleFunc("/api/cameras/acls/{name}", HandleCameraACL(store))
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		return w
+	}
+
+	w := serve(http.MethodPut, "/api/cameras/acls/guest", `{"token": "guest-tablet-token-0001", "cameras": ["front-door"]}`)
+	if w.Code != http.StatusCreated || bytes.Contains(w.Body.Bytes(), []byte("guest-tablet-token-0001")) {
+		t.Fatalf("expected 201 without the token echoed back, got %d %s", w.Code, w.Body)
+	}
+	if w := serve(http.MethodPut, "/api/cameras/acls/guest", `{"cameras": ["front-door", "garage"]}`); w.Code != http.StatusOK {
+		t.Errorf("expected 200 replacing the entry, got %d %s", w.Code, w.Body)
+	}
+	if w := serve(http.MethodPut, "/api/cameras/acls/kids", `{"token": "guest-tablet-token-0001"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a reused token, got %d", w.Code)
+	}
+	if w := serve(http.MethodPut, "/api/cameras/acls/kids", `{"cameras": ["nursery"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a new entry without a token, got %d", w.Code)
+	}
+
+	w = serve(http.MethodGet, "/api/cameras/acls", "")
+	var entries []camera.ACLEntry
+	json.NewDecoder(w.Body).Decode(&entries)
+	if len(entries) != 1 || len(entries[0].Cameras) != 2 || entries[0].Token != "" {
+		t.Errorf("expected one entry with 2 cameras and no token, got %+v", entries)
+	}
+
+	if w := serve(http.MethodDelete, "/api/cameras/acls/Guest", ""); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", w.Code)
+	}
+	if w := serve(http.MethodDelete, "/api/cameras/acls/Guest", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 deleting it again, got %d", w.Code)
+	}
+}
+
+func TestCameraACL_LimitsCameraEndpoints(t *testing.T) {
+	client, _ := newStubBridge(t, twoCamerasBody)
+	limited := func(req *http.Request) *http.Request {
+		return req.WithContext(camera.WithAllowedCameras(req.Context(), []string{"front-door"}))
+	}
+
+	w := httptest.NewRecorder()
+	HandleGetCameras(client, ListOptions{})(w, limited(httptest.NewRequest(http.MethodGet, "/api/cameras", nil)))
+	var list camera.CamerasResponse
+	json.NewDecoder(w.Body).Decode(&list)
+	if len(list.Cameras) != 1 || list.Cameras[0].NameURI != "front-door" {
+		t.Errorf("expected only front-door in the list, got %+v", list.Cameras)
+	}
+
+	w = httptest.NewRecorder()
+	HandleGetCameraStream(client)(w, limited(httptest.NewRequest(http.MethodGet, "/api/cameras/stream?name=nursery", nil)))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 streaming a camera outside the ACL, got %d %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	HandleGetCameraStream(client)(w, limited(httptest.NewRequest(http.MethodGet, "/api/cameras/stream?name=front-door", nil)))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 streaming a camera in the ACL, got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestCameraACL_SnapshotForbidden(t *testing.T) {
+	client := newSnapshotBridge(t, http.StatusOK, []byte{0xFF, 0xD8})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/cameras/snapshot?name=front-door", nil)
+	req = req.WithContext(camera.WithAllowedCameras(req.Context(), []string{"garage"}))
+	w := httptest.NewRecorder()
+	HandleCameraSnapshot(client)(w, req)
+
+	var resp camera.CamerasResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusForbidden || resp.Code != "FORBIDDEN" {
+		t.Errorf("expected 403 FORBIDDEN, got %d %+v", w.Code, resp)
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pantheon/artemis/camera"
+	"github.com/pantheon/artemis/middleware"
+)
+
+// defaultClipSeconds is the clip length when ?seconds= is omitted.
+const defaultClipSeconds = 10
+
+// HandleCameraCaptureClip records a short clip of a camera's stream to disk
+// on the Artemis host, e.g. to keep as evidence.
+// POST /api/cameras/capture-clip?name=front-door[&seconds=10]
+// Returns: camera.ClipResponse JSON once the clip is written
+//
+// The request is held for the length of the clip (plus the time ffmpeg
+// takes to connect). seconds is capped by the recorder's maximum duration.
+// Answers 501 when ffmpeg isn't installed and 503 when the camera is offline
+// or too many captures are already running.
+func HandleCameraCaptureClip(cameraClient *camera.Client, recorder *camera.ClipRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests.
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !recorder.Available() {
+			sendCameraError(w, r, http.StatusNotImplemented, "ffmpeg not available — install ffmpeg on the Artemis host to capture clips")
+			return
+		}
+
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			sendCameraError(w, r, http.StatusBadRequest, "Missing required 'name' query parameter")
+			return
+		}
+		maxSeconds := int(recorder.MaxDuration() / time.Second)
+		seconds := defaultClipSeconds
+		if secondsStr := r.URL.Query().Get("seconds"); secondsStr != "" {
+			var err error
+			if seconds, err = strconv.Atoi(secondsStr); err != nil || seconds < 1 || seconds > maxSeconds {
+				sendCameraError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid seconds %q — must be a whole number from 1 to %d", secondsStr, maxSeconds))
+				return
+			}
+		}
+		seconds = min(seconds, maxSeconds)
+
+		cam, err := cameraClient.GetCamera(r.Context(), name)
+		if err != nil {
+			var ambiguous *camera.AmbiguousNameError
+			if errors.As(err, &ambiguous) {
+				sendCameraCandidates(w, r, ambiguous)
+				return
+			}
+			log.Printf("❌ Failed to get camera '%s' for clip: %v", name, err)
+			sendCameraError(w, r, http.StatusNotFound, "Camera not found: "+err.Error())
+			return
+		}
+		if !camera.Allowed(r.Context(), cam.NameURI) {
+			sendCameraForbidden(w, r, cam)
+			return
+		}
+		if cam.Status != "online" {
+			sendCameraError(w, r, http.StatusServiceUnavailable, fmt.Sprintf("Camera '%s' is %s — try again once it's online", cam.Name, cam.Status))
+			return
+		}
+
+		log.Printf("🎬 Clip capture request - Camera: %s, Seconds: %d - Client: %s", cam.NameURI, seconds, middleware.ClientIP(r))
+
+		clip, err := recorder.Capture(r.Context(), *cam, time.Duration(seconds)*time.Second)
+		if errors.Is(err, camera.ErrCaptureBusy) {
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			sendCameraError(w, r, http.StatusServiceUnavailable, "Too many clip captures in progress — try again shortly")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to capture clip of '%s': %v", cam.NameURI, err)
+			sendCameraError(w, r, http.StatusBadGateway, "Failed to capture clip: "+err.Error())
+			return
+		}
+
+		log.Printf("✅ Captured %ds clip of '%s' (%d bytes): %s", seconds, cam.NameURI, clip.SizeBytes, clip.Path)
+		writeJSON(w, r, http.StatusOK, camera.ClipResponse{
+			Success:    true,
+			Name:       clip.Name,
+			NameURI:    clip.NameURI,
+			File:       clip.File,
+			Path:       clip.Path,
+			URL:        strings.TrimSuffix(r.URL.Path, "capture-clip") + "clips/" + clip.File,
+			Seconds:    seconds,
+			SizeBytes:  clip.SizeBytes,
+			CapturedAt: clip.CapturedAt,
+			Message:    fmt.Sprintf("Captured %ds clip of %s", seconds, clip.Name),
+		})
+	}
+}
+
+// HandleGetCameraClip downloads a clip written by HandleCameraCaptureClip.
+// GET /api/cameras/clips/{file}
+// Returns: the MP4 file (supports Range requests for seeking)
+func HandleGetCameraClip(recorder *camera.ClipRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept GET requests.
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		path, err := recorder.ClipPath(r.PathValue("file"))
+		if err != nil {
+			sendCameraError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if !camera.ClipAllowed(r.Context(), r.PathValue("file")) {
+			sendCameraError(w, r, http.StatusForbidden, "This token doesn't have access to the camera this clip is from")
+			return
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			sendCameraError(w, r, http.StatusNotFound, "Clip not found: "+r.PathValue("file"))
+			return
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			sendCameraError(w, r, http.StatusInternalServerError, "Failed to read clip")
+			return
+		}
+
+		w.Header().Set("Content-Type", "video/mp4")
+		http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/camera"
+	"github.com/pantheon/artemis/errcode"
+)
+
+// newClipRecorder returns a recorder writing to a temp directory, with a fake
+// ffmpeg on the PATH that writes "video" to its output file, or with no
+// ffmpeg at all.
+func newClipRecorder(t *testing.T, withFFmpeg bool) *camera.ClipRecorder {
+	t.Helper()
+	bin := t.TempDir()
+	if withFFmpeg {
+		script := "#!/bin/sh\nfor last; do :; done\nprintf video > \"$last\"\n"
+		if err := os.WriteFile(filepath.Join(bin, "ffmpeg"), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin)
+	return camera.NewClipRecorder(t.TempDir(), 30*time.Second, 1)
+}
+
+// clipMux routes the capture and download endpoints like main.go does.
+func clipMux(client *camera.Client, recorder *camera.ClipRecorder) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/cameras/capture-clip", HandleCameraCaptureClip(client, recorder))
+	mux.HandleFunc("/api/cameras/clips/{file}", HandleGetCameraClip(recorder))
+	return mux
+}
+
+func TestCameraCaptureClip(t *testing.T) {
+	mux := clipMux(newSnapshotBridge(t, http.StatusOK, nil), newClipRecorder(t, true))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/cameras/capture-clip?name=front-door&seconds=3", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp camera.ClipResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.Success || resp.Seconds != 3 || resp.SizeBytes != 5 || resp.URL != "/api/cameras/clips/"+resp.File {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, resp.URL, nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "video/mp4" || w.Body.String() != "video" {
+		t.Errorf("expected the clip to download, got %d %q: %q", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+}
+
+func TestCameraCaptureClip_Errors(t *testing.T) {
+	client := newSnapshotBridge(t, http.StatusOK, nil)
+
+	tests := map[string]struct {
+		withFFmpeg bool
+		method     string
+		path       string
+		status     int
+		code       errcode.Code
+	}{
+		"no ffmpeg":       {false, http.MethodPost, "/api/cameras/capture-clip?name=front-door", http.StatusNotImplemented, errcode.NotSupported},
+		"too long":        {true, http.MethodPost, "/api/cameras/capture-clip?name=front-door&seconds=31", http.StatusBadRequest, errcode.InvalidRequest},
+		"missing name":    {true, http.MethodPost, "/api/cameras/capture-clip", http.StatusBadRequest, errcode.InvalidRequest},
+		"unknown camera":  {true, http.MethodPost, "/api/cameras/capture-clip?name=garage", http.StatusNotFound, errcode.NotFound},
+		"missing clip":    {true, http.MethodGet, "/api/cameras/clips/front-door-20260101-120000.mp4", http.StatusNotFound, errcode.NotFound},
+		"not a clip file": {true, http.MethodGet, "/api/cameras/clips/pantheon.db", http.StatusBadRequest, errcode.InvalidRequest},
+	}
+	for name, tt := range tests {
+		mux := clipMux(client, newClipRecorder(t, tt.withFFmpeg))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+		var resp camera.CamerasResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		if w.Code != tt.status || resp.Code != tt.code {
+			t.Errorf("%s: expected %d %s, got %d %+v", name, tt.status, tt.code, w.Code, resp)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/pantheon/artemis/camera"
+)
+
+// ndjsonContentType is the media type for newline-delimited JSON: one JSON
+// value per line.
+const ndjsonContentType = "application/x-ndjson"
+
+// Line types of an NDJSON camera list.
+const (
+	CameraLineCamera = "camera" // One camera; sent as each is ready
+	CameraLineEnd    = "end"    // The list is complete
+	CameraLineError  = "error"  // The list failed; no more lines follow
+)
+
+// CameraListLine is one line of the camera list streamed as NDJSON
+// (GET /api/cameras with Accept: application/x-ndjson). A successful stream
+// is zero or more "camera" lines followed by one "end" line; a stream that
+// ends any other way — with an "error" line, or cut off — is incomplete.
+type CameraListLine struct {
+	Type    string         `json:"type"`              // CameraLineCamera, CameraLineEnd, or CameraLineError
+	Camera  *camera.Camera `json:"camera,omitempty"`  // "camera" lines
+	Count   int            `json:"count"`             // Cameras sent so far
+	Message string         `json:"message,omitempty"` // "end" lines, as in the JSON response
+	Error   string         `json:"error,omitempty"`   // "error" lines
+}
+
+// wantsNDJSON reports whether the request's Accept header lists NDJSON.
+// Clients that don't ask for it keep getting the regular JSON response.
+func wantsNDJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err == nil && mediaType == ndjsonContentType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// streamCameraList writes the camera list as NDJSON, flushing after every
+// line so the client can render cameras as they arrive instead of waiting
+// for the whole list. If the bridge can't be queried, nothing has been sent
+// yet, so the error status is kept and the body is a single "error" line.
+func streamCameraList(w http.ResponseWriter, r *http.Request, cameraClient *camera.Client) {
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx holding lines back
+
+	encoder := json.NewEncoder(w)
+	cameras, err := cameraClient.GetCameras(r.Context())
+	if err != nil {
+		log.Printf("❌ Failed to fetch cameras from Wyze Bridge: %v", err)
+		status, message := upstreamStatus(err, http.StatusInternalServerError)
+		w.WriteHeader(status)
+		encoder.Encode(CameraListLine{Type: CameraLineError, Error: "Failed to fetch cameras: " + message})
+		return
+	}
+	cameras = camera.FilterAllowed(r.Context(), cameras)
+
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+
+	for i := range cameras {
+		// A server-side deadline leaves the client listening, so say why
+		// the list stops; if the client itself left, the write just fails.
+		if err := r.Context().Err(); err != nil {
+			log.Printf("📷 Camera stream cancelled after %d of %d camera(s): %v", i, len(cameras), err)
+			encoder.Encode(CameraListLine{Type: CameraLineError, Count: i, Error: "Camera list interrupted: " + err.Error()})
+			return
+		}
+		// A failed write means the client is gone; there's no one to tell.
+		if err := encoder.Encode(CameraListLine{Type: CameraLineCamera, Camera: &cameras[i], Count: i + 1}); err != nil {
+			log.Printf("❌ Camera stream: failed to write camera %d: %v", i+1, err)
+			return
+		}
+		rc.Flush() // Without flushing support the lines still arrive, just together
+	}
+
+	log.Printf("📷 Streamed %d camera(s) to client", len(cameras))
+	encoder.Encode(CameraListLine{Type: CameraLineEnd, Count: len(cameras), Message: formatCameraCountMessage(len(cameras))})
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pantheon/artemis/camera"
+)
+
+// readCameraLines decodes every line of an NDJSON camera list.
+func readCameraLines(t *testing.T, w *httptest.ResponseRecorder) []CameraListLine {
+	t.Helper()
+	var lines []CameraListLine
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var line CameraListLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("line %q isn't JSON: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestGetCameras_NDJSON(t *testing.T) {
+	client, _ := newStubBridge(t, twoCamerasBody)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/cameras", nil)
+	r.Header.Set("Accept", "application/x-ndjson, application/json;q=0.5")
+	w := httptest.NewRecorder()
+	HandleGetCameras(client, ListOptions{})(w, r)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != ndjsonContentType {
+		t.Fatalf("expected a 200 NDJSON response, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !w.Flushed {
+		t.Error("expected the stream to be flushed as cameras were written")
+	}
+
+	lines := readCameraLines(t, w)
+	if len(lines) != 3 {
+		t.Fatalf("expected two cameras and an end line, got %+v", lines)
+	}
+	for i, line := range lines[:2] {
+		if line.Type != CameraLineCamera || line.Camera == nil || line.Count != i+1 {
+			t.Errorf("line %d: expected camera %d, got %+v", i, i+1, line)
+		}
+	}
+	if end := lines[2]; end.Type != CameraLineEnd || end.Count != 2 || end.Message == "" {
+		t.Errorf("expected an end line counting 2 cameras, got %+v", end)
+	}
+}
+
+func TestGetCameras_NDJSONBridgeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bridge down", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	r := httptest.NewRequest(http.MethodGet, "/api/cameras", nil)
+	r.Header.Set("Accept", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	HandleGetCameras(camera.NewClient(server.URL, ""), ListOptions{})(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", w.Code)
+	}
+	lines := readCameraLines(t, w)
+	if len(lines) != 1 || lines[0].Type != CameraLineError || lines[0].Error == "" {
+		t.Errorf("expected a single error line, got %+v", lines)
+	}
+}
+
+func TestWantsNDJSON(t *testing.T) {
+	tests := []struct {
+		accept []string
+		want   bool
+	}{
+		{nil, false},
+		{[]string{"application/json"}, false},
+		{[]string{"*/*"}, false},
+		{[]string{"application/x-ndjson"}, true},
+		{[]string{"text/html", "application/x-ndjson; charset=utf-8"}, true},
+		{[]string{"application/json, application/x-ndjson"}, true},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/cameras", nil)
+		for _, accept := range tt.accept {
+			r.Header.Add("Accept", accept)
+		}
+		if got := wantsNDJSON(r); got != tt.want {
+			t.Errorf("Accept %q: expected %v, got %v", tt.accept, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,455 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/camera"
+)
+
+// stubBridge is a fake Wyze Bridge for camera handler tests.
+// It serves a fixed camera list at /api and records control commands
+// sent to /api/<name>/<action>. Cameras listed in failing return HTTP 500.
+type stubBridge struct {
+	mu       sync.Mutex
+	commands []string
+	failing  map[string]bool
+}
+
+// newStubBridge starts the fake bridge and returns a client pointed at it.
+func newStubBridge(t *testing.T, camerasBody string, failing ...string) (*camera.Client, *stubBridge) {
+	t.Helper()
+	stub := &stubBridge{failing: make(map[string]bool)}
+	for _, name := range failing {
+		stub.failing[name] = true
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api" {
+			w.Write([]byte(camerasBody))
+			return
+		}
+
+		// /api/<name>/<action>
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/"), "/")
+		if len(parts) == 2 {
+			stub.mu.Lock()
+			stub.commands = append(stub.commands, parts[0]+"/"+parts[1])
+			stub.mu.Unlock()
+			if stub.failing[parts[0]] {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`{"status": "success"}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return camera.NewClient(server.URL, ""), stub
+}
+
+const twoCamerasBody = `{
+	"cameras": {
+		"front-door": {"name_uri": "front-door", "nickname": "Front Door", "connected": true, "enabled": true},
+		"nursery":    {"name_uri": "nursery", "nickname": "Nursery", "connected": true, "enabled": true}
+	}
+}`
+
+// =============================================================================
+// POST /api/cameras/privacy — Privacy Mode
+// =============================================================================
+
+func TestCameraPrivacy_DisablesAllCameras(t *testing.T) {
+	client, stub := newStubBridge(t, twoCamerasBody)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/cameras/privacy", bytes.NewBufferString(`{"enabled": true}`))
+	w := httptest.NewRecorder()
+	HandleCameraPrivacy(client)(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp camera.PrivacyResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.Success || resp.Affected != 2 || resp.Failed != 0 {
+		t.Errorf("expected 2 affected and 0 failed, got %+v", resp)
+	}
+
+	for _, cmd := range stub.commands {
+		if !strings.HasSuffix(cmd, "/disable") {
+			t.Errorf("expected only disable commands, got %s", cmd)
+		}
+	}
+	if len(stub.commands) != 2 {
+		t.Errorf("expected 2 bridge commands, got %d", len(stub.commands))
+	}
+}
+
+func TestCameraPrivacy_ReportsPartialFailure(t *testing.T) {
+	client, _ := newStubBridge(t, twoCamerasBody, "nursery")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/cameras/privacy", bytes.NewBufferString(`{"enabled": false}`))
+	w := httptest.NewRecorder()
+	HandleCameraPrivacy(client)(w, req)
+
+	var resp camera.PrivacyResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+
+	if resp.Success {
+		t.Error("expected success=false when a camera fails")
+	}
+	if resp.Affected != 1 || resp.Failed != 1 {
+		t.Errorf("expected 1 affected and 1 failed, got affected=%d failed=%d", resp.Affected, resp.Failed)
+	}
+	for _, result := range resp.Results {
+		if result.NameURI == "nursery" && (result.Success || result.Error == "") {
+			t.Errorf("expected nursery to be reported as failed with an error, got %+v", result)
+		}
+	}
+}
+
+func TestCameraPrivacy_InvalidBody(t *testing.T) {
+	client, _ := newStubBridge(t, twoCamerasBody)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/cameras/privacy", bytes.NewBufferString(`not json`))
+	w := httptest.NewRecorder()
+	HandleCameraPrivacy(client)(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
+// =============================================================================
+// POST /api/cameras/restart — Stream restart
+// =============================================================================
+
+func TestCameraRestart_MissingName(t *testing.T) {
+	client, _ := newStubBridge(t, twoCamerasBody)
+
+	w := httptest.NewRecorder()
+	HandleCameraRestart(client)(w, httptest.NewRequest(http.MethodPost, "/api/cameras/restart", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestCameraRestart_BridgeWithoutRestart(t *testing.T) {
+	// An older bridge: knows the camera, but 404s the restart command.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/front-door" {
+			w.Write([]byte(`{"name_uri": "front-door", "nickname": "Front Door", "connected": true, "enabled": true}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	HandleCameraRestart(camera.NewClient(server.URL, ""))(w, httptest.NewRequest(http.MethodPost, "/api/cameras/restart?name=front-door", nil))
+
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected status 501, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp camera.CamerasResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Success || !strings.Contains(resp.Message, "can't restart") {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+// =============================================================================
+// GET /api/cameras/snapshot — Snapshots
+// =============================================================================
+
+// newSnapshotBridge serves one camera, "front-door", whose snapshot endpoint
+// answers with the given status and body.
+func newSnapshotBridge(t *testing.T, status int, body []byte) *camera.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/front-door":
+			w.Write([]byte(`{"name_uri": "front-door", "nickname": "Front Door", "connected": true, "enabled": true}`))
+		case "/snapshot/front-door.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.WriteHeader(status)
+			w.Write(body)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return camera.NewClient(server.URL, "")
+}
+
+func TestCameraSnapshot_RawByDefault(t *testing.T) {
+	image := []byte{0xFF, 0xD8, 0xFF, 0xE0, 'j', 'p', 'g'}
+	client := newSnapshotBridge(t, http.StatusOK, image)
+
+	w := httptest.NewRecorder()
+	HandleCameraSnapshot(client)(w, httptest.NewRequest(http.MethodGet, "/api/cameras/snapshot?name=front-door", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("expected image/jpeg, got %q", ct)
+	}
+	if !bytes.Equal(w.Body.Bytes(), image) {
+		t.Errorf("expected the raw image bytes, got %v", w.Body.Bytes())
+	}
+}
+
+func TestCameraSnapshot_JSON(t *testing.T) {
+	client := newSnapshotBridge(t, http.StatusOK, []byte("jpeg-bytes"))
+
+	w := httptest.NewRecorder()
+	HandleCameraSnapshot(client)(w, httptest.NewRequest(http.MethodGet, "/api/cameras/snapshot?name=front-door&format=json", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp camera.SnapshotResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Name != "Front Door" || resp.ContentType != "image/jpeg" || resp.CapturedAt.IsZero() {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if resp.DataBase64 != "anBlZy1ieXRlcw==" {
+		t.Errorf("expected base64 of the image, got %q", resp.DataBase64)
+	}
+}
+
+func TestCameraSnapshot_Unavailable(t *testing.T) {
+	client := newSnapshotBridge(t, http.StatusNotFound, nil)
+
+	w := httptest.NewRecorder()
+	HandleCameraSnapshot(client)(w, httptest.NewRequest(http.MethodGet, "/api/cameras/snapshot?name=front-door&format=json", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "No snapshot available") {
+		t.Errorf("expected a clear unavailable message, got %s", w.Body.String())
+	}
+}
+
+func TestCameraSnapshot_TooLarge(t *testing.T) {
+	client := newSnapshotBridge(t, http.StatusOK, make([]byte, camera.MaxSnapshotBytes+1))
+
+	w := httptest.NewRecorder()
+	HandleCameraSnapshot(client)(w, httptest.NewRequest(http.MethodGet, "/api/cameras/snapshot?name=front-door", nil))
+
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("expected status 502, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "exceeds") {
+		t.Errorf("expected a size error, got %s", w.Body.String())
+	}
+}
+
+// =============================================================================
+// GET /api/cameras/stream?quality=... — Stream Quality
+// =============================================================================
+
+func TestCameraStream_Quality(t *testing.T) {
+	client := newSnapshotBridge(t, http.StatusOK, nil) // Also serves /api/front-door
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantSuffix string // Expected end of streamUrl
+	}{
+		{"default is hd", "", http.StatusOK, "/front-door/stream.m3u8"},
+		{"hd", "&quality=hd", http.StatusOK, "/front-door/stream.m3u8"},
+		{"sd uses the substream", "&quality=sd", http.StatusOK, "/front-door-sub/stream.m3u8"},
+		{"invalid", "&quality=4k", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			HandleGetCameraStream(client)(w, httptest.NewRequest(http.MethodGet, "/api/cameras/stream?name=front-door"+tt.query, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp camera.StreamResponse
+			json.NewDecoder(w.Body).Decode(&resp)
+			if !strings.HasSuffix(resp.StreamURL, tt.wantSuffix) {
+				t.Errorf("expected streamUrl ending in %s, got %s", tt.wantSuffix, resp.StreamURL)
+			}
+			if resp.Streams.SD == nil || !strings.HasSuffix(resp.Streams.SD.RTSP, "/front-door-sub") {
+				t.Errorf("expected substream URLs alongside the main ones, got %+v", resp.Streams)
+			}
+		})
+	}
+}
+
+// =============================================================================
+// GET /api/cameras/default — Quick-view camera
+// =============================================================================
+
+func TestDefaultCamera(t *testing.T) {
+	client, _ := newStubBridge(t, `{
+		"cameras": {
+			"garage":     {"name_uri": "garage", "nickname": "Garage", "connected": false, "enabled": true},
+			"nursery":    {"name_uri": "nursery", "nickname": "Nursery", "connected": true, "enabled": true},
+			"front-door": {"name_uri": "front-door", "nickname": "Front Door", "connected": true, "enabled": true}
+		}
+	}`)
+
+	tests := []struct {
+		name          string
+		defaultCamera string
+		wantCamera    string
+		wantSource    string
+	}{
+		{"configured and online", "nursery", "nursery", camera.DefaultSourceConfigured},
+		{"configured but offline", "garage", "front-door", camera.DefaultSourceFallback},
+		{"configured but unknown", "attic", "front-door", camera.DefaultSourceFallback},
+		{"unset", "", "front-door", camera.DefaultSourceFallback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			HandleGetDefaultCamera(client, tt.defaultCamera)(w, httptest.NewRequest(http.MethodGet, "/api/cameras/default", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+
+			var resp camera.DefaultCameraResponse
+			json.NewDecoder(w.Body).Decode(&resp)
+			if resp.NameURI != tt.wantCamera || resp.Source != tt.wantSource {
+				t.Errorf("expected %s (%s), got %s (%s)", tt.wantCamera, tt.wantSource, resp.NameURI, resp.Source)
+			}
+			if !strings.HasSuffix(resp.StreamURL, "/"+tt.wantCamera+"/stream.m3u8") {
+				t.Errorf("expected the camera's HLS URL, got %s", resp.StreamURL)
+			}
+		})
+	}
+}
+
+func TestDefaultCamera_NoneOnline(t *testing.T) {
+	client, _ := newStubBridge(t, `{"cameras": {"garage": {"name_uri": "garage", "connected": false, "enabled": true}}}`)
+
+	w := httptest.NewRecorder()
+	HandleGetDefaultCamera(client, "garage")(w, httptest.NewRequest(http.MethodGet, "/api/cameras/default", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// =============================================================================
+// GET /api/cameras/overview.jpg — Overview grid
+// =============================================================================
+
+func TestCameraOverview(t *testing.T) {
+	var snapshot bytes.Buffer
+	jpeg.Encode(&snapshot, image.NewRGBA(image.Rect(0, 0, 64, 36)), nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api":
+			w.Write([]byte(`{"cameras": {
+				"front-door": {"name_uri": "front-door", "connected": true, "enabled": true},
+				"nursery":    {"name_uri": "nursery", "connected": true, "enabled": true},
+				"garage":     {"name_uri": "garage", "connected": false, "enabled": true}
+			}}`))
+		case "/snapshot/front-door.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write(snapshot.Bytes())
+		default:
+			http.NotFound(w, r) // nursery's snapshot fails
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := camera.NewClient(server.URL, "")
+
+	w := httptest.NewRecorder()
+	HandleCameraOverview(client)(w, httptest.NewRequest(http.MethodGet, "/api/cameras/overview.jpg?cols=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Cache-Control") == "" {
+		t.Error("expected a Cache-Control header")
+	}
+
+	// Two online cameras, one per row; the failed one still gets a tile
+	grid, err := jpeg.Decode(w.Body)
+	if err != nil {
+		t.Fatalf("expected a JPEG, got error: %v", err)
+	}
+	if b := grid.Bounds(); b.Dx() != camera.OverviewTileWidth || b.Dy() != 2*camera.OverviewTileHeight {
+		t.Errorf("expected a 1x2 grid, got %dx%d", b.Dx(), b.Dy())
+	}
+}
+
+func TestCameraOverview_InvalidParams(t *testing.T) {
+	client, _ := newStubBridge(t, twoCamerasBody)
+
+	for _, query := range []string{"cols=0", "cols=abc", "max=-1"} {
+		w := httptest.NewRecorder()
+		HandleCameraOverview(client)(w, httptest.NewRequest(http.MethodGet, "/api/cameras/overview.jpg?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+// =============================================================================
+// GET /api/cameras/bridge-status
+// =============================================================================
+
+func TestGetBridgeStatus_LegacyBridge(t *testing.T) {
+	client, _ := newStubBridge(t, twoCamerasBody) // Has no status endpoint
+
+	w := httptest.NewRecorder()
+	HandleGetBridgeStatus(client)(w, httptest.NewRequest(http.MethodGet, "/api/cameras/bridge-status", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp camera.BridgeStatusResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.Success || resp.Reported || resp.TotalCameras != 2 || resp.OnlineCameras != 2 || !resp.Features.WebRTC {
+		t.Errorf("expected 2 of 2 online with legacy features, got %+v", resp)
+	}
+}
+
+func TestGetCameras_BridgeTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	HandleGetCameras(camera.NewClient(server.URL, ""), ListOptions{})(w, httptest.NewRequest(http.MethodGet, "/api/cameras", nil).WithContext(ctx))
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected status 504, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp camera.CamerasResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !strings.Contains(resp.Message, "Wyze Bridge timed out") {
+		t.Errorf("unexpected message %q", resp.Message)
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// APIVersion is the version of the JSON API reported by /api/capabilities.
+// Bumped only for breaking changes; new endpoints and fields don't change it.
+const APIVersion = "1"
+
+// Capabilities describes what this server supports, so clients can adapt
+// their UI (e.g., hide the camera tab when cameras are disabled).
+// Built once from config at startup; serving it never calls an upstream.
+type Capabilities struct {
+	APIVersion       string                  `json:"apiVersion"`
+	APIBasePath      string                  `json:"apiBasePath"`
+	ResponseEnvelope bool                    `json:"responseEnvelope"` // List endpoints use the {success, data, message} envelope
+	IfMatchRequired  bool                    `json:"ifMatchRequired"`  // Room and preset updates must send If-Match (else 428)
+	TLS              bool                    `json:"tls"`              // Whether this request reached Artemis over TLS (false behind a TLS-terminating proxy)
+	Auth             AuthCapabilities        `json:"auth"`
+	Integrations     IntegrationCapabilities `json:"integrations"`
+	Features         FeatureCapabilities     `json:"features"`
+}
+
+// AuthCapabilities reports which endpoints need credentials.
+type AuthCapabilities struct {
+	Required bool `json:"required"` // Whether regular API endpoints need a token (never, so far)
+	Admin    bool `json:"admin"`    // Whether /admin endpoints are enabled (ADMIN_TOKEN set)
+}
+
+// IntegrationCapabilities reports which integrations are switched on.
+// Routes of a disabled integration answer 404 "feature disabled".
+type IntegrationCapabilities struct {
+	Govee     bool `json:"govee"`
+	FireTV    bool `json:"fireTv"`
+	Cameras   bool `json:"cameras"`
+	Webhooks  bool `json:"webhooks"`
+	MQTT      bool `json:"mqtt"`
+	Dashboard bool `json:"dashboard"`
+}
+
+// FeatureCapabilities reports individual features, most of which depend on
+// an integration or an optional setting.
+type FeatureCapabilities struct {
+	Profiles       bool     `json:"profiles"`
+	Rooms          bool     `json:"rooms"`
+	RoomScenes     bool     `json:"roomScenes"`     // GET /room/{id}/template
+	RoomApply      bool     `json:"roomApply"`      // POST /rooms/{name}/apply
+	DevicePresets  bool     `json:"devicePresets"`  // /govee/devices/{id}/presets
+	DeviceGroupBy  []string `json:"deviceGroupBy"`  // Supported ?groupBy= values for the Govee device list
+	DeviceEvents   bool     `json:"deviceEvents"`   // SSE stream at /events/devices
+	StatePolling   bool     `json:"statePolling"`   // Device states are polled in the background
+	CommandRetry   bool     `json:"commandRetry"`   // Commands to offline devices are queued and retried
+	PartyMode      bool     `json:"partyMode"`      // /govee/party/start and /stop
+	Diagnostics    bool     `json:"diagnostics"`    // /govee/devices/diagnose
+	CommandProbes  bool     `json:"commandProbes"`  // /govee/devices/{id}/capabilities?verify=true
+	DeviceMetrics  bool     `json:"deviceMetrics"`  // Per-device Prometheus gauges at /metrics
+	WakeOnLAN      bool     `json:"wakeOnLan"`      // /firetv/wol
+	RawKeycodes    bool     `json:"rawKeycodes"`    // Fire TV "keycode" commands
+	StreamWatchdog bool     `json:"streamWatchdog"` // Stalled camera streams are restarted automatically
+	ClipCapture    bool     `json:"clipCapture"`    // /cameras/capture-clip (ffmpeg is installed)
+	DailyReport    bool     `json:"dailyReport"`    // /reports/daily-diff
+}
+
+// HandleGetCapabilities returns the server's capabilities.
+// GET /api/capabilities
+func HandleGetCapabilities(capabilities Capabilities) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept GET requests.
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		response := capabilities
+		response.TLS = r.TLS != nil
+		writeJSON(w, r, http.StatusOK, response)
+	}
+}
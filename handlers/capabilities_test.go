@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetCapabilities(t *testing.T) {
+	handler := HandleGetCapabilities(Capabilities{
+		APIVersion:   APIVersion,
+		APIBasePath:  "/api",
+		Integrations: IntegrationCapabilities{Govee: true},
+		Features:     FeatureCapabilities{DeviceGroupBy: GroupByValues},
+	})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/api/capabilities", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var caps Capabilities
+	if err := json.NewDecoder(w.Body).Decode(&caps); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if caps.APIVersion != APIVersion || !caps.Integrations.Govee || caps.Integrations.Cameras {
+		t.Errorf("unexpected capabilities: %+v", caps)
+	}
+	if len(caps.Features.DeviceGroupBy) != len(GroupByValues) {
+		t.Errorf("expected %v, got %v", GroupByValues, caps.Features.DeviceGroupBy)
+	}
+	if caps.TLS {
+		t.Error("expected tls=false for a plain HTTP request")
+	}
+}
+
+func TestGetCapabilities_ReportsTLS(t *testing.T) {
+	handler := HandleGetCapabilities(Capabilities{APIVersion: APIVersion})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/capabilities", nil)
+	req.TLS = &tls.ConnectionState{}
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var caps Capabilities
+	json.NewDecoder(w.Body).Decode(&caps)
+	if !caps.TLS {
+		t.Error("expected tls=true for a TLS request")
+	}
+}
+
+func TestGetCapabilities_MethodNotAllowed(t *testing.T) {
+	w := httptest.NewRecorder()
+	HandleGetCapabilities(Capabilities{})(w, httptest.NewRequest(http.MethodPost, "/api/capabilities", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+}
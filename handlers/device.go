@@ -3,21 +3,26 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/firetv"
 )
 
 // DeviceHandler holds the database connection and provides HTTP handlers
 // for device CRUD operations. Use NewDeviceHandler to create one.
 type DeviceHandler struct {
-	DB *sql.DB
+	DB   *sql.DB
+	List ListOptions // Shape of GET /api/profile/{profileId}/devices
 }
 
-// NewDeviceHandler creates a new DeviceHandler with the given database connection.
-func NewDeviceHandler(database *sql.DB) *DeviceHandler {
-	return &DeviceHandler{DB: database}
+// NewDeviceHandler creates a new DeviceHandler with the given database
+// connection, listing devices as listOpts says.
+func NewDeviceHandler(database *sql.DB, listOpts ListOptions) *DeviceHandler {
+	return &DeviceHandler{DB: database, List: listOpts}
 }
 
 // =============================================================================
@@ -30,11 +35,13 @@ type createDeviceRequest struct {
 	DeviceType string  `json:"deviceType"`
 	ExternalID *string `json:"externalId,omitempty"`
 	Model      *string `json:"model,omitempty"`
+	MACAddress *string `json:"macAddress,omitempty"` // For Wake-on-LAN; stored in metadata
 }
 
 // updateDeviceRequest is the JSON body for PUT /api/device/{id}
 type updateDeviceRequest struct {
-	Name string `json:"name"`
+	Name       string  `json:"name"`
+	MACAddress *string `json:"macAddress,omitempty"` // Omit to keep, "" to remove
 }
 
 // assignDeviceRequest is the JSON body for PUT /api/device/{id}/assign
@@ -50,41 +57,50 @@ type assignDeviceRequest struct {
 // The device starts unassigned (no room).
 // POST /api/profile/{profileId}/devices
 // Request body: {"name": "Desk Lamp", "deviceType": "govee_light", "externalId": "...", "model": "H6160"}
+// An optional "macAddress" is kept in the device's metadata for Wake-on-LAN.
 // Response (201): device object
 func (h *DeviceHandler) HandleCreateDevice(w http.ResponseWriter, r *http.Request) {
 	profileID := r.PathValue("profileId")
 	if profileID == "" {
-		writeError(w, http.StatusBadRequest, "Profile ID is required")
+		writeError(w, r, http.StatusBadRequest, "Profile ID is required")
 		return
 	}
 
 	// Parse request body
 	var req createDeviceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSONBody(r, &req); err != nil {
 		log.Printf("❌ Device create: invalid request body: %v", err)
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Validate required fields
 	if req.Name == "" {
-		writeError(w, http.StatusBadRequest, "Name is required")
+		writeError(w, r, http.StatusBadRequest, "Name is required")
 		return
 	}
 	if req.DeviceType == "" {
-		writeError(w, http.StatusBadRequest, "Device type is required")
+		writeError(w, r, http.StatusBadRequest, "Device type is required")
 		return
 	}
+	var metadata *string
+	if req.MACAddress != nil {
+		var err error
+		if metadata, err = withMACAddress(nil, *req.MACAddress); err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 
 	// Verify the profile exists before registering a device under it
 	_, err := db.GetProfile(h.DB, profileID)
 	if err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Profile not found")
+			writeError(w, r, http.StatusNotFound, "Profile not found")
 			return
 		}
 		log.Printf("❌ Device create: failed to verify profile: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to verify profile")
+		writeError(w, r, http.StatusInternalServerError, "Failed to verify profile")
 		return
 	}
 
@@ -92,12 +108,19 @@ func (h *DeviceHandler) HandleCreateDevice(w http.ResponseWriter, r *http.Reques
 	device, err := db.CreateDevice(h.DB, profileID, req.Name, req.DeviceType, req.ExternalID, req.Model)
 	if err != nil {
 		log.Printf("❌ Device create failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to create device")
+		writeError(w, r, http.StatusInternalServerError, "Failed to create device")
 		return
 	}
+	if metadata != nil {
+		if device, err = db.UpdateDeviceMetadata(h.DB, device.ID, metadata); err != nil {
+			log.Printf("❌ Device create: failed to save MAC address: %v", err)
+			writeError(w, r, http.StatusInternalServerError, "Failed to save MAC address")
+			return
+		}
+	}
 
 	log.Printf("📱 Created device: %s (id: %s, type: %s) for profile %s", device.Name, device.ID, device.DeviceType, profileID)
-	writeJSON(w, http.StatusCreated, device)
+	writeJSON(w, r, http.StatusCreated, device)
 }
 
 // HandleListDevices returns all devices for the given profile.
@@ -106,14 +129,14 @@ func (h *DeviceHandler) HandleCreateDevice(w http.ResponseWriter, r *http.Reques
 func (h *DeviceHandler) HandleListDevices(w http.ResponseWriter, r *http.Request) {
 	profileID := r.PathValue("profileId")
 	if profileID == "" {
-		writeError(w, http.StatusBadRequest, "Profile ID is required")
+		writeError(w, r, http.StatusBadRequest, "Profile ID is required")
 		return
 	}
 
 	devices, err := db.ListDevicesByProfile(h.DB, profileID)
 	if err != nil {
 		log.Printf("❌ Device list failed for profile %s: %v", profileID, err)
-		writeError(w, http.StatusInternalServerError, "Failed to list devices")
+		writeError(w, r, http.StatusInternalServerError, "Failed to list devices")
 		return
 	}
 
@@ -122,7 +145,7 @@ func (h *DeviceHandler) HandleListDevices(w http.ResponseWriter, r *http.Request
 		devices = []db.Device{}
 	}
 
-	writeJSON(w, http.StatusOK, devices)
+	writeList(w, r, h.List, devices, fmt.Sprintf("Found %d device(s)", len(devices)))
 }
 
 // HandleGetDevice returns a single device by ID.
@@ -131,62 +154,80 @@ func (h *DeviceHandler) HandleListDevices(w http.ResponseWriter, r *http.Request
 func (h *DeviceHandler) HandleGetDevice(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "Device ID is required")
+		writeError(w, r, http.StatusBadRequest, "Device ID is required")
 		return
 	}
 
 	device, err := db.GetDevice(h.DB, id)
 	if err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Device not found")
+			writeError(w, r, http.StatusNotFound, "Device not found")
 			return
 		}
 		log.Printf("❌ Device get failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to get device")
+		writeError(w, r, http.StatusInternalServerError, "Failed to get device")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, device)
+	writeJSON(w, r, http.StatusOK, device)
 }
 
-// HandleUpdateDevice updates a device's friendly name.
+// HandleUpdateDevice updates a device's friendly name and, optionally, the
+// MAC address used for Wake-on-LAN.
 // PUT /api/device/{id}
-// Request body: {"name": "New Lamp Name"}
+// Request body: {"name": "New Lamp Name", "macAddress": "AA:BB:CC:DD:EE:FF"}
 // Response (200): updated device object
 func (h *DeviceHandler) HandleUpdateDevice(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "Device ID is required")
+		writeError(w, r, http.StatusBadRequest, "Device ID is required")
 		return
 	}
 
 	// Parse request body
 	var req updateDeviceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSONBody(r, &req); err != nil {
 		log.Printf("❌ Device update: invalid request body: %v", err)
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if req.Name == "" {
-		writeError(w, http.StatusBadRequest, "Name is required")
+		writeError(w, r, http.StatusBadRequest, "Name is required")
 		return
 	}
+	if req.MACAddress != nil && *req.MACAddress != "" {
+		if _, err := firetv.ParseMAC(*req.MACAddress); err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 
 	// Update the device
 	device, err := db.UpdateDevice(h.DB, id, req.Name)
 	if err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Device not found")
+			writeError(w, r, http.StatusNotFound, "Device not found")
 			return
 		}
 		log.Printf("❌ Device update failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to update device")
+		writeError(w, r, http.StatusInternalServerError, "Failed to update device")
 		return
 	}
+	if req.MACAddress != nil {
+		metadata, err := withMACAddress(device.Metadata, *req.MACAddress)
+		if err == nil {
+			device, err = db.UpdateDeviceMetadata(h.DB, id, metadata)
+		}
+		if err != nil {
+			log.Printf("❌ Device update: failed to save MAC address: %v", err)
+			writeError(w, r, http.StatusInternalServerError, "Failed to save MAC address")
+			return
+		}
+	}
 
 	log.Printf("📱 Updated device: %s (id: %s)", device.Name, device.ID)
-	writeJSON(w, http.StatusOK, device)
+	writeJSON(w, r, http.StatusOK, device)
 }
 
 // HandleAssignDevice assigns a device to a room.
@@ -196,20 +237,20 @@ func (h *DeviceHandler) HandleUpdateDevice(w http.ResponseWriter, r *http.Reques
 func (h *DeviceHandler) HandleAssignDevice(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "Device ID is required")
+		writeError(w, r, http.StatusBadRequest, "Device ID is required")
 		return
 	}
 
 	// Parse request body
 	var req assignDeviceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSONBody(r, &req); err != nil {
 		log.Printf("❌ Device assign: invalid request body: %v", err)
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if req.RoomID == "" {
-		writeError(w, http.StatusBadRequest, "Room ID is required")
+		writeError(w, r, http.StatusBadRequest, "Room ID is required")
 		return
 	}
 
@@ -217,11 +258,11 @@ func (h *DeviceHandler) HandleAssignDevice(w http.ResponseWriter, r *http.Reques
 	_, err := db.GetRoom(h.DB, req.RoomID)
 	if err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Room not found")
+			writeError(w, r, http.StatusNotFound, "Room not found")
 			return
 		}
 		log.Printf("❌ Device assign: failed to verify room: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to verify room")
+		writeError(w, r, http.StatusInternalServerError, "Failed to verify room")
 		return
 	}
 
@@ -229,16 +270,16 @@ func (h *DeviceHandler) HandleAssignDevice(w http.ResponseWriter, r *http.Reques
 	device, err := db.AssignDeviceToRoom(h.DB, id, req.RoomID)
 	if err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Device not found")
+			writeError(w, r, http.StatusNotFound, "Device not found")
 			return
 		}
 		log.Printf("❌ Device assign failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to assign device")
+		writeError(w, r, http.StatusInternalServerError, "Failed to assign device")
 		return
 	}
 
 	log.Printf("📱 Assigned device %s to room %s", device.Name, req.RoomID)
-	writeJSON(w, http.StatusOK, device)
+	writeJSON(w, r, http.StatusOK, device)
 }
 
 // HandleUnassignDevice removes a device from its room (sets room_id to NULL).
@@ -247,23 +288,23 @@ func (h *DeviceHandler) HandleAssignDevice(w http.ResponseWriter, r *http.Reques
 func (h *DeviceHandler) HandleUnassignDevice(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "Device ID is required")
+		writeError(w, r, http.StatusBadRequest, "Device ID is required")
 		return
 	}
 
 	device, err := db.UnassignDevice(h.DB, id)
 	if err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Device not found")
+			writeError(w, r, http.StatusNotFound, "Device not found")
 			return
 		}
 		log.Printf("❌ Device unassign failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to unassign device")
+		writeError(w, r, http.StatusInternalServerError, "Failed to unassign device")
 		return
 	}
 
 	log.Printf("📱 Unassigned device %s from its room", device.Name)
-	writeJSON(w, http.StatusOK, device)
+	writeJSON(w, r, http.StatusOK, device)
 }
 
 // HandleDeleteDevice permanently removes a device.
@@ -272,20 +313,73 @@ func (h *DeviceHandler) HandleUnassignDevice(w http.ResponseWriter, r *http.Requ
 func (h *DeviceHandler) HandleDeleteDevice(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "Device ID is required")
+		writeError(w, r, http.StatusBadRequest, "Device ID is required")
 		return
 	}
 
 	if err := db.DeleteDevice(h.DB, id); err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Device not found")
+			writeError(w, r, http.StatusNotFound, "Device not found")
 			return
 		}
 		log.Printf("❌ Device delete failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to delete device")
+		writeError(w, r, http.StatusInternalServerError, "Failed to delete device")
 		return
 	}
 
 	log.Printf("📱 Deleted device: %s", id)
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// =============================================================================
+// Metadata
+// =============================================================================
+
+// macAddressKey is the metadata key holding a device's MAC address.
+const macAddressKey = "macAddress"
+
+// deviceMACAddress returns the MAC address stored in a device's metadata,
+// or "" if there is none.
+func deviceMACAddress(device db.Device) string {
+	if device.Metadata == nil {
+		return ""
+	}
+	var metadata map[string]interface{}
+	if json.Unmarshal([]byte(*device.Metadata), &metadata) != nil {
+		return ""
+	}
+	mac, _ := metadata[macAddressKey].(string)
+	return mac
+}
+
+// withMACAddress returns metadata with its MAC address set to mac (after
+// validating it), keeping any other keys. An empty mac removes the MAC
+// address; nil is returned if that leaves the metadata empty.
+func withMACAddress(metadata *string, mac string) (*string, error) {
+	fields := make(map[string]interface{})
+	if metadata != nil {
+		if err := json.Unmarshal([]byte(*metadata), &fields); err != nil {
+			return nil, fmt.Errorf("device metadata isn't a JSON object: %w", err)
+		}
+	}
+
+	if mac == "" {
+		delete(fields, macAddressKey)
+	} else {
+		parsed, err := firetv.ParseMAC(mac)
+		if err != nil {
+			return nil, err
+		}
+		fields[macAddressKey] = strings.ToUpper(parsed.String())
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	encoded := string(data)
+	return &encoded, nil
+}
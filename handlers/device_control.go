@@ -0,0 +1,423 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pantheon/artemis/camera"
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/events"
+	"github.com/pantheon/artemis/firetv"
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/macros"
+	"github.com/pantheon/artemis/middleware"
+	"github.com/pantheon/artemis/upstream"
+)
+
+// Target types of POST /api/devices/control.
+const (
+	TargetGovee      = "govee"       // One Govee device, by device ID
+	TargetGoveeGroup = "govee_group" // Every Govee light of a room, by room name or ID
+	TargetFireTV     = "firetv"      // A Fire TV, by host
+	TargetCamera     = "camera"      // A Wyze camera, by name
+)
+
+// targetActions lists the actions each target type accepts, in the order
+// error messages list them.
+var targetActions = map[string][]string{
+	TargetGovee:      {"turn", "brightness", "color"},
+	TargetGoveeGroup: {"turn", "brightness", "color"},
+	TargetFireTV:     {"command"},
+	TargetCamera:     {"turn", "restart"},
+}
+
+// DeviceTarget names what a unified control request acts on. Macro steps
+// use the same targets.
+type DeviceTarget = macros.Target
+
+// DeviceControlRequest is the body of POST /api/devices/control, e.g.
+// {"target": {"type": "govee_group", "id": "Living Room"}, "action": "brightness", "value": 40}
+type DeviceControlRequest struct {
+	Target DeviceTarget `json:"target"`
+	Action string       `json:"action"`
+	Value  interface{}  `json:"value,omitempty"` // Depends on the action; see HandleDeviceControl
+}
+
+// DeviceControlResult is the outcome for one device of a unified control
+// request.
+type DeviceControlResult struct {
+	ID      string `json:"id"`             // Govee device ID, Fire TV host, or camera name URI
+	Name    string `json:"name,omitempty"` // Display name, when known
+	Success bool   `json:"success"`
+	Offline bool   `json:"offline,omitempty"` // Govee reported the device offline
+	Skipped bool   `json:"skipped,omitempty"` // Not attempted: the state poller last saw it offline
+	Error   string `json:"error,omitempty"`
+}
+
+// DeviceControlResponse is the response of POST /api/devices/control.
+type DeviceControlResponse struct {
+	Success   bool                  `json:"success"` // Whether every device succeeded
+	Type      string                `json:"type"`
+	Target    string                `json:"target"` // The target's id, as sent
+	Action    string                `json:"action"`
+	Message   string                `json:"message"`
+	Results   []DeviceControlResult `json:"results"`        // One per device; a group has one per light
+	Code      errcode.Code          `json:"code,omitempty"` // Machine-readable error code (errors only)
+	Timestamp string                `json:"timestamp"`
+}
+
+// DeviceControllers are the subsystems HandleDeviceControl dispatches to.
+// A nil or empty client means the integration is turned off.
+type DeviceControllers struct {
+	Govee       []*govee.Client
+	Database    *sql.DB
+	Optimistic  *govee.OptimisticStates // Records successful Govee commands, if non-nil
+	RetryQueue  *govee.RetryQueue       // Drops older queued commands for devices commanded successfully, if non-nil
+	Poller      *govee.StatePoller      // Lets groups skip known-offline lights, if non-nil
+	SkipOffline bool                    // Groups skip lights Poller last saw offline (?skipOffline= overrides)
+	Events      *events.Broker          // Receives device.command_failed events, if non-nil
+	FireTV      []*firetv.Client
+	Cameras     *camera.Client
+}
+
+// HandleDeviceControl is one control entry point for every kind of device.
+// POST /api/devices/control
+// Accepts: DeviceControlRequest JSON body
+// Returns: DeviceControlResponse JSON
+//
+// The target's type picks the subsystem, and the action must be one that
+// type supports:
+// - govee, govee_group: "turn" (bool), "brightness" (0-100), "color" ({r, g, b})
+// - firetv: "command" (a Fire TV command name such as "home" or "sleep")
+// - camera: "turn" (bool; false stops the stream), "restart"
+//
+// Each goes through the same path as the subsystem's own endpoint. A group
+// sends to its lights concurrently and answers 200 even when some fail, like
+// /rooms/{name}/apply; a single device that fails gets the usual upstream
+// error status. An integration that's turned off answers 404
+// FEATURE_DISABLED.
+func HandleDeviceControl(controllers DeviceControllers) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req DeviceControlRequest
+		if err := decodeJSONBody(r, &req); err != nil {
+			log.Printf("❌ Error decoding device control request: %v", err)
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := validateDeviceControl(req); err != nil {
+			writeErrorFrom(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		log.Printf("🎛️  Device control request - Type: %s, Target: %s, Action: %s - Client: %s",
+			req.Target.Type, req.Target.ID, req.Action, middleware.ClientIP(r))
+
+		results, status, err := runDeviceControl(r, controllers, req)
+		response := DeviceControlResponse{
+			Success:   err == nil,
+			Type:      req.Target.Type,
+			Target:    req.Target.ID,
+			Action:    req.Action,
+			Results:   results,
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+		if response.Results == nil {
+			response.Results = []DeviceControlResult{}
+		}
+		if err != nil {
+			log.Printf("❌ Device control failed - Type: %s, Target: %s: %v", req.Target.Type, req.Target.ID, err)
+			response.Message = err.Error()
+			response.Code = deviceControlCode(err, status)
+			writeJSON(w, r, status, response)
+			return
+		}
+
+		succeeded := 0
+		for _, result := range results {
+			if result.Success {
+				succeeded++
+			}
+		}
+		response.Success = succeeded == len(results)
+		response.Message = fmt.Sprintf("%s applied to %d of %d device(s)", req.Action, succeeded, len(results))
+		writeJSON(w, r, http.StatusOK, response)
+	}
+}
+
+// validateDeviceControl checks that a request names a target and an action
+// its type supports. A wrong action is an *govee.InvalidCommandError.
+func validateDeviceControl(req DeviceControlRequest) error {
+	actions, ok := targetActions[req.Target.Type]
+	if !ok {
+		return fmt.Errorf("target.type must be one of %s, %s, %s, or %s, got %q",
+			TargetGovee, TargetGoveeGroup, TargetFireTV, TargetCamera, req.Target.Type)
+	}
+	if req.Target.ID == "" {
+		return fmt.Errorf("target.id is required")
+	}
+	if !slices.Contains(actions, req.Action) {
+		return &govee.InvalidCommandError{Err: fmt.Errorf("Action %q doesn't apply to %s targets (supported: %s)",
+			req.Action, req.Target.Type, strings.Join(actions, ", "))}
+	}
+	return nil
+}
+
+// runDeviceControl sends a validated request to its subsystem, returning
+// the per-device results, and the status and error of a failed request.
+func runDeviceControl(r *http.Request, controllers DeviceControllers, req DeviceControlRequest) ([]DeviceControlResult, int, error) {
+	switch req.Target.Type {
+	case TargetGovee:
+		return controlGoveeTarget(r, controllers, req)
+	case TargetGoveeGroup:
+		return controlGoveeGroup(r, controllers, req)
+	case TargetFireTV:
+		return controlFireTVTarget(r, controllers, req)
+	case TargetCamera:
+		return controlCameraTarget(r, controllers, req)
+	}
+	return nil, http.StatusBadRequest, fmt.Errorf("unknown target type %q", req.Target.Type)
+}
+
+// errIntegrationDisabled is returned for a target whose integration is off.
+type errIntegrationDisabled string
+
+func (e errIntegrationDisabled) Error() string {
+	return fmt.Sprintf("Feature disabled: %s is turned off on this server", string(e))
+}
+
+// deviceControlCode picks the error code of a failed unified control request.
+func deviceControlCode(err error, status int) errcode.Code {
+	var disabled errIntegrationDisabled
+	if errors.As(err, &disabled) {
+		return errcode.FeatureDisabled
+	}
+	return errorCode(err, status)
+}
+
+// controlGoveeTarget runs a command on one Govee device through
+// govee.ExecuteCommand, the control path shared by every transport.
+func controlGoveeTarget(r *http.Request, controllers DeviceControllers, req DeviceControlRequest) ([]DeviceControlResult, int, error) {
+	if len(controllers.Govee) == 0 {
+		return nil, http.StatusNotFound, errIntegrationDisabled("Govee")
+	}
+	apiKeyIndex := -1 // Any account
+	if req.Target.APIKeyIndex != nil {
+		apiKeyIndex = *req.Target.APIKeyIndex
+		if apiKeyIndex < 0 || apiKeyIndex >= len(controllers.Govee) {
+			return nil, http.StatusBadRequest, fmt.Errorf("Invalid API key index %d", apiKeyIndex)
+		}
+	}
+	if err := govee.ValidateCommand(req.Action, req.Value); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	device, index, err := findDevice(r.Context(), controllers.Govee, req.Target.ID, apiKeyIndex)
+	if errors.Is(err, errDeviceNotFound) {
+		return nil, http.StatusNotFound, &deviceNotFoundError{req.Target.ID}
+	}
+	if err != nil {
+		log.Printf("❌ Error resolving device %s: %v", req.Target.ID, err)
+		return nil, http.StatusBadGateway, fmt.Errorf("Couldn't load the Govee device list")
+	}
+
+	result := DeviceControlResult{ID: device.Device, Name: device.DeviceName}
+	if err := sendGoveeCommand(r, controllers, index, device, req.Action, req.Value); err != nil {
+		status, _ := upstreamStatus(err, http.StatusBadRequest)
+		return []DeviceControlResult{goveeFailure(result, err)}, status, err
+	}
+	result.Success = true
+	return []DeviceControlResult{result}, http.StatusOK, nil
+}
+
+// controlGoveeGroup runs a command on every Govee light of a room,
+// concurrently. Lights the state poller last saw offline are skipped, as
+// for room scenes, when controllers.SkipOffline is set.
+func controlGoveeGroup(r *http.Request, controllers DeviceControllers, req DeviceControlRequest) ([]DeviceControlResult, int, error) {
+	if len(controllers.Govee) == 0 {
+		return nil, http.StatusNotFound, errIntegrationDisabled("Govee")
+	}
+	if err := govee.ValidateCommand(req.Action, req.Value); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	offline, err := offlineFilter(r, controllers.Poller, controllers.SkipOffline)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	room, status, err := findRoomByName(controllers.Database, req.Target.ID, req.Target.ProfileID)
+	if err != nil {
+		return nil, status, err
+	}
+	lights, err := roomLights(controllers.Database, room.ID)
+	if err != nil {
+		log.Printf("❌ Device control: failed to list devices in room %s: %v", room.ID, err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to list devices in room")
+	}
+	if len(lights) == 0 {
+		return nil, http.StatusBadRequest, fmt.Errorf("Room %s has no Govee lights", room.Name)
+	}
+
+	results := make([]DeviceControlResult, len(lights))
+	var wg sync.WaitGroup
+	for i, light := range lights {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = controlGroupLight(r, controllers, offline, light, req)
+		}()
+	}
+	wg.Wait()
+	return results, http.StatusOK, nil
+}
+
+// controlGroupLight runs a group's command on one of its lights.
+func controlGroupLight(r *http.Request, controllers DeviceControllers, offline *govee.StatePoller, light roomLight, req DeviceControlRequest) DeviceControlResult {
+	result := DeviceControlResult{ID: light.deviceID, Name: light.name}
+
+	device, index, err := findDevice(r.Context(), controllers.Govee, light.deviceID, -1)
+	if err != nil {
+		if errors.Is(err, errDeviceNotFound) {
+			result.Error = "No configured Govee account has this device"
+		} else {
+			log.Printf("❌ Device control: error resolving device %s: %v", light.deviceID, err)
+			result.Error = "Couldn't load the Govee device list"
+		}
+		return result
+	}
+	if knownOffline(offline, index, device.Device) {
+		result.Error, result.Offline, result.Skipped = skippedOfflineMessage, true, true
+		return result
+	}
+
+	if err := sendGoveeCommand(r, controllers, index, device, req.Action, req.Value); err != nil {
+		return goveeFailure(result, err)
+	}
+	result.Success = true
+	return result
+}
+
+// sendGoveeCommand sends one command and records its outcome: the new state
+// on success, a device.command_failed event on failure.
+func sendGoveeCommand(r *http.Request, controllers DeviceControllers, apiKeyIndex int, device govee.Device, command string, value interface{}) error {
+	err := govee.ExecuteCommand(r.Context(), controllers.Govee[apiKeyIndex], device.Device, device.Model, command, value, 0)
+	if err != nil {
+		publishCommandFailed(controllers.Events, ControlRequest{
+			APIKeyIndex: apiKeyIndex,
+			DeviceID:    device.Device,
+			Model:       device.Model,
+			Command:     command,
+			Value:       value,
+		}, err)
+		return err
+	}
+	if controllers.Optimistic != nil {
+		controllers.Optimistic.Record(apiKeyIndex, device.Device, device.Model, command, value)
+	}
+	controllers.RetryQueue.Forget(apiKeyIndex, device.Device)
+	return nil
+}
+
+// goveeFailure fills in a Govee device's result for a failed command.
+func goveeFailure(result DeviceControlResult, err error) DeviceControlResult {
+	_, message := upstreamStatus(err, http.StatusBadRequest)
+	result.Error = message
+	result.Offline = govee.IsOfflineError(err)
+	return result
+}
+
+// controlFireTVTarget sends a named remote command to a Fire TV, through
+// the same Fire TV service POST /api/firetv/command would pick.
+func controlFireTVTarget(r *http.Request, controllers DeviceControllers, req DeviceControlRequest) ([]DeviceControlResult, int, error) {
+	if len(controllers.FireTV) == 0 {
+		return nil, http.StatusNotFound, errIntegrationDisabled("Fire TV")
+	}
+	command, ok := req.Value.(string)
+	if !ok || command == "" {
+		return nil, http.StatusBadRequest, &govee.InvalidCommandError{Err: fmt.Errorf("Invalid value for 'command' - expected a Fire TV command name such as \"home\"")}
+	}
+	firetvClient, err := fireTVService(controllers.FireTV, req.Target.ID, req.Target.ServiceIndex)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	result := DeviceControlResult{ID: req.Target.ID}
+	sent, err := firetvClient.SendCommand(r.Context(), req.Target.ID, command, "", "")
+	if err != nil {
+		status, message := upstreamStatus(err, http.StatusBadRequest)
+		result.Error = message
+		return []DeviceControlResult{result}, status, errors.New(message)
+	}
+	result.Success = sent.Success
+	if !sent.Success {
+		result.Error = sent.Message
+	}
+	return []DeviceControlResult{result}, http.StatusOK, nil
+}
+
+// controlCameraTarget turns a camera's stream on or off, or restarts it,
+// like /cameras/privacy and /cameras/restart do for their cameras. Cameras
+// outside the request's camera ACL (see middleware.CameraScope) are 403.
+func controlCameraTarget(r *http.Request, controllers DeviceControllers, req DeviceControlRequest) ([]DeviceControlResult, int, error) {
+	if controllers.Cameras == nil {
+		return nil, http.StatusNotFound, errIntegrationDisabled("Cameras")
+	}
+	enabled, isBool := req.Value.(bool)
+	if req.Action == "turn" && !isBool {
+		return nil, http.StatusBadRequest, &govee.InvalidCommandError{Err: fmt.Errorf("Invalid value for 'turn' - expected boolean")}
+	}
+
+	cam, err := controllers.Cameras.GetCamera(r.Context(), req.Target.ID)
+	if err != nil {
+		var ambiguous *camera.AmbiguousNameError
+		if errors.As(err, &ambiguous) {
+			return nil, http.StatusConflict, fmt.Errorf("Display name '%s' matches %d cameras — use one of their nameUri values", ambiguous.DisplayName, len(ambiguous.Candidates))
+		}
+		// An unreachable bridge isn't a missing camera
+		if _, unreachable := upstream.As(err); unreachable {
+			status, message := upstreamStatus(err, http.StatusBadGateway)
+			return nil, status, errors.New(message)
+		}
+		return nil, http.StatusNotFound, fmt.Errorf("Camera not found: %v", err)
+	}
+
+	if !camera.Allowed(r.Context(), cam.NameURI) {
+		log.Printf("🛑 Camera '%s' isn't in the caller's camera ACL - Client: %s", cam.NameURI, middleware.ClientIP(r))
+		return nil, http.StatusForbidden, fmt.Errorf("This token doesn't have access to camera '%s'", cam.NameURI)
+	}
+
+	result := DeviceControlResult{ID: cam.NameURI, Name: cam.Name}
+	if req.Action == "turn" {
+		err = controllers.Cameras.SetCameraEnabled(r.Context(), cam.NameURI, enabled)
+	} else {
+		var restart camera.RestartResult
+		restart, err = controllers.Cameras.RecoverStream(r.Context(), *cam)
+		if err == nil && !restart.Ready {
+			result.Error = "Stream restarted but isn't ready yet — try again shortly"
+			return []DeviceControlResult{result}, http.StatusOK, nil
+		}
+	}
+	if errors.Is(err, camera.ErrRestartUnsupported) {
+		return nil, http.StatusNotImplemented, fmt.Errorf("This Wyze Bridge version can't restart streams")
+	}
+	if err != nil {
+		status, message := upstreamStatus(err, http.StatusBadGateway)
+		result.Error = message
+		return []DeviceControlResult{result}, status, errors.New(message)
+	}
+	result.Success = true
+	return []DeviceControlResult{result}, http.StatusOK, nil
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pantheon/artemis/camera"
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/govee"
+)
+
+// controlDevices posts body to /api/devices/control.
+func controlDevices(controllers DeviceControllers, body string) (*httptest.ResponseRecorder, DeviceControlResponse) {
+	w := httptest.NewRecorder()
+	HandleDeviceControl(controllers)(w, httptest.NewRequest(http.MethodPost, "/api/devices/control", bytes.NewBufferString(body)))
+	var resp DeviceControlResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestDeviceControl_GoveeDevice(t *testing.T) {
+	optimistic := govee.NewOptimisticStates("")
+	controllers := DeviceControllers{Govee: newGoveeStub(t, offlinePlugAccount).clients, Optimistic: optimistic}
+
+	w, resp := controlDevices(controllers, `{"target": {"type": "govee", "id": "AA:01"}, "action": "brightness", "value": 40}`)
+	if w.Code != http.StatusOK || !resp.Success || len(resp.Results) != 1 || resp.Results[0].ID != "AA:01" {
+		t.Fatalf("expected the lamp to be dimmed, got %d %+v", w.Code, resp)
+	}
+	if state, ok := optimistic.Get(0, "AA:01"); !ok || state.Brightness == nil || *state.Brightness != 40 {
+		t.Errorf("expected the brightness to be recorded, got %+v", state)
+	}
+
+	w, resp = controlDevices(controllers, `{"target": {"type": "govee", "id": "AA:02"}, "action": "turn", "value": true}`)
+	if w.Code == http.StatusOK || resp.Success || resp.Code != errcode.DeviceOffline || !resp.Results[0].Offline {
+		t.Errorf("expected the offline plug to fail with DEVICE_OFFLINE, got %d %+v", w.Code, resp)
+	}
+}
+
+func TestDeviceControl_GoveeGroup(t *testing.T) {
+	database, _ := newRoomApplyDB(t)
+	controllers := DeviceControllers{Govee: newGoveeStub(t, offlinePlugAccount).clients, Database: database}
+
+	w, resp := controlDevices(controllers, `{"target": {"type": "govee_group", "id": "living room"}, "action": "turn", "value": false}`)
+	if w.Code != http.StatusOK || resp.Success || len(resp.Results) != 2 {
+		t.Fatalf("expected a partial failure with 2 results, got %d %+v", w.Code, resp)
+	}
+	// Results follow the room's device order: the lamp, then the plug
+	if lamp, plug := resp.Results[0], resp.Results[1]; !lamp.Success || plug.Success || !plug.Offline {
+		t.Errorf("expected the lamp to turn off and the plug to be offline, got %+v", resp.Results)
+	}
+	if resp.Message != "turn applied to 1 of 2 device(s)" {
+		t.Errorf("unexpected message %q", resp.Message)
+	}
+}
+
+func TestDeviceControl_GoveeGroupSkipOffline(t *testing.T) {
+	database, _ := newRoomApplyDB(t)
+	poller := newOfflinePoller(t, "AA:02", "H5080")
+	body := `{"target": {"type": "govee_group", "id": "Living Room"}, "action": "turn", "value": true}`
+
+	controllers := DeviceControllers{Govee: newGoveeStub(t, offlinePlugAccount).clients, Database: database, Poller: poller, SkipOffline: true}
+	if _, resp := controlDevices(controllers, body); len(resp.Results) != 2 || !resp.Results[1].Skipped {
+		t.Errorf("expected the offline plug to be skipped, got %+v", resp.Results)
+	}
+
+	// GOVEE_BATCH_SKIP_OFFLINE=false: the plug is attempted
+	controllers.SkipOffline = false
+	if _, resp := controlDevices(controllers, body); len(resp.Results) != 2 || resp.Results[1].Skipped || !resp.Results[1].Offline {
+		t.Errorf("expected the plug to be attempted, got %+v", resp.Results)
+	}
+}
+
+func TestDeviceControl_RejectsBadRequests(t *testing.T) {
+	database, _ := newRoomApplyDB(t)
+	controllers := DeviceControllers{Govee: newGoveeStub(t, offlinePlugAccount).clients, Database: database}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   errcode.Code
+	}{
+		{"unknown type", `{"target": {"type": "toaster", "id": "x"}, "action": "turn", "value": true}`, http.StatusBadRequest, errcode.InvalidRequest},
+		{"missing id", `{"target": {"type": "govee"}, "action": "turn", "value": true}`, http.StatusBadRequest, errcode.InvalidRequest},
+		{"action for another type", `{"target": {"type": "govee", "id": "AA:01"}, "action": "restart"}`, http.StatusBadRequest, errcode.InvalidCommand},
+		{"bad value", `{"target": {"type": "govee_group", "id": "Living Room"}, "action": "brightness", "value": 150}`, http.StatusBadRequest, errcode.InvalidCommand},
+		{"unknown device", `{"target": {"type": "govee", "id": "FF:FF"}, "action": "turn", "value": true}`, http.StatusNotFound, errcode.DeviceNotFound},
+		{"unknown room", `{"target": {"type": "govee_group", "id": "Garage"}, "action": "turn", "value": true}`, http.StatusNotFound, errcode.NotFound},
+		{"fire tv disabled", `{"target": {"type": "firetv", "id": "192.168.1.50"}, "action": "command", "value": "home"}`, http.StatusNotFound, errcode.FeatureDisabled},
+		{"cameras disabled", `{"target": {"type": "camera", "id": "front-door"}, "action": "restart"}`, http.StatusNotFound, errcode.FeatureDisabled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, resp := controlDevices(controllers, tt.body)
+			if w.Code != tt.status || resp.Code != tt.code {
+				t.Errorf("expected %d %s, got %d: %s", tt.status, tt.code, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestDeviceControl_CameraACL(t *testing.T) {
+	client, stub := newStubBridge(t, twoCamerasBody)
+	controllers := DeviceControllers{Cameras: client}
+
+	// A token limited to the front door, as middleware.CameraScope would set up
+	control := func(body string) *httptest.ResponseRecorder {
+		ctx := camera.WithAllowedCameras(context.Background(), []string{"front-door"})
+		w := httptest.NewRecorder()
+		HandleDeviceControl(controllers)(w, httptest.NewRequest(http.MethodPost, "/api/devices/control", bytes.NewBufferString(body)).WithContext(ctx))
+		return w
+	}
+
+	if w := control(`{"target": {"type": "camera", "id": "nursery"}, "action": "turn", "value": false}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a camera outside the ACL, got %d %s", w.Code, w.Body)
+	}
+	if w := control(`{"target": {"type": "camera", "id": "front-door"}, "action": "turn", "value": false}`); w.Code != http.StatusOK {
+		t.Errorf("expected the front door to be controllable, got %d %s", w.Code, w.Body)
+	}
+	if len(stub.commands) != 1 || stub.commands[0] != "front-door/disable" {
+		t.Errorf("expected only the front door to be disabled, got %v", stub.commands)
+	}
+}
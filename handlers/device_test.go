@@ -31,7 +31,7 @@ func setupTestDeviceHandler(t *testing.T) (*DeviceHandler, *sql.DB, *db.Profile,
 		t.Fatalf("Failed to create test room: %v", err)
 	}
 
-	return NewDeviceHandler(database), database, profile, room
+	return NewDeviceHandler(database, ListOptions{}), database, profile, room
 }
 
 // =============================================================================
@@ -502,3 +502,58 @@ func TestDeviceFullFlow(t *testing.T) {
 		t.Fatalf("get after delete: expected 404, got %d", getW.Code)
 	}
 }
+
+// =============================================================================
+// MAC address (Wake-on-LAN) metadata
+// =============================================================================
+
+func TestDevice_MACAddress(t *testing.T) {
+	h, _, profile, _ := setupTestDeviceHandler(t)
+
+	// Invalid MACs are rejected before anything is stored
+	body := `{"name": "Living Room TV", "deviceType": "fire_tv", "macAddress": "not-a-mac"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/profile/"+profile.ID+"/devices", bytes.NewBufferString(body))
+	req.SetPathValue("profileId", profile.ID)
+	w := httptest.NewRecorder()
+	h.HandleCreateDevice(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an invalid MAC, got %d", w.Code)
+	}
+
+	body = `{"name": "Living Room TV", "deviceType": "fire_tv", "macAddress": "aa-bb-cc-dd-ee-ff"}`
+	req = httptest.NewRequest(http.MethodPost, "/api/profile/"+profile.ID+"/devices", bytes.NewBufferString(body))
+	req.SetPathValue("profileId", profile.ID)
+	w = httptest.NewRecorder()
+	h.HandleCreateDevice(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var device db.Device
+	json.NewDecoder(w.Body).Decode(&device)
+	if got := deviceMACAddress(device); got != "AA:BB:CC:DD:EE:FF" {
+		t.Errorf("expected the normalized MAC to be stored, got %q (metadata %v)", got, device.Metadata)
+	}
+
+	// Updating the name alone keeps the MAC; "" removes it
+	for _, tt := range []struct {
+		body    string
+		wantMAC string
+	}{
+		{`{"name": "Den TV"}`, "AA:BB:CC:DD:EE:FF"},
+		{`{"name": "Den TV", "macAddress": "11:22:33:44:55:66"}`, "11:22:33:44:55:66"},
+		{`{"name": "Den TV", "macAddress": ""}`, ""},
+	} {
+		req := httptest.NewRequest(http.MethodPut, "/api/device/"+device.ID, bytes.NewBufferString(tt.body))
+		req.SetPathValue("id", device.ID)
+		w := httptest.NewRecorder()
+		h.HandleUpdateDevice(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", tt.body, w.Code, w.Body.String())
+		}
+		var updated db.Device
+		json.NewDecoder(w.Body).Decode(&updated)
+		if got := deviceMACAddress(updated); got != tt.wantMAC {
+			t.Errorf("%s: expected MAC %q, got %q", tt.body, tt.wantMAC, got)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pantheon/artemis/events"
+	"github.com/pantheon/artemis/middleware"
+)
+
+// sseRetry is the reconnect delay sent to clients in the SSE "retry" field.
+// EventSource reconnects on its own after a dropped connection; this keeps
+// it from waiting longer than necessary (browsers default to ~3-5s).
+const sseRetry = 2 * time.Second
+
+// HandleEventStream streams events from a broker to the client using
+// Server-Sent Events (SSE).
+// GET /api/events/devices
+//
+// Each event is written as:
+//
+//	id: 42
+//	event: device.state
+//	data: {"id":42,"type":"device.state","data":{...},"time":"..."}
+//
+// Reconnecting clients send the standard Last-Event-ID header (EventSource
+// does this automatically) and receive every buffered event they missed
+// before live events resume. Clients that can't set headers may pass
+// ?lastEventId=N instead. If the gap is larger than the buffer, a "missed"
+// event is sent first so the app knows to re-fetch full state.
+//
+// While idle, a ": heartbeat" comment is written every heartbeat interval so
+// NATs and proxies don't drop the connection for silence (EventSource ignores
+// comments). A heartbeat of 0 disables it.
+//
+// Closing shutdown ends every open stream, so http.Server.Shutdown (which
+// waits for active requests) isn't held up by clients that never hang up.
+// EventSource reconnects to the next server on its own.
+//
+// A client that can't keep up is disconnected rather than silently skipped;
+// it catches up through the same Last-Event-ID replay when it reconnects.
+func HandleEventStream(broker *events.Broker, heartbeat time.Duration, shutdown <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept GET requests.
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse the resume point, if any.
+		lastEventID := uint64(0)
+		lastEventIDStr := r.Header.Get("Last-Event-ID")
+		if lastEventIDStr == "" {
+			lastEventIDStr = r.URL.Query().Get("lastEventId")
+		}
+		if lastEventIDStr != "" {
+			parsed, err := strconv.ParseUint(lastEventIDStr, 10, 64)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, "Invalid Last-Event-ID")
+				return
+			}
+			lastEventID = parsed
+		}
+
+		rc := http.NewResponseController(w)
+
+		// SSE headers — keep the connection open and uncached.
+		// X-Accel-Buffering stops nginx from buffering the stream, which
+		// would otherwise hold events (and heartbeats) back from the client.
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		replay, missed, live, cancel := broker.Subscribe(lastEventID)
+		defer cancel()
+
+		log.Printf("📡 Event stream opened (last event %d, replaying %d) - Client: %s",
+			lastEventID, len(replay), middleware.ClientIP(r))
+
+		fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+		if missed {
+			writeSSE(w, events.Event{
+				Type: events.TypeMissed,
+				Data: map[string]string{"message": "You may have missed updates — re-fetch current state"},
+			})
+		}
+		for _, event := range replay {
+			writeSSE(w, event)
+		}
+		if err := rc.Flush(); err != nil {
+			log.Printf("❌ Event stream: response does not support flushing: %v", err)
+			return
+		}
+
+		// A nil channel never fires, so a disabled heartbeat just never ticks.
+		var heartbeats <-chan time.Time
+		if heartbeat > 0 {
+			ticker := time.NewTicker(heartbeat)
+			defer ticker.Stop()
+			heartbeats = ticker.C
+		}
+
+		for {
+			select {
+			case <-r.Context().Done():
+				log.Printf("📡 Event stream closed - Client: %s", middleware.ClientIP(r))
+				return
+			case <-shutdown:
+				log.Printf("📡 Event stream closed for shutdown - Client: %s", middleware.ClientIP(r))
+				return
+			case event, ok := <-live:
+				if !ok {
+					// Too far behind — end the stream so EventSource
+					// reconnects with Last-Event-ID and gets the replay.
+					log.Printf("📡 Event stream closed, client fell behind - Client: %s", middleware.ClientIP(r))
+					return
+				}
+				writeSSE(w, event)
+				if err := rc.Flush(); err != nil {
+					return
+				}
+			case <-heartbeats:
+				// A failed write means the client is gone even if the
+				// request context hasn't noticed yet.
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				if err := rc.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// writeSSE writes a single event in SSE wire format.
+// Events without an ID (such as the "missed" marker) omit the id line so
+// they don't move the client's Last-Event-ID.
+func writeSSE(w http.ResponseWriter, event events.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("❌ Error encoding event %d: %v", event.ID, err)
+		return
+	}
+
+	if event.ID > 0 {
+		fmt.Fprintf(w, "id: %d\n", event.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/events"
+)
+
+// readSSELines reads lines from an SSE response until n "data:" lines have
+// been seen or the timeout elapses.
+func readSSELines(t *testing.T, resp *http.Response, n int) []string {
+	t.Helper()
+	var lines []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(resp.Body)
+		seen := 0
+		for scanner.Scan() {
+			line := scanner.Text()
+			lines = append(lines, line)
+			if strings.HasPrefix(line, "data:") {
+				seen++
+				if seen == n {
+					return
+				}
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for %d events, got lines: %v", n, lines)
+	}
+	return lines
+}
+
+// =============================================================================
+// GET /api/events/devices — SSE stream
+// =============================================================================
+
+func TestEventStream_ReplaysFromLastEventID(t *testing.T) {
+	broker := events.NewBroker(10)
+	broker.Publish("device.state", map[string]int{"n": 1})
+	broker.Publish("device.state", map[string]int{"n": 2})
+	broker.Publish("device.state", map[string]int{"n": 3})
+
+	server := httptest.NewServer(HandleEventStream(broker, 0, nil))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	req.Header.Set("Last-Event-ID", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got %s", ct)
+	}
+
+	lines := readSSELines(t, resp, 2)
+	joined := strings.Join(lines, "\n")
+	if !strings.Contains(joined, "id: 2") || !strings.Contains(joined, "id: 3") {
+		t.Errorf("expected replay of events 2 and 3, got:\n%s", joined)
+	}
+	if strings.Contains(joined, "id: 1\n") {
+		t.Errorf("did not expect event 1 to be replayed, got:\n%s", joined)
+	}
+}
+
+func TestEventStream_SendsMissedMarker(t *testing.T) {
+	broker := events.NewBroker(2)
+	for i := 0; i < 5; i++ {
+		broker.Publish("device.state", i)
+	}
+
+	server := httptest.NewServer(HandleEventStream(broker, 0, nil))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?lastEventId=1", nil)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	lines := readSSELines(t, resp, 1)
+	if !strings.Contains(strings.Join(lines, "\n"), "event: missed") {
+		t.Errorf("expected a missed marker first, got: %v", lines)
+	}
+}
+
+func TestEventStream_InvalidLastEventID(t *testing.T) {
+	broker := events.NewBroker(10)
+	req := httptest.NewRequest(http.MethodGet, "/api/events/devices", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	w := httptest.NewRecorder()
+
+	HandleEventStream(broker, 0, nil)(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestEventStream_SendsHeartbeatsWhileIdle(t *testing.T) {
+	broker := events.NewBroker(10)
+	server := httptest.NewServer(HandleEventStream(broker, 20*time.Millisecond, nil))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if cc := resp.Header.Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("expected Cache-Control no-store, got %q", cc)
+	}
+	if xab := resp.Header.Get("X-Accel-Buffering"); xab != "no" {
+		t.Errorf("expected X-Accel-Buffering no, got %q", xab)
+	}
+
+	// No events are published, so anything after the retry hint is a heartbeat.
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	var seen []string
+	heartbeats := 0
+	deadline := time.After(2 * time.Second)
+	for heartbeats < 2 {
+		select {
+		case line := <-lines:
+			seen = append(seen, line)
+			if line == ": heartbeat" {
+				heartbeats++
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for heartbeats, got lines: %v", seen)
+		}
+	}
+	if seen[0] != "retry: 2000" {
+		t.Errorf("expected a retry hint first, got %q", seen[0])
+	}
+}
+
+func TestEventStream_StopsWhenClientDisconnects(t *testing.T) {
+	broker := events.NewBroker(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/api/events/devices", nil).WithContext(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		HandleEventStream(broker, 10*time.Millisecond, nil)(httptest.NewRecorder(), req)
+		close(done)
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("handler did not return after the client disconnected")
+	}
+}
+
+func TestEventStream_EndsOnServerShutdown(t *testing.T) {
+	broker := events.NewBroker(10)
+	closing := make(chan struct{})
+	server := httptest.NewUnstartedServer(HandleEventStream(broker, 0, closing))
+	server.Config.RegisterOnShutdown(func() { close(closing) })
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := server.Config.Shutdown(ctx); err != nil {
+		t.Fatalf("expected Shutdown not to wait on the open stream, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// requestedFields returns the field names in the request's ?fields= list,
+// or nil when the request didn't ask for a subset.
+func requestedFields(r *http.Request) []string {
+	if r == nil {
+		return nil
+	}
+	var fields []string
+	for _, field := range strings.Split(r.URL.Query().Get("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" && !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// selectFields applies the request's ?fields= to a list: each item keeps only
+// the requested top-level JSON fields. Items is returned unchanged when no
+// fields were requested or it isn't a slice. Names are checked against the
+// item type's JSON fields; unknown ones are an error when strict, and
+// otherwise dropped.
+func selectFields(r *http.Request, items interface{}, strict bool) (interface{}, error) {
+	fields := requestedFields(r)
+	value := reflect.ValueOf(items)
+	if len(fields) == 0 || value.Kind() != reflect.Slice {
+		return items, nil
+	}
+
+	if known := jsonFieldNames(value.Type().Elem()); known != nil {
+		var unknown []string
+		fields = slices.DeleteFunc(fields, func(field string) bool {
+			if slices.Contains(known, field) {
+				return false
+			}
+			unknown = append(unknown, field)
+			return true
+		})
+		if len(unknown) > 0 && strict {
+			return nil, fmt.Errorf("Unknown field(s) %s — must be one of: %s", strings.Join(unknown, ", "), strings.Join(known, ", "))
+		}
+	}
+
+	// Round-trip through JSON so the fields are the ones clients see
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var full []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &full); err != nil {
+		return items, nil // Not a list of objects; nothing to select from
+	}
+
+	projected := make([]map[string]json.RawMessage, len(full))
+	for i, item := range full {
+		projected[i] = make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if raw, ok := item[field]; ok {
+				projected[i][field] = raw
+			}
+		}
+	}
+	return projected, nil
+}
+
+// jsonFieldNames lists the top-level JSON field names of a struct type (or
+// pointer to one), including those of embedded structs, in declaration
+// order. Returns nil for other types, whose fields can't be known up front.
+func jsonFieldNames(t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	names := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			names = append(names, jsonFieldNames(field.Type)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pantheon/artemis/camera"
+)
+
+func TestWriteList_Fields(t *testing.T) {
+	devices := []DeviceResponse{
+		{ID: "AA:01", Name: "Desk Lamp", Model: "H6008", Type: "light"},
+		{ID: "AA:02", Name: "Strip", Model: "H6159", Type: "light"},
+	}
+
+	w := httptest.NewRecorder()
+	writeList(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices?fields=id,name,bogus", nil), ListOptions{}, devices, "")
+
+	want := `[{"id":"AA:01","name":"Desk Lamp"},{"id":"AA:02","name":"Strip"}]`
+	if got := strings.TrimSpace(w.Body.String()); w.Code != http.StatusOK || got != want {
+		t.Errorf("expected %s with the unknown field ignored, got %d %s", want, w.Code, got)
+	}
+
+	w = httptest.NewRecorder()
+	writeList(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices", nil), ListOptions{}, devices, "")
+	if !strings.Contains(w.Body.String(), `"model":"H6008"`) {
+		t.Errorf("expected every field without ?fields=, got %s", w.Body)
+	}
+}
+
+func TestWriteList_FieldsStrict(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeList(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices?fields=id,bogus", nil), ListOptions{StrictFields: true}, []DeviceResponse{{ID: "AA:01"}}, "")
+
+	var resp ErrorResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusBadRequest || !strings.Contains(resp.Error, "bogus") {
+		t.Errorf("expected 400 naming the unknown field, got %d %+v", w.Code, resp)
+	}
+}
+
+func TestGetCameras_Fields(t *testing.T) {
+	client, _ := newStubBridge(t, twoCamerasBody)
+
+	w := httptest.NewRecorder()
+	HandleGetCameras(client, ListOptions{})(w, httptest.NewRequest(http.MethodGet, "/api/cameras?fields=nameUri,status", nil))
+
+	var resp struct {
+		Success bool                     `json:"success"`
+		Cameras []map[string]interface{} `json:"cameras"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.Success || len(resp.Cameras) != 2 || len(resp.Cameras[0]) != 2 || resp.Cameras[0]["nameUri"] == nil {
+		t.Errorf("expected 2 cameras with only nameUri and status, got %+v", resp)
+	}
+}
+
+func TestJSONFieldNames(t *testing.T) {
+	names := jsonFieldNames(reflect.TypeFor[camera.DefaultCameraResponse]())
+	if len(names) == 0 || names[0] != "success" || names[len(names)-1] != "source" {
+		t.Errorf("expected the embedded response's fields followed by source, got %v", names)
+	}
+	if jsonFieldNames(reflect.TypeFor[string]()) != nil {
+		t.Error("expected no known fields for a non-struct type")
+	}
+}
@@ -1,12 +1,19 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/pantheon/artemis/errcode"
 	"github.com/pantheon/artemis/firetv"
+	"github.com/pantheon/artemis/middleware"
 )
 
 // FireTVDiscoverResponse is the response sent to the iOS app for device discovery.
@@ -15,6 +22,7 @@ type FireTVDiscoverResponse struct {
 	Success bool                       `json:"success"` // Whether the discovery scan succeeded
 	Devices []firetv.DiscoveredDevice  `json:"devices"` // List of Fire TV devices found on the LAN
 	Message string                     `json:"message"` // Human-readable status (e.g., "Found 2 device(s)")
+	Truncated bool                     `json:"truncated"` // True when more devices were found than ?max= allowed
 }
 
 // FireTVPairRequest is the request body from the iOS app for pairing.
@@ -22,6 +30,10 @@ type FireTVDiscoverResponse struct {
 type FireTVPairRequest struct {
 	Host string `json:"host"`          // IP address of the Fire TV device
 	PIN  string `json:"pin,omitempty"` // 6-digit PIN from the TV screen (empty to start pairing)
+
+	// Fire TV service to use, from the device's discovery result. Optional:
+	// by default the service whose subnets contain host is used.
+	ServiceIndex *int `json:"serviceIndex,omitempty"`
 }
 
 // FireTVPairResponse is the response sent to the iOS app for pairing.
@@ -40,22 +52,39 @@ type FireTVCommandRequest struct {
 	Command    string `json:"command"`               // Command name (e.g., "home", "up", "text_input")
 	Text       string `json:"text,omitempty"`        // Text to send (for "text_input" command)
 	AppPackage string `json:"appPackage,omitempty"`  // Package name (for "launch_app" command)
+
+	// Raw Android keycode to send instead of a named command (e.g., 85 =
+	// KEYCODE_MEDIA_PLAY_PAUSE). Only accepted when raw keycodes are enabled.
+	Keycode *int `json:"keycode,omitempty"`
+
+	// Fire TV service to use, as for FireTVPairRequest.
+	ServiceIndex *int `json:"serviceIndex,omitempty"`
 }
 
 // FireTVCommandResponse is the response sent to the iOS app after a command.
 type FireTVCommandResponse struct {
-	Success   bool   `json:"success"`   // Whether the command was sent successfully
-	Message   string `json:"message"`   // Status message (e.g., "Sent command: home")
-	Command   string `json:"command"`   // Echo of the command that was executed
-	Timestamp string `json:"timestamp"` // When the command was processed
+	Success   bool         `json:"success"`        // Whether the command was sent successfully
+	Message   string       `json:"message"`        // Status message (e.g., "Sent command: home")
+	Command   string       `json:"command"`        // Echo of the command that was executed
+	Timestamp string       `json:"timestamp"`      // When the command was processed
+	Code      errcode.Code `json:"code,omitempty"` // Machine-readable error code (errors only)
 }
 
 // HandleFireTVDiscover handles device discovery requests from the iOS app.
-// GET /api/firetv/discover
+// GET /api/firetv/discover[?timeout=<seconds>&max=<devices>]
 // Proxies to the Python Fire TV microservice which scans the LAN via mDNS
 // for devices advertising the Android TV Remote v2 service type.
+//
+// timeout lets the app trade speed for thoroughness (quick partial scan vs.
+// long scan) and max caps how many devices are returned. Both are clamped to
+// sane ranges; the response's "truncated" flag says whether max cut the list short.
 // Returns a JSON list of discovered devices with name, IP, port, and model.
-func HandleFireTVDiscover(firetvClient *firetv.Client) http.HandlerFunc {
+//
+// With several Fire TV services configured, all of them scan at once and
+// each device carries the serviceIndex that found it. A service that fails
+// is listed in serviceErrors instead of failing the scan; only when every
+// service fails is the response an error.
+func HandleFireTVDiscover(firetvClients []*firetv.Client) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Only accept GET requests for discovery.
 		if r.Method != http.MethodGet {
@@ -63,26 +92,136 @@ func HandleFireTVDiscover(firetvClient *firetv.Client) http.HandlerFunc {
 			return
 		}
 
-		log.Printf("📺 Fire TV discovery request from client: %s", r.RemoteAddr)
+		// Optional scan controls: ?timeout=<seconds> and ?max=<devices>.
+		opts, err := parseDiscoverOptions(r)
+		if err != nil {
+			sendFireTVError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
 
-		// Proxy the discovery request to the Python Fire TV service.
-		// This triggers a ~5 second mDNS scan on the local network.
-		result, err := firetvClient.Discover()
+		log.Printf("📺 Fire TV discovery request (timeout: %s, max: %d) from client: %s",
+			opts.Timeout, opts.MaxDevices, middleware.ClientIP(r))
+
+		// Proxy the discovery request to the Python Fire TV service(s).
+		// This triggers an mDNS scan on the local network (~5 seconds by default).
+		result, err := discoverAll(r.Context(), firetvClients, opts)
 		if err != nil {
 			log.Printf("❌ Fire TV discovery failed: %v", err)
-			sendFireTVError(w, http.StatusInternalServerError, err.Error())
+			status, message := upstreamStatus(err, http.StatusInternalServerError)
+			sendFireTVError(w, r, status, message)
 			return
 		}
 
+		// mDNS can report the same TV more than once (e.g., one answer per
+		// network interface), so collapse duplicates before returning.
+		discovered := len(result.Devices)
+		result.Devices = dedupeDiscoveredDevices(result.Devices)
+		if duplicates := discovered - len(result.Devices); duplicates > 0 {
+			log.Printf("📺 Collapsed %d duplicate Fire TV discovery result(s)", duplicates)
+			result.Message = fmt.Sprintf("Found %d device(s)", len(result.Devices))
+		}
+
+		// Enforce the limit here too, in case the service ignored it.
+		if opts.MaxDevices > 0 && len(result.Devices) > opts.MaxDevices {
+			result.Devices = result.Devices[:opts.MaxDevices]
+			result.Truncated = true
+			result.Message = fmt.Sprintf("Found more than %d device(s) — showing the first %d", opts.MaxDevices, opts.MaxDevices)
+		}
+
 		log.Printf("📺 Returning %d Fire TV device(s) to client", len(result.Devices))
 
 		// Send the discovery results to the iOS app.
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(result); err != nil {
-			log.Printf("❌ Error encoding Fire TV discover response: %v", err)
+		writeJSON(w, r, http.StatusOK, result)
+	}
+}
+
+// discoverAll runs discovery on every Fire TV service concurrently and merges
+// the results, tagging each device with the index of the service that found
+// it. With a single service its response is returned as is; otherwise an
+// error is returned only if every service failed.
+func discoverAll(ctx context.Context, firetvClients []*firetv.Client, opts firetv.DiscoverOptions) (*firetv.DiscoverResponse, error) {
+	results := make([]*firetv.DiscoverResponse, len(firetvClients))
+	errs := make([]error, len(firetvClients))
+	var wg sync.WaitGroup
+	for i, client := range firetvClients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = client.Discover(ctx, opts)
+		}()
+	}
+	wg.Wait()
+
+	if len(firetvClients) == 1 {
+		return results[0], errs[0]
+	}
+
+	merged := &firetv.DiscoverResponse{Devices: []firetv.DiscoveredDevice{}}
+	for i, result := range results {
+		if errs[i] != nil {
+			log.Printf("⚠️  Fire TV service %d (%s) discovery failed: %v", i, firetvClients[i].BaseURL(), errs[i])
+			merged.ServiceErrors = append(merged.ServiceErrors, fmt.Sprintf("service %d (%s): %v", i, firetvClients[i].BaseURL(), errs[i]))
+			continue
+		}
+		for _, device := range result.Devices {
+			device.ServiceIndex = i
+			merged.Devices = append(merged.Devices, device)
+		}
+		merged.Truncated = merged.Truncated || result.Truncated
+	}
+	if len(merged.ServiceErrors) == len(firetvClients) {
+		return nil, fmt.Errorf("all %d Fire TV services failed: %s", len(firetvClients), strings.Join(merged.ServiceErrors, "; "))
+	}
+
+	merged.Success = true
+	merged.Message = fmt.Sprintf("Found %d device(s)", len(merged.Devices))
+	return merged, nil
+}
+
+// fireTVService picks the Fire TV service for a request: the one at
+// serviceIndex if given, else the first whose subnets contain host, else the
+// first service.
+func fireTVService(firetvClients []*firetv.Client, host string, serviceIndex *int) (*firetv.Client, error) {
+	if serviceIndex != nil {
+		if *serviceIndex < 0 || *serviceIndex >= len(firetvClients) {
+			return nil, fmt.Errorf("serviceIndex must be between 0 and %d, got %d", len(firetvClients)-1, *serviceIndex)
+		}
+		return firetvClients[*serviceIndex], nil
+	}
+	for _, client := range firetvClients {
+		if client.Serves(host) {
+			return client, nil
 		}
 	}
+	return firetvClients[0], nil
+}
+
+// parseDiscoverOptions reads and clamps the optional discovery query params.
+// Non-numeric values are rejected; out-of-range values are clamped to the
+// limits in the firetv package. Missing params leave the service defaults.
+func parseDiscoverOptions(r *http.Request) (firetv.DiscoverOptions, error) {
+	var opts firetv.DiscoverOptions
+	query := r.URL.Query()
+
+	if timeoutStr := query.Get("timeout"); timeoutStr != "" {
+		seconds, err := strconv.ParseFloat(timeoutStr, 64)
+		if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+			return opts, fmt.Errorf("timeout must be a number of seconds")
+		}
+		// Clamp in seconds first so huge values can't overflow a Duration.
+		seconds = min(max(seconds, firetv.MinDiscoverTimeout.Seconds()), firetv.MaxDiscoverTimeout.Seconds())
+		opts.Timeout = time.Duration(seconds * float64(time.Second))
+	}
+
+	if maxStr := query.Get("max"); maxStr != "" {
+		maxDevices, err := strconv.Atoi(maxStr)
+		if err != nil {
+			return opts, fmt.Errorf("max must be a whole number of devices")
+		}
+		opts.MaxDevices = min(max(maxDevices, 1), firetv.MaxDiscoverDevices)
+	}
+
+	return opts, nil
 }
 
 // HandleFireTVPair handles pairing requests from the iOS app.
@@ -93,7 +232,9 @@ func HandleFireTVDiscover(firetvClient *firetv.Client) http.HandlerFunc {
 // Two-step flow:
 //   Step 1: {"host": "192.168.1.50"} → TV shows a PIN. Response has awaitingPin=true.
 //   Step 2: {"host": "192.168.1.50", "pin": "123456"} → Verifies PIN. Response has deviceName.
-func HandleFireTVPair(firetvClient *firetv.Client) http.HandlerFunc {
+//
+// Both steps must reach the same Fire TV service; see fireTVService.
+func HandleFireTVPair(firetvClients []*firetv.Client) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Only accept POST requests for pairing.
 		if r.Method != http.MethodPost {
@@ -103,35 +244,40 @@ func HandleFireTVPair(firetvClient *firetv.Client) http.HandlerFunc {
 
 		// Parse the request body from the iOS app.
 		var req FireTVPairRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := decodeJSONBody(r, &req); err != nil {
 			log.Printf("❌ Error decoding Fire TV pair request: %v", err)
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			writeError(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		// Validate that host is provided.
 		if req.Host == "" {
-			sendFireTVError(w, http.StatusBadRequest, "host is required")
+			sendFireTVError(w, r, http.StatusBadRequest, "host is required")
+			return
+		}
+
+		firetvClient, err := fireTVService(firetvClients, req.Host, req.ServiceIndex)
+		if err != nil {
+			sendFireTVError(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		log.Printf("📺 Fire TV pair request - Host: %s, PIN: %s - Client: %s",
-			req.Host, maskPIN(req.PIN), r.RemoteAddr)
+			req.Host, maskPIN(req.PIN), middleware.ClientIP(r))
 
 		var result *firetv.PairResponse
-		var err error
-
 		if req.PIN == "" {
 			// Step 1: Start pairing — TV will display a PIN.
-			result, err = firetvClient.StartPairing(req.Host)
+			result, err = firetvClient.StartPairing(r.Context(), req.Host)
 		} else {
 			// Step 2: Finish pairing with the user-provided PIN.
-			result, err = firetvClient.FinishPairing(req.Host, req.PIN)
+			result, err = firetvClient.FinishPairing(r.Context(), req.Host, req.PIN)
 		}
 
 		if err != nil {
 			log.Printf("❌ Fire TV pairing failed: %v", err)
-			sendFireTVError(w, http.StatusBadRequest, err.Error())
+			status, message := upstreamStatus(err, http.StatusBadRequest)
+			sendFireTVError(w, r, status, message)
 			return
 		}
 
@@ -146,11 +292,7 @@ func HandleFireTVPair(firetvClient *firetv.Client) http.HandlerFunc {
 
 		log.Printf("📺 Fire TV pair result: success=%v, awaiting_pin=%v", result.Success, result.AwaitingPIN)
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.Printf("❌ Error encoding Fire TV pair response: %v", err)
-		}
+		writeJSON(w, r, http.StatusOK, response)
 	}
 }
 
@@ -170,7 +312,13 @@ func HandleFireTVPair(firetvClient *firetv.Client) http.HandlerFunc {
 //   Power: power, sleep
 //   Volume: volume_up, volume_down, mute
 //   Special: text_input (with text field), launch_app (with appPackage field)
-func HandleFireTVCommand(firetvClient *firetv.Client) http.HandlerFunc {
+//
+// Escape hatch for keys without a named command (only when allowRawKeycodes):
+//   {"host": "192.168.1.50", "keycode": 85}
+//
+// With several Fire TV services, add "serviceIndex" from discovery or let
+// the host's subnet pick the service (see fireTVService).
+func HandleFireTVCommand(firetvClients []*firetv.Client, allowRawKeycodes bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Only accept POST requests for commands.
 		if r.Method != http.MethodPost {
@@ -180,30 +328,40 @@ func HandleFireTVCommand(firetvClient *firetv.Client) http.HandlerFunc {
 
 		// Parse the request body from the iOS app.
 		var req FireTVCommandRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := decodeJSONBody(r, &req); err != nil {
 			log.Printf("❌ Error decoding Fire TV command request: %v", err)
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			writeError(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		// Validate required fields.
 		if req.Host == "" {
-			sendFireTVError(w, http.StatusBadRequest, "host is required")
+			sendFireTVError(w, r, http.StatusBadRequest, "host is required")
+			return
+		}
+		firetvClient, err := fireTVService(firetvClients, req.Host, req.ServiceIndex)
+		if err != nil {
+			sendFireTVError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.Keycode != nil {
+			sendFireTVKeycode(w, r, firetvClient, req, allowRawKeycodes)
 			return
 		}
 		if req.Command == "" {
-			sendFireTVError(w, http.StatusBadRequest, "command is required")
+			sendFireTVError(w, r, http.StatusBadRequest, "command is required")
 			return
 		}
 
 		log.Printf("📺 Fire TV command request - Host: %s, Command: %s - Client: %s",
-			req.Host, req.Command, r.RemoteAddr)
+			req.Host, req.Command, middleware.ClientIP(r))
 
 		// Proxy the command to the Python Fire TV service.
-		result, err := firetvClient.SendCommand(req.Host, req.Command, req.Text, req.AppPackage)
+		result, err := firetvClient.SendCommand(r.Context(), req.Host, req.Command, req.Text, req.AppPackage)
 		if err != nil {
 			log.Printf("❌ Fire TV command failed: %v", err)
-			sendFireTVError(w, http.StatusBadRequest, err.Error())
+			status, message := upstreamStatus(err, http.StatusBadRequest)
+			sendFireTVError(w, r, status, message)
 			return
 		}
 
@@ -217,26 +375,95 @@ func HandleFireTVCommand(firetvClient *firetv.Client) http.HandlerFunc {
 
 		log.Printf("✅ Fire TV command successful - Host: %s, Command: %s", req.Host, req.Command)
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.Printf("❌ Error encoding Fire TV command response: %v", err)
-		}
+		writeJSON(w, r, http.StatusOK, response)
 	}
 }
 
+// sendFireTVKeycode handles the raw keycode path of HandleFireTVCommand.
+func sendFireTVKeycode(w http.ResponseWriter, r *http.Request, firetvClient *firetv.Client, req FireTVCommandRequest, allowRawKeycodes bool) {
+	if !allowRawKeycodes {
+		sendFireTVError(w, r, http.StatusForbidden, "Raw keycodes are disabled — set FIRETV_ALLOW_RAW_KEYCODES=true to enable them")
+		return
+	}
+	if req.Command != "" {
+		sendFireTVError(w, r, http.StatusBadRequest, "send either command or keycode, not both")
+		return
+	}
+	keycode := *req.Keycode
+	if keycode < firetv.MinKeycode || keycode > firetv.MaxKeycode {
+		sendFireTVError(w, r, http.StatusBadRequest,
+			fmt.Sprintf("keycode must be a valid Android keycode (%d-%d), got %d", firetv.MinKeycode, firetv.MaxKeycode, keycode))
+		return
+	}
+
+	log.Printf("📺 Fire TV raw keycode request - Host: %s, Keycode: %d - Client: %s",
+		req.Host, keycode, middleware.ClientIP(r))
+
+	result, err := firetvClient.SendKeycode(r.Context(), req.Host, keycode)
+	if err != nil {
+		log.Printf("❌ Fire TV keycode failed: %v", err)
+		status, message := upstreamStatus(err, http.StatusBadRequest)
+		sendFireTVError(w, r, status, message)
+		return
+	}
+
+	log.Printf("✅ Fire TV keycode sent - Host: %s, Keycode: %d", req.Host, keycode)
+	writeJSON(w, r, http.StatusOK, FireTVCommandResponse{
+		Success:   result.Success,
+		Message:   result.Message,
+		Command:   result.Command,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
 // sendFireTVError sends a JSON error response for Fire TV endpoints.
 // Uses a consistent format matching the other handler error patterns.
-func sendFireTVError(w http.ResponseWriter, statusCode int, message string) {
+func sendFireTVError(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
 	response := FireTVCommandResponse{
 		Success:   false,
 		Message:   message,
 		Timestamp: time.Now().Format(time.RFC3339),
+		Code:      errcode.ForStatus(statusCode),
+	}
+
+	writeJSON(w, r, statusCode, response)
+}
+
+// dedupeDiscoveredDevices collapses discovery results that refer to the same
+// TV. Devices are keyed by host (IP address); when a host appears more than
+// once, the entries are merged field by field, keeping the first non-empty
+// name, model, and port seen. Output order follows each host's first
+// appearance so the list stays stable between scans.
+func dedupeDiscoveredDevices(devices []firetv.DiscoveredDevice) []firetv.DiscoveredDevice {
+	if len(devices) == 0 {
+		return devices
+	}
+
+	merged := make([]firetv.DiscoveredDevice, 0, len(devices))
+	indexByHost := make(map[string]int, len(devices))
+
+	for _, device := range devices {
+		i, seen := indexByHost[device.Host]
+		if !seen {
+			indexByHost[device.Host] = len(merged)
+			merged = append(merged, device)
+			continue
+		}
+
+		// Fill in anything the earlier entry was missing.
+		existing := &merged[i]
+		if existing.Name == "" {
+			existing.Name = device.Name
+		}
+		if existing.Model == "" {
+			existing.Model = device.Model
+		}
+		if existing.Port == 0 {
+			existing.Port = device.Port
+		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(response)
+	return merged
 }
 
 // maskPIN partially masks the PIN for logging (shows first 2 digits only).
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/firetv"
+	"github.com/pantheon/artemis/middleware"
+)
+
+// FireTVSearchRequest is the body of POST /api/firetv/search.
+type FireTVSearchRequest struct {
+	Host       string `json:"host"`
+	AppPackage string `json:"appPackage"` // App to search in, e.g. "com.netflix.ninja"
+	Query      string `json:"query"`
+
+	// How long to wait for the app to start before navigating to search.
+	// Optional: defaults to FIRETV_SEARCH_LAUNCH_DELAY.
+	LaunchDelayMs *int `json:"launchDelayMs,omitempty"`
+
+	// Fire TV service to use, as for FireTVPairRequest.
+	ServiceIndex *int `json:"serviceIndex,omitempty"`
+}
+
+// FireTVSearchStep is the outcome of one command of a search.
+type FireTVSearchStep struct {
+	Step    int          `json:"step"`              // 1-based
+	Command string       `json:"command"`           // launch_app, a navigation key, keyevent, or text_input
+	Text    string       `json:"text,omitempty"`    // text_input: the chunk sent
+	Keycode int          `json:"keycode,omitempty"` // keyevent: the keycode sent
+	Success bool         `json:"success"`
+	Skipped bool         `json:"skipped,omitempty"` // Not sent: an earlier step failed
+	Message string       `json:"message"`
+	Code    errcode.Code `json:"code,omitempty"` // Machine-readable error code (failed steps only)
+}
+
+// FireTVSearchResponse is the response of POST /api/firetv/search.
+type FireTVSearchResponse struct {
+	Success   bool               `json:"success"` // Whether every step succeeded
+	Message   string             `json:"message"`
+	Profile   string             `json:"profile"` // Navigation profile used, or "generic"
+	Steps     []FireTVSearchStep `json:"steps"`
+	Timestamp string             `json:"timestamp"`
+}
+
+// HandleFireTVSearch opens an app and searches it for a query.
+// POST /api/firetv/search
+// Request body: {"host": "192.168.1.50", "appPackage": "com.netflix.ninja", "query": "The Crown"}
+// Returns: FireTVSearchResponse JSON
+//
+// Launches the app, waits for it to start (launchDelayMs, or launchDelay),
+// navigates to its search box with the app's firetv.SearchProfile (apps
+// without one get KEYCODE_SEARCH), then types the query as text_input
+// commands of firetv.TextChunkSize characters. Each command is a step in
+// the response. Once a step fails the rest are skipped, since keys sent to
+// the wrong screen would only wander around the app. The response is 200
+// whenever the request was valid; "success" says whether every step
+// succeeded.
+func HandleFireTVSearch(firetvClients []*firetv.Client, launchDelay time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req FireTVSearchRequest
+		if err := decodeJSONBody(r, &req); err != nil {
+			log.Printf("❌ Error decoding Fire TV search request: %v", err)
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		req.Query = strings.TrimSpace(req.Query)
+		if req.Host == "" || req.AppPackage == "" || req.Query == "" {
+			sendFireTVError(w, r, http.StatusBadRequest, "host, appPackage, and query are required")
+			return
+		}
+		if utf8.RuneCountInString(req.Query) > firetv.MaxSearchQueryLength {
+			sendFireTVError(w, r, http.StatusBadRequest, fmt.Sprintf("query must be at most %d characters", firetv.MaxSearchQueryLength))
+			return
+		}
+		delay := launchDelay
+		if req.LaunchDelayMs != nil {
+			delay = time.Duration(*req.LaunchDelayMs) * time.Millisecond
+			if delay < 0 || delay > firetv.MaxSearchLaunchDelay {
+				sendFireTVError(w, r, http.StatusBadRequest, fmt.Sprintf("launchDelayMs must be between 0 and %d", firetv.MaxSearchLaunchDelay.Milliseconds()))
+				return
+			}
+		}
+		firetvClient, err := fireTVService(firetvClients, req.Host, req.ServiceIndex)
+		if err != nil {
+			sendFireTVError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		profile := firetv.SearchProfileFor(req.AppPackage)
+		log.Printf("📺 Fire TV search request - Host: %s, App: %s (%s profile), Query: %q - Client: %s",
+			req.Host, req.AppPackage, profile.Name, req.Query, middleware.ClientIP(r))
+
+		steps := fireTVSearchSteps(req, profile)
+		succeeded, failed := 0, false
+		for i := range steps {
+			step := &steps[i]
+			if failed {
+				step.Skipped = true
+				step.Message = "Skipped: an earlier step failed"
+				continue
+			}
+			if i > 0 && !pause(r.Context(), fireTVSearchPause(steps[i-1], delay)) {
+				step.Message = "Cancelled: the request ended before this step"
+				failed = true
+				continue
+			}
+
+			if err := sendFireTVSearchStep(r.Context(), firetvClient, req, step); err != nil {
+				log.Printf("⚠️  Fire TV search step %d (%s) failed: %v", step.Step, step.Command, err)
+				status, message := upstreamStatus(err, http.StatusBadGateway)
+				step.Message = message
+				step.Code = errorCode(err, status)
+				failed = true
+				continue
+			}
+			succeeded++
+		}
+
+		response := FireTVSearchResponse{
+			Success:   succeeded == len(steps),
+			Message:   fmt.Sprintf("Searched %s for %q", req.AppPackage, req.Query),
+			Profile:   profile.Name,
+			Steps:     steps,
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+		if !response.Success {
+			response.Message = fmt.Sprintf("Search stopped: %d of %d step(s) succeeded", succeeded, len(steps))
+		}
+		writeJSON(w, r, http.StatusOK, response)
+	}
+}
+
+// fireTVSearchSteps lists a search's commands: launch the app, navigate to
+// search, then type the query in chunks.
+func fireTVSearchSteps(req FireTVSearchRequest, profile firetv.SearchProfile) []FireTVSearchStep {
+	steps := []FireTVSearchStep{{Command: "launch_app"}}
+	if profile.Keys == nil {
+		steps = append(steps, FireTVSearchStep{Command: firetv.KeyeventCommand, Keycode: firetv.SearchKeycode})
+	}
+	for _, key := range profile.Keys {
+		steps = append(steps, FireTVSearchStep{Command: key})
+	}
+	for _, chunk := range firetv.TextChunks(req.Query, firetv.TextChunkSize) {
+		steps = append(steps, FireTVSearchStep{Command: "text_input", Text: chunk})
+	}
+	for i := range steps {
+		steps[i].Step = i + 1
+	}
+	return steps
+}
+
+// fireTVSearchPause is how long to wait after previous before the next
+// step: the launch delay after launching the app, less between text chunks
+// than between navigation keys.
+func fireTVSearchPause(previous FireTVSearchStep, launchDelay time.Duration) time.Duration {
+	switch previous.Command {
+	case "launch_app":
+		return launchDelay
+	case "text_input":
+		return firetv.TextChunkPause
+	default:
+		return firetv.NavigationPause
+	}
+}
+
+// sendFireTVSearchStep sends one step's command and fills in its result.
+func sendFireTVSearchStep(ctx context.Context, firetvClient *firetv.Client, req FireTVSearchRequest, step *FireTVSearchStep) error {
+	var result *firetv.CommandResponse
+	var err error
+	switch step.Command {
+	case firetv.KeyeventCommand:
+		result, err = firetvClient.SendKeycode(ctx, req.Host, step.Keycode)
+	case "launch_app":
+		result, err = firetvClient.SendCommand(ctx, req.Host, step.Command, "", req.AppPackage)
+	default:
+		result, err = firetvClient.SendCommand(ctx, req.Host, step.Command, step.Text, "")
+	}
+	if err != nil {
+		return err
+	}
+	step.Success = result.Success
+	step.Message = result.Message
+	if !result.Success {
+		return fmt.Errorf("%s", result.Message)
+	}
+	return nil
+}
+
+// pause waits for d, or returns false if ctx ends first.
+func pause(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
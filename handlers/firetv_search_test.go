@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pantheon/artemis/firetv"
+)
+
+// newSearchFireTVService starts a fake Fire TV service that records every
+// command it receives and fails the one named failCommand (if any).
+func newSearchFireTVService(t *testing.T, failCommand string) (*firetv.Client, func() []firetv.CommandRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var sent []firetv.CommandRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req firetv.CommandRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		sent = append(sent, req)
+		mu.Unlock()
+		if req.Command == failCommand {
+			w.Write([]byte(`{"success": false, "message": "Device not paired"}`))
+			return
+		}
+		w.Write([]byte(`{"success": true, "message": "ok"}`))
+	}))
+	t.Cleanup(server.Close)
+	return firetv.NewClient(server.URL), func() []firetv.CommandRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]firetv.CommandRequest(nil), sent...)
+	}
+}
+
+func postFireTVSearch(client *firetv.Client, body string) (*httptest.ResponseRecorder, FireTVSearchResponse) {
+	w := httptest.NewRecorder()
+	HandleFireTVSearch([]*firetv.Client{client}, 0)(w, httptest.NewRequest(http.MethodPost, "/api/firetv/search", strings.NewReader(body)))
+	var resp FireTVSearchResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestFireTVSearch_ProfileSteps(t *testing.T) {
+	client, sent := newSearchFireTVService(t, "")
+
+	w, resp := postFireTVSearch(client, `{"host": "192.168.1.50", "appPackage": "com.netflix.ninja", "query": "The Crown season four", "launchDelayMs": 0}`)
+
+	if w.Code != http.StatusOK || !resp.Success || resp.Profile != "Netflix" {
+		t.Fatalf("expected a successful Netflix search, got %d %+v", w.Code, resp)
+	}
+	// launch_app, 4 navigation keys, then 21 characters in 2 chunks
+	got := sent()
+	if len(got) != 7 || len(resp.Steps) != 7 {
+		t.Fatalf("expected 7 commands, got %d: %+v", len(got), got)
+	}
+	if got[0].Command != "launch_app" || got[0].AppPackage != "com.netflix.ninja" {
+		t.Errorf("expected the app launched first, got %+v", got[0])
+	}
+	if got[4].Command != "select" || got[5].Text != "The Crown season" || got[6].Text != " four" {
+		t.Errorf("expected navigation then the query in chunks, got %+v", got[4:])
+	}
+}
+
+func TestFireTVSearch_GenericProfile(t *testing.T) {
+	client, sent := newSearchFireTVService(t, "")
+
+	_, resp := postFireTVSearch(client, `{"host": "192.168.1.50", "appPackage": "com.example.app", "query": "news"}`)
+
+	got := sent()
+	if resp.Profile != "generic" || len(got) != 3 || got[1].Command != firetv.KeyeventCommand || got[1].Keycode != firetv.SearchKeycode {
+		t.Errorf("expected KEYCODE_SEARCH for an app without a profile, got %s %+v", resp.Profile, got)
+	}
+}
+
+func TestFireTVSearch_SkipsAfterFailure(t *testing.T) {
+	client, sent := newSearchFireTVService(t, "launch_app")
+
+	w, resp := postFireTVSearch(client, `{"host": "192.168.1.50", "appPackage": "com.hulu.plus", "query": "news"}`)
+
+	if w.Code != http.StatusOK || resp.Success {
+		t.Fatalf("expected 200 with success=false, got %d %+v", w.Code, resp)
+	}
+	if len(sent()) != 1 {
+		t.Errorf("expected nothing sent after the failed launch, got %+v", sent())
+	}
+	if resp.Steps[0].Success || resp.Steps[0].Code == "" || !resp.Steps[1].Skipped || !resp.Steps[len(resp.Steps)-1].Skipped {
+		t.Errorf("expected the launch failed with a code and the rest skipped, got %+v", resp.Steps)
+	}
+}
+
+func TestFireTVSearch_Validation(t *testing.T) {
+	client, sent := newSearchFireTVService(t, "")
+
+	for _, body := range []string{
+		`{"host": "192.168.1.50", "appPackage": "com.hulu.plus"}`,
+		`{"host": "192.168.1.50", "query": "news"}`,
+		`{"host": "192.168.1.50", "appPackage": "com.hulu.plus", "query": "` + strings.Repeat("a", 101) + `"}`,
+		`{"host": "192.168.1.50", "appPackage": "com.hulu.plus", "query": "news", "launchDelayMs": 60000}`,
+	} {
+		if w, _ := postFireTVSearch(client, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+	if len(sent()) != 0 {
+		t.Errorf("expected nothing sent for invalid requests, got %+v", sent())
+	}
+}
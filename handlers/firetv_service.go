@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pantheon/artemis/firetv"
+)
+
+// FireTVServiceRestartResponse is the response for POST /api/firetv/service/restart.
+type FireTVServiceRestartResponse struct {
+	Success   bool                          `json:"success"`   // Whether every targeted service is healthy after the reset
+	Services  []firetv.ServiceRestartResult `json:"services"`  // One result per targeted service
+	Message   string                        `json:"message"`   // Status message for the UI
+	Timestamp string                        `json:"timestamp"` // When the response was generated
+}
+
+// HandleFireTVServiceRestart resets the Python Fire TV service and reports
+// its health afterwards.
+// POST /api/firetv/service/restart[?serviceIndex=<n>]
+//
+// For when pairing or commands hang: the service drops its pairing sessions
+// and remote connections, then Artemis waits (up to ~15s) for /health to
+// pass. Resets every configured service unless serviceIndex picks one.
+// Answers 200 when every targeted service is healthy afterwards, 501 when
+// none of them has a /reset endpoint, and 502 otherwise, with per-service
+// results in each case.
+func HandleFireTVServiceRestart(firetvClients []*firetv.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests.
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		indexes := make([]int, len(firetvClients))
+		for i := range firetvClients {
+			indexes[i] = i
+		}
+		if indexStr := r.URL.Query().Get("serviceIndex"); indexStr != "" {
+			index, err := strconv.Atoi(indexStr)
+			if err != nil || index < 0 || index >= len(firetvClients) {
+				sendFireTVError(w, r, http.StatusBadRequest, fmt.Sprintf("serviceIndex must be between 0 and %d", len(firetvClients)-1))
+				return
+			}
+			indexes = []int{index}
+		}
+
+		// Reset the services concurrently so each one's health wait doesn't
+		// add up.
+		results := make([]firetv.ServiceRestartResult, len(indexes))
+		errs := make([]error, len(indexes))
+		var wg sync.WaitGroup
+		for i, index := range indexes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], errs[i] = firetvClients[index].RestartService(r.Context())
+				results[i].ServiceIndex = index
+			}()
+		}
+		wg.Wait()
+
+		healthy, unsupported := 0, 0
+		for i, result := range results {
+			switch {
+			case result.Healthy:
+				healthy++
+			case errors.Is(errs[i], firetv.ErrResetUnsupported):
+				unsupported++
+			default:
+				log.Printf("❌ Fire TV service #%d restart failed: %s", result.ServiceIndex, result.Error)
+			}
+		}
+
+		response := FireTVServiceRestartResponse{
+			Success:   healthy == len(results),
+			Services:  results,
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+		status := http.StatusOK
+		switch {
+		case response.Success:
+			response.Message = fmt.Sprintf("Reset %d Fire TV service(s); all healthy", len(results))
+		case unsupported == len(results):
+			status = http.StatusNotImplemented
+			response.Message = "This Fire TV service version can't be reset remotely — restart it on the host instead"
+		default:
+			status = http.StatusBadGateway
+			response.Message = fmt.Sprintf("%d of %d Fire TV service(s) healthy after reset", healthy, len(results))
+		}
+
+		writeJSON(w, r, status, response)
+	}
+}
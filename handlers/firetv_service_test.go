@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pantheon/artemis/firetv"
+)
+
+// newResettableFireTVService starts a fake Python Fire TV service that
+// answers /health, and /reset when withReset is set.
+func newResettableFireTVService(t *testing.T, withReset bool) *firetv.Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "healthy"}`))
+	})
+	if withReset {
+		mux.HandleFunc("POST /reset", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"success": true, "message": "Cleared 1 pairing session(s)"}`))
+		})
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return firetv.NewClient(server.URL)
+}
+
+func TestFireTVServiceRestart_ResetsAndChecksHealth(t *testing.T) {
+	clients := []*firetv.Client{newResettableFireTVService(t, true), newResettableFireTVService(t, true)}
+
+	w := httptest.NewRecorder()
+	HandleFireTVServiceRestart(clients)(w, httptest.NewRequest(http.MethodPost, "/api/firetv/service/restart?serviceIndex=1", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp FireTVServiceRestartResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.Success || len(resp.Services) != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	result := resp.Services[0]
+	if result.ServiceIndex != 1 || !result.Reset || !result.Healthy || result.Message != "Cleared 1 pairing session(s)" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestFireTVServiceRestart_ServiceWithoutReset(t *testing.T) {
+	clients := []*firetv.Client{newResettableFireTVService(t, false)}
+
+	w := httptest.NewRecorder()
+	HandleFireTVServiceRestart(clients)(w, httptest.NewRequest(http.MethodPost, "/api/firetv/service/restart", nil))
+
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected status 501, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestFireTVServiceRestart_UnreachableService(t *testing.T) {
+	clients := []*firetv.Client{newResettableFireTVService(t, true), firetv.NewClient(closedPortURL(t))}
+
+	w := httptest.NewRecorder()
+	HandleFireTVServiceRestart(clients)(w, httptest.NewRequest(http.MethodPost, "/api/firetv/service/restart", nil))
+
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("expected status 502, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp FireTVServiceRestartResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Success || len(resp.Services) != 2 || !resp.Services[0].Healthy || resp.Services[1].Reset || resp.Services[1].Error == "" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestFireTVServiceRestart_InvalidServiceIndex(t *testing.T) {
+	clients := []*firetv.Client{newResettableFireTVService(t, true)}
+
+	w := httptest.NewRecorder()
+	HandleFireTVServiceRestart(clients)(w, httptest.NewRequest(http.MethodPost, "/api/firetv/service/restart?serviceIndex=3", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pantheon/artemis/firetv"
+)
+
+// newStubFireTVService starts a fake Python Fire TV service that answers
+// GET /discover with the given JSON body, and returns a client pointed at it.
+func newStubFireTVService(t *testing.T, discoverBody string) *firetv.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(discoverBody))
+	}))
+	t.Cleanup(server.Close)
+	return firetv.NewClient(server.URL)
+}
+
+// =============================================================================
+// GET /api/firetv/discover — Discovery
+// =============================================================================
+
+func TestFireTVDiscover_CollapsesDuplicateHosts(t *testing.T) {
+	// The same TV (192.168.1.50) is reported twice on different ports, once
+	// without a name and once without a model, as happens with multi-homed hosts.
+	client := newStubFireTVService(t, `{
+		"success": true,
+		"message": "Found 3 device(s)",
+		"devices": [
+			{"name": "", "host": "192.168.1.50", "port": 6466, "model": "AFTMM"},
+			{"name": "Bedroom Fire TV", "host": "192.168.1.60", "port": 6466},
+			{"name": "Living Room Fire TV", "host": "192.168.1.50", "port": 6467}
+		]
+	}`)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/firetv/discover", nil)
+	w := httptest.NewRecorder()
+	HandleFireTVDiscover([]*firetv.Client{client})(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp firetv.DiscoverResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+
+	if len(resp.Devices) != 2 {
+		t.Fatalf("expected 2 devices after de-duplication, got %d: %+v", len(resp.Devices), resp.Devices)
+	}
+
+	// Order follows first appearance: .50 then .60
+	first := resp.Devices[0]
+	if first.Host != "192.168.1.50" {
+		t.Errorf("expected first device host 192.168.1.50, got %s", first.Host)
+	}
+	if first.Name != "Living Room Fire TV" {
+		t.Errorf("expected merged name 'Living Room Fire TV', got '%s'", first.Name)
+	}
+	if first.Model != "AFTMM" {
+		t.Errorf("expected merged model 'AFTMM', got '%s'", first.Model)
+	}
+	if first.Port != 6466 {
+		t.Errorf("expected port from first sighting (6466), got %d", first.Port)
+	}
+	if resp.Devices[1].Host != "192.168.1.60" {
+		t.Errorf("expected second device host 192.168.1.60, got %s", resp.Devices[1].Host)
+	}
+	if resp.Message != "Found 2 device(s)" {
+		t.Errorf("expected message to reflect de-duplicated count, got '%s'", resp.Message)
+	}
+}
+
+func TestFireTVDiscover_NoDuplicatesUnchanged(t *testing.T) {
+	client := newStubFireTVService(t, `{
+		"success": true,
+		"message": "Found 1 device(s)",
+		"devices": [{"name": "Den", "host": "10.0.0.5", "port": 6466}]
+	}`)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/firetv/discover", nil)
+	w := httptest.NewRecorder()
+	HandleFireTVDiscover([]*firetv.Client{client})(w, req)
+
+	var resp firetv.DiscoverResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+
+	if len(resp.Devices) != 1 || resp.Devices[0].Name != "Den" {
+		t.Errorf("expected single device 'Den' unchanged, got %+v", resp.Devices)
+	}
+	if resp.Message != "Found 1 device(s)" {
+		t.Errorf("expected original message preserved, got '%s'", resp.Message)
+	}
+}
+
+// newRecordingFireTVService is like newStubFireTVService but also records the
+// raw query string of each /discover call so tests can check passthrough.
+func newRecordingFireTVService(t *testing.T, discoverBody string) (*firetv.Client, *string) {
+	t.Helper()
+	var lastQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(discoverBody))
+	}))
+	t.Cleanup(server.Close)
+	return firetv.NewClient(server.URL), &lastQuery
+}
+
+func TestFireTVDiscover_PassesThroughClampedOptions(t *testing.T) {
+	client, lastQuery := newRecordingFireTVService(t, `{"success": true, "message": "Found 0 device(s)", "devices": []}`)
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"", ""},
+		{"?timeout=2.5&max=3", "max=3&timeout=2.5"},
+		{"?timeout=0.1", "timeout=1"},  // Clamped up to the minimum
+		{"?timeout=600", "timeout=30"}, // Clamped down to the maximum
+		{"?max=0", "max=1"},
+		{"?max=5000", "max=100"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/firetv/discover"+tt.query, nil)
+		w := httptest.NewRecorder()
+		HandleFireTVDiscover([]*firetv.Client{client})(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("%q: expected status 200, got %d", tt.query, w.Code)
+			continue
+		}
+		if *lastQuery != tt.expected {
+			t.Errorf("%q: expected service query %q, got %q", tt.query, tt.expected, *lastQuery)
+		}
+	}
+}
+
+func TestFireTVDiscover_RejectsNonNumericOptions(t *testing.T) {
+	client, _ := newRecordingFireTVService(t, `{"success": true, "devices": []}`)
+
+	for _, query := range []string{"?timeout=soon", "?max=lots", "?timeout=NaN"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/firetv/discover"+query, nil)
+		w := httptest.NewRecorder()
+		HandleFireTVDiscover([]*firetv.Client{client})(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestFireTVDiscover_TruncatesToMax(t *testing.T) {
+	// The service ignores max and returns everything it found.
+	client := newStubFireTVService(t, `{
+		"success": true,
+		"message": "Found 3 device(s)",
+		"devices": [
+			{"name": "A", "host": "10.0.0.1", "port": 6466},
+			{"name": "B", "host": "10.0.0.2", "port": 6466},
+			{"name": "C", "host": "10.0.0.3", "port": 6466}
+		]
+	}`)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/firetv/discover?max=2", nil)
+	w := httptest.NewRecorder()
+	HandleFireTVDiscover([]*firetv.Client{client})(w, req)
+
+	var resp firetv.DiscoverResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+
+	if len(resp.Devices) != 2 || resp.Devices[1].Name != "B" {
+		t.Errorf("expected first 2 devices, got %+v", resp.Devices)
+	}
+	if !resp.Truncated {
+		t.Error("expected truncated=true")
+	}
+
+	// Without a limit nothing is truncated.
+	req = httptest.NewRequest(http.MethodGet, "/api/firetv/discover", nil)
+	w = httptest.NewRecorder()
+	HandleFireTVDiscover([]*firetv.Client{client})(w, req)
+
+	resp = firetv.DiscoverResponse{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Devices) != 3 || resp.Truncated {
+		t.Errorf("expected all 3 devices untruncated, got %d (truncated=%v)", len(resp.Devices), resp.Truncated)
+	}
+}
+
+// =============================================================================
+// POST /api/firetv/command — Raw keycodes
+// =============================================================================
+
+func TestFireTVCommand_ProxiesRawKeycode(t *testing.T) {
+	var sent firetv.CommandRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"success": true, "message": "Sent keycode 85", "command": "keyevent"}`))
+	}))
+	defer server.Close()
+
+	body := `{"host": "192.168.1.50", "keycode": 85}`
+	w := httptest.NewRecorder()
+	HandleFireTVCommand([]*firetv.Client{firetv.NewClient(server.URL)}, true)(w, httptest.NewRequest(http.MethodPost, "/api/firetv/command", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if sent.Command != firetv.KeyeventCommand || sent.Keycode != 85 || sent.Host != "192.168.1.50" {
+		t.Errorf("unexpected request to the service: %+v", sent)
+	}
+}
+
+func TestFireTVCommand_RejectsRawKeycodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		allow    bool
+		body     string
+		expected int
+	}{
+		{"disabled", false, `{"host": "192.168.1.50", "keycode": 85}`, http.StatusForbidden},
+		{"zero", true, `{"host": "192.168.1.50", "keycode": 0}`, http.StatusBadRequest},
+		{"above range", true, `{"host": "192.168.1.50", "keycode": 5000}`, http.StatusBadRequest},
+		{"with command", true, `{"host": "192.168.1.50", "command": "home", "keycode": 3}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newStubFireTVService(t, `{}`)
+			w := httptest.NewRecorder()
+			HandleFireTVCommand([]*firetv.Client{client}, tt.allow)(w, httptest.NewRequest(http.MethodPost, "/api/firetv/command", strings.NewReader(tt.body)))
+
+			if w.Code != tt.expected {
+				t.Errorf("expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// =============================================================================
+// Multiple Fire TV services
+// =============================================================================
+
+func TestFireTVDiscover_MergesServicesAndReportsFailures(t *testing.T) {
+	first := newStubFireTVService(t, `{"success": true, "devices": [{"name": "Living Room", "host": "192.168.1.50", "port": 6466}]}`)
+	second := newStubFireTVService(t, `{"success": true, "devices": [{"name": "Garage", "host": "192.168.20.7", "port": 6466}]}`)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "scan failed", http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	w := httptest.NewRecorder()
+	HandleFireTVDiscover([]*firetv.Client{first, firetv.NewClient(failing.URL), second})(w, httptest.NewRequest(http.MethodGet, "/api/firetv/discover", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp firetv.DiscoverResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+
+	if len(resp.Devices) != 2 {
+		t.Fatalf("expected 2 devices, got %+v", resp.Devices)
+	}
+	if resp.Devices[0].ServiceIndex != 0 || resp.Devices[1].ServiceIndex != 2 {
+		t.Errorf("expected service indexes 0 and 2, got %d and %d", resp.Devices[0].ServiceIndex, resp.Devices[1].ServiceIndex)
+	}
+	if len(resp.ServiceErrors) != 1 || !strings.HasPrefix(resp.ServiceErrors[0], "service 1 ") {
+		t.Errorf("expected one error for service 1, got %v", resp.ServiceErrors)
+	}
+}
+
+func TestFireTVDiscover_FailsWhenEveryServiceFails(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "scan failed", http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	w := httptest.NewRecorder()
+	HandleFireTVDiscover([]*firetv.Client{firetv.NewClient(failing.URL), firetv.NewClient(failing.URL)})(w, httptest.NewRequest(http.MethodGet, "/api/firetv/discover", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestFireTVCommand_RoutesToService(t *testing.T) {
+	// newCountingService returns a client whose service counts commands.
+	newCountingService := func(count *int, subnets ...string) *firetv.Client {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*count++
+			w.Write([]byte(`{"success": true, "message": "Sent command: home", "command": "home"}`))
+		}))
+		t.Cleanup(server.Close)
+		client := firetv.NewClient(server.URL)
+		if err := client.SetSubnets(subnets); err != nil {
+			t.Fatalf("SetSubnets: %v", err)
+		}
+		return client
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int // Index of the service that should get the command
+	}{
+		{"explicit index", `{"host": "192.168.1.50", "command": "home", "serviceIndex": 1}`, 1},
+		{"subnet match", `{"host": "192.168.20.7", "command": "home"}`, 1},
+		{"no match falls back to first", `{"host": "10.0.0.5", "command": "home"}`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counts := make([]int, 2)
+			clients := []*firetv.Client{
+				newCountingService(&counts[0], "192.168.1.0/24"),
+				newCountingService(&counts[1], "192.168.20.0/24"),
+			}
+
+			w := httptest.NewRecorder()
+			HandleFireTVCommand(clients, false)(w, httptest.NewRequest(http.MethodPost, "/api/firetv/command", strings.NewReader(tt.body)))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if counts[tt.expected] != 1 || counts[1-tt.expected] != 0 {
+				t.Errorf("expected the command to reach service %d only, got counts %v", tt.expected, counts)
+			}
+		})
+	}
+}
+
+func TestFireTVCommand_RejectsInvalidServiceIndex(t *testing.T) {
+	client := newStubFireTVService(t, `{}`)
+	body := `{"host": "192.168.1.50", "command": "home", "serviceIndex": 3}`
+	w := httptest.NewRecorder()
+	HandleFireTVCommand([]*firetv.Client{client}, false)(w, httptest.NewRequest(http.MethodPost, "/api/firetv/command", strings.NewReader(body)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestFireTVCommand_ServiceNotRunning(t *testing.T) {
+	body := `{"host": "192.168.1.50", "command": "home"}`
+	w := httptest.NewRecorder()
+	HandleFireTVCommand([]*firetv.Client{firetv.NewClient(closedPortURL(t))}, false)(w, httptest.NewRequest(http.MethodPost, "/api/firetv/command", strings.NewReader(body)))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp FireTVCommandResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !strings.Contains(resp.Message, "Fire TV service is not running") {
+		t.Errorf("unexpected message %q", resp.Message)
+	}
+}
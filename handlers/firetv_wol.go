@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/firetv"
+	"github.com/pantheon/artemis/middleware"
+)
+
+// FireTVWakeRequest is the request body for POST /api/firetv/wol.
+// Give either mac, or name to use the MAC address stored on the registered
+// fire_tv device with that name.
+type FireTVWakeRequest struct {
+	MAC       string `json:"mac,omitempty"`       // e.g. "AA:BB:CC:DD:EE:FF"
+	Name      string `json:"name,omitempty"`      // Registered device name, e.g. "Living Room TV"
+	Broadcast string `json:"broadcast,omitempty"` // "host[:port]"; default 255.255.255.255:9
+}
+
+// FireTVWakeResponse reports whether the magic packet was sent.
+type FireTVWakeResponse struct {
+	Success   bool         `json:"success"`           // Whether the packet left the server
+	Message   string       `json:"message"`           // Status message for the UI
+	MAC       string       `json:"mac,omitempty"`     // MAC address the packet was for
+	Address   string       `json:"address,omitempty"` // Where the packet was sent
+	Timestamp string       `json:"timestamp"`         // When the response was generated
+	Code      errcode.Code `json:"code,omitempty"`    // Machine-readable error code (errors only)
+}
+
+// HandleFireTVWakeOnLAN wakes a Fire TV with a Wake-on-LAN magic packet,
+// sent over UDP by this server (the Fire TV service isn't involved).
+// Useful when the TV is asleep too deeply for the remote protocol's power
+// command to reach it.
+// POST /api/firetv/wol
+// Body: {"mac": "AA:BB:CC:DD:EE:FF"} or {"name": "Living Room TV"},
+// optionally with "broadcast" (e.g. "192.168.1.255") for a specific subnet
+//
+// success=true means the packet was sent; UDP can't confirm the TV woke up.
+func HandleFireTVWakeOnLAN(database *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests.
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req FireTVWakeRequest
+		if err := decodeJSONBody(r, &req); err != nil {
+			sendFireTVWakeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		macAddress := req.MAC
+		switch {
+		case req.MAC != "" && req.Name != "":
+			sendFireTVWakeError(w, r, http.StatusBadRequest, "send either mac or name, not both")
+			return
+		case req.Name != "":
+			var status int
+			var err error
+			if macAddress, status, err = lookupFireTVMAC(database, req.Name); err != nil {
+				sendFireTVWakeError(w, r, status, err.Error())
+				return
+			}
+		case req.MAC == "":
+			sendFireTVWakeError(w, r, http.StatusBadRequest, "mac or name is required")
+			return
+		}
+
+		mac, err := firetv.ParseMAC(macAddress)
+		if err != nil {
+			sendFireTVWakeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		log.Printf("📺 Fire TV Wake-on-LAN request - MAC: %s - Client: %s", mac, middleware.ClientIP(r))
+
+		address, err := firetv.WakeOnLAN(r.Context(), mac, req.Broadcast)
+		if err != nil {
+			log.Printf("❌ Fire TV Wake-on-LAN failed: %v", err)
+			sendFireTVWakeError(w, r, http.StatusBadGateway, err.Error())
+			return
+		}
+
+		writeJSON(w, r, http.StatusOK, FireTVWakeResponse{
+			Success:   true,
+			Message:   "Sent Wake-on-LAN packet",
+			MAC:       strings.ToUpper(mac.String()),
+			Address:   address,
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+	}
+}
+
+// lookupFireTVMAC finds the MAC address stored on the registered fire_tv
+// device named name (case-insensitive). On failure it returns the HTTP
+// status to answer with.
+func lookupFireTVMAC(database *sql.DB, name string) (string, int, error) {
+	devices, err := db.ListDevicesByType(database, "fire_tv")
+	if err != nil {
+		log.Printf("❌ Fire TV Wake-on-LAN: failed to list devices: %v", err)
+		return "", http.StatusInternalServerError, fmt.Errorf("Failed to look up device")
+	}
+
+	var matches []db.Device
+	for _, device := range devices {
+		if strings.EqualFold(device.Name, name) {
+			matches = append(matches, device)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return "", http.StatusNotFound, fmt.Errorf("No Fire TV named %q is registered", name)
+	case len(matches) > 1:
+		return "", http.StatusConflict, fmt.Errorf("%d Fire TVs are named %q — send mac instead", len(matches), name)
+	}
+
+	mac := deviceMACAddress(matches[0])
+	if mac == "" {
+		return "", http.StatusBadRequest, fmt.Errorf("Fire TV %q has no MAC address — set macAddress on the device first", name)
+	}
+	return mac, http.StatusOK, nil
+}
+
+// sendFireTVWakeError sends a JSON error response for the Wake-on-LAN endpoint.
+func sendFireTVWakeError(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	writeJSON(w, r, statusCode, FireTVWakeResponse{
+		Success:   false,
+		Message:   message,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Code:      errcode.ForStatus(statusCode),
+	})
+}
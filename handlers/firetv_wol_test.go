@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/firetv"
+)
+
+// listenForMagicPacket starts a UDP listener on localhost standing in for
+// the broadcast address, and returns its address and a channel that
+// receives the first packet.
+func listenForMagicPacket(t *testing.T) (string, <-chan []byte) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	packets := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 256)
+		n, _, err := conn.ReadFrom(buf)
+		if err == nil {
+			packets <- buf[:n]
+		}
+	}()
+	return conn.LocalAddr().String(), packets
+}
+
+func TestFireTVWakeOnLAN(t *testing.T) {
+	_, database, profile, _ := setupTestDeviceHandler(t)
+	tv, _ := db.CreateDevice(database, profile.ID, "Living Room TV", "fire_tv", nil, nil)
+	metadata := `{"macAddress": "AA:BB:CC:DD:EE:FF"}`
+	db.UpdateDeviceMetadata(database, tv.ID, &metadata)
+	db.CreateDevice(database, profile.ID, "Bedroom TV", "fire_tv", nil, nil) // No MAC
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"by MAC", `{"mac": "aa:bb:cc:dd:ee:ff"}`, http.StatusOK},
+		{"by name", `{"name": "living room tv"}`, http.StatusOK},
+		{"invalid MAC", `{"mac": "AA:BB:CC"}`, http.StatusBadRequest},
+		{"neither", `{}`, http.StatusBadRequest},
+		{"both", `{"mac": "AA:BB:CC:DD:EE:FF", "name": "Living Room TV"}`, http.StatusBadRequest},
+		{"unknown name", `{"name": "Garage TV"}`, http.StatusNotFound},
+		{"name without a MAC", `{"name": "Bedroom TV"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, packets := listenForMagicPacket(t)
+
+			// Point the packet at the local listener instead of the broadcast address
+			var body map[string]interface{}
+			json.Unmarshal([]byte(tt.body), &body)
+			body["broadcast"] = address
+			encoded, _ := json.Marshal(body)
+
+			w := httptest.NewRecorder()
+			HandleFireTVWakeOnLAN(database)(w, httptest.NewRequest(http.MethodPost, "/api/firetv/wol", strings.NewReader(string(encoded))))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp FireTVWakeResponse
+			json.NewDecoder(w.Body).Decode(&resp)
+			if !resp.Success || resp.MAC != "AA:BB:CC:DD:EE:FF" || resp.Address != address {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+
+			mac, _ := firetv.ParseMAC("AA:BB:CC:DD:EE:FF")
+			select {
+			case packet := <-packets:
+				if !bytes.Equal(packet, firetv.MagicPacket(mac)) {
+					t.Errorf("expected a magic packet for AA:BB:CC:DD:EE:FF, got % x", packet)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("no packet received")
+			}
+		})
+	}
+}
+
+func TestMagicPacket(t *testing.T) {
+	mac, _ := firetv.ParseMAC("01:02:03:04:05:06")
+	packet := firetv.MagicPacket(mac)
+
+	if len(packet) != 102 {
+		t.Fatalf("expected 102 bytes, got %d", len(packet))
+	}
+	if !bytes.Equal(packet[:6], bytes.Repeat([]byte{0xFF}, 6)) {
+		t.Errorf("expected six 0xFF bytes first, got % x", packet[:6])
+	}
+	for i := 6; i < len(packet); i += 6 {
+		if !bytes.Equal(packet[i:i+6], mac) {
+			t.Fatalf("expected the MAC repeated at offset %d, got % x", i, packet[i:i+6])
+		}
+	}
+}
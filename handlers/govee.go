@@ -1,13 +1,20 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/events"
 	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/middleware"
+	"github.com/pantheon/artemis/upstream"
 )
 
 // DeviceResponse represents a simplified device for the frontend
@@ -19,6 +26,17 @@ type DeviceResponse struct {
 	Type         string   `json:"type"`         // Device type (e.g., "light")
 	Capabilities []string `json:"capabilities"` // Supported commands
 	APIKeyIndex  int      `json:"apiKeyIndex"`  // Which API key owns this device (0 = primary, 1 = secondary)
+
+	// Capabilities as flags, so the app can disable controls (e.g., the
+	// color wheel) without knowing Govee's command names.
+	CanToggle    bool `json:"canToggle"`    // Supports "turn"
+	CanDim       bool `json:"canDim"`       // Supports "brightness"
+	CanColor     bool `json:"canColor"`     // Supports "color"
+	CanColorTemp bool `json:"canColorTemp"` // Supports "colorTem"
+
+	// Valid color temperature range in Kelvin, so the app can set slider bounds.
+	// Only present for devices that support the "colorTem" command.
+	ColorTemRange *govee.ColorTemRange `json:"colorTemRange,omitempty"`
 }
 
 // ControlRequest represents a device control request from the frontend
@@ -26,12 +44,57 @@ type DeviceResponse struct {
 // - "turn": value should be boolean (true = on, false = off)
 // - "brightness": value should be number 0-100
 // - "color": value should be object with r, g, b fields (each 0-255)
+// - "colorTem": value should be a Kelvin number within the model's colorTemRange
 type ControlRequest struct {
 	DeviceID    string      `json:"deviceId"`    // Device MAC address
 	Model       string      `json:"model"`       // Device model (needed for some commands)
-	Command     string      `json:"command"`     // Command type: "turn", "brightness", "color"
+	Command     string      `json:"command"`     // Command type: "turn", "brightness", "color", "colorTem"
 	Value       interface{} `json:"value"`       // Command value (type depends on command)
 	APIKeyIndex int         `json:"apiKeyIndex"` // Which API key owns this device (0 = primary, 1 = secondary)
+
+	// Optional fade duration for "brightness" and "color" (milliseconds, max 10000).
+	// Fades are emulated by stepping the value, since the Govee v1 API has no
+	// transition parameter for any model.
+	TransitionMs int `json:"transitionMs,omitempty"`
+
+	// Optional time limit for the whole command (milliseconds, clamped to the
+	// Govee client's 10s request timeout). A command that runs out of time
+	// answers 504 with timedOut=true. Omit for the full client timeout.
+	TimeoutMs int `json:"timeoutMs,omitempty"`
+
+	// Keep the device's brightness when setting "color", for bulbs that
+	// reset it along with the color (see govee.Client.SetColorKeepBrightness).
+	// Not combinable with transitionMs.
+	PreserveBrightness bool `json:"preserveBrightness,omitempty"`
+}
+
+// CommandFailedEvent is the data of a "device.command_failed" event,
+// published when a control command fails and isn't queued for retry.
+type CommandFailedEvent struct {
+	APIKeyIndex int         `json:"apiKeyIndex"`
+	DeviceID    string      `json:"deviceId"`
+	Model       string      `json:"model"`
+	Command     string      `json:"command"`
+	Value       interface{} `json:"value"`
+	Error       string      `json:"error"`
+	TimedOut    bool        `json:"timedOut"` // The device didn't answer in time
+}
+
+// publishCommandFailed publishes a "device.command_failed" event, if there's
+// a broker to publish on.
+func publishCommandFailed(deviceEvents *events.Broker, req ControlRequest, err error) {
+	if deviceEvents == nil {
+		return
+	}
+	deviceEvents.Publish("device.command_failed", CommandFailedEvent{
+		APIKeyIndex: req.APIKeyIndex,
+		DeviceID:    req.DeviceID,
+		Model:       req.Model,
+		Command:     req.Command,
+		Value:       req.Value,
+		Error:       err.Error(),
+		TimedOut:    govee.IsTimeoutError(err),
+	})
 }
 
 // ControlResponse represents the response after controlling a device
@@ -40,10 +103,34 @@ type ControlResponse struct {
 	Message   string `json:"message"`   // Success or error message
 	DeviceID  string `json:"deviceId"`  // Which device was controlled
 	Timestamp string `json:"timestamp"` // When the command was executed
+
+	// True when the device was offline and the command was queued for retry
+	// (see govee.RetryQueue). The final outcome arrives as a
+	// "device.command_retry" event on the device event stream.
+	Queued bool `json:"queued,omitempty"`
+
+	// True when a newer command of the same kind for the same device
+	// replaced this one before it was sent (see govee.Coalescer). Nothing
+	// went wrong; the newer value is the one applied.
+	Coalesced bool `json:"coalesced,omitempty"`
+
+	// True when the command ran out of time (see ControlRequest.TimeoutMs)
+	TimedOut bool `json:"timedOut,omitempty"`
+
+	// Commands sent, in order, for a color set with preserveBrightness
+	// (e.g. ["color 255,0,0", "brightness 40"])
+	Steps []string `json:"steps,omitempty"`
+
+	// Known devices closest to an unknown deviceId (404 only)
+	Suggestions []DeviceSuggestion `json:"suggestions,omitempty"`
+
+	// Machine-readable error code when the command failed (see the errcode
+	// package), e.g. "DEVICE_OFFLINE" or "GOVEE_RATE_LIMITED"
+	Code errcode.Code `json:"code,omitempty"`
 }
 
 // RGBValue represents an RGB color from the frontend
-// Used when command is "color"
+// Used when command is "color", and for the color in StateResponse
 type RGBValue struct {
 	R int `json:"r"` // Red (0-255)
 	G int `json:"g"` // Green (0-255)
@@ -53,7 +140,21 @@ type RGBValue struct {
 // HandleGetDevices returns all Govee devices from all configured API keys
 // GET /api/govee/devices
 // Returns: JSON array of DeviceResponse objects from both primary and secondary accounts
-func HandleGetDevices(goveeClients []*govee.Client) http.HandlerFunc {
+//
+// Device names that appear in more than one account get that account's
+// label (see AccountLabels) so the app can tell them apart.
+//
+// Each account's list is served from the client's cache while it is younger
+// than the cache TTL (see govee.Client.GetDevicesCached); ?fresh=true
+// fetches new lists from Govee and refreshes the cache.
+//
+// ?capability=color keeps only devices supporting that command; a
+// comma-separated list (capability=color,brightness) requires all of them.
+//
+// ?groupBy=type|account|room returns a GroupedDevicesResponse instead, with
+// devices nested under group keys. Rooms come from registered devices (see
+// groupDevices); devices in no room are grouped under "Unassigned".
+func HandleGetDevices(goveeClients []*govee.Client, accountLabels AccountLabels, database *sql.DB, listOpts ListOptions) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Only accept GET requests
 		if r.Method != http.MethodGet {
@@ -61,14 +162,27 @@ func HandleGetDevices(goveeClients []*govee.Client) http.HandlerFunc {
 			return
 		}
 
-		log.Printf("💡 Fetching Govee devices from %d account(s) - Client: %s", len(goveeClients), r.RemoteAddr)
+		groupBy := r.URL.Query().Get("groupBy")
+		if groupBy != "" && !isValidGroupBy(groupBy) {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid groupBy %q — must be 'type', 'account', or 'room'", groupBy))
+			return
+		}
+
+		fresh := r.URL.Query().Get("fresh") == "true"
+		capabilities := capabilityFilter(r)
+
+		log.Printf("💡 Fetching Govee devices from %d account(s) - Client: %s", len(goveeClients), middleware.ClientIP(r))
 
-		// Collect all devices from all API keys
-		var allDevices []DeviceResponse
+		// Collect all devices from all API keys (empty array instead of null)
+		allDevices := []DeviceResponse{}
 
 		// Fetch devices from each API key
 		for apiKeyIndex, client := range goveeClients {
-			devices, err := client.GetDevices()
+			fetch := client.GetDevicesCached
+			if fresh {
+				fetch = client.GetDevices
+			}
+			devices, err := fetch(r.Context())
 			if err != nil {
 				log.Printf("❌ Error fetching devices from API key #%d: %v", apiKeyIndex, err)
 				// Continue with other API keys even if one fails
@@ -79,26 +193,56 @@ func HandleGetDevices(goveeClients []*govee.Client) http.HandlerFunc {
 
 			// Transform and tag each device with its API key index
 			for _, device := range devices {
-				allDevices = append(allDevices, DeviceResponse{
-					ID:           device.Device,
-					Name:         device.DeviceName,
-					Model:        device.Model,
-					Type:         "light", // Most Govee devices are lights
-					Capabilities: device.SupportCmds,
-					APIKeyIndex:  apiKeyIndex, // Track which API key owns this device
-				})
+				if !supportsAll(device, capabilities) {
+					continue
+				}
+				allDevices = append(allDevices, newDeviceResponse(client, apiKeyIndex, device))
 			}
 		}
 
+		accountLabels.apply(allDevices)
+
 		log.Printf("💡 Returning %d total device(s) to client", len(allDevices))
 
-		// Send JSON response
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(allDevices); err != nil {
-			log.Printf("❌ Error encoding response: %v", err)
+		if groupBy != "" {
+			grouped, err := groupDevices(allDevices, groupBy, accountLabels, database)
+			if err != nil {
+				log.Printf("❌ Device grouping: %v", err)
+				writeError(w, r, http.StatusInternalServerError, "Failed to load room membership")
+				return
+			}
+			writeList(w, r, listOpts, grouped, fmt.Sprintf("Found %d device(s) in %d group(s)", grouped.Total, len(grouped.Groups)))
+			return
 		}
+
+		// Send JSON response
+		writeList(w, r, listOpts, allDevices, fmt.Sprintf("Found %d device(s)", len(allDevices)))
+	}
+}
+
+// newDeviceResponse converts a Govee device into the frontend format,
+// tagged with the API key that owns it.
+func newDeviceResponse(client *govee.Client, apiKeyIndex int, device govee.Device) DeviceResponse {
+	deviceResp := DeviceResponse{
+		ID:           device.Device,
+		Name:         device.DeviceName,
+		Model:        device.Model,
+		Type:         "light", // Most Govee devices are lights
+		Capabilities: device.SupportCmds,
+		APIKeyIndex:  apiKeyIndex, // Track which API key owns this device
+		CanToggle:    supportsCommand(device, "turn"),
+		CanDim:       supportsCommand(device, "brightness"),
+		CanColor:     supportsCommand(device, "color"),
+		CanColorTemp: supportsCommand(device, "colorTem"),
+	}
+
+	// Include the Kelvin range for color-temperature capable devices
+	if deviceResp.CanColorTemp {
+		colorTemRange := client.ColorTemRange(device.Model)
+		deviceResp.ColorTemRange = &colorTemRange
 	}
+
+	return deviceResp
 }
 
 // HandleControlDevice processes device control requests from the frontend
@@ -106,12 +250,29 @@ func HandleGetDevices(goveeClients []*govee.Client) http.HandlerFunc {
 // Accepts: ControlRequest JSON body
 // Returns: ControlResponse JSON
 //
-// The handler routes commands to the appropriate Govee client method:
+// The handler routes commands (via govee.ExecuteCommand) to the appropriate Govee client method:
 // - "turn": Calls TurnOn or TurnOff based on boolean value
 // - "brightness": Calls SetBrightness with integer value (0-100)
 // - "color": Calls SetColor with RGB values from object
+// - "colorTem": Calls SetColorTemperature with integer Kelvin
 // Uses the apiKeyIndex from the request to select the correct API key
-func HandleControlDevice(goveeClients []*govee.Client) http.HandlerFunc {
+//
+// A "color" with preserveBrightness reads the brightness, sets the color,
+// and puts the brightness back if the color changed it (see
+// govee.ExecuteColorKeepBrightness); steps lists the commands sent.
+//
+// If retryQueue is non-nil and Govee reports the device offline, the command
+// is queued for retry and the handler answers 202 with queued=true.
+// Successful commands are recorded in optimistic (if non-nil) so the state
+// endpoint has something to show for devices that can't report their state.
+//
+// If coalescer is non-nil, "brightness" and "color" commands go through it:
+// bursts (e.g., a slider being dragged) are merged so only the latest value
+// is sent, and replaced commands answer 200 with coalesced=true.
+//
+// Commands that fail (and aren't queued) are published on deviceEvents, if
+// non-nil, as a "device.command_failed" CommandFailedEvent.
+func HandleControlDevice(goveeClients []*govee.Client, retryQueue *govee.RetryQueue, optimistic *govee.OptimisticStates, coalescer *govee.Coalescer, deviceEvents *events.Broker) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Only accept POST requests
 		if r.Method != http.MethodPost {
@@ -121,128 +282,348 @@ func HandleControlDevice(goveeClients []*govee.Client) http.HandlerFunc {
 
 		// Parse the request body
 		var req ControlRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := decodeJSONBody(r, &req); err != nil {
 			log.Printf("❌ Error decoding control request: %v", err)
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			writeError(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		log.Printf("💡 Control request - Device: %s, Command: %s, API Key Index: %d - Client: %s",
-			req.DeviceID, req.Command, req.APIKeyIndex, r.RemoteAddr)
+		controlDevice(w, r, req, goveeClients, retryQueue, optimistic, coalescer, deviceEvents)
+	}
+}
 
-		// Validate API key index
-		if req.APIKeyIndex < 0 || req.APIKeyIndex >= len(goveeClients) {
-			log.Printf("❌ Invalid API key index: %d (have %d clients)", req.APIKeyIndex, len(goveeClients))
-			sendErrorResponse(w, req.DeviceID, "Invalid API key index")
-			return
-		}
+// controlDevice runs a decoded control request and writes the response.
+// Shared by the query-style and path-style control endpoints.
+func controlDevice(w http.ResponseWriter, r *http.Request, req ControlRequest, goveeClients []*govee.Client, retryQueue *govee.RetryQueue, optimistic *govee.OptimisticStates, coalescer *govee.Coalescer, deviceEvents *events.Broker) {
+	status, response := runControl(r, req, goveeClients, retryQueue, optimistic, coalescer, deviceEvents)
+	writeJSON(w, r, status, response)
+}
 
-		// Select the correct client based on API key index
-		goveeClient := goveeClients[req.APIKeyIndex]
+// runControl runs a decoded control request and returns the status and
+// response to answer with. Shared by controlDevice and the batch endpoint.
+func runControl(r *http.Request, req ControlRequest, goveeClients []*govee.Client, retryQueue *govee.RetryQueue, optimistic *govee.OptimisticStates, coalescer *govee.Coalescer, deviceEvents *events.Broker) (int, ControlResponse) {
+	log.Printf("💡 Control request - Device: %s, Command: %s, API Key Index: %d - Client: %s",
+		req.DeviceID, req.Command, req.APIKeyIndex, middleware.ClientIP(r))
 
-		// Execute the appropriate command based on command type
-		var err error
-		switch req.Command {
-		case "turn":
-			// Value should be boolean
-			isOn, ok := req.Value.(bool)
-			if !ok {
-				sendErrorResponse(w, req.DeviceID, "Invalid value for 'turn' command - expected boolean")
-				return
-			}
+	// Validate API key index
+	if req.APIKeyIndex < 0 || req.APIKeyIndex >= len(goveeClients) {
+		log.Printf("❌ Invalid API key index: %d (have %d clients)", req.APIKeyIndex, len(goveeClients))
+		return http.StatusBadRequest, controlErrorResponse(req.DeviceID, "Invalid API key index")
+	}
+
+	if req.TimeoutMs < 0 {
+		return http.StatusBadRequest, controlErrorResponse(req.DeviceID, "timeoutMs must not be negative")
+	}
+
+	if req.PreserveBrightness && req.Command != "color" {
+		return http.StatusBadRequest, controlErrorResponse(req.DeviceID, "preserveBrightness only applies to the color command")
+	}
+	if req.PreserveBrightness && req.TransitionMs > 0 {
+		return http.StatusBadRequest, controlErrorResponse(req.DeviceID, "preserveBrightness can't be combined with transitionMs")
+	}
 
-			if isOn {
-				err = goveeClient.TurnOn(req.DeviceID, req.Model)
-			} else {
-				err = goveeClient.TurnOff(req.DeviceID, req.Model)
+	// Select the correct client based on API key index
+	goveeClient := goveeClients[req.APIKeyIndex]
+
+	ctx, cancel := govee.CommandContext(r.Context(), time.Duration(req.TimeoutMs)*time.Millisecond)
+	defer cancel()
+
+	// Catch mistyped device IDs, wrong models and unsupported commands
+	// before Govee answers with a generic error, and accept IDs in any case
+	// or separator style
+	var device *govee.Device
+	var err error
+	if goveeClient.DeviceCheck() {
+		device, err = checkDeviceID(ctx, goveeClient, req.APIKeyIndex, req.DeviceID)
+	}
+	var unknown *unknownDeviceError
+	if errors.As(err, &unknown) {
+		log.Printf("❌ %v", err)
+		return http.StatusNotFound, ControlResponse{
+			Success:     false,
+			Message:     err.Error(),
+			DeviceID:    req.DeviceID,
+			Timestamp:   time.Now().Format(time.RFC3339),
+			Suggestions: unknown.suggestions,
+			Code:        errcode.DeviceNotFound,
+		}
+	}
+	if device != nil {
+		if err := govee.CheckDeviceCommand(*device, req.Model, req.Command); err != nil {
+			log.Printf("❌ %v", err)
+			return controlFailure(req.DeviceID, err)
+		}
+		req.DeviceID = device.Device
+		if req.Model == "" {
+			req.Model = device.Model
+		}
+	}
+
+	// Execute the command through the shared control path
+	// (the MQTT bridge uses the same function, so behavior is identical)
+	transition := time.Duration(req.TransitionMs) * time.Millisecond
+	var update govee.ColorUpdate
+	send := func() error {
+		if req.PreserveBrightness {
+			// Devices that can't report state fall back to the brightness
+			// last set through Artemis
+			var lastKnown *int
+			if optimistic != nil {
+				if state, ok := optimistic.Get(req.APIKeyIndex, req.DeviceID); ok {
+					lastKnown = state.Brightness
+				}
 			}
+			var err error
+			update, err = govee.ExecuteColorKeepBrightness(ctx, goveeClient, req.DeviceID, req.Model, req.Value, device == nil || device.Retrievable, lastKnown)
+			return err
+		}
+		return govee.ExecuteCommand(ctx, goveeClient, req.DeviceID, req.Model, req.Command, req.Value, transition)
+	}
 
-		case "brightness":
-			// Value should be number (will come as float64 from JSON)
-			brightness, ok := req.Value.(float64)
-			if !ok {
-				sendErrorResponse(w, req.DeviceID, "Invalid value for 'brightness' command - expected number")
-				return
+	// Merge slider bursts so Govee only sees the latest value
+	if coalescer != nil && (req.Command == "brightness" || req.Command == "color") {
+		var coalesced bool
+		coalesced, err = coalescer.Do(req.APIKeyIndex, req.DeviceID, req.Command, send)
+		if coalesced {
+			return http.StatusOK, ControlResponse{
+				Success:   true,
+				Message:   "Superseded by a newer " + req.Command + " command",
+				DeviceID:  req.DeviceID,
+				Timestamp: time.Now().Format(time.RFC3339),
+				Coalesced: true,
 			}
+		}
+	} else {
+		err = send()
+	}
 
-			err = goveeClient.SetBrightness(req.DeviceID, req.Model, int(brightness))
+	// Queue commands for offline devices instead of dropping them
+	if err != nil && retryQueue != nil && govee.IsOfflineError(err) {
+		retryQueue.Enqueue(req.APIKeyIndex, req.DeviceID, req.Model, req.Command, req.Value)
+		return http.StatusAccepted, ControlResponse{
+			Success:   false,
+			Message:   "Device is offline — command queued and will be retried when it's reachable",
+			DeviceID:  req.DeviceID,
+			Timestamp: time.Now().Format(time.RFC3339),
+			Queued:    true,
+			Code:      errcode.DeviceOffline,
+		}
+	}
 
-		case "color":
-			// Value should be object with r, g, b fields
-			// JSON unmarshals objects as map[string]interface{}
-			colorMap, ok := req.Value.(map[string]interface{})
-			if !ok {
-				sendErrorResponse(w, req.DeviceID, "Invalid value for 'color' command - expected object with r, g, b")
-				return
+	// Check if command execution failed
+	if err != nil {
+		log.Printf("❌ Error executing command: %v", err)
+		publishCommandFailed(deviceEvents, req, err)
+		if govee.IsTimeoutError(err) {
+			return http.StatusGatewayTimeout, ControlResponse{
+				Success:   false,
+				Message:   "Device didn't respond in time: " + err.Error(),
+				DeviceID:  req.DeviceID,
+				Timestamp: time.Now().Format(time.RFC3339),
+				TimedOut:  true,
+				Code:      errcode.UpstreamTimeout,
 			}
+		}
+		return controlFailure(req.DeviceID, err)
+	}
+
+	if optimistic != nil {
+		optimistic.Record(req.APIKeyIndex, req.DeviceID, req.Model, req.Command, req.Value)
+	}
+	// The device now has the user's latest command; an older one waiting
+	// for it to come back online must not replay over it
+	retryQueue.Forget(req.APIKeyIndex, req.DeviceID)
+
+	// Send success response
+	message := "Device controlled successfully"
+	if transition > 0 && (req.Command == "brightness" || req.Command == "color") {
+		message = fmt.Sprintf("Fading %s over %dms", req.Command, min(transition, govee.MaxTransition).Milliseconds())
+	}
+	if req.PreserveBrightness {
+		switch {
+		case update.Brightness == nil:
+			message = "Color set; brightness unknown, so it couldn't be preserved"
+		case update.Restored:
+			message = fmt.Sprintf("Color set, brightness restored to %d", *update.Brightness)
+		default:
+			message = fmt.Sprintf("Color set, brightness kept at %d", *update.Brightness)
+		}
+	}
+	response := ControlResponse{
+		Success:   true,
+		Message:   message,
+		DeviceID:  req.DeviceID,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Steps:     update.Steps,
+	}
 
-			// Extract RGB values (they come as float64 from JSON)
-			r, okR := colorMap["r"].(float64)
-			g, okG := colorMap["g"].(float64)
-			b, okB := colorMap["b"].(float64)
+	log.Printf("✅ Control command successful - Device: %s, Command: %s", req.DeviceID, req.Command)
 
-			if !okR || !okG || !okB {
-				sendErrorResponse(w, req.DeviceID, "Color object must have r, g, b numeric fields")
-				return
-			}
+	return http.StatusOK, response
+}
 
-			err = goveeClient.SetColor(req.DeviceID, req.Model, int(r), int(g), int(b))
+// ResetRequest identifies the device to reset
+type ResetRequest struct {
+	DeviceID    string `json:"deviceId"`    // Device MAC address
+	Model       string `json:"model"`       // Device model number
+	APIKeyIndex int    `json:"apiKeyIndex"` // Which API key owns this device (0 = primary, 1 = secondary)
+}
 
-		default:
-			sendErrorResponse(w, req.DeviceID, "Unknown command: "+req.Command)
+// ResetResponse reports the outcome of a reset sequence
+type ResetResponse struct {
+	Success   bool               `json:"success"`          // Whether every step succeeded
+	Message   string             `json:"message"`          // Success or error message
+	DeviceID  string             `json:"deviceId"`         // Which device was reset
+	Steps     []string           `json:"steps"`            // Commands sent, in order
+	Before    *govee.DeviceState `json:"before,omitempty"` // State captured before the reset (if readable)
+	State     *govee.DeviceState `json:"state,omitempty"`  // Final state after the reset (if readable)
+	Timestamp string             `json:"timestamp"`        // When the reset finished
+}
+
+// HandleResetDevice returns a device stuck in a scene/effect/music mode to
+// plain static control
+// POST /api/govee/devices/reset
+// Accepts: ResetRequest JSON body
+// Returns: ResetResponse JSON with the steps taken and the final state
+//
+// The sequence (power-cycle, re-apply static color, restore brightness) is
+// implemented by govee.Client.ResetDevice. It takes a few seconds because
+// the device needs time to settle between commands.
+func HandleResetDevice(goveeClients []*govee.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse the request body
+		var req ResetRequest
+		if err := decodeJSONBody(r, &req); err != nil {
+			log.Printf("❌ Error decoding reset request: %v", err)
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if req.DeviceID == "" || req.Model == "" {
+			sendErrorResponse(w, r, req.DeviceID, "deviceId and model are required")
+			return
+		}
+
+		// Validate API key index
+		if req.APIKeyIndex < 0 || req.APIKeyIndex >= len(goveeClients) {
+			log.Printf("❌ Invalid API key index: %d (have %d clients)", req.APIKeyIndex, len(goveeClients))
+			sendErrorResponse(w, r, req.DeviceID, "Invalid API key index")
 			return
 		}
 
-		// Check if command execution failed
+		log.Printf("💡 Reset request - Device: %s, API Key Index: %d - Client: %s",
+			req.DeviceID, req.APIKeyIndex, middleware.ClientIP(r))
+
+		result, err := goveeClients[req.APIKeyIndex].ResetDevice(r.Context(), req.DeviceID, req.Model)
 		if err != nil {
-			log.Printf("❌ Error executing command: %v", err)
-			sendErrorResponse(w, req.DeviceID, err.Error())
+			log.Printf("❌ Error resetting device: %v", err)
+			sendControlError(w, r, req.DeviceID, err)
 			return
 		}
 
-		// Send success response
-		response := ControlResponse{
+		// The client doesn't know which account it belongs to — fill it in.
+		for _, state := range []*govee.DeviceState{result.Before, result.After} {
+			if state != nil {
+				state.APIKeyIndex = req.APIKeyIndex
+			}
+		}
+
+		response := ResetResponse{
 			Success:   true,
-			Message:   "Device controlled successfully",
+			Message:   "Device reset to static control",
 			DeviceID:  req.DeviceID,
+			Steps:     result.Steps,
+			Before:    result.Before,
+			State:     result.After,
 			Timestamp: time.Now().Format(time.RFC3339),
 		}
 
-		log.Printf("✅ Control command successful - Device: %s, Command: %s", req.DeviceID, req.Command)
+		writeJSON(w, r, http.StatusOK, response)
+	}
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.Printf("❌ Error encoding response: %v", err)
+// supportsCommand reports whether a device lists cmd in its SupportCmds
+func supportsCommand(device govee.Device, cmd string) bool {
+	for _, supported := range device.SupportCmds {
+		if supported == cmd {
+			return true
 		}
 	}
+	return false
 }
 
 // sendErrorResponse is a helper function to send error responses
 // Encapsulates the common error response pattern
-func sendErrorResponse(w http.ResponseWriter, deviceID, message string) {
-	response := ControlResponse{
+func sendErrorResponse(w http.ResponseWriter, r *http.Request, deviceID, message string) {
+	writeJSON(w, r, http.StatusBadRequest, controlErrorResponse(deviceID, message))
+}
+
+// controlErrorResponse is the ControlResponse of an invalid control request.
+func controlErrorResponse(deviceID, message string) ControlResponse {
+	return ControlResponse{
 		Success:   false,
 		Message:   message,
 		DeviceID:  deviceID,
 		Timestamp: time.Now().Format(time.RFC3339),
+		Code:      errcode.InvalidRequest,
 	}
+}
+
+// sendControlError sends the error of a failed Govee call like
+// sendErrorResponse, but with a distinct status when Govee couldn't be
+// reached (see upstreamStatus) and a code saying what went wrong (see
+// errorCode).
+func sendControlError(w http.ResponseWriter, r *http.Request, deviceID string, err error) {
+	status, response := controlFailure(deviceID, err)
+	writeJSON(w, r, status, response)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusBadRequest)
-	json.NewEncoder(w).Encode(response)
+// controlFailure is the status and ControlResponse of a failed Govee call,
+// as sent by sendControlError.
+func controlFailure(deviceID string, err error) (int, ControlResponse) {
+	status, message := upstreamStatus(err, http.StatusBadRequest)
+	return status, ControlResponse{
+		Success:   false,
+		Message:   message,
+		DeviceID:  deviceID,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Code:      errorCode(err, status),
+	}
 }
 
 // StateResponse represents the simplified device state for the frontend
+// Brightness, Color, and ColorTem are omitted when the device doesn't report
+// them, so the app can keep its sliders where they are instead of resetting
+// them. A light in white mode has a ColorTem and no Color.
 type StateResponse struct {
-	DeviceID string `json:"deviceId"` // Device MAC address
-	IsOn     bool   `json:"isOn"`     // Whether device is currently on
+	DeviceID   string    `json:"deviceId"`             // Device MAC address
+	IsOn       bool      `json:"isOn"`                 // Whether device is currently on
+	Brightness *int      `json:"brightness,omitempty"` // 0-100
+	Color      *RGBValue `json:"color,omitempty"`      // Current RGB color
+	ColorTem   *int      `json:"colorTem,omitempty"`   // Current color temperature in Kelvin
+	Source     string    `json:"source"`               // Where the state came from: "cache" (state poller), "live" (fresh Govee read), or "optimistic" (last commands sent)
 }
 
 // HandleGetDeviceState queries the current state of a specific device
-// GET /api/govee/devices/state?deviceId=X&model=Y&apiKeyIndex=Z
-// Returns: StateResponse JSON with current on/off state
-func HandleGetDeviceState(goveeClients []*govee.Client) http.HandlerFunc {
+// GET /api/govee/devices/state?deviceId=X&model=Y&apiKeyIndex=Z[&fresh=true]
+// Returns: StateResponse JSON with current on/off state, and brightness and
+// color when the device reports them
+//
+// When the background state poller is enabled (statePoller != nil), the state
+// is served from its shared cache and only read from Govee on a cache miss.
+// Pass fresh=true to bypass the cache and force a live read (the result is
+// still written back to the cache for other callers).
+//
+// When the live read fails for a device Govee can't read state from (but not
+// one that's offline), the state implied by the last successful commands is
+// served instead, with source "optimistic". A successful read discards it.
+func HandleGetDeviceState(goveeClients []*govee.Client, statePoller *govee.StatePoller, optimistic *govee.OptimisticStates) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Only accept GET requests
 		if r.Method != http.MethodGet {
@@ -253,71 +634,106 @@ func HandleGetDeviceState(goveeClients []*govee.Client) http.HandlerFunc {
 		// Parse query parameters
 		deviceID := r.URL.Query().Get("deviceId")
 		model := r.URL.Query().Get("model")
+		forceFresh := r.URL.Query().Get("fresh") == "true"
 		apiKeyIndex := 0 // Default to primary
 
-		// Parse apiKeyIndex if provided
+		// Parse apiKeyIndex if provided. Atoi rejects trailing garbage ("1abc")
+		// that would otherwise silently target account 1.
 		if apiKeyIndexStr := r.URL.Query().Get("apiKeyIndex"); apiKeyIndexStr != "" {
 			var err error
-			if _, err = fmt.Sscanf(apiKeyIndexStr, "%d", &apiKeyIndex); err != nil {
-				http.Error(w, "Invalid apiKeyIndex", http.StatusBadRequest)
+			if apiKeyIndex, err = strconv.Atoi(apiKeyIndexStr); err != nil {
+				writeError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid apiKeyIndex %q: must be an integer", apiKeyIndexStr))
 				return
 			}
 		}
 
 		// Validate parameters
 		if deviceID == "" || model == "" {
-			http.Error(w, "Missing deviceId or model parameter", http.StatusBadRequest)
+			writeError(w, r, http.StatusBadRequest, "Missing deviceId or model parameter")
 			return
 		}
 
 		// Validate API key index
 		if apiKeyIndex < 0 || apiKeyIndex >= len(goveeClients) {
-			http.Error(w, "Invalid API key index", http.StatusBadRequest)
+			log.Printf("❌ Invalid API key index: %d (have %d clients)", apiKeyIndex, len(goveeClients))
+			writeError(w, r, http.StatusBadRequest, "Invalid API key index")
 			return
 		}
 
-		// Get the appropriate client
-		client := goveeClients[apiKeyIndex]
+		serveDeviceState(w, r, goveeClients, statePoller, optimistic, apiKeyIndex, deviceID, model, forceFresh)
+	}
+}
 
-		// Query device state
-		stateResp, err := client.GetDeviceState(deviceID, model)
-		if err != nil {
-			log.Printf("❌ Error querying device state: %v", err)
-			http.Error(w, "Failed to query device state", http.StatusInternalServerError)
-			return
-		}
+// serveDeviceState looks up a device's state (cache, live read, or optimistic
+// guess) and writes the StateResponse. Shared by the query-style and
+// path-style state endpoints.
+func serveDeviceState(w http.ResponseWriter, r *http.Request, goveeClients []*govee.Client, statePoller *govee.StatePoller, optimistic *govee.OptimisticStates, apiKeyIndex int, deviceID, model string, forceFresh bool) {
+	// Serve from the shared cache when possible, otherwise read live
+	var state govee.DeviceState
+	source := "live"
+	cached := false
+	if statePoller != nil && !forceFresh {
+		state, cached = statePoller.Get(apiKeyIndex, deviceID)
+	}
 
-		// Extract power state from properties
-		// The Govee API returns properties as an array of objects with varying keys
-		// Common keys: "online" (bool), "powerState" (string "on"/"off"), "brightness" (int)
-		isOn := false
-		for _, prop := range stateResp.Data.Properties {
-			// Check for "online" property (boolean)
-			if onlineVal, exists := prop["online"]; exists {
-				if boolVal, ok := onlineVal.(bool); ok {
-					isOn = boolVal
-					break
-				}
-			}
-			// Check for "powerState" property (string)
-			if powerStateVal, exists := prop["powerState"]; exists {
-				if strVal, ok := powerStateVal.(string); ok {
-					isOn = (strVal == "on")
-					break
+	if cached {
+		source = "cache"
+	} else {
+		var err error
+		state, err = readDeviceState(r.Context(), goveeClients, statePoller, apiKeyIndex, deviceID, model)
+		if err != nil {
+			guess, found := optimisticState(optimistic, apiKeyIndex, deviceID, err)
+			if !found {
+				log.Printf("❌ Error querying device state: %v", err)
+				status, message := http.StatusInternalServerError, "Failed to query device state"
+				if classified, ok := upstream.As(err); ok {
+					status, message = classified.HTTPStatus(), classified.Message()
 				}
+				writeErrorCode(w, r, status, errorCode(err, status), message)
+				return
 			}
+			state, source = guess, "optimistic"
+		} else if optimistic != nil {
+			optimistic.Clear(apiKeyIndex, deviceID)
 		}
+	}
 
-		// Send simplified response
-		response := StateResponse{
-			DeviceID: deviceID,
-			IsOn:     isOn,
-		}
+	// Send simplified response
+	response := StateResponse{
+		DeviceID:   deviceID,
+		IsOn:       state.IsOn(),
+		Brightness: state.Brightness,
+		ColorTem:   state.ColorTem,
+		Source:     source,
+	}
+	if state.Color != nil {
+		response.Color = &RGBValue{R: state.Color.R, G: state.Color.G, B: state.Color.B}
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.Printf("❌ Error encoding response: %v", err)
-		}
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+// optimisticState returns the optimistic state to serve after a failed live
+// read. Offline devices get none: their last commands say nothing about
+// what they're doing now.
+func optimisticState(optimistic *govee.OptimisticStates, apiKeyIndex int, deviceID string, readErr error) (govee.DeviceState, bool) {
+	if optimistic == nil || govee.IsOfflineError(readErr) {
+		return govee.DeviceState{}, false
+	}
+	return optimistic.Get(apiKeyIndex, deviceID)
+}
+
+// readDeviceState performs a live state read from Govee.
+// Goes through the state poller when one is configured so the fresh result
+// is shared with other callers; otherwise queries the client directly.
+func readDeviceState(ctx context.Context, goveeClients []*govee.Client, statePoller *govee.StatePoller, apiKeyIndex int, deviceID, model string) (govee.DeviceState, error) {
+	if statePoller != nil {
+		return statePoller.Refresh(ctx, apiKeyIndex, deviceID, model)
+	}
+
+	stateResp, err := goveeClients[apiKeyIndex].GetDeviceState(ctx, deviceID, model)
+	if err != nil {
+		return govee.DeviceState{}, err
 	}
+	return govee.NormalizeState(stateResp, apiKeyIndex), nil
 }
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pantheon/artemis/events"
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/middleware"
+)
+
+// maxBatchCommands is how many commands one batch control request may hold.
+const maxBatchCommands = 50
+
+// batchWorkers is how many commands of a batch run at once. The Govee
+// client's rate limit (and strict-serial mode, if on) still apply on top.
+const batchWorkers = 4
+
+// BatchControlResult is the outcome of one command of a batch, in request
+// order. Status is the HTTP status the command would have answered with on
+// its own.
+type BatchControlResult struct {
+	Status int `json:"status"`
+	ControlResponse
+}
+
+// BatchControlResponse reports the outcome of every command of a batch.
+type BatchControlResponse struct {
+	Success   bool                 `json:"success"` // Whether every command succeeded
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Results   []BatchControlResult `json:"results"` // Same order as the request
+	Timestamp string               `json:"timestamp"`
+}
+
+// HandleControlDevicesBatch runs several control commands in one request,
+// e.g. turning off every light at once.
+// POST /api/govee/devices/control/batch
+// Body: JSON array of ControlRequest (at most 50)
+// Returns: BatchControlResponse
+//
+// Each command goes through the same path as POST /api/govee/devices/control
+// (retry queue, optimistic state, coalescing, failure events), with up to
+// batchWorkers running at a time. A failed command doesn't stop the others:
+// the batch answers 200 and each result carries its own success, status and
+// error code.
+func HandleControlDevicesBatch(goveeClients []*govee.Client, retryQueue *govee.RetryQueue, optimistic *govee.OptimisticStates, coalescer *govee.Coalescer, deviceEvents *events.Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var reqs []ControlRequest
+		if err := decodeJSONBody(r, &reqs); err != nil {
+			log.Printf("❌ Error decoding batch control request: %v", err)
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if len(reqs) == 0 {
+			writeError(w, r, http.StatusBadRequest, "Provide at least one command")
+			return
+		}
+		if len(reqs) > maxBatchCommands {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("A batch may hold at most %d commands, got %d", maxBatchCommands, len(reqs)))
+			return
+		}
+
+		log.Printf("💡 Batch control request - %d command(s) - Client: %s", len(reqs), middleware.ClientIP(r))
+
+		results := make([]BatchControlResult, len(reqs))
+		slots := make(chan struct{}, batchWorkers)
+		var wg sync.WaitGroup
+		for i, req := range reqs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				slots <- struct{}{}
+				defer func() { <-slots }()
+
+				status, response := runControl(r, req, goveeClients, retryQueue, optimistic, coalescer, deviceEvents)
+				results[i] = BatchControlResult{Status: status, ControlResponse: response}
+			}()
+		}
+		wg.Wait()
+
+		response := BatchControlResponse{
+			Results:   results,
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+		for _, result := range results {
+			if result.Success {
+				response.Succeeded++
+			} else {
+				response.Failed++
+			}
+		}
+		response.Success = response.Failed == 0
+
+		log.Printf("✅ Batch control: %d succeeded, %d failed", response.Succeeded, response.Failed)
+		writeJSON(w, r, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pantheon/artemis/govee"
+)
+
+func TestControlDevicesBatch(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"code": 200, "data": {"devices": [
+				{"device": "AA:01", "model": "H6008", "deviceName": "Desk Lamp"},
+				{"device": "AA:02", "model": "H6008", "deviceName": "Floor Lamp"}
+			]}}`))
+			return
+		}
+		var req govee.ControlRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		sent = append(sent, req.Device)
+		mu.Unlock()
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	}))
+	t.Cleanup(server.Close)
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(server.URL)
+
+	// The middle command names an account that doesn't exist.
+	body := `[
+		{"deviceId": "AA:01", "model": "H6008", "command": "turn", "value": false},
+		{"deviceId": "AA:03", "model": "H6008", "command": "turn", "value": false, "apiKeyIndex": 5},
+		{"deviceId": "AA:02", "model": "H6008", "command": "turn", "value": false}
+	]`
+	w := httptest.NewRecorder()
+	HandleControlDevicesBatch([]*govee.Client{client}, nil, nil, nil, nil)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control/batch", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp BatchControlResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Success || resp.Succeeded != 2 || resp.Failed != 1 || len(resp.Results) != 3 {
+		t.Fatalf("unexpected summary: %+v", resp)
+	}
+	for i, deviceID := range []string{"AA:01", "AA:03", "AA:02"} {
+		if resp.Results[i].DeviceID != deviceID {
+			t.Errorf("result %d: expected device %s, got %s", i, deviceID, resp.Results[i].DeviceID)
+		}
+	}
+	if r := resp.Results[0]; !r.Success || r.Status != http.StatusOK {
+		t.Errorf("expected the first command to succeed, got %+v", r)
+	}
+	if r := resp.Results[1]; r.Success || r.Status != http.StatusBadRequest || r.Message != "Invalid API key index" {
+		t.Errorf("expected the second command to fail on its API key index, got %+v", r)
+	}
+	if r := resp.Results[2]; !r.Success || r.Status != http.StatusOK {
+		t.Errorf("expected the third command to succeed, got %+v", r)
+	}
+
+	slices.Sort(sent)
+	if !slices.Equal(sent, []string{"AA:01", "AA:02"}) {
+		t.Errorf("expected commands sent to AA:01 and AA:02, got %v", sent)
+	}
+}
+
+func TestControlDevicesBatch_Size(t *testing.T) {
+	handler := HandleControlDevicesBatch(nil, nil, nil, nil, nil)
+
+	tooMany := "[" + strings.Repeat(`{"deviceId": "AA:01", "command": "turn", "value": false},`, maxBatchCommands) +
+		`{"deviceId": "AA:01", "command": "turn", "value": false}]`
+	for name, body := range map[string]string{"empty": "[]", "too many": tooMany, "not an array": `{"deviceId": "AA:01"}`} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control/batch", strings.NewReader(body)))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/middleware"
+)
+
+// DeviceCapabilitiesResponse compares what a device advertises with what
+// probing its model found.
+type DeviceCapabilitiesResponse struct {
+	DeviceID    string   `json:"deviceId"`
+	Model       string   `json:"model"`
+	Retrievable bool     `json:"retrievable"` // Only retrievable devices can be probed
+	Advertised  []string `json:"advertised"`  // supportCmds from the device list
+
+	// What probing this model has found so far; nil until it's been probed.
+	Verification *govee.CapabilityCheck `json:"verification,omitempty"`
+
+	// Verification is the result of earlier probes — nothing was sent for
+	// this request.
+	Cached bool `json:"cached,omitempty"`
+
+	Timestamp string `json:"timestamp"`
+}
+
+// HandleDeviceCapabilities reports the commands a device advertises and,
+// optionally, which ones Govee actually accepts for its model.
+// GET /api/govee/devices/{id}/capabilities[?apiKeyIndex=Z][&verify=true][&refresh=true]
+// Returns: DeviceCapabilitiesResponse JSON
+//
+// Without verify, earlier results for the model are included if there are
+// any. With verify=true the device is probed by re-sending its current
+// values (see govee.Client.ProbeCommands) unless its model already has a
+// complete result; refresh=true probes anyway. Results are merged per
+// model in cache. Only devices that can report their state are probed
+// (400 otherwise), so a probe can be checked and undone.
+func HandleDeviceCapabilities(goveeClients []*govee.Client, cache *govee.CapabilityCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept GET requests
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		device, apiKeyIndex, status, err := resolveDevicePath(r, goveeClients)
+		if err != nil {
+			writeErrorFrom(w, r, status, err)
+			return
+		}
+
+		query := r.URL.Query()
+		verify, _ := strconv.ParseBool(query.Get("verify"))
+		refresh, _ := strconv.ParseBool(query.Get("refresh"))
+
+		response := DeviceCapabilitiesResponse{
+			DeviceID:    device.Device,
+			Model:       device.Model,
+			Retrievable: device.Retrievable,
+			Advertised:  device.SupportCmds,
+		}
+		if response.Advertised == nil {
+			response.Advertised = []string{}
+		}
+
+		cached, ok := cache.Get(device.Model)
+		switch {
+		case ok && (!verify || (cached.Complete && !refresh)):
+			response.Verification, response.Cached = &cached, true
+
+		case verify:
+			if !device.Retrievable {
+				writeError(w, r, http.StatusBadRequest, "This device can't report its state, so its commands can't be safely probed")
+				return
+			}
+
+			log.Printf("🔬 Capability check request - Device: %s, Model: %s - Client: %s", device.Device, device.Model, middleware.ClientIP(r))
+			check, err := goveeClients[apiKeyIndex].ProbeCommands(r.Context(), device.Device, device.Model, device.SupportCmds)
+			if err != nil {
+				log.Printf("❌ Capability check failed for %s: %v", device.Device, err)
+				status, message := upstreamStatus(err, http.StatusBadGateway)
+				writeError(w, r, status, message)
+				return
+			}
+			merged := cache.Merge(*check)
+			merged.Restored = check.Restored
+			response.Verification = &merged
+		}
+
+		response.Timestamp = time.Now().Format(time.RFC3339)
+		writeJSON(w, r, http.StatusOK, response)
+	}
+}
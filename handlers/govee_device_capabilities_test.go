@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pantheon/artemis/govee"
+)
+
+func TestDeviceCapabilities(t *testing.T) {
+	// The stub devices (AA:01, AA:02) can't report state, so can't be probed.
+	stub := newGoveeStub(t, pathAccounts...)
+	clients := stub.clients
+	cache := govee.NewCapabilityCache()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/govee/devices/{id}/capabilities", HandleDeviceCapabilities(clients, cache))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/api/govee/devices/AA:01/capabilities")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp DeviceCapabilitiesResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Model != "H6008" || len(resp.Advertised) != 3 || resp.Verification != nil {
+		t.Errorf("expected the H6008's three advertised commands and no verification, got %+v", resp)
+	}
+
+	if w := get("/api/govee/devices/AA:01/capabilities?verify=true"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 probing a non-retrievable device, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := get("/api/govee/devices/ZZ:99/capabilities"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown device, got %d", w.Code)
+	}
+
+	// Earlier results for the model are served without probing.
+	cache.Merge(govee.CapabilityCheck{Model: "H6008", Probes: []govee.CommandProbe{
+		{Command: "turn", Advertised: true, Result: govee.ProbeVerified},
+		{Command: "color", Advertised: true, Result: govee.ProbeRejected},
+	}})
+	w = get("/api/govee/devices/AA:01/capabilities?verify=true")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	resp = DeviceCapabilitiesResponse{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.Cached || resp.Verification == nil || len(resp.Verification.Discrepancies) != 1 {
+		t.Errorf("expected the cached check with one discrepancy, got %+v", resp)
+	}
+
+	if len(stub.commands()) != 0 {
+		t.Errorf("expected no commands to be sent, got %v", stub.commands())
+	}
+}
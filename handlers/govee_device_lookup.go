@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/pantheon/artemis/govee"
+)
+
+// maxDeviceSuggestions is how many known devices an unknown-device error
+// suggests.
+const maxDeviceSuggestions = 3
+
+// DeviceSuggestion is a known device offered in place of an unknown one.
+type DeviceSuggestion struct {
+	DeviceID string `json:"deviceId"`
+	Name     string `json:"name"`
+	Model    string `json:"model"`
+}
+
+// unknownDeviceError means an account's device list doesn't have the device
+// a request named.
+type unknownDeviceError struct {
+	deviceID    string
+	apiKeyIndex int
+	suggestions []DeviceSuggestion
+}
+
+func (e *unknownDeviceError) Error() string {
+	message := fmt.Sprintf("device %s not found in account %d", e.deviceID, e.apiKeyIndex)
+	if len(e.suggestions) == 0 {
+		return message
+	}
+	names := make([]string, len(e.suggestions))
+	for i, s := range e.suggestions {
+		names[i] = fmt.Sprintf("%s (%s)", s.DeviceID, s.Name)
+	}
+	return message + " — did you mean " + strings.Join(names, ", ") + "?"
+}
+
+// checkDeviceID looks deviceID up in the account's cached device list,
+// ignoring case and separators, so a mistyped ID gets a clear error instead
+// of Govee's generic one. Returns the listed device, or an
+// *unknownDeviceError with the closest matches. When the list can't be
+// loaded the check is skipped: it returns nil and no error, and the command
+// is sent as given.
+//
+// The list is the client's cached one (see govee.Client.GetDevicesCached).
+// Listing devices with ?fresh=true refreshes it, so a newly added device is
+// found at once.
+func checkDeviceID(ctx context.Context, client *govee.Client, apiKeyIndex int, deviceID string) (*govee.Device, error) {
+	devices, err := client.GetDevicesCached(ctx)
+	if err != nil {
+		log.Printf("⚠️  Couldn't check device %s against API key #%d's device list: %v", deviceID, apiKeyIndex, err)
+		return nil, nil
+	}
+
+	for _, device := range devices {
+		if govee.SameDeviceID(device.Device, deviceID) {
+			return &device, nil
+		}
+	}
+	return nil, &unknownDeviceError{
+		deviceID:    deviceID,
+		apiKeyIndex: apiKeyIndex,
+		suggestions: suggestDevices(devices, deviceID),
+	}
+}
+
+// suggestDevices returns the devices closest to deviceID, by edit distance
+// to their ID or (for clients that sent a name) their name.
+func suggestDevices(devices []govee.Device, deviceID string) []DeviceSuggestion {
+	wantID := govee.NormalizeDeviceID(deviceID)
+	wantName := strings.ToLower(deviceID)
+
+	distance := func(device govee.Device) int {
+		return min(
+			editDistance(wantID, govee.NormalizeDeviceID(device.Device)),
+			editDistance(wantName, strings.ToLower(device.DeviceName)),
+		)
+	}
+
+	ranked := slices.Clone(devices)
+	slices.SortStableFunc(ranked, func(a, b govee.Device) int {
+		return distance(a) - distance(b)
+	})
+
+	suggestions := []DeviceSuggestion{}
+	for _, device := range ranked[:min(len(ranked), maxDeviceSuggestions)] {
+		suggestions = append(suggestions, DeviceSuggestion{
+			DeviceID: device.Device,
+			Name:     device.DeviceName,
+			Model:    device.Model,
+		})
+	}
+	return suggestions
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/pantheon/artemis/govee"
+)
+
+func TestSuggestDevices_ClosestFirst(t *testing.T) {
+	devices := []govee.Device{
+		{Device: "11:22:33:44:55:66:77:88", DeviceName: "TV Strip"},
+		{Device: "AA:BB:CC:DD:EE:FF:00:11", DeviceName: "Desk Lamp"},
+		{Device: "AA:BB:CC:DD:EE:FF:99:99", DeviceName: "Floor Lamp"},
+		{Device: "FF:FF:FF:FF:FF:FF:FF:FF", DeviceName: "Porch"},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"one digit off", "AA:BB:CC:DD:EE:FF:00:12", "AA:BB:CC:DD:EE:FF:00:11"},
+		{"name instead of ID", "desk lamp", "AA:BB:CC:DD:EE:FF:00:11"},
+		{"misspelled name", "Tv Stirp", "11:22:33:44:55:66:77:88"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestions := suggestDevices(devices, tt.query)
+			if len(suggestions) != maxDeviceSuggestions {
+				t.Fatalf("expected %d suggestions, got %+v", maxDeviceSuggestions, suggestions)
+			}
+			if suggestions[0].DeviceID != tt.want {
+				t.Errorf("expected %s first, got %+v", tt.want, suggestions)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"lamp", "lamp", 0},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
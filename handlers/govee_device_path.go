@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/pantheon/artemis/events"
+	"github.com/pantheon/artemis/govee"
+)
+
+// errDeviceNotFound means no configured Govee account lists the device.
+var errDeviceNotFound = errors.New("device not found")
+
+// deviceNotFoundError is resolveDevicePath's user-facing errDeviceNotFound.
+type deviceNotFoundError struct {
+	deviceID string
+}
+
+func (e *deviceNotFoundError) Error() string {
+	return "Device not found: " + e.deviceID
+}
+
+func (e *deviceNotFoundError) Is(target error) bool {
+	return target == errDeviceNotFound
+}
+
+// HandleGetDeviceStateByID is the path-style variant of HandleGetDeviceState.
+// GET /api/govee/devices/{id}/state[?fresh=true][&apiKeyIndex=Z]
+// Returns: StateResponse JSON, same as the query-style endpoint
+//
+// The model and account are looked up in the (cached) device list, so the
+// client only needs the device ID. apiKeyIndex is only needed to pick an
+// account when a device is shared between both.
+func HandleGetDeviceStateByID(goveeClients []*govee.Client, statePoller *govee.StatePoller, optimistic *govee.OptimisticStates) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept GET requests
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		device, apiKeyIndex, status, err := resolveDevicePath(r, goveeClients)
+		if err != nil {
+			writeErrorFrom(w, r, status, err)
+			return
+		}
+
+		forceFresh := r.URL.Query().Get("fresh") == "true"
+		serveDeviceState(w, r, goveeClients, statePoller, optimistic, apiKeyIndex, device.Device, device.Model, forceFresh)
+	}
+}
+
+// HandleControlDeviceByID is the path-style variant of HandleControlDevice.
+// POST /api/govee/devices/{id}/control[?apiKeyIndex=Z]
+// Accepts: ControlRequest JSON body; only command, value, transitionMs and
+// preserveBrightness are used — the device, model and account come from the path and the
+// (cached) device list
+// Returns: ControlResponse JSON, same as the query-style endpoint
+func HandleControlDeviceByID(goveeClients []*govee.Client, retryQueue *govee.RetryQueue, optimistic *govee.OptimisticStates, coalescer *govee.Coalescer, deviceEvents *events.Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req ControlRequest
+		if err := decodeJSONBody(r, &req); err != nil {
+			log.Printf("❌ Error decoding control request: %v", err)
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		device, apiKeyIndex, status, err := resolveDevicePath(r, goveeClients)
+		if err != nil {
+			writeErrorFrom(w, r, status, err)
+			return
+		}
+
+		req.DeviceID, req.Model, req.APIKeyIndex = device.Device, device.Model, apiKeyIndex
+		controlDevice(w, r, req, goveeClients, retryQueue, optimistic, coalescer, deviceEvents)
+	}
+}
+
+// resolveDevicePath finds the device named by the {id} path value, limited
+// to the account in ?apiKeyIndex= when given. On failure it returns the HTTP
+// status to answer with.
+func resolveDevicePath(r *http.Request, goveeClients []*govee.Client) (govee.Device, int, int, error) {
+	deviceID := r.PathValue("id")
+
+	apiKeyIndex := -1 // Any account
+	if apiKeyIndexStr := r.URL.Query().Get("apiKeyIndex"); apiKeyIndexStr != "" {
+		index, err := strconv.Atoi(apiKeyIndexStr)
+		if err != nil || index < 0 || index >= len(goveeClients) {
+			return govee.Device{}, 0, http.StatusBadRequest, fmt.Errorf("Invalid apiKeyIndex %q", apiKeyIndexStr)
+		}
+		apiKeyIndex = index
+	}
+
+	device, index, err := findDevice(r.Context(), goveeClients, deviceID, apiKeyIndex)
+	if errors.Is(err, errDeviceNotFound) {
+		return govee.Device{}, 0, http.StatusNotFound, &deviceNotFoundError{deviceID}
+	}
+	if err != nil {
+		log.Printf("❌ Error resolving device %s: %v", deviceID, err)
+		return govee.Device{}, 0, http.StatusBadGateway, fmt.Errorf("Couldn't load the Govee device list")
+	}
+	return device, index, http.StatusOK, nil
+}
+
+// findDevice looks a device up in each account's cached device list, in API
+// key order (or only apiKeyIndex's, if it isn't -1), and returns it with the
+// index of the account that has it. IDs match ignoring case and separators.
+// Returns errDeviceNotFound if no list has it, or the fetch error if a list
+// that might have had it couldn't be loaded.
+func findDevice(ctx context.Context, goveeClients []*govee.Client, deviceID string, apiKeyIndex int) (govee.Device, int, error) {
+	var fetchErr error
+	for index, client := range goveeClients {
+		if apiKeyIndex != -1 && index != apiKeyIndex {
+			continue
+		}
+
+		devices, err := client.GetDevicesCached(ctx)
+		if err != nil {
+			fetchErr = fmt.Errorf("API key #%d: %w", index, err)
+			continue
+		}
+		for _, device := range devices {
+			if govee.SameDeviceID(device.Device, deviceID) {
+				return device, index, nil
+			}
+		}
+	}
+
+	if fetchErr != nil {
+		return govee.Device{}, 0, fetchErr
+	}
+	return govee.Device{}, 0, errDeviceNotFound
+}
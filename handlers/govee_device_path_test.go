@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/govee"
+)
+
+// pathAccounts are two accounts: the first lists no devices, the second
+// lists the desk lamp.
+var pathAccounts = []goveeAccount{
+	{name: "primary", devices: `{"code": 200, "message": "Success", "data": {"devices": []}}`},
+	{name: "secondary", devices: searchDevicesBody},
+}
+
+// newPathMux routes the path-style endpoints like main.go does.
+func newPathMux(clients []*govee.Client) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/govee/devices/{id}/state", HandleGetDeviceStateByID(clients, nil, nil))
+	mux.HandleFunc("/api/govee/devices/{id}/control", HandleControlDeviceByID(clients, nil, nil, nil, nil))
+	return mux
+}
+
+func TestDevicePath_State(t *testing.T) {
+	clients := newGoveeStub(t, pathAccounts...).clients
+	mux := newPathMux(clients)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices/AA:01/state", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp StateResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.DeviceID != "AA:01" || !resp.IsOn {
+		t.Errorf("expected AA:01 on, got %+v", resp)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want int
+		code errcode.Code
+	}{
+		{"unknown device", "/api/govee/devices/ZZ:99/state", http.StatusNotFound, errcode.DeviceNotFound},
+		{"device not on the given account", "/api/govee/devices/AA:01/state?apiKeyIndex=0", http.StatusNotFound, errcode.DeviceNotFound},
+		{"invalid account", "/api/govee/devices/AA:01/state?apiKeyIndex=5", http.StatusBadRequest, errcode.InvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			var resp ErrorResponse
+			json.NewDecoder(w.Body).Decode(&resp)
+			if resp.Code != tt.code {
+				t.Errorf("expected code %s, got %q", tt.code, resp.Code)
+			}
+		})
+	}
+}
+
+func TestDevicePath_ControlResolvesModelAndAccount(t *testing.T) {
+	stub := newGoveeStub(t, pathAccounts...)
+	clients := stub.clients
+
+	w := httptest.NewRecorder()
+	body := strings.NewReader(`{"command": "turn", "value": false}`)
+	newPathMux(clients).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/AA:01/control", body))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(stub.commands()) != 1 || stub.commands()[0] != "secondary AA:01 H6008 turn" {
+		t.Errorf("expected the command to go to the second account with the listed model, got %v", stub.commands())
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/middleware"
+)
+
+// DiagnoseRequest identifies the device to diagnose. The model and whether
+// the device can report state come from the (cached) device list.
+type DiagnoseRequest struct {
+	DeviceID    string `json:"deviceId"`    // Device MAC address
+	APIKeyIndex int    `json:"apiKeyIndex"` // Which API key owns this device (0 = primary, 1 = secondary)
+
+	// Brightness to re-apply to a device that can't report state, e.g. what
+	// the app last set. Defaults to the optimistic state's brightness;
+	// ignored for devices that can report state.
+	Brightness *int `json:"brightness,omitempty"`
+}
+
+// DiagnoseResponse reports the outcome of a diagnostic round trip
+type DiagnoseResponse struct {
+	*govee.DiagnoseResult
+	DeviceID  string `json:"deviceId"`  // Which device was diagnosed
+	Model     string `json:"model"`     // Its model, from the device list
+	Timestamp string `json:"timestamp"` // When the diagnosis finished
+}
+
+// HandleDiagnoseDevice checks whether a device responds end to end, to tell
+// Govee API problems from device problems
+// POST /api/govee/devices/diagnose
+// Accepts: DiagnoseRequest JSON body
+// Returns: DiagnoseResponse JSON with the timing and outcome of each step
+//
+// The device's state is read, its current brightness re-applied (a no-op
+// for the light), and the state read again; see govee.Client.DiagnoseDevice.
+// The response is 200 even when a step fails — the steps are the answer.
+func HandleDiagnoseDevice(goveeClients []*govee.Client, optimistic *govee.OptimisticStates) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req DiagnoseRequest
+		if err := decodeJSONBody(r, &req); err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.DeviceID == "" {
+			writeError(w, r, http.StatusBadRequest, "deviceId is required")
+			return
+		}
+		if req.APIKeyIndex < 0 || req.APIKeyIndex >= len(goveeClients) {
+			writeError(w, r, http.StatusBadRequest, "Invalid API key index")
+			return
+		}
+
+		device, _, err := findDevice(r.Context(), goveeClients, req.DeviceID, req.APIKeyIndex)
+		if errors.Is(err, errDeviceNotFound) {
+			writeError(w, r, http.StatusNotFound, "Device not found: "+req.DeviceID)
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Error resolving device %s: %v", req.DeviceID, err)
+			writeError(w, r, http.StatusBadGateway, "Couldn't load the Govee device list")
+			return
+		}
+
+		// Devices that can't report state need a known brightness to re-apply
+		brightness := 0
+		if !device.Retrievable {
+			known := false
+			if req.Brightness != nil {
+				brightness, known = *req.Brightness, true
+			} else if optimistic != nil {
+				if state, ok := optimistic.Get(req.APIKeyIndex, req.DeviceID); ok && state.Brightness != nil {
+					brightness, known = *state.Brightness, true
+				}
+			}
+			if !known {
+				writeError(w, r, http.StatusBadRequest, "This device can't report its state — pass the brightness it's at")
+				return
+			}
+			if brightness < 0 || brightness > 100 {
+				writeError(w, r, http.StatusBadRequest, fmt.Sprintf("brightness must be between 0 and 100, got %d", brightness))
+				return
+			}
+		}
+
+		log.Printf("🩺 Diagnose request - Device: %s, API Key Index: %d - Client: %s",
+			req.DeviceID, req.APIKeyIndex, middleware.ClientIP(r))
+
+		result := goveeClients[req.APIKeyIndex].DiagnoseDevice(r.Context(), device.Device, device.Model, device.Retrievable, brightness)
+
+		// The client doesn't know which account it belongs to — fill it in.
+		for _, state := range []*govee.DeviceState{result.Before, result.After} {
+			if state != nil {
+				state.APIKeyIndex = req.APIKeyIndex
+			}
+		}
+
+		writeJSON(w, r, http.StatusOK, DiagnoseResponse{
+			DiagnoseResult: result,
+			DeviceID:       device.Device,
+			Model:          device.Model,
+			Timestamp:      time.Now().Format(time.RFC3339),
+		})
+	}
+}
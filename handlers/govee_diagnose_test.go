@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDiagnoseDevice(t *testing.T) {
+	stub := newGoveeStub(t, pathAccounts...)
+	clients := stub.clients
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"unknown device", `{"deviceId": "ZZ:99", "apiKeyIndex": 1}`, http.StatusNotFound},
+		{"wrong account", `{"deviceId": "AA:01", "apiKeyIndex": 0}`, http.StatusNotFound},
+		{"no brightness for a non-retrievable device", `{"deviceId": "AA:01", "apiKeyIndex": 1}`, http.StatusBadRequest},
+		{"brightness out of range", `{"deviceId": "AA:01", "apiKeyIndex": 1, "brightness": 150}`, http.StatusBadRequest},
+		{"write then assume", `{"deviceId": "AA:01", "apiKeyIndex": 1, "brightness": 40}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			HandleDiagnoseDevice(clients, nil)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/diagnose", strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp DiagnoseResponse
+			json.NewDecoder(w.Body).Decode(&resp)
+			if resp.DiagnoseResult == nil || !resp.RoundTrip || resp.Retrievable || resp.Model != "H6008" {
+				t.Errorf("expected an assumed round trip on the H6008, got %s", w.Body.String())
+			}
+		})
+	}
+
+	if len(stub.commands()) != 1 || stub.commands()[0] != "secondary AA:01 H6008 brightness" {
+		t.Errorf("expected one brightness command on the secondary account, got %v", stub.commands())
+	}
+}
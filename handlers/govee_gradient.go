@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/middleware"
+)
+
+// GradientRequest is the body of POST /api/govee/groups/{name}/gradient, e.g.
+// {"from": {"kelvin": 2700}, "to": {"kelvin": 6500}, "order": ["Floor Lamp", "Desk Lamp"], "brightness": 60}
+type GradientRequest struct {
+	From  govee.GradientStop   `json:"from"`
+	To    govee.GradientStop   `json:"to"`
+	Stops []govee.GradientStop `json:"stops,omitempty"` // Intermediate colors, evenly spaced between from and to
+
+	// Lights along the gradient, first to last, by device ID or registered
+	// name. Defaults to every Govee light of the room in registration order;
+	// lights left out aren't changed.
+	Order []string `json:"order,omitempty"`
+
+	Brightness   *int `json:"brightness,omitempty"`   // 0-100, set on every light
+	TransitionMs int  `json:"transitionMs,omitempty"` // Fade RGB colors and brightness (max 10000)
+}
+
+// GradientDeviceResult is the outcome for one light of a gradient.
+type GradientDeviceResult struct {
+	DeviceID string            `json:"deviceId"`
+	Name     string            `json:"name"`
+	Position int               `json:"position"`         // 0 for the first light along the gradient
+	Color    *govee.ColorValue `json:"color,omitempty"`  // RGB gradients
+	Kelvin   int               `json:"kelvin,omitempty"` // colorTem gradients, after clamping to the device's range
+	Success  bool              `json:"success"`
+	Offline  bool              `json:"offline,omitempty"` // Govee reported the device offline
+	Skipped  bool              `json:"skipped,omitempty"` // Not attempted: the state poller last saw it offline
+	Steps    []string          `json:"steps"`             // Commands sent, in order
+	Error    string            `json:"error,omitempty"`
+}
+
+// GradientResponse reports the color and outcome for every light of a
+// gradient.
+type GradientResponse struct {
+	Success   bool                   `json:"success"`           // Whether every light succeeded (or, in a preview, resolved)
+	Preview   bool                   `json:"preview,omitempty"` // Nothing was sent
+	Mode      string                 `json:"mode"`              // govee.GradientRGB or govee.GradientColorTem
+	RoomID    string                 `json:"roomId"`
+	Room      string                 `json:"room"`
+	Results   []GradientDeviceResult `json:"results"`           // In gradient order
+	Skipped   int                    `json:"skipped,omitempty"` // Lights skipped as offline
+	Timestamp string                 `json:"timestamp"`
+}
+
+// HandleApplyGradient spreads a color gradient across the Govee lights of a
+// group (a room).
+// POST /api/govee/groups/{name}/gradient
+// Accepts: GradientRequest JSON body
+// Returns: GradientResponse JSON (200 even when some lights fail)
+//
+// {name} is resolved like /api/rooms/{name}/apply, including ?profileId=.
+// The lights are laid out evenly along the gradient in order, so the first
+// gets from, the last gets to, and the ones between are interpolated; each
+// light is then set concurrently. Stops are either all RGB colors or all
+// color temperatures. Successful commands are recorded in optimistic, if
+// non-nil, and drop any older command retryQueue (which may be nil) holds
+// for the light. ?preview=true computes the colors without sending anything.
+//
+// Lights the state poller last saw offline keep their place in the gradient
+// but are skipped when skipOffline is set, as for room scenes.
+func HandleApplyGradient(goveeClients []*govee.Client, database *sql.DB, optimistic *govee.OptimisticStates, retryQueue *govee.RetryQueue, poller *govee.StatePoller, skipOffline bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req GradientRequest
+		if err := decodeJSONBody(r, &req); err != nil {
+			log.Printf("❌ Error decoding gradient request: %v", err)
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		gradient, err := govee.NewGradient(slices.Concat([]govee.GradientStop{req.From}, req.Stops, []govee.GradientStop{req.To}))
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.Brightness != nil && (*req.Brightness < 0 || *req.Brightness > 100) {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("brightness must be between 0 and 100, got %d", *req.Brightness))
+			return
+		}
+		if req.TransitionMs < 0 {
+			writeError(w, r, http.StatusBadRequest, "transitionMs must not be negative")
+			return
+		}
+		offline, err := offlineFilter(r, poller, skipOffline)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		room, status, err := findRoomByName(database, r.PathValue("name"), r.URL.Query().Get("profileId"))
+		if err != nil {
+			writeError(w, r, status, err.Error())
+			return
+		}
+
+		lights, err := roomLights(database, room.ID)
+		if err != nil {
+			log.Printf("❌ Gradient: failed to list devices in room %s: %v", room.ID, err)
+			writeError(w, r, http.StatusInternalServerError, "Failed to list devices in room")
+			return
+		}
+		if req.Order != nil {
+			if lights, err = orderLights(lights, req.Order); err != nil {
+				writeError(w, r, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		if len(lights) == 0 {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("Room %s has no Govee lights", room.Name))
+			return
+		}
+
+		preview, _ := strconv.ParseBool(r.URL.Query().Get("preview"))
+		log.Printf("🌈 Gradient request - Room: %s, Mode: %s, Lights: %d, Preview: %t - Client: %s", room.Name, gradient.Mode, len(lights), preview, middleware.ClientIP(r))
+
+		transition := time.Duration(req.TransitionMs) * time.Millisecond
+		colors := gradient.Spread(len(lights))
+		results := make([]GradientDeviceResult, len(lights))
+		var wg sync.WaitGroup
+		for i, light := range lights {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = applyGradientColor(r, goveeClients, optimistic, retryQueue, offline, light, i, colors[i], req.Brightness, transition, preview)
+			}()
+		}
+		wg.Wait()
+
+		success, skipped := true, 0
+		for _, result := range results {
+			success = success && result.Success
+			if result.Skipped {
+				skipped++
+			}
+		}
+
+		writeJSON(w, r, http.StatusOK, GradientResponse{
+			Success:   success,
+			Preview:   preview,
+			Mode:      gradient.Mode,
+			RoomID:    room.ID,
+			Room:      room.Name,
+			Results:   results,
+			Skipped:   skipped,
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+	}
+}
+
+// orderLights returns the room's lights in the order given by keys (device
+// IDs or registered names). Messages are user-facing.
+func orderLights(lights []roomLight, keys []string) ([]roomLight, error) {
+	ordered := make([]roomLight, 0, len(keys))
+	for _, key := range keys {
+		index := slices.IndexFunc(lights, func(light roomLight) bool {
+			return govee.SameDeviceID(light.deviceID, key) || strings.EqualFold(light.name, key)
+		})
+		if index == -1 {
+			return nil, fmt.Errorf("order: %s is not a Govee light registered in this room", key)
+		}
+		if slices.Contains(ordered, lights[index]) {
+			return nil, fmt.Errorf("order: %s is listed more than once", key)
+		}
+		ordered = append(ordered, lights[index])
+	}
+	return ordered, nil
+}
+
+// applyGradientColor sets one light to its gradient color (or, for a
+// preview, only resolves it). RGB colors go through govee.ApplyState;
+// temperatures are clamped to the device's colorTem range, which can differ
+// between models in the same room, and aren't faded. If offline is non-nil,
+// a light it last saw offline is skipped.
+func applyGradientColor(r *http.Request, goveeClients []*govee.Client, optimistic *govee.OptimisticStates, retryQueue *govee.RetryQueue, offline *govee.StatePoller, light roomLight, position int, color govee.GradientStop, brightness *int, transition time.Duration, preview bool) GradientDeviceResult {
+	result := GradientDeviceResult{DeviceID: light.deviceID, Name: light.name, Position: position, Color: color.Color, Steps: []string{}}
+
+	device, apiKeyIndex, err := findDevice(r.Context(), goveeClients, light.deviceID, -1)
+	if err != nil {
+		if errors.Is(err, errDeviceNotFound) {
+			result.Error = "No configured Govee account has this device"
+		} else {
+			log.Printf("❌ Gradient: error resolving device %s: %v", light.deviceID, err)
+			result.Error = "Couldn't load the Govee device list"
+		}
+		return result
+	}
+	client := goveeClients[apiKeyIndex]
+	if color.Color == nil {
+		result.Kelvin = client.ColorTemRange(device.Model).Clamp(color.Kelvin)
+	}
+
+	if knownOffline(offline, apiKeyIndex, device.Device) {
+		result.Error, result.Offline, result.Skipped = skippedOfflineMessage, true, true
+		return result
+	}
+
+	if preview {
+		result.Success = true
+		return result
+	}
+
+	state := govee.TargetState{Color: color.Color, Brightness: brightness}
+	if color.Color == nil {
+		state.ColorTem = &result.Kelvin
+	}
+	steps, err := client.ApplyState(r.Context(), device.Device, device.Model, state, transition)
+	result.Steps = append(result.Steps, steps...)
+	if optimistic != nil {
+		recordTargetState(optimistic, apiKeyIndex, device, state, len(steps))
+	}
+	if len(steps) > 0 {
+		retryQueue.Forget(apiKeyIndex, device.Device)
+	}
+	if err != nil {
+		return gradientFailure(result, err)
+	}
+
+	result.Success = true
+	return result
+}
+
+// gradientFailure fills in a light's result for a failed command.
+func gradientFailure(result GradientDeviceResult, err error) GradientDeviceResult {
+	log.Printf("❌ Gradient: %s: %v", result.DeviceID, err)
+	result.Error = err.Error()
+	result.Offline = govee.IsOfflineError(err)
+	return result
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pantheon/artemis/govee"
+)
+
+// applyGradient posts body to /api/govee/groups/{name}/gradient through a
+// mux so the path value is set.
+func applyGradient(handler http.HandlerFunc, path, body string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/govee/groups/{name}/gradient", handler)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body)))
+	return w
+}
+
+func TestApplyGradient_RGB(t *testing.T) {
+	database, _ := newRoomApplyDB(t)
+	optimistic := govee.NewOptimisticStates("")
+	handler := HandleApplyGradient(newGoveeStub(t, offlinePlugAccount).clients, database, optimistic, nil, nil, true)
+
+	w := applyGradient(handler, "/api/govee/groups/living%20room/gradient",
+		`{"from": {"color": {"r": 255, "g": 0, "b": 0}}, "to": {"color": {"r": 0, "g": 0, "b": 255}}, "brightness": 40}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp GradientResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Success || resp.Mode != govee.GradientRGB || len(resp.Results) != 2 {
+		t.Fatalf("expected a partly failed RGB gradient over two lights, got %+v", resp)
+	}
+
+	// Registration order: the desk lamp starts the gradient, the plug ends it
+	lamp, plug := resp.Results[0], resp.Results[1]
+	if lamp.Name != "Desk Lamp" || !lamp.Success || *lamp.Color != (govee.ColorValue{R: 255}) {
+		t.Errorf("expected the desk lamp to be set red, got %+v", lamp)
+	}
+	if len(lamp.Steps) != 2 || lamp.Steps[1] != "brightness 40" {
+		t.Errorf("expected color then brightness, got %v", lamp.Steps)
+	}
+	if plug.Position != 1 || plug.Success || !plug.Offline || *plug.Color != (govee.ColorValue{B: 255}) {
+		t.Errorf("expected the offline plug to end the gradient in blue, got %+v", plug)
+	}
+
+	if state, ok := optimistic.Get(0, "AA:01"); !ok || state.Color == nil || state.Color.R != 255 {
+		t.Errorf("expected the desk lamp's color to be recorded, got %+v", state)
+	}
+}
+
+func TestApplyGradient_ColorTemPreview(t *testing.T) {
+	database, _ := newRoomApplyDB(t)
+	handler := HandleApplyGradient(newGoveeStub(t, offlinePlugAccount).clients, database, nil, nil, nil, true)
+
+	w := applyGradient(handler, "/api/govee/groups/Living%20Room/gradient?preview=true",
+		`{"from": {"kelvin": 2000}, "to": {"kelvin": 9000}, "stops": [{"kelvin": 3000}], "order": ["plug", "AA:01"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp GradientResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.Success || !resp.Preview || resp.Mode != govee.GradientColorTem || len(resp.Results) != 2 {
+		t.Fatalf("expected a colorTem preview over two lights, got %+v", resp)
+	}
+	// The H6008 only goes up to 6500K
+	if got := resp.Results[0]; got.DeviceID != "AA:02" || got.Kelvin != 2000 || len(got.Steps) != 0 {
+		t.Errorf("expected the plug first at 2000K with nothing sent, got %+v", got)
+	}
+	if got := resp.Results[1]; got.DeviceID != "AA:01" || got.Kelvin != 6500 {
+		t.Errorf("expected the desk lamp clamped to 6500K, got %+v", got)
+	}
+}
+
+func TestApplyGradient_BadRequests(t *testing.T) {
+	database, _ := newRoomApplyDB(t)
+	handler := HandleApplyGradient(newGoveeStub(t, offlinePlugAccount).clients, database, nil, nil, nil, true)
+
+	tests := map[string]struct {
+		path, body string
+		status     int
+	}{
+		"one endpoint":  {"/api/govee/groups/living%20room/gradient", `{"from": {"kelvin": 2700}}`, http.StatusBadRequest},
+		"mixed stops":   {"/api/govee/groups/living%20room/gradient", `{"from": {"kelvin": 2700}, "to": {"color": {"r": 0, "g": 0, "b": 255}}}`, http.StatusBadRequest},
+		"unknown light": {"/api/govee/groups/living%20room/gradient", `{"from": {"kelvin": 2700}, "to": {"kelvin": 6500}, "order": ["Hallway"]}`, http.StatusBadRequest},
+		"repeat light":  {"/api/govee/groups/living%20room/gradient", `{"from": {"kelvin": 2700}, "to": {"kelvin": 6500}, "order": ["AA:01", "desk lamp"]}`, http.StatusBadRequest},
+		"unknown room":  {"/api/govee/groups/attic/gradient", `{"from": {"kelvin": 2700}, "to": {"kelvin": 6500}}`, http.StatusNotFound},
+	}
+	for name, tt := range tests {
+		if w := applyGradient(handler, tt.path, tt.body); w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", name, tt.status, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestApplyGradient_SkipsKnownOfflineLights(t *testing.T) {
+	database, _ := newRoomApplyDB(t)
+	handler := HandleApplyGradient(newGoveeStub(t, offlinePlugAccount).clients, database, nil, nil, newOfflinePoller(t, "AA:02", "H5080"), true)
+
+	w := applyGradient(handler, "/api/govee/groups/living%20room/gradient", `{"from": {"kelvin": 2700}, "to": {"kelvin": 6500}}`)
+	var resp GradientResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Skipped != 1 || len(resp.Results) != 2 {
+		t.Fatalf("expected one skipped light, got %+v", resp)
+	}
+	// The plug keeps its place at the cool end of the gradient
+	if plug := resp.Results[1]; !plug.Skipped || plug.Kelvin != 6500 || len(plug.Steps) != 0 {
+		t.Errorf("expected the plug skipped at 6500K, got %+v", plug)
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/pantheon/artemis/db"
+)
+
+// Supported values for GET /api/govee/devices?groupBy=...
+const (
+	GroupByType    = "type"
+	GroupByAccount = "account"
+	GroupByRoom    = "room"
+)
+
+// unassignedGroup holds devices that aren't in any room when grouping by room.
+// Always listed last.
+const unassignedGroup = "Unassigned"
+
+// DeviceGroup is one section of a grouped device list.
+type DeviceGroup struct {
+	Key     string           `json:"key"`              // Type, account label, or room name
+	RoomID  string           `json:"roomId,omitempty"` // Set when grouping by room (except "Unassigned")
+	Count   int              `json:"count"`            // Number of devices in the group
+	Devices []DeviceResponse `json:"devices"`
+}
+
+// GroupedDevicesResponse is returned by GET /api/govee/devices?groupBy=...
+// instead of the flat array.
+type GroupedDevicesResponse struct {
+	GroupBy string        `json:"groupBy"`
+	Total   int           `json:"total"` // Devices across all groups (a device in two rooms counts once)
+	Groups  []DeviceGroup `json:"groups"`
+}
+
+// GroupByValues lists every supported groupBy value.
+var GroupByValues = []string{GroupByType, GroupByAccount, GroupByRoom}
+
+// isValidGroupBy reports whether groupBy is a supported grouping.
+func isValidGroupBy(groupBy string) bool {
+	return slices.Contains(GroupByValues, groupBy)
+}
+
+// groupDevices sections the device list by type, account, or room.
+//
+// Room membership comes from registered "govee_light" devices whose
+// externalId is the Govee device ID. A Govee device registered in rooms of
+// several profiles appears in each of those rooms; devices in no room land
+// in "Unassigned". Returns an error when room membership can't be loaded,
+// rather than reporting every device as unassigned.
+func groupDevices(devices []DeviceResponse, groupBy string, accountLabels AccountLabels, database *sql.DB) (GroupedDevicesResponse, error) {
+	var groups []*DeviceGroup
+	byID := make(map[string]*DeviceGroup)
+	add := func(id, key, roomID string, device DeviceResponse) {
+		group, ok := byID[id]
+		if !ok {
+			group = &DeviceGroup{Key: key, RoomID: roomID, Devices: []DeviceResponse{}}
+			byID[id] = group
+			groups = append(groups, group)
+		}
+		group.Devices = append(group.Devices, device)
+		group.Count++
+	}
+
+	var rooms map[string][]db.Room
+	if groupBy == GroupByRoom {
+		var err error
+		if rooms, err = deviceRooms(database); err != nil {
+			return GroupedDevicesResponse{}, err
+		}
+	}
+
+	for _, device := range devices {
+		switch groupBy {
+		case GroupByType:
+			add(device.Type, device.Type, "", device)
+		case GroupByAccount:
+			add(fmt.Sprint(device.APIKeyIndex), accountLabels.name(device.APIKeyIndex), "", device)
+		case GroupByRoom:
+			if len(rooms[device.ID]) == 0 {
+				add("", unassignedGroup, "", device)
+			}
+			for _, room := range rooms[device.ID] {
+				add(room.ID, room.Name, room.ID, device)
+			}
+		}
+	}
+
+	// Alphabetical for stable sections; accounts stay in API key order
+	// (their insertion order follows the device list) and "Unassigned" goes last.
+	if groupBy != GroupByAccount {
+		sort.SliceStable(groups, func(i, j int) bool {
+			if (groups[i].Key == unassignedGroup) != (groups[j].Key == unassignedGroup) {
+				return groups[j].Key == unassignedGroup
+			}
+			return groups[i].Key < groups[j].Key
+		})
+	}
+
+	response := GroupedDevicesResponse{GroupBy: groupBy, Total: len(devices), Groups: []DeviceGroup{}}
+	for _, group := range groups {
+		response.Groups = append(response.Groups, *group)
+	}
+	return response, nil
+}
+
+// deviceRooms maps Govee device IDs to the rooms their registered devices
+// are assigned to. Without a database nothing is in a room. A failed lookup
+// is returned, since treating it as "no rooms" would wrongly list every
+// device as unassigned.
+func deviceRooms(database *sql.DB) (map[string][]db.Room, error) {
+	rooms := make(map[string][]db.Room)
+	if database == nil {
+		return rooms, nil
+	}
+
+	registered, err := db.ListDevicesByType(database, "govee_light")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load registered devices: %w", err)
+	}
+
+	roomByID := make(map[string]*db.Room)
+	for _, d := range registered {
+		if d.ExternalID == nil || d.RoomID == nil {
+			continue
+		}
+
+		room, ok := roomByID[*d.RoomID]
+		if !ok {
+			room, err = db.GetRoom(database, *d.RoomID)
+			if err != nil && !isNotFound(err) {
+				return nil, fmt.Errorf("failed to load room %s: %w", *d.RoomID, err)
+			}
+			// A room deleted since the device list was read has no devices
+			roomByID[*d.RoomID] = room
+		}
+		if room == nil {
+			continue
+		}
+
+		// Skip duplicates (the same Govee device registered twice in one room)
+		duplicate := false
+		for _, existing := range rooms[*d.ExternalID] {
+			duplicate = duplicate || existing.ID == room.ID
+		}
+		if !duplicate {
+			rooms[*d.ExternalID] = append(rooms[*d.ExternalID], *room)
+		}
+	}
+	return rooms, nil
+}
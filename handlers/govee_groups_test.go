@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pantheon/artemis/db"
+)
+
+// groupedDevices runs GET /api/govee/devices?groupBy=... against the search
+// stub (AA:01 "Desk Lamp", AA:02 "Smart Plug") and decodes the response.
+func groupedDevices(t *testing.T, handler http.HandlerFunc, groupBy string) GroupedDevicesResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices?groupBy="+groupBy, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp GroupedDevicesResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func TestGetDevices_GroupByRoom(t *testing.T) {
+	clients := newGoveeStub(t, searchAccount).clients
+
+	database, err := db.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init test DB: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	// The lamp is registered and assigned to the office; the plug isn't registered.
+	profile, _ := db.CreateProfile(database, "Shakur")
+	office, _ := db.CreateRoom(database, profile.ID, "Office", "desktopcomputer")
+	lampID := "AA:01"
+	lamp, _ := db.CreateDevice(database, profile.ID, "Desk Lamp", "govee_light", &lampID, nil)
+	db.AssignDeviceToRoom(database, lamp.ID, office.ID)
+
+	resp := groupedDevices(t, HandleGetDevices(clients, AccountLabels{}, database, ListOptions{}), GroupByRoom)
+
+	if resp.GroupBy != GroupByRoom || resp.Total != 2 || len(resp.Groups) != 2 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if g := resp.Groups[0]; g.Key != "Office" || g.RoomID != office.ID || g.Count != 1 || g.Devices[0].ID != "AA:01" {
+		t.Errorf("unexpected office group: %+v", g)
+	}
+	if g := resp.Groups[1]; g.Key != "Unassigned" || g.Count != 1 || g.Devices[0].ID != "AA:02" {
+		t.Errorf("expected the plug under Unassigned, got %+v", g)
+	}
+}
+
+func TestGetDevices_GroupByRoomFailsWithoutMembership(t *testing.T) {
+	clients := newGoveeStub(t, searchAccount).clients
+	database, err := db.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init test DB: %v", err)
+	}
+	database.Close() // Every query fails
+
+	w := httptest.NewRecorder()
+	HandleGetDevices(clients, AccountLabels{}, database, ListOptions{})(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices?groupBy=room", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500 instead of listing every device as unassigned, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetDevices_GroupByAccountAndType(t *testing.T) {
+	clients := newGoveeStub(t, searchAccount).clients
+	handler := HandleGetDevices(clients, AccountLabels{Labels: []string{"Mine"}}, nil, ListOptions{})
+
+	byAccount := groupedDevices(t, handler, GroupByAccount)
+	if len(byAccount.Groups) != 1 || byAccount.Groups[0].Key != "Mine" || byAccount.Groups[0].Count != 2 {
+		t.Errorf("unexpected account groups: %+v", byAccount.Groups)
+	}
+
+	byType := groupedDevices(t, handler, GroupByType)
+	if len(byType.Groups) != 1 || byType.Groups[0].Key != "light" || byType.Groups[0].Count != 2 {
+		t.Errorf("unexpected type groups: %+v", byType.Groups)
+	}
+}
+
+func TestGetDevices_InvalidGroupBy(t *testing.T) {
+	stub := newGoveeStub(t, searchAccount)
+	clients := stub.clients
+
+	w := httptest.NewRecorder()
+	HandleGetDevices(clients, AccountLabels{}, nil, ListOptions{})(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices?groupBy=color", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+	if stub.listCalls() != 0 {
+		t.Errorf("expected no Govee calls for an invalid groupBy, got %d", stub.listCalls())
+	}
+}
+
+func TestAccountLabels_NameFallback(t *testing.T) {
+	labels := AccountLabels{Labels: []string{"Mine"}}
+	if got := labels.name(0); got != "Mine" {
+		t.Errorf("expected configured label, got %q", got)
+	}
+	if got := labels.name(1); got != "Account 2" {
+		t.Errorf("expected fallback label, got %q", got)
+	}
+}
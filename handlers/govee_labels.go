@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AccountLabels disambiguates device names shared across Govee accounts.
+// When two accounts each have a "Bedroom Light", the merged device list
+// shows "Mine: Bedroom Light" and "Shared: Bedroom Light" instead.
+type AccountLabels struct {
+	// Labels indexed by apiKeyIndex (0 = primary, 1 = secondary).
+	// Accounts without a label keep their device names as-is.
+	Labels []string
+
+	// Append the label ("Bedroom Light (Mine)") instead of prefixing it.
+	Suffix bool
+}
+
+// label returns the configured label for an API key, or "" if none.
+func (a AccountLabels) label(apiKeyIndex int) string {
+	if apiKeyIndex < 0 || apiKeyIndex >= len(a.Labels) {
+		return ""
+	}
+	return strings.TrimSpace(a.Labels[apiKeyIndex])
+}
+
+// name returns the label for an API key, falling back to "Account N"
+// (1-based) when none is configured. Used as the group key when grouping
+// devices by account.
+func (a AccountLabels) name(apiKeyIndex int) string {
+	if label := a.label(apiKeyIndex); label != "" {
+		return label
+	}
+	return fmt.Sprintf("Account %d", apiKeyIndex+1)
+}
+
+// apply adds the account label to the name of every device whose name
+// (compared case-insensitively) also appears under a different account.
+// Names unique across accounts are left alone to avoid clutter, and nothing
+// is renamed on Govee's side — this only affects the response.
+func (a AccountLabels) apply(devices []DeviceResponse) {
+	if len(a.Labels) == 0 {
+		return
+	}
+
+	// Which accounts use each name
+	accounts := make(map[string]map[int]bool)
+	for _, d := range devices {
+		key := strings.ToLower(strings.TrimSpace(d.Name))
+		if accounts[key] == nil {
+			accounts[key] = make(map[int]bool)
+		}
+		accounts[key][d.APIKeyIndex] = true
+	}
+
+	for i, d := range devices {
+		label := a.label(d.APIKeyIndex)
+		if label == "" || len(accounts[strings.ToLower(strings.TrimSpace(d.Name))]) < 2 {
+			continue
+		}
+		if a.Suffix {
+			devices[i].Name = fmt.Sprintf("%s (%s)", d.Name, label)
+		} else {
+			devices[i].Name = fmt.Sprintf("%s: %s", label, d.Name)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pantheon/artemis/govee"
+)
+
+func TestAccountLabels_OnlyLabelsCollisions(t *testing.T) {
+	devices := []DeviceResponse{
+		{ID: "AA:01", Name: "Bedroom Light", APIKeyIndex: 0},
+		{ID: "AA:02", Name: "Desk Lamp", APIKeyIndex: 0},
+		{ID: "BB:01", Name: "bedroom light", APIKeyIndex: 1},
+	}
+
+	AccountLabels{Labels: []string{"Mine", "Shared"}}.apply(devices)
+
+	want := []string{"Mine: Bedroom Light", "Desk Lamp", "Shared: bedroom light"}
+	for i, d := range devices {
+		if d.Name != want[i] {
+			t.Errorf("device %s: expected name %q, got %q", d.ID, want[i], d.Name)
+		}
+	}
+}
+
+func TestAccountLabels_Suffix(t *testing.T) {
+	devices := []DeviceResponse{
+		{Name: "Bedroom Light", APIKeyIndex: 0},
+		{Name: "Bedroom Light", APIKeyIndex: 1},
+	}
+
+	AccountLabels{Labels: []string{"Mine", "Shared"}, Suffix: true}.apply(devices)
+
+	if devices[0].Name != "Bedroom Light (Mine)" || devices[1].Name != "Bedroom Light (Shared)" {
+		t.Errorf("unexpected names: %q, %q", devices[0].Name, devices[1].Name)
+	}
+}
+
+func TestAccountLabels_SameAccountDuplicatesAndMissingLabels(t *testing.T) {
+	devices := []DeviceResponse{
+		// Duplicates within one account aren't an account collision
+		{Name: "Strip", APIKeyIndex: 0},
+		{Name: "Strip", APIKeyIndex: 0},
+		// Collision, but only the primary account has a label
+		{Name: "Porch", APIKeyIndex: 0},
+		{Name: "Porch", APIKeyIndex: 1},
+	}
+
+	AccountLabels{Labels: []string{"Mine"}}.apply(devices)
+
+	want := []string{"Strip", "Strip", "Mine: Porch", "Porch"}
+	for i, d := range devices {
+		if d.Name != want[i] {
+			t.Errorf("device %d: expected name %q, got %q", i, want[i], d.Name)
+		}
+	}
+}
+
+func TestHandleGetDevices_LabelsSharedNames(t *testing.T) {
+	newAccount := func(body string) *govee.Client {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		client := govee.NewClient("test-key")
+		client.SetBaseURL(server.URL)
+		return client
+	}
+	clients := []*govee.Client{
+		newAccount(`{"code": 200, "data": {"devices": [{"device": "AA:01", "model": "H6008", "deviceName": "Bedroom Light", "supportCmds": ["turn"]}]}}`),
+		newAccount(`{"code": 200, "data": {"devices": [{"device": "BB:01", "model": "H6008", "deviceName": "Bedroom Light", "supportCmds": ["turn"]}]}}`),
+	}
+
+	w := httptest.NewRecorder()
+	HandleGetDevices(clients, AccountLabels{Labels: []string{"Mine", "Shared"}}, nil, ListOptions{})(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices", nil))
+
+	var devices []DeviceResponse
+	if err := json.NewDecoder(w.Body).Decode(&devices); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(devices) != 2 || devices[0].Name != "Mine: Bedroom Light" || devices[1].Name != "Shared: Bedroom Light" {
+		t.Errorf("expected labelled names, got %+v", devices)
+	}
+}
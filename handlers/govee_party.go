@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/govee"
+)
+
+// PartyStartRequest is the body of POST /api/govee/party/start.
+// Devices and rooms can be combined; a device reached both ways joins once.
+type PartyStartRequest struct {
+	Devices    []govee.PartyDevice `json:"devices,omitempty"`
+	RoomIDs    []string            `json:"roomIds,omitempty"`    // Every registered Govee light in these rooms
+	IntervalMs int64               `json:"intervalMs,omitempty"` // Time between color changes (default 12000)
+	Palette    []govee.ColorValue  `json:"palette,omitempty"`    // Colors to cycle through (default: rainbow)
+}
+
+// PartyStopRequest is the body of POST /api/govee/party/stop.
+type PartyStopRequest struct {
+	PartyID string `json:"partyId,omitempty"` // Omit to stop every party
+	Restore bool   `json:"restore"`           // Put devices back the way they were before the party
+}
+
+// PartyStopResponse lists the parties that were stopped.
+type PartyStopResponse struct {
+	Stopped []govee.PartyStatus `json:"stopped"`
+}
+
+// HandleStartParty starts "party mode": the selected devices cycle through
+// a palette in the background until stopped.
+// POST /api/govee/party/start
+// Accepts: PartyStartRequest JSON body
+// Returns: PartyStatus JSON (201), including the partyId needed to stop it
+//
+// Room members are registered "govee_light" devices whose externalId is the
+// Govee device ID; their account and model come from the (cached) Govee
+// device list. A device already in another party is a 409.
+func HandleStartParty(goveeClients []*govee.Client, parties *govee.PartyManager, database *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req PartyStartRequest
+		if err := decodeJSONBody(r, &req); err != nil {
+			log.Printf("❌ Error decoding party start request: %v", err)
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		devices := req.Devices
+		if len(req.RoomIDs) > 0 {
+			roomDevices, status, err := partyRoomDevices(r, goveeClients, database, req.RoomIDs)
+			if err != nil {
+				writeError(w, r, status, err.Error())
+				return
+			}
+			devices = append(devices, roomDevices...)
+		}
+		if len(devices) == 0 {
+			writeError(w, r, http.StatusBadRequest, "Provide devices and/or roomIds with at least one Govee light")
+			return
+		}
+
+		status, err := parties.Start(r.Context(), govee.PartyOptions{
+			Devices:  devices,
+			Interval: time.Duration(req.IntervalMs) * time.Millisecond,
+			Palette:  req.Palette,
+		})
+		if err != nil {
+			log.Printf("❌ Error starting party: %v", err)
+			if errors.Is(err, govee.ErrDeviceInParty) {
+				writeError(w, r, http.StatusConflict, err.Error())
+				return
+			}
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		writeJSON(w, r, http.StatusCreated, status)
+	}
+}
+
+// HandleStopParty stops one party (or all of them when partyId is omitted).
+// POST /api/govee/party/stop
+// Accepts: PartyStopRequest JSON body
+// Returns: PartyStopResponse JSON; 404 for an unknown partyId
+//
+// With restore, each device is set back to the power, brightness and color
+// it had when the party started. Devices that couldn't be restored are
+// listed in restoreFailed.
+func HandleStopParty(parties *govee.PartyManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req PartyStopRequest
+		if err := decodeJSONBody(r, &req); err != nil {
+			log.Printf("❌ Error decoding party stop request: %v", err)
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		stopped, err := parties.Stop(r.Context(), req.PartyID, req.Restore)
+		if err != nil {
+			if errors.Is(err, govee.ErrPartyNotFound) {
+				writeError(w, r, http.StatusNotFound, err.Error())
+				return
+			}
+			log.Printf("❌ Error stopping party: %v", err)
+			writeError(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, r, http.StatusOK, PartyStopResponse{Stopped: stopped})
+	}
+}
+
+// partyRoomDevices resolves rooms to the Govee lights registered in them.
+// Lights that no configured account knows about are skipped with a warning.
+// On failure it returns the HTTP status to answer with.
+func partyRoomDevices(r *http.Request, goveeClients []*govee.Client, database *sql.DB, roomIDs []string) ([]govee.PartyDevice, int, error) {
+	if database == nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("roomIds aren't supported without a database")
+	}
+
+	// Govee device ID -> where it lives, from each account's device list
+	known := make(map[string]govee.PartyDevice)
+	for apiKeyIndex, client := range goveeClients {
+		devices, err := client.GetDevicesCached(r.Context())
+		if err != nil {
+			log.Printf("❌ Party: error fetching devices from API key #%d: %v", apiKeyIndex, err)
+			// Continue with other API keys even if one fails
+			continue
+		}
+		for _, device := range devices {
+			if _, ok := known[device.Device]; !ok {
+				known[device.Device] = govee.PartyDevice{APIKeyIndex: apiKeyIndex, DeviceID: device.Device, Model: device.Model}
+			}
+		}
+	}
+
+	var devices []govee.PartyDevice
+	for _, roomID := range roomIDs {
+		if _, err := db.GetRoom(database, roomID); err != nil {
+			if isNotFound(err) {
+				return nil, http.StatusNotFound, fmt.Errorf("room not found: %s", roomID)
+			}
+			log.Printf("❌ Party: failed to load room %s: %v", roomID, err)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to load room %s", roomID)
+		}
+
+		registered, err := db.ListDevicesByRoom(database, roomID)
+		if err != nil {
+			log.Printf("❌ Party: failed to list devices in room %s: %v", roomID, err)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to list devices in room %s", roomID)
+		}
+		for _, d := range registered {
+			if d.DeviceType != "govee_light" || d.ExternalID == nil {
+				continue
+			}
+			device, ok := known[*d.ExternalID]
+			if !ok {
+				log.Printf("⚠️  Party: skipping %q in room %s, no Govee account has device %s", d.Name, roomID, *d.ExternalID)
+				continue
+			}
+			devices = append(devices, device)
+		}
+	}
+	return devices, http.StatusOK, nil
+}
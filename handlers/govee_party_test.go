@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/govee"
+)
+
+// postParty sends body to a party handler and returns the recorder.
+func postParty(handler http.HandlerFunc, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body)))
+	return w
+}
+
+func TestParty_StartByRoomAndStop(t *testing.T) {
+	clients := newGoveeStub(t, searchAccount).clients
+	parties := govee.NewPartyManager(clients)
+	t.Cleanup(parties.Shutdown)
+
+	database, err := db.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init test DB: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	// The desk lamp is registered in the living room, next to a non-Govee device.
+	profile, _ := db.CreateProfile(database, "Shakur")
+	room, _ := db.CreateRoom(database, profile.ID, "Living Room", "sofa")
+	lampID := "AA:01"
+	lamp, _ := db.CreateDevice(database, profile.ID, "Lamp", "govee_light", &lampID, nil)
+	tv, _ := db.CreateDevice(database, profile.ID, "TV", "fire_tv", nil, nil)
+	db.AssignDeviceToRoom(database, lamp.ID, room.ID)
+	db.AssignDeviceToRoom(database, tv.ID, room.ID)
+
+	start := HandleStartParty(clients, parties, database)
+	w := postParty(start, "/api/govee/party/start", `{"roomIds": ["`+room.ID+`"], "intervalMs": 15000}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var status govee.PartyStatus
+	json.NewDecoder(w.Body).Decode(&status)
+	want := govee.PartyDevice{APIKeyIndex: 0, DeviceID: "AA:01", Model: "H6008"}
+	if len(status.Devices) != 1 || status.Devices[0] != want {
+		t.Errorf("expected the room's lamp to join, got %+v", status.Devices)
+	}
+	if status.IntervalMs != 15000 || len(status.Palette) != len(govee.DefaultPartyPalette) {
+		t.Errorf("expected 15s interval and default palette, got %+v", status)
+	}
+
+	// The lamp can't join a second party while the first is running.
+	w = postParty(start, "/api/govee/party/start", `{"devices": [{"deviceId": "AA:01", "model": "H6008"}]}`)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status 409, got %d", w.Code)
+	}
+
+	stop := HandleStopParty(parties)
+	if w := postParty(stop, "/api/govee/party/stop", `{"partyId": "nope"}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown party, got %d", w.Code)
+	}
+
+	w = postParty(stop, "/api/govee/party/stop", `{"partyId": "`+status.ID+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var stopped PartyStopResponse
+	json.NewDecoder(w.Body).Decode(&stopped)
+	if len(stopped.Stopped) != 1 || stopped.Stopped[0].ID != status.ID {
+		t.Errorf("expected party %s to be stopped, got %+v", status.ID, stopped.Stopped)
+	}
+}
+
+func TestParty_StartRejectsBadRequests(t *testing.T) {
+	clients := newGoveeStub(t, searchAccount).clients
+	parties := govee.NewPartyManager(clients)
+	t.Cleanup(parties.Shutdown)
+
+	database, err := db.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init test DB: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	start := HandleStartParty(clients, parties, database)
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"no devices", `{}`, http.StatusBadRequest},
+		{"unknown room", `{"roomIds": ["missing"]}`, http.StatusNotFound},
+		{"interval too short", `{"devices": [{"deviceId": "AA:01", "model": "H6008"}], "intervalMs": 1000}`, http.StatusBadRequest},
+		{"bad account", `{"devices": [{"apiKeyIndex": 3, "deviceId": "AA:01", "model": "H6008"}]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := postParty(start, "/api/govee/party/start", tt.body); w.Code != tt.expected {
+				t.Errorf("expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/middleware"
+)
+
+// PresetRequest is the body of POST /api/govee/devices/{id}/presets and
+// PUT /api/govee/devices/{id}/presets/{name}, e.g.
+// {"name": "Reading", "state": {"on": true, "color": {"r": 255, "g": 200, "b": 150}, "brightness": 80}}
+type PresetRequest struct {
+	Name         string            `json:"name"`
+	State        govee.TargetState `json:"state"`
+	TransitionMs int               `json:"transitionMs,omitempty"` // Fade when applied (max 10000)
+}
+
+func (req PresetRequest) preset() govee.Preset {
+	return govee.Preset{Name: req.Name, State: req.State, TransitionMs: req.TransitionMs}
+}
+
+// PresetApplyResponse reports the outcome of applying a preset.
+type PresetApplyResponse struct {
+	Success   bool     `json:"success"`
+	Preview   bool     `json:"preview,omitempty"` // Nothing was sent
+	DeviceID  string   `json:"deviceId"`
+	Preset    string   `json:"preset"`
+	Offline   bool     `json:"offline,omitempty"` // Govee reported the device offline
+	Steps     []string `json:"steps"`             // Commands sent, in order
+	Error     string   `json:"error,omitempty"`
+	Timestamp string   `json:"timestamp"`
+
+	// Commands that would be sent, in order (preview only)
+	Commands []govee.PlannedCommand `json:"commands,omitempty"`
+}
+
+// HandleDevicePresets lists and creates a device's presets.
+// GET  /api/govee/devices/{id}/presets — list presets, sorted by name
+// POST /api/govee/devices/{id}/presets — create one from a PresetRequest; returns 201
+//
+// Presets are stored by device ID and don't need the device to be reachable;
+// names are unique per device (case-insensitive), so creating a duplicate
+// answers 409.
+func HandleDevicePresets(store *govee.PresetStore, listOpts ListOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deviceID := r.PathValue("id")
+
+		switch r.Method {
+		case http.MethodGet:
+			presets := store.List(deviceID)
+			if presets == nil {
+				presets = []govee.Preset{}
+			}
+			writeList(w, r, listOpts, presets, fmt.Sprintf("Found %d preset(s)", len(presets)))
+
+		case http.MethodPost:
+			var req PresetRequest
+			if err := decodeJSONBody(r, &req); err != nil {
+				writeError(w, r, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			preset, err := store.Create(deviceID, req.preset())
+			if err != nil {
+				sendPresetError(w, r, deviceID, req.Name, err)
+				return
+			}
+
+			log.Printf("💡 Created preset '%s' for %s", preset.Name, deviceID)
+			setVersionETag(w, preset.Version)
+			writeJSON(w, r, http.StatusCreated, preset)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// HandleDevicePreset replaces or deletes one preset.
+// PUT    /api/govee/devices/{id}/presets/{name} — replace it with a PresetRequest (which may rename it)
+// DELETE /api/govee/devices/{id}/presets/{name} — returns 204
+//
+// Both answer 404 if the device has no preset called {name}. PUT honors
+// If-Match with the preset's ETag (its version), answering 412 if the
+// preset changed since; with requireIfMatch, a PUT without it is a 428.
+func HandleDevicePreset(store *govee.PresetStore, requireIfMatch bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deviceID, name := r.PathValue("id"), r.PathValue("name")
+
+		switch r.Method {
+		case http.MethodPut:
+			ifVersion, ok := ifMatchVersion(w, r, requireIfMatch)
+			if !ok {
+				return
+			}
+			var req PresetRequest
+			if err := decodeJSONBody(r, &req); err != nil {
+				writeError(w, r, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			preset, err := store.Update(deviceID, name, req.preset(), ifVersion)
+			if err != nil {
+				sendPresetError(w, r, deviceID, name, err)
+				return
+			}
+
+			log.Printf("💡 Updated preset '%s' for %s (version %d)", preset.Name, deviceID, preset.Version)
+			setVersionETag(w, preset.Version)
+			writeJSON(w, r, http.StatusOK, preset)
+
+		case http.MethodDelete:
+			if err := store.Delete(deviceID, name); err != nil {
+				sendPresetError(w, r, deviceID, name, err)
+				return
+			}
+
+			log.Printf("💡 Deleted preset '%s' for %s", name, deviceID)
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// HandleApplyDevicePreset puts a device into one of its presets.
+// POST /api/govee/devices/{id}/presets/{name}/apply[?apiKeyIndex=Z][&preview=true]
+// Returns: PresetApplyResponse JSON
+//
+// The state is applied like a room scene entry: power → color → brightness
+// (see govee.ApplyState), fading over the preset's transitionMs. Successful
+// commands are recorded in optimistic, if non-nil, and drop any older
+// command retryQueue (which may be nil) holds for the device. With ?preview=true
+// nothing is sent and the response lists the commands that would run.
+func HandleApplyDevicePreset(goveeClients []*govee.Client, store *govee.PresetStore, optimistic *govee.OptimisticStates, retryQueue *govee.RetryQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		preset, err := store.Get(r.PathValue("id"), r.PathValue("name"))
+		if err != nil {
+			sendPresetError(w, r, r.PathValue("id"), r.PathValue("name"), err)
+			return
+		}
+
+		device, apiKeyIndex, status, err := resolveDevicePath(r, goveeClients)
+		if err != nil {
+			writeErrorFrom(w, r, status, err)
+			return
+		}
+
+		preview, _ := strconv.ParseBool(r.URL.Query().Get("preview"))
+		log.Printf("💡 Preset apply request - Device: %s, Preset: %s, Preview: %t - Client: %s", device.Device, preset.Name, preview, middleware.ClientIP(r))
+
+		response := PresetApplyResponse{
+			Preview:   preview,
+			DeviceID:  device.Device,
+			Preset:    preset.Name,
+			Steps:     []string{},
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+		if preview {
+			response.Success = true
+			response.Commands = preset.State.Plan()
+			writeJSON(w, r, http.StatusOK, response)
+			return
+		}
+
+		transition := time.Duration(preset.TransitionMs) * time.Millisecond
+		steps, err := goveeClients[apiKeyIndex].ApplyState(r.Context(), device.Device, device.Model, preset.State, transition)
+		response.Steps = append(response.Steps, steps...)
+		if optimistic != nil {
+			recordTargetState(optimistic, apiKeyIndex, device, preset.State, len(steps))
+		}
+		if len(steps) > 0 {
+			retryQueue.Forget(apiKeyIndex, device.Device)
+		}
+		if err != nil {
+			log.Printf("❌ Preset apply: %s '%s': %v", device.Device, preset.Name, err)
+			status, message := upstreamStatus(err, http.StatusBadGateway)
+			response.Error = message
+			response.Offline = govee.IsOfflineError(err)
+			writeJSON(w, r, status, response)
+			return
+		}
+
+		response.Success = true
+		writeJSON(w, r, http.StatusOK, response)
+	}
+}
+
+// sendPresetError answers a failed preset store operation: 404 for a
+// missing preset, 409 for a name that's taken, 412 for a stale If-Match,
+// 400 for a preset that doesn't validate, and 500 if it couldn't be saved.
+func sendPresetError(w http.ResponseWriter, r *http.Request, deviceID, name string, err error) {
+	switch {
+	case errors.Is(err, govee.ErrPresetNotFound):
+		writeError(w, r, http.StatusNotFound, fmt.Sprintf("Preset not found: %s", name))
+	case errors.Is(err, govee.ErrPresetExists):
+		writeError(w, r, http.StatusConflict, "This device already has a preset with that name")
+	case errors.Is(err, govee.ErrStalePreset):
+		sendStaleVersion(w, r, "preset")
+	case errors.Is(err, govee.ErrInvalidPreset):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("❌ Failed to save presets for %s: %v", deviceID, err)
+		writeError(w, r, http.StatusInternalServerError, "Failed to save preset")
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pantheon/artemis/govee"
+)
+
+// newPresetsMux serves the preset endpoints backed by store and the room
+// apply stub, which reports the smart plug (AA:02) offline.
+func newPresetsMux(t *testing.T, store *govee.PresetStore, optimistic *govee.OptimisticStates) *http.ServeMux {
+	clients := newGoveeStub(t, offlinePlugAccount).clients
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/govee/devices/{id}/presets", HandleDevicePresets(store, ListOptions{}))
+	mux.HandleFunc("/api/govee/devices/{id}/presets/{name}", HandleDevicePreset(store, true))
+	mux.HandleFunc("/api/govee/devices/{id}/presets/{name}/apply", HandleApplyDevicePreset(clients, store, optimistic, nil))
+	return mux
+}
+
+func servePresets(mux *http.ServeMux, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestDevicePresets_CRUD(t *testing.T) {
+	store, _ := govee.NewPresetStore("")
+	mux := newPresetsMux(t, store, nil)
+
+	w := servePresets(mux, http.MethodPost, "/api/govee/devices/AA:01/presets", `{"name": "Reading", "state": {"brightness": 80}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := servePresets(mux, http.MethodPost, "/api/govee/devices/aa-01/presets", `{"name": "reading", "state": {"brightness": 20}}`); w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for a duplicate name, got %d", w.Code)
+	}
+	if w := servePresets(mux, http.MethodPost, "/api/govee/devices/AA:01/presets", `{"name": "Loud", "state": {"brightness": 150}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid state, got %d", w.Code)
+	}
+	if w := servePresets(mux, http.MethodPost, "/api/govee/devices/AA:01/presets", `{"name": "Read/Write", "state": {"brightness": 20}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a name with a slash, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/govee/devices/AA:01/presets/reading", bytes.NewBufferString(`{"name": "Night Reading", "state": {"brightness": 30}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = servePresets(mux, http.MethodGet, "/api/govee/devices/AA:01/presets", "")
+	var presets []govee.Preset
+	json.NewDecoder(w.Body).Decode(&presets)
+	if len(presets) != 1 || presets[0].Name != "Night Reading" || *presets[0].State.Brightness != 30 {
+		t.Errorf("expected the renamed preset, got %+v", presets)
+	}
+
+	if w := servePresets(mux, http.MethodDelete, "/api/govee/devices/AA:01/presets/Night%20Reading", ""); w.Code != http.StatusNoContent {
+		t.Errorf("expected status 204, got %d", w.Code)
+	}
+	if w := servePresets(mux, http.MethodDelete, "/api/govee/devices/AA:01/presets/Night%20Reading", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a deleted preset, got %d", w.Code)
+	}
+	if w := servePresets(mux, http.MethodGet, "/api/govee/devices/AA:01/presets", ""); w.Body.String() != "[]\n" {
+		t.Errorf("expected an empty list, got %s", w.Body.String())
+	}
+}
+
+func TestDevicePreset_IfMatch(t *testing.T) {
+	store, _ := govee.NewPresetStore("")
+	mux := newPresetsMux(t, store, nil)
+
+	w := servePresets(mux, http.MethodPost, "/api/govee/devices/AA:01/presets", `{"name": "Reading", "state": {"brightness": 80}}`)
+	etag := w.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf(`expected ETag "1" on create, got %q`, etag)
+	}
+
+	put := func(ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/govee/devices/AA:01/presets/Reading", bytes.NewBufferString(body))
+		req.Header.Set("If-Match", ifMatch)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := put(etag, `{"name": "Reading", "state": {"brightness": 60}}`); w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf(`expected 200 with ETag "2", got %d %q: %s`, w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+	if w := put(etag, `{"name": "Reading", "state": {"brightness": 10}}`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected status 412 for a stale ETag, got %d: %s", w.Code, w.Body.String())
+	}
+	if preset, _ := store.Get("AA:01", "Reading"); *preset.State.Brightness != 60 {
+		t.Errorf("expected the stale update to be dropped, got brightness %d", *preset.State.Brightness)
+	}
+}
+
+func TestApplyDevicePreset_SendsOrderedCommands(t *testing.T) {
+	store, _ := govee.NewPresetStore("")
+	optimistic := govee.NewOptimisticStates("")
+	mux := newPresetsMux(t, store, optimistic)
+	servePresets(mux, http.MethodPost, "/api/govee/devices/AA:01/presets", `{"name": "Relax", "state": {"brightness": 30, "color": {"r": 255, "g": 120, "b": 0}}}`)
+
+	w := servePresets(mux, http.MethodPost, "/api/govee/devices/aa01/presets/relax/apply", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp PresetApplyResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.Success || resp.DeviceID != "AA:01" || resp.Preset != "Relax" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(resp.Steps) != 2 || resp.Steps[0] != "color 255,120,0" || resp.Steps[1] != "brightness 30" {
+		t.Errorf("expected color then brightness, got %v", resp.Steps)
+	}
+	if state, ok := optimistic.Get(0, "AA:01"); !ok || state.Brightness == nil || *state.Brightness != 30 {
+		t.Errorf("expected the applied state to be recorded, got %+v", state)
+	}
+}
+
+func TestApplyDevicePreset_Errors(t *testing.T) {
+	store, _ := govee.NewPresetStore("")
+	mux := newPresetsMux(t, store, nil)
+	servePresets(mux, http.MethodPost, "/api/govee/devices/AA:02/presets", `{"name": "Bright", "state": {"brightness": 100}}`)
+	servePresets(mux, http.MethodPost, "/api/govee/devices/FF:FF/presets", `{"name": "Bright", "state": {"brightness": 100}}`)
+
+	if w := servePresets(mux, http.MethodPost, "/api/govee/devices/AA:02/presets/Missing/apply", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown preset, got %d", w.Code)
+	}
+	if w := servePresets(mux, http.MethodPost, "/api/govee/devices/FF:FF/presets/Bright/apply", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown device, got %d", w.Code)
+	}
+
+	w := servePresets(mux, http.MethodPost, "/api/govee/devices/AA:02/presets/Bright/apply", "")
+	var resp PresetApplyResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusBadGateway || resp.Success || !resp.Offline {
+		t.Errorf("expected an offline failure with status 502, got %d: %+v", w.Code, resp)
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/pantheon/artemis/govee"
+)
+
+// skippedOfflineMessage is the error of a device a batch skipped.
+const skippedOfflineMessage = "Skipped: the device was offline when last polled"
+
+// offlineFilter returns the poller to check devices against before a batch
+// sends them commands, or nil to attempt every device. Batches (room scenes,
+// gradients, group commands) skip devices the state poller last saw offline
+// when skipByDefault is set (GOVEE_BATCH_SKIP_OFFLINE), rather than spending
+// a rate-limited command on each only to hear the same. ?skipOffline=
+// overrides skipByDefault; an invalid value is an error. A nil poller
+// attempts every device.
+func offlineFilter(r *http.Request, poller *govee.StatePoller, skipByDefault bool) (*govee.StatePoller, error) {
+	skip := skipByDefault
+	if raw := r.URL.Query().Get("skipOffline"); raw != "" {
+		var err error
+		if skip, err = strconv.ParseBool(raw); err != nil {
+			return nil, fmt.Errorf("skipOffline must be true or false, got %q", raw)
+		}
+	}
+	if !skip {
+		return nil, nil
+	}
+	return poller, nil
+}
+
+// knownOffline reports whether the poller's cached state, while still
+// fresh, says the device is offline. Devices without a fresh state are
+// assumed reachable.
+func knownOffline(poller *govee.StatePoller, apiKeyIndex int, deviceID string) bool {
+	if poller == nil {
+		return false
+	}
+	state, ok := poller.Get(apiKeyIndex, deviceID)
+	return ok && state.Online != nil && !*state.Online
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/middleware"
+)
+
+// RoomApplyRequest is the body of POST /api/rooms/{name}/apply.
+// Each entry of devices is keyed by a Govee device ID or the name of a
+// device registered in the room, e.g.
+// {"devices": {"Desk Lamp": {"on": true, "color": {"r": 255, "g": 120, "b": 0}, "brightness": 40}}, "transitionMs": 2000}
+type RoomApplyRequest struct {
+	Devices      map[string]govee.TargetState `json:"devices"`
+	TransitionMs int                          `json:"transitionMs,omitempty"` // Fade color and brightness (max 10000)
+}
+
+// RoomApplyDeviceResult is the outcome for one entry of the request.
+type RoomApplyDeviceResult struct {
+	Key      string   `json:"key"`                // As given in the request
+	DeviceID string   `json:"deviceId,omitempty"` // Govee device ID, once resolved
+	Name     string   `json:"name,omitempty"`     // Registered device name, once resolved
+	Success  bool     `json:"success"`
+	Offline  bool     `json:"offline,omitempty"` // Govee reported the device offline
+	Skipped  bool     `json:"skipped,omitempty"` // Not attempted: the state poller last saw it offline
+	Steps    []string `json:"steps"`             // Commands sent, in order
+	Error    string   `json:"error,omitempty"`
+
+	// Commands that would be sent, in order (preview only)
+	Commands []govee.PlannedCommand `json:"commands,omitempty"`
+}
+
+// RoomApplyResponse reports the outcome for every device of a room scene.
+type RoomApplyResponse struct {
+	Success   bool                    `json:"success"`           // Whether every device succeeded (or, in a preview, resolved)
+	Preview   bool                    `json:"preview,omitempty"` // Nothing was sent
+	RoomID    string                  `json:"roomId"`
+	Room      string                  `json:"room"`
+	Results   []RoomApplyDeviceResult `json:"results"`           // Sorted by key
+	Skipped   int                     `json:"skipped,omitempty"` // Devices skipped as offline
+	Timestamp string                  `json:"timestamp"`
+}
+
+// roomLight is a registered Govee light in a room.
+type roomLight struct {
+	name     string
+	deviceID string
+}
+
+// HandleApplyRoomScene puts several lights of a room into different states
+// at once.
+// POST /api/rooms/{name}/apply
+// Accepts: RoomApplyRequest JSON body
+// Returns: RoomApplyResponse JSON (200 even when some devices fail)
+//
+// {name} is a room name (case-insensitive) or room ID; when several profiles
+// have a room with that name, pass ?profileId= to pick one. Devices run
+// concurrently, each in the order power → color → brightness (see
+// govee.ApplyState), so an offline or slow device doesn't hold up the rest.
+// Successful commands are recorded in optimistic, if non-nil, and drop any
+// older command retryQueue (which may be nil) holds for the device.
+//
+// Devices the state poller last saw offline are skipped and reported with
+// skipped: true instead of using up a rate-limited command, when skipOffline
+// is set (?skipOffline= overrides it per request). poller may be nil when
+// polling is off.
+//
+// With ?preview=true nothing is sent: each device is resolved as usual and
+// its result lists the commands that would run, so the app can describe the
+// scene before applying it.
+func HandleApplyRoomScene(goveeClients []*govee.Client, database *sql.DB, optimistic *govee.OptimisticStates, retryQueue *govee.RetryQueue, poller *govee.StatePoller, skipOffline bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req RoomApplyRequest
+		if err := decodeJSONBody(r, &req); err != nil {
+			log.Printf("❌ Error decoding room apply request: %v", err)
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if len(req.Devices) == 0 {
+			writeError(w, r, http.StatusBadRequest, "devices must map at least one device to a state")
+			return
+		}
+		if req.TransitionMs < 0 {
+			writeError(w, r, http.StatusBadRequest, "transitionMs must not be negative")
+			return
+		}
+		for key, state := range req.Devices {
+			if err := state.Validate(); err != nil {
+				writeError(w, r, http.StatusBadRequest, fmt.Sprintf("%s: %v", key, err))
+				return
+			}
+		}
+		offline, err := offlineFilter(r, poller, skipOffline)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		room, status, err := findRoomByName(database, r.PathValue("name"), r.URL.Query().Get("profileId"))
+		if err != nil {
+			writeError(w, r, status, err.Error())
+			return
+		}
+
+		lights, err := roomLights(database, room.ID)
+		if err != nil {
+			log.Printf("❌ Room apply: failed to list devices in room %s: %v", room.ID, err)
+			writeError(w, r, http.StatusInternalServerError, "Failed to list devices in room")
+			return
+		}
+
+		preview, _ := strconv.ParseBool(r.URL.Query().Get("preview"))
+		log.Printf("💡 Room apply request - Room: %s, Devices: %d, Preview: %t - Client: %s", room.Name, len(req.Devices), preview, middleware.ClientIP(r))
+
+		keys := make([]string, 0, len(req.Devices))
+		for key := range req.Devices {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		transition := time.Duration(req.TransitionMs) * time.Millisecond
+		results := make([]RoomApplyDeviceResult, len(keys))
+		var wg sync.WaitGroup
+		for i, key := range keys {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = applyRoomDeviceState(r, goveeClients, optimistic, retryQueue, offline, lights, key, req.Devices[key], transition, preview)
+			}()
+		}
+		wg.Wait()
+
+		success, skipped := true, 0
+		for _, result := range results {
+			success = success && result.Success
+			if result.Skipped {
+				skipped++
+			}
+		}
+
+		writeJSON(w, r, http.StatusOK, RoomApplyResponse{
+			Success:   success,
+			Preview:   preview,
+			RoomID:    room.ID,
+			Room:      room.Name,
+			Results:   results,
+			Skipped:   skipped,
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+	}
+}
+
+// applyRoomDeviceState resolves one request entry to a light in the room
+// and applies its state (or, for a preview, lists the commands it would send).
+// If offline is non-nil, a device it last saw offline is skipped.
+func applyRoomDeviceState(r *http.Request, goveeClients []*govee.Client, optimistic *govee.OptimisticStates, retryQueue *govee.RetryQueue, offline *govee.StatePoller, lights []roomLight, key string, state govee.TargetState, transition time.Duration, preview bool) RoomApplyDeviceResult {
+	result := RoomApplyDeviceResult{Key: key, Steps: []string{}}
+
+	index := slices.IndexFunc(lights, func(light roomLight) bool {
+		return govee.SameDeviceID(light.deviceID, key) || strings.EqualFold(light.name, key)
+	})
+	if index == -1 {
+		result.Error = "Not a Govee light registered in this room"
+		return result
+	}
+	result.DeviceID, result.Name = lights[index].deviceID, lights[index].name
+
+	device, apiKeyIndex, err := findDevice(r.Context(), goveeClients, result.DeviceID, -1)
+	if err != nil {
+		if errors.Is(err, errDeviceNotFound) {
+			result.Error = "No configured Govee account has this device"
+		} else {
+			log.Printf("❌ Room apply: error resolving device %s: %v", result.DeviceID, err)
+			result.Error = "Couldn't load the Govee device list"
+		}
+		return result
+	}
+	if knownOffline(offline, apiKeyIndex, device.Device) {
+		result.Error, result.Offline, result.Skipped = skippedOfflineMessage, true, true
+		return result
+	}
+
+	if preview {
+		result.Commands = state.Plan()
+		result.Success = true
+		return result
+	}
+
+	steps, err := goveeClients[apiKeyIndex].ApplyState(r.Context(), device.Device, device.Model, state, transition)
+	result.Steps = append(result.Steps, steps...)
+	if optimistic != nil {
+		recordTargetState(optimistic, apiKeyIndex, device, state, len(steps))
+	}
+	if len(steps) > 0 {
+		retryQueue.Forget(apiKeyIndex, device.Device)
+	}
+	if err != nil {
+		log.Printf("❌ Room apply: %s: %v", result.DeviceID, err)
+		result.Error = err.Error()
+		result.Offline = govee.IsOfflineError(err)
+		return result
+	}
+
+	result.Success = true
+	return result
+}
+
+// recordTargetState records the first sent commands of a state's plan as
+// optimistic state.
+func recordTargetState(optimistic *govee.OptimisticStates, apiKeyIndex int, device govee.Device, state govee.TargetState, sent int) {
+	for _, command := range state.Plan()[:sent] {
+		// Record takes values in their decoded-JSON form
+		var value interface{}
+		switch v := command.Value.(type) {
+		case bool:
+			value = v
+		case int:
+			value = float64(v)
+		case govee.ColorValue:
+			value = map[string]interface{}{"r": float64(v.R), "g": float64(v.G), "b": float64(v.B)}
+		}
+		optimistic.Record(apiKeyIndex, device.Device, device.Model, command.Command, value)
+	}
+}
+
+// findRoomByName looks a room up by ID, or by name (case-insensitive) across
+// profiles — only profileID's rooms, if given. On failure it returns the
+// HTTP status to answer with.
+func findRoomByName(database *sql.DB, name, profileID string) (*db.Room, int, error) {
+	if room, err := db.GetRoom(database, name); err == nil {
+		return room, http.StatusOK, nil
+	} else if !isNotFound(err) {
+		log.Printf("❌ Room apply: failed to load room %s: %v", name, err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to look up room")
+	}
+
+	var profiles []db.Profile
+	if profileID != "" {
+		profile, err := db.GetProfile(database, profileID)
+		if err != nil {
+			if isNotFound(err) {
+				return nil, http.StatusNotFound, fmt.Errorf("Profile not found: %s", profileID)
+			}
+			log.Printf("❌ Room apply: failed to load profile %s: %v", profileID, err)
+			return nil, http.StatusInternalServerError, fmt.Errorf("Failed to look up room")
+		}
+		profiles = []db.Profile{*profile}
+	} else {
+		var err error
+		if profiles, err = db.ListProfiles(database); err != nil {
+			log.Printf("❌ Room apply: failed to list profiles: %v", err)
+			return nil, http.StatusInternalServerError, fmt.Errorf("Failed to look up room")
+		}
+	}
+
+	var matches []db.Room
+	for _, profile := range profiles {
+		rooms, err := db.ListRoomsByProfile(database, profile.ID)
+		if err != nil {
+			log.Printf("❌ Room apply: failed to list rooms of profile %s: %v", profile.ID, err)
+			return nil, http.StatusInternalServerError, fmt.Errorf("Failed to look up room")
+		}
+		for _, room := range rooms {
+			if strings.EqualFold(room.Name, name) {
+				matches = append(matches, room)
+			}
+		}
+	}
+
+	switch {
+	case len(matches) == 0:
+		return nil, http.StatusNotFound, fmt.Errorf("Room not found: %s", name)
+	case len(matches) > 1:
+		return nil, http.StatusConflict, fmt.Errorf("%d rooms are named %q — pass profileId or use the room ID", len(matches), name)
+	}
+	return &matches[0], http.StatusOK, nil
+}
+
+// RoomGoveeDevices resolves a room, by ID or by name (see findRoomByName),
+// to its registered Govee lights, each in the account whose device list has
+// it. Lights no account lists are skipped with a warning. For callers
+// outside a request, such as SHUTDOWN_ACTIONS that name a room.
+func RoomGoveeDevices(ctx context.Context, goveeClients []*govee.Client, database *sql.DB, room, profileID string) ([]govee.PartyDevice, error) {
+	if database == nil {
+		return nil, fmt.Errorf("rooms aren't supported without a database")
+	}
+	found, _, err := findRoomByName(database, room, profileID)
+	if err != nil {
+		return nil, err
+	}
+	lights, err := roomLights(database, found.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices in room %s: %w", found.Name, err)
+	}
+
+	var devices []govee.PartyDevice
+	for _, light := range lights {
+		device, index, err := findDevice(ctx, goveeClients, light.deviceID, -1)
+		if err != nil {
+			log.Printf("⚠️  Skipping %q in room %s: %v", light.name, found.Name, err)
+			continue
+		}
+		devices = append(devices, govee.PartyDevice{APIKeyIndex: index, DeviceID: device.Device, Model: device.Model})
+	}
+	return devices, nil
+}
+
+// roomLights lists the registered Govee lights in a room.
+func roomLights(database *sql.DB, roomID string) ([]roomLight, error) {
+	registered, err := db.ListDevicesByRoom(database, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	var lights []roomLight
+	for _, d := range registered {
+		if d.DeviceType != "govee_light" || d.ExternalID == nil {
+			continue
+		}
+		lights = append(lights, roomLight{name: d.Name, deviceID: *d.ExternalID})
+	}
+	return lights, nil
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/govee"
+)
+
+// newRoomApplyDB registers the desk lamp and smart plug in a living room.
+func newRoomApplyDB(t *testing.T) (*sql.DB, *db.Room) {
+	t.Helper()
+	database, err := db.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init test DB: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	profile, _ := db.CreateProfile(database, "Shakur")
+	room, _ := db.CreateRoom(database, profile.ID, "Living Room", "sofa")
+	for _, d := range []struct{ name, externalID string }{{"Desk Lamp", "AA:01"}, {"Plug", "AA:02"}} {
+		device, _ := db.CreateDevice(database, profile.ID, d.name, "govee_light", &d.externalID, nil)
+		db.AssignDeviceToRoom(database, device.ID, room.ID)
+	}
+	return database, room
+}
+
+// applyRoom posts body to /api/rooms/{name}/apply through a mux so the path
+// value is set.
+func applyRoom(handler http.HandlerFunc, path, body string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/rooms/{name}/apply", handler)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body)))
+	return w
+}
+
+func TestApplyRoomScene_PerDeviceResults(t *testing.T) {
+	database, room := newRoomApplyDB(t)
+	optimistic := govee.NewOptimisticStates("")
+	handler := HandleApplyRoomScene(newGoveeStub(t, offlinePlugAccount).clients, database, optimistic, nil, nil, true)
+
+	w := applyRoom(handler, "/api/rooms/living%20room/apply", `{"devices": {
+		"desk lamp": {"color": {"r": 255, "g": 120, "b": 0}, "brightness": 40},
+		"AA:02": {"on": true},
+		"Hallway": {"on": false}
+	}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp RoomApplyResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Success || resp.RoomID != room.ID || len(resp.Results) != 3 {
+		t.Fatalf("expected a partial failure for room %s with 3 results, got %+v", room.ID, resp)
+	}
+
+	// Results are sorted by key: "AA:02", "Hallway", "desk lamp"
+	plug, hallway, lamp := resp.Results[0], resp.Results[1], resp.Results[2]
+	if plug.Success || !plug.Offline || plug.Name != "Plug" {
+		t.Errorf("expected the plug to be reported offline, got %+v", plug)
+	}
+	if hallway.Success || hallway.DeviceID != "" || hallway.Error == "" {
+		t.Errorf("expected an error for a device not in the room, got %+v", hallway)
+	}
+	if !lamp.Success || lamp.DeviceID != "AA:01" || strings.Join(lamp.Steps, "; ") != "color 255,120,0; brightness 40" {
+		t.Errorf("expected the lamp's color then brightness, got %+v", lamp)
+	}
+
+	state, ok := optimistic.Get(0, "AA:01")
+	if !ok || state.Brightness == nil || *state.Brightness != 40 {
+		t.Errorf("expected the lamp's brightness to be recorded, got %+v", state)
+	}
+}
+
+func TestApplyRoomScene_RejectsBadRequests(t *testing.T) {
+	database, _ := newRoomApplyDB(t)
+	handler := HandleApplyRoomScene(newGoveeStub(t, offlinePlugAccount).clients, database, nil, nil, nil, true)
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"no devices", "/api/rooms/Living%20Room/apply", `{"devices": {}}`, http.StatusBadRequest},
+		{"empty state", "/api/rooms/Living%20Room/apply", `{"devices": {"Desk Lamp": {}}}`, http.StatusBadRequest},
+		{"bad brightness", "/api/rooms/Living%20Room/apply", `{"devices": {"Desk Lamp": {"brightness": 150}}}`, http.StatusBadRequest},
+		{"negative transition", "/api/rooms/Living%20Room/apply", `{"devices": {"Desk Lamp": {"on": true}}, "transitionMs": -1}`, http.StatusBadRequest},
+		{"unknown room", "/api/rooms/Garage/apply", `{"devices": {"Desk Lamp": {"on": true}}}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := applyRoom(handler, tt.path, tt.body); w.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestApplyRoomScene_AmbiguousRoomName(t *testing.T) {
+	database, room := newRoomApplyDB(t)
+	other, _ := db.CreateProfile(database, "Guest")
+	db.CreateRoom(database, other.ID, "Living Room", "sofa")
+	handler := HandleApplyRoomScene(newGoveeStub(t, offlinePlugAccount).clients, database, nil, nil, nil, true)
+
+	body := `{"devices": {"Desk Lamp": {"brightness": 10}}}`
+	if w := applyRoom(handler, "/api/rooms/Living%20Room/apply", body); w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for a name in two profiles, got %d", w.Code)
+	}
+	if w := applyRoom(handler, "/api/rooms/Living%20Room/apply?profileId="+room.ProfileID, body); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 with profileId, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := applyRoom(handler, "/api/rooms/"+room.ID+"/apply", body); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 by room ID, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestApplyRoomScene_PreviewSendsNothing(t *testing.T) {
+	database, _ := newRoomApplyDB(t)
+
+	var controls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			atomic.AddInt32(&controls, 1)
+		}
+		w.Write([]byte(searchDevicesBody))
+	}))
+	t.Cleanup(server.Close)
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(server.URL)
+	handler := HandleApplyRoomScene([]*govee.Client{client}, database, nil, nil, nil, true)
+
+	w := applyRoom(handler, "/api/rooms/Living%20Room/apply?preview=true", `{"devices": {
+		"Desk Lamp": {"brightness": 30, "on": true, "color": {"r": 255, "g": 180, "b": 100}},
+		"aa-02": {"on": false, "brightness": 10},
+		"Hallway": {"on": true}
+	}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if n := atomic.LoadInt32(&controls); n != 0 {
+		t.Errorf("expected no control commands in a preview, got %d", n)
+	}
+
+	var resp RoomApplyResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.Preview || resp.Success || len(resp.Results) != 3 {
+		t.Fatalf("expected a preview with an unresolved device, got %+v", resp)
+	}
+
+	// Sorted by key: "Desk Lamp", "Hallway", "aa-02"
+	lamp, hallway, plug := resp.Results[0], resp.Results[1], resp.Results[2]
+	var steps []string
+	for _, command := range lamp.Commands {
+		steps = append(steps, command.Step)
+	}
+	if got := strings.Join(steps, "; "); got != "turn on; color 255,180,100; brightness 30" {
+		t.Errorf("expected the lamp's ordered commands, got %q", got)
+	}
+	if !lamp.Success || len(lamp.Steps) != 0 {
+		t.Errorf("expected the lamp to resolve with nothing sent, got %+v", lamp)
+	}
+	if hallway.Success || hallway.Commands != nil {
+		t.Errorf("expected no commands for a device not in the room, got %+v", hallway)
+	}
+	if plug.DeviceID != "AA:02" || len(plug.Commands) != 2 || plug.Commands[1].Step != "turn off" {
+		t.Errorf("expected the plug to resolve by ID and turn off last, got %+v", plug)
+	}
+}
+
+// newOfflinePoller returns a state poller whose cache says deviceID is
+// offline.
+func newOfflinePoller(t *testing.T, deviceID, model string) *govee.StatePoller {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code": 200, "data": {"device": "` + deviceID + `", "model": "` + model + `", "properties": [{"online": false}]}}`))
+	}))
+	t.Cleanup(server.Close)
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(server.URL)
+
+	poller := govee.NewStatePoller([]*govee.Client{client}, time.Minute, 0)
+	if _, err := poller.Refresh(context.Background(), 0, deviceID, model); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	return poller
+}
+
+func TestApplyRoomScene_SkipsKnownOfflineDevices(t *testing.T) {
+	database, _ := newRoomApplyDB(t)
+	poller := newOfflinePoller(t, "AA:02", "H5080")
+	handler := HandleApplyRoomScene(newGoveeStub(t, offlinePlugAccount).clients, database, nil, nil, poller, true)
+	body := `{"devices": {"Desk Lamp": {"on": true}, "Plug": {"on": true}}}`
+
+	w := applyRoom(handler, "/api/rooms/Living%20Room/apply", body)
+	var resp RoomApplyResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Skipped != 1 || len(resp.Results) != 2 {
+		t.Fatalf("expected one skipped device, got %+v", resp)
+	}
+	lamp, plug := resp.Results[0], resp.Results[1]
+	if !lamp.Success || lamp.Skipped {
+		t.Errorf("expected the lamp to be applied, got %+v", lamp)
+	}
+	if !plug.Skipped || !plug.Offline || len(plug.Steps) != 0 || plug.Error != skippedOfflineMessage {
+		t.Errorf("expected the plug to be skipped without sending anything, got %+v", plug)
+	}
+
+	// Attempting anyway reaches Govee, which reports it offline
+	w = applyRoom(handler, "/api/rooms/Living%20Room/apply?skipOffline=false", body)
+	resp = RoomApplyResponse{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if plug := resp.Results[1]; resp.Skipped != 0 || plug.Skipped || !plug.Offline || plug.Error == skippedOfflineMessage {
+		t.Errorf("expected the plug to be attempted, got %+v", plug)
+	}
+
+	if w := applyRoom(handler, "/api/rooms/Living%20Room/apply?skipOffline=maybe", body); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid skipOffline, got %d", w.Code)
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/govee"
+)
+
+// HandleSearchDevices finds Govee devices by partial name or model.
+// GET /api/govee/devices/search?q=lamp&capability=color
+// Returns: JSON array of matching DeviceResponse objects (empty if none match)
+//
+// q is matched case-insensitively as a substring of the Govee device name,
+// the model, and any name the device was given locally (a registered
+// "govee_light" device whose externalId is the Govee device ID).
+// capability (repeatable or comma-separated) keeps only devices supporting
+// every listed command, e.g. capability=color. At least one of q or capability is required.
+//
+// Search-as-you-type fires a request per keystroke, so devices come from
+// the client's cached list (see govee.Client.GetDevicesCached), and results
+// may lag a new device by up to the cache TTL.
+func HandleSearchDevices(goveeClients []*govee.Client, database *sql.DB, listOpts ListOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept GET requests
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+		capabilities := capabilityFilter(r)
+		if query == "" && len(capabilities) == 0 {
+			writeError(w, r, http.StatusBadRequest, "Provide a search term (q) and/or a capability filter")
+			return
+		}
+
+		localNames := localDeviceNames(database)
+
+		matches := []DeviceResponse{}
+		for apiKeyIndex, client := range goveeClients {
+			devices, err := client.GetDevicesCached(r.Context())
+			if err != nil {
+				log.Printf("❌ Device search: error fetching devices from API key #%d: %v", apiKeyIndex, err)
+				// Continue with other API keys even if one fails
+				continue
+			}
+
+			for _, device := range devices {
+				if !supportsAll(device, capabilities) {
+					continue
+				}
+				if query != "" && !matchesQuery(device, localNames[device.Device], query) {
+					continue
+				}
+				matches = append(matches, newDeviceResponse(client, apiKeyIndex, device))
+			}
+		}
+
+		log.Printf("💡 Device search q=%q capability=%v: %d match(es)", query, capabilities, len(matches))
+		writeList(w, r, listOpts, matches, fmt.Sprintf("Found %d matching device(s)", len(matches)))
+	}
+}
+
+// localDeviceNames maps Govee device IDs to the names users gave them when
+// registering them as devices. Lookup failures are logged and treated as
+// "no local names" so search still works off the Govee names.
+func localDeviceNames(database *sql.DB) map[string][]string {
+	names := make(map[string][]string)
+	if database == nil {
+		return names
+	}
+
+	registered, err := db.ListDevicesByType(database, "govee_light")
+	if err != nil {
+		log.Printf("⚠️  Device search: failed to load local device names: %v", err)
+		return names
+	}
+	for _, d := range registered {
+		if d.ExternalID != nil {
+			names[*d.ExternalID] = append(names[*d.ExternalID], d.Name)
+		}
+	}
+	return names
+}
+
+// matchesQuery reports whether the lowercase query is a substring of the
+// device's Govee name, model, or any of its local names.
+func matchesQuery(device govee.Device, localNames []string, query string) bool {
+	candidates := append([]string{device.DeviceName, device.Model}, localNames...)
+	for _, candidate := range candidates {
+		if strings.Contains(strings.ToLower(candidate), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// capabilityFilter returns the commands named by the request's capability
+// parameters, which may be repeated and/or comma-separated.
+func capabilityFilter(r *http.Request) []string {
+	var commands []string
+	for _, param := range r.URL.Query()["capability"] {
+		for _, cmd := range strings.Split(param, ",") {
+			if cmd = strings.TrimSpace(cmd); cmd != "" {
+				commands = append(commands, cmd)
+			}
+		}
+	}
+	return commands
+}
+
+// supportsAll reports whether the device supports every listed command
+// (compared case-insensitively).
+func supportsAll(device govee.Device, commands []string) bool {
+	for _, cmd := range commands {
+		found := false
+		for _, supported := range device.SupportCmds {
+			if strings.EqualFold(supported, cmd) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
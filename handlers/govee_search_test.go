@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/pantheon/artemis/db"
+)
+
+// searchDevicesBody is a v1 device list with a color bulb and a plain plug.
+const searchDevicesBody = `{"code": 200, "message": "Success", "data": {"devices": [
+	{"device": "AA:01", "model": "H6008", "deviceName": "Desk Lamp", "supportCmds": ["turn", "brightness", "color"]},
+	{"device": "AA:02", "model": "H5080", "deviceName": "Smart Plug", "supportCmds": ["turn"]}
+]}}`
+
+// searchDevices runs a search request and returns the matching device IDs.
+func searchDevices(t *testing.T, handler http.HandlerFunc, rawQuery string) (int, []string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices/search?"+rawQuery, nil))
+
+	var results []DeviceResponse
+	json.NewDecoder(w.Body).Decode(&results)
+	ids := []string{}
+	for _, d := range results {
+		ids = append(ids, d.ID)
+	}
+	return w.Code, ids
+}
+
+func TestSearchDevices(t *testing.T) {
+	stub := newGoveeStub(t, searchAccount)
+	clients := stub.clients
+
+	database, err := db.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init test DB: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	// The plug was registered locally as "Christmas Tree".
+	profile, _ := db.CreateProfile(database, "Shakur")
+	plugID := "AA:02"
+	db.CreateDevice(database, profile.ID, "Christmas Tree", "govee_light", &plugID, nil)
+
+	handler := HandleSearchDevices(clients, database, ListOptions{})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"name substring, case-insensitive", "q=desk", []string{"AA:01"}},
+		{"model", "q=h50", []string{"AA:02"}},
+		{"local name", "q=tree", []string{"AA:02"}},
+		{"capability only", "capability=color", []string{"AA:01"}},
+		{"query and capability", "q=smart&capability=color", []string{}},
+		{"no match", "q=garage", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, ids := searchDevices(t, handler, tt.query)
+			if code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", code)
+			}
+			if len(ids) != len(tt.expected) || (len(ids) > 0 && ids[0] != tt.expected[0]) {
+				t.Errorf("expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+
+	// Every search after the first is served from the cached device list.
+	if n := stub.listCalls(); n != 1 {
+		t.Errorf("expected the device list to be fetched once, got %d", n)
+	}
+}
+
+func TestSearchDevices_RequiresFilter(t *testing.T) {
+	clients := newGoveeStub(t, searchAccount).clients
+
+	code, _ := searchDevices(t, HandleSearchDevices(clients, nil, ListOptions{}), "q=")
+	if code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", code)
+	}
+}
+
+func TestGetDevices_Envelope(t *testing.T) {
+	clients := newGoveeStub(t, searchAccount).clients
+
+	w := httptest.NewRecorder()
+	HandleGetDevices(clients, AccountLabels{}, nil, ListOptions{Envelope: true})(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices", nil))
+
+	var resp struct {
+		Success bool             `json:"success"`
+		Data    []DeviceResponse `json:"data"`
+		Message string           `json:"message"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("expected an envelope, got decode error: %v", err)
+	}
+	if !resp.Success || len(resp.Data) != 2 || resp.Message != "Found 2 device(s)" {
+		t.Errorf("expected 2 devices in a successful envelope, got %+v", resp)
+	}
+}
+
+func TestGetDevices_CapabilityFlags(t *testing.T) {
+	clients := newGoveeStub(t, searchAccount).clients
+
+	w := httptest.NewRecorder()
+	HandleGetDevices(clients, AccountLabels{}, nil, ListOptions{})(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices", nil))
+
+	var devices []DeviceResponse
+	if err := json.NewDecoder(w.Body).Decode(&devices); err != nil || len(devices) != 2 {
+		t.Fatalf("expected 2 devices, got %v (%v)", devices, err)
+	}
+	if d := devices[0]; !d.CanToggle || !d.CanDim || !d.CanColor || d.CanColorTemp || len(d.Capabilities) != 3 {
+		t.Errorf("expected the bulb to toggle, dim and color but not set color temperature, got %+v", d)
+	}
+	if d := devices[1]; !d.CanToggle || d.CanDim || d.CanColor || d.CanColorTemp {
+		t.Errorf("expected the plug to only toggle, got %+v", d)
+	}
+}
+
+func TestGetDevices_CapabilityFilter(t *testing.T) {
+	clients := newGoveeStub(t, searchAccount).clients
+	handler := HandleGetDevices(clients, AccountLabels{}, nil, ListOptions{})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"absent", "", []string{"AA:01", "AA:02"}},
+		{"single", "capability=color", []string{"AA:01"}},
+		{"multiple", "capability=turn,brightness", []string{"AA:01"}},
+		{"shared", "capability=turn", []string{"AA:01", "AA:02"}},
+		{"no match", "capability=color,colorTem", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices?"+tt.query, nil))
+
+			var devices []DeviceResponse
+			if err := json.NewDecoder(w.Body).Decode(&devices); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			ids := []string{}
+			for _, d := range devices {
+				ids = append(ids, d.ID)
+			}
+			if !slices.Equal(ids, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pantheon/artemis/govee"
+)
+
+// goveeAccount describes one fake Govee account served by newGoveeStub.
+type goveeAccount struct {
+	name    string // prefixes this account's entries in goveeStub.commands
+	devices string // device list body
+	offline string // device ID whose control commands fail as offline
+}
+
+var (
+	// searchAccount lists the desk lamp (AA:01) and smart plug (AA:02).
+	searchAccount = goveeAccount{devices: searchDevicesBody}
+	// offlinePlugAccount is searchAccount with the smart plug offline.
+	offlinePlugAccount = goveeAccount{devices: searchDevicesBody, offline: "AA:02"}
+)
+
+// goveeStub records the requests made to the accounts behind clients.
+type goveeStub struct {
+	clients []*govee.Client
+
+	mu    sync.Mutex
+	lists int
+	sent  []string
+	ids   []string
+}
+
+// newGoveeStub returns one client per account. State reads report the
+// device on; control commands are recorded before they are answered.
+func newGoveeStub(t *testing.T, accounts ...goveeAccount) *goveeStub {
+	t.Helper()
+	stub := &goveeStub{}
+	for _, account := range accounts {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			stub.mu.Lock()
+			defer stub.mu.Unlock()
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/state"):
+				w.Write([]byte(`{"code": 200, "data": {"device": "AA:01", "model": "H6008", "properties": [{"powerState": "on"}]}}`))
+			case r.Method == http.MethodPut:
+				var req govee.ControlRequest
+				json.NewDecoder(r.Body).Decode(&req)
+				stub.sent = append(stub.sent, strings.TrimSpace(account.name+" "+req.Device+" "+req.Model+" "+req.Cmd.Name))
+				stub.ids = append(stub.ids, req.Device)
+				if req.Device == account.offline {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"code": 400, "message": "Device Offline"}`))
+					return
+				}
+				w.Write([]byte(`{"code": 200, "message": "Success"}`))
+			default:
+				stub.lists++
+				w.Write([]byte(account.devices))
+			}
+		}))
+		t.Cleanup(server.Close)
+
+		client := govee.NewClient("test-key")
+		client.SetBaseURL(server.URL)
+		stub.clients = append(stub.clients, client)
+	}
+	return stub
+}
+
+// listCalls returns how many times a device list was fetched.
+func (s *goveeStub) listCalls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lists
+}
+
+// commands returns the control commands sent, as
+// "<account> <device> <model> <command>".
+func (s *goveeStub) commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.sent...)
+}
+
+// deviceIDs returns the device ID of each control command sent.
+func (s *goveeStub) deviceIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.ids...)
+}
@@ -0,0 +1,544 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/events"
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/middleware"
+)
+
+// newOfflineGoveeClients returns clients whose every control command fails
+// with Govee's "Device Offline" error.
+func newOfflineGoveeClients(t *testing.T) []*govee.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code": 400, "message": "Device Offline"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(server.URL)
+	return []*govee.Client{client}
+}
+
+func TestControlDevice_QueuesOfflineCommand(t *testing.T) {
+	clients := newOfflineGoveeClients(t)
+	poller := govee.NewStatePoller(clients, time.Minute, 0)
+	queue := govee.NewRetryQueue(clients, poller, time.Minute, 3)
+
+	body := `{"deviceId": "AA:BB", "model": "H6008", "command": "turn", "value": true}`
+	w := httptest.NewRecorder()
+	HandleControlDevice(clients, queue, nil, nil, nil)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(body)))
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ControlResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.Queued || resp.Success {
+		t.Errorf("expected queued=true success=false, got %+v", resp)
+	}
+	if pending := queue.Pending(); len(pending) != 1 || pending[0].DeviceID != "AA:BB" {
+		t.Errorf("expected the command to be queued, got %+v", pending)
+	}
+}
+
+func TestControlDevice_SuccessDropsQueuedCommand(t *testing.T) {
+	offline := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if offline {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code": 400, "message": "Device Offline"}`))
+			return
+		}
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	}))
+	t.Cleanup(server.Close)
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(server.URL)
+	client.SetDeviceCheck(false) // The stub has no device list
+	clients := []*govee.Client{client}
+	queue := govee.NewRetryQueue(clients, govee.NewStatePoller(clients, time.Minute, 0), time.Minute, 3)
+	handler := HandleControlDevice(clients, queue, nil, nil, nil)
+
+	on := `{"deviceId": "AA:BB", "model": "H6008", "command": "turn", "value": true}`
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(on)))
+	if w.Code != http.StatusAccepted || len(queue.Pending()) != 1 {
+		t.Fatalf("expected \"on\" to be queued, got %d with %d pending", w.Code, len(queue.Pending()))
+	}
+
+	offline = false
+	off := `{"deviceId": "AA:BB", "model": "H6008", "command": "turn", "value": false}`
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(off)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if pending := queue.Pending(); len(pending) != 0 {
+		t.Errorf("expected the queued \"on\" to be dropped, got %+v", pending)
+	}
+}
+
+func TestControlDevice_OfflineWithoutQueueFails(t *testing.T) {
+	clients := newOfflineGoveeClients(t)
+
+	body := `{"deviceId": "AA:BB", "model": "H6008", "command": "turn", "value": true}`
+	w := httptest.NewRecorder()
+	HandleControlDevice(clients, nil, nil, nil, nil)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(body)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+	var resp ControlResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Code != errcode.DeviceOffline {
+		t.Errorf("expected code DEVICE_OFFLINE, got %q", resp.Code)
+	}
+}
+
+func TestControlDevice_ErrorCodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"code": 429, "message": "Too Many Requests"}`))
+	}))
+	t.Cleanup(server.Close)
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(server.URL)
+	clients := []*govee.Client{client}
+
+	tests := map[string]struct {
+		body string
+		want errcode.Code
+	}{
+		"rate limited":    {`{"deviceId": "AA:BB", "model": "H6008", "command": "turn", "value": true}`, errcode.GoveeRateLimited},
+		"invalid command": {`{"deviceId": "AA:BB", "model": "H6008", "command": "blink", "value": true}`, errcode.InvalidCommand},
+		"bad value":       {`{"deviceId": "AA:BB", "model": "H6008", "command": "brightness", "value": 150}`, errcode.InvalidCommand},
+		"bad colorTem":    {`{"deviceId": "AA:BB", "model": "H6008", "command": "colorTem", "value": 12000}`, errcode.InvalidCommand},
+		"bad api key":     {`{"deviceId": "AA:BB", "model": "H6008", "command": "turn", "value": true, "apiKeyIndex": 3}`, errcode.InvalidRequest},
+	}
+	for name, tt := range tests {
+		w := httptest.NewRecorder()
+		HandleControlDevice(clients, nil, nil, nil, nil)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(tt.body)))
+		var resp ControlResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		if resp.Success || resp.Code != tt.want {
+			t.Errorf("%s: expected code %s, got %d %+v", name, tt.want, w.Code, resp)
+		}
+	}
+}
+
+func TestControlDevice_GoveeUnreachable(t *testing.T) {
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(closedPortURL(t))
+
+	body := `{"deviceId": "AA:BB", "model": "H6008", "command": "turn", "value": true}`
+	w := httptest.NewRecorder()
+	HandleControlDevice([]*govee.Client{client}, nil, nil, nil, nil)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(body)))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ControlResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Message != "Govee API is not running (connection refused)" {
+		t.Errorf("unexpected message %q", resp.Message)
+	}
+	if resp.Code != errcode.ServiceUnavailable {
+		t.Errorf("expected code SERVICE_UNAVAILABLE, got %q", resp.Code)
+	}
+}
+
+func TestControlDevice_PublishesFailures(t *testing.T) {
+	clients := newOfflineGoveeClients(t)
+	broker := events.NewBroker(10)
+	_, _, live, cancel := broker.Subscribe(0)
+	defer cancel()
+
+	body := `{"deviceId": "AA:BB", "model": "H6008", "command": "turn", "value": true}`
+	w := httptest.NewRecorder()
+	HandleControlDevice(clients, nil, nil, nil, broker)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(body)))
+
+	select {
+	case event := <-live:
+		failure, ok := event.Data.(CommandFailedEvent)
+		if event.Type != "device.command_failed" || !ok || failure.DeviceID != "AA:BB" || failure.Error == "" {
+			t.Errorf("expected a device.command_failed event for AA:BB, got %+v", event)
+		}
+	default:
+		t.Error("expected the failure to be published")
+	}
+}
+
+func TestControlDevice_TimeoutMs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	}))
+	t.Cleanup(server.Close)
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(server.URL)
+
+	body := `{"deviceId": "AA:BB", "model": "H6008", "command": "turn", "value": true, "timeoutMs": 50}`
+	w := httptest.NewRecorder()
+	start := time.Now()
+	HandleControlDevice([]*govee.Client{client}, nil, nil, nil, nil)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(body)))
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected status 504, got %d: %s", w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("expected the command to give up at timeoutMs, took %s", elapsed)
+	}
+	var resp ControlResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.TimedOut || resp.Success || resp.Code != errcode.UpstreamTimeout {
+		t.Errorf("expected timedOut=true success=false code=UPSTREAM_TIMEOUT, got %+v", resp)
+	}
+}
+
+func TestControlDevice_RejectsNegativeTimeoutMs(t *testing.T) {
+	clients := newOfflineGoveeClients(t)
+
+	body := `{"deviceId": "AA:BB", "model": "H6008", "command": "turn", "value": true, "timeoutMs": -1}`
+	w := httptest.NewRecorder()
+	HandleControlDevice(clients, nil, nil, nil, nil)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(body)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestGetDeviceState_ValidatesAPIKeyIndex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code": 200, "data": {"device": "AA:BB", "model": "H6008", "properties": [{"powerState": "on"}]}}`))
+	}))
+	t.Cleanup(server.Close)
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(server.URL)
+	handler := HandleGetDeviceState([]*govee.Client{client}, nil, nil)
+
+	tests := []struct {
+		name     string
+		index    string
+		expected int
+	}{
+		{"trailing garbage", "1abc", http.StatusBadRequest},
+		{"negative", "-1", http.StatusBadRequest},
+		{"out of range", "99", http.StatusBadRequest},
+		{"empty defaults to primary", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			url := "/api/govee/devices/state?deviceId=AA:BB&model=H6008&apiKeyIndex=" + tt.index
+			handler(w, httptest.NewRequest(http.MethodGet, url, nil))
+			if w.Code != tt.expected {
+				t.Errorf("expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetDeviceState_BrightnessAndColor(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties string
+		expected   string
+	}{
+		{"color", `[{"online": true}, {"powerState": "on"}, {"brightness": 65}, {"color": {"r": 255, "g": 120, "b": 0}}]`,
+			`{"deviceId":"AA:BB","isOn":true,"brightness":65,"color":{"r":255,"g":120,"b":0},"source":"live"}`},
+		{"white mode", `[{"powerState": "on"}, {"brightness": 40}, {"colorTemInKelvin": 2700}]`,
+			`{"deviceId":"AA:BB","isOn":true,"brightness":40,"colorTem":2700,"source":"live"}`},
+		{"not reported", `[{"online": "true"}, {"powerState": "off"}]`,
+			`{"deviceId":"AA:BB","isOn":false,"source":"live"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"code": 200, "data": {"device": "AA:BB", "model": "H6008", "properties": ` + tt.properties + `}}`))
+			}))
+			t.Cleanup(server.Close)
+			client := govee.NewClient("test-key")
+			client.SetBaseURL(server.URL)
+
+			w := httptest.NewRecorder()
+			HandleGetDeviceState([]*govee.Client{client}, nil, nil)(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices/state?deviceId=AA:BB&model=H6008", nil))
+
+			if got := strings.TrimSpace(w.Body.String()); w.Code != http.StatusOK || got != tt.expected {
+				t.Errorf("expected %s, got %d %s", tt.expected, w.Code, got)
+			}
+		})
+	}
+}
+
+func TestGetDeviceState_ServesOptimisticStateForUnreadableDevice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/state") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code": 400, "message": "device not support retrieve"}`))
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"code": 200, "data": {"devices": [{"device": "AA:BB", "model": "H6008", "deviceName": "Lamp"}]}}`))
+			return
+		}
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	}))
+	t.Cleanup(server.Close)
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(server.URL)
+	clients := []*govee.Client{client}
+	optimistic := govee.NewOptimisticStates("")
+
+	getState := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		HandleGetDeviceState(clients, nil, optimistic)(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices/state?deviceId=AA:BB&model=H6008", nil))
+		return w
+	}
+
+	// Nothing commanded yet, so there's nothing to show
+	if w := getState(); w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500 before any command, got %d", w.Code)
+	}
+
+	body := `{"deviceId": "AA:BB", "model": "H6008", "command": "turn", "value": true}`
+	w := httptest.NewRecorder()
+	HandleControlDevice(clients, nil, optimistic, nil, nil)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected control to succeed, got %d", w.Code)
+	}
+
+	w = getState()
+	var resp StateResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || !resp.IsOn || resp.Source != "optimistic" {
+		t.Errorf("expected an optimistic on state, got %d %+v", w.Code, resp)
+	}
+}
+
+// deviceListAccount lists a desk lamp (which lists no commands) and a strip
+// that can't do color.
+var deviceListAccount = goveeAccount{devices: `{"code": 200, "data": {"devices": [
+	{"device": "AA:BB:CC:DD:EE:FF:00:11", "model": "H6008", "deviceName": "Desk Lamp"},
+	{"device": "11:22:33:44:55:66:77:88", "model": "H6159", "deviceName": "TV Strip", "supportCmds": ["turn", "brightness"]}
+]}}`}
+
+func TestControlDevice_UnknownDeviceID(t *testing.T) {
+	stub := newGoveeStub(t, deviceListAccount)
+	clients := stub.clients
+
+	body := `{"deviceId": "AA:BB:CC:DD:EE:FF:00:12", "model": "H6008", "command": "turn", "value": true}`
+	w := httptest.NewRecorder()
+	HandleControlDevice(clients, nil, nil, nil, nil)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(body)))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ControlResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !strings.Contains(resp.Message, "not found in account 0") || resp.Code != errcode.DeviceNotFound {
+		t.Errorf("expected a DEVICE_NOT_FOUND message naming the account, got %q %q", resp.Code, resp.Message)
+	}
+	if len(resp.Suggestions) != 2 || resp.Suggestions[0].DeviceID != "AA:BB:CC:DD:EE:FF:00:11" {
+		t.Errorf("expected the desk lamp to be suggested first, got %+v", resp.Suggestions)
+	}
+	if len(stub.deviceIDs()) != 0 {
+		t.Errorf("expected nothing to be sent to Govee, got %v", stub.deviceIDs())
+	}
+}
+
+func TestControlDevice_ChecksModelAndCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		deviceCheck bool
+		status      int
+		message     string
+	}{
+		{"unsupported command", `{"deviceId": "11:22:33:44:55:66:77:88", "model": "H6159", "command": "color", "value": {"r": 255, "g": 0, "b": 0}}`,
+			true, http.StatusBadRequest, "device does not support command 'color'"},
+		{"wrong model", `{"deviceId": "11:22:33:44:55:66:77:88", "model": "H6008", "command": "turn", "value": true}`,
+			true, http.StatusBadRequest, "device 11:22:33:44:55:66:77:88 is model H6159, not H6008"},
+		{"supported command", `{"deviceId": "11:22:33:44:55:66:77:88", "model": "h6159", "command": "brightness", "value": 50}`,
+			true, http.StatusOK, "Device controlled successfully"},
+		{"no listed commands", `{"deviceId": "AA:BB:CC:DD:EE:FF:00:11", "model": "H6008", "command": "color", "value": {"r": 255, "g": 0, "b": 0}}`,
+			true, http.StatusOK, "Device controlled successfully"},
+		{"check disabled", `{"deviceId": "11:22:33:44:55:66:77:88", "model": "H6008", "command": "color", "value": {"r": 255, "g": 0, "b": 0}}`,
+			false, http.StatusOK, "Device controlled successfully"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newGoveeStub(t, deviceListAccount)
+			clients := stub.clients
+			clients[0].SetDeviceCheck(tt.deviceCheck)
+
+			w := httptest.NewRecorder()
+			HandleControlDevice(clients, nil, nil, nil, nil)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(tt.body)))
+
+			var resp ControlResponse
+			json.NewDecoder(w.Body).Decode(&resp)
+			if w.Code != tt.status || resp.Message != tt.message {
+				t.Fatalf("expected %d %q, got %d %q", tt.status, tt.message, w.Code, resp.Message)
+			}
+			if tt.status == http.StatusBadRequest {
+				if resp.Code != errcode.InvalidCommand {
+					t.Errorf("expected code %s, got %s", errcode.InvalidCommand, resp.Code)
+				}
+				if len(stub.deviceIDs()) != 0 {
+					t.Errorf("expected nothing to be sent to Govee, got %v", stub.deviceIDs())
+				}
+			}
+		})
+	}
+}
+
+func TestControlDevice_NormalizesDeviceID(t *testing.T) {
+	stub := newGoveeStub(t, deviceListAccount)
+	clients := stub.clients
+
+	// Wrong case and separators, and no model: both come from the device list
+	body := `{"deviceId": "aa-bb-cc-dd-ee-ff-00-11", "command": "turn", "value": true}`
+	w := httptest.NewRecorder()
+	HandleControlDevice(clients, nil, nil, nil, nil)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ControlResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.DeviceID != "AA:BB:CC:DD:EE:FF:00:11" {
+		t.Errorf("expected the canonical device ID in the response, got %q", resp.DeviceID)
+	}
+	if len(stub.deviceIDs()) != 1 || stub.deviceIDs()[0] != "AA:BB:CC:DD:EE:FF:00:11" {
+		t.Errorf("expected the canonical ID to be sent to Govee, got %v", stub.deviceIDs())
+	}
+}
+
+func TestControlDevice_ValidDeviceID(t *testing.T) {
+	stub := newGoveeStub(t, deviceListAccount)
+	clients := stub.clients
+
+	body := `{"deviceId": "11:22:33:44:55:66:77:88", "model": "H6159", "command": "turn", "value": false}`
+	w := httptest.NewRecorder()
+	HandleControlDevice(clients, nil, nil, nil, nil)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK || len(stub.deviceIDs()) != 1 {
+		t.Fatalf("expected the command to be sent, got %d (sent %v): %s", w.Code, stub.deviceIDs(), w.Body.String())
+	}
+}
+
+func TestControlDevice_NumericStringValues(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      string
+		wantStatus int
+		wantSent   int
+	}{
+		{"brightness as string", `"50"`, http.StatusOK, 1},
+		{"brightness as float", `50.0`, http.StatusOK, 1},
+		{"garbage brightness", `"bright"`, http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newGoveeStub(t, deviceListAccount)
+			clients := stub.clients
+
+			body := `{"deviceId": "AA:BB:CC:DD:EE:FF:00:11", "model": "H6008", "command": "brightness", "value": ` + tt.value + `}`
+			w := httptest.NewRecorder()
+			HandleControlDevice(clients, nil, nil, nil, nil)(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(body)))
+
+			if w.Code != tt.wantStatus || len(stub.deviceIDs()) != tt.wantSent {
+				t.Fatalf("expected status %d with %d command(s) sent, got %d (sent %v): %s", tt.wantStatus, tt.wantSent, w.Code, stub.deviceIDs(), w.Body.String())
+			}
+			if tt.wantStatus == http.StatusBadRequest {
+				var resp ControlResponse
+				json.NewDecoder(w.Body).Decode(&resp)
+				if resp.Message != `brightness must be a number, got "bright"` {
+					t.Errorf("unexpected message %q", resp.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestControlDevice_IdempotencyKeyReplay(t *testing.T) {
+	stub := newGoveeStub(t, deviceListAccount)
+	clients := stub.clients
+	handler := middleware.Idempotent(middleware.NewIdempotencyCache(time.Minute, 10), HandleControlDevice(clients, nil, nil, nil, nil))
+
+	body := `{"deviceId": "AA:BB:CC:DD:EE:FF:00:11", "model": "H6008", "command": "turn", "value": true}`
+	var responses []*httptest.ResponseRecorder
+	for range 2 {
+		req := httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(body))
+		req.Header.Set(middleware.IdempotencyKeyHeader, "toggle-1")
+		w := httptest.NewRecorder()
+		handler(w, req)
+		responses = append(responses, w)
+	}
+
+	if len(stub.deviceIDs()) != 1 {
+		t.Fatalf("expected one upstream command, got %d", len(stub.deviceIDs()))
+	}
+	if responses[0].Code != http.StatusOK || responses[1].Code != http.StatusOK {
+		t.Fatalf("expected status 200 twice, got %d and %d", responses[0].Code, responses[1].Code)
+	}
+	if responses[1].Body.String() != responses[0].Body.String() || responses[1].Header().Get(middleware.ReplayedHeader) != "true" {
+		t.Errorf("expected the cached response to be replayed, got %s", responses[1].Body)
+	}
+}
+
+func TestControlDevice_PreserveBrightness(t *testing.T) {
+	// The desk lamp can't report its state, so the last brightness set
+	// through Artemis is the one kept
+	var sent []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.Write([]byte(searchDevicesBody))
+			return
+		}
+		var req govee.ControlRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sent = append(sent, req.Cmd.Name)
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	}))
+	t.Cleanup(server.Close)
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(server.URL)
+
+	optimistic := govee.NewOptimisticStates("")
+	optimistic.Record(0, "AA:01", "H6008", "brightness", float64(40))
+	handler := HandleControlDevice([]*govee.Client{client}, nil, optimistic, nil, nil)
+
+	body := `{"deviceId": "AA:01", "command": "color", "value": {"r": 255, "g": 0, "b": 0}, "preserveBrightness": true}`
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(body)))
+	var resp ControlResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || strings.Join(resp.Steps, "; ") != "color 255,0,0; brightness 40" {
+		t.Fatalf("expected the color then brightness 40, got %d %+v", w.Code, resp)
+	}
+	if strings.Join(sent, ",") != "color,brightness" {
+		t.Errorf("expected color then brightness to be sent, got %v", sent)
+	}
+
+	for _, body := range []string{
+		`{"deviceId": "AA:01", "command": "brightness", "value": 50, "preserveBrightness": true}`,
+		`{"deviceId": "AA:01", "command": "color", "value": {"r": 1, "g": 2, "b": 3}, "preserveBrightness": true, "transitionMs": 500}`,
+	} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/middleware"
+)
+
+// TimerRequest is the body of POST /api/govee/devices/{id}/timer.
+type TimerRequest struct {
+	Action  string `json:"action"`  // "on" or "off"
+	Minutes int    `json:"minutes"` // Delay, 1-1440
+}
+
+// TimerResponse reports the timer that was set and where it runs.
+type TimerResponse struct {
+	Success   bool        `json:"success"`
+	Timer     govee.Timer `json:"timer"`
+	Message   string      `json:"message"`
+	Timestamp string      `json:"timestamp"`
+}
+
+// HandleSetDeviceTimer sets an on/off timer on a device.
+// POST /api/govee/devices/{id}/timer[?apiKeyIndex=Z]
+// Accepts: TimerRequest JSON body
+// Returns: TimerResponse JSON; timer.mechanism is "device" when the timer
+// was stored on the device, or "artemis" when Artemis keeps it
+//
+// Models that advertise a timer capability (API v2 only) get a device timer,
+// which fires even if Artemis is down. Other models, and devices that reject
+// the timer, fall back to a timer Artemis keeps in memory until it's due.
+func HandleSetDeviceTimer(goveeClients []*govee.Client, scheduler *govee.TimerScheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req TimerRequest
+		if err := decodeJSONBody(r, &req); err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.Action != "on" && req.Action != "off" {
+			writeErrorCode(w, r, http.StatusBadRequest, errcode.InvalidRequest, `action must be "on" or "off"`)
+			return
+		}
+		delay := time.Duration(req.Minutes) * time.Minute
+		if delay < govee.MinTimerDelay || delay > govee.MaxTimerDelay {
+			writeErrorCode(w, r, http.StatusBadRequest, errcode.InvalidRequest, fmt.Sprintf("minutes must be between %d and %d", int(govee.MinTimerDelay.Minutes()), int(govee.MaxTimerDelay.Minutes())))
+			return
+		}
+
+		device, apiKeyIndex, status, err := resolveDevicePath(r, goveeClients)
+		if err != nil {
+			writeErrorFrom(w, r, status, err)
+			return
+		}
+
+		log.Printf("⏲️  Timer request - Device: %s, Action: %s, Minutes: %d - Client: %s", device.Device, req.Action, req.Minutes, middleware.ClientIP(r))
+
+		timer, err := scheduler.Set(r.Context(), apiKeyIndex, device.Device, device.Model, req.Action, delay)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		message := fmt.Sprintf("Timer stored on the device: %s in %d min", req.Action, req.Minutes)
+		if timer.Mechanism == govee.TimerArtemis {
+			message = fmt.Sprintf("Timer kept by Artemis: %s in %d min (lost if the server restarts)", req.Action, req.Minutes)
+		}
+		writeJSON(w, r, http.StatusOK, TimerResponse{
+			Success:   true,
+			Timer:     timer,
+			Message:   message,
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/govee"
+)
+
+func TestSetDeviceTimer(t *testing.T) {
+	stub := newGoveeStub(t, pathAccounts...)
+	clients := stub.clients
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/govee/devices/{id}/timer", HandleSetDeviceTimer(clients, govee.NewTimerScheduler(clients)))
+
+	// API v1 has no device timers, so Artemis keeps it
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/AA:01/timer", strings.NewReader(`{"action": "off", "minutes": 30}`)))
+	var resp TimerResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || resp.Timer.Mechanism != govee.TimerArtemis || resp.Timer.APIKeyIndex != 1 || resp.Timer.Model != "H6008" {
+		t.Errorf("expected an Artemis-managed timer on the secondary account's lamp, got %d %+v", w.Code, resp)
+	}
+	if len(stub.commands()) != 0 {
+		t.Errorf("expected nothing sent before the timer is due, got %v", stub.commands())
+	}
+
+	for _, body := range []string{`{"action": "toggle", "minutes": 30}`, `{"action": "on", "minutes": 0}`, `{"action": "on", "minutes": 1441}`} {
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/AA:01/timer", strings.NewReader(body)))
+		var errResp ErrorResponse
+		json.NewDecoder(w.Body).Decode(&errResp)
+		if w.Code != http.StatusBadRequest || errResp.Code != errcode.InvalidRequest {
+			t.Errorf("expected 400 for %s, got %d %+v", body, w.Code, errResp)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/govee/devices/FF:FF/timer", strings.NewReader(`{"action": "on", "minutes": 5}`)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown device, got %d", w.Code)
+	}
+}
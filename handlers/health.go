@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/pantheon/artemis/upstream"
+)
+
+// HealthResponse is the body of GET /api/health.
+type HealthResponse struct {
+	Status  string `json:"status"` // "healthy" whenever the server answers
+	Service string `json:"service"`
+
+	// Upstream circuit breakers (omitted when disabled). An "open" breaker
+	// means requests to that upstream are currently failing fast.
+	Breakers []upstream.BreakerStatus `json:"breakers,omitempty"`
+}
+
+// HandleHealth reports that the server is up, along with the state of each
+// breaker. Upstream outages don't change the status or the 200, so a
+// monitor restarting Artemis on failure won't restart it for a dead bridge.
+// GET /api/health
+func HandleHealth(breakers []*upstream.Breaker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response := HealthResponse{Status: "healthy", Service: "artemis"}
+		for _, breaker := range breakers {
+			response.Breakers = append(response.Breakers, breaker.Status())
+		}
+		writeJSON(w, r, http.StatusOK, response)
+	}
+}
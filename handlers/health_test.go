@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/upstream"
+)
+
+func TestHealth_ReportsBreakers(t *testing.T) {
+	breakers := []*upstream.Breaker{upstream.NewBreaker("Wyze Bridge", 5, 30*time.Second)}
+
+	w := httptest.NewRecorder()
+	HandleHealth(breakers)(w, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var resp HealthResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Status != "healthy" || resp.Service != "artemis" {
+		t.Errorf("unexpected status fields: %+v", resp)
+	}
+	if len(resp.Breakers) != 1 || resp.Breakers[0].Name != "Wyze Bridge" || resp.Breakers[0].State != upstream.BreakerClosed {
+		t.Errorf("unexpected breakers: %+v", resp.Breakers)
+	}
+}
+
+func TestHealth_OmitsBreakersWhenDisabled(t *testing.T) {
+	w := httptest.NewRecorder()
+	HandleHealth(nil)(w, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+
+	if got, want := w.Body.String(), "{\"status\":\"healthy\",\"service\":\"artemis\"}\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -2,25 +2,179 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
+
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/upstream"
 )
 
 // writeJSON encodes the given value as JSON and writes it to the response
 // with the specified status code. Sets Content-Type to application/json.
-func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+//
+// Output is compact by default. Adding ?pretty=true to any request indents
+// the response for reading in a browser or curl while debugging. Field order
+// is deterministic either way: struct fields follow their declaration order
+// and encoding/json sorts map keys.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(v); err != nil {
+
+	encoder := json.NewEncoder(w)
+	if wantsPrettyJSON(r) {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(v); err != nil {
 		log.Printf("❌ Error encoding JSON response: %v", err)
 	}
 }
 
+// wantsPrettyJSON reports whether the request asked for indented output
+// (?pretty=true, or any other value strconv.ParseBool accepts as true).
+func wantsPrettyJSON(r *http.Request) bool {
+	if r == nil {
+		return false
+	}
+	pretty, _ := strconv.ParseBool(r.URL.Query().Get("pretty"))
+	return pretty
+}
+
+// ListOptions shapes the responses of list endpoints. The zero value sends
+// bare arrays (or grouped objects) and ignores unknown ?fields= names, so
+// existing clients keep working.
+type ListOptions struct {
+	Envelope     bool // Wrap results in a ListResponse (RESPONSE_ENVELOPE)
+	StrictFields bool // Reject unknown ?fields= names with 400 (RESPONSE_FIELDS_STRICT)
+}
+
+// ListResponse is the uniform list envelope used when ListOptions.Envelope
+// is on.
+// Format: {"success": true, "data": [...], "message": "Found 3 device(s)"}
+type ListResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data"`
+	Message string      `json:"message"`
+}
+
+// writeList sends a list endpoint's result with status 200: as-is by default,
+// or wrapped in a ListResponse when opts.Envelope is on. message summarizes
+// the result and only appears in the envelope. A ?fields= list trims each
+// item to those fields (see selectFields).
+func writeList(w http.ResponseWriter, r *http.Request, opts ListOptions, data interface{}, message string) {
+	data, err := selectFields(r, data, opts.StrictFields)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !opts.Envelope {
+		writeJSON(w, r, http.StatusOK, data)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, ListResponse{Success: true, Data: data, Message: message})
+}
+
+// ErrorResponse is the body of every JSON error response.
+type ErrorResponse struct {
+	Error string       `json:"error"` // Human-readable; may change between versions
+	Code  errcode.Code `json:"code"`  // Stable and machine-readable (see the errcode package)
+}
+
 // writeError sends a JSON error response with the given status code and message.
-// Format: {"error": "message here"}
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
+// Format: {"error": "message here", "code": "NOT_FOUND"}
+//
+// The code is the generic one for the status (see errcode.ForStatus); use
+// writeErrorCode or writeErrorFrom when a more specific one is known.
+// "Method not allowed" answers stay plain text, as before.
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	writeErrorCode(w, r, status, errcode.ForStatus(status), message)
+}
+
+// writeErrorCode sends a JSON error response with an explicit error code.
+func writeErrorCode(w http.ResponseWriter, r *http.Request, status int, code errcode.Code, message string) {
+	writeJSON(w, r, status, ErrorResponse{Error: message, Code: code})
+}
+
+// writeErrorFrom sends err's message as a JSON error response, with the
+// code errorCode picks for it.
+func writeErrorFrom(w http.ResponseWriter, r *http.Request, status int, err error) {
+	writeErrorCode(w, r, status, errorCode(err, status), err.Error())
+}
+
+// errorCode picks the error code for err, sent with status. Errors that say
+// what went wrong (Govee rate limiting, an offline device, an invalid
+// command, an unknown device, a timeout) get their own code; anything else
+// gets the status's generic one.
+func errorCode(err error, status int) errcode.Code {
+	switch {
+	case errors.Is(err, errDeviceNotFound):
+		return errcode.DeviceNotFound
+	case govee.IsInvalidCommandError(err):
+		return errcode.InvalidCommand
+	case govee.IsRateLimitError(err):
+		return errcode.GoveeRateLimited
+	case govee.IsOfflineError(err):
+		return errcode.DeviceOffline
+	case govee.IsTimeoutError(err):
+		return errcode.UpstreamTimeout
+	}
+	return errcode.ForStatus(status)
+}
+
+// HandleFeatureDisabled answers every request with 404 for an integration
+// that was switched off in config (e.g., ENABLE_FIRETV=false). Registered in
+// place of the integration's real handlers so clients get a clear reason
+// instead of a bare "404 page not found".
+func HandleFeatureDisabled(feature string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeErrorCode(w, r, http.StatusNotFound, errcode.FeatureDisabled, fmt.Sprintf("Feature disabled: %s is turned off on this server", feature))
+	}
+}
+
+// decodeJSONBody decodes a JSON request body into dst.
+//
+// Instead of a generic "Invalid request body", the returned error explains
+// what was wrong so API clients can fix their request:
+// - a Content-Type other than JSON (a missing header is allowed)
+// - an empty body
+// - truncated or malformed JSON (with the byte offset when known)
+// - a field with the wrong type
+//
+// The error message is safe to send to the client; every case is a 400.
+func decodeJSONBody(r *http.Request, dst interface{}) error {
+	// Only check Content-Type when one is sent — many simple clients omit it.
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+			return fmt.Errorf("Content-Type must be application/json (got %q)", contentType)
+		}
+	}
+
+	err := json.NewDecoder(r.Body).Decode(dst)
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return errors.New("Request body is empty — expected a JSON object")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return errors.New("Request body contains truncated JSON")
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("Request body contains malformed JSON at position %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return fmt.Errorf("Request body has an invalid value for %q (expected %s)", typeErr.Field, typeErr.Type)
+	default:
+		return fmt.Errorf("Invalid request body: %v", err)
+	}
 }
 
 // isNotFound checks if an error message indicates a "not found" condition
@@ -28,3 +182,14 @@ func writeError(w http.ResponseWriter, status int, message string) {
 func isNotFound(err error) bool {
 	return strings.Contains(err.Error(), "not found")
 }
+
+// upstreamStatus picks the response for an error from an upstream client.
+// Classified transport failures (see upstream.Error) get their own status
+// and a user-facing message, e.g. 503 "Fire TV service is not running";
+// anything else gets fallback and the error's own message.
+func upstreamStatus(err error, fallback int) (int, string) {
+	if classified, ok := upstream.As(err); ok {
+		return classified.HTTPStatus(), classified.Message()
+	}
+	return fallback, err.Error()
+}
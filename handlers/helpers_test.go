@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/govee"
+)
+
+// =============================================================================
+// decodeJSONBody — request body validation
+// =============================================================================
+
+func TestDecodeJSONBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     string // Substring expected in the error ("" = success)
+	}{
+		{"valid JSON", "application/json", `{"name": "Den"}`, ""},
+		{"JSON with charset", "application/json; charset=utf-8", `{"name": "Den"}`, ""},
+		{"missing content type", "", `{"name": "Den"}`, ""},
+		{"empty body", "application/json", ``, "empty"},
+		{"wrong content type", "text/plain", `{"name": "Den"}`, "Content-Type must be application/json"},
+		{"form content type", "application/x-www-form-urlencoded", `name=Den`, "Content-Type must be application/json"},
+		{"truncated JSON", "application/json", `{"name": "De`, "truncated"},
+		{"malformed JSON", "application/json", `{"name" "Den"}`, "malformed JSON at position"},
+		{"wrong field type", "application/json", `{"name": 42}`, `invalid value for "name"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			var dst struct {
+				Name string `json:"name"`
+			}
+			err := decodeJSONBody(req, &dst)
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected success, got %v", err)
+				}
+				if dst.Name != "Den" {
+					t.Errorf("expected name 'Den', got '%s'", dst.Name)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %q", tt.wantErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestCreateProfile_EmptyBodyMessage(t *testing.T) {
+	h, _ := setupTestProfileHandler(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/profile", bytes.NewBufferString(""))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	h.HandleCreateProfile(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+
+	var resp map[string]string
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !strings.Contains(resp["error"], "empty") {
+		t.Errorf("expected an 'empty body' error, got %q", resp["error"])
+	}
+}
+
+func TestControlDevice_WrongContentType(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", strings.NewReader(`{"deviceId": "AA:BB"}`))
+	req.Header.Set("Content-Type", "text/plain")
+	w := httptest.NewRecorder()
+
+	HandleControlDevice(nil, nil, nil, nil, nil)(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Content-Type must be application/json") {
+		t.Errorf("expected content-type error, got %q", w.Body.String())
+	}
+}
+
+// =============================================================================
+// HandleFeatureDisabled — switched-off integrations
+// =============================================================================
+
+func TestHandleFeatureDisabled(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/firetv/command", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+
+	HandleFeatureDisabled("Fire TV")(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", w.Code)
+	}
+
+	var resp map[string]string
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !strings.Contains(resp["error"], "Feature disabled") || !strings.Contains(resp["error"], "Fire TV") {
+		t.Errorf("expected a feature-disabled error naming Fire TV, got %q", resp["error"])
+	}
+	if resp["code"] != string(errcode.FeatureDisabled) {
+		t.Errorf("expected code FEATURE_DISABLED, got %q", resp["code"])
+	}
+}
+
+// =============================================================================
+// writeError / errorCode — machine-readable error codes
+// =============================================================================
+
+func TestWriteError_GenericCode(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/room/missing", nil)
+	w := httptest.NewRecorder()
+
+	writeError(w, req, http.StatusNotFound, "Room not found")
+
+	if got := w.Body.String(); got != "{\"error\":\"Room not found\",\"code\":\"NOT_FOUND\"}\n" {
+		t.Errorf("expected the error and its status's code, got %q", got)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := map[string]struct {
+		err    error
+		status int
+		want   errcode.Code
+	}{
+		"unknown device":   {&deviceNotFoundError{"AA:01"}, http.StatusNotFound, errcode.DeviceNotFound},
+		"invalid command":  {&govee.InvalidCommandError{Err: errors.New("Unknown command: blink")}, http.StatusBadRequest, errcode.InvalidCommand},
+		"rate limited":     {errors.New("govee API error (code 429): Too Many Requests"), http.StatusBadRequest, errcode.GoveeRateLimited},
+		"device offline":   {errors.New("govee API error (code 400): Device Offline"), http.StatusBadRequest, errcode.DeviceOffline},
+		"timed out":        {fmt.Errorf("turn failed: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, errcode.UpstreamTimeout},
+		"unclassified 400": {errors.New("govee API error (code 400): Bad Request"), http.StatusBadRequest, errcode.InvalidRequest},
+		"service down":     {errors.New("connection refused"), http.StatusServiceUnavailable, errcode.ServiceUnavailable},
+	}
+	for name, tt := range tests {
+		if got := errorCode(tt.err, tt.status); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", name, tt.want, got)
+		}
+	}
+}
+
+// =============================================================================
+// writeJSON — ?pretty=true
+// =============================================================================
+
+func TestWriteJSON_CompactByDefault(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/profiles", nil)
+	w := httptest.NewRecorder()
+
+	writeJSON(w, req, http.StatusOK, map[string]string{"b": "2", "a": "1"})
+
+	if got := w.Body.String(); got != "{\"a\":\"1\",\"b\":\"2\"}\n" {
+		t.Errorf("expected compact output with sorted keys, got %q", got)
+	}
+}
+
+func TestWriteJSON_Pretty(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/profiles?pretty=true", nil)
+	w := httptest.NewRecorder()
+
+	writeJSON(w, req, http.StatusOK, map[string]string{"b": "2", "a": "1"})
+
+	if got := w.Body.String(); got != "{\n  \"a\": \"1\",\n  \"b\": \"2\"\n}\n" {
+		t.Errorf("expected indented output, got %q", got)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %q", ct)
+	}
+}
+
+func TestWriteError_Pretty(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/room/missing?pretty=1", nil)
+	w := httptest.NewRecorder()
+
+	writeError(w, req, http.StatusNotFound, "Room not found")
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "\n  \"error\": ") {
+		t.Errorf("expected indented error body, got %q", w.Body.String())
+	}
+}
+
+// =============================================================================
+// writeList — optional list envelope
+// =============================================================================
+
+func TestWriteList_BareByDefault(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeList(w, httptest.NewRequest(http.MethodGet, "/", nil), ListOptions{}, []string{"a", "b"}, "Found 2 item(s)")
+
+	if got := strings.TrimSpace(w.Body.String()); got != `["a","b"]` {
+		t.Errorf("expected a bare array, got %s", got)
+	}
+}
+
+func TestWriteList_Envelope(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeList(w, httptest.NewRequest(http.MethodGet, "/", nil), ListOptions{Envelope: true}, []string{"a", "b"}, "Found 2 item(s)")
+
+	want := `{"success":true,"data":["a","b"],"message":"Found 2 item(s)"}`
+	if got := strings.TrimSpace(w.Body.String()); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+// =============================================================================
+// upstreamStatus — unreachable upstream services
+// =============================================================================
+
+// closedPortURL returns a URL nothing listens on, so requests to it are
+// refused.
+func closedPortURL(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	listener.Close()
+	return "http://" + listener.Addr().String()
+}
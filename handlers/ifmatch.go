@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// setVersionETag sends an object's version as its ETag, e.g. "3". Clients
+// echo it in If-Match to update the object only if no one changed it since.
+func setVersionETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatchVersion reads the version an update is conditional on from the
+// If-Match header. Returns 0 for "update whatever is there": "*", or no
+// header when not required. Required, a missing header answers 428
+// Precondition Required, so an update can't silently overwrite a version it
+// never saw (REQUIRE_IF_MATCH, on by default).
+//
+// An ETag that isn't a version this server issued can't match the current
+// one, so it answers 412 like a stale version. If ok is false, the response
+// has been written.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request, required bool) (version int, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch {
+	case header == "":
+		if required {
+			writeError(w, r, http.StatusPreconditionRequired, "If-Match is required — send the ETag from when you read this object")
+			return 0, false
+		}
+		return 0, true
+	case header == "*":
+		return 0, true
+	}
+
+	// Weak ETags never match for If-Match (RFC 9110 §13.1.1)
+	unquoted, err := strconv.Unquote(header)
+	if err == nil {
+		version, err = strconv.Atoi(unquoted)
+	}
+	if err != nil || version < 1 {
+		writeError(w, r, http.StatusPreconditionFailed, fmt.Sprintf("If-Match %s doesn't match the current version", header))
+		return 0, false
+	}
+	return version, true
+}
+
+// sendStaleVersion answers an update whose If-Match version is out of date.
+func sendStaleVersion(w http.ResponseWriter, r *http.Request, what string) {
+	writeError(w, r, http.StatusPreconditionFailed, fmt.Sprintf("The %s was changed by someone else — fetch it again and retry", what))
+}
@@ -1,10 +1,11 @@
 package handlers
 
 import (
-	"encoding/json"
 	"log"
 	"net/http"
 	"time"
+
+	"github.com/pantheon/artemis/middleware"
 )
 
 // LightbulbToggleRequest represents the incoming request body
@@ -31,9 +32,9 @@ func HandleLightbulbToggle(w http.ResponseWriter, r *http.Request) {
 
 	// Parse the request body
 	var req LightbulbToggleRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSONBody(r, &req); err != nil {
 		log.Printf("Error decoding request body: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -41,7 +42,7 @@ func HandleLightbulbToggle(w http.ResponseWriter, r *http.Request) {
 	log.Printf("🔆 Lightbulb toggled - State: %t (turned %s) - Client: %s",
 		req.IsOn,
 		map[bool]string{true: "ON", false: "OFF"}[req.IsOn],
-		r.RemoteAddr,
+		middleware.ClientIP(r),
 	)
 
 	// Create response
@@ -53,11 +54,5 @@ func HandleLightbulbToggle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Set response headers
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-
-	// Encode and send response
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding response: %v", err)
-	}
+	writeJSON(w, r, http.StatusOK, response)
 }
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pantheon/artemis/middleware"
+)
+
+func TestLightbulbToggle_LogsClientIP(t *testing.T) {
+	var logs bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(previous) })
+
+	proxies, _ := middleware.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	handler := middleware.RealIP(http.HandlerFunc(HandleLightbulbToggle), proxies)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/lightbulb/toggle", strings.NewReader(`{"isOn": true}`))
+	req.RemoteAddr = "10.0.0.2:51000"
+	req.Header.Set("X-Forwarded-For", "192.168.1.20")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if line := logs.String(); !strings.Contains(line, "Client: 192.168.1.20") {
+		t.Errorf("expected the forwarded client IP in the log, got %q", line)
+	}
+}
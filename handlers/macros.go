@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/macros"
+	"github.com/pantheon/artemis/middleware"
+)
+
+// MacroStepResult is the outcome of one step of a macro run.
+type MacroStepResult struct {
+	Step       int                   `json:"step"` // 1-based position in the macro
+	Type       string                `json:"type"`
+	Target     string                `json:"target"`
+	Action     string                `json:"action"`
+	Success    bool                  `json:"success"`           // Whether every device of the step succeeded
+	Skipped    bool                  `json:"skipped,omitempty"` // Not run: an earlier stopOnError step failed
+	Message    string                `json:"message"`
+	Code       errcode.Code          `json:"code,omitempty"` // Machine-readable error code (failed steps only)
+	Results    []DeviceControlResult `json:"results"`
+	DurationMs int64                 `json:"durationMs"`
+}
+
+// MacroRunResponse is the response of POST /api/macros/{name}/run.
+type MacroRunResponse struct {
+	Success   bool              `json:"success"` // Whether every step succeeded
+	Macro     string            `json:"macro"`
+	Message   string            `json:"message"`
+	Steps     []MacroStepResult `json:"steps"`
+	Timestamp string            `json:"timestamp"`
+}
+
+// HandleMacros lists and creates macros.
+// GET  /api/macros — list macros, sorted by name
+// POST /api/macros — create one from a macros.Macro body; returns 201
+//
+// Names are unique (case-insensitive), so creating a duplicate answers 409.
+// Each step must name a target type and an action it supports, as for
+// POST /api/devices/control; nothing is sent until the macro is run.
+func HandleMacros(store *macros.Store, listOpts ListOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			list := store.List()
+			if list == nil {
+				list = []macros.Macro{}
+			}
+			writeList(w, r, listOpts, list, fmt.Sprintf("Found %d macro(s)", len(list)))
+
+		case http.MethodPost:
+			var macro macros.Macro
+			if err := decodeJSONBody(r, &macro); err != nil {
+				writeError(w, r, http.StatusBadRequest, err.Error())
+				return
+			}
+			if err := validateMacroSteps(macro); err != nil {
+				writeErrorFrom(w, r, http.StatusBadRequest, err)
+				return
+			}
+
+			created, err := store.Create(macro)
+			if err != nil {
+				sendMacroError(w, r, macro.Name, err)
+				return
+			}
+
+			log.Printf("🪄 Created macro '%s' (%d step(s))", created.Name, len(created.Steps))
+			writeJSON(w, r, http.StatusCreated, created)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// HandleMacro reads, replaces, or deletes one macro.
+// GET    /api/macros/{name}
+// PUT    /api/macros/{name} — replace it with a macros.Macro body (which may rename it)
+// DELETE /api/macros/{name} — returns 204
+//
+// All answer 404 if there is no macro called {name}.
+func HandleMacro(store *macros.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+
+		switch r.Method {
+		case http.MethodGet:
+			macro, err := store.Get(name)
+			if err != nil {
+				sendMacroError(w, r, name, err)
+				return
+			}
+			writeJSON(w, r, http.StatusOK, macro)
+
+		case http.MethodPut:
+			var macro macros.Macro
+			if err := decodeJSONBody(r, &macro); err != nil {
+				writeError(w, r, http.StatusBadRequest, err.Error())
+				return
+			}
+			if err := validateMacroSteps(macro); err != nil {
+				writeErrorFrom(w, r, http.StatusBadRequest, err)
+				return
+			}
+
+			updated, err := store.Update(name, macro)
+			if err != nil {
+				sendMacroError(w, r, name, err)
+				return
+			}
+
+			log.Printf("🪄 Updated macro '%s' (%d step(s))", updated.Name, len(updated.Steps))
+			writeJSON(w, r, http.StatusOK, updated)
+
+		case http.MethodDelete:
+			if err := store.Delete(name); err != nil {
+				sendMacroError(w, r, name, err)
+				return
+			}
+
+			log.Printf("🪄 Deleted macro '%s'", name)
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// HandleRunMacro runs a macro's steps in order.
+// POST /api/macros/{name}/run
+// Returns: MacroRunResponse JSON
+//
+// Each step goes through POST /api/devices/control's path, after waiting its
+// delayMs. A failed step is reported with its error and code and the run
+// moves on, unless the step has stopOnError, in which case the remaining
+// steps are reported as skipped. A step whose subsystem is down (its
+// circuit breaker is open, or its integration is turned off) fails like any
+// other, so the rest of the macro still runs. The response is 200 whenever
+// the macro was found; "success" says whether every step succeeded.
+func HandleRunMacro(store *macros.Store, controllers DeviceControllers) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept POST requests
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		macro, err := store.Get(r.PathValue("name"))
+		if err != nil {
+			sendMacroError(w, r, r.PathValue("name"), err)
+			return
+		}
+
+		log.Printf("🪄 Running macro '%s' (%d step(s)) - Client: %s", macro.Name, len(macro.Steps), middleware.ClientIP(r))
+
+		response := MacroRunResponse{
+			Macro: macro.Name,
+			Steps: make([]MacroStepResult, 0, len(macro.Steps)),
+		}
+		succeeded, stopped := 0, false
+		for i, step := range macro.Steps {
+			result := MacroStepResult{
+				Step:    i + 1,
+				Type:    step.Target.Type,
+				Target:  step.Target.ID,
+				Action:  step.Action,
+				Results: []DeviceControlResult{},
+			}
+			if stopped {
+				result.Skipped = true
+				result.Message = "Skipped: an earlier step failed"
+				response.Steps = append(response.Steps, result)
+				continue
+			}
+
+			result = runMacroStep(r, controllers, step, result)
+			if result.Success {
+				succeeded++
+			} else {
+				log.Printf("⚠️  Macro '%s' step %d (%s %s) failed: %s", macro.Name, i+1, step.Action, step.Target.ID, result.Message)
+				stopped = step.StopOnError
+			}
+			response.Steps = append(response.Steps, result)
+		}
+
+		response.Success = succeeded == len(macro.Steps)
+		response.Message = fmt.Sprintf("%d of %d step(s) succeeded", succeeded, len(macro.Steps))
+		response.Timestamp = time.Now().Format(time.RFC3339)
+		writeJSON(w, r, http.StatusOK, response)
+	}
+}
+
+// runMacroStep waits for a step's delay, then runs it as a unified control
+// request and fills in result.
+func runMacroStep(r *http.Request, controllers DeviceControllers, step macros.Step, result MacroStepResult) MacroStepResult {
+	if step.DelayMs > 0 {
+		select {
+		case <-r.Context().Done():
+			result.Message = "Cancelled: the request ended before this step"
+			return result
+		case <-time.After(time.Duration(step.DelayMs) * time.Millisecond):
+		}
+	}
+
+	req := DeviceControlRequest{Target: step.Target, Action: step.Action, Value: step.Value}
+	start := time.Now()
+	results, status, err := runDeviceControl(r, controllers, req)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if results != nil {
+		result.Results = results
+	}
+	if err != nil {
+		result.Message = err.Error()
+		result.Code = deviceControlCode(err, status)
+		return result
+	}
+
+	succeeded := 0
+	for _, device := range results {
+		if device.Success {
+			succeeded++
+		}
+	}
+	result.Success = succeeded == len(results)
+	result.Message = fmt.Sprintf("%s applied to %d of %d device(s)", step.Action, succeeded, len(results))
+	return result
+}
+
+// validateMacroSteps checks that each step's target type supports its
+// action, naming the step in the error.
+func validateMacroSteps(macro macros.Macro) error {
+	for i, step := range macro.Steps {
+		if step.Target.Type == "" {
+			continue // The store reports missing fields
+		}
+		req := DeviceControlRequest{Target: step.Target, Action: step.Action, Value: step.Value}
+		if err := validateDeviceControl(req); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// sendMacroError answers a failed macro store operation: 404 for a missing
+// macro, 409 for a name that's taken, 400 for a macro that doesn't validate,
+// and 500 if it couldn't be saved.
+func sendMacroError(w http.ResponseWriter, r *http.Request, name string, err error) {
+	switch {
+	case errors.Is(err, macros.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, fmt.Sprintf("Macro not found: %s", name))
+	case errors.Is(err, macros.ErrExists):
+		writeError(w, r, http.StatusConflict, "A macro with that name already exists")
+	case errors.Is(err, macros.ErrInvalid):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("❌ Failed to save macro '%s': %v", name, err)
+		writeError(w, r, http.StatusInternalServerError, "Failed to save macro")
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/camera"
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/macros"
+	"github.com/pantheon/artemis/upstream"
+)
+
+// newMacroMux routes the macro endpoints like main.go does.
+func newMacroMux(store *macros.Store, controllers DeviceControllers) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/macros", HandleMacros(store, ListOptions{}))
+	mux.HandleFunc("/api/macros/{name}", HandleMacro(store))
+	mux.HandleFunc("/api/macros/{name}/run", HandleRunMacro(store, controllers))
+	return mux
+}
+
+// downBridge returns a camera client whose Wyze Bridge answers 500, with its
+// circuit breaker already open.
+func downBridge(t *testing.T) *camera.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+	client := camera.NewClient(server.URL, "")
+	breaker := upstream.NewBreaker("Wyze Bridge", 1, time.Minute)
+	client.SetBreaker(breaker)
+	client.GetCameras(context.Background())
+	if !breaker.Open() {
+		t.Fatal("expected the bridge's breaker to be open")
+	}
+	return client
+}
+
+func TestMacros_CRUD(t *testing.T) {
+	store, _ := macros.NewStore("")
+	mux := newMacroMux(store, DeviceControllers{})
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		return w
+	}
+
+	body := `{"name": "Movie Night", "steps": [{"target": {"type": "govee_group", "id": "Living Room"}, "action": "brightness", "value": 20}]}`
+	if w := serve(http.MethodPost, "/api/macros", body); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d %s", w.Code, w.Body)
+	}
+	if w := serve(http.MethodPost, "/api/macros", body); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a duplicate, got %d", w.Code)
+	}
+
+	w := serve(http.MethodPost, "/api/macros", `{"name": "Bad", "steps": [{"target": {"type": "camera", "id": "Porch"}, "action": "brightness", "value": 20}]}`)
+	var errResp ErrorResponse
+	json.NewDecoder(w.Body).Decode(&errResp)
+	if w.Code != http.StatusBadRequest || errResp.Code != errcode.InvalidCommand {
+		t.Errorf("expected 400 INVALID_COMMAND for an action the target doesn't take, got %d %+v", w.Code, errResp)
+	}
+	if w := serve(http.MethodPost, "/api/macros", `{"name": "Empty", "steps": []}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a macro without steps, got %d", w.Code)
+	}
+	if w := serve(http.MethodPost, "/api/macros", `{"name": "<b>Night</b>", "steps": [{"target": {"type": "govee_group", "id": "Living Room"}, "action": "brightness", "value": 20}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a name with markup, got %d", w.Code)
+	}
+
+	w = serve(http.MethodGet, "/api/macros/movie%20night", "")
+	var macro macros.Macro
+	json.NewDecoder(w.Body).Decode(&macro)
+	if w.Code != http.StatusOK || macro.Name != "Movie Night" || len(macro.Steps) != 1 {
+		t.Errorf("expected the macro, got %d %+v", w.Code, macro)
+	}
+
+	if w := serve(http.MethodDelete, "/api/macros/Movie%20Night", ""); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", w.Code)
+	}
+	if w := serve(http.MethodPost, "/api/macros/Movie%20Night/run", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 running a deleted macro, got %d", w.Code)
+	}
+}
+
+func TestRunMacro_ReportsEachStep(t *testing.T) {
+	store, _ := macros.NewStore("")
+	store.Create(macros.Macro{Name: "Movie Night", Steps: []macros.Step{
+		{Target: macros.Target{Type: TargetGovee, ID: "AA:01"}, Action: "brightness", Value: 20.0},
+		{Target: macros.Target{Type: TargetCamera, ID: "Living Room"}, Action: "turn", Value: false},
+		{Target: macros.Target{Type: TargetFireTV, ID: "192.168.1.50"}, Action: "command", Value: "netflix"},
+		{Target: macros.Target{Type: TargetGovee, ID: "AA:02"}, Action: "turn", Value: true, StopOnError: true},
+		{Target: macros.Target{Type: TargetGovee, ID: "AA:01"}, Action: "turn", Value: false},
+	}})
+	controllers := DeviceControllers{Govee: newGoveeStub(t, offlinePlugAccount).clients, Cameras: downBridge(t)}
+
+	w := httptest.NewRecorder()
+	newMacroMux(store, controllers).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/macros/movie%20night/run", nil))
+	var resp MacroRunResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || resp.Success || len(resp.Steps) != 5 {
+		t.Fatalf("expected 200 with 5 step results, got %d %+v", w.Code, resp)
+	}
+
+	lights, cam, tv, plug, last := resp.Steps[0], resp.Steps[1], resp.Steps[2], resp.Steps[3], resp.Steps[4]
+	if !lights.Success {
+		t.Errorf("expected the lamp to be dimmed, got %+v", lights)
+	}
+	if cam.Success || cam.Code != errcode.ServiceUnavailable {
+		t.Errorf("expected the camera step to report the bridge as unavailable, got %+v", cam)
+	}
+	if tv.Success || tv.Code != errcode.FeatureDisabled {
+		t.Errorf("expected the Fire TV step to report the integration disabled, got %+v", tv)
+	}
+	if plug.Success || plug.Code != errcode.DeviceOffline {
+		t.Errorf("expected the offline plug to fail, got %+v", plug)
+	}
+	if !last.Skipped || last.Success {
+		t.Errorf("expected the step after a failed stopOnError step to be skipped, got %+v", last)
+	}
+	if resp.Message != "1 of 5 step(s) succeeded" {
+		t.Errorf("unexpected message %q", resp.Message)
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/pantheon/artemis/govee"
+)
+
+// metricsContentType is the Prometheus text exposition format.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// deviceGauge is one per-device gauge of GET /api/metrics.
+type deviceGauge struct {
+	name  string
+	help  string
+	value func(govee.DeviceState) (float64, bool) // false when the state doesn't report it
+}
+
+// deviceGauges lists the per-device gauges, in output order.
+var deviceGauges = []deviceGauge{
+	{"govee_device_online", "Whether Govee's cloud can reach the device (1) or not (0).", func(s govee.DeviceState) (float64, bool) {
+		if s.Online == nil {
+			return 0, false
+		}
+		return boolGauge(*s.Online), true
+	}},
+	{"govee_device_power", "Whether the light is switched on (1) or off (0).", func(s govee.DeviceState) (float64, bool) {
+		if s.PowerOn == nil {
+			return 0, false
+		}
+		return boolGauge(*s.PowerOn), true
+	}},
+	{"govee_device_brightness", "Brightness of the light, 0-100.", func(s govee.DeviceState) (float64, bool) {
+		if s.Brightness == nil {
+			return 0, false
+		}
+		return float64(*s.Brightness), true
+	}},
+}
+
+// HandleDeviceMetrics serves per-device gauges for Prometheus to scrape.
+// GET /api/metrics
+// Returns: Prometheus text format, e.g. govee_device_power{device="AA:BB:...",name="Desk Lamp"} 1
+//
+// Values come from the state poller's cache, so they change once per poll
+// and serving a scrape never calls Govee for states. Names come from the
+// device list the poller also keeps fresh. Series are labeled only by
+// device ID and name to bound cardinality; a device on two accounts is
+// reported once. Devices without a fresh polled state are left out.
+func HandleDeviceMetrics(goveeClients []*govee.Client, poller *govee.StatePoller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept GET requests
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		states := poller.States()
+		slices.SortFunc(states, cmpStates)
+		states = slices.CompactFunc(states, func(a, b govee.DeviceState) bool {
+			return govee.SameDeviceID(a.DeviceID, b.DeviceID)
+		})
+
+		names := deviceNames(r, goveeClients)
+		w.Header().Set("Content-Type", metricsContentType)
+		for _, gauge := range deviceGauges {
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", gauge.name, gauge.help, gauge.name)
+			for _, state := range states {
+				if value, ok := gauge.value(state); ok {
+					writeDeviceSample(w, gauge.name, state.DeviceID, names[strings.ToUpper(state.DeviceID)], value)
+				}
+			}
+		}
+	}
+}
+
+// cmpStates orders states by device ID, then account, so the first state
+// of a device shared by two accounts is the first account's.
+func cmpStates(a, b govee.DeviceState) int {
+	if c := strings.Compare(strings.ToUpper(a.DeviceID), strings.ToUpper(b.DeviceID)); c != 0 {
+		return c
+	}
+	return a.APIKeyIndex - b.APIKeyIndex
+}
+
+// deviceNames maps upper-cased device IDs to their Govee names, from each
+// account's cached device list. An account whose list can't be fetched
+// just leaves its devices unnamed.
+func deviceNames(r *http.Request, goveeClients []*govee.Client) map[string]string {
+	names := make(map[string]string)
+	for index, client := range goveeClients {
+		devices, err := client.GetDevicesCached(r.Context())
+		if err != nil {
+			log.Printf("⚠️  Metrics: failed to list devices for API key #%d: %v", index, err)
+			continue
+		}
+		for _, device := range devices {
+			if _, ok := names[strings.ToUpper(device.Device)]; !ok {
+				names[strings.ToUpper(device.Device)] = device.DeviceName
+			}
+		}
+	}
+	return names
+}
+
+// writeDeviceSample writes one sample line of a per-device gauge.
+func writeDeviceSample(w io.Writer, metric, deviceID, name string, value float64) {
+	fmt.Fprintf(w, "%s{device=\"%s\",name=\"%s\"} %g\n", metric, escapeLabel(deviceID), escapeLabel(name), value)
+}
+
+// labelEscaper escapes a label value for the text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value for the text format.
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+// boolGauge is 1 for true and 0 for false.
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
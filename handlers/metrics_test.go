@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/govee"
+)
+
+func TestDeviceMetrics(t *testing.T) {
+	// The desk lamp is on at 42%; the plug only reports that it's offline
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("device") {
+		case "AA:01":
+			w.Write([]byte(`{"code": 200, "data": {"device": "AA:01", "model": "H6008", "properties": [{"online": true}, {"powerState": "on"}, {"brightness": 42}]}}`))
+		case "AA:02":
+			w.Write([]byte(`{"code": 200, "data": {"device": "AA:02", "model": "H5080", "properties": [{"online": "false"}]}}`))
+		default:
+			w.Write([]byte(searchDevicesBody))
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(server.URL)
+	clients := []*govee.Client{client}
+
+	poller := govee.NewStatePoller(clients, time.Minute, 0)
+	for _, d := range []struct{ id, model string }{{"AA:02", "H5080"}, {"AA:01", "H6008"}} {
+		if _, err := poller.Refresh(context.Background(), 0, d.id, d.model); err != nil {
+			t.Fatalf("Refresh returned error: %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	HandleDeviceMetrics(clients, poller)(w, httptest.NewRequest(http.MethodGet, "/api/metrics", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != metricsContentType {
+		t.Fatalf("expected a 200 Prometheus response, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	var samples []string
+	for _, line := range strings.Split(strings.TrimSpace(w.Body.String()), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			samples = append(samples, line)
+		}
+	}
+	want := []string{
+		`govee_device_online{device="AA:01",name="Desk Lamp"} 1`,
+		`govee_device_online{device="AA:02",name="Smart Plug"} 0`,
+		`govee_device_power{device="AA:01",name="Desk Lamp"} 1`,
+		`govee_device_brightness{device="AA:01",name="Desk Lamp"} 42`,
+	}
+	if got := strings.Join(samples, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("unexpected samples:\n%s", got)
+	}
+	if !strings.Contains(w.Body.String(), "# TYPE govee_device_brightness gauge") {
+		t.Errorf("expected TYPE lines, got:\n%s", w.Body.String())
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("Kid's \"Lamp\"\\2\n"); got != `Kid's \"Lamp\"\\2\n` {
+		t.Errorf("unexpected escaping: %s", got)
+	}
+}
@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 
@@ -12,12 +12,14 @@ import (
 // ProfileHandler holds the database connection and provides HTTP handlers
 // for profile CRUD operations. Use NewProfileHandler to create one.
 type ProfileHandler struct {
-	DB *sql.DB
+	DB   *sql.DB
+	List ListOptions // Shape of GET /api/profiles
 }
 
-// NewProfileHandler creates a new ProfileHandler with the given database connection.
-func NewProfileHandler(database *sql.DB) *ProfileHandler {
-	return &ProfileHandler{DB: database}
+// NewProfileHandler creates a new ProfileHandler with the given database
+// connection, listing profiles as listOpts says.
+func NewProfileHandler(database *sql.DB, listOpts ListOptions) *ProfileHandler {
+	return &ProfileHandler{DB: database, List: listOpts}
 }
 
 // =============================================================================
@@ -56,15 +58,15 @@ type updateProfileRequest struct {
 func (h *ProfileHandler) HandleCreateProfile(w http.ResponseWriter, r *http.Request) {
 	// Parse request body
 	var req createProfileRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSONBody(r, &req); err != nil {
 		log.Printf("❌ Profile create: invalid request body: %v", err)
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Validate required fields
 	if req.Name == "" {
-		writeError(w, http.StatusBadRequest, "Name is required")
+		writeError(w, r, http.StatusBadRequest, "Name is required")
 		return
 	}
 
@@ -72,12 +74,12 @@ func (h *ProfileHandler) HandleCreateProfile(w http.ResponseWriter, r *http.Requ
 	profile, err := db.CreateProfile(h.DB, req.Name)
 	if err != nil {
 		log.Printf("❌ Profile create failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to create profile")
+		writeError(w, r, http.StatusInternalServerError, "Failed to create profile")
 		return
 	}
 
 	log.Printf("👤 Created profile: %s (id: %s)", profile.Name, profile.ID)
-	writeJSON(w, http.StatusCreated, profile)
+	writeJSON(w, r, http.StatusCreated, profile)
 }
 
 // HandleGetProfile returns a single profile by ID, enriched with its rooms and devices.
@@ -86,7 +88,7 @@ func (h *ProfileHandler) HandleCreateProfile(w http.ResponseWriter, r *http.Requ
 func (h *ProfileHandler) HandleGetProfile(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "Profile ID is required")
+		writeError(w, r, http.StatusBadRequest, "Profile ID is required")
 		return
 	}
 
@@ -94,11 +96,11 @@ func (h *ProfileHandler) HandleGetProfile(w http.ResponseWriter, r *http.Request
 	profile, err := db.GetProfile(h.DB, id)
 	if err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Profile not found")
+			writeError(w, r, http.StatusNotFound, "Profile not found")
 			return
 		}
 		log.Printf("❌ Profile get failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to get profile")
+		writeError(w, r, http.StatusInternalServerError, "Failed to get profile")
 		return
 	}
 
@@ -106,14 +108,14 @@ func (h *ProfileHandler) HandleGetProfile(w http.ResponseWriter, r *http.Request
 	rooms, err := db.ListRoomsByProfile(h.DB, id)
 	if err != nil {
 		log.Printf("❌ Failed to list rooms for profile %s: %v", id, err)
-		writeError(w, http.StatusInternalServerError, "Failed to get profile rooms")
+		writeError(w, r, http.StatusInternalServerError, "Failed to get profile rooms")
 		return
 	}
 
 	devices, err := db.ListDevicesByProfile(h.DB, id)
 	if err != nil {
 		log.Printf("❌ Failed to list devices for profile %s: %v", id, err)
-		writeError(w, http.StatusInternalServerError, "Failed to get profile devices")
+		writeError(w, r, http.StatusInternalServerError, "Failed to get profile devices")
 		return
 	}
 
@@ -135,7 +137,7 @@ func (h *ProfileHandler) HandleGetProfile(w http.ResponseWriter, r *http.Request
 		UpdatedAt: profile.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
 
-	writeJSON(w, http.StatusOK, resp)
+	writeJSON(w, r, http.StatusOK, resp)
 }
 
 // HandleListProfiles returns all profiles. Useful for development and debugging.
@@ -145,7 +147,7 @@ func (h *ProfileHandler) HandleListProfiles(w http.ResponseWriter, r *http.Reque
 	profiles, err := db.ListProfiles(h.DB)
 	if err != nil {
 		log.Printf("❌ Profile list failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to list profiles")
+		writeError(w, r, http.StatusInternalServerError, "Failed to list profiles")
 		return
 	}
 
@@ -154,7 +156,7 @@ func (h *ProfileHandler) HandleListProfiles(w http.ResponseWriter, r *http.Reque
 		profiles = []db.Profile{}
 	}
 
-	writeJSON(w, http.StatusOK, profiles)
+	writeList(w, r, h.List, profiles, fmt.Sprintf("Found %d profile(s)", len(profiles)))
 }
 
 // HandleUpdateProfile updates a profile's name.
@@ -164,20 +166,20 @@ func (h *ProfileHandler) HandleListProfiles(w http.ResponseWriter, r *http.Reque
 func (h *ProfileHandler) HandleUpdateProfile(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "Profile ID is required")
+		writeError(w, r, http.StatusBadRequest, "Profile ID is required")
 		return
 	}
 
 	// Parse request body
 	var req updateProfileRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSONBody(r, &req); err != nil {
 		log.Printf("❌ Profile update: invalid request body: %v", err)
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if req.Name == "" {
-		writeError(w, http.StatusBadRequest, "Name is required")
+		writeError(w, r, http.StatusBadRequest, "Name is required")
 		return
 	}
 
@@ -185,16 +187,16 @@ func (h *ProfileHandler) HandleUpdateProfile(w http.ResponseWriter, r *http.Requ
 	profile, err := db.UpdateProfile(h.DB, id, req.Name)
 	if err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Profile not found")
+			writeError(w, r, http.StatusNotFound, "Profile not found")
 			return
 		}
 		log.Printf("❌ Profile update failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to update profile")
+		writeError(w, r, http.StatusInternalServerError, "Failed to update profile")
 		return
 	}
 
 	log.Printf("👤 Updated profile: %s (id: %s)", profile.Name, profile.ID)
-	writeJSON(w, http.StatusOK, profile)
+	writeJSON(w, r, http.StatusOK, profile)
 }
 
 // HandleDeleteProfile removes a profile and all associated rooms/devices (cascade).
@@ -203,17 +205,17 @@ func (h *ProfileHandler) HandleUpdateProfile(w http.ResponseWriter, r *http.Requ
 func (h *ProfileHandler) HandleDeleteProfile(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "Profile ID is required")
+		writeError(w, r, http.StatusBadRequest, "Profile ID is required")
 		return
 	}
 
 	if err := db.DeleteProfile(h.DB, id); err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Profile not found")
+			writeError(w, r, http.StatusNotFound, "Profile not found")
 			return
 		}
 		log.Printf("❌ Profile delete failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to delete profile")
+		writeError(w, r, http.StatusInternalServerError, "Failed to delete profile")
 		return
 	}
 
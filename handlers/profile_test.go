@@ -20,7 +20,7 @@ func setupTestProfileHandler(t *testing.T) (*ProfileHandler, *sql.DB) {
 		t.Fatalf("Failed to init test DB: %v", err)
 	}
 	t.Cleanup(func() { database.Close() })
-	return NewProfileHandler(database), database
+	return NewProfileHandler(database, ListOptions{}), database
 }
 
 // =============================================================================
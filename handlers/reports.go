@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/pantheon/artemis/reports"
+)
+
+// DailyDiffResponse is the response of GET /api/reports/daily-diff.
+type DailyDiffResponse struct {
+	reports.Diff
+	Timestamp string `json:"timestamp"`
+}
+
+// HandleDailyDiff compares the latest daily snapshot with the one before:
+// which lights were on longer or shorter, and which lights and cameras went
+// offline.
+// GET /api/reports/daily-diff
+// Returns: DailyDiffResponse JSON, or 404 until two snapshots have been taken
+func HandleDailyDiff(recorder *reports.Recorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept GET requests
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		diff, err := recorder.DailyDiff()
+		if errors.Is(err, reports.ErrNotEnoughSnapshots) {
+			writeError(w, r, http.StatusNotFound, "No report yet — snapshots are taken once a day, and two are needed to compare")
+			return
+		}
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, r, http.StatusOK, DailyDiffResponse{
+			Diff:      diff,
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+	}
+}
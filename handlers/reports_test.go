@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/reports"
+)
+
+// stateList is a reports.LightSource serving fixed states.
+type stateList []govee.DeviceState
+
+func (s stateList) States() []govee.DeviceState { return s }
+
+func TestDailyDiff(t *testing.T) {
+	on := true
+	recorder, err := reports.NewRecorder("", "00:00", 7, stateList{{DeviceID: "AA:01", PowerOn: &on}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := HandleDailyDiff(recorder)
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/api/reports/daily-diff", nil))
+	var errResp ErrorResponse
+	json.NewDecoder(w.Body).Decode(&errResp)
+	if w.Code != http.StatusNotFound || errResp.Code != errcode.NotFound {
+		t.Fatalf("expected 404 before any snapshot, got %d %+v", w.Code, errResp)
+	}
+
+	// On for one sample yesterday and two today
+	recorder.Sample(context.Background())
+	recorder.Snapshot(time.Now())
+	recorder.Sample(context.Background())
+	recorder.Sample(context.Background())
+	recorder.Snapshot(time.Now())
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/api/reports/daily-diff", nil))
+	var resp DailyDiffResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || resp.Summary.LightsOnLonger != 1 || len(resp.Lights) != 1 || resp.Lights[0].Change != reports.ChangeOnLonger {
+		t.Errorf("expected the lamp to have been on longer, got %d %+v", w.Code, resp)
+	}
+}
@@ -2,22 +2,36 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/names"
 )
 
 // RoomHandler holds the database connection and provides HTTP handlers
 // for room CRUD operations. Use NewRoomHandler to create one.
 type RoomHandler struct {
-	DB *sql.DB
+	DB   *sql.DB
+	List ListOptions // Shape of GET /api/profile/{profileId}/rooms
+
+	// RequireIfMatch answers updates without If-Match with 428 instead of
+	// letting them overwrite any version (REQUIRE_IF_MATCH).
+	RequireIfMatch bool
+
+	// nameMu serializes creates and renames, so two requests for one name
+	// never race for SQLite's write lock and one gets a 500 instead of a 409.
+	nameMu sync.Mutex
 }
 
-// NewRoomHandler creates a new RoomHandler with the given database connection.
-func NewRoomHandler(database *sql.DB) *RoomHandler {
-	return &RoomHandler{DB: database}
+// NewRoomHandler creates a new RoomHandler with the given database
+// connection, listing rooms as listOpts says.
+func NewRoomHandler(database *sql.DB, listOpts ListOptions, requireIfMatch bool) *RoomHandler {
+	return &RoomHandler{DB: database, List: listOpts, RequireIfMatch: requireIfMatch}
 }
 
 // =============================================================================
@@ -53,6 +67,7 @@ type roomDetailResponse struct {
 	BeaconUUID  *string     `json:"beaconUuid,omitempty"`
 	BeaconMajor *int        `json:"beaconMajor,omitempty"`
 	BeaconMinor *int        `json:"beaconMinor,omitempty"`
+	Version     int         `json:"version"`
 	Devices     []db.Device `json:"devices"`
 	CreatedAt   string      `json:"createdAt"`
 	UpdatedAt   string      `json:"updatedAt"`
@@ -65,27 +80,29 @@ type roomDetailResponse struct {
 // HandleCreateRoom creates a new room under the given profile.
 // POST /api/profile/{profileId}/rooms
 // Request body: {"name": "Living Room", "icon": "sofa"}
-// Response (201): room object
+// Response (201): room object; 409 if the profile has a room with the name
+// (rename that one with PUT /api/room/{id} instead)
 func (h *RoomHandler) HandleCreateRoom(w http.ResponseWriter, r *http.Request) {
 	profileID := r.PathValue("profileId")
 	if profileID == "" {
-		writeError(w, http.StatusBadRequest, "Profile ID is required")
+		writeError(w, r, http.StatusBadRequest, "Profile ID is required")
 		return
 	}
 
 	// Parse request body
 	var req createRoomRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSONBody(r, &req); err != nil {
 		log.Printf("❌ Room create: invalid request body: %v", err)
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Validate required fields
-	if req.Name == "" {
-		writeError(w, http.StatusBadRequest, "Name is required")
+	if err := names.Validate(req.Name); err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
+	req.Name = strings.TrimSpace(req.Name)
 
 	// Default icon if not provided
 	icon := req.Icon
@@ -97,24 +114,31 @@ func (h *RoomHandler) HandleCreateRoom(w http.ResponseWriter, r *http.Request) {
 	_, err := db.GetProfile(h.DB, profileID)
 	if err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Profile not found")
+			writeError(w, r, http.StatusNotFound, "Profile not found")
 			return
 		}
 		log.Printf("❌ Room create: failed to verify profile: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to verify profile")
+		writeError(w, r, http.StatusInternalServerError, "Failed to verify profile")
 		return
 	}
 
 	// Create the room
+	h.nameMu.Lock()
 	room, err := db.CreateRoom(h.DB, profileID, req.Name, icon)
+	h.nameMu.Unlock()
 	if err != nil {
+		if errors.Is(err, db.ErrRoomExists) {
+			writeError(w, r, http.StatusConflict, fmt.Sprintf("A room named %q already exists", req.Name))
+			return
+		}
 		log.Printf("❌ Room create failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to create room")
+		writeError(w, r, http.StatusInternalServerError, "Failed to create room")
 		return
 	}
 
 	log.Printf("🏠 Created room: %s (id: %s) for profile %s", room.Name, room.ID, profileID)
-	writeJSON(w, http.StatusCreated, room)
+	setVersionETag(w, room.Version)
+	writeJSON(w, r, http.StatusCreated, room)
 }
 
 // HandleListRooms returns all rooms for the given profile.
@@ -123,14 +147,14 @@ func (h *RoomHandler) HandleCreateRoom(w http.ResponseWriter, r *http.Request) {
 func (h *RoomHandler) HandleListRooms(w http.ResponseWriter, r *http.Request) {
 	profileID := r.PathValue("profileId")
 	if profileID == "" {
-		writeError(w, http.StatusBadRequest, "Profile ID is required")
+		writeError(w, r, http.StatusBadRequest, "Profile ID is required")
 		return
 	}
 
 	rooms, err := db.ListRoomsByProfile(h.DB, profileID)
 	if err != nil {
 		log.Printf("❌ Room list failed for profile %s: %v", profileID, err)
-		writeError(w, http.StatusInternalServerError, "Failed to list rooms")
+		writeError(w, r, http.StatusInternalServerError, "Failed to list rooms")
 		return
 	}
 
@@ -139,7 +163,7 @@ func (h *RoomHandler) HandleListRooms(w http.ResponseWriter, r *http.Request) {
 		rooms = []db.Room{}
 	}
 
-	writeJSON(w, http.StatusOK, rooms)
+	writeList(w, r, h.List, rooms, fmt.Sprintf("Found %d room(s)", len(rooms)))
 }
 
 // HandleGetRoom returns a single room by ID, enriched with its assigned devices.
@@ -148,7 +172,7 @@ func (h *RoomHandler) HandleListRooms(w http.ResponseWriter, r *http.Request) {
 func (h *RoomHandler) HandleGetRoom(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "Room ID is required")
+		writeError(w, r, http.StatusBadRequest, "Room ID is required")
 		return
 	}
 
@@ -156,11 +180,11 @@ func (h *RoomHandler) HandleGetRoom(w http.ResponseWriter, r *http.Request) {
 	room, err := db.GetRoom(h.DB, id)
 	if err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Room not found")
+			writeError(w, r, http.StatusNotFound, "Room not found")
 			return
 		}
 		log.Printf("❌ Room get failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to get room")
+		writeError(w, r, http.StatusInternalServerError, "Failed to get room")
 		return
 	}
 
@@ -168,7 +192,7 @@ func (h *RoomHandler) HandleGetRoom(w http.ResponseWriter, r *http.Request) {
 	devices, err := db.ListDevicesByRoom(h.DB, id)
 	if err != nil {
 		log.Printf("❌ Failed to list devices for room %s: %v", id, err)
-		writeError(w, http.StatusInternalServerError, "Failed to get room devices")
+		writeError(w, r, http.StatusInternalServerError, "Failed to get room devices")
 		return
 	}
 
@@ -186,98 +210,128 @@ func (h *RoomHandler) HandleGetRoom(w http.ResponseWriter, r *http.Request) {
 		BeaconUUID:  room.BeaconUUID,
 		BeaconMajor: room.BeaconMajor,
 		BeaconMinor: room.BeaconMinor,
+		Version:     room.Version,
 		Devices:     devices,
 		CreatedAt:   room.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		UpdatedAt:   room.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
 
-	writeJSON(w, http.StatusOK, resp)
+	setVersionETag(w, room.Version)
+	writeJSON(w, r, http.StatusOK, resp)
 }
 
 // HandleUpdateRoom updates a room's name and icon.
 // PUT /api/room/{id}
 // Request body: {"name": "Home Office", "icon": "desktopcomputer"}
-// Response (200): updated room object
+// Optional If-Match: the room's ETag; 412 if the room changed since
+// Response (200): updated room object; 409 if another room in the profile
+// has the name
 func (h *RoomHandler) HandleUpdateRoom(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "Room ID is required")
+		writeError(w, r, http.StatusBadRequest, "Room ID is required")
+		return
+	}
+	ifVersion, ok := ifMatchVersion(w, r, h.RequireIfMatch)
+	if !ok {
 		return
 	}
 
 	// Parse request body
 	var req updateRoomRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSONBody(r, &req); err != nil {
 		log.Printf("❌ Room update: invalid request body: %v", err)
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if req.Name == "" {
-		writeError(w, http.StatusBadRequest, "Name is required")
+	if err := names.Validate(req.Name); err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
+	req.Name = strings.TrimSpace(req.Name)
 	if req.Icon == "" {
-		writeError(w, http.StatusBadRequest, "Icon is required")
+		writeError(w, r, http.StatusBadRequest, "Icon is required")
 		return
 	}
 
 	// Update the room
-	room, err := db.UpdateRoom(h.DB, id, req.Name, req.Icon)
+	h.nameMu.Lock()
+	room, err := db.UpdateRoom(h.DB, id, req.Name, req.Icon, ifVersion)
+	h.nameMu.Unlock()
 	if err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Room not found")
+			writeError(w, r, http.StatusNotFound, "Room not found")
+			return
+		}
+		if errors.Is(err, db.ErrStaleVersion) {
+			sendStaleVersion(w, r, "room")
+			return
+		}
+		if errors.Is(err, db.ErrRoomExists) {
+			writeError(w, r, http.StatusConflict, fmt.Sprintf("A room named %q already exists", req.Name))
 			return
 		}
 		log.Printf("❌ Room update failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to update room")
+		writeError(w, r, http.StatusInternalServerError, "Failed to update room")
 		return
 	}
 
-	log.Printf("🏠 Updated room: %s (id: %s)", room.Name, room.ID)
-	writeJSON(w, http.StatusOK, room)
+	log.Printf("🏠 Updated room: %s (id: %s, version %d)", room.Name, room.ID, room.Version)
+	setVersionETag(w, room.Version)
+	writeJSON(w, r, http.StatusOK, room)
 }
 
 // HandleUpdateRoomBeacon sets the iBeacon configuration for a room.
 // This links the room to a physical BLE beacon for proximity detection.
 // PUT /api/room/{id}/beacon
 // Request body: {"uuid": "E2C56DB5-...", "major": 1, "minor": 100}
+// Optional If-Match: the room's ETag; 412 if the room changed since
 // Response (200): updated room object with beacon fields
 func (h *RoomHandler) HandleUpdateRoomBeacon(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "Room ID is required")
+		writeError(w, r, http.StatusBadRequest, "Room ID is required")
+		return
+	}
+	ifVersion, ok := ifMatchVersion(w, r, h.RequireIfMatch)
+	if !ok {
 		return
 	}
 
 	// Parse request body
 	var req updateRoomBeaconRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSONBody(r, &req); err != nil {
 		log.Printf("❌ Room beacon update: invalid request body: %v", err)
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Validate required fields
 	if req.UUID == "" {
-		writeError(w, http.StatusBadRequest, "Beacon UUID is required")
+		writeError(w, r, http.StatusBadRequest, "Beacon UUID is required")
 		return
 	}
 
 	// Update beacon configuration
-	room, err := db.UpdateRoomBeacon(h.DB, id, req.UUID, req.Major, req.Minor)
+	room, err := db.UpdateRoomBeacon(h.DB, id, req.UUID, req.Major, req.Minor, ifVersion)
 	if err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Room not found")
+			writeError(w, r, http.StatusNotFound, "Room not found")
+			return
+		}
+		if errors.Is(err, db.ErrStaleVersion) {
+			sendStaleVersion(w, r, "room")
 			return
 		}
 		log.Printf("❌ Room beacon update failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to update room beacon")
+		writeError(w, r, http.StatusInternalServerError, "Failed to update room beacon")
 		return
 	}
 
 	log.Printf("📡 Updated beacon for room %s: uuid=%s major=%d minor=%d", room.Name, req.UUID, req.Major, req.Minor)
-	writeJSON(w, http.StatusOK, room)
+	setVersionETag(w, room.Version)
+	writeJSON(w, r, http.StatusOK, room)
 }
 
 // HandleDeleteRoom removes a room. Devices assigned to this room will have
@@ -287,17 +341,17 @@ func (h *RoomHandler) HandleUpdateRoomBeacon(w http.ResponseWriter, r *http.Requ
 func (h *RoomHandler) HandleDeleteRoom(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "Room ID is required")
+		writeError(w, r, http.StatusBadRequest, "Room ID is required")
 		return
 	}
 
 	if err := db.DeleteRoom(h.DB, id); err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "Room not found")
+			writeError(w, r, http.StatusNotFound, "Room not found")
 			return
 		}
 		log.Printf("❌ Room delete failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to delete room")
+		writeError(w, r, http.StatusInternalServerError, "Failed to delete room")
 		return
 	}
 
@@ -92,7 +92,7 @@ type roomInteractionResponse struct {
 func (h *RoomTemplateHandler) HandleGetRoomTemplate(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "room id is required")
+		writeError(w, r, http.StatusBadRequest, "room id is required")
 		return
 	}
 
@@ -100,17 +100,17 @@ func (h *RoomTemplateHandler) HandleGetRoomTemplate(w http.ResponseWriter, r *ht
 	room, err := db.GetRoom(h.DB, id)
 	if err != nil {
 		if isNotFound(err) {
-			writeError(w, http.StatusNotFound, "room not found")
+			writeError(w, r, http.StatusNotFound, "room not found")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, "failed to look up room")
+		writeError(w, r, http.StatusInternalServerError, "failed to look up room")
 		return
 	}
 
 	// Get the default template for this room name.
 	template := defaultTemplate(room.Name)
 
-	writeJSON(w, http.StatusOK, template)
+	writeJSON(w, r, http.StatusOK, template)
 }
 
 // =============================================================================
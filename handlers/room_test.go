@@ -6,9 +6,15 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/names"
 )
 
 // setupTestRoomHandler creates a RoomHandler backed by an in-memory SQLite DB.
@@ -27,7 +33,7 @@ func setupTestRoomHandler(t *testing.T) (*RoomHandler, *sql.DB, *db.Profile) {
 		t.Fatalf("Failed to create test profile: %v", err)
 	}
 
-	return NewRoomHandler(database), database, profile
+	return NewRoomHandler(database, ListOptions{}, true), database, profile
 }
 
 // =============================================================================
@@ -113,6 +119,91 @@ func TestCreateRoom_ProfileNotFound(t *testing.T) {
 	}
 }
 
+func TestCreateRoom_InvalidName(t *testing.T) {
+	h, _, profile := setupTestRoomHandler(t)
+
+	for _, name := range []string{"   ", "Living/Room", strings.Repeat("a", names.MaxLength+1)} {
+		body, _ := json.Marshal(createRoomRequest{Name: name})
+		req := httptest.NewRequest(http.MethodPost, "/api/profile/"+profile.ID+"/rooms", bytes.NewReader(body))
+		req.SetPathValue("profileId", profile.ID)
+		w := httptest.NewRecorder()
+
+		h.HandleCreateRoom(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("name %q: expected status 400, got %d", name, w.Code)
+		}
+	}
+}
+
+func TestCreateRoom_DuplicateName(t *testing.T) {
+	h, database, profile := setupTestRoomHandler(t)
+	db.CreateRoom(database, profile.ID, "Living Room", "sofa")
+
+	body := `{"name": " living room ", "icon": "house"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/profile/"+profile.ID+"/rooms", bytes.NewBufferString(body))
+	req.SetPathValue("profileId", profile.ID)
+	w := httptest.NewRecorder()
+
+	h.HandleCreateRoom(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Another profile may use the name
+	other, _ := db.CreateProfile(database, "Other User")
+	req = httptest.NewRequest(http.MethodPost, "/api/profile/"+other.ID+"/rooms", bytes.NewBufferString(body))
+	req.SetPathValue("profileId", other.ID)
+	w = httptest.NewRecorder()
+
+	h.HandleCreateRoom(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201 in another profile, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCreateRoom_ConcurrentDuplicates(t *testing.T) {
+	// A file database, so the requests use separate connections
+	database, err := db.InitDB(filepath.Join(t.TempDir(), "artemis.db"))
+	if err != nil {
+		t.Fatalf("Failed to init test DB: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	profile, _ := db.CreateProfile(database, "Test User")
+	h := NewRoomHandler(database, ListOptions{}, true)
+
+	var created, conflicts atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/api/profile/"+profile.ID+"/rooms", bytes.NewBufferString(`{"name": "Office"}`))
+			req.SetPathValue("profileId", profile.ID)
+			w := httptest.NewRecorder()
+			h.HandleCreateRoom(w, req)
+			switch w.Code {
+			case http.StatusCreated:
+				created.Add(1)
+			case http.StatusConflict:
+				conflicts.Add(1)
+			default:
+				t.Errorf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+
+	if created.Load() != 1 || conflicts.Load() != 9 {
+		t.Errorf("expected 1 create and 9 conflicts, got %d and %d", created.Load(), conflicts.Load())
+	}
+	if rooms, _ := db.ListRoomsByProfile(database, profile.ID); len(rooms) != 1 {
+		t.Errorf("expected 1 room, got %d", len(rooms))
+	}
+}
+
 // =============================================================================
 // GET /api/profile/{profileId}/rooms — List Rooms
 // =============================================================================
@@ -242,6 +333,7 @@ func TestUpdateRoom_Success(t *testing.T) {
 	body := `{"name": "Home Office", "icon": "desktopcomputer"}`
 	req := httptest.NewRequest(http.MethodPut, "/api/room/"+room.ID, bytes.NewBufferString(body))
 	req.SetPathValue("id", room.ID)
+	req.Header.Set("If-Match", `"1"`)
 	w := httptest.NewRecorder()
 
 	h.HandleUpdateRoom(w, req)
@@ -260,12 +352,114 @@ func TestUpdateRoom_Success(t *testing.T) {
 	}
 }
 
+func TestUpdateRoom_DuplicateName(t *testing.T) {
+	h, database, profile := setupTestRoomHandler(t)
+
+	db.CreateRoom(database, profile.ID, "Office", "desktopcomputer")
+	room, _ := db.CreateRoom(database, profile.ID, "Den", "house")
+
+	body := `{"name": "OFFICE", "icon": "house"}`
+	req := httptest.NewRequest(http.MethodPut, "/api/room/"+room.ID, bytes.NewBufferString(body))
+	req.SetPathValue("id", room.ID)
+	req.Header.Set("If-Match", `"1"`)
+	w := httptest.NewRecorder()
+
+	h.HandleUpdateRoom(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Changing only the case of its own name is fine
+	req = httptest.NewRequest(http.MethodPut, "/api/room/"+room.ID, bytes.NewBufferString(`{"name": "DEN", "icon": "house"}`))
+	req.SetPathValue("id", room.ID)
+	req.Header.Set("If-Match", `"1"`)
+	w = httptest.NewRecorder()
+
+	h.HandleUpdateRoom(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 renaming a room's own name, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestUpdateRoom_IfMatch(t *testing.T) {
+	h, database, profile := setupTestRoomHandler(t)
+	room, _ := db.CreateRoom(database, profile.ID, "Office", "house")
+
+	update := func(ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/room/"+room.ID, bytes.NewBufferString(body))
+		req.SetPathValue("id", room.ID)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		h.HandleUpdateRoom(w, req)
+		return w
+	}
+
+	// A fresh ETag applies and returns the next one
+	w := update(`"1"`, `{"name": "Study", "icon": "book"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if etag := w.Header().Get("ETag"); etag != `"2"` {
+		t.Errorf(`expected ETag "2", got %s`, etag)
+	}
+
+	// A second client still holding "1" is rejected
+	if w := update(`"1"`, `{"name": "Gym", "icon": "figure.run"}`); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected status 412 for a stale ETag, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := update(`W/"2"`, `{"name": "Gym", "icon": "figure.run"}`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected status 412 for a weak ETag, got %d", w.Code)
+	}
+	current, _ := db.GetRoom(database, room.ID)
+	if current.Name != "Study" || current.Version != 2 {
+		t.Errorf("expected the room unchanged by stale updates, got %+v", current)
+	}
+
+	if w := update("*", `{"name": "Den", "icon": "sofa"}`); w.Code != http.StatusOK {
+		t.Errorf("expected If-Match: * to update any version, got %d", w.Code)
+	}
+}
+
+func TestUpdateRoom_IfMatchRequiredByDefault(t *testing.T) {
+	h, database, profile := setupTestRoomHandler(t)
+	room, _ := db.CreateRoom(database, profile.ID, "Office", "house")
+
+	update := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/room/"+room.ID, bytes.NewBufferString(`{"name": "Gym", "icon": "figure.run"}`))
+		req.SetPathValue("id", room.ID)
+		w := httptest.NewRecorder()
+		h.HandleUpdateRoom(w, req)
+		return w
+	}
+
+	w := update()
+	var resp ErrorResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusPreconditionRequired || resp.Code != errcode.IfMatchRequired {
+		t.Fatalf("expected 428 IF_MATCH_REQUIRED without If-Match, got %d %+v", w.Code, resp)
+	}
+	if current, _ := db.GetRoom(database, room.ID); current.Name != "Office" {
+		t.Errorf("expected the room unchanged, got %+v", current)
+	}
+
+	// REQUIRE_IF_MATCH=false: last write wins
+	h.RequireIfMatch = false
+	if w := update(); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 without If-Match when not required, got %d", w.Code)
+	}
+}
+
 func TestUpdateRoom_NotFound(t *testing.T) {
 	h, _, _ := setupTestRoomHandler(t)
 
 	body := `{"name": "Whatever", "icon": "house"}`
 	req := httptest.NewRequest(http.MethodPut, "/api/room/nonexistent", bytes.NewBufferString(body))
 	req.SetPathValue("id", "nonexistent")
+	req.Header.Set("If-Match", "*")
 	w := httptest.NewRecorder()
 
 	h.HandleUpdateRoom(w, req)
@@ -283,6 +477,7 @@ func TestUpdateRoom_MissingFields(t *testing.T) {
 	body := `{"name": "New Name", "icon": ""}`
 	req := httptest.NewRequest(http.MethodPut, "/api/room/"+room.ID, bytes.NewBufferString(body))
 	req.SetPathValue("id", room.ID)
+	req.Header.Set("If-Match", "*")
 	w := httptest.NewRecorder()
 
 	h.HandleUpdateRoom(w, req)
@@ -304,6 +499,7 @@ func TestUpdateRoomBeacon_Success(t *testing.T) {
 	body := `{"uuid": "E2C56DB5-DFFB-48D2-B060-D0F5A71096E0", "major": 1, "minor": 100}`
 	req := httptest.NewRequest(http.MethodPut, "/api/room/"+room.ID+"/beacon", bytes.NewBufferString(body))
 	req.SetPathValue("id", room.ID)
+	req.Header.Set("If-Match", `"1"`)
 	w := httptest.NewRecorder()
 
 	h.HandleUpdateRoomBeacon(w, req)
@@ -334,6 +530,7 @@ func TestUpdateRoomBeacon_MissingUUID(t *testing.T) {
 	body := `{"uuid": "", "major": 1, "minor": 100}`
 	req := httptest.NewRequest(http.MethodPut, "/api/room/"+room.ID+"/beacon", bytes.NewBufferString(body))
 	req.SetPathValue("id", room.ID)
+	req.Header.Set("If-Match", "*")
 	w := httptest.NewRecorder()
 
 	h.HandleUpdateRoomBeacon(w, req)
@@ -349,6 +546,7 @@ func TestUpdateRoomBeacon_NotFound(t *testing.T) {
 	body := `{"uuid": "E2C56DB5-DFFB-48D2-B060-D0F5A71096E0", "major": 1, "minor": 100}`
 	req := httptest.NewRequest(http.MethodPut, "/api/room/nonexistent/beacon", bytes.NewBufferString(body))
 	req.SetPathValue("id", "nonexistent")
+	req.Header.Set("If-Match", "*")
 	w := httptest.NewRecorder()
 
 	h.HandleUpdateRoomBeacon(w, req)
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RouteInfo describes one route the server exposes, as listed by
+// GET /api/routes.
+type RouteInfo struct {
+	Methods     []string `json:"methods"`           // Empty when the route accepts any method
+	Path        string   `json:"path"`              // Full path pattern, e.g. "/api/room/{id}"
+	Description string   `json:"description"`       // One line, as in the startup log
+	Feature     string   `json:"feature,omitempty"` // Integration the route belongs to (e.g. "Govee")
+	Enabled     bool     `json:"enabled"`           // False when its integration is switched off (the route answers 404)
+}
+
+// HandleListRoutes returns the route table, in registration order.
+// GET /api/routes
+//
+// routes is called on every request, so the list includes routes
+// registered after this handler was built (such as /routes itself).
+func HandleListRoutes(routes func() []RouteInfo, listOpts ListOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only accept GET requests.
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		list := routes()
+		writeList(w, r, listOpts, list, fmt.Sprintf("Found %d route(s)", len(list)))
+	}
+}
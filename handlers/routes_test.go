@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListRoutes(t *testing.T) {
+	routes := func() []RouteInfo {
+		return []RouteInfo{
+			{Methods: []string{"GET"}, Path: "/api/health", Description: "Health check", Enabled: true},
+			{Methods: []string{"GET"}, Path: "/api/cameras", Description: "List Wyze cameras", Feature: "Cameras"},
+		}
+	}
+
+	w := httptest.NewRecorder()
+	HandleListRoutes(routes, ListOptions{})(w, httptest.NewRequest(http.MethodGet, "/api/routes", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var got []RouteInfo
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(got) != 2 || got[1].Feature != "Cameras" || got[1].Enabled {
+		t.Errorf("unexpected routes: %+v", got)
+	}
+}
+
+func TestListRoutes_RejectsPost(t *testing.T) {
+	w := httptest.NewRecorder()
+	HandleListRoutes(func() []RouteInfo { return nil }, ListOptions{})(w, httptest.NewRequest(http.MethodPost, "/api/routes", nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+}
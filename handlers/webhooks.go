@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pantheon/artemis/webhooks"
+)
+
+// WebhookRequest is the body of POST /api/webhooks.
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"` // Event types to deliver; empty means all
+	Secret string   `json:"secret"` // Optional HMAC-SHA256 signing key
+}
+
+// WebhookResponse describes a webhook. The secret is never sent back;
+// hasSecret says whether deliveries are signed.
+type WebhookResponse struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	HasSecret bool      `json:"hasSecret"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func newWebhookResponse(hook webhooks.Webhook) WebhookResponse {
+	events := hook.Events
+	if events == nil {
+		events = []string{}
+	}
+	return WebhookResponse{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Events:    events,
+		HasSecret: hook.Secret != "",
+		CreatedAt: hook.CreatedAt,
+	}
+}
+
+// HandleWebhooks lists and registers outbound webhooks.
+// GET  /api/webhooks — list webhooks (secrets are never returned)
+// POST /api/webhooks — register one: {"url", "events", "secret"}; returns 201
+//
+// events filters by event type (see webhooks.KnownEvents); leave it empty
+// to receive every event. With a secret, each delivery carries an
+// X-Artemis-Signature HMAC of its body.
+func HandleWebhooks(store *webhooks.Store, listOpts ListOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			hooks := store.List()
+			resp := make([]WebhookResponse, 0, len(hooks))
+			for _, hook := range hooks {
+				resp = append(resp, newWebhookResponse(hook))
+			}
+			writeList(w, r, listOpts, resp, fmt.Sprintf("Found %d webhook(s)", len(resp)))
+
+		case http.MethodPost:
+			var req WebhookRequest
+			if err := decodeJSONBody(r, &req); err != nil {
+				writeError(w, r, http.StatusBadRequest, err.Error())
+				return
+			}
+			if err := webhooks.Validate(webhooks.Webhook{URL: req.URL, Events: req.Events}); err != nil {
+				writeError(w, r, http.StatusBadRequest, "Invalid webhook: "+err.Error())
+				return
+			}
+
+			hook, err := store.Add(webhooks.Webhook{URL: req.URL, Events: req.Events, Secret: req.Secret})
+			if err != nil {
+				log.Printf("❌ Failed to register webhook: %v", err)
+				writeError(w, r, http.StatusInternalServerError, "Failed to save webhook")
+				return
+			}
+
+			log.Printf("🪝 Registered webhook %s → %s (events: %v)", hook.ID, hook.URL, hook.Events)
+			writeJSON(w, r, http.StatusCreated, newWebhookResponse(hook))
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// HandleDeleteWebhook removes a webhook.
+// DELETE /api/webhooks/{id} — returns 204, or 404 if there is no such webhook
+func HandleDeleteWebhook(store *webhooks.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := r.PathValue("id")
+		if err := store.Delete(id); err != nil {
+			if errors.Is(err, webhooks.ErrNotFound) {
+				writeError(w, r, http.StatusNotFound, "Webhook not found")
+				return
+			}
+			log.Printf("❌ Failed to delete webhook %s: %v", id, err)
+			writeError(w, r, http.StatusInternalServerError, "Failed to delete webhook")
+			return
+		}
+
+		log.Printf("🪝 Deleted webhook %s", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pantheon/artemis/webhooks"
+)
+
+func newWebhooksMux(store *webhooks.Store) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/webhooks", HandleWebhooks(store, ListOptions{}))
+	mux.HandleFunc("/api/webhooks/{id}", HandleDeleteWebhook(store))
+	return mux
+}
+
+func TestWebhooks_RegisterListDelete(t *testing.T) {
+	store, _ := webhooks.NewStore("")
+	mux := newWebhooksMux(store)
+
+	body := `{"url": "https://example.com/hook", "events": ["camera.offline"], "secret": "s3cret"}`
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/webhooks", strings.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "s3cret") {
+		t.Errorf("expected the secret not to be returned, got %s", w.Body.String())
+	}
+	var created WebhookResponse
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.ID == "" || !created.HasSecret {
+		t.Errorf("expected an ID and hasSecret=true, got %+v", created)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/webhooks", nil))
+	var listed []WebhookResponse
+	json.NewDecoder(w.Body).Decode(&listed)
+	if len(listed) != 1 || listed[0].ID != created.ID {
+		t.Errorf("expected the registered webhook to be listed, got %+v", listed)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/webhooks/"+created.ID, nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status 204, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/webhooks/"+created.ID, nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a deleted webhook, got %d", w.Code)
+	}
+}
+
+func TestWebhooks_RejectsInvalid(t *testing.T) {
+	store, _ := webhooks.NewStore("")
+	mux := newWebhooksMux(store)
+
+	for _, body := range []string{
+		`{"url": "not a url"}`,
+		`{"url": "https://example.com", "events": ["scene.fired"]}`,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/webhooks", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, w.Code)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pantheon/artemis/config"
+)
+
+// listen opens the server's listener: a Unix domain socket when
+// LISTEN_SOCKET is set, otherwise TCP on HOST:PORT.
+//
+// The Unix listener removes its socket file when closed, so a clean shutdown
+// leaves nothing behind.
+func listen(cfg *config.Config) (net.Listener, error) {
+	if cfg.ListenSocket == "" {
+		return net.Listen("tcp", cfg.GetAddress())
+	}
+
+	if err := removeStaleSocket(cfg.ListenSocket); err != nil {
+		return nil, err
+	}
+	return net.Listen("unix", cfg.ListenSocket)
+}
+
+// removeStaleSocket deletes a socket file left behind by a previous run that
+// didn't shut down cleanly (crash, SIGKILL), so the new listener can bind.
+//
+// It refuses to delete anything that isn't a socket, and refuses to take over
+// a socket another process is still serving on.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s: %w", path, err)
+	}
+	if info.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket — refusing to remove it", path)
+	}
+
+	// If something answers, another server (probably another Artemis) is live.
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is already in use by another process", path)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoveStaleSocket_RemovesDeadSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "artemis.sock")
+
+	// Simulate a crashed run: the socket file exists but nobody is listening.
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("failed to create socket: %v", err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+
+	if err := removeStaleSocket(path); err != nil {
+		t.Fatalf("expected stale socket to be removed, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be gone, stat returned %v", path, err)
+	}
+}
+
+func TestRemoveStaleSocket_RefusesLiveSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "artemis.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("failed to create socket: %v", err)
+	}
+	defer listener.Close()
+
+	if err := removeStaleSocket(path); err == nil {
+		t.Error("expected an error for a socket that is still being served")
+	}
+}
+
+func TestRemoveStaleSocket_RefusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if err := removeStaleSocket(path); err == nil {
+		t.Error("expected an error for a regular file")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected regular file to be left alone, stat returned %v", err)
+	}
+}
+
+func TestRemoveStaleSocket_MissingPathIsFine(t *testing.T) {
+	if err := removeStaleSocket(filepath.Join(t.TempDir(), "missing.sock")); err != nil {
+		t.Errorf("expected no error for a missing path, got %v", err)
+	}
+}
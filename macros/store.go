@@ -0,0 +1,276 @@
+// Package macros stores named, ordered lists of device commands that span
+// subsystems ("Movie Night": dim the lights, open an app on the Fire TV,
+// turn off the living room camera), run with POST /api/macros/{name}/run.
+package macros
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pantheon/artemis/names"
+	"github.com/pantheon/artemis/persist"
+)
+
+// Limits on a macro. Names follow names.Validate.
+const (
+	MaxSteps     = 50
+	MaxStepDelay = time.Minute // Longest pause before a step
+)
+
+var (
+	// ErrNotFound means there is no macro with the given name.
+	ErrNotFound = errors.New("macro not found")
+
+	// ErrExists means another macro already has the name.
+	ErrExists = errors.New("macro already exists")
+
+	// ErrInvalid wraps a macro's validation error.
+	ErrInvalid = errors.New("invalid macro")
+)
+
+// Target names what a step (or a POST /api/devices/control request) acts on.
+type Target struct {
+	Type string `json:"type"` // "govee", "govee_group", "firetv", or "camera"
+	ID   string `json:"id"`   // Device ID, room name, Fire TV host, or camera name
+
+	APIKeyIndex  *int   `json:"apiKeyIndex,omitempty"`  // govee: only look the device up in this account
+	ProfileID    string `json:"profileId,omitempty"`    // govee_group: disambiguate a room name
+	ServiceIndex *int   `json:"serviceIndex,omitempty"` // firetv: which Fire TV service to use
+}
+
+// Step is one command of a macro, with the same target, action, and value
+// as a POST /api/devices/control request.
+type Step struct {
+	Target      Target      `json:"target"`
+	Action      string      `json:"action"`
+	Value       interface{} `json:"value,omitempty"`
+	DelayMs     int         `json:"delayMs,omitempty"`     // Pause before the step (max MaxStepDelay)
+	StopOnError bool        `json:"stopOnError,omitempty"` // Skip the remaining steps if this one fails
+}
+
+// Macro is a named, ordered list of steps.
+type Macro struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Steps       []Step    `json:"steps"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Validate checks a macro's name and the shape of its steps. Whether each
+// step's target type takes its action is checked by the handler, which owns
+// that table. Messages are user-facing.
+func (m Macro) Validate() error {
+	if err := names.Validate(m.Name); err != nil {
+		return err
+	}
+	if len(m.Steps) == 0 {
+		return fmt.Errorf("steps must not be empty")
+	}
+	if len(m.Steps) > MaxSteps {
+		return fmt.Errorf("a macro has at most %d steps", MaxSteps)
+	}
+	for i, step := range m.Steps {
+		if step.Target.Type == "" || step.Target.ID == "" || step.Action == "" {
+			return fmt.Errorf("step %d: target.type, target.id, and action are required", i+1)
+		}
+		if step.DelayMs < 0 || time.Duration(step.DelayMs)*time.Millisecond > MaxStepDelay {
+			return fmt.Errorf("step %d: delayMs must be between 0 and %d", i+1, MaxStepDelay.Milliseconds())
+		}
+	}
+	return nil
+}
+
+// Store holds the macros and saves them to a JSON file on every change, so
+// they survive restarts. Names match case-insensitively. Safe for concurrent
+// use.
+type Store struct {
+	path string // "" keeps macros in memory only
+
+	mu     sync.RWMutex
+	macros []Macro // Sorted by name
+}
+
+// NewStore loads the macros saved at path, if any. A missing file is an
+// empty store; an unreadable one is an error, so a typo'd path doesn't
+// silently drop every macro on the next save.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &s.macros); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// List returns every macro, sorted by name.
+func (s *Store) List() []Macro {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.macros)
+}
+
+// Get returns a macro by name. Returns ErrNotFound if there is none.
+func (s *Store) Get(name string) (Macro, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if i := index(s.macros, name); i != -1 {
+		return s.macros[i], nil
+	}
+	return Macro{}, ErrNotFound
+}
+
+// Create validates and saves a new macro. Returns ErrExists if one already
+// has its name.
+func (s *Store) Create(macro Macro) (Macro, error) {
+	return s.put(macro.Name, macro, true)
+}
+
+// Update validates and replaces the macro called name; it may be renamed.
+// Returns ErrNotFound if there is no such macro, or ErrExists if the new
+// name is taken by another one.
+func (s *Store) Update(name string, macro Macro) (Macro, error) {
+	return s.put(name, macro, false)
+}
+
+// Delete removes a macro. Returns ErrNotFound if there is none.
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.macros
+	i := index(previous, name)
+	if i == -1 {
+		return ErrNotFound
+	}
+	s.macros = slices.Delete(slices.Clone(previous), i, i+1)
+	if err := s.save(); err != nil {
+		s.macros = previous
+		return err
+	}
+	return nil
+}
+
+// put creates (create) or replaces the macro called name.
+func (s *Store) put(name string, macro Macro, create bool) (Macro, error) {
+	if err := macro.Validate(); err != nil {
+		return Macro{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	macro.Name = strings.TrimSpace(macro.Name)
+	macro.UpdatedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.macros
+	macros := slices.Clone(previous)
+	if !create {
+		i := index(macros, name)
+		if i == -1 {
+			return Macro{}, ErrNotFound
+		}
+		macros = slices.Delete(macros, i, i+1)
+	}
+	if index(macros, macro.Name) != -1 {
+		return Macro{}, ErrExists
+	}
+	macros = append(macros, macro)
+	slices.SortFunc(macros, func(a, b Macro) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	s.macros = macros
+	if err := s.save(); err != nil {
+		s.macros = previous
+		return Macro{}, err
+	}
+	return macro, nil
+}
+
+// ValidateAll checks a backup's macros: each is valid and no two share a
+// name. Messages are user-facing.
+func ValidateAll(macros []Macro) error {
+	for i, macro := range macros {
+		if err := macro.Validate(); err != nil {
+			return fmt.Errorf("macros[%d]: %v", i, err)
+		}
+		if index(macros[:i], macro.Name) != -1 {
+			return fmt.Errorf("macros[%d]: duplicate name %q", i, macro.Name)
+		}
+	}
+	return nil
+}
+
+// Restore writes a backup's macros. With replace, they become the store's
+// only macros; otherwise each one is added, or replaces the macro of the
+// same name.
+func (s *Store) Restore(restored []Macro, replace bool) error {
+	if err := ValidateAll(restored); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.macros
+	var macros []Macro
+	if !replace {
+		macros = slices.Clone(previous)
+	}
+	for _, macro := range restored {
+		macro.Name = strings.TrimSpace(macro.Name)
+		if i := index(macros, macro.Name); i != -1 {
+			macros = slices.Delete(macros, i, i+1)
+		}
+		macros = append(macros, macro)
+	}
+	slices.SortFunc(macros, func(a, b Macro) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	s.macros = macros
+	if err := s.save(); err != nil {
+		s.macros = previous
+		return err
+	}
+	return nil
+}
+
+// index finds a macro by name (case-insensitive), or -1.
+func index(macros []Macro, name string) int {
+	name = strings.TrimSpace(name)
+	return slices.IndexFunc(macros, func(m Macro) bool {
+		return strings.EqualFold(m.Name, name)
+	})
+}
+
+// save writes every macro to the store's file via a temp file and rename,
+// so a crash mid-write never leaves a truncated file. Caller holds s.mu.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	if err := persist.WriteJSON(s.path, s.macros, 0o600); err != nil {
+		return fmt.Errorf("failed to save macros: %w", err)
+	}
+	log.Printf("🪄 Saved %d macro(s) to %s", len(s.macros), s.path)
+	return nil
+}
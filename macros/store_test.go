@@ -0,0 +1,119 @@
+package macros
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// movieNight is a valid three-subsystem macro.
+func movieNight() Macro {
+	return Macro{Name: "Movie Night", Steps: []Step{
+		{Target: Target{Type: "govee_group", ID: "Living Room"}, Action: "brightness", Value: 20.0},
+		{Target: Target{Type: "firetv", ID: "192.168.1.50"}, Action: "command", Value: "netflix", DelayMs: 500},
+		{Target: Target{Type: "camera", ID: "Living Room Cam"}, Action: "turn", Value: false},
+	}}
+}
+
+func TestStore_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "macros.json")
+
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore returned error: %v", err)
+	}
+	if _, err := store.Create(movieNight()); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	bedtime := movieNight()
+	bedtime.Name = "Bedtime"
+	store.Create(bedtime)
+	if err := store.Delete("bedtime"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+
+	reloaded, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore returned error on reload: %v", err)
+	}
+	macros := reloaded.List()
+	if len(macros) != 1 || macros[0].Name != "Movie Night" || len(macros[0].Steps) != 3 || macros[0].Steps[1].DelayMs != 500 {
+		t.Errorf("expected only Movie Night with its steps, got %+v", macros)
+	}
+	if _, err := reloaded.Get("Bedtime"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a deleted macro, got %v", err)
+	}
+}
+
+func TestStore_ConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "macros.json")
+	store, _ := NewStore(path)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			macro := movieNight()
+			macro.Name = fmt.Sprintf("Macro %d", i)
+			if _, err := store.Create(macro); err != nil {
+				t.Error(err)
+			}
+			if i%4 == 0 {
+				store.Delete(macro.Name)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	reloaded, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("expected a valid file after concurrent writes, got %v", err)
+	}
+	if got := len(reloaded.List()); got != 30 {
+		t.Errorf("expected 30 macros after 40 creates and 10 deletes, got %d", got)
+	}
+}
+
+func TestStore_NamesAndValidation(t *testing.T) {
+	store, _ := NewStore("")
+
+	macro := movieNight()
+	macro.Name = "  Movie Night "
+	created, err := store.Create(macro)
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if created.Name != "Movie Night" || created.UpdatedAt.IsZero() {
+		t.Errorf("expected a trimmed name and an update time, got %+v", created)
+	}
+	if _, err := store.Create(movieNight()); !errors.Is(err, ErrExists) {
+		t.Errorf("expected ErrExists for a duplicate, got %v", err)
+	}
+	if _, err := store.Update("Missing", movieNight()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	renamed := movieNight()
+	renamed.Name = "Cinema"
+	if _, err := store.Update("movie night", renamed); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if _, err := store.Get("Cinema"); err != nil {
+		t.Errorf("expected the renamed macro, got %v", err)
+	}
+
+	invalid := []Macro{
+		{Name: "", Steps: movieNight().Steps},
+		{Name: "Empty"},
+		{Name: "No action", Steps: []Step{{Target: Target{Type: "govee", ID: "AA:01"}}}},
+		{Name: "Long delay", Steps: []Step{{Target: Target{Type: "govee", ID: "AA:01"}, Action: "turn", Value: true, DelayMs: 60001}}},
+	}
+	for _, m := range invalid {
+		if _, err := store.Create(m); !errors.Is(err, ErrInvalid) {
+			t.Errorf("expected ErrInvalid for %q, got %v", m.Name, err)
+		}
+	}
+}
@@ -1,19 +1,42 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/pantheon/artemis/camera"
 	"github.com/pantheon/artemis/config"
+	"github.com/pantheon/artemis/dashboard"
 	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/events"
 	"github.com/pantheon/artemis/firetv"
 	"github.com/pantheon/artemis/govee"
 	"github.com/pantheon/artemis/handlers"
+	"github.com/pantheon/artemis/macros"
 	"github.com/pantheon/artemis/middleware"
+	"github.com/pantheon/artemis/mqtt"
+	"github.com/pantheon/artemis/reports"
+	"github.com/pantheon/artemis/tracing"
+	"github.com/pantheon/artemis/upstream"
+	"github.com/pantheon/artemis/webhooks"
 )
 
 func main() {
+	// --selftest checks config and dependencies, then exits without serving.
+	// Used by CI and deployment scripts to gate on a healthy environment.
+	selfTest := flag.Bool("selftest", false, "validate config and check dependency health, then exit (0 = ok, 2 = config error, 3 = dependency down)")
+	flag.Parse()
+	if *selfTest {
+		os.Exit(runSelfTest(os.Stdout))
+	}
+
 	// Load configuration from environment variables and .env file
 	cfg, err := config.Load()
 	if err != nil {
@@ -33,172 +56,722 @@ func main() {
 	defer database.Close()
 	log.Printf("🗄️  Database ready at %s", cfg.DBPath)
 
+	// Cancelled on SIGINT/SIGTERM — stops background workers and triggers a
+	// graceful HTTP shutdown (which also removes the Unix socket, if used).
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Export request traces when an OTLP collector is configured. Without one
+	// the tracing middleware isn't installed and client spans are no-ops.
+	var shutdownTracing func(context.Context) error
+	if cfg.OTelExporterEndpoint != "" {
+		shutdownTracing, err = tracing.Setup(ctx, cfg.OTelExporterEndpoint)
+		if err != nil {
+			log.Printf("❌ Tracing disabled: %v", err)
+		} else {
+			log.Printf("🔭 Exporting traces to %s", cfg.OTelExporterEndpoint)
+		}
+	}
+
+	// One circuit breaker per upstream client, so an outage fails fast
+	// instead of every request waiting out a timeout. Listed on /health, and
+	// kept per subsystem for the fail-fast routes below.
+	var breakers, goveeBreakers, fireTVBreakers, cameraBreakers []*upstream.Breaker
+	newBreaker := func(name string) *upstream.Breaker {
+		breaker := upstream.NewBreaker(name, cfg.UpstreamBreakerThreshold, cfg.UpstreamBreakerCooldown)
+		if breaker != nil {
+			breaker.SetFailFast(cfg.UpstreamFailFast)
+			breakers = append(breakers, breaker)
+		}
+		return breaker
+	}
+
 	// Initialize Govee API clients for controlling smart lights
-	// Create primary client (required)
-	goveeClients := []*govee.Client{
-		govee.NewClient(cfg.GoveeAPIKey),
+	// Create primary client (required unless ENABLE_GOVEE=false)
+	var goveeClients []*govee.Client
+	if cfg.EnableGovee {
+		if cfg.GoveeProxyURL != "" {
+			log.Printf("💡 Govee requests go through GOVEE_PROXY_URL")
+		}
+
+		goveeClients = append(goveeClients, newGoveeClient(cfg, cfg.GoveeAPIKey))
+		log.Printf("💡 Primary Govee client initialized (API %s)", cfg.GoveeAPIVersion)
+		if cfg.GoveeStrictSerial {
+			goveeClients[0].SetStrictSerial(cfg.GoveeStrictSerialSpacing)
+			log.Printf("💡 Primary Govee key in strict-serial mode (%s apart)", cfg.GoveeStrictSerialSpacing)
+		}
+
+		// Create secondary client if API key is configured
+		if cfg.GoveeAPIKeySecondary != "" {
+			goveeClients = append(goveeClients, newGoveeClient(cfg, cfg.GoveeAPIKeySecondary))
+			log.Printf("💡 Secondary Govee client initialized (devices from both accounts will be shown)")
+			if cfg.GoveeStrictSerialSecondary {
+				goveeClients[1].SetStrictSerial(cfg.GoveeStrictSerialSpacing)
+				log.Printf("💡 Secondary Govee key in strict-serial mode (%s apart)", cfg.GoveeStrictSerialSpacing)
+			}
+		}
+		if cfg.GoveeRateLimit > 0 {
+			mode := "waiting when exhausted"
+			if cfg.GoveeRateLimitFailFast {
+				mode = "failing fast when exhausted"
+			}
+			log.Printf("💡 Govee requests limited to %d/minute per key, %s", cfg.GoveeRateLimit, mode)
+		} else {
+			log.Printf("⚠️  Govee client-side rate limit disabled (GOVEE_RATE_LIMIT=0)")
+		}
+		if !cfg.GoveeDeviceCheck {
+			log.Printf("⚠️  Govee control requests are sent unchecked (GOVEE_DEVICE_CHECK=false)")
+		}
+		for i, client := range goveeClients {
+			client.SetRateLimit(cfg.GoveeRateLimit, cfg.GoveeRateLimitFailFast)
+			client.SetRetries(cfg.GoveeRetries)
+			client.SetDeviceCacheTTL(cfg.GoveeDeviceCacheTTL)
+			client.SetDeviceCheck(cfg.GoveeDeviceCheck)
+			breaker := newBreaker(fmt.Sprintf("Govee API #%d", i))
+			client.SetBreaker(breaker)
+			goveeBreakers = append(goveeBreakers, breaker)
+		}
+	} else {
+		log.Printf("⚠️  Govee integration disabled (ENABLE_GOVEE=false)")
+	}
+
+	// Event broker for the device SSE stream. Keeps a bounded buffer of recent
+	// events so reconnecting clients can catch up via Last-Event-ID.
+	deviceEvents := events.NewBroker(cfg.EventBufferSize)
+
+	// Outbound webhooks get every device event the store's webhooks ask for
+	var webhookStore *webhooks.Store
+	if cfg.EnableWebhooks {
+		webhookStore, err = webhooks.NewStore(cfg.WebhooksFile)
+		if err != nil {
+			log.Fatalf("Failed to load webhooks: %v", err)
+		}
+		webhooks.NewDispatcher(webhookStore).Start(ctx, deviceEvents)
+		log.Printf("🪝 Webhooks enabled (%d registered, saved to %s)", len(webhookStore.List()), cfg.WebhooksFile)
+		if cfg.AdminToken == "" {
+			log.Printf("⚠️  Webhooks can't be managed — set ADMIN_TOKEN to use /webhooks")
+		}
+	}
+
+	// Macros span subsystems, so they load whichever integrations are on
+	macroStore, err := macros.NewStore(cfg.MacrosFile)
+	if err != nil {
+		log.Fatalf("Failed to load macros: %v", err)
+	}
+	log.Printf("🪄 %d macro(s) loaded from %s", len(macroStore.List()), cfg.MacrosFile)
+
+	// The state poller, retry queue, and MQTT bridge all work through the
+	// Govee clients, so none of them start when Govee is disabled.
+	var statePoller *govee.StatePoller
+	var retryQueue *govee.RetryQueue
+	var partyManager *govee.PartyManager
+	var optimisticStates *govee.OptimisticStates
+	var coalescer *govee.Coalescer
+	var presetStore *govee.PresetStore
+	var timerScheduler *govee.TimerScheduler
+	if cfg.EnableGovee {
+		// Last-commanded state for devices that can't report their own
+		optimisticStates = govee.NewOptimisticStates(cfg.GoveeOptimisticStateFile)
+
+		// Per-device presets ("Reading", "Relax")
+		presetStore, err = govee.NewPresetStore(cfg.GoveePresetsFile)
+		if err != nil {
+			log.Fatalf("Failed to load presets: %v", err)
+		}
+		log.Printf("💡 %d device preset(s) loaded from %s", presetStore.Count(), cfg.GoveePresetsFile)
+
+		// Merge brightness/color bursts (slider drags) if configured
+		if cfg.GoveeCoalesceWindow > 0 {
+			coalescer = govee.NewCoalescer(cfg.GoveeCoalesceWindow)
+			log.Printf("💡 Brightness/color commands coalesced over %s", cfg.GoveeCoalesceWindow)
+		}
+
+		// Party mode color loops, started and stopped through the API
+		partyManager = govee.NewPartyManager(goveeClients)
+
+		// On/off timers, stored on the device where the model supports it.
+		// Timers Artemis keeps publish their outcome on the device event stream.
+		timerScheduler = govee.NewTimerScheduler(goveeClients)
+		timerScheduler.OnFire(func(result govee.TimerResult) {
+			if result.Success {
+				optimisticStates.Record(result.APIKeyIndex, result.DeviceID, result.Model, "turn", result.Action)
+				retryQueue.Forget(result.APIKeyIndex, result.DeviceID)
+			}
+			deviceEvents.Publish("device.timer", result)
+		})
+
+		// Start the background state poller if configured.
+		// It keeps a shared cache of device states so read paths don't each hit Govee,
+		// and publishes a device event whenever a polled state changes.
+		// The MQTT bridge publishes state from the poller's cache, so enabling the
+		// bridge also enables the poller (at the MQTT state interval) if needed.
+		// The offline retry queue watches reachability through the poller too,
+		// and device metrics and the daily report are read from its cache.
+		pollInterval := cfg.GoveeStatePollInterval
+		if pollInterval <= 0 && cfg.MQTTBrokerURL != "" {
+			pollInterval = cfg.MQTTStateInterval
+		}
+		if pollInterval <= 0 && (cfg.GoveeCommandRetry || cfg.GoveeDeviceMetrics || cfg.EnableDailyReport || cfg.GoveeOfflineAlerts) {
+			pollInterval = 30 * time.Second
+		}
+
+		if pollInterval > 0 {
+			statePoller = govee.NewStatePoller(goveeClients, pollInterval, cfg.GoveeStateCacheTTL)
+
+			// Unreachable devices are reported once they've stayed
+			// offline for the grace period
+			var offlineWatcher *govee.OfflineWatcher
+			if cfg.GoveeOfflineAlerts {
+				offlineWatcher = govee.NewOfflineWatcher(cfg.GoveeOfflineAlertGrace, statePoller.DeviceName)
+				offlineWatcher.OnChange(func(event govee.OfflineEvent) {
+					if event.Online {
+						deviceEvents.Publish("device.online", event)
+					} else {
+						deviceEvents.Publish("device.offline", event)
+					}
+				})
+				log.Printf("💡 Offline alerts enabled (after %s offline)", cfg.GoveeOfflineAlertGrace)
+			}
+
+			statePoller.OnStateChange(func(state govee.DeviceState) {
+				deviceEvents.Publish("device.state", state)
+				if offlineWatcher != nil {
+					offlineWatcher.Observe(state)
+				}
+			})
+			statePoller.Start(ctx)
+			log.Printf("💡 Govee state poller started (every %s)", pollInterval)
+		}
+
+		// Start the optional offline retry queue. Final outcomes are published
+		// on the device event stream so the app can tell the user.
+		if cfg.GoveeCommandRetry {
+			retryQueue = govee.NewRetryQueue(goveeClients, statePoller, cfg.GoveeCommandRetryMaxAge, cfg.GoveeCommandRetryMaxAttempts)
+			retryQueue.OnResult(func(outcome govee.RetryOutcome) {
+				if outcome.Success {
+					optimisticStates.Record(outcome.APIKeyIndex, outcome.DeviceID, outcome.Model, outcome.Command, outcome.Value)
+				}
+				deviceEvents.Publish("device.command_retry", outcome)
+			})
+			retryQueue.Start(ctx)
+			partyManager.SetRetryQueue(retryQueue)
+			log.Printf("💡 Offline command retry enabled (up to %s, %d attempt(s))",
+				cfg.GoveeCommandRetryMaxAge, cfg.GoveeCommandRetryMaxAttempts)
+		}
+
+		// Start the optional MQTT bridge (Home Assistant integration).
+		// Off unless MQTT_BROKER_URL is set; a connection failure is logged but
+		// doesn't stop the HTTP API from serving.
+		if cfg.MQTTBrokerURL != "" {
+			bridge := mqtt.NewBridge(mqtt.Config{
+				BrokerURL:     cfg.MQTTBrokerURL,
+				ClientID:      cfg.MQTTClientID,
+				Username:      cfg.MQTTUsername,
+				Password:      cfg.MQTTPassword,
+				TopicPrefix:   cfg.MQTTTopicPrefix,
+				StateInterval: cfg.MQTTStateInterval,
+			}, goveeClients, statePoller)
+			bridge.SetRetryQueue(retryQueue)
+			if err := bridge.Start(ctx); err != nil {
+				log.Printf("❌ MQTT bridge disabled: %v", err)
+			}
+		}
 	}
-	log.Printf("💡 Primary Govee client initialized")
 
-	// Create secondary client if API key is configured
-	if cfg.GoveeAPIKeySecondary != "" {
-		goveeClients = append(goveeClients, govee.NewClient(cfg.GoveeAPIKeySecondary))
-		log.Printf("💡 Secondary Govee client initialized (devices from both accounts will be shown)")
+	// Fire TV Remote - control Fire TV devices via Python microservice
+	// Initialize the Fire TV client that communicates with the Python service
+	var firetvClients []*firetv.Client
+	if cfg.EnableFireTV {
+		for i, service := range cfg.FireTVServices {
+			firetvClient := firetv.NewClient(service.URL)
+			if err := firetvClient.SetSubnets(service.Subnets); err != nil {
+				log.Fatalf("Invalid FIRETV_SERVICES: service %d: %v", i, err)
+			}
+			breaker := newBreaker(fmt.Sprintf("Fire TV service #%d", i))
+			firetvClient.SetBreaker(breaker)
+			fireTVBreakers = append(fireTVBreakers, breaker)
+			firetvClients = append(firetvClients, firetvClient)
+			log.Printf("📺 Fire TV client %d initialized (service URL: %s, subnets: %v)", i, service.URL, service.Subnets)
+
+			// Check if the Python Fire TV service is reachable (non-blocking warning)
+			if err := firetvClient.CheckHealth(ctx); err != nil {
+				log.Printf("⚠️  Fire TV service %d not reachable: %v", i, err)
+				log.Printf("⚠️  Fire TV features on its network will not work until the Python service is started")
+				log.Printf("⚠️  Start it with: cd ../firestick && uvicorn main:app --host 0.0.0.0 --port 9090")
+			} else {
+				log.Printf("📺 Fire TV service %d is healthy and reachable", i)
+			}
+		}
+	} else {
+		log.Printf("⚠️  Fire TV integration disabled (ENABLE_FIRETV=false)")
+	}
+
+	// Wyze Camera Bridge - view live camera streams
+	// Initialize the camera client that communicates with Docker Wyze Bridge
+	var cameraClient *camera.Client
+	var clipRecorder *camera.ClipRecorder
+	var cameraACL *camera.ACLStore
+	if cfg.EnableCameras {
+		cameraClient = camera.NewClientWithAuth(cfg.WyzeBridgeURL, cfg.WyzeBridgeAPIKey, cfg.WyzeBridgeAuthMode, cfg.WyzeBridgeUsername)
+		breaker := newBreaker("Wyze Bridge")
+		cameraClient.SetBreaker(breaker)
+		cameraBreakers = append(cameraBreakers, breaker)
+		log.Printf("📷 Camera client initialized (bridge URL: %s, auth: %s)", cfg.WyzeBridgeURL, cfg.WyzeBridgeAuthMode)
+
+		// Check if the Wyze Bridge is reachable (non-blocking warning)
+		if err := cameraClient.CheckHealth(ctx); err != nil {
+			log.Printf("⚠️  Wyze Bridge not reachable: %v", err)
+			log.Printf("⚠️  Camera features will not work until Wyze Bridge is started")
+			log.Printf("⚠️  Start it with: cd .. && docker compose up -d")
+		} else {
+			log.Printf("📷 Wyze Bridge is healthy and reachable")
+		}
+
+		// Start the optional stream watchdog, which restarts streams that
+		// stall while their camera is online. Restarts are published on the
+		// device event stream so the app can reload the player.
+		if cfg.CameraStreamWatchdogInterval > 0 {
+			watchdog := camera.NewStreamWatchdog(cameraClient, cfg.CameraStreamWatchdogInterval)
+			watchdog.OnRestart(func(result camera.RestartResult) {
+				deviceEvents.Publish("camera.stream_restart", result)
+			})
+			watchdog.Start(ctx)
+			log.Printf("📷 Stream watchdog started (every %s)", cfg.CameraStreamWatchdogInterval)
+		}
+
+		// Watch for cameras going offline, independently of the watchdog, so
+		// camera.offline events (and webhooks) don't depend on it
+		if cfg.CameraStatusInterval > 0 {
+			monitor := camera.NewStatusMonitor(cameraClient, cfg.CameraStatusInterval)
+			monitor.OnOffline(func(cam camera.Camera) {
+				deviceEvents.Publish("camera.offline", cam)
+			})
+			monitor.Start(ctx)
+			log.Printf("📷 Camera status monitor started (every %s)", cfg.CameraStatusInterval)
+		}
+
+		// Clip capture shells out to ffmpeg; it's looked up once, here
+		clipRecorder = camera.NewClipRecorder(cfg.CameraClipDir, cfg.CameraClipMaxDuration, cfg.CameraClipMaxConcurrent)
+		if clipRecorder.Available() {
+			log.Printf("🎬 Clip capture enabled (dir: %s, max %s, %d at once)", cfg.CameraClipDir, cfg.CameraClipMaxDuration, cfg.CameraClipMaxConcurrent)
+		} else {
+			log.Printf("⚠️  ffmpeg not found — POST /api/cameras/capture-clip will answer 501")
+		}
+
+		// Per-token camera ACLs need the admin token to manage them
+		if cfg.EnableCameraACL {
+			if cfg.AdminToken == "" {
+				log.Printf("⚠️  Camera ACLs inactive — set ADMIN_TOKEN to enable them")
+			} else {
+				cameraACL, err = camera.NewACLStore(cfg.CameraACLFile)
+				if err != nil {
+					log.Fatalf("Failed to load camera ACLs: %v", err)
+				}
+				log.Printf("🔐 Camera ACLs enabled (%d token(s), saved to %s)", len(cameraACL.List()), cfg.CameraACLFile)
+			}
+		}
+	} else {
+		log.Printf("⚠️  Camera integration disabled (ENABLE_CAMERAS=false)")
+	}
+
+	// Daily snapshots of light and camera states, compared at
+	// /api/reports/daily-diff. Lights come from the state poller's cache and
+	// cameras from the bridge, so either is left out when it isn't running.
+	var dailyReport *reports.Recorder
+	if cfg.EnableDailyReport {
+		var lights reports.LightSource
+		if statePoller != nil {
+			lights = statePoller
+		}
+		var cameras reports.CameraSource
+		if cameraClient != nil {
+			cameras = cameraClient
+		}
+		dailyReport, err = reports.NewRecorder(cfg.DailyReportFile, cfg.DailyReportTime, cfg.DailyReportKeep, lights, cameras)
+		if err != nil {
+			log.Fatalf("Failed to load daily report snapshots: %v", err)
+		}
+		dailyReport.Start(ctx)
+		log.Printf("📊 Daily report enabled (snapshot at %s, keeping %d in %s)", cfg.DailyReportTime, cfg.DailyReportKeep, cfg.DailyReportFile)
 	}
 
 	// Log startup information
 	log.Printf("🚀 Starting Artemis server in %s mode", cfg.Environment)
-	log.Printf("📍 Server will be available at http://%s", cfg.GetAddress())
+	log.Printf("📍 Server will be available at %s", cfg.GetListenDescription())
 
 	// Create a new HTTP mux (router)
 	// Uses Go 1.22+ enhanced pattern matching for path parameters ({id}, {profileId})
 	mux := http.NewServeMux()
 
+	// Uniform {success, data, message} list responses, if configured,
+	// passed to every list handler
+	listOpts := handlers.ListOptions{Envelope: cfg.ResponseEnvelope, StrictFields: cfg.ResponseFieldsStrict}
+	if cfg.ResponseEnvelope {
+		log.Printf("📦 List responses are wrapped in a {success, data, message} envelope")
+	}
+
+	// Optimistic concurrency for rooms and presets: If-Match is always
+	// honored, and required unless turned off
+	if !cfg.RequireIfMatch {
+		log.Printf("⚠️  Room and preset updates without If-Match overwrite any version (REQUIRE_IF_MATCH=false)")
+	}
+
 	// ==========================================================================
 	// Profile, Room & Device endpoints — CRUD for user management
 	// ==========================================================================
 
 	// Initialize handler structs with database dependency
-	profileHandler := handlers.NewProfileHandler(database)
-	roomHandler := handlers.NewRoomHandler(database)
-	deviceHandler := handlers.NewDeviceHandler(database)
+	profileHandler := handlers.NewProfileHandler(database, listOpts)
+	roomHandler := handlers.NewRoomHandler(database, listOpts, cfg.RequireIfMatch)
+	deviceHandler := handlers.NewDeviceHandler(database, listOpts)
 	roomTemplateHandler := handlers.NewRoomTemplateHandler(database)
 
+	// Every route is registered through the route table, which also feeds
+	// GET /api/routes and the startup log
+	routes := newRouteTable(mux, cfg.APIBasePath)
+
+	// Control and command endpoints replay their response to a retried
+	// request with the same Idempotency-Key instead of running it twice
+	idempotency := middleware.NewIdempotencyCache(cfg.IdempotencyTTL, cfg.IdempotencyMaxKeys)
+	idempotent := func(handler http.HandlerFunc) http.HandlerFunc {
+		return middleware.Idempotent(idempotency, handler)
+	}
+
+	// Routes that can't answer without an upstream return 503 straight away
+	// while all of its breakers are open, unless UPSTREAM_FAIL_FAST=false.
+	// Routes served from caches or local state stay up during an outage.
+	failFast := func(service string, breakers []*upstream.Breaker) func(http.HandlerFunc) http.HandlerFunc {
+		return func(handler http.HandlerFunc) http.HandlerFunc {
+			if !cfg.UpstreamFailFast {
+				return handler
+			}
+			return middleware.FailFast(service, breakers, handler)
+		}
+	}
+	needsGovee := failFast("The Govee API", goveeBreakers)
+	needsFireTV := failFast("The Fire TV service", fireTVBreakers)
+	needsBridge := failFast("Wyze Bridge", cameraBreakers)
+
+	// With camera ACLs on, camera routes need a token and only show the
+	// cameras it may see
+	cameraAccess := func(handler http.HandlerFunc) http.HandlerFunc {
+		if cameraACL == nil {
+			return handler
+		}
+		return middleware.CameraACL(cfg.AdminToken, cameraACL, handler)
+	}
+	// Routes that reach cameras only for some requests (unified control,
+	// macros) apply the same tokens, but let tokenless requests through
+	// without access to any camera
+	cameraScope := func(handler http.HandlerFunc) http.HandlerFunc {
+		if cameraACL == nil {
+			return handler
+		}
+		return middleware.CameraScope(cfg.AdminToken, cameraACL, handler)
+	}
+
 	// Profile endpoints
-	mux.HandleFunc("POST "+cfg.APIBasePath+"/profile", profileHandler.HandleCreateProfile)
-	mux.HandleFunc("GET "+cfg.APIBasePath+"/profile/{id}", profileHandler.HandleGetProfile)
-	mux.HandleFunc("GET "+cfg.APIBasePath+"/profiles", profileHandler.HandleListProfiles)
-	mux.HandleFunc("PUT "+cfg.APIBasePath+"/profile/{id}", profileHandler.HandleUpdateProfile)
-	mux.HandleFunc("DELETE "+cfg.APIBasePath+"/profile/{id}", profileHandler.HandleDeleteProfile)
+	routes.handle("POST", "/profile", "Create profile", http.HandlerFunc(profileHandler.HandleCreateProfile))
+	routes.handle("GET", "/profile/{id}", "Get profile (with rooms & devices)", http.HandlerFunc(profileHandler.HandleGetProfile))
+	routes.handle("GET", "/profiles", "List all profiles", http.HandlerFunc(profileHandler.HandleListProfiles))
+	routes.handle("PUT", "/profile/{id}", "Update profile", http.HandlerFunc(profileHandler.HandleUpdateProfile))
+	routes.handle("DELETE", "/profile/{id}", "Delete profile (cascade)", http.HandlerFunc(profileHandler.HandleDeleteProfile))
 
 	// Room endpoints
-	mux.HandleFunc("POST "+cfg.APIBasePath+"/profile/{profileId}/rooms", roomHandler.HandleCreateRoom)
-	mux.HandleFunc("GET "+cfg.APIBasePath+"/profile/{profileId}/rooms", roomHandler.HandleListRooms)
-	mux.HandleFunc("GET "+cfg.APIBasePath+"/room/{id}", roomHandler.HandleGetRoom)
-	mux.HandleFunc("PUT "+cfg.APIBasePath+"/room/{id}", roomHandler.HandleUpdateRoom)
-	mux.HandleFunc("PUT "+cfg.APIBasePath+"/room/{id}/beacon", roomHandler.HandleUpdateRoomBeacon)
-	mux.HandleFunc("DELETE "+cfg.APIBasePath+"/room/{id}", roomHandler.HandleDeleteRoom)
-	mux.HandleFunc("GET "+cfg.APIBasePath+"/room/{id}/template", roomTemplateHandler.HandleGetRoomTemplate)
+	routes.handle("POST", "/profile/{profileId}/rooms", "Create room", http.HandlerFunc(roomHandler.HandleCreateRoom))
+	routes.handle("GET", "/profile/{profileId}/rooms", "List rooms", http.HandlerFunc(roomHandler.HandleListRooms))
+	routes.handle("GET", "/room/{id}", "Get room (with devices)", http.HandlerFunc(roomHandler.HandleGetRoom))
+	routes.handle("PUT", "/room/{id}", "Update room", http.HandlerFunc(roomHandler.HandleUpdateRoom))
+	routes.handle("PUT", "/room/{id}/beacon", "Set beacon config", http.HandlerFunc(roomHandler.HandleUpdateRoomBeacon))
+	routes.handle("DELETE", "/room/{id}", "Delete room", http.HandlerFunc(roomHandler.HandleDeleteRoom))
+	routes.handle("GET", "/room/{id}/template", "Get room scene template", http.HandlerFunc(roomTemplateHandler.HandleGetRoomTemplate))
 
 	// Device endpoints
-	mux.HandleFunc("POST "+cfg.APIBasePath+"/profile/{profileId}/devices", deviceHandler.HandleCreateDevice)
-	mux.HandleFunc("GET "+cfg.APIBasePath+"/profile/{profileId}/devices", deviceHandler.HandleListDevices)
-	mux.HandleFunc("GET "+cfg.APIBasePath+"/device/{id}", deviceHandler.HandleGetDevice)
-	mux.HandleFunc("PUT "+cfg.APIBasePath+"/device/{id}", deviceHandler.HandleUpdateDevice)
-	mux.HandleFunc("PUT "+cfg.APIBasePath+"/device/{id}/assign", deviceHandler.HandleAssignDevice)
-	mux.HandleFunc("PUT "+cfg.APIBasePath+"/device/{id}/unassign", deviceHandler.HandleUnassignDevice)
-	mux.HandleFunc("DELETE "+cfg.APIBasePath+"/device/{id}", deviceHandler.HandleDeleteDevice)
+	routes.handle("POST", "/profile/{profileId}/devices", "Create device", http.HandlerFunc(deviceHandler.HandleCreateDevice))
+	routes.handle("GET", "/profile/{profileId}/devices", "List devices", http.HandlerFunc(deviceHandler.HandleListDevices))
+	routes.handle("GET", "/device/{id}", "Get device", http.HandlerFunc(deviceHandler.HandleGetDevice))
+	routes.handle("PUT", "/device/{id}", "Update device", http.HandlerFunc(deviceHandler.HandleUpdateDevice))
+	routes.handle("PUT", "/device/{id}/assign", "Assign device to room", http.HandlerFunc(deviceHandler.HandleAssignDevice))
+	routes.handle("PUT", "/device/{id}/unassign", "Unassign device", http.HandlerFunc(deviceHandler.HandleUnassignDevice))
+	routes.handle("DELETE", "/device/{id}", "Delete device", http.HandlerFunc(deviceHandler.HandleDeleteDevice))
+
+	// Admin endpoints — backup and restore of all persisted data.
+	// Gated behind ADMIN_TOKEN; disabled (403) when it isn't set.
+	adminHandler := handlers.NewAdminHandler(database, handlers.BackupStores{
+		Presets:    presetStore,
+		Macros:     macroStore,
+		Webhooks:   webhookStore,
+		CameraACLs: cameraACL,
+		Optimistic: optimisticStates,
+	})
+	routes.handle("GET", "/admin/export", "Export all data (ADMIN_TOKEN)", middleware.RequireToken(cfg.AdminToken, adminHandler.HandleExport))
+	routes.handle("POST", "/admin/import", "Import a backup (ADMIN_TOKEN)", middleware.RequireToken(cfg.AdminToken, adminHandler.HandleImport))
 
 	// ==========================================================================
 	// Integration endpoints — External service control
 	// ==========================================================================
 
-	// Register API routes
 	// Lightbulb toggle endpoint - called when user taps the lightbulb in the app
-	mux.HandleFunc(cfg.APIBasePath+"/lightbulb/toggle", handlers.HandleLightbulbToggle)
+	routes.handle("POST", "/lightbulb/toggle", "Toggle lightbulb state", idempotent(handlers.HandleLightbulbToggle))
 
-	// Govee smart light endpoints - control real Govee devices
-	// List all Govee devices from all configured accounts
-	mux.HandleFunc(cfg.APIBasePath+"/govee/devices", handlers.HandleGetDevices(goveeClients))
-	// Control a specific Govee device (turn on/off, brightness, color)
-	mux.HandleFunc(cfg.APIBasePath+"/govee/devices/control", handlers.HandleControlDevice(goveeClients))
-	// Query current state of a specific device
-	mux.HandleFunc(cfg.APIBasePath+"/govee/devices/state", handlers.HandleGetDeviceState(goveeClients))
+	// Live device events (SSE) — state changes detected by the state poller.
+	// Streams never finish on their own, so they end when streamsClosing is
+	// closed at shutdown instead of holding server.Shutdown for its timeout.
+	streamsClosing := make(chan struct{})
+	routes.handle("GET", "/events/devices", "Device event stream (SSE)", handlers.HandleEventStream(deviceEvents, cfg.SSEHeartbeatInterval, streamsClosing))
 
-	// Fire TV Remote endpoints - control Fire TV devices via Python microservice
-	// Initialize the Fire TV client that communicates with the Python service
-	firetvClient := firetv.NewClient(cfg.FireTVServiceURL)
-	log.Printf("📺 Fire TV client initialized (service URL: %s)", cfg.FireTVServiceURL)
-
-	// Check if the Python Fire TV service is reachable (non-blocking warning)
-	if err := firetvClient.CheckHealth(); err != nil {
-		log.Printf("⚠️  Fire TV service not reachable: %v", err)
-		log.Printf("⚠️  Fire TV features will not work until the Python service is started")
-		log.Printf("⚠️  Start it with: cd ../firestick && uvicorn main:app --host 0.0.0.0 --port 9090")
-	} else {
-		log.Printf("📺 Fire TV service is healthy and reachable")
+	// Each integration's routes are registered together so its ENABLE_* flag
+	// decides in one place whether they're served or answer "feature disabled".
+	// Handlers of a disabled integration are built with nil clients but never run.
+	routes.integration("Govee", cfg.EnableGovee, []integrationRoute{
+		{"GET", "/govee/devices", "List all Govee devices", needsGovee(handlers.HandleGetDevices(goveeClients, handlers.AccountLabels{
+			Labels: cfg.GoveeAccountLabels,
+			Suffix: cfg.GoveeAccountLabelPosition == "suffix",
+		}, database, listOpts))},
+		{"GET", "/govee/devices/search", "Search devices by name/model and capability", handlers.HandleSearchDevices(goveeClients, database, listOpts)},
+		{"POST", "/govee/devices/control", "Control Govee device", needsGovee(idempotent(handlers.HandleControlDevice(goveeClients, retryQueue, optimisticStates, coalescer, deviceEvents)))},
+		{"POST", "/govee/devices/control/batch", "Run several control commands at once", needsGovee(idempotent(handlers.HandleControlDevicesBatch(goveeClients, retryQueue, optimisticStates, coalescer, deviceEvents)))},
+		{"GET", "/govee/devices/state", "Query device state", handlers.HandleGetDeviceState(goveeClients, statePoller, optimisticStates)},
+		// Path-style variants that look up model/account from the device list
+		{"GET", "/govee/devices/{id}/state", "Query device state by path", handlers.HandleGetDeviceStateByID(goveeClients, statePoller, optimisticStates)},
+		{"POST", "/govee/devices/{id}/control", "Control Govee device by path", needsGovee(idempotent(handlers.HandleControlDeviceByID(goveeClients, retryQueue, optimisticStates, coalescer, deviceEvents)))},
+		{"GET POST", "/govee/devices/{id}/presets", "List (GET) or create (POST) device presets", handlers.HandleDevicePresets(presetStore, listOpts)},
+		{"PUT DELETE", "/govee/devices/{id}/presets/{name}", "Replace (PUT) or delete (DELETE) a device preset", handlers.HandleDevicePreset(presetStore, cfg.RequireIfMatch)},
+		{"POST", "/govee/devices/{id}/presets/{name}/apply", "Apply a device preset", needsGovee(idempotent(handlers.HandleApplyDevicePreset(goveeClients, presetStore, optimisticStates, retryQueue)))},
+		{"POST", "/govee/devices/{id}/timer", "Set an on/off timer (on the device where supported)", needsGovee(idempotent(handlers.HandleSetDeviceTimer(goveeClients, timerScheduler)))},
+		{"POST", "/govee/devices/reset", "Reset device to static control", needsGovee(idempotent(handlers.HandleResetDevice(goveeClients)))},
+		{"POST", "/govee/devices/diagnose", "Diagnose a device with a state round trip", needsGovee(handlers.HandleDiagnoseDevice(goveeClients, optimisticStates))},
+		{"GET", "/govee/devices/{id}/capabilities", "Advertised commands, optionally verified by probing (?verify=true)", handlers.HandleDeviceCapabilities(goveeClients, govee.NewCapabilityCache())},
+		{"POST", "/govee/party/start", "Start party mode color loop", needsGovee(handlers.HandleStartParty(goveeClients, partyManager, database))},
+		{"POST", "/govee/party/stop", "Stop party mode", handlers.HandleStopParty(partyManager)},
+		{"POST", "/rooms/{name}/apply", "Apply per-device states to a room", needsGovee(idempotent(handlers.HandleApplyRoomScene(goveeClients, database, optimisticStates, retryQueue, statePoller, cfg.GoveeBatchSkipOffline)))},
+		{"POST", "/govee/groups/{name}/gradient", "Spread a color gradient across a room's lights", needsGovee(idempotent(handlers.HandleApplyGradient(goveeClients, database, optimisticStates, retryQueue, statePoller, cfg.GoveeBatchSkipOffline)))},
+	})
+
+	// Per-device gauges for Prometheus, only when asked for since they add a
+	// series per device
+	if cfg.EnableGovee && cfg.GoveeDeviceMetrics {
+		routes.handle("GET", "/metrics", "Per-device gauges for Prometheus", handlers.HandleDeviceMetrics(goveeClients, statePoller))
 	}
 
-	// Discover Fire TV devices on the local network
-	mux.HandleFunc(cfg.APIBasePath+"/firetv/discover", handlers.HandleFireTVDiscover(firetvClient))
-	// Pair with a Fire TV device (two-step PIN flow)
-	mux.HandleFunc(cfg.APIBasePath+"/firetv/pair", handlers.HandleFireTVPair(firetvClient))
-	// Send remote control commands to a paired Fire TV device
-	mux.HandleFunc(cfg.APIBasePath+"/firetv/command", handlers.HandleFireTVCommand(firetvClient))
+	routes.integration("Fire TV", cfg.EnableFireTV, []integrationRoute{
+		{"GET", "/firetv/discover", "Discover Fire TV devices on LAN", needsFireTV(handlers.HandleFireTVDiscover(firetvClients))},
+		{"POST", "/firetv/pair", "Pair with a Fire TV device", needsFireTV(handlers.HandleFireTVPair(firetvClients))},
+		{"POST", "/firetv/command", "Send command to Fire TV", needsFireTV(idempotent(handlers.HandleFireTVCommand(firetvClients, cfg.FireTVAllowRawKeycodes)))},
+		{"POST", "/firetv/search", "Open an app and search it for text", needsFireTV(idempotent(handlers.HandleFireTVSearch(firetvClients, cfg.FireTVSearchLaunchDelay)))},
+		{"POST", "/firetv/wol", "Wake a Fire TV with Wake-on-LAN", idempotent(handlers.HandleFireTVWakeOnLAN(database))},
+		{"POST", "/firetv/service/restart", "Reset a hung Fire TV service (ADMIN_TOKEN)", middleware.RequireToken(cfg.AdminToken, handlers.HandleFireTVServiceRestart(firetvClients))},
+	})
 
-	// Wyze Camera Bridge endpoints - view live camera streams
-	// Initialize the camera client that communicates with Docker Wyze Bridge
-	cameraClient := camera.NewClient(cfg.WyzeBridgeURL, cfg.WyzeBridgeAPIKey)
-	log.Printf("📷 Camera client initialized (bridge URL: %s)", cfg.WyzeBridgeURL)
-
-	// Check if the Wyze Bridge is reachable (non-blocking warning)
-	if err := cameraClient.CheckHealth(); err != nil {
-		log.Printf("⚠️  Wyze Bridge not reachable: %v", err)
-		log.Printf("⚠️  Camera features will not work until Wyze Bridge is started")
-		log.Printf("⚠️  Start it with: cd .. && docker compose up -d")
-	} else {
-		log.Printf("📷 Wyze Bridge is healthy and reachable")
+	routes.integration("Webhooks", cfg.EnableWebhooks, []integrationRoute{
+		{"GET POST", "/webhooks", "List (GET) or register (POST) webhooks (ADMIN_TOKEN)", middleware.RequireToken(cfg.AdminToken, handlers.HandleWebhooks(webhookStore, listOpts))},
+		{"DELETE", "/webhooks/{id}", "Remove a webhook (ADMIN_TOKEN)", middleware.RequireToken(cfg.AdminToken, handlers.HandleDeleteWebhook(webhookStore))},
+	})
+
+	cameraRoutes := []integrationRoute{
+		{"GET", "/cameras", "List Wyze cameras", cameraAccess(needsBridge(handlers.HandleGetCameras(cameraClient, listOpts)))},
+		{"GET", "/cameras/stream", "Get camera stream URLs", cameraAccess(needsBridge(handlers.HandleGetCameraStream(cameraClient)))},
+		{"GET", "/cameras/default", "Quick-view camera stream URLs", cameraAccess(needsBridge(handlers.HandleGetDefaultCamera(cameraClient, cfg.DefaultCamera)))},
+		{"POST", "/cameras/privacy", "Toggle camera privacy mode", cameraAccess(needsBridge(idempotent(handlers.HandleCameraPrivacy(cameraClient))))},
+		{"GET", "/cameras/snapshot", "Camera still image (format=json for base64)", cameraAccess(needsBridge(handlers.HandleCameraSnapshot(cameraClient)))},
+		{"GET", "/cameras/overview.jpg", "All online cameras in one grid image", cameraAccess(needsBridge(handlers.HandleCameraOverview(cameraClient)))},
+		{"POST", "/cameras/restart", "Restart a stalled camera stream", cameraAccess(needsBridge(handlers.HandleCameraRestart(cameraClient)))},
+		{"GET", "/cameras/bridge-status", "Wyze Bridge version and features", cameraAccess(needsBridge(handlers.HandleGetBridgeStatus(cameraClient)))},
+		{"POST", "/cameras/capture-clip", "Record a short clip to disk (needs ffmpeg)", cameraAccess(needsBridge(idempotent(handlers.HandleCameraCaptureClip(cameraClient, clipRecorder))))},
+		{"GET", "/cameras/clips/{file}", "Download a captured clip", cameraAccess(handlers.HandleGetCameraClip(clipRecorder))},
+	}
+	if cameraACL != nil {
+		cameraRoutes = append(cameraRoutes,
+			integrationRoute{"GET", "/cameras/acls", "List camera ACLs (ADMIN_TOKEN)", middleware.RequireToken(cfg.AdminToken, handlers.HandleCameraACLs(cameraACL, listOpts))},
+			integrationRoute{"PUT DELETE", "/cameras/acls/{name}", "Set (PUT) or delete (DELETE) a token's camera ACL (ADMIN_TOKEN)", middleware.RequireToken(cfg.AdminToken, handlers.HandleCameraACL(cameraACL))},
+		)
 	}
+	routes.integration("Cameras", cfg.EnableCameras, cameraRoutes)
 
-	// List all cameras with status and stream URLs
-	mux.HandleFunc(cfg.APIBasePath+"/cameras", handlers.HandleGetCameras(cameraClient))
-	// Get stream URLs for a specific camera by name
-	mux.HandleFunc(cfg.APIBasePath+"/cameras/stream", handlers.HandleGetCameraStream(cameraClient))
+	// One control endpoint for every kind of device; targets whose
+	// integration is off answer FEATURE_DISABLED
+	deviceControllers := handlers.DeviceControllers{
+		Govee:       goveeClients,
+		Database:    database,
+		Optimistic:  optimisticStates,
+		RetryQueue:  retryQueue,
+		Poller:      statePoller,
+		SkipOffline: cfg.GoveeBatchSkipOffline,
+		Events:      deviceEvents,
+		FireTV:      firetvClients,
+		Cameras:     cameraClient,
+	}
+	routes.handle("POST", "/devices/control", "Control a Govee device or group, Fire TV, or camera", cameraScope(idempotent(handlers.HandleDeviceControl(deviceControllers))))
 
-	// Health check endpoint - useful for monitoring server status
-	mux.HandleFunc(cfg.APIBasePath+"/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"healthy","service":"artemis"}`))
+	// Macros: saved, ordered control steps across subsystems, run as one
+	routes.handle("GET POST", "/macros", "List (GET) or create (POST) macros", handlers.HandleMacros(macroStore, listOpts))
+	routes.handle("GET PUT DELETE", "/macros/{name}", "Get, replace (PUT), or delete (DELETE) a macro", handlers.HandleMacro(macroStore))
+	routes.handle("POST", "/macros/{name}/run", "Run a macro's steps in order", cameraScope(idempotent(handlers.HandleRunMacro(macroStore, deviceControllers))))
+
+	routes.integration("Daily report", cfg.EnableDailyReport, []integrationRoute{
+		{"GET", "/reports/daily-diff", "Compare the last two daily state snapshots", handlers.HandleDailyDiff(dailyReport)},
 	})
 
+	// Browser dashboard for users without the iOS app
+	if cfg.EnableDashboard {
+		dashboardHandler, err := dashboard.Handler(cfg.APIBasePath, cfg.ServerAPIKeyHeader)
+		if err != nil {
+			log.Fatalf("Failed to load dashboard: %v", err)
+		}
+		routes.handleRoot("GET", dashboard.Path, "Browser dashboard", dashboardHandler)
+		routes.handleRoot("GET", "/{$}", "Redirect to the dashboard", http.RedirectHandler(dashboard.Path, http.StatusFound))
+	}
+
+	// What this server supports, so clients can hide features that are off
+	routes.handle("GET", "/capabilities", "Enabled integrations and features", handlers.HandleGetCapabilities(buildCapabilities(cfg, statePoller != nil, clipRecorder.Available())))
+
+	// Health check endpoint - useful for monitoring server status
+	// Also reports the state of the upstream circuit breakers
+	routes.handle("", "/health", "Health check", handlers.HandleHealth(breakers))
+
+	// The route table itself, for debugging and client generation
+	routes.handle("GET", "/routes", "List all routes", handlers.HandleListRoutes(routes.list, listOpts))
+
 	// Apply middleware
 	var handler http.Handler = mux
 
+	// Honor per-request deadlines (X-Request-Timeout-Ms) in every handler's
+	// outbound calls
+	handler = middleware.RequestDeadline(handler, cfg.MaxRequestTimeout)
+
+	// Reject POST/PUT bodies that aren't JSON with 415 before any handler
+	// tries to decode them
+	handler = middleware.RequireJSON(handler)
+
+	// Require the shared API key on every API route except /health, when
+	// one is configured. Inside CORS so 401s still carry CORS headers.
+	var corsHeaders []string
+	if cfg.ServerAPIKey != "" {
+		handler = middleware.AuthWithOptions(handler, cfg.ServerAPIKey, middleware.AuthOptions{
+			Header:     cfg.ServerAPIKeyHeader,
+			PathPrefix: cfg.APIBasePath,
+			Exempt:     []string{cfg.APIBasePath + "/health"},
+		})
+		corsHeaders = append(corsHeaders, cfg.ServerAPIKeyHeader)
+		log.Printf("🔒 API requests require the %s header", cfg.ServerAPIKeyHeader)
+	} else {
+		log.Printf("⚠️  SERVER_API_KEY is unset — the API is open to anyone who can reach it")
+	}
+
 	// Add CORS middleware (allows frontend to make requests)
-	handler = middleware.CORS(handler)
+	handler = middleware.CORS(handler, corsHeaders...)
+
+	// Add tracing middleware if an OTLP collector is configured.
+	// Spans are named after the matched route, which the mux records on the
+	// request: CORS, Auth, and RequireJSON pass the request through untouched,
+	// and RequestDeadline copies the route back from the one it replaces.
+	if shutdownTracing != nil {
+		handler = middleware.Tracing(handler)
+	}
 
-	// Add request logging middleware if enabled
-	if cfg.EnableRequestLogging {
+	// Add request logging middleware if enabled.
+	// LOG_BODIES switches to the verbose logger, which also prints redacted
+	// request/response bodies for API routes.
+	if cfg.LogBodies {
+		handler = middleware.RequestBodyLogger(handler, middleware.BodyLogOptions{
+			PathPrefix: cfg.APIBasePath,
+			MaxBytes:   cfg.LogBodyMaxBytes,
+		})
+		log.Printf("⚠️  Body logging is enabled (LOG_BODIES=true) — do not use in production")
+	} else if cfg.EnableRequestLogging {
 		handler = middleware.RequestLogger(handler)
 	}
 
+	// Tag each request with an ID (the client's X-Request-ID, or a new one)
+	// before logging, so its log lines can be correlated
+	handler = middleware.RequestID(handler)
+
+	// Resolve the real client IP before logging. Forwarding headers are only
+	// honored when the request arrives from one of the configured proxies.
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	handler = middleware.RealIP(handler, trustedProxies)
+
+	// Turn panics anywhere in the chain into 500s. Outermost so no other
+	// middleware can crash the connection; it sets the CORS headers and
+	// request ID on the 500 itself.
+	handler = middleware.Recover(handler)
+
+	// Open the listener (Unix socket when LISTEN_SOCKET is set, else TCP)
+	listener, err := listen(cfg)
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+
 	// Start the server
-	log.Printf("✅ Server is listening on %s", cfg.GetAddress())
-	log.Printf("📝 API endpoints:")
-	log.Printf("  Profile & Room Management:")
-	log.Printf("   - POST   %s/profile - Create profile", cfg.APIBasePath)
-	log.Printf("   - GET    %s/profile/{id} - Get profile (with rooms & devices)", cfg.APIBasePath)
-	log.Printf("   - GET    %s/profiles - List all profiles", cfg.APIBasePath)
-	log.Printf("   - PUT    %s/profile/{id} - Update profile", cfg.APIBasePath)
-	log.Printf("   - DELETE %s/profile/{id} - Delete profile (cascade)", cfg.APIBasePath)
-	log.Printf("   - POST   %s/profile/{id}/rooms - Create room", cfg.APIBasePath)
-	log.Printf("   - GET    %s/profile/{id}/rooms - List rooms", cfg.APIBasePath)
-	log.Printf("   - GET    %s/room/{id} - Get room (with devices)", cfg.APIBasePath)
-	log.Printf("   - PUT    %s/room/{id} - Update room", cfg.APIBasePath)
-	log.Printf("   - PUT    %s/room/{id}/beacon - Set beacon config", cfg.APIBasePath)
-	log.Printf("   - DELETE %s/room/{id} - Delete room", cfg.APIBasePath)
-	log.Printf("   - GET    %s/room/{id}/template - Get room scene template", cfg.APIBasePath)
-	log.Printf("   - POST   %s/profile/{id}/devices - Create device", cfg.APIBasePath)
-	log.Printf("   - GET    %s/profile/{id}/devices - List devices", cfg.APIBasePath)
-	log.Printf("   - GET    %s/device/{id} - Get device", cfg.APIBasePath)
-	log.Printf("   - PUT    %s/device/{id} - Update device", cfg.APIBasePath)
-	log.Printf("   - PUT    %s/device/{id}/assign - Assign device to room", cfg.APIBasePath)
-	log.Printf("   - PUT    %s/device/{id}/unassign - Unassign device", cfg.APIBasePath)
-	log.Printf("   - DELETE %s/device/{id} - Delete device", cfg.APIBasePath)
-	log.Printf("  Integrations:")
-	log.Printf("   - POST %s/lightbulb/toggle - Toggle lightbulb state", cfg.APIBasePath)
-	log.Printf("   - GET  %s/govee/devices - List all Govee devices", cfg.APIBasePath)
-	log.Printf("   - POST %s/govee/devices/control - Control Govee device", cfg.APIBasePath)
-	log.Printf("   - GET  %s/govee/devices/state - Query device state", cfg.APIBasePath)
-	log.Printf("   - GET  %s/firetv/discover - Discover Fire TV devices on LAN", cfg.APIBasePath)
-	log.Printf("   - POST %s/firetv/pair - Pair with a Fire TV device", cfg.APIBasePath)
-	log.Printf("   - POST %s/firetv/command - Send command to Fire TV", cfg.APIBasePath)
-	log.Printf("   - GET  %s/cameras - List Wyze cameras", cfg.APIBasePath)
-	log.Printf("   - GET  %s/cameras/stream - Get camera stream URLs", cfg.APIBasePath)
-	log.Printf("   - GET  %s/health - Health check", cfg.APIBasePath)
-
-	if err := http.ListenAndServe(cfg.GetAddress(), handler); err != nil {
+	log.Printf("✅ Server is listening on %s", cfg.GetListenDescription())
+	routes.logStartup(cfg.LogLevel == "debug")
+
+	server := &http.Server{Handler: handler}
+	server.RegisterOnShutdown(func() { close(streamsClosing) })
+
+	// Shut down gracefully on SIGINT/SIGTERM so in-flight requests finish
+	// and the socket file is cleaned up. Serve returns as soon as shutdown
+	// starts, so main waits on shutdownDone before exiting.
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		log.Printf("🛑 Shutting down gracefully (waiting up to %s for in-flight requests)...", cfg.ShutdownDrainTimeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownDrainTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("❌ Graceful shutdown failed: %v", err)
+		}
+
+		// End any party and fade first so their loops can't change a light
+		// after the shutdown actions have run
+		if partyManager != nil {
+			partyManager.Shutdown()
+		}
+		for _, client := range goveeClients {
+			client.CancelFades()
+		}
+
+		// Put devices in their configured safe state once no more requests
+		// can arrive (so a late command can't undo it)
+		if len(cfg.ShutdownActions) > 0 {
+			if cfg.EnableGovee {
+				runShutdownActions(goveeClients, database, cfg.ShutdownActions, cfg.ShutdownActionsTimeout)
+			} else {
+				log.Printf("⚠️  Skipping %d shutdown action(s): Govee integration is disabled", len(cfg.ShutdownActions))
+			}
+		}
+	}()
+
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("Server failed to start: %v", err)
 	}
+	<-shutdownDone
+
+	// Flush spans still buffered for export
+	if shutdownTracing != nil {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := shutdownTracing(flushCtx); err != nil {
+			log.Printf("⚠️  Failed to flush traces: %v", err)
+		}
+		cancel()
+	}
+	log.Printf("👋 Server stopped")
+}
+
+// newGoveeClient creates a Govee client for apiKey with the configured API
+// version, sending its requests through GOVEE_PROXY_URL when set (instead of
+// the environment's proxy).
+func newGoveeClient(cfg *config.Config, apiKey string) *govee.Client {
+	client := govee.NewClientWithVersion(apiKey, cfg.GoveeAPIVersion)
+	if cfg.GoveeProxyURL != "" {
+		// Validated by config.Validate
+		if proxyURL, err := config.ParseProxyURL(cfg.GoveeProxyURL); err == nil {
+			client.SetProxy(proxyURL)
+		}
+	}
+	return client
 }
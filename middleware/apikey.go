@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// DefaultAPIKeyHeader is the header Auth reads the API key from unless
+// AuthOptions.Header says otherwise.
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// AuthOptions configures AuthWithOptions.
+type AuthOptions struct {
+	Header     string   // Header carrying the key; empty means DefaultAPIKeyHeader
+	PathPrefix string   // Only paths under this prefix need the key, e.g. "/api"; empty means all
+	Exempt     []string // Paths that never need the key, e.g. "/api/health"
+}
+
+// Auth requires every request to carry apiKey in the X-API-Key header,
+// answering 401 otherwise. The key is never read from the query string,
+// where it would end up in access logs and browser history.
+// CORS preflights, which browsers send without custom headers, pass through.
+//
+// It's a shared key for the whole API, on top of the per-endpoint bearer
+// tokens (RequireToken, CameraACL). Wire it in only when a key is
+// configured; an empty apiKey rejects every request.
+func Auth(next http.Handler, apiKey string) http.Handler {
+	return AuthWithOptions(next, apiKey, AuthOptions{})
+}
+
+// AuthWithOptions is Auth with a custom header, limited to paths under
+// opts.PathPrefix, and with opts.Exempt paths let through without the key.
+func AuthWithOptions(next http.Handler, apiKey string, opts AuthOptions) http.Handler {
+	header := opts.Header
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions || !underPrefix(r.URL.Path, opts.PathPrefix) || slices.Contains(opts.Exempt, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		provided := r.Header.Get(header)
+		if provided == "" || apiKey == "" || !tokensMatch(provided, apiKey) {
+			log.Printf("🛑 Rejected request to %s from %s: missing or wrong %s", r.URL.Path, ClientIP(r), header)
+			writeError(w, http.StatusUnauthorized, "Missing or invalid "+header+" header")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// underPrefix reports whether path is prefix itself or below it ("/api"
+// covers "/api/health" but not "/apidocs"). An empty prefix covers all.
+func underPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
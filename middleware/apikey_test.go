@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pantheon/artemis/errcode"
+)
+
+func TestAuth(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	handler := AuthWithOptions(ok, "s3cret", AuthOptions{PathPrefix: "/api", Exempt: []string{"/api/health"}})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		want   int
+	}{
+		{"allowed", http.MethodPost, "/api/govee/devices/control", "s3cret", http.StatusNoContent},
+		{"missing key", http.MethodPost, "/api/govee/devices/control", "", http.StatusUnauthorized},
+		{"wrong key", http.MethodPost, "/api/govee/devices/control", "nope", http.StatusUnauthorized},
+		{"health is exempt", http.MethodGet, "/api/health", "", http.StatusNoContent},
+		{"outside the API", http.MethodGet, "/dashboard/", "", http.StatusNoContent},
+		{"CORS preflight", http.MethodOptions, "/api/govee/devices/control", "", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, w.Code)
+			}
+			if tt.want == http.StatusUnauthorized {
+				var body struct {
+					Error string       `json:"error"`
+					Code  errcode.Code `json:"code"`
+				}
+				if err := json.NewDecoder(w.Body).Decode(&body); err != nil || body.Code != errcode.Unauthorized {
+					t.Errorf("expected a JSON UNAUTHORIZED error, got %q (%v)", w.Body.String(), err)
+				}
+			}
+		})
+	}
+}
+
+func TestAuth_IgnoresQueryParameter(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	handler := Auth(ok, "s3cret")
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{"GET", http.MethodGet, "/api/events/devices?apiKey=s3cret", http.StatusUnauthorized},
+		{"HEAD", http.MethodHead, "/api/cameras/snapshot?name=porch&apiKey=s3cret", http.StatusUnauthorized},
+		{"POST", http.MethodPost, "/api/govee/devices/control?apiKey=s3cret", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestAuth_CustomHeader(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	handler := AuthWithOptions(ok, "s3cret", AuthOptions{Header: "X-Artemis-Key"})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/govee/devices", nil)
+	req.Header.Set("X-API-Key", "s3cret")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the default header to be ignored, got %d", w.Code)
+	}
+
+	req.Header.Set("X-Artemis-Key", "s3cret")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected the custom header to be accepted, got %d", w.Code)
+	}
+}
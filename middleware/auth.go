@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/pantheon/artemis/errcode"
+)
+
+// RequireToken gates a handler behind a static bearer token
+// ("Authorization: Bearer <token>").
+//
+// If token is empty the endpoint is disabled entirely (403), so admin
+// endpoints are never exposed unauthenticated just because the token was
+// left unset.
+func RequireToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			writeError(w, http.StatusForbidden, "This endpoint is disabled — set ADMIN_TOKEN to enable it")
+			return
+		}
+
+		provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !tokensMatch(provided, token) {
+			log.Printf("🛑 Rejected unauthenticated request to %s from %s", r.URL.Path, ClientIP(r))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="artemis"`)
+			writeError(w, http.StatusUnauthorized, "Missing or invalid bearer token")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// tokensMatch compares a provided token with the expected one in constant
+// time, so response timing doesn't leak the token.
+func tokensMatch(provided, token string) bool {
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+// writeError sends a {"error": message, "code": ...} response with the
+// generic code for status, matching the handlers package's error format.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeErrorCode(w, status, errcode.ForStatus(status), message)
+}
+
+// writeErrorCode is writeError with an explicit error code.
+func writeErrorCode(w http.ResponseWriter, status int, code errcode.Code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error string       `json:"error"`
+		Code  errcode.Code `json:"code"`
+	}{message, code})
+}
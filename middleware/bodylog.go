@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// bodyCaptureLimit is the most of each body kept in memory for logging.
+// Bodies must be parsed in full to be redacted, so anything larger than this
+// is summarized instead of shown. Independent of BodyLogOptions.MaxBytes,
+// which only limits how much of the redacted body is printed.
+const bodyCaptureLimit = 64 * 1024
+
+// sensitiveKeys are JSON keys whose values are never logged. Keys are
+// compared case-insensitively with "_" and "-" removed, so "api_key",
+// "apiKey", and "API-KEY" all match "apikey".
+var sensitiveKeys = map[string]bool{
+	"apikey":        true,
+	"key":           true,
+	"pin":           true,
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+	"accesstoken":   true,
+	"refreshtoken":  true,
+	"authorization": true,
+}
+
+// BodyLogOptions controls RequestBodyLogger.
+type BodyLogOptions struct {
+	// Only requests whose path starts with this prefix have bodies logged
+	// (e.g., "/api"). Empty logs every request.
+	PathPrefix string
+
+	// Maximum number of bytes of each (redacted) body to print.
+	MaxBytes int
+}
+
+// cappedBuffer keeps the first limit bytes written to it and counts the rest.
+// Write never fails, so it's safe to use as a tee target.
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+	total int
+}
+
+func newCappedBuffer(limit int) *cappedBuffer {
+	return &cappedBuffer{limit: limit}
+}
+
+// Write implements io.Writer.
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	c.total += len(p)
+	if room := c.limit - c.buf.Len(); room > 0 {
+		c.buf.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+// complete reports whether the whole body fit in the buffer.
+func (c *cappedBuffer) complete() bool {
+	return c.total == c.buf.Len()
+}
+
+// teeReadCloser pairs a TeeReader with the original body's Close.
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// formatBody renders a captured body for the log: JSON bodies are redacted
+// and printed up to maxBytes; other content is summarized rather than
+// printed, since it can't be reliably redacted.
+func formatBody(body *cappedBuffer, maxBytes int) string {
+	if body.total == 0 {
+		return "(empty)"
+	}
+	if !body.complete() {
+		return fmt.Sprintf("(%d bytes — too large to redact, not shown)", body.total)
+	}
+
+	var parsed interface{}
+	if err := json.Unmarshal(body.buf.Bytes(), &parsed); err != nil {
+		return fmt.Sprintf("(%d bytes of non-JSON content, not shown)", body.total)
+	}
+
+	redacted, err := json.Marshal(redact(parsed))
+	if err != nil {
+		return fmt.Sprintf("(%d bytes — failed to re-encode: %v)", body.total, err)
+	}
+
+	if maxBytes > 0 && len(redacted) > maxBytes {
+		// Drop any multi-byte character split by the cut.
+		shown := strings.ToValidUTF8(string(redacted[:maxBytes]), "")
+		return fmt.Sprintf("%s… (truncated, %d bytes total)", shown, len(redacted))
+	}
+	return string(redacted)
+}
+
+// redact returns a copy of a decoded JSON value with the values of
+// sensitive keys replaced, at any depth.
+func redact(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, inner := range val {
+			if isSensitiveKey(k) {
+				out[k] = "[REDACTED]"
+			} else {
+				out[k] = redact(inner)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, inner := range val {
+			out[i] = redact(inner)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// isSensitiveKey reports whether a JSON key names a secret.
+func isSensitiveKey(key string) bool {
+	normalized := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	return sensitiveKeys[normalized]
+}
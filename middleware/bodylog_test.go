@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLogs redirects the standard logger for the duration of a test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(previous) })
+	return &buf
+}
+
+func TestRequestBodyLogger_LogsRedactedBodies(t *testing.T) {
+	logs := captureLogs(t)
+
+	var handlerSaw string
+	handler := RequestBodyLogger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		handlerSaw = string(body)
+		w.Write([]byte(`{"success": true, "token": "abc123"}`))
+	}), BodyLogOptions{PathPrefix: "/api", MaxBytes: 1024})
+
+	reqBody := `{"host": "192.168.1.50", "pin": "123456", "nested": {"api_key": "sk-live"}, "apiKeyIndex": 1}`
+	req := httptest.NewRequest(http.MethodPost, "/api/firetv/pair", strings.NewReader(reqBody))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if handlerSaw != reqBody {
+		t.Errorf("handler should receive the original body, got %q", handlerSaw)
+	}
+
+	out := logs.String()
+	for _, secret := range []string{"123456", "sk-live", "abc123"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log leaked secret %q:\n%s", secret, out)
+		}
+	}
+	for _, expected := range []string{`"host":"192.168.1.50"`, `"apiKeyIndex":1`, `"pin":"[REDACTED]"`, `"success":true`} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected log to contain %s:\n%s", expected, out)
+		}
+	}
+}
+
+func TestRequestBodyLogger_SkipsOtherPaths(t *testing.T) {
+	logs := captureLogs(t)
+
+	handler := RequestBodyLogger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"visible": "no"}`))
+	}), BodyLogOptions{PathPrefix: "/api", MaxBytes: 1024})
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if strings.Contains(logs.String(), "body") {
+		t.Errorf("expected no body logging outside /api:\n%s", logs.String())
+	}
+}
+
+func TestRequestLogger_DoesNotLogBodies(t *testing.T) {
+	logs := captureLogs(t)
+
+	handler := RequestLogger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"secret-ish": "value"}`))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/health", nil))
+
+	if strings.Contains(logs.String(), "value") {
+		t.Errorf("default logger must not log bodies:\n%s", logs.String())
+	}
+}
+
+func TestFormatBody(t *testing.T) {
+	capture := func(body string) *cappedBuffer {
+		buf := newCappedBuffer(32)
+		buf.Write([]byte(body))
+		return buf
+	}
+
+	tests := []struct {
+		name     string
+		body     *cappedBuffer
+		maxBytes int
+		expected string
+	}{
+		{"empty", capture(""), 100, "(empty)"},
+		{"non-JSON omitted", capture("pin=1234"), 100, "(8 bytes of non-JSON content, not shown)"},
+		{"truncated to max", capture(`{"name": "Living Room"}`), 10, `{"name":"L… (truncated, 22 bytes total)`},
+		{"over capture limit", capture(strings.Repeat("x", 40)), 100, "(40 bytes — too large to redact, not shown)"},
+		{"array of objects", capture(`[{"Password": "x"}]`), 100, `[{"Password":"[REDACTED]"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatBody(tt.body, tt.maxBytes); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
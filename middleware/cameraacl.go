@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/pantheon/artemis/camera"
+)
+
+// CameraACL requires a bearer token on camera endpoints and limits each
+// request to the cameras its token may see (see camera.ACLStore). The admin
+// token sees every camera; a token with an ACL entry sees only that entry's
+// cameras; anything else is 401. Handlers enforce the limit through
+// camera.Allowed and camera.FilterAllowed.
+//
+// If adminToken is empty ACLs are inactive and every request passes
+// through unchanged, since there would be no token to manage them with.
+func CameraACL(adminToken string, acl *camera.ACLStore, next http.HandlerFunc) http.HandlerFunc {
+	if adminToken == "" {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		provided, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if provided != "" && tokensMatch(provided, adminToken) {
+			next(w, r)
+			return
+		}
+
+		entry, ok := acl.Lookup(provided)
+		if !ok {
+			log.Printf("🛑 Rejected camera request to %s without a valid token from %s", r.URL.Path, ClientIP(r))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="artemis"`)
+			writeError(w, http.StatusUnauthorized, "Missing or invalid bearer token")
+			return
+		}
+
+		next(w, r.WithContext(camera.WithAllowedCameras(r.Context(), entry.Cameras)))
+	}
+}
+
+// CameraScope applies camera ACLs to routes that reach cameras only some of
+// the time, such as unified device control and macros. Tokens work as in
+// CameraACL: the admin token sees every camera, an ACL token sees its
+// entry's cameras, and an unknown token is 401. A request without a token
+// still passes, because its Govee or Fire TV commands need none, but it may
+// control no camera. Handlers check camera.Allowed before touching one.
+//
+// Like CameraACL, it is inactive when adminToken is empty.
+func CameraScope(adminToken string, acl *camera.ACLStore, next http.HandlerFunc) http.HandlerFunc {
+	if adminToken == "" {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		provided, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if provided == "" {
+			next(w, r.WithContext(camera.WithAllowedCameras(r.Context(), []string{})))
+			return
+		}
+		CameraACL(adminToken, acl, next)(w, r)
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pantheon/artemis/camera"
+)
+
+func TestCameraACL(t *testing.T) {
+	acl, _ := camera.NewACLStore("")
+	acl.Put("Guest", camera.ACLEntry{Token: "guest-tablet-token-0001", Cameras: []string{"front-door"}})
+
+	// Answers 204 if the request may see the bedroom camera, else 200
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if camera.Allowed(r.Context(), "bedroom") {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	tests := []struct {
+		name       string
+		adminToken string
+		header     string
+		want       int
+	}{
+		{"admin sees every camera", "s3cret", "Bearer s3cret", http.StatusNoContent},
+		{"ACL token is limited", "s3cret", "Bearer guest-tablet-token-0001", http.StatusOK},
+		{"unknown token", "s3cret", "Bearer nope", http.StatusUnauthorized},
+		{"missing header", "s3cret", "", http.StatusUnauthorized},
+		{"inactive without an admin token", "", "", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/cameras", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			CameraACL(tt.adminToken, acl, handler)(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestCameraScope(t *testing.T) {
+	acl, _ := camera.NewACLStore("")
+	acl.Put("Guest", camera.ACLEntry{Token: "guest-tablet-token-0001", Cameras: []string{"front-door"}})
+
+	// Answers 204 if the request may see the front door camera, else 200
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if camera.Allowed(r.Context(), "front-door") {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	tests := []struct {
+		name       string
+		adminToken string
+		header     string
+		want       int
+	}{
+		{"admin sees every camera", "s3cret", "Bearer s3cret", http.StatusNoContent},
+		{"ACL token sees its cameras", "s3cret", "Bearer guest-tablet-token-0001", http.StatusNoContent},
+		{"no token passes without cameras", "s3cret", "", http.StatusOK},
+		{"unknown token", "s3cret", "Bearer nope", http.StatusUnauthorized},
+		{"inactive without an admin token", "", "", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/devices/control", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			CameraScope(tt.adminToken, acl, handler)(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// clientIPKey is the context key under which RealIP stores the resolved
+// client address. Unexported so only this package can set it.
+type clientIPKey struct{}
+
+// ParseTrustedProxies converts a list of CIDR ranges (e.g., "10.0.0.0/8")
+// or bare IP addresses (e.g., "127.0.0.1") into networks RealIP can match
+// against. Empty entries are skipped. Returns an error naming the first
+// entry that isn't a valid IP or CIDR.
+func ParseTrustedProxies(entries []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		// Treat a bare IP as a single-address network.
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address: %q", entry)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy CIDR: %q", entry)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// RealIP is middleware that works out the real client IP for each request
+// and stores it in the request context for ClientIP to read.
+//
+// Forwarding headers are only honored when the direct peer (r.RemoteAddr) is
+// one of the trusted proxies — otherwise any client could claim to be anyone
+// by sending its own X-Forwarded-For. With no trusted proxies configured the
+// headers are always ignored and the peer address is used.
+func RealIP(next http.Handler, trustedProxies []*net.IPNet) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := resolveClientIP(r, trustedProxies)
+		ctx := context.WithValue(r.Context(), clientIPKey{}, ip)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ClientIP returns the client IP resolved by RealIP, or the host portion of
+// r.RemoteAddr if RealIP hasn't run for this request.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok && ip != "" {
+		return ip
+	}
+	return remoteHost(r.RemoteAddr)
+}
+
+// resolveClientIP applies the trusted-proxy rules to a single request.
+func resolveClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	peer := remoteHost(r.RemoteAddr)
+	if !isTrusted(peer, trustedProxies) {
+		return peer
+	}
+
+	// X-Forwarded-For is "client, proxy1, proxy2" — each hop appends the
+	// address it received the request from. Walk it right to left, skipping
+	// our own trusted proxies; the first untrusted address is the client.
+	// Anything further left was supplied by the client and can't be trusted.
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				// A malformed entry means the chain can't be trusted past here.
+				break
+			}
+			if !isTrusted(hop, trustedProxies) {
+				return hop
+			}
+		}
+	}
+
+	// Single-hop proxies (e.g., nginx with proxy_set_header X-Real-IP).
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	return peer
+}
+
+// isTrusted reports whether ip falls inside any of the trusted networks.
+func isTrusted(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteHost strips the port from an address like "192.168.1.5:52311".
+// Returns the input unchanged if it has no port.
+func remoteHost(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
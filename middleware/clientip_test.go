@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// resolveVia runs a request through RealIP and returns what ClientIP saw.
+func resolveVia(t *testing.T, trusted []string, remoteAddr string, headers map[string]string) string {
+	t.Helper()
+	proxies, err := ParseTrustedProxies(trusted)
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies returned error: %v", err)
+	}
+
+	var got string
+	handler := RealIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = ClientIP(r)
+	}), proxies)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	return got
+}
+
+func TestClientIP_NoTrustedProxiesIgnoresHeaders(t *testing.T) {
+	got := resolveVia(t, nil, "203.0.113.9:5000", map[string]string{
+		"X-Forwarded-For": "1.2.3.4",
+		"X-Real-IP":       "5.6.7.8",
+	})
+	if got != "203.0.113.9" {
+		t.Errorf("expected peer address when no proxies are trusted, got %s", got)
+	}
+}
+
+func TestClientIP_UntrustedPeerCannotSpoof(t *testing.T) {
+	// A client talking to us directly tries to claim another address.
+	got := resolveVia(t, []string{"10.0.0.0/8"}, "198.51.100.7:4000", map[string]string{
+		"X-Forwarded-For": "1.2.3.4",
+	})
+	if got != "198.51.100.7" {
+		t.Errorf("expected spoofed header to be ignored, got %s", got)
+	}
+}
+
+func TestClientIP_TrustedProxyForwardedFor(t *testing.T) {
+	got := resolveVia(t, []string{"10.0.0.0/8"}, "10.0.0.2:8080", map[string]string{
+		"X-Forwarded-For": "192.168.1.44",
+	})
+	if got != "192.168.1.44" {
+		t.Errorf("expected forwarded client IP, got %s", got)
+	}
+}
+
+func TestClientIP_SpoofedLeftmostEntryIgnored(t *testing.T) {
+	// The client prepended a fake hop; the proxy appended the real peer.
+	// Only the rightmost untrusted entry is believed.
+	got := resolveVia(t, []string{"10.0.0.0/8"}, "10.0.0.2:8080", map[string]string{
+		"X-Forwarded-For": "1.2.3.4, 192.168.1.44",
+	})
+	if got != "192.168.1.44" {
+		t.Errorf("expected rightmost untrusted hop, got %s", got)
+	}
+}
+
+func TestClientIP_SkipsChainedTrustedProxies(t *testing.T) {
+	got := resolveVia(t, []string{"10.0.0.0/8", "127.0.0.1"}, "127.0.0.1:8080", map[string]string{
+		"X-Forwarded-For": "192.168.1.44, 10.0.0.3",
+	})
+	if got != "192.168.1.44" {
+		t.Errorf("expected client behind two trusted proxies, got %s", got)
+	}
+}
+
+func TestClientIP_TrustedProxyRealIP(t *testing.T) {
+	got := resolveVia(t, []string{"127.0.0.1"}, "127.0.0.1:8080", map[string]string{
+		"X-Real-IP": "192.168.1.44",
+	})
+	if got != "192.168.1.44" {
+		t.Errorf("expected X-Real-IP from trusted proxy, got %s", got)
+	}
+}
+
+func TestClientIP_MalformedHeaderFallsBack(t *testing.T) {
+	got := resolveVia(t, []string{"127.0.0.1"}, "127.0.0.1:8080", map[string]string{
+		"X-Forwarded-For": "not-an-ip",
+	})
+	if got != "127.0.0.1" {
+		t.Errorf("expected peer address for malformed header, got %s", got)
+	}
+}
+
+func TestClientIP_WithoutMiddleware(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	if got := ClientIP(req); got != "192.0.2.1" {
+		t.Errorf("expected RemoteAddr host, got %s", got)
+	}
+}
+
+func TestParseTrustedProxies_Invalid(t *testing.T) {
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/8", "nope"}); err == nil {
+		t.Error("expected error for invalid entry")
+	}
+}
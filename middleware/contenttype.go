@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// RequireJSON rejects POST, PUT, and PATCH requests whose body isn't JSON
+// with 415 Unsupported Media Type, before a handler tries to decode a form
+// or a missing Content-Type as JSON.
+//
+// "application/json" (with any parameters, e.g. "; charset=utf-8") and
+// "+json" types are accepted. Requests without a body pass through, so
+// action endpoints that only take query parameters still work when clients
+// send no Content-Type. Paths starting with one of exemptPrefixes are never
+// checked, for endpoints that accept other media types.
+func RequireJSON(next http.Handler, exemptPrefixes ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasJSONBodyMethod(r.Method) || !hasBody(r) || isExempt(r.URL.Path, exemptPrefixes) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		contentType := r.Header.Get("Content-Type")
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+			log.Printf("🛑 Rejected %s %s with Content-Type %q", r.Method, r.URL.Path, contentType)
+			writeError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasJSONBodyMethod reports whether requests with this method carry a JSON
+// body on this API.
+func hasJSONBodyMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+// hasBody reports whether the request has a body. Chunked requests have an
+// unknown length (-1) and are assumed to.
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
+
+// isExempt reports whether path starts with one of the prefixes.
+func isExempt(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequireJSON(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	handler := RequireJSON(ok, "/api/upload")
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"json", http.MethodPost, "/api/govee/control", "application/json", `{}`, http.StatusNoContent},
+		{"json with charset", http.MethodPut, "/api/device/1", "application/json; charset=utf-8", `{}`, http.StatusNoContent},
+		{"json suffix", http.MethodPost, "/api/govee/control", "application/merge-patch+json", `{}`, http.StatusNoContent},
+		{"missing", http.MethodPost, "/api/govee/control", "", `{}`, http.StatusUnsupportedMediaType},
+		{"form encoded", http.MethodPost, "/api/lightbulb/toggle", "application/x-www-form-urlencoded", "isOn=true", http.StatusUnsupportedMediaType},
+		{"text", http.MethodPut, "/api/room/1", "text/plain", `{}`, http.StatusUnsupportedMediaType},
+		{"no body", http.MethodPost, "/api/cameras/restart?name=garage", "", "", http.StatusNoContent},
+		{"GET is unchecked", http.MethodGet, "/api/govee/devices", "", "", http.StatusNoContent},
+		{"exempt path", http.MethodPost, "/api/upload/photo", "image/jpeg", "\xff\xd8", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, w.Code)
+			}
+			if tt.want == http.StatusUnsupportedMediaType && !strings.Contains(w.Body.String(), `"error"`) {
+				t.Errorf("expected a JSON error body, got %s", w.Body.String())
+			}
+		})
+	}
+}
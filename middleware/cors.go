@@ -2,16 +2,21 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 )
 
 // CORS middleware adds CORS headers to responses
 // This allows the frontend app to make requests to the backend
-func CORS(next http.Handler) http.Handler {
+// extraHeaders are allowed on top of the standard ones (e.g., the Auth
+// API key header).
+func CORS(next http.Handler, extraHeaders ...string) http.Handler {
+	allowHeaders := strings.Join(append([]string{"Content-Type", "Authorization", "Idempotency-Key", "If-Match", "X-Request-Timeout-Ms", RequestIDHeader}, extraHeaders...), ", ")
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Set CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		setCORSResponseHeaders(w.Header())
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
 
 		// Handle preflight requests
 		if r.Method == "OPTIONS" {
@@ -23,3 +28,11 @@ func CORS(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// setCORSResponseHeaders sets the CORS headers a browser checks on an actual
+// (non-preflight) response. Shared with Recover, which answers outside CORS.
+func setCORSResponseHeaders(h http.Header) {
+	h.Set("Access-Control-Allow-Origin", "*")
+	// Browsers hide response headers outside the CORS safelist unless exposed
+	h.Set("Access-Control-Expose-Headers", "ETag, "+RequestIDHeader)
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pantheon/artemis/errcode"
+)
+
+// RequestTimeoutHeader lets a client cap how long the server works on its
+// request, in milliseconds, e.g. "X-Request-Timeout-Ms: 3000" so a control
+// command gives up after 3 seconds instead of leaving the UI hanging.
+const RequestTimeoutHeader = "X-Request-Timeout-Ms"
+
+// RequestDeadline gives requests that send X-Request-Timeout-Ms a context
+// with that deadline, clamped to max. Handlers pass the request context to
+// the Govee, Fire TV, and Wyze clients, so their outbound calls are cut off
+// at the deadline.
+//
+// When the deadline has passed and the handler answers with a server error,
+// the response is replaced by 504 Gateway Timeout with a JSON error, so the
+// client can tell its own deadline from an upstream failure. A handler's own
+// 504 (e.g. the control endpoint's) and successful responses are sent as is.
+//
+// An invalid header is a 400. Requests without the header are untouched, as
+// are all requests when max isn't positive.
+func RequestDeadline(next http.Handler, max time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw := strings.TrimSpace(r.Header.Get(RequestTimeoutHeader))
+		if raw == "" || max <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ms, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || ms <= 0 {
+			writeError(w, http.StatusBadRequest, RequestTimeoutHeader+" must be a positive number of milliseconds")
+			return
+		}
+		// Compare in milliseconds so huge values can't overflow the Duration
+		timeout := max
+		if ms < max.Milliseconds() {
+			timeout = time.Duration(ms) * time.Millisecond
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		dw := &deadlineWriter{ResponseWriter: w, ctx: ctx, timeout: timeout}
+		inner := r.WithContext(ctx)
+		next.ServeHTTP(dw, inner)
+
+		// ServeMux records the matched route on the request it was given;
+		// copy it back so outer middleware (Tracing) still sees it
+		r.Pattern = inner.Pattern
+
+		// A handler that gave up without answering still owes the client one
+		if !dw.wroteHeader && dw.expired() {
+			dw.WriteHeader(http.StatusInternalServerError)
+		}
+		if dw.timedOut {
+			log.Printf("⏱️  %s %s exceeded its %s deadline (%s)", r.Method, r.URL.Path, timeout, RequestTimeoutHeader)
+		}
+	})
+}
+
+// deadlineWriter swaps a handler's server error for a 504 once the
+// request's deadline has passed, and drops the rest of the handler's body.
+type deadlineWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	timeout     time.Duration
+	wroteHeader bool
+	timedOut    bool // The 504 was sent in place of the handler's response
+}
+
+// expired reports whether the request's own deadline has passed.
+func (dw *deadlineWriter) expired() bool {
+	return errors.Is(dw.ctx.Err(), context.DeadlineExceeded)
+}
+
+// WriteHeader sends the handler's status, or the 504 in its place.
+func (dw *deadlineWriter) WriteHeader(code int) {
+	if dw.wroteHeader {
+		return
+	}
+	dw.wroteHeader = true
+
+	if code >= 500 && code != http.StatusGatewayTimeout && dw.expired() {
+		dw.timedOut = true
+		dw.Header().Del("Content-Length")
+		writeErrorCode(dw.ResponseWriter, http.StatusGatewayTimeout, errcode.RequestTimeout, fmt.Sprintf("Request timed out after %s (%s)", dw.timeout, RequestTimeoutHeader))
+		return
+	}
+	dw.ResponseWriter.WriteHeader(code)
+}
+
+// Write sends the handler's body, unless the 504 replaced it.
+func (dw *deadlineWriter) Write(b []byte) (int, error) {
+	if !dw.wroteHeader {
+		dw.WriteHeader(http.StatusOK)
+	}
+	if dw.timedOut {
+		return len(b), nil
+	}
+	return dw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying ResponseWriter so http.ResponseController
+// can reach optional interfaces like http.Flusher
+func (dw *deadlineWriter) Unwrap() http.ResponseWriter {
+	return dw.ResponseWriter
+}
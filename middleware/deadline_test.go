@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newSlowUpstream returns a server that answers after delay (or once the
+// caller gives up) and a handler that proxies to it, answering 502 when the
+// upstream call fails — like the Govee and camera handlers.
+func newSlowUpstream(t *testing.T, delay time.Duration) http.Handler {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+			w.Write([]byte("ok"))
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream.URL, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, "upstream failed: "+err.Error())
+			return
+		}
+		resp.Body.Close()
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func TestRequestDeadline_SlowUpstreamTimesOut(t *testing.T) {
+	handler := RequestDeadline(newSlowUpstream(t, 5*time.Second), 30*time.Second)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", nil)
+	req.Header.Set(RequestTimeoutHeader, "50")
+	w := httptest.NewRecorder()
+	start := time.Now()
+	handler.ServeHTTP(w, req)
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the request to give up at its deadline, took %s", elapsed)
+	}
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected status 504, got %d: %s", w.Code, w.Body.String())
+	}
+	var body map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil || body["error"] != "Request timed out after 50ms (X-Request-Timeout-Ms)" || body["code"] != "REQUEST_TIMEOUT" {
+		t.Errorf("expected a single JSON timeout error, got %q (%v)", w.Body.String(), err)
+	}
+}
+
+func TestRequestDeadline_FastUpstreamPassesThrough(t *testing.T) {
+	handler := RequestDeadline(newSlowUpstream(t, 0), 30*time.Second)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/cameras", nil)
+	req.Header.Set(RequestTimeoutHeader, "3000")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRequestDeadline_ClampsAndValidates(t *testing.T) {
+	var deadline time.Time
+	var remaining time.Duration
+	handler := RequestDeadline(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ = r.Context().Deadline()
+		remaining = time.Until(deadline)
+		w.WriteHeader(http.StatusNoContent)
+	}), time.Second)
+
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{"", http.StatusNoContent},
+		{"99999999999999999", http.StatusNoContent}, // Clamped to the max
+		{"0", http.StatusBadRequest},
+		{"-5", http.StatusBadRequest},
+		{"3s", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		deadline = time.Time{}
+		req := httptest.NewRequest(http.MethodGet, "/api/govee/devices", nil)
+		if tt.header != "" {
+			req.Header.Set(RequestTimeoutHeader, tt.header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tt.want {
+			t.Errorf("%q: expected status %d, got %d", tt.header, tt.want, w.Code)
+		}
+		if tt.header == "" && !deadline.IsZero() {
+			t.Errorf("expected no deadline without the header, got %s", deadline)
+		}
+		if tt.want == http.StatusNoContent && tt.header != "" && remaining > time.Second {
+			t.Errorf("%q: expected the deadline clamped to 1s, got %s", tt.header, remaining)
+		}
+	}
+}
+
+func TestRequestDeadline_KeepsHandlerTimeoutResponse(t *testing.T) {
+	// The control endpoint answers its own 504 with a ControlResponse body
+	handler := RequestDeadline(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusGatewayTimeout)
+		w.Write([]byte(`{"success": false, "timedOut": true}`))
+	}), time.Second)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/govee/devices/control", nil)
+	req.Header.Set(RequestTimeoutHeader, "10")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusGatewayTimeout || w.Body.String() != `{"success": false, "timedOut": true}` {
+		t.Errorf("expected the handler's own 504 body, got %d %s", w.Code, w.Body.String())
+	}
+}
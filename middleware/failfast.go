@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/upstream"
+)
+
+// FailFast answers 503 SERVICE_UNAVAILABLE right away, without running next,
+// while every one of breakers is open: the upstream named service is known to
+// be down, so the handler would only wait out a timeout or get ErrCircuitOpen
+// from its client. Retry-After says when the first breaker half-opens.
+//
+// Nil breakers (disabled ones) are skipped, and with none left next always
+// runs. With several breakers (e.g. one per Govee key), next still runs while
+// any of them may get through.
+func FailFast(service string, breakers []*upstream.Breaker, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var retryAt *time.Time
+		open := 0
+		for _, breaker := range breakers {
+			if breaker == nil {
+				continue
+			}
+			if !breaker.Open() {
+				next(w, r)
+				return
+			}
+			open++
+			if status := breaker.Status(); status.RetryAt != nil && (retryAt == nil || status.RetryAt.Before(*retryAt)) {
+				retryAt = status.RetryAt
+			}
+		}
+		if open == 0 {
+			next(w, r)
+			return
+		}
+
+		message := fmt.Sprintf("%s is down (circuit open) — not attempting the request", service)
+		if retryAt != nil {
+			seconds := max(1, int(math.Ceil(time.Until(*retryAt).Seconds())))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			message += fmt.Sprintf(", try again in %ds", seconds)
+		}
+		log.Printf("⚡ Failed fast on %s %s: %s is down", r.Method, r.URL.Path, service)
+		writeErrorCode(w, http.StatusServiceUnavailable, errcode.ServiceUnavailable, message)
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/errcode"
+	"github.com/pantheon/artemis/upstream"
+)
+
+// openBreaker returns a breaker that one failed request has opened.
+func openBreaker(t *testing.T) *upstream.Breaker {
+	t.Helper()
+	breaker := upstream.NewBreaker("Wyze Bridge", 1, time.Minute)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	if resp, err := breaker.Do(http.DefaultClient, req, "Wyze Bridge"); err == nil {
+		resp.Body.Close()
+	}
+	if !breaker.Open() {
+		t.Fatal("expected the breaker to be open")
+	}
+	return breaker
+}
+
+func TestFailFast_OpenCircuitAnswers503(t *testing.T) {
+	calls := 0
+	handler := FailFast("Wyze Bridge", []*upstream.Breaker{openBreaker(t)}, countingHandler(&calls, http.StatusOK))
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/api/cameras", nil))
+
+	var resp struct {
+		Error string       `json:"error"`
+		Code  errcode.Code `json:"code"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusServiceUnavailable || resp.Code != errcode.ServiceUnavailable || resp.Error == "" {
+		t.Errorf("expected 503 SERVICE_UNAVAILABLE with a message, got %d %+v", w.Code, resp)
+	}
+	if retry := w.Header().Get("Retry-After"); retry == "" || retry == "0" {
+		t.Errorf("expected a Retry-After header, got %q", retry)
+	}
+	if calls != 0 {
+		t.Errorf("expected the handler not to run, ran %d times", calls)
+	}
+}
+
+func TestFailFast_RunsWhileAnyBreakerMayPass(t *testing.T) {
+	calls := 0
+	breakers := []*upstream.Breaker{openBreaker(t), upstream.NewBreaker("Govee API #1", 5, time.Minute)}
+	handler := FailFast("Govee API", breakers, countingHandler(&calls, http.StatusOK))
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/api/govee/devices", nil))
+	if w.Code != http.StatusOK || calls != 1 {
+		t.Errorf("expected the handler to run with one breaker closed, got %d (%d calls)", w.Code, calls)
+	}
+}
+
+func TestFailFast_NoBreakers(t *testing.T) {
+	calls := 0
+	handler := FailFast("Wyze Bridge", []*upstream.Breaker{nil}, countingHandler(&calls, http.StatusOK))
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/api/cameras", nil))
+	if w.Code != http.StatusOK || calls != 1 {
+		t.Errorf("expected disabled breakers to let the handler run, got %d (%d calls)", w.Code, calls)
+	}
+}
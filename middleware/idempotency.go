@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pantheon/artemis/errcode"
+)
+
+// IdempotencyKeyHeader is the request header clients send to make a retried
+// request safe to repeat. Any unique string up to maxIdempotencyKeyLength
+// works; a UUID per user action is typical.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// ReplayedHeader is set to "true" on responses served from the cache.
+const ReplayedHeader = "Idempotent-Replayed"
+
+const (
+	// Longest Idempotency-Key accepted.
+	maxIdempotencyKeyLength = 255
+
+	// Responses larger than this aren't cached (the request still runs).
+	maxIdempotentResponseBytes = 1 << 20
+
+	// How much of the request body is hashed to detect a key being reused
+	// for a different request.
+	maxIdempotentBodyBytes = 1 << 20
+)
+
+// IdempotencyCache remembers the responses of requests sent with an
+// Idempotency-Key so a retry (e.g., a mobile client resending a toggle after
+// a network blip) gets the first response back instead of running again.
+// Entries expire after a TTL and the oldest are evicted beyond a size
+// limit. Safe for concurrent use.
+type IdempotencyCache struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time // Injectable for tests
+
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+	order   []string // Keys in insertion order, for eviction
+}
+
+// idempotencyEntry is one key's request fingerprint and, once the request
+// finished, its response.
+type idempotencyEntry struct {
+	fingerprint [sha256.Size]byte
+	expires     time.Time
+	done        bool
+
+	status int
+	header http.Header
+	body   []byte
+}
+
+// NewIdempotencyCache creates a cache that keeps responses for ttl and at
+// most maxEntries keys. Returns nil (idempotency keys ignored) when ttl or
+// maxEntries isn't positive.
+func NewIdempotencyCache(ttl time.Duration, maxEntries int) *IdempotencyCache {
+	if ttl <= 0 || maxEntries <= 0 {
+		return nil
+	}
+	return &IdempotencyCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*idempotencyEntry),
+	}
+}
+
+// Idempotent makes next replay its cached response for requests that repeat
+// an Idempotency-Key. Keys are scoped to the endpoint (method and path), so
+// the same key sent to two endpoints runs both.
+//
+//   - A repeat of a finished request gets the cached status, headers, and
+//     body, with Idempotent-Replayed: true, and next doesn't run.
+//   - A repeat while the first is still running gets 409.
+//   - Reusing a key for a different request (other query or body) gets 422.
+//   - 5xx responses and handler panics aren't cached, so a failed request
+//     can be retried with the same key.
+//
+// Requests without the header, and every request when cache is nil, go
+// straight to next.
+func Idempotent(cache *IdempotencyCache, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if cache == nil || key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		fingerprint, err := requestFingerprint(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+
+		scopedKey := r.Method + " " + r.URL.Path + "\x00" + key
+		entry, found := cache.begin(scopedKey, fingerprint)
+		if found {
+			switch {
+			case entry.fingerprint != fingerprint:
+				writeErrorCode(w, http.StatusUnprocessableEntity, errcode.IdempotencyKeyReused, "Idempotency-Key was already used for a different request")
+			case !entry.done:
+				writeError(w, http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+			default:
+				log.Printf("🔁 Replaying cached response for %s %s (Idempotency-Key %q)", r.Method, r.URL.Path, key)
+				for name, values := range entry.header {
+					w.Header()[name] = values
+				}
+				w.Header().Set(ReplayedHeader, "true")
+				w.WriteHeader(entry.status)
+				w.Write(entry.body)
+			}
+			return
+		}
+
+		// If next panics, finish never runs; forget the key so retries
+		// aren't answered 409 until it expires.
+		finished := false
+		defer func() {
+			if !finished {
+				cache.forget(scopedKey)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+		cache.finish(scopedKey, recorder)
+		finished = true
+	}
+}
+
+// begin returns the entry for key if there is an unexpired one, or else
+// records a new in-flight entry and returns found=false.
+func (c *IdempotencyCache) begin(key string, fingerprint [sha256.Size]byte) (idempotencyEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if entry, ok := c.entries[key]; ok && now.Before(entry.expires) {
+		return *entry, true
+	}
+
+	c.evictLocked(now)
+	c.entries[key] = &idempotencyEntry{fingerprint: fingerprint, expires: now.Add(c.ttl)}
+	c.order = append(c.order, key)
+	return idempotencyEntry{}, false
+}
+
+// finish stores the recorded response for key, or forgets the key when the
+// response shouldn't be replayed (5xx, or too large to keep).
+func (c *IdempotencyCache) finish(key string, recorder *responseRecorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	if recorder.status >= http.StatusInternalServerError || recorder.overflow {
+		delete(c.entries, key)
+		return
+	}
+	entry.done = true
+	entry.status = recorder.status
+	entry.header = recorder.Header().Clone()
+	entry.body = recorder.body.Bytes()
+	entry.expires = c.now().Add(c.ttl)
+}
+
+// forget drops key's entry if it's still in flight.
+func (c *IdempotencyCache) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[key]; ok && !entry.done {
+		delete(c.entries, key)
+	}
+}
+
+// evictLocked drops expired entries, then the oldest ones until there's
+// room for one more. Caller holds c.mu.
+func (c *IdempotencyCache) evictLocked(now time.Time) {
+	kept := c.order[:0]
+	for _, key := range c.order {
+		entry, ok := c.entries[key]
+		if !ok {
+			continue // Forgotten by finish
+		}
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		kept = append(kept, key)
+	}
+	c.order = kept
+
+	for len(c.order) >= c.maxEntries {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// requestFingerprint hashes what makes a request distinct beyond its
+// endpoint: the query string and the body. The body is restored for the
+// handler.
+func requestFingerprint(r *http.Request) ([sha256.Size]byte, error) {
+	hash := sha256.New()
+	io.WriteString(hash, r.URL.RawQuery+"\x00")
+
+	if r.Body != nil {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			return [sha256.Size]byte{}, err
+		}
+		hash.Write(body)
+		r.Body = teeReadCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	}
+
+	var sum [sha256.Size]byte
+	copy(sum[:], hash.Sum(nil))
+	return sum, nil
+}
+
+// responseRecorder passes a response through while keeping a copy of its
+// status and body, up to maxIdempotentResponseBytes.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	overflow    bool // The body outgrew the limit and wasn't kept
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status, rec.wroteHeader = status, true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	rec.wroteHeader = true
+	if !rec.overflow {
+		if rec.body.Len()+len(p) > maxIdempotentResponseBytes {
+			rec.overflow = true
+			rec.body.Reset()
+		} else {
+			rec.body.Write(p)
+		}
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+// Unwrap exposes the underlying ResponseWriter to http.ResponseController.
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
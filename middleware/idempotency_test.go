@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingHandler answers with an incrementing count and the request body,
+// so replays are visible as a repeated count.
+func countingHandler(calls *int, status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"call": %d, "body": %q}`, *calls, body)
+	}
+}
+
+func idempotentRequest(handler http.HandlerFunc, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func TestIdempotent_ReplaysCachedResponse(t *testing.T) {
+	calls := 0
+	handler := Idempotent(NewIdempotencyCache(time.Minute, 10), countingHandler(&calls, http.StatusOK))
+
+	first := idempotentRequest(handler, "/api/lightbulb/toggle", "abc", `{"on": true}`)
+	replay := idempotentRequest(handler, "/api/lightbulb/toggle", "abc", `{"on": true}`)
+
+	if calls != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", calls)
+	}
+	if replay.Code != first.Code || replay.Body.String() != first.Body.String() {
+		t.Errorf("expected the replay to match the first response, got %d %s vs %d %s", replay.Code, replay.Body, first.Code, first.Body)
+	}
+	if replay.Header().Get(ReplayedHeader) != "true" || first.Header().Get(ReplayedHeader) != "" {
+		t.Error("expected only the replay to be marked Idempotent-Replayed")
+	}
+	if replay.Header().Get("Content-Type") != "application/json" {
+		t.Error("expected the replay to keep the response headers")
+	}
+	if first.Body.String() != `{"call": 1, "body": "{\"on\": true}"}` {
+		t.Errorf("expected the handler to see the full body, got %s", first.Body)
+	}
+}
+
+func TestIdempotent_ScopesAndMismatches(t *testing.T) {
+	calls := 0
+	handler := Idempotent(NewIdempotencyCache(time.Minute, 10), countingHandler(&calls, http.StatusOK))
+
+	idempotentRequest(handler, "/api/govee/devices/control", "abc", `{"command": "turn"}`)
+
+	// No key: always runs
+	idempotentRequest(handler, "/api/govee/devices/control", "", `{"command": "turn"}`)
+	// Same key on another endpoint: runs
+	idempotentRequest(handler, "/api/firetv/command", "abc", `{"command": "turn"}`)
+	if calls != 3 {
+		t.Errorf("expected 3 handler runs, got %d", calls)
+	}
+
+	// Same key and endpoint, different body
+	if w := idempotentRequest(handler, "/api/govee/devices/control", "abc", `{"command": "color"}`); w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), `"code":"IDEMPOTENCY_KEY_REUSED"`) {
+		t.Errorf("expected a 422 IDEMPOTENCY_KEY_REUSED for a reused key, got %d: %s", w.Code, w.Body.String())
+	}
+	if calls != 3 {
+		t.Errorf("expected a mismatched key not to run the handler, got %d runs", calls)
+	}
+}
+
+func TestIdempotent_DoesNotCacheServerErrors(t *testing.T) {
+	calls := 0
+	handler := Idempotent(NewIdempotencyCache(time.Minute, 10), countingHandler(&calls, http.StatusBadGateway))
+
+	idempotentRequest(handler, "/api/govee/devices/control", "abc", "{}")
+	idempotentRequest(handler, "/api/govee/devices/control", "abc", "{}")
+	if calls != 2 {
+		t.Errorf("expected a 5xx to be retried, handler ran %d times", calls)
+	}
+}
+
+func TestIdempotent_ForgetsKeyWhenHandlerPanics(t *testing.T) {
+	calls := 0
+	handler := Idempotent(NewIdempotencyCache(time.Minute, 10), func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	func() {
+		defer func() { recover() }() // Recover would turn this into a 500
+		idempotentRequest(handler, "/api/lightbulb/toggle", "abc", `{}`)
+	}()
+
+	retry := idempotentRequest(handler, "/api/lightbulb/toggle", "abc", `{}`)
+	if retry.Code != http.StatusOK || calls != 2 {
+		t.Errorf("expected the retry to run the handler again, got %d after %d call(s)", retry.Code, calls)
+	}
+}
+
+func TestIdempotent_InFlightDuplicate(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := Idempotent(NewIdempotencyCache(time.Minute, 10), func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	done := make(chan struct{})
+	go func() {
+		idempotentRequest(handler, "/api/lightbulb/toggle", "abc", "")
+		close(done)
+	}()
+	<-started
+
+	if w := idempotentRequest(handler, "/api/lightbulb/toggle", "abc", ""); w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 while the first request runs, got %d", w.Code)
+	}
+	close(release)
+	<-done
+}
+
+func TestIdempotencyCache_ExpiresAndEvicts(t *testing.T) {
+	calls := 0
+	cache := NewIdempotencyCache(time.Minute, 2)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	handler := Idempotent(cache, countingHandler(&calls, http.StatusOK))
+
+	idempotentRequest(handler, "/api/x", "a", "")
+	now = now.Add(2 * time.Minute)
+	idempotentRequest(handler, "/api/x", "a", "")
+	if calls != 2 {
+		t.Fatalf("expected an expired key to run again, got %d runs", calls)
+	}
+
+	// "a" is the oldest of three keys in a cache of two, so it's evicted
+	idempotentRequest(handler, "/api/x", "b", "")
+	idempotentRequest(handler, "/api/x", "c", "")
+	idempotentRequest(handler, "/api/x", "a", "")
+	if calls != 5 {
+		t.Errorf("expected the evicted key to run again, got %d runs", calls)
+	}
+	idempotentRequest(handler, "/api/x", "c", "")
+	if calls != 5 {
+		t.Errorf("expected a cached key to replay, got %d runs", calls)
+	}
+}
+
+func TestIdempotent_DisabledAndInvalidKeys(t *testing.T) {
+	calls := 0
+	if NewIdempotencyCache(0, 10) != nil {
+		t.Fatal("expected a zero TTL to disable the cache")
+	}
+	disabled := Idempotent(nil, countingHandler(&calls, http.StatusOK))
+	idempotentRequest(disabled, "/api/x", "a", "")
+	idempotentRequest(disabled, "/api/x", "a", "")
+	if calls != 2 {
+		t.Errorf("expected keys to be ignored when disabled, got %d runs", calls)
+	}
+
+	handler := Idempotent(NewIdempotencyCache(time.Minute, 10), countingHandler(&calls, http.StatusOK))
+	if w := idempotentRequest(handler, "/api/x", string(bytes.Repeat([]byte("k"), 256)), ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an overlong key, got %d", w.Code)
+	}
+}
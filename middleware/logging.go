@@ -1,20 +1,33 @@
 package middleware
 
 import (
+	"io"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
 // responseWriter wraps http.ResponseWriter to capture the status code
+// and, when body logging is enabled, a capped copy of the response body
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	body       *cappedBuffer // nil unless the response body is being logged
 }
 
 // newResponseWriter creates a new responseWriter
 func newResponseWriter(w http.ResponseWriter) *responseWriter {
-	return &responseWriter{w, http.StatusOK}
+	return &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+}
+
+// Write tees the response body into the capture buffer (if any) and
+// writes it to the client
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.body != nil {
+		rw.body.Write(b)
+	}
+	return rw.ResponseWriter.Write(b)
 }
 
 // WriteHeader captures the status code and writes the header
@@ -23,27 +36,69 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap exposes the underlying ResponseWriter so http.ResponseController
+// can reach optional interfaces like http.Flusher (needed for SSE streams)
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // RequestLogger is middleware that logs HTTP requests
 // It logs the method, path, status code, and duration of each request
+// The client address comes from ClientIP, so it reflects the real client
+// (not the reverse proxy) when RealIP runs before this middleware, and the
+// request ID from RequestIDFromContext when RequestID does
 func RequestLogger(next http.Handler) http.Handler {
+	return requestLogger(next, nil)
+}
+
+// RequestBodyLogger is RequestLogger plus request and response bodies for
+// paths under opts.PathPrefix. Meant for debugging client payload issues
+// (LOG_BODIES=true): bodies are redacted and truncated, see BodyLogOptions.
+func RequestBodyLogger(next http.Handler, opts BodyLogOptions) http.Handler {
+	return requestLogger(next, &opts)
+}
+
+// requestLogger implements both loggers; bodyOpts is nil when bodies
+// shouldn't be logged
+func requestLogger(next http.Handler, bodyOpts *BodyLogOptions) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
 		// Wrap the response writer to capture status code
 		wrapped := newResponseWriter(w)
 
+		// Tee both bodies into capped buffers as they're read and written,
+		// so handlers see the original streams untouched
+		logBodies := bodyOpts != nil && strings.HasPrefix(r.URL.Path, bodyOpts.PathPrefix)
+		var requestBody *cappedBuffer
+		if logBodies {
+			requestBody = newCappedBuffer(bodyCaptureLimit)
+			r.Body = teeReadCloser{io.TeeReader(r.Body, requestBody), r.Body}
+			wrapped.body = newCappedBuffer(bodyCaptureLimit)
+		}
+
 		// Call the next handler
 		next.ServeHTTP(wrapped, r)
 
 		// Log the request details
 		duration := time.Since(start)
+		requestID := ""
+		if id := RequestIDFromContext(r.Context()); id != "" {
+			requestID = " - Request ID: " + id
+		}
 		log.Printf(
-			"%s %s - Status: %d - Duration: %v - Client: %s",
+			"%s %s - Status: %d - Duration: %v - Client: %s%s",
 			r.Method,
 			r.URL.Path,
 			wrapped.statusCode,
 			duration,
-			r.RemoteAddr,
+			ClientIP(r),
+			requestID,
 		)
+
+		if logBodies {
+			log.Printf("   ↳ Request body: %s", formatBody(requestBody, bodyOpts.MaxBytes))
+			log.Printf("   ↳ Response body: %s", formatBody(wrapped.body, bodyOpts.MaxBytes))
+		}
 	})
 }
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/pantheon/artemis/errcode"
+)
+
+// Recover turns a panic in next into a 500 INTERNAL_ERROR response, logging
+// it with the stack trace, so one bad handler fails its own request instead
+// of the connection (or, in a handler's own goroutine, the process).
+//
+// Recover is meant to be the outermost middleware, so a panic in any other
+// middleware is caught too. Since CORS and RequestID may not have run (or
+// their headers may be all that's left), the 500 sets the CORS headers
+// itself and carries the request's X-Request-ID, assigning one if needed.
+//
+// http.ErrAbortHandler is re-raised: it's how a handler deliberately aborts
+// a response. A panic after the response has started can't change its
+// status; the client just gets what was written so far.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			// RequestID set the header before calling in, if it got that far
+			id := w.Header().Get(RequestIDHeader)
+			if id == "" {
+				id = newRequestID()
+				w.Header().Set(RequestIDHeader, id)
+			}
+			setCORSResponseHeaders(w.Header())
+
+			log.Printf("❌ Panic serving %s %s from %s (request ID %q): %v\n%s", r.Method, r.URL.Path, ClientIP(r), id, recovered, debug.Stack())
+			writeErrorCode(w, http.StatusInternalServerError, errcode.Internal, "Internal server error")
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
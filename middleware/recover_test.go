@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		var counts map[string]int
+		counts["requests"]++ // nil map
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(Recover(mux))
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL + "/panic")
+	if err != nil {
+		t.Fatalf("expected a response, got %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError || !strings.Contains(string(body), `"code":"INTERNAL_ERROR"`) {
+		t.Errorf("expected a 500 INTERNAL_ERROR, got %d %s", resp.StatusCode, body)
+	}
+
+	// The server keeps serving
+	resp, err = http.Get(server.URL + "/ok")
+	if err != nil {
+		t.Fatalf("expected the server to still answer, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected status 204 after the panic, got %d", resp.StatusCode)
+	}
+}
+
+func TestRecover_OutermostSetsCORSAndRequestID(t *testing.T) {
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+
+	// Wrapped as in main.go: Recover outside RequestID and CORS
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	Recover(RequestID(CORS(panicking))).ServeHTTP(w, req)
+	if w.Code != http.StatusInternalServerError || w.Header().Get(RequestIDHeader) != "req-123" || w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("expected a 500 with the request's ID and CORS headers, got %d %v", w.Code, w.Header())
+	}
+
+	// A panic before RequestID ran still gets an ID and CORS headers
+	w = httptest.NewRecorder()
+	Recover(panicking).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	if w.Header().Get(RequestIDHeader) == "" || w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("expected Recover to set the request ID and CORS headers, got %v", w.Header())
+	}
+}
+
+func TestRecover_AbortHandler(t *testing.T) {
+	handler := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("expected ErrAbortHandler to be re-raised, got %v", recovered)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
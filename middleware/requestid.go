@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+)
+
+// RequestIDHeader carries the request ID, in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength caps an incoming X-Request-ID; longer ones are replaced.
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key RequestID stores the ID under.
+type requestIDKey struct{}
+
+// RequestID gives every request an ID for correlating its log lines: the
+// client's X-Request-ID if it sent a usable one (e.g., from a proxy or the
+// app, so its own logs match), otherwise a random UUID. The ID is stored in
+// the request context (see RequestIDFromContext) and echoed in the
+// X-Request-ID response header.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext returns the ID RequestID assigned to the request ctx
+// belongs to, or "" if RequestID hasn't run.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID reports whether a client-supplied ID is safe to reuse:
+// non-empty, not too long, and printable ASCII without spaces, so it can't
+// break up a log line.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random UUID v4 string.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	// Set version (4) and variant (RFC 4122) bits
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%s-%s-%s-%s-%s",
+		hex.EncodeToString(b[0:4]),
+		hex.EncodeToString(b[4:6]),
+		hex.EncodeToString(b[6:8]),
+		hex.EncodeToString(b[8:10]),
+		hex.EncodeToString(b[10:16]),
+	)
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// serveWithRequestID runs RequestID around a handler that records the ID it
+// saw in the context, and returns that ID and the response header.
+func serveWithRequestID(t *testing.T, incoming string) (seen, header string) {
+	t.Helper()
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	if incoming != "" {
+		req.Header.Set(RequestIDHeader, incoming)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return seen, w.Header().Get(RequestIDHeader)
+}
+
+func TestRequestID_Generated(t *testing.T) {
+	seen, header := serveWithRequestID(t, "")
+	if !uuidPattern.MatchString(header) {
+		t.Fatalf("expected a UUID in the response header, got %q", header)
+	}
+	if seen != header {
+		t.Errorf("expected the handler to see the header's ID %q, got %q", header, seen)
+	}
+
+	if _, other := serveWithRequestID(t, ""); other == header {
+		t.Errorf("expected a new ID per request, got %q twice", header)
+	}
+}
+
+func TestRequestID_Incoming(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		reused   bool
+	}{
+		{"reused", "app-7f3a9c", true},
+		{"with a space", "two words", false},
+		{"with a newline", "abc\nFAKE LOG LINE", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen, header := serveWithRequestID(t, tt.incoming)
+			if seen != header {
+				t.Errorf("expected the handler to see the header's ID %q, got %q", header, seen)
+			}
+			if tt.reused && header != tt.incoming {
+				t.Errorf("expected the incoming ID to be reused, got %q", header)
+			}
+			if !tt.reused && !uuidPattern.MatchString(header) {
+				t.Errorf("expected the incoming ID to be replaced by a UUID, got %q", header)
+			}
+		})
+	}
+}
+
+func TestRequestLogger_LogsRequestID(t *testing.T) {
+	logs := captureLogs(t)
+
+	handler := RequestID(RequestLogger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	req.Header.Set(RequestIDHeader, "app-7f3a9c")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !strings.Contains(logs.String(), "Request ID: app-7f3a9c") {
+		t.Errorf("expected the request ID in the log line, got %q", logs.String())
+	}
+}
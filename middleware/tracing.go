@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pantheon/artemis/tracing"
+)
+
+// Tracing is middleware that starts a root span for each request (or
+// continues the caller's trace when it sends a traceparent header) and puts
+// it in the request context, so client calls made by the handler show up as
+// child spans.
+//
+// The span is named after the matched route pattern (e.g.,
+// "GET /api/device/{id}") rather than the raw path, which keeps IDs out of
+// span names. Middleware between Tracing and the mux that replaces the
+// request must copy its Pattern back, as RequestDeadline does.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+r.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("client.address", ClientIP(r)),
+			),
+		)
+		defer span.End()
+
+		// Wrap the response writer to capture status code
+		wrapped := newResponseWriter(w)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(wrapped, r)
+
+		// ServeMux records the matched pattern on the request it was given
+		if r.Pattern != "" {
+			name := r.Pattern
+			if !strings.Contains(name, " ") {
+				name = r.Method + " " + name // pattern registered without a method
+			}
+			span.SetName(name)
+			span.SetAttributes(attribute.String("http.route", r.Pattern))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", wrapped.statusCode))
+		if wrapped.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(wrapped.statusCode))
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/pantheon/artemis/tracing"
+)
+
+// recordSpans installs a tracer provider that keeps finished spans in memory
+// and restores the previous globals when the test ends.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
+
+func TestTracing_ClientCallIsChildOfRequestSpan(t *testing.T) {
+	recorder := recordSpans(t)
+
+	var upstreamTraceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: tracing.NewTransport()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/device/{id}", func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream.URL+"/state", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("upstream request failed: %v", err)
+			return
+		}
+		resp.Body.Close()
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	rec := httptest.NewRecorder()
+	Tracing(mux).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/device/42", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans (client + server), got %d", len(spans))
+	}
+	clientSpan, serverSpan := spans[0], spans[1]
+
+	if serverSpan.Name() != "GET /api/device/{id}" {
+		t.Errorf("expected server span named after the route, got %q", serverSpan.Name())
+	}
+	if !hasAttribute(serverSpan.Attributes(), attribute.Int("http.response.status_code", http.StatusAccepted)) {
+		t.Errorf("expected status code attribute on server span, got %v", serverSpan.Attributes())
+	}
+	if clientSpan.Parent().SpanID() != serverSpan.SpanContext().SpanID() {
+		t.Error("expected the client span to be a child of the request span")
+	}
+	if upstreamTraceparent == "" {
+		t.Error("expected the outbound request to carry a traceparent header")
+	}
+}
+
+func TestTracing_NamesSpanThroughRequestDeadline(t *testing.T) {
+	recorder := recordSpans(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/device/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/device/42", nil)
+	req.Header.Set(RequestTimeoutHeader, "1000")
+	Tracing(RequestDeadline(mux, time.Second)).ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "GET /api/device/{id}" {
+		t.Fatalf("expected one span named after the route, got %d (%v)", len(spans), spans)
+	}
+}
+
+func TestTracing_ContinuesIncomingTrace(t *testing.T) {
+	recorder := recordSpans(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	Tracing(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the caller's trace ID, got %s", got)
+	}
+}
+
+// hasAttribute reports whether attrs contains want with the same value.
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,352 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/pantheon/artemis/govee"
+)
+
+// Connection settings for the broker.
+const (
+	connectTimeout = 10 * time.Second
+
+	// QoS 1 (at least once) for both commands and state. Commands are
+	// idempotent ("turn on" twice is still on), so duplicates are harmless.
+	qos = 1
+)
+
+// Config holds the settings needed to connect the bridge to a broker.
+type Config struct {
+	BrokerURL     string        // e.g., "tcp://192.168.1.10:1883"
+	ClientID      string        // Must be unique per broker
+	Username      string        // Optional
+	Password      string        // Optional
+	TopicPrefix   string        // Root of every topic (e.g., "artemis")
+	StateInterval time.Duration // How often device state is published
+}
+
+// CommandPayload is the JSON body accepted on a device's command topic.
+// It mirrors the HTTP control request, so the same commands and values work:
+//
+//	{"command": "turn", "value": true}
+//	{"command": "brightness", "value": 75}
+//	{"command": "color", "value": {"r": 255, "g": 120, "b": 0}}
+//	{"command": "colorTem", "value": 4500}
+//
+// Model and APIKeyIndex are optional — the bridge looks them up from the
+// device list. The plain payloads "ON" and "OFF" (Home Assistant's default
+// for lights) are accepted as shorthand for the "turn" command.
+type CommandPayload struct {
+	Command     string      `json:"command"`
+	Value       interface{} `json:"value"`
+	Model       string      `json:"model,omitempty"`
+	APIKeyIndex *int        `json:"apiKeyIndex,omitempty"`
+
+	// Optional fade duration in milliseconds for "brightness" and "color".
+	TransitionMs int `json:"transitionMs,omitempty"`
+}
+
+// deviceRef is what the bridge needs to route a command for a device ID.
+type deviceRef struct {
+	model       string
+	apiKeyIndex int
+}
+
+// Bridge connects Govee devices to an MQTT broker for Home Assistant and
+// other home-automation systems.
+//
+// Topics (with the default "artemis" prefix):
+// - artemis/govee/<deviceId>/set   — commands in (see CommandPayload)
+// - artemis/govee/<deviceId>/state — retained JSON govee.DeviceState out
+// - artemis/status                 — "online" / "offline" (last will)
+//
+// Commands go through govee.ExecuteCommand, the same control path as the
+// HTTP endpoint. State comes from the shared state poller cache, so the
+// bridge never reads device state itself (beyond one refresh after each
+// command) and stays inside Govee's rate limit.
+type Bridge struct {
+	cfg         Config
+	clients     []*govee.Client
+	statePoller *govee.StatePoller
+	retryQueue  *govee.RetryQueue // Optional; see SetRetryQueue
+
+	// publish sends a message to the broker. Set by Start; tests replace it
+	// to capture outgoing messages without a real broker.
+	publish func(topic string, retained bool, payload []byte)
+
+	mu      sync.RWMutex
+	devices map[string]deviceRef // Keyed by device ID (MAC address)
+}
+
+// NewBridge creates a bridge for the given Govee clients.
+// statePoller must be running — it is the source of published state.
+func NewBridge(cfg Config, clients []*govee.Client, statePoller *govee.StatePoller) *Bridge {
+	if cfg.TopicPrefix == "" {
+		cfg.TopicPrefix = "artemis"
+	}
+	if cfg.StateInterval <= 0 {
+		cfg.StateInterval = 30 * time.Second
+	}
+	return &Bridge{
+		cfg:         cfg,
+		clients:     clients,
+		statePoller: statePoller,
+		devices:     make(map[string]deviceRef),
+	}
+}
+
+// SetRetryQueue makes the bridge drop any retry still queued for a device
+// once one of its commands succeeds, so the older command isn't replayed
+// over it. Call before Start.
+func (b *Bridge) SetRetryQueue(q *govee.RetryQueue) {
+	b.retryQueue = q
+}
+
+// Start connects to the broker, subscribes to the command topics, and begins
+// publishing state in a background goroutine until ctx is cancelled.
+// Returns an error only if the initial connection fails.
+func (b *Bridge) Start(ctx context.Context) error {
+	statusTopic := b.cfg.TopicPrefix + "/status"
+
+	opts := paho.NewClientOptions().
+		AddBroker(b.cfg.BrokerURL).
+		SetClientID(b.cfg.ClientID).
+		SetUsername(b.cfg.Username).
+		SetPassword(b.cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectTimeout(connectTimeout).
+		// Tell subscribers we're gone if the connection drops unexpectedly.
+		SetWill(statusTopic, "offline", qos, true)
+
+	// (Re)subscribe on every connect — subscriptions don't survive a
+	// reconnect with a clean session.
+	opts.SetOnConnectHandler(func(client paho.Client) {
+		commandTopic := b.cfg.TopicPrefix + "/govee/+/set"
+		token := client.Subscribe(commandTopic, qos, func(_ paho.Client, msg paho.Message) {
+			if err := b.handleCommand(ctx, msg.Topic(), msg.Payload()); err != nil {
+				log.Printf("❌ MQTT command on %s failed: %v", msg.Topic(), err)
+			}
+		})
+		if token.Wait() && token.Error() != nil {
+			log.Printf("❌ MQTT subscribe to %s failed: %v", commandTopic, token.Error())
+			return
+		}
+		client.Publish(statusTopic, qos, true, "online")
+		log.Printf("📨 MQTT bridge connected to %s (listening on %s)", b.cfg.BrokerURL, commandTopic)
+	})
+	opts.SetConnectionLostHandler(func(_ paho.Client, err error) {
+		log.Printf("⚠️  MQTT connection lost: %v (reconnecting)", err)
+	})
+
+	client := paho.NewClient(opts)
+	b.publish = func(topic string, retained bool, payload []byte) {
+		client.Publish(topic, qos, retained, payload)
+	}
+
+	token := client.Connect()
+	if !token.WaitTimeout(connectTimeout) {
+		return fmt.Errorf("timed out connecting to MQTT broker %s", b.cfg.BrokerURL)
+	}
+	if token.Error() != nil {
+		return fmt.Errorf("failed to connect to MQTT broker: %w", token.Error())
+	}
+
+	go func() {
+		ticker := time.NewTicker(b.cfg.StateInterval)
+		defer ticker.Stop()
+
+		b.refreshDevices(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				client.Publish(statusTopic, qos, true, "offline").WaitTimeout(time.Second)
+				client.Disconnect(250)
+				log.Printf("📨 MQTT bridge stopped")
+				return
+			case <-ticker.C:
+				b.refreshDevices(ctx)
+				b.publishStates()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// refreshDevices rebuilds the device ID -> model/account index used to route
+// commands, so payloads only need to name the device. It reads each
+// client's cached device list (see govee.Client.GetDevicesCached), shared
+// with the HTTP endpoints, so it doesn't spend Govee's rate limit on every
+// state interval.
+func (b *Bridge) refreshDevices(ctx context.Context) {
+	devices := make(map[string]deviceRef)
+	for apiKeyIndex, client := range b.clients {
+		list, err := client.GetDevicesCached(ctx)
+		if err != nil {
+			log.Printf("⚠️  MQTT bridge: failed to list devices for API key #%d: %v", apiKeyIndex, err)
+			continue
+		}
+		for _, device := range list {
+			devices[device.Device] = deviceRef{model: device.Model, apiKeyIndex: apiKeyIndex}
+		}
+	}
+
+	b.mu.Lock()
+	b.devices = devices
+	b.mu.Unlock()
+}
+
+// publishStates publishes every cached device state as a retained message.
+func (b *Bridge) publishStates() {
+	for _, state := range b.statePoller.States() {
+		b.publishState(state)
+	}
+}
+
+// publishState publishes one device's state to its state topic.
+func (b *Bridge) publishState(state govee.DeviceState) {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("❌ Error encoding MQTT state for %s: %v", state.DeviceID, err)
+		return
+	}
+	b.publish(b.stateTopic(state.DeviceID), true, payload)
+}
+
+// handleCommand parses a message from a command topic and executes it.
+func (b *Bridge) handleCommand(ctx context.Context, topic string, payload []byte) error {
+	deviceID, ok := b.deviceIDFromTopic(topic)
+	if !ok {
+		return fmt.Errorf("unexpected topic %q", topic)
+	}
+
+	cmd, err := parseCommandPayload(payload)
+	if err != nil {
+		return err
+	}
+
+	// Fill in the model and account from the device list when not given.
+	b.mu.RLock()
+	ref, known := b.devices[deviceID]
+	b.mu.RUnlock()
+
+	apiKeyIndex := ref.apiKeyIndex
+	if cmd.APIKeyIndex != nil {
+		apiKeyIndex = *cmd.APIKeyIndex
+	}
+	if apiKeyIndex < 0 || apiKeyIndex >= len(b.clients) {
+		return fmt.Errorf("invalid API key index: %d", apiKeyIndex)
+	}
+	client := b.clients[apiKeyIndex]
+
+	// Same checks as the HTTP control endpoint (GOVEE_DEVICE_CHECK): the
+	// device must be listed, with the given model, and support the command
+	model := cmd.Model
+	if client.DeviceCheck() {
+		device, err := checkCommand(ctx, client, apiKeyIndex, deviceID, cmd)
+		if err != nil {
+			return err
+		}
+		if device != nil {
+			deviceID = device.Device
+			if model == "" {
+				model = device.Model
+			}
+		}
+	}
+	if model == "" {
+		if !known {
+			return fmt.Errorf("unknown device %s (include \"model\" in the payload)", deviceID)
+		}
+		model = ref.model
+	}
+
+	log.Printf("📨 MQTT command - Device: %s, Command: %s, API Key Index: %d", deviceID, cmd.Command, apiKeyIndex)
+
+	if err := govee.ExecuteCommand(ctx, client, deviceID, model, cmd.Command, cmd.Value,
+		time.Duration(cmd.TransitionMs)*time.Millisecond); err != nil {
+		return err
+	}
+
+	log.Printf("✅ MQTT command successful - Device: %s, Command: %s", deviceID, cmd.Command)
+	b.retryQueue.Forget(apiKeyIndex, deviceID)
+
+	// Publish the new state right away so subscribers don't wait for the
+	// next interval. Runs in the background so the MQTT handler isn't held up.
+	go func() {
+		state, err := b.statePoller.Refresh(ctx, apiKeyIndex, deviceID, model)
+		if err != nil {
+			log.Printf("⚠️  MQTT bridge: failed to refresh %s after command: %v", deviceID, err)
+			return
+		}
+		b.publishState(state)
+	}()
+
+	return nil
+}
+
+// checkCommand looks deviceID up in the account's cached device list,
+// ignoring case and separators, and checks the command against it (see
+// govee.CheckDeviceCommand). Returns the listed device. When the list can't
+// be loaded the check is skipped: it returns nil and no error, and the
+// command is sent as given.
+func checkCommand(ctx context.Context, client *govee.Client, apiKeyIndex int, deviceID string, cmd CommandPayload) (*govee.Device, error) {
+	devices, err := client.GetDevicesCached(ctx)
+	if err != nil {
+		log.Printf("⚠️  MQTT bridge: couldn't check %s against API key #%d's device list: %v", deviceID, apiKeyIndex, err)
+		return nil, nil
+	}
+	for _, device := range devices {
+		if govee.SameDeviceID(device.Device, deviceID) {
+			return &device, govee.CheckDeviceCommand(device, cmd.Model, cmd.Command)
+		}
+	}
+	return nil, fmt.Errorf("device %s not found in account %d", deviceID, apiKeyIndex)
+}
+
+// parseCommandPayload decodes a command message. Besides the JSON form,
+// it accepts bare "ON"/"OFF" (case-insensitive) as a turn command.
+func parseCommandPayload(payload []byte) (CommandPayload, error) {
+	trimmed := strings.TrimSpace(string(payload))
+	switch strings.ToUpper(trimmed) {
+	case "ON":
+		return CommandPayload{Command: "turn", Value: true}, nil
+	case "OFF":
+		return CommandPayload{Command: "turn", Value: false}, nil
+	}
+
+	var cmd CommandPayload
+	if err := json.Unmarshal([]byte(trimmed), &cmd); err != nil {
+		return CommandPayload{}, fmt.Errorf("invalid command payload: %w", err)
+	}
+	if cmd.Command == "" {
+		return CommandPayload{}, fmt.Errorf("command payload is missing \"command\"")
+	}
+	return cmd, nil
+}
+
+// deviceIDFromTopic extracts the device ID from <prefix>/govee/<deviceId>/set.
+func (b *Bridge) deviceIDFromTopic(topic string) (string, bool) {
+	rest, ok := strings.CutPrefix(topic, b.cfg.TopicPrefix+"/govee/")
+	if !ok {
+		return "", false
+	}
+	deviceID, ok := strings.CutSuffix(rest, "/set")
+	if !ok || deviceID == "" || strings.Contains(deviceID, "/") {
+		return "", false
+	}
+	return deviceID, true
+}
+
+// stateTopic returns the topic a device's state is published to.
+func (b *Bridge) stateTopic(deviceID string) string {
+	return b.cfg.TopicPrefix + "/govee/" + deviceID + "/state"
+}
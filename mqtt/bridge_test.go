@@ -0,0 +1,180 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/govee"
+)
+
+// stubGovee is a fake Govee API that records control commands and serves a
+// fixed device list and state.
+type stubGovee struct {
+	mu       sync.Mutex
+	controls []string // Raw control request bodies, in order
+}
+
+func (s *stubGovee) commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.controls...)
+}
+
+// newTestBridge builds a bridge backed by a stub Govee API, with outgoing
+// MQTT messages captured on the returned channel instead of a real broker.
+func newTestBridge(t *testing.T) (*Bridge, *stubGovee, chan string) {
+	t.Helper()
+	stub := &stubGovee{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/devices/control"):
+			body, _ := io.ReadAll(r.Body)
+			stub.mu.Lock()
+			stub.controls = append(stub.controls, string(body))
+			stub.mu.Unlock()
+			w.Write([]byte(`{"code": 200, "message": "Success"}`))
+		case strings.HasSuffix(r.URL.Path, "/devices/state"):
+			w.Write([]byte(`{"code": 200, "message": "Success", "data": {
+				"device": "AA:BB", "model": "H6008",
+				"properties": [{"online": true}, {"powerState": "on"}, {"brightness": 40}]
+			}}`))
+		default:
+			w.Write([]byte(`{"code": 200, "message": "Success", "data": {"devices": [
+				{"device": "AA:BB", "model": "H6008", "deviceName": "Desk Lamp", "retrievable": true,
+				 "supportCmds": ["turn", "brightness", "color"]}
+			]}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(server.URL)
+	clients := []*govee.Client{client}
+
+	bridge := NewBridge(Config{TopicPrefix: "artemis"}, clients, govee.NewStatePoller(clients, time.Minute, 0))
+	published := make(chan string, 10)
+	bridge.publish = func(topic string, retained bool, payload []byte) {
+		published <- topic + " " + string(payload)
+	}
+	bridge.refreshDevices(context.Background())
+
+	return bridge, stub, published
+}
+
+func TestHandleCommand_RoutesThroughControlPath(t *testing.T) {
+	bridge, stub, published := newTestBridge(t)
+
+	err := bridge.handleCommand(context.Background(), "artemis/govee/AA:BB/set", []byte(`{"command": "brightness", "value": 75}`))
+	if err != nil {
+		t.Fatalf("handleCommand returned error: %v", err)
+	}
+
+	commands := stub.commands()
+	if len(commands) != 1 {
+		t.Fatalf("expected 1 control call, got %d", len(commands))
+	}
+	var sent govee.ControlRequest
+	if err := json.Unmarshal([]byte(commands[0]), &sent); err != nil {
+		t.Fatalf("failed to decode control body: %v", err)
+	}
+	if sent.Device != "AA:BB" || sent.Model != "H6008" || sent.Cmd.Name != "brightness" {
+		t.Errorf("unexpected control request %+v (model should come from the device list)", sent)
+	}
+
+	// The fresh state is published right after the command.
+	select {
+	case msg := <-published:
+		if !strings.HasPrefix(msg, "artemis/govee/AA:BB/state ") || !strings.Contains(msg, `"brightness":40`) {
+			t.Errorf("unexpected state message %q", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected state to be published after the command")
+	}
+}
+
+func TestHandleCommand_AcceptsOnOffShorthand(t *testing.T) {
+	bridge, stub, _ := newTestBridge(t)
+
+	if err := bridge.handleCommand(context.Background(), "artemis/govee/AA:BB/set", []byte("OFF")); err != nil {
+		t.Fatalf("handleCommand returned error: %v", err)
+	}
+
+	commands := stub.commands()
+	if len(commands) != 1 || !strings.Contains(commands[0], `"value":"off"`) {
+		t.Errorf("expected a turn-off control call, got %v", commands)
+	}
+}
+
+func TestHandleCommand_RejectsInvalidInput(t *testing.T) {
+	bridge, stub, _ := newTestBridge(t)
+
+	tests := []struct {
+		name    string
+		topic   string
+		payload string
+	}{
+		{"unknown device without model", "artemis/govee/FF:FF/set", `{"command": "turn", "value": true}`},
+		{"malformed JSON", "artemis/govee/AA:BB/set", `{"command":`},
+		{"missing command", "artemis/govee/AA:BB/set", `{"value": true}`},
+		{"wrong value type", "artemis/govee/AA:BB/set", `{"command": "turn", "value": "yes"}`},
+		{"unknown command", "artemis/govee/AA:BB/set", `{"command": "dance"}`},
+		{"bad API key index", "artemis/govee/AA:BB/set", `{"command": "turn", "value": true, "apiKeyIndex": 3}`},
+		{"unlisted device with model", "artemis/govee/FF:FF/set", `{"command": "turn", "value": true, "model": "H6008"}`},
+		{"wrong model", "artemis/govee/AA:BB/set", `{"command": "turn", "value": true, "model": "H6199"}`},
+		{"unsupported command", "artemis/govee/AA:BB/set", `{"command": "colorTem", "value": 4000}`},
+		{"wrong topic", "artemis/govee/AA:BB/state", `ON`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := bridge.handleCommand(context.Background(), tt.topic, []byte(tt.payload)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	if calls := stub.commands(); len(calls) != 0 {
+		t.Errorf("expected no control calls for invalid input, got %d", len(calls))
+	}
+}
+
+func TestHandleCommand_DeviceCheck(t *testing.T) {
+	bridge, stub, _ := newTestBridge(t)
+
+	// IDs in any case or separator style reach Govee in the listed form
+	if err := bridge.handleCommand(context.Background(), "artemis/govee/aa-bb/set", []byte("ON")); err != nil {
+		t.Fatalf("handleCommand returned error: %v", err)
+	}
+	if commands := stub.commands(); len(commands) != 1 || !strings.Contains(commands[0], `"device":"AA:BB"`) {
+		t.Errorf("expected the listed device ID to be sent, got %v", commands)
+	}
+
+	// With GOVEE_DEVICE_CHECK off, an unlisted device with a model is sent as given
+	bridge.clients[0].SetDeviceCheck(false)
+	if err := bridge.handleCommand(context.Background(), "artemis/govee/FF:FF/set", []byte(`{"command": "turn", "value": true, "model": "H6008"}`)); err != nil {
+		t.Fatalf("expected an unchecked command to be sent, got %v", err)
+	}
+	if commands := stub.commands(); len(commands) != 2 || !strings.Contains(commands[1], `"device":"FF:FF"`) {
+		t.Errorf("expected the unlisted device to be sent, got %v", commands)
+	}
+}
+
+func TestDeviceIDFromTopic(t *testing.T) {
+	bridge := NewBridge(Config{TopicPrefix: "home/artemis"}, nil, nil)
+
+	if id, ok := bridge.deviceIDFromTopic("home/artemis/govee/AA:BB:CC/set"); !ok || id != "AA:BB:CC" {
+		t.Errorf("expected AA:BB:CC, got %q (ok=%v)", id, ok)
+	}
+	for _, topic := range []string{"artemis/govee/AA:BB/set", "home/artemis/govee//set", "home/artemis/govee/a/b/set"} {
+		if _, ok := bridge.deviceIDFromTopic(topic); ok {
+			t.Errorf("expected %q to be rejected", topic)
+		}
+	}
+}
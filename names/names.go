@@ -0,0 +1,38 @@
+// Package names validates the user-chosen names of rooms, presets, and
+// macros, so every store accepts the same characters and a name that saves
+// in one place can be used in another (an MQTT topic, a Siri phrase).
+package names
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxLength is the longest name accepted, in characters.
+const MaxLength = 64
+
+// punctuation lists the non-alphanumeric characters allowed in a name,
+// besides spaces.
+const punctuation = "-_'.&()#,!+"
+
+// Validate reports why name can't be used: it is blank, longer than
+// MaxLength, or has a character other than a letter, digit, space, or one of
+// - _ ' . & ( ) # , ! +. Surrounding spaces are ignored; callers trim them
+// before saving.
+func Validate(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len([]rune(name)) > MaxLength {
+		return fmt.Errorf("name must be at most %d characters", MaxLength)
+	}
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' || strings.ContainsRune(punctuation, r) {
+			continue
+		}
+		return fmt.Errorf("name must not contain %q", r)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package names
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := []string{"Living Room", "Kid's Room #2", "Küche", "Movie (Night)", "  Reading  ", strings.Repeat("a", MaxLength)}
+	for _, name := range valid {
+		if err := Validate(name); err != nil {
+			t.Errorf("Validate(%q) = %v, want nil", name, err)
+		}
+	}
+
+	invalid := []string{"", "   ", strings.Repeat("a", MaxLength+1), "a/b", "tab\there", "new\nline", "<script>", "50%"}
+	for _, name := range invalid {
+		if err := Validate(name); err == nil {
+			t.Errorf("Validate(%q) = nil, want an error", name)
+		}
+	}
+}
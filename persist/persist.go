@@ -0,0 +1,80 @@
+// Package persist writes the JSON files Artemis keeps its settings in
+// (presets, macros, webhooks, camera ACLs, daily snapshots, and optimistic
+// device states) so that neither concurrent saves nor a crash can leave a
+// file corrupt.
+package persist
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileLocks holds one mutex per file, by absolute path, so saves to the
+// same file never interleave, even from two stores opened on one path.
+var (
+	fileLocksMu sync.Mutex
+	fileLocks   = map[string]*sync.Mutex{}
+)
+
+// lockFor returns the mutex that serializes writes to path.
+func lockFor(path string) *sync.Mutex {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	fileLocksMu.Lock()
+	defer fileLocksMu.Unlock()
+	mu, ok := fileLocks[path]
+	if !ok {
+		mu = &sync.Mutex{}
+		fileLocks[path] = mu
+	}
+	return mu
+}
+
+// WriteFile replaces path with data atomically: the data goes to a uniquely
+// named temp file in the same directory, is flushed to disk, and is renamed
+// over path. Readers and a crash mid-write see either the old file or the
+// new one, never a mix. Writes to the same path are serialized. The temp
+// file is removed if anything fails.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	mu := lockFor(path)
+	mu.Lock()
+	defer mu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// After a successful rename there's nothing left to remove
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// WriteJSON writes v to path as indented JSON with WriteFile.
+func WriteJSON(path string, v interface{}, perm os.FileMode) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filepath.Base(path), err)
+	}
+	return WriteFile(path, data, perm)
+}
@@ -0,0 +1,66 @@
+package persist
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestWriteJSON_ConcurrentWritesStayValid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			items := make([]int, i+1)
+			for j := range items {
+				items[j] = i
+			}
+			if err := WriteJSON(path, items, 0o600); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []int
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatalf("expected valid JSON after concurrent writes, got %v: %s", err, data)
+	}
+	// One writer's data, whole: every element is that writer's index
+	for _, item := range items {
+		if item != len(items)-1 {
+			t.Fatalf("expected one write's items, got a mix: %v", items)
+		}
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected no temp files left behind, got %d entries", len(entries))
+	}
+}
+
+func TestWriteFile_Permissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	if err := WriteFile(path, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %v (%v)", info.Mode().Perm(), err)
+	}
+}
+
+func TestWriteFile_MissingDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "store.json")
+	if err := WriteFile(path, []byte("{}"), 0o600); err == nil {
+		t.Error("expected an error writing into a missing directory")
+	}
+}
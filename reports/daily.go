@@ -0,0 +1,316 @@
+// Package reports keeps a daily snapshot of every light and camera and
+// compares the last two, for "what changed today" views in the app.
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pantheon/artemis/camera"
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/persist"
+)
+
+// sampleInterval is how often the recorder reads the state poller's cache
+// and the camera list. On-time and offline-time are counted in these steps,
+// so they are accurate to about this much.
+const sampleInterval = 5 * time.Minute
+
+// ErrNotEnoughSnapshots means fewer than two snapshots have been taken, so
+// there is nothing to compare yet.
+var ErrNotEnoughSnapshots = errors.New("not enough snapshots yet")
+
+// LightSource provides the light states to sample; *govee.StatePoller
+// satisfies it.
+type LightSource interface {
+	States() []govee.DeviceState
+}
+
+// CameraSource provides the cameras to sample; *camera.Client satisfies it.
+type CameraSource interface {
+	GetCameras(ctx context.Context) ([]camera.Camera, error)
+}
+
+// Snapshot is one day's record: each device's state when it was taken, and
+// how long it spent on (lights) or offline (cameras) since the one before.
+type Snapshot struct {
+	Since   time.Time        `json:"since"`   // Start of the period: the previous snapshot, or startup
+	TakenAt time.Time        `json:"takenAt"` // End of the period
+	Lights  []LightSnapshot  `json:"lights,omitempty"`
+	Cameras []CameraSnapshot `json:"cameras,omitempty"`
+}
+
+// LightSnapshot is one light in a Snapshot.
+type LightSnapshot struct {
+	APIKeyIndex int    `json:"apiKeyIndex,omitempty"`
+	DeviceID    string `json:"deviceId"`
+	Model       string `json:"model,omitempty"`
+	On          bool   `json:"on,omitempty"`
+	Online      bool   `json:"online,omitempty"`
+	OnMinutes   int    `json:"onMinutes,omitempty"`
+}
+
+// CameraSnapshot is one camera in a Snapshot.
+type CameraSnapshot struct {
+	NameURI        string `json:"nameUri"`
+	Name           string `json:"name,omitempty"`
+	Online         bool   `json:"online,omitempty"`
+	OfflineMinutes int    `json:"offlineMinutes,omitempty"`
+}
+
+// lightTally accumulates a light's day between snapshots.
+type lightTally struct {
+	LightSnapshot
+	onTime time.Duration
+}
+
+// cameraTally accumulates a camera's day between snapshots.
+type cameraTally struct {
+	CameraSnapshot
+	offlineTime time.Duration
+}
+
+// Recorder samples light and camera states through the day and takes a
+// snapshot once a day at a fixed local time, keeping the most recent few in
+// a JSON file so reports survive restarts. The partial day since the last
+// snapshot is only kept in memory, so a restart starts it over.
+// Safe for concurrent use.
+type Recorder struct {
+	path     string        // JSON file snapshots are saved to; "" keeps them in memory only
+	at       time.Duration // Time of day snapshots are taken, as an offset from local midnight
+	keep     int           // How many snapshots to keep
+	lights   LightSource   // nil when the state poller isn't running
+	cameras  CameraSource  // nil when cameras are disabled
+	interval time.Duration // Sampling interval, sampleInterval outside tests
+
+	mu        sync.Mutex
+	since     time.Time
+	lightDay  map[string]*lightTally  // Keyed by "apiKeyIndex/deviceID"
+	cameraDay map[string]*cameraTally // Keyed by name URI
+	snapshots []Snapshot              // Oldest first
+}
+
+// NewRecorder creates a recorder that snapshots at the local time at
+// ("HH:MM") and keeps the last keep snapshots in path. Snapshots already
+// saved to path are loaded; an unreadable file is an error, so a typo'd
+// path doesn't silently drop the history on the next save. lights and
+// cameras may be nil to leave them out of the report.
+func NewRecorder(path, at string, keep int, lights LightSource, cameras CameraSource) (*Recorder, error) {
+	offset, err := ParseTimeOfDay(at)
+	if err != nil {
+		return nil, err
+	}
+	if keep < 2 {
+		return nil, fmt.Errorf("at least 2 snapshots must be kept to compare them, got %d", keep)
+	}
+
+	r := &Recorder{
+		path:      path,
+		at:        offset,
+		keep:      keep,
+		lights:    lights,
+		cameras:   cameras,
+		interval:  sampleInterval,
+		since:     time.Now(),
+		lightDay:  make(map[string]*lightTally),
+		cameraDay: make(map[string]*cameraTally),
+	}
+	if path == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &r.snapshots); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// ParseTimeOfDay parses "HH:MM" (24-hour) into an offset from midnight.
+func ParseTimeOfDay(at string) (time.Duration, error) {
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return 0, fmt.Errorf("time of day must be HH:MM (24-hour), got %q", at)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Start samples every interval and takes a snapshot at the configured time
+// each day, in a background goroutine, until ctx is cancelled.
+func (r *Recorder) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		timer := time.NewTimer(time.Until(nextSnapshot(time.Now(), r.at)))
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Printf("📊 Daily report recorder stopped")
+				return
+			case <-ticker.C:
+				r.Sample(ctx)
+			case <-timer.C:
+				r.Sample(ctx)
+				if _, err := r.Snapshot(time.Now()); err != nil {
+					log.Printf("⚠️  Daily report: %v", err)
+				}
+				timer.Reset(time.Until(nextSnapshot(time.Now(), r.at)))
+			}
+		}
+	}()
+}
+
+// nextSnapshot returns the next time after now that is at past local
+// midnight, today or tomorrow.
+func nextSnapshot(now time.Time, at time.Duration) time.Time {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	next := midnight.Add(at)
+	if !next.After(now) {
+		next = midnight.AddDate(0, 0, 1).Add(at)
+	}
+	return next
+}
+
+// Sample reads the current light and camera states, counting one interval
+// of on-time for each light that is on and offline-time for each camera
+// that is offline. A camera list that can't be read is logged and skipped.
+func (r *Recorder) Sample(ctx context.Context) {
+	var states []govee.DeviceState
+	if r.lights != nil {
+		states = r.lights.States()
+	}
+	var cams []camera.Camera
+	if r.cameras != nil {
+		var err error
+		if cams, err = r.cameras.GetCameras(ctx); err != nil {
+			log.Printf("⚠️  Daily report: failed to list cameras: %v", err)
+			cams = nil
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, state := range states {
+		key := fmt.Sprintf("%d/%s", state.APIKeyIndex, state.DeviceID)
+		tally, ok := r.lightDay[key]
+		if !ok {
+			tally = &lightTally{LightSnapshot: LightSnapshot{APIKeyIndex: state.APIKeyIndex, DeviceID: state.DeviceID}}
+			r.lightDay[key] = tally
+		}
+		tally.Model = state.Model
+		tally.On = state.IsOn()
+		tally.Online = state.Online == nil || *state.Online
+		if tally.On {
+			tally.onTime += r.interval
+		}
+	}
+	for _, cam := range cams {
+		tally, ok := r.cameraDay[cam.NameURI]
+		if !ok {
+			tally = &cameraTally{CameraSnapshot: CameraSnapshot{NameURI: cam.NameURI}}
+			r.cameraDay[cam.NameURI] = tally
+		}
+		tally.Name = cam.Name
+		tally.Online = cam.Status == "online"
+		if !tally.Online {
+			tally.offlineTime += r.interval
+		}
+	}
+}
+
+// Snapshot closes the current period at now: it records every light and
+// camera sampled since the last snapshot, saves the most recent snapshots,
+// and starts a new period. The snapshot is kept in memory even if saving
+// fails.
+func (r *Recorder) Snapshot(now time.Time) (Snapshot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := Snapshot{Since: r.since, TakenAt: now}
+	for _, tally := range r.lightDay {
+		light := tally.LightSnapshot
+		light.OnMinutes = int(tally.onTime / time.Minute)
+		snapshot.Lights = append(snapshot.Lights, light)
+	}
+	sort.Slice(snapshot.Lights, func(i, j int) bool {
+		a, b := snapshot.Lights[i], snapshot.Lights[j]
+		if a.APIKeyIndex != b.APIKeyIndex {
+			return a.APIKeyIndex < b.APIKeyIndex
+		}
+		return a.DeviceID < b.DeviceID
+	})
+	for _, tally := range r.cameraDay {
+		cam := tally.CameraSnapshot
+		cam.OfflineMinutes = int(tally.offlineTime / time.Minute)
+		snapshot.Cameras = append(snapshot.Cameras, cam)
+	}
+	sort.Slice(snapshot.Cameras, func(i, j int) bool {
+		return snapshot.Cameras[i].NameURI < snapshot.Cameras[j].NameURI
+	})
+
+	r.snapshots = append(r.snapshots, snapshot)
+	if len(r.snapshots) > r.keep {
+		r.snapshots = r.snapshots[len(r.snapshots)-r.keep:]
+	}
+	r.since = now
+	r.lightDay = make(map[string]*lightTally)
+	r.cameraDay = make(map[string]*cameraTally)
+
+	log.Printf("📊 Daily snapshot taken: %d light(s), %d camera(s)", len(snapshot.Lights), len(snapshot.Cameras))
+	return snapshot, r.save()
+}
+
+// Snapshots returns the kept snapshots, oldest first.
+func (r *Recorder) Snapshots() []Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Snapshot(nil), r.snapshots...)
+}
+
+// DailyDiff compares the latest snapshot with the one before it. Returns
+// ErrNotEnoughSnapshots until two have been taken.
+func (r *Recorder) DailyDiff() (Diff, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.snapshots) < 2 {
+		return Diff{}, ErrNotEnoughSnapshots
+	}
+	n := len(r.snapshots)
+	return Compare(r.snapshots[n-2], r.snapshots[n-1]), nil
+}
+
+// save writes the kept snapshots to the recorder's file via a temp file and
+// rename, so a crash mid-write never leaves a truncated file. They're
+// written without indentation, and empty fields are left out, to keep a
+// week of a large home small. Caller holds r.mu.
+func (r *Recorder) save() error {
+	if r.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(r.snapshots)
+	if err != nil {
+		return err
+	}
+	if err := persist.WriteFile(r.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save snapshots: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/camera"
+	"github.com/pantheon/artemis/govee"
+)
+
+// fakeLights serves a fixed set of light states.
+type fakeLights []govee.DeviceState
+
+func (f fakeLights) States() []govee.DeviceState { return f }
+
+// fakeCameras serves a fixed camera list, or an error.
+type fakeCameras struct {
+	cams []camera.Camera
+	err  error
+}
+
+func (f *fakeCameras) GetCameras(ctx context.Context) ([]camera.Camera, error) {
+	return f.cams, f.err
+}
+
+func light(deviceID string, on, online bool) govee.DeviceState {
+	return govee.DeviceState{DeviceID: deviceID, Model: "H6008", PowerOn: &on, Online: &online}
+}
+
+func TestRecorder_SnapshotTalliesSamples(t *testing.T) {
+	lights := fakeLights{light("AA:01", true, true), light("AA:02", false, true)}
+	cams := &fakeCameras{cams: []camera.Camera{{Name: "Front Door", NameURI: "front-door", Status: "offline"}}}
+	recorder, err := NewRecorder("", "00:00", 7, &lights, cams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 3 {
+		recorder.Sample(context.Background())
+	}
+	lights[1] = light("AA:02", true, false)
+	cams.err = errors.New("bridge down") // A failed camera list doesn't count as offline time
+	recorder.Sample(context.Background())
+
+	snapshot, err := recorder.Snapshot(time.Now())
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %v", err)
+	}
+	if len(snapshot.Lights) != 2 || len(snapshot.Cameras) != 1 {
+		t.Fatalf("expected 2 lights and 1 camera, got %+v", snapshot)
+	}
+	lamp, plug, door := snapshot.Lights[0], snapshot.Lights[1], snapshot.Cameras[0]
+	if lamp.OnMinutes != 20 || !lamp.On || !lamp.Online {
+		t.Errorf("expected the lamp on for 4 samples (20 minutes), got %+v", lamp)
+	}
+	if plug.OnMinutes != 5 || !plug.On || plug.Online {
+		t.Errorf("expected the plug on for 1 sample and offline at the end, got %+v", plug)
+	}
+	if door.OfflineMinutes != 15 || door.Online || door.Name != "Front Door" {
+		t.Errorf("expected the camera offline for 3 samples, got %+v", door)
+	}
+
+	// The next period starts empty
+	if next, _ := recorder.Snapshot(time.Now()); len(next.Lights) != 0 || !next.Since.Equal(snapshot.TakenAt) {
+		t.Errorf("expected an empty period starting at the last snapshot, got %+v", next)
+	}
+}
+
+func TestRecorder_PersistsAndTrims(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.json")
+	lights := fakeLights{light("AA:01", true, true)}
+	recorder, err := NewRecorder(path, "06:30", 2, lights, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorder.DailyDiff(); !errors.Is(err, ErrNotEnoughSnapshots) {
+		t.Errorf("expected ErrNotEnoughSnapshots before any snapshot, got %v", err)
+	}
+
+	start := time.Date(2026, 1, 1, 6, 30, 0, 0, time.UTC)
+	for day := range 3 {
+		for range day + 1 {
+			recorder.Sample(context.Background())
+		}
+		if _, err := recorder.Snapshot(start.AddDate(0, 0, day)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reloaded, err := NewRecorder(path, "06:30", 2, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to reload snapshots: %v", err)
+	}
+	snapshots := reloaded.Snapshots()
+	if len(snapshots) != 2 || !snapshots[1].TakenAt.Equal(start.AddDate(0, 0, 2)) {
+		t.Fatalf("expected the last 2 snapshots to be kept, got %+v", snapshots)
+	}
+
+	diff, err := reloaded.DailyDiff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Lights) != 1 || diff.Lights[0].OnMinutesToday != 15 || diff.Lights[0].OnMinutesYesterday != 10 {
+		t.Errorf("expected 15 minutes today vs 10 yesterday, got %+v", diff.Lights)
+	}
+}
+
+func TestNewRecorder_Validation(t *testing.T) {
+	for _, at := range []string{"", "24:00", "7am", "12:60"} {
+		if _, err := NewRecorder("", at, 7, nil, nil); err == nil {
+			t.Errorf("expected %q to be refused", at)
+		}
+	}
+	if _, err := NewRecorder("", "00:00", 1, nil, nil); err == nil {
+		t.Error("expected keep=1 to be refused")
+	}
+}
+
+func TestNextSnapshot(t *testing.T) {
+	at := 6*time.Hour + 30*time.Minute
+	morning := time.Date(2026, 3, 1, 5, 0, 0, 0, time.UTC)
+	if got := nextSnapshot(morning, at); !got.Equal(time.Date(2026, 3, 1, 6, 30, 0, 0, time.UTC)) {
+		t.Errorf("expected later the same day, got %s", got)
+	}
+	evening := time.Date(2026, 3, 1, 6, 30, 0, 0, time.UTC)
+	if got := nextSnapshot(evening, at); !got.Equal(time.Date(2026, 3, 2, 6, 30, 0, 0, time.UTC)) {
+		t.Errorf("expected the next day, got %s", got)
+	}
+}
@@ -0,0 +1,181 @@
+package reports
+
+import (
+	"sort"
+	"time"
+)
+
+// How a light's on-time compares between two snapshots.
+const (
+	ChangeOnLonger  = "on_longer"
+	ChangeOnShorter = "on_shorter"
+	ChangeUnchanged = "unchanged"
+	ChangeNew       = "new"     // Only in today's snapshot
+	ChangeRemoved   = "removed" // Only in yesterday's snapshot
+)
+
+// Period is the span a snapshot covers.
+type Period struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+}
+
+// Diff compares two consecutive snapshots, "yesterday" and "today".
+type Diff struct {
+	Today     Period         `json:"today"`
+	Yesterday Period         `json:"yesterday"`
+	Summary   DiffSummary    `json:"summary"`
+	Lights    []LightChange  `json:"lights"`  // Biggest change in on-time first
+	Cameras   []CameraChange `json:"cameras"` // By name URI
+}
+
+// DiffSummary counts the notable changes in a Diff.
+type DiffSummary struct {
+	LightsOnLonger     int `json:"lightsOnLonger"`
+	LightsOnShorter    int `json:"lightsOnShorter"`
+	LightsWentOffline  int `json:"lightsWentOffline"`
+	CamerasWentOffline int `json:"camerasWentOffline"`
+}
+
+// LightChange is how one light's day compares with the day before.
+type LightChange struct {
+	APIKeyIndex        int    `json:"apiKeyIndex"`
+	DeviceID           string `json:"deviceId"`
+	Model              string `json:"model,omitempty"`
+	Change             string `json:"change"` // ChangeOnLonger, ChangeOnShorter, ChangeUnchanged, ChangeNew, or ChangeRemoved
+	OnMinutesToday     int    `json:"onMinutesToday"`
+	OnMinutesYesterday int    `json:"onMinutesYesterday"`
+	OnMinutesDelta     int    `json:"onMinutesDelta"` // Today minus yesterday
+	OnNow              bool   `json:"onNow"`          // On when today's snapshot was taken
+	WentOffline        bool   `json:"wentOffline"`    // Reachable at yesterday's snapshot, not at today's
+}
+
+// CameraChange is how one camera's day compares with the day before.
+type CameraChange struct {
+	NameURI                 string `json:"nameUri"`
+	Name                    string `json:"name,omitempty"`
+	OfflineMinutesToday     int    `json:"offlineMinutesToday"`
+	OfflineMinutesYesterday int    `json:"offlineMinutesYesterday"`
+	OnlineNow               bool   `json:"onlineNow"` // Online when today's snapshot was taken
+
+	// Offline at today's snapshot after being online at yesterday's, or
+	// offline at some point today after never being offline yesterday
+	WentOffline bool `json:"wentOffline"`
+	// Offline at yesterday's snapshot, online at today's
+	CameBackOnline bool `json:"cameBackOnline"`
+}
+
+// Compare diffs two snapshots. Devices in only one of them are listed too:
+// lights as ChangeNew or ChangeRemoved, cameras with zero minutes for the
+// missing day.
+func Compare(yesterday, today Snapshot) Diff {
+	diff := Diff{
+		Today:     Period{Since: today.Since, Until: today.TakenAt},
+		Yesterday: Period{Since: yesterday.Since, Until: yesterday.TakenAt},
+		Lights:    []LightChange{},
+		Cameras:   []CameraChange{},
+	}
+
+	type lightKey struct {
+		apiKeyIndex int
+		deviceID    string
+	}
+	before := make(map[lightKey]LightSnapshot, len(yesterday.Lights))
+	for _, light := range yesterday.Lights {
+		before[lightKey{light.APIKeyIndex, light.DeviceID}] = light
+	}
+	for _, light := range today.Lights {
+		key := lightKey{light.APIKeyIndex, light.DeviceID}
+		previous, existed := before[key]
+		delete(before, key)
+
+		change := LightChange{
+			APIKeyIndex:        light.APIKeyIndex,
+			DeviceID:           light.DeviceID,
+			Model:              light.Model,
+			OnMinutesToday:     light.OnMinutes,
+			OnMinutesYesterday: previous.OnMinutes,
+			OnMinutesDelta:     light.OnMinutes - previous.OnMinutes,
+			OnNow:              light.On,
+			WentOffline:        existed && previous.Online && !light.Online,
+		}
+		switch {
+		case !existed:
+			change.Change = ChangeNew
+		case change.OnMinutesDelta > 0:
+			change.Change = ChangeOnLonger
+			diff.Summary.LightsOnLonger++
+		case change.OnMinutesDelta < 0:
+			change.Change = ChangeOnShorter
+			diff.Summary.LightsOnShorter++
+		default:
+			change.Change = ChangeUnchanged
+		}
+		if change.WentOffline {
+			diff.Summary.LightsWentOffline++
+		}
+		diff.Lights = append(diff.Lights, change)
+	}
+	for _, light := range before {
+		diff.Lights = append(diff.Lights, LightChange{
+			APIKeyIndex:        light.APIKeyIndex,
+			DeviceID:           light.DeviceID,
+			Model:              light.Model,
+			Change:             ChangeRemoved,
+			OnMinutesYesterday: light.OnMinutes,
+			OnMinutesDelta:     -light.OnMinutes,
+		})
+	}
+	sort.Slice(diff.Lights, func(i, j int) bool {
+		a, b := diff.Lights[i], diff.Lights[j]
+		if abs(a.OnMinutesDelta) != abs(b.OnMinutesDelta) {
+			return abs(a.OnMinutesDelta) > abs(b.OnMinutesDelta)
+		}
+		if a.APIKeyIndex != b.APIKeyIndex {
+			return a.APIKeyIndex < b.APIKeyIndex
+		}
+		return a.DeviceID < b.DeviceID
+	})
+
+	camerasBefore := make(map[string]CameraSnapshot, len(yesterday.Cameras))
+	for _, cam := range yesterday.Cameras {
+		camerasBefore[cam.NameURI] = cam
+	}
+	for _, cam := range today.Cameras {
+		previous, existed := camerasBefore[cam.NameURI]
+		delete(camerasBefore, cam.NameURI)
+
+		change := CameraChange{
+			NameURI:                 cam.NameURI,
+			Name:                    cam.Name,
+			OfflineMinutesToday:     cam.OfflineMinutes,
+			OfflineMinutesYesterday: previous.OfflineMinutes,
+			OnlineNow:               cam.Online,
+			WentOffline: (existed && previous.Online && !cam.Online) ||
+				(cam.OfflineMinutes > 0 && previous.OfflineMinutes == 0),
+			CameBackOnline: existed && !previous.Online && cam.Online,
+		}
+		if change.WentOffline {
+			diff.Summary.CamerasWentOffline++
+		}
+		diff.Cameras = append(diff.Cameras, change)
+	}
+	for _, cam := range camerasBefore {
+		diff.Cameras = append(diff.Cameras, CameraChange{
+			NameURI:                 cam.NameURI,
+			Name:                    cam.Name,
+			OfflineMinutesYesterday: cam.OfflineMinutes,
+		})
+	}
+	sort.Slice(diff.Cameras, func(i, j int) bool {
+		return diff.Cameras[i].NameURI < diff.Cameras[j].NameURI
+	})
+	return diff
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
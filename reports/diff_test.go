@@ -0,0 +1,66 @@
+package reports
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	yesterday := Snapshot{
+		Lights: []LightSnapshot{
+			{DeviceID: "AA:01", On: true, Online: true, OnMinutes: 60},
+			{DeviceID: "AA:02", Online: true, OnMinutes: 300},
+			{DeviceID: "AA:03", Online: true, OnMinutes: 10},
+		},
+		Cameras: []CameraSnapshot{
+			{NameURI: "back-yard", Online: false, OfflineMinutes: 120},
+			{NameURI: "front-door", Online: true},
+		},
+	}
+	today := Snapshot{
+		Lights: []LightSnapshot{
+			{DeviceID: "AA:01", On: true, Online: true, OnMinutes: 240},
+			{DeviceID: "AA:02", Online: false, OnMinutes: 30},
+			{DeviceID: "BB:01", Online: true, OnMinutes: 5},
+		},
+		Cameras: []CameraSnapshot{
+			{NameURI: "back-yard", Online: true},
+			{NameURI: "front-door", Online: false, OfflineMinutes: 45},
+		},
+	}
+
+	diff := Compare(yesterday, today)
+
+	// Biggest change first: AA:02 (-270), AA:01 (+180), AA:03 (-10, removed), BB:01 (+5, new)
+	want := []struct {
+		deviceID string
+		change   string
+		delta    int
+	}{
+		{"AA:02", ChangeOnShorter, -270},
+		{"AA:01", ChangeOnLonger, 180},
+		{"AA:03", ChangeRemoved, -10},
+		{"BB:01", ChangeNew, 5},
+	}
+	if len(diff.Lights) != len(want) {
+		t.Fatalf("expected %d lights, got %+v", len(want), diff.Lights)
+	}
+	for i, w := range want {
+		if got := diff.Lights[i]; got.DeviceID != w.deviceID || got.Change != w.change || got.OnMinutesDelta != w.delta {
+			t.Errorf("light %d: expected %s %s %+d, got %+v", i, w.deviceID, w.change, w.delta, got)
+		}
+	}
+	if !diff.Lights[0].WentOffline || diff.Lights[1].WentOffline {
+		t.Errorf("expected only AA:02 to have gone offline, got %+v", diff.Lights)
+	}
+
+	backYard, frontDoor := diff.Cameras[0], diff.Cameras[1]
+	if !backYard.CameBackOnline || backYard.WentOffline {
+		t.Errorf("expected the back yard camera to be back online, got %+v", backYard)
+	}
+	if !frontDoor.WentOffline || frontDoor.OfflineMinutesToday != 45 {
+		t.Errorf("expected the front door camera to have gone offline, got %+v", frontDoor)
+	}
+
+	wantSummary := DiffSummary{LightsOnLonger: 1, LightsOnShorter: 1, LightsWentOffline: 1, CamerasWentOffline: 1}
+	if diff.Summary != wantSummary {
+		t.Errorf("expected summary %+v, got %+v", wantSummary, diff.Summary)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/pantheon/artemis/handlers"
+)
+
+// routeTable registers the server's routes on a mux and records each one
+// with its description, for GET /api/routes and the startup log. Keeping the
+// description next to the registration means neither list can drift from
+// what's actually served.
+type routeTable struct {
+	mux      *http.ServeMux
+	basePath string
+	routes   []handlers.RouteInfo
+}
+
+// integrationRoute is one endpoint of an optional integration, relative to
+// the API base path.
+type integrationRoute struct {
+	methods     string // As for routeTable.handle
+	path        string
+	description string
+	handler     http.HandlerFunc
+}
+
+func newRouteTable(mux *http.ServeMux, basePath string) *routeTable {
+	return &routeTable{mux: mux, basePath: basePath}
+}
+
+// handle registers handler at path, relative to the API base path.
+// methods lists the methods the route answers, space-separated ("GET",
+// "GET POST"); other methods get 405 from the mux. Empty accepts any method.
+func (t *routeTable) handle(methods, path, description string, handler http.Handler) {
+	t.add(handlers.RouteInfo{
+		Methods:     strings.Fields(methods),
+		Path:        t.basePath + path,
+		Description: description,
+		Enabled:     true,
+	}, handler)
+}
+
+// handleRoot is handle for a path outside the API base path (e.g., the
+// dashboard).
+func (t *routeTable) handleRoot(methods, path, description string, handler http.Handler) {
+	t.add(handlers.RouteInfo{
+		Methods:     strings.Fields(methods),
+		Path:        path,
+		Description: description,
+		Enabled:     true,
+	}, handler)
+}
+
+// integration registers an integration's routes, or — when the integration
+// is disabled — a "feature disabled" 404 on each of them.
+func (t *routeTable) integration(feature string, enabled bool, routes []integrationRoute) {
+	for _, route := range routes {
+		var handler http.Handler = route.handler
+		if !enabled {
+			handler = handlers.HandleFeatureDisabled(feature)
+		}
+		t.add(handlers.RouteInfo{
+			Methods:     strings.Fields(route.methods),
+			Path:        t.basePath + route.path,
+			Description: route.description,
+			Feature:     feature,
+			Enabled:     enabled,
+		}, handler)
+	}
+}
+
+// add registers handler under one mux pattern per method and records the route.
+func (t *routeTable) add(info handlers.RouteInfo, handler http.Handler) {
+	if len(info.Methods) == 0 {
+		t.mux.Handle(info.Path, handler)
+	}
+	for _, method := range info.Methods {
+		t.mux.Handle(method+" "+info.Path, handler)
+	}
+	if info.Methods == nil {
+		info.Methods = []string{}
+	}
+	t.routes = append(t.routes, info)
+}
+
+// list returns a copy of the recorded routes, in registration order.
+func (t *routeTable) list() []handlers.RouteInfo {
+	return slices.Clone(t.routes)
+}
+
+// summary describes the registered routes in one line, e.g.
+// "42 routes, 36 enabled (disabled: Fire TV, Cameras)".
+func (t *routeTable) summary() string {
+	enabled := 0
+	var disabled []string
+	for _, route := range t.routes {
+		if route.Enabled {
+			enabled++
+		} else if !slices.Contains(disabled, route.Feature) {
+			disabled = append(disabled, route.Feature)
+		}
+	}
+
+	summary := fmt.Sprintf("%d routes, %d enabled", len(t.routes), enabled)
+	if len(disabled) > 0 {
+		summary += " (disabled: " + strings.Join(disabled, ", ") + ")"
+	}
+	return summary
+}
+
+// logStartup logs the routes once the server is listening: the one-line
+// summary, and with verbose (LOG_LEVEL=debug) every route.
+func (t *routeTable) logStartup(verbose bool) {
+	if !verbose {
+		log.Printf("📝 API: %s — LOG_LEVEL=debug lists them, as does GET %s/routes", t.summary(), t.basePath)
+		return
+	}
+
+	log.Printf("📝 API endpoints (%s):", t.summary())
+	for _, route := range t.routes {
+		methods := strings.Join(route.Methods, ",")
+		if methods == "" {
+			methods = "ANY"
+		}
+		if route.Enabled {
+			log.Printf("   - %-6s %s - %s", methods, route.Path, route.Description)
+		} else {
+			log.Printf("   - %-6s %s - %s (%s disabled)", methods, route.Path, route.Description, route.Feature)
+		}
+	}
+}
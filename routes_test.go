@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteTable_Integration(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	routes := newRouteTable(http.NewServeMux(), "/api")
+	routes.integration("Govee", true, []integrationRoute{{"GET", "/govee/devices", "List devices", ok}})
+	routes.integration("Cameras", false, []integrationRoute{{"GET", "/cameras", "List cameras", ok}})
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/govee/devices", http.StatusOK},
+		{"/api/cameras", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		routes.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.want, w.Code)
+		}
+	}
+
+	list := routes.list()
+	if len(list) != 2 {
+		t.Fatalf("expected 2 routes, got %d", len(list))
+	}
+	if list[0].Path != "/api/govee/devices" || list[0].Feature != "Govee" || !list[0].Enabled {
+		t.Errorf("unexpected Govee route: %+v", list[0])
+	}
+	if list[1].Path != "/api/cameras" || list[1].Enabled {
+		t.Errorf("expected the Cameras route to be recorded as disabled: %+v", list[1])
+	}
+}
+
+func TestRouteTable_Methods(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	routes := newRouteTable(http.NewServeMux(), "/api")
+	routes.handle("GET POST", "/webhooks", "Webhooks", http.HandlerFunc(ok))
+	routes.handle("", "/health", "Health check", http.HandlerFunc(ok))
+	routes.handleRoot("GET", "/dashboard", "Dashboard", http.HandlerFunc(ok))
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/api/webhooks", http.StatusOK},
+		{http.MethodPost, "/api/webhooks", http.StatusOK},
+		{http.MethodDelete, "/api/webhooks", http.StatusMethodNotAllowed},
+		{http.MethodPut, "/api/health", http.StatusOK},
+		{http.MethodGet, "/dashboard", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		routes.mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.want, w.Code)
+		}
+	}
+
+	list := routes.list()
+	if got := list[0].Methods; len(got) != 2 || got[0] != "GET" || got[1] != "POST" {
+		t.Errorf("expected methods [GET POST], got %v", got)
+	}
+	if got := list[1].Methods; got == nil || len(got) != 0 {
+		t.Errorf("expected an empty (non-nil) method list, got %#v", got)
+	}
+}
+
+func TestRouteTable_Summary(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	routes := newRouteTable(http.NewServeMux(), "/api")
+	routes.handle("GET", "/health", "Health check", http.HandlerFunc(ok))
+	routes.integration("Fire TV", false, []integrationRoute{{"GET", "/firetv/discover", "Discover", ok}, {"POST", "/firetv/command", "Command", ok}})
+	routes.integration("Cameras", false, []integrationRoute{{"GET", "/cameras", "List cameras", ok}})
+
+	if got := routes.summary(); got != "4 routes, 1 enabled (disabled: Fire TV, Cameras)" {
+		t.Errorf("unexpected summary %q", got)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pantheon/artemis/camera"
+	"github.com/pantheon/artemis/config"
+	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/firetv"
+)
+
+// Exit codes for --selftest, so deployment scripts can tell failures apart.
+const (
+	selfTestOK             = 0 // Config is valid and every dependency is healthy
+	selfTestConfigError    = 2 // Config failed to load or validate
+	selfTestDependencyDown = 3 // At least one dependency check failed
+)
+
+// selfTestCheck is a single named dependency probe.
+type selfTestCheck struct {
+	name string
+	run  func() error
+}
+
+// runSelfTest loads and validates the config, probes every dependency, and
+// prints a summary to out. Returns the process exit code. Never starts the
+// HTTP server.
+func runSelfTest(out io.Writer) int {
+	fmt.Fprintln(out, "🩺 Artemis self-test")
+
+	cfg, err := config.Load()
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		fmt.Fprintf(out, "  ❌ config: %v\n", err)
+		fmt.Fprintln(out, "Result: configuration error")
+		return selfTestConfigError
+	}
+	fmt.Fprintln(out, "  ✅ config")
+
+	if !runChecks(out, dependencyChecks(cfg)) {
+		fmt.Fprintln(out, "Result: one or more dependencies are down")
+		return selfTestDependencyDown
+	}
+
+	fmt.Fprintln(out, "Result: all checks passed")
+	return selfTestOK
+}
+
+// dependencyChecks builds the probes for everything Artemis talks to.
+// Integrations switched off via ENABLE_* are skipped.
+func dependencyChecks(cfg *config.Config) []selfTestCheck {
+	checks := []selfTestCheck{
+		{"database", func() error {
+			database, err := db.InitDB(cfg.DBPath)
+			if err != nil {
+				return err
+			}
+			return database.Close()
+		}},
+	}
+	if cfg.EnableGovee {
+		checks = append(checks, selfTestCheck{"govee (primary)", func() error {
+			return newGoveeClient(cfg, cfg.GoveeAPIKey).CheckHealth(context.Background())
+		}})
+		if cfg.GoveeAPIKeySecondary != "" {
+			checks = append(checks, selfTestCheck{"govee (secondary)", func() error {
+				return newGoveeClient(cfg, cfg.GoveeAPIKeySecondary).CheckHealth(context.Background())
+			}})
+		}
+	}
+	if cfg.EnableFireTV {
+		for i, service := range cfg.FireTVServices {
+			name := "fire TV service"
+			if len(cfg.FireTVServices) > 1 {
+				name = fmt.Sprintf("fire TV service %d (%s)", i, service.URL)
+			}
+			checks = append(checks, selfTestCheck{name, func() error {
+				return firetv.NewClient(service.URL).CheckHealth(context.Background())
+			}})
+		}
+	}
+	if cfg.EnableCameras {
+		checks = append(checks, selfTestCheck{"wyze bridge", func() error {
+			return camera.NewClientWithAuth(cfg.WyzeBridgeURL, cfg.WyzeBridgeAPIKey, cfg.WyzeBridgeAuthMode, cfg.WyzeBridgeUsername).CheckHealth(context.Background())
+		}})
+	}
+	return checks
+}
+
+// runChecks runs each check in order, printing one line per check with its
+// duration. Returns true if every check passed.
+func runChecks(out io.Writer, checks []selfTestCheck) bool {
+	healthy := true
+	for _, check := range checks {
+		start := time.Now()
+		err := check.run()
+		elapsed := time.Since(start).Round(time.Millisecond)
+
+		if err != nil {
+			healthy = false
+			fmt.Fprintf(out, "  ❌ %s (%s): %v\n", check.name, elapsed, err)
+			continue
+		}
+		fmt.Fprintf(out, "  ✅ %s (%s)\n", check.name, elapsed)
+	}
+	return healthy
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pantheon/artemis/config"
+)
+
+func TestRunChecks_ReportsEachCheck(t *testing.T) {
+	var out bytes.Buffer
+	ok := runChecks(&out, []selfTestCheck{
+		{"database", func() error { return nil }},
+		{"wyze bridge", func() error { return errors.New("connection refused") }},
+		{"fire TV service", func() error { return nil }},
+	})
+
+	if ok {
+		t.Error("expected runChecks to fail when any check fails")
+	}
+
+	summary := out.String()
+	for _, expected := range []string{"✅ database", "❌ wyze bridge", "connection refused", "✅ fire TV service"} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("expected summary to contain %q:\n%s", expected, summary)
+		}
+	}
+}
+
+func TestRunChecks_AllHealthy(t *testing.T) {
+	var out bytes.Buffer
+	if !runChecks(&out, []selfTestCheck{{"database", func() error { return nil }}}) {
+		t.Errorf("expected all checks to pass:\n%s", out.String())
+	}
+}
+
+func TestRunSelfTest_ConfigError(t *testing.T) {
+	// An empty key wins over any .env file, since godotenv doesn't override.
+	t.Setenv("GOVEE_API_KEY", "")
+
+	var out bytes.Buffer
+	if code := runSelfTest(&out); code != selfTestConfigError {
+		t.Errorf("expected exit code %d for missing GOVEE_API_KEY, got %d:\n%s", selfTestConfigError, code, out.String())
+	}
+}
+
+func TestDependencyChecks_SkipsDisabledIntegrations(t *testing.T) {
+	cfg := &config.Config{
+		DBPath:         ":memory:",
+		EnableGovee:    false,
+		EnableFireTV:   true,
+		EnableCameras:  false,
+		FireTVServices: []config.FireTVService{{URL: "http://localhost:9090"}},
+	}
+
+	var names []string
+	for _, check := range dependencyChecks(cfg) {
+		names = append(names, check.name)
+	}
+
+	got := strings.Join(names, ",")
+	if got != "database,fire TV service" {
+		t.Errorf("expected only database and fire TV checks, got %q", got)
+	}
+}
+
+func TestDependencyChecks_OneCheckPerFireTVService(t *testing.T) {
+	cfg := &config.Config{
+		DBPath:       ":memory:",
+		EnableFireTV: true,
+		FireTVServices: []config.FireTVService{
+			{URL: "http://10.0.1.5:9090"},
+			{URL: "http://10.0.20.5:9090", Subnets: []string{"10.0.20.0/24"}},
+		},
+	}
+
+	var names []string
+	for _, check := range dependencyChecks(cfg) {
+		names = append(names, check.name)
+	}
+
+	got := strings.Join(names, ",")
+	if got != "database,fire TV service 0 (http://10.0.1.5:9090),fire TV service 1 (http://10.0.20.5:9090)" {
+		t.Errorf("expected a check per Fire TV service, got %q", got)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pantheon/artemis/config"
+	"github.com/pantheon/artemis/govee"
+	"github.com/pantheon/artemis/handlers"
+)
+
+// runShutdownActions sends the configured on-shutdown commands concurrently
+// and waits up to timeout for them. Each result is logged; commands still in
+// flight when the timeout passes are abandoned (the process is exiting), so
+// an unreachable Govee API can't hang termination. An action with timeoutMs
+// gives up on its device sooner and is reported as timed out rather than
+// failed. Actions naming a room are sent to each of its Govee lights (see
+// expandShutdownActions), and every light counts as an action. Returns the
+// number of actions that succeeded.
+func runShutdownActions(clients []*govee.Client, database *sql.DB, actions []config.ShutdownAction, timeout time.Duration) int {
+	if len(actions) == 0 {
+		return 0
+	}
+
+	// Cancels requests still in flight once the timeout passes
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	actions = expandShutdownActions(ctx, clients, database, actions)
+	log.Printf("🛑 Running %d shutdown action(s) (timeout %s)...", len(actions), timeout)
+
+	results := make(chan shutdownResult, len(actions))
+	var wg sync.WaitGroup
+	for _, action := range actions {
+		if action.APIKeyIndex < 0 || action.APIKeyIndex >= len(clients) {
+			log.Printf("❌ Shutdown action %s %s: no Govee client for apiKeyIndex %d", action.Command, action.DeviceID, action.APIKeyIndex)
+			results <- shutdownFailed
+			continue
+		}
+
+		wg.Add(1)
+		go func(action config.ShutdownAction) {
+			defer wg.Done()
+			actionCtx, cancel := govee.CommandContext(ctx, time.Duration(action.TimeoutMs)*time.Millisecond)
+			defer cancel()
+			// No fade — there's no time to step through one
+			err := govee.ExecuteCommand(actionCtx, clients[action.APIKeyIndex], action.DeviceID, action.Model, action.Command, action.Value, 0)
+			switch {
+			case err == nil:
+				log.Printf("✅ Shutdown action %s %s done", action.Command, action.DeviceID)
+				results <- shutdownSucceeded
+			case govee.IsTimeoutError(err):
+				log.Printf("⏱️  Shutdown action %s %s timed out: %v", action.Command, action.DeviceID, err)
+				results <- shutdownTimedOut
+			default:
+				log.Printf("❌ Shutdown action %s %s failed: %v", action.Command, action.DeviceID, err)
+				results <- shutdownFailed
+			}
+		}(action)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("⚠️  Shutdown actions timed out after %s — not waiting for the rest", timeout)
+	}
+
+	// Count whatever finished in time
+	succeeded, timedOut := 0, 0
+	for {
+		select {
+		case result := <-results:
+			switch result {
+			case shutdownSucceeded:
+				succeeded++
+			case shutdownTimedOut:
+				timedOut++
+			}
+		default:
+			log.Printf("🛑 Shutdown actions: %d of %d succeeded, %d timed out", succeeded, len(actions), timedOut)
+			return succeeded
+		}
+	}
+}
+
+// expandShutdownActions replaces each action that names a room with one
+// action per Govee light in the room, as a room apply does. A room that
+// can't be resolved is logged and dropped.
+func expandShutdownActions(ctx context.Context, clients []*govee.Client, database *sql.DB, actions []config.ShutdownAction) []config.ShutdownAction {
+	expanded := make([]config.ShutdownAction, 0, len(actions))
+	for _, action := range actions {
+		if action.Room == "" {
+			expanded = append(expanded, action)
+			continue
+		}
+		devices, err := handlers.RoomGoveeDevices(ctx, clients, database, action.Room, action.ProfileID)
+		if err != nil {
+			log.Printf("❌ Shutdown action %s room %s: %v", action.Command, action.Room, err)
+			continue
+		}
+		for _, device := range devices {
+			light := action
+			light.Room, light.ProfileID = "", ""
+			light.APIKeyIndex, light.DeviceID, light.Model = device.APIKeyIndex, device.DeviceID, device.Model
+			expanded = append(expanded, light)
+		}
+	}
+	return expanded
+}
+
+// shutdownResult is the outcome of one shutdown action.
+type shutdownResult int
+
+const (
+	shutdownSucceeded shutdownResult = iota
+	shutdownFailed
+	shutdownTimedOut // The device didn't answer within its timeoutMs
+)
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/config"
+	"github.com/pantheon/artemis/db"
+	"github.com/pantheon/artemis/govee"
+)
+
+// newShutdownStubClient returns a Govee client whose control endpoint
+// answers after delay and counts the commands it receives.
+func newShutdownStubClient(t *testing.T, delay time.Duration) (*govee.Client, *int32) {
+	t.Helper()
+	var commands int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&commands, 1)
+		time.Sleep(delay)
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(server.URL)
+	return client, &commands
+}
+
+func TestRunShutdownActions(t *testing.T) {
+	client, commands := newShutdownStubClient(t, 0)
+	actions := []config.ShutdownAction{
+		{DeviceID: "AA:01", Model: "H6008", Command: "turn", Value: false},
+		{DeviceID: "AA:02", Model: "H6008", Command: "brightness", Value: float64(10)},
+		// Invalid value and unknown account both fail without stopping the others
+		{DeviceID: "AA:03", Model: "H6008", Command: "turn", Value: "off"},
+		{APIKeyIndex: 1, DeviceID: "AA:04", Model: "H6008", Command: "turn", Value: false},
+	}
+
+	if got := runShutdownActions([]*govee.Client{client}, nil, actions, time.Second); got != 2 {
+		t.Errorf("expected 2 successful actions, got %d", got)
+	}
+	if *commands != 2 {
+		t.Errorf("expected 2 commands sent to Govee, got %d", *commands)
+	}
+}
+
+func TestRunShutdownActions_Timeout(t *testing.T) {
+	client, _ := newShutdownStubClient(t, 500*time.Millisecond)
+	actions := []config.ShutdownAction{{DeviceID: "AA:01", Model: "H6008", Command: "turn", Value: false}}
+
+	start := time.Now()
+	if got := runShutdownActions([]*govee.Client{client}, nil, actions, 50*time.Millisecond); got != 0 {
+		t.Errorf("expected no completed actions, got %d", got)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("expected shutdown actions to stop waiting at the timeout, took %s", elapsed)
+	}
+}
+
+func TestRunShutdownActions_PerActionTimeout(t *testing.T) {
+	slow, _ := newShutdownStubClient(t, 500*time.Millisecond)
+	fast, _ := newShutdownStubClient(t, 0)
+	actions := []config.ShutdownAction{
+		{DeviceID: "AA:01", Model: "H6008", Command: "turn", Value: false, TimeoutMs: 50},
+		{APIKeyIndex: 1, DeviceID: "AA:02", Model: "H6008", Command: "turn", Value: false},
+	}
+
+	// The slow device gives up after 50ms, well before the overall timeout
+	start := time.Now()
+	if got := runShutdownActions([]*govee.Client{slow, fast}, nil, actions, 2*time.Second); got != 1 {
+		t.Errorf("expected 1 successful action, got %d", got)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("expected the slow action to give up at its own timeout, took %s", elapsed)
+	}
+}
+
+func TestRunShutdownActions_Room(t *testing.T) {
+	var sent []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.Write([]byte(`{"code": 200, "message": "Success", "data": {"devices": [
+				{"device": "AA:01", "model": "H6008"}, {"device": "AA:02", "model": "H5080"}]}}`))
+			return
+		}
+		var req govee.ControlRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		sent = append(sent, req.Device+" "+req.Model)
+		mu.Unlock()
+		w.Write([]byte(`{"code": 200, "message": "Success"}`))
+	}))
+	t.Cleanup(server.Close)
+	client := govee.NewClient("test-key")
+	client.SetBaseURL(server.URL)
+
+	database, err := db.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init test DB: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	profile, _ := db.CreateProfile(database, "Shakur")
+	room, _ := db.CreateRoom(database, profile.ID, "Living Room", "sofa")
+	for _, externalID := range []string{"AA:01", "AA:02", "FF:FF"} {
+		device, _ := db.CreateDevice(database, profile.ID, externalID, "govee_light", &externalID, nil)
+		db.AssignDeviceToRoom(database, device.ID, room.ID)
+	}
+
+	// The light no account lists is skipped; an unknown room is dropped
+	actions := []config.ShutdownAction{
+		{Room: "living room", Command: "turn", Value: false},
+		{Room: "Garage", Command: "turn", Value: false},
+	}
+	if got := runShutdownActions([]*govee.Client{client}, database, actions, time.Second); got != 2 {
+		t.Errorf("expected 2 successful actions, got %d", got)
+	}
+	slices.Sort(sent)
+	if strings.Join(sent, ", ") != "AA:01 H6008, AA:02 H5080" {
+		t.Errorf("expected one command per light with its listed model, got %v", sent)
+	}
+}
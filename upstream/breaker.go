@@ -0,0 +1,204 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of sending a request while a Breaker is
+// open. Wrapped in an *Error of KindCircuitOpen.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// States of a Breaker.
+const (
+	BreakerClosed   = "closed"    // Requests go through
+	BreakerOpen     = "open"      // Requests fail fast until the cooldown ends
+	BreakerHalfOpen = "half_open" // One trial request decides whether to close
+)
+
+// Breaker is a circuit breaker for one upstream client. After threshold
+// consecutive failures it opens, and requests fail fast with ErrCircuitOpen
+// for cooldown instead of each waiting for a connection timeout. Then it
+// half-opens: the next request is sent as a trial, and closes the breaker if
+// it succeeds or opens it for another cooldown if it fails.
+//
+// A failure is a request that got no response (other than one cancelled by
+// the caller) or a 5xx response. A nil *Breaker lets everything through.
+type Breaker struct {
+	name      string // Shown in logs and the health endpoint, e.g. "Govee API #0"
+	threshold int
+	cooldown  time.Duration
+	failFast  bool             // Whether an open breaker blocks requests (see SetFailFast)
+	now       func() time.Time // Replaced in tests
+
+	mu       sync.Mutex
+	state    string
+	failures int       // Consecutive failures
+	openedAt time.Time // When the breaker last opened
+	trial    bool      // A half-open trial request is in flight
+}
+
+// BreakerStatus is a snapshot of a Breaker, as shown on /api/health.
+type BreakerStatus struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"` // "closed", "open", or "half_open"
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	RetryAt             *time.Time `json:"retryAt,omitempty"` // When an open breaker half-opens
+}
+
+// NewBreaker creates a closed breaker. threshold <= 0 disables it: the
+// result is nil, which lets every request through.
+func NewBreaker(name string, threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &Breaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		failFast:  true,
+		now:       time.Now,
+		state:     BreakerClosed,
+	}
+}
+
+// SetFailFast chooses what an open breaker does. Enabled (the default), it
+// fails requests fast with ErrCircuitOpen. Disabled, every request is still
+// sent and the breaker only tracks the upstream's health for Open and the
+// health endpoint.
+func (b *Breaker) SetFailFast(enabled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failFast = enabled
+}
+
+// Do sends req with httpClient unless b is open, records the outcome, and
+// classifies failures (see Classify). service names the upstream in errors.
+func (b *Breaker) Do(httpClient *http.Client, req *http.Request, service string) (*http.Response, error) {
+	if err := b.allow(); err != nil {
+		return nil, &Error{Service: service, Kind: KindCircuitOpen, Err: err}
+	}
+
+	resp, err := httpClient.Do(req)
+	switch {
+	case err != nil && errors.Is(err, context.Canceled):
+		b.release()
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		b.recordFailure()
+	default:
+		b.recordSuccess()
+	}
+	return resp, Classify(service, err)
+}
+
+// Status returns a snapshot of the breaker, which must not be nil.
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{Name: b.name, State: b.currentState(), ConsecutiveFailures: b.failures}
+	if status.State == BreakerOpen {
+		retryAt := b.openedAt.Add(b.cooldown)
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+// Open reports whether the upstream is known to be down: the breaker is
+// open and its cooldown hasn't ended. A nil breaker is never open.
+func (b *Breaker) Open() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState() == BreakerOpen
+}
+
+// currentState is the state, accounting for an elapsed cooldown.
+// b.mu must be held.
+func (b *Breaker) currentState() string {
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether a request may be sent, returning ErrCircuitOpen if
+// not. In the half-open state only one trial request is let through.
+// Without fail-fast every request is let through.
+func (b *Breaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.failFast {
+		// Still half-open after the cooldown, so a failure reopens it
+		if b.currentState() == BreakerHalfOpen {
+			b.state = BreakerHalfOpen
+		}
+		return nil
+	}
+
+	switch b.currentState() {
+	case BreakerOpen:
+		return ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.trial {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.trial = true
+	}
+	return nil
+}
+
+func (b *Breaker) recordSuccess() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != BreakerClosed {
+		log.Printf("🔌 %s recovered — circuit closed", b.name)
+	}
+	b.state = BreakerClosed
+	b.failures = 0
+	b.trial = false
+}
+
+func (b *Breaker) recordFailure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		if b.state == BreakerClosed {
+			log.Printf("🔌 %s failed %d times in a row — circuit open, failing fast for %s", b.name, b.failures, b.cooldown)
+		}
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+	b.trial = false
+}
+
+// release ends a half-open trial that the caller cancelled, without counting
+// it either way, so the next request can try instead.
+func (b *Breaker) release() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
@@ -0,0 +1,213 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestBreaker returns a breaker on a fake clock, and a function that
+// advances the clock.
+func newTestBreaker(threshold int, cooldown time.Duration) (*Breaker, func(time.Duration)) {
+	breaker := NewBreaker("Test service", threshold, cooldown)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return now }
+	return breaker, func(d time.Duration) { now = now.Add(d) }
+}
+
+// newFlakyServer starts a server that answers 500 while *failing is true
+// and 200 otherwise, counting the requests it gets.
+func newFlakyServer(t *testing.T, failing *atomic.Bool, hits *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func send(t *testing.T, breaker *Breaker, url string) error {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	resp, err := breaker.Do(http.DefaultClient, req, "Test service")
+	if err == nil {
+		resp.Body.Close()
+	}
+	return err
+}
+
+func TestBreaker_OpensAfterThresholdAndRecovers(t *testing.T) {
+	var failing atomic.Bool
+	var hits atomic.Int32
+	failing.Store(true)
+	server := newFlakyServer(t, &failing, &hits)
+	breaker, advance := newTestBreaker(3, 30*time.Second)
+
+	for range 3 {
+		if err := send(t, breaker, server.URL); err != nil {
+			t.Fatalf("expected 5xx responses to be returned, got %v", err)
+		}
+	}
+	if state := breaker.Status().State; state != BreakerOpen {
+		t.Fatalf("expected the breaker to open after 3 failures, got %s", state)
+	}
+
+	// Open: fail fast without reaching the server
+	err := send(t, breaker, server.URL)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if classified, ok := As(err); !ok || classified.HTTPStatus() != http.StatusServiceUnavailable {
+		t.Errorf("expected a 503 classified error, got %v", err)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("expected no request while open, server got %d", hits.Load())
+	}
+	if status := breaker.Status(); status.RetryAt == nil {
+		t.Error("expected retryAt while open")
+	}
+
+	// Half-open after the cooldown: a failing trial reopens it
+	advance(30 * time.Second)
+	if state := breaker.Status().State; state != BreakerHalfOpen {
+		t.Fatalf("expected half_open after the cooldown, got %s", state)
+	}
+	send(t, breaker, server.URL)
+	if state := breaker.Status().State; state != BreakerOpen {
+		t.Fatalf("expected a failed trial to reopen the breaker, got %s", state)
+	}
+
+	// A successful trial closes it
+	advance(30 * time.Second)
+	failing.Store(false)
+	if err := send(t, breaker, server.URL); err != nil {
+		t.Fatalf("expected the trial to go through, got %v", err)
+	}
+	if status := breaker.Status(); status.State != BreakerClosed || status.ConsecutiveFailures != 0 {
+		t.Errorf("expected closed with no failures, got %+v", status)
+	}
+}
+
+func TestBreaker_SuccessResetsCount(t *testing.T) {
+	var failing atomic.Bool
+	var hits atomic.Int32
+	server := newFlakyServer(t, &failing, &hits)
+	breaker, _ := newTestBreaker(2, time.Minute)
+
+	failing.Store(true)
+	send(t, breaker, server.URL)
+	failing.Store(false)
+	send(t, breaker, server.URL)
+	failing.Store(true)
+	send(t, breaker, server.URL)
+
+	if state := breaker.Status().State; state != BreakerClosed {
+		t.Errorf("expected failures separated by a success to keep the breaker closed, got %s", state)
+	}
+}
+
+func TestBreaker_HalfOpenAllowsOneTrial(t *testing.T) {
+	breaker, advance := newTestBreaker(1, time.Second)
+	breaker.recordFailure()
+	advance(time.Second)
+
+	if err := breaker.allow(); err != nil {
+		t.Fatalf("expected the first request after the cooldown to be let through, got %v", err)
+	}
+	if err := breaker.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected a second concurrent request to fail fast, got %v", err)
+	}
+
+	// A cancelled trial frees the slot without counting
+	breaker.release()
+	if err := breaker.allow(); err != nil {
+		t.Errorf("expected a new trial after a cancelled one, got %v", err)
+	}
+}
+
+func TestBreaker_IgnoresCancelledRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	breaker, _ := newTestBreaker(1, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	breaker.Do(http.DefaultClient, req, "Test service")
+
+	if status := breaker.Status(); status.State != BreakerClosed || status.ConsecutiveFailures != 0 {
+		t.Errorf("expected a cancelled request not to count, got %+v", status)
+	}
+}
+
+func TestBreaker_NilLetsEverythingThrough(t *testing.T) {
+	if breaker := NewBreaker("Test service", 0, time.Minute); breaker != nil {
+		t.Fatal("expected threshold 0 to disable the breaker")
+	}
+
+	var breaker *Breaker
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	for range 10 {
+		if err := send(t, breaker, server.URL); err != nil {
+			t.Fatalf("expected a nil breaker to send every request, got %v", err)
+		}
+	}
+}
+
+func TestBreaker_WithoutFailFastKeepsSending(t *testing.T) {
+	var failing atomic.Bool
+	var hits atomic.Int32
+	failing.Store(true)
+	server := newFlakyServer(t, &failing, &hits)
+	breaker, advance := newTestBreaker(2, time.Minute)
+	breaker.SetFailFast(false)
+
+	for range 4 {
+		if err := send(t, breaker, server.URL); err != nil {
+			t.Fatalf("expected every request to be sent, got %v", err)
+		}
+	}
+	if hits.Load() != 4 || !breaker.Open() {
+		t.Fatalf("expected 4 requests and an open breaker, got %d hits, %+v", hits.Load(), breaker.Status())
+	}
+
+	// A failure after the cooldown reopens it; a success closes it
+	advance(time.Minute)
+	send(t, breaker, server.URL)
+	if !breaker.Open() {
+		t.Errorf("expected a failure after the cooldown to reopen the breaker, got %+v", breaker.Status())
+	}
+	failing.Store(false)
+	send(t, breaker, server.URL)
+	if state := breaker.Status().State; state != BreakerClosed {
+		t.Errorf("expected a success to close the breaker, got %s", state)
+	}
+}
+
+func TestBreaker_Open(t *testing.T) {
+	var nilBreaker *Breaker
+	if nilBreaker.Open() {
+		t.Error("expected a nil breaker never to be open")
+	}
+
+	breaker, advance := newTestBreaker(1, time.Second)
+	breaker.recordFailure()
+	if !breaker.Open() {
+		t.Error("expected the breaker to be open after reaching the threshold")
+	}
+	advance(time.Second)
+	if breaker.Open() {
+		t.Error("expected a half-open breaker not to count as open")
+	}
+}
@@ -0,0 +1,129 @@
+// Package upstream classifies failed requests to the services Artemis
+// proxies (Govee, the Fire TV service, the Wyze Bridge), so handlers can
+// tell "the service isn't running" from "it timed out, try again".
+package upstream
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// Kind is the category of a request that failed before a response arrived.
+type Kind string
+
+const (
+	KindTimeout           Kind = "timeout"            // Deadline or client timeout
+	KindDNS               Kind = "dns"                // Host name didn't resolve
+	KindConnectionRefused Kind = "connection_refused" // Nothing listening on the port
+	KindTLS               Kind = "tls"                // Handshake or certificate failure
+	KindCircuitOpen       Kind = "circuit_open"       // Not sent: recent requests failed (see Breaker)
+)
+
+// Error is a classified request failure. Error() is the underlying error's
+// message, so wrapping an error with Classify doesn't change what's logged.
+type Error struct {
+	Service string // e.g., "Govee API", "Fire TV service", "Wyze Bridge"
+	Kind    Kind
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Message is a user-facing description of the failure.
+func (e *Error) Message() string {
+	switch e.Kind {
+	case KindTimeout:
+		return fmt.Sprintf("%s timed out — try again", e.Service)
+	case KindDNS:
+		return fmt.Sprintf("Couldn't resolve the %s host — check its URL", e.Service)
+	case KindConnectionRefused:
+		return fmt.Sprintf("%s is not running (connection refused)", e.Service)
+	case KindCircuitOpen:
+		return fmt.Sprintf("%s is unavailable after repeated failures — try again shortly", e.Service)
+	default:
+		return fmt.Sprintf("Secure connection to %s failed — check its certificate", e.Service)
+	}
+}
+
+// HTTPStatus is the status a handler should answer with: 504 for timeouts,
+// 503 when the service isn't running or its breaker is open, and 502
+// otherwise.
+func (e *Error) HTTPStatus() int {
+	switch e.Kind {
+	case KindTimeout:
+		return http.StatusGatewayTimeout
+	case KindConnectionRefused, KindCircuitOpen:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// Classify wraps err, as returned by http.Client.Do, in an *Error when it is
+// a timeout, DNS, connection-refused, or TLS failure. Other errors
+// (including a cancelled request) are returned unchanged.
+func Classify(service string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *Error
+	if errors.As(err, &classified) {
+		return err
+	}
+	if kind, ok := kindOf(err); ok {
+		return &Error{Service: service, Kind: kind, Err: err}
+	}
+	return err
+}
+
+// As returns the *Error in err's chain, if any.
+func As(err error) (*Error, bool) {
+	var classified *Error
+	ok := errors.As(err, &classified)
+	return classified, ok
+}
+
+// kindOf reports which Kind err falls into. Timeouts are checked first,
+// since a DNS lookup that times out is a timeout for the caller too.
+func kindOf(err error) (Kind, bool) {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return KindTimeout, true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return KindDNS, true
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return KindConnectionRefused, true
+	}
+
+	var (
+		verifyErr    *tls.CertificateVerificationError
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	if errors.As(err, &verifyErr) || errors.As(err, &recordErr) || errors.As(err, &alertErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return KindTLS, true
+	}
+
+	return "", false
+}
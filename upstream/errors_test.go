@@ -0,0 +1,128 @@
+package upstream
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// timeoutError is a net.Error that reports a timeout, like the one
+// http.Client returns when its Timeout elapses.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// asDoError wraps err the way http.Client.Do does.
+func asDoError(err error) error {
+	return &url.Error{Op: "Get", URL: "http://upstream.test", Err: err}
+}
+
+func TestClassify_SimulatedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		kind   Kind
+		status int
+	}{
+		{"deadline", context.DeadlineExceeded, KindTimeout, http.StatusGatewayTimeout},
+		{"client timeout", timeoutError{}, KindTimeout, http.StatusGatewayTimeout},
+		{"dns timeout", &net.DNSError{Err: "timeout", Name: "upstream.test", IsTimeout: true}, KindTimeout, http.StatusGatewayTimeout},
+		{"dns", &net.DNSError{Err: "no such host", Name: "upstream.test", IsNotFound: true}, KindDNS, http.StatusBadGateway},
+		{"refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, KindConnectionRefused, http.StatusServiceUnavailable},
+		{"unknown authority", &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}, KindTLS, http.StatusBadGateway},
+		{"not tls", tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}, KindTLS, http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("failed to fetch devices: %w", Classify("Govee API", asDoError(tt.err)))
+
+			classified, ok := As(err)
+			if !ok {
+				t.Fatalf("expected %v to be classified", err)
+			}
+			if classified.Kind != tt.kind {
+				t.Errorf("expected kind %q, got %q", tt.kind, classified.Kind)
+			}
+			if classified.HTTPStatus() != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, classified.HTTPStatus())
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected the original error to stay in the chain")
+			}
+		})
+	}
+}
+
+func TestClassify_LeavesOtherErrorsAlone(t *testing.T) {
+	for _, err := range []error{nil, context.Canceled, errors.New("HTTP error 500: boom")} {
+		if got := Classify("Govee API", err); got != err {
+			t.Errorf("expected %v unchanged, got %v", err, got)
+		}
+	}
+}
+
+func TestClassify_KeepsMessage(t *testing.T) {
+	err := asDoError(context.DeadlineExceeded)
+	if got := Classify("Wyze Bridge", err).Error(); got != err.Error() {
+		t.Errorf("expected message %q, got %q", err.Error(), got)
+	}
+}
+
+func TestClassify_RealConnections(t *testing.T) {
+	// A listener that's closed again leaves a port nothing listens on.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	refusedURL := "http://" + listener.Addr().String()
+	listener.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+
+	// Only the timeout case gets a tight deadline; the others must fail for
+	// their own reason even on a slow (-race) run.
+	client := &http.Client{Timeout: 10 * time.Second}
+	tests := []struct {
+		name   string
+		url    string
+		client *http.Client
+		kind   Kind
+	}{
+		{"refused", refusedURL, client, KindConnectionRefused},
+		{"timeout", slow.URL, &http.Client{Timeout: 50 * time.Millisecond}, KindTimeout},
+		{"untrusted certificate", tlsServer.URL, client, KindTLS},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.client.Get(tt.url)
+			if err == nil {
+				resp.Body.Close()
+				t.Fatal("expected the request to fail")
+			}
+			classified, ok := As(Classify("Fire TV service", err))
+			if !ok || classified.Kind != tt.kind {
+				t.Errorf("expected kind %q, got %v (%v)", tt.kind, classified, err)
+			}
+		})
+	}
+}
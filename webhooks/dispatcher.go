@@ -0,0 +1,190 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pantheon/artemis/events"
+	"github.com/pantheon/artemis/tracing"
+)
+
+// Delivery defaults. A failed delivery is retried maxAttempts-1 times,
+// waiting firstBackoff, then twice as long each time (1s, 2s, 4s, 8s).
+const (
+	defaultMaxAttempts  = 5
+	defaultFirstBackoff = time.Second
+	deliveryTimeout     = 10 * time.Second
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Artemis-Event"     // Event type, e.g. "camera.offline"
+	HeaderDelivery  = "X-Artemis-Delivery"  // Event ID, the same across retries
+	HeaderSignature = "X-Artemis-Signature" // "sha256=<hex HMAC of the body>", when the webhook has a secret
+)
+
+// Dispatcher POSTs events from a broker to every webhook that wants them.
+//
+// The body is the event as the SSE stream sends it:
+//
+//	{"id": 42, "type": "camera.offline", "data": {...}, "time": "..."}
+//
+// Deliveries run concurrently, so one slow endpoint doesn't hold up the
+// others. Anything but a 2xx response is retried with exponential backoff
+// and dropped with a log line after the last attempt.
+type Dispatcher struct {
+	store      *Store
+	httpClient *http.Client
+
+	maxAttempts  int
+	firstBackoff time.Duration
+}
+
+// NewDispatcher creates a dispatcher for the store's webhooks.
+func NewDispatcher(store *Store) *Dispatcher {
+	return &Dispatcher{
+		store: store,
+		httpClient: &http.Client{
+			Timeout:   deliveryTimeout,
+			Transport: tracing.NewTransport(), // client span per delivery
+		},
+		maxAttempts:  defaultMaxAttempts,
+		firstBackoff: defaultFirstBackoff,
+	}
+}
+
+// Start subscribes to broker and delivers its events in a background
+// goroutine until ctx is cancelled. Pending retries are abandoned then.
+//
+// If the broker drops the subscription for falling behind, Start resubscribes
+// from the last event it dispatched, so buffered events are still delivered.
+func (d *Dispatcher) Start(ctx context.Context, broker *events.Broker) {
+	// The first subscription is made before returning so no event published
+	// right after Start is missed.
+	_, _, live, cancel := broker.Subscribe(0)
+	go func() {
+		var lastID uint64
+		for {
+			resubscribe := d.consume(ctx, live, &lastID)
+			cancel()
+			if !resubscribe {
+				return
+			}
+
+			var replay []events.Event
+			var missed bool
+			replay, missed, live, cancel = broker.Subscribe(lastID)
+			if missed {
+				log.Printf("⚠️  Webhooks: fell behind, some events were not delivered")
+			}
+			for _, event := range replay {
+				d.Dispatch(ctx, event)
+				lastID = event.ID
+			}
+		}
+	}()
+}
+
+// consume dispatches live events until ctx is cancelled (false) or the
+// broker closes the channel (true), recording the last event's ID.
+func (d *Dispatcher) consume(ctx context.Context, live <-chan events.Event, lastID *uint64) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case event, ok := <-live:
+			if !ok {
+				return true
+			}
+			d.Dispatch(ctx, event)
+			*lastID = event.ID
+		}
+	}
+}
+
+// Dispatch starts delivering event to every webhook that wants it and
+// returns without waiting for the deliveries.
+func (d *Dispatcher) Dispatch(ctx context.Context, event events.Event) {
+	var body []byte
+	for _, hook := range d.store.List() {
+		if !hook.Wants(event.Type) {
+			continue
+		}
+		if body == nil {
+			var err error
+			if body, err = json.Marshal(event); err != nil {
+				log.Printf("❌ Webhooks: failed to encode %s event: %v", event.Type, err)
+				return
+			}
+		}
+		go d.deliver(ctx, hook, event, body)
+	}
+}
+
+// deliver POSTs body to the webhook, retrying with backoff.
+func (d *Dispatcher) deliver(ctx context.Context, hook Webhook, event events.Event, body []byte) {
+	backoff := d.firstBackoff
+	for attempt := 1; ; attempt++ {
+		err := d.post(ctx, hook, event, body)
+		if err == nil {
+			return
+		}
+		if attempt >= d.maxAttempts {
+			log.Printf("❌ Webhooks: dropped %s event %d for %s after %d attempt(s): %v", event.Type, event.ID, hook.URL, attempt, err)
+			return
+		}
+
+		log.Printf("⚠️  Webhooks: %s event %d to %s failed (%v), retrying in %s", event.Type, event.ID, hook.URL, err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post makes one delivery attempt.
+func (d *Dispatcher) post(ctx context.Context, hook Webhook, event events.Event, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, event.Type)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(event.ID, 10))
+	if hook.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(hook.Secret, body))
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096)) // Let the connection be reused
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the X-Artemis-Signature value for body: "sha256=" followed by
+// the hex HMAC-SHA256 of the exact body bytes, keyed with secret. Receivers
+// should recompute it over the raw body and compare in constant time.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
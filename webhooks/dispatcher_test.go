@@ -0,0 +1,117 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pantheon/artemis/events"
+)
+
+// recordingEndpoint fails the first failures requests, then records each
+// delivery's headers and body on the returned channel.
+func recordingEndpoint(t *testing.T, failures int) (string, <-chan *http.Request, <-chan []byte) {
+	t.Helper()
+	var mu sync.Mutex
+	requests := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, requests, bodies
+}
+
+func TestDispatcher_DeliversSignedEvents(t *testing.T) {
+	url, requests, bodies := recordingEndpoint(t, 0)
+	store, _ := NewStore("")
+	store.Add(Webhook{URL: url, Events: []string{EventCameraOffline}, Secret: "s3cret"})
+
+	broker := events.NewBroker(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	NewDispatcher(store).Start(ctx, broker)
+
+	broker.Publish(EventDeviceState, map[string]string{"deviceId": "AA:BB"}) // Filtered out
+	event := broker.Publish(EventCameraOffline, map[string]string{"nameUri": "garage"})
+
+	select {
+	case req := <-requests:
+		body := <-bodies
+		if got := req.Header.Get(HeaderEvent); got != EventCameraOffline {
+			t.Errorf("expected %s header %q, got %q", HeaderEvent, EventCameraOffline, got)
+		}
+		if got := req.Header.Get(HeaderDelivery); got != "2" || event.ID != 2 {
+			t.Errorf("expected delivery ID 2, got %q", got)
+		}
+		if got, want := req.Header.Get(HeaderSignature), Sign("s3cret", body); got != want {
+			t.Errorf("expected signature %s, got %s", want, got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the camera.offline event to be delivered")
+	}
+
+	select {
+	case <-requests:
+		t.Error("expected the filtered device.state event not to be delivered")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	url, requests, _ := recordingEndpoint(t, 2)
+	store, _ := NewStore("")
+	store.Add(Webhook{URL: url})
+
+	d := NewDispatcher(store)
+	d.firstBackoff = time.Millisecond
+	d.Dispatch(context.Background(), events.Event{ID: 7, Type: EventDeviceCommandFailed})
+
+	select {
+	case req := <-requests:
+		if req.Header.Get(HeaderSignature) != "" {
+			t.Error("expected no signature for a webhook without a secret")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the third attempt to be delivered")
+	}
+}
+
+func TestDispatcher_DropsAfterMaxAttempts(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+	store, _ := NewStore("")
+	store.Add(Webhook{URL: server.URL})
+
+	d := NewDispatcher(store)
+	d.firstBackoff = time.Millisecond
+	d.maxAttempts = 3
+	d.Dispatch(context.Background(), events.Event{ID: 1, Type: EventCameraOffline})
+
+	time.Sleep(200 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts before dropping, got %d", attempts)
+	}
+}
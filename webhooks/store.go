@@ -0,0 +1,233 @@
+// Package webhooks delivers Artemis events (device commands failing, cameras
+// going offline, ...) to integrators' URLs as signed JSON POSTs.
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pantheon/artemis/persist"
+)
+
+// Event types a webhook can subscribe to. These are the event types
+// published on the device event stream (/api/events/devices).
+const (
+	EventDeviceState         = "device.state"          // A polled device state changed
+	EventDeviceCommandFailed = "device.command_failed" // A control command failed (not queued)
+	EventDeviceCommandRetry  = "device.command_retry"  // A queued command was finally delivered or dropped
+	EventDeviceTimer         = "device.timer"          // A timer kept by Artemis fired
+	EventDeviceOffline       = "device.offline"        // A device stayed unreachable for the grace period
+	EventDeviceOnline        = "device.online"         // A device reported offline is reachable again
+	EventCameraOffline       = "camera.offline"        // A camera went from online to offline
+	EventCameraStreamRestart = "camera.stream_restart" // The stream watchdog restarted a stalled stream
+)
+
+// KnownEvents lists every event type a webhook may filter on.
+var KnownEvents = []string{
+	EventDeviceState,
+	EventDeviceCommandFailed,
+	EventDeviceCommandRetry,
+	EventDeviceTimer,
+	EventDeviceOffline,
+	EventDeviceOnline,
+	EventCameraOffline,
+	EventCameraStreamRestart,
+}
+
+// ErrNotFound means no webhook has the given ID.
+var ErrNotFound = errors.New("webhook not found")
+
+// Webhook is one registered delivery target.
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"` // Event types to deliver; empty means all
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Wants reports whether the webhook subscribes to eventType.
+func (h Webhook) Wants(eventType string) bool {
+	return len(h.Events) == 0 || slices.Contains(h.Events, eventType)
+}
+
+// Store holds the registered webhooks and saves them to a JSON file on
+// every change, so they survive restarts. Safe for concurrent use.
+type Store struct {
+	path string // "" keeps webhooks in memory only
+
+	mu    sync.RWMutex
+	hooks map[string]Webhook
+}
+
+// NewStore loads the webhooks saved at path, if any. A missing file is an
+// empty store; an unreadable one is an error, so a typo'd path doesn't
+// silently drop every webhook on the next save.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, hooks: make(map[string]Webhook)}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var saved []Webhook
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for _, hook := range saved {
+		s.hooks[hook.ID] = hook
+	}
+	return s, nil
+}
+
+// Validate checks a webhook's URL and event filter.
+func Validate(hook Webhook) error {
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http(s) URL, got %q", hook.URL)
+	}
+	for _, event := range hook.Events {
+		if !slices.Contains(KnownEvents, event) {
+			return fmt.Errorf("unknown event type %q (known: %v)", event, KnownEvents)
+		}
+	}
+	return nil
+}
+
+// Add validates and registers a webhook, assigning its ID and creation time.
+func (s *Store) Add(hook Webhook) (Webhook, error) {
+	if err := Validate(hook); err != nil {
+		return Webhook{}, err
+	}
+
+	b := make([]byte, 8)
+	rand.Read(b)
+	hook.ID = hex.EncodeToString(b)
+	hook.CreatedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks[hook.ID] = hook
+	if err := s.save(); err != nil {
+		delete(s.hooks, hook.ID)
+		return Webhook{}, err
+	}
+	return hook, nil
+}
+
+// Delete removes a webhook. Returns ErrNotFound if there is none with id.
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hook, ok := s.hooks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.hooks, id)
+	if err := s.save(); err != nil {
+		s.hooks[id] = hook
+		return err
+	}
+	return nil
+}
+
+// List returns every webhook, oldest first.
+func (s *Store) List() []Webhook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hooks := make([]Webhook, 0, len(s.hooks))
+	for _, hook := range s.hooks {
+		hooks = append(hooks, hook)
+	}
+	sort.Slice(hooks, func(i, j int) bool {
+		if !hooks[i].CreatedAt.Equal(hooks[j].CreatedAt) {
+			return hooks[i].CreatedAt.Before(hooks[j].CreatedAt)
+		}
+		return hooks[i].ID < hooks[j].ID
+	})
+	return hooks
+}
+
+// ValidateAll checks a backup's webhooks: each has an ID, no two share one,
+// and each passes Validate.
+func ValidateAll(hooks []Webhook) error {
+	seen := make(map[string]bool, len(hooks))
+	for i, hook := range hooks {
+		if hook.ID == "" {
+			return fmt.Errorf("webhooks[%d]: id is required", i)
+		}
+		if seen[hook.ID] {
+			return fmt.Errorf("webhooks[%d]: duplicate id %s", i, hook.ID)
+		}
+		seen[hook.ID] = true
+		if err := Validate(hook); err != nil {
+			return fmt.Errorf("webhooks[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Restore writes a backup's webhooks, keeping their IDs, secrets, and
+// creation times. With replace, they become the only webhooks; otherwise
+// each one is added, or replaces the webhook with the same ID.
+func (s *Store) Restore(hooks []Webhook, replace bool) error {
+	if err := ValidateAll(hooks); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.hooks
+	s.hooks = make(map[string]Webhook, len(previous)+len(hooks))
+	if !replace {
+		maps.Copy(s.hooks, previous)
+	}
+	for _, hook := range hooks {
+		s.hooks[hook.ID] = hook
+	}
+	if err := s.save(); err != nil {
+		s.hooks = previous
+		return err
+	}
+	return nil
+}
+
+// save writes every webhook to the store's file via a temp file and rename,
+// so a crash mid-write never leaves a truncated file. Caller holds s.mu.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	hooks := make([]Webhook, 0, len(s.hooks))
+	for _, hook := range s.hooks {
+		hooks = append(hooks, hook)
+	}
+	// Secrets are in the file, so keep it private
+	if err := persist.WriteJSON(s.path, hooks, 0o600); err != nil {
+		return fmt.Errorf("failed to save webhooks: %w", err)
+	}
+	log.Printf("🪝 Saved %d webhook(s) to %s", len(hooks), s.path)
+	return nil
+}
@@ -0,0 +1,66 @@
+package webhooks
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore returned error: %v", err)
+	}
+	kept, _ := store.Add(Webhook{URL: "https://example.com/a", Events: []string{EventCameraOffline}, Secret: "s3cret"})
+	removed, _ := store.Add(Webhook{URL: "https://example.com/b"})
+	if err := store.Delete(removed.ID); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+
+	reloaded, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore returned error on reload: %v", err)
+	}
+	hooks := reloaded.List()
+	if len(hooks) != 1 || hooks[0].ID != kept.ID || hooks[0].Secret != "s3cret" {
+		t.Errorf("expected only the kept webhook with its secret, got %+v", hooks)
+	}
+	if err := reloaded.Delete(removed.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a deleted webhook, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		hook    Webhook
+		wantErr bool
+	}{
+		{"https", Webhook{URL: "https://example.com/hook"}, false},
+		{"known events", Webhook{URL: "http://10.0.0.5:9000", Events: []string{EventDeviceCommandFailed}}, false},
+		{"relative URL", Webhook{URL: "/hook"}, true},
+		{"other scheme", Webhook{URL: "ftp://example.com"}, true},
+		{"unknown event", Webhook{URL: "https://example.com", Events: []string{"scene.fired"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.hook); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhook_Wants(t *testing.T) {
+	all := Webhook{}
+	filtered := Webhook{Events: []string{EventCameraOffline}}
+
+	if !all.Wants(EventDeviceState) {
+		t.Error("expected a webhook without a filter to want every event")
+	}
+	if filtered.Wants(EventDeviceState) || !filtered.Wants(EventCameraOffline) {
+		t.Error("expected a filtered webhook to want only its events")
+	}
+}